@@ -17,6 +17,11 @@ func main() {
 		host    = pflag.StringP("host", "H", "http://127.0.0.1:8080", "Ephemeris daemon URL (e.g. http://192.168.8.1:8080)")
 		jsonOut = pflag.Bool("json", false, "Output raw JSON instead of formatted text")
 		filter  = pflag.StringSlice("filter", nil, "Event types to show in watch (e.g. --filter state,log)")
+		outFile = pflag.StringP("output-file", "o", "", "Write output to a file instead of stdout (replaced only on success)")
+		quiet   = pflag.BoolP("quiet", "q", false, "Suppress formatted output; with --json, print only the JSON result")
+		color   = pflag.String("color", ctl.ColorAuto, "Colorize output: auto, always, or never")
+		lang    = pflag.String("lang", "", "Output language (default: from $EPHCTL_LANG or the locale)")
+		token   = pflag.String("token", "", "API token for control commands (default: $EPHCTL_TOKEN)")
 	)
 
 	// Stop parsing global flags at the first non-flag argument (the command
@@ -29,9 +34,45 @@ func main() {
 		os.Exit(2)
 	}
 
+	if err := ctl.SetColorMode(*color); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(2)
+	}
+
+	// A broken translation file should not stop the command; fall back to
+	// English and say why.
+	if err := ctl.SetLanguage(*lang); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: translations:", err)
+	}
+
+	// The token is read from the environment here rather than used as the
+	// flag default, so --help does not print it.
+	if *token == "" {
+		*token = os.Getenv("EPHCTL_TOKEN")
+	}
+	ctl.SetToken(*token)
+
 	cmd := pflag.Arg(0)
 	subArgs := pflag.Args()[1:]
 
+	// Output to a file never reaches the terminal, so --quiet has nothing
+	// more to do there. Otherwise quiet discards formatted output, while
+	// with --json the structured result is still printed.
+	var out *ctl.OutputFile
+	switch {
+	case *outFile != "":
+		var err error
+		if out, err = ctl.OpenOutputFile(*outFile); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+	case *quiet && !*jsonOut:
+		if err := ctl.Quiet(); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+	}
+
 	var err error
 	switch cmd {
 	// ── Query commands ────────────────────────────────────────────
@@ -41,14 +82,72 @@ func main() {
 	case "health":
 		err = ctl.Health(*host, *jsonOut)
 
+	case "ready":
+		err = ctl.Ready(*host, *jsonOut)
+
+	case "health-history":
+		opts := ctl.HealthHistoryOptions{JSON: *jsonOut}
+		hhFlags := pflag.NewFlagSet("health-history", pflag.ContinueOnError)
+		hhFlags.IntVar(&opts.Limit, "limit", 0, "Limit number of samples shown")
+		_ = hhFlags.Parse(subArgs)
+		err = ctl.HealthHistory(*host, opts)
+
+	case "batch":
+		if len(subArgs) == 0 {
+			fmt.Fprintln(os.Stderr, "usage: ephctl batch PATH [PATH...]")
+			os.Exit(2)
+		}
+		err = ctl.Batch(*host, subArgs)
+
+	case "wait-for-change":
+		opts := ctl.WaitOptions{JSON: *jsonOut}
+		waitFlags := pflag.NewFlagSet("wait-for-change", pflag.ContinueOnError)
+		waitFlags.StringVar(&opts.State, "state", "", "Wait until the state differs from this (default: current)")
+		waitFlags.StringVar(&opts.Pass, "pass", "", "Wait until the tracked pass differs from this pass_token")
+		waitFlags.DurationVar(&opts.Timeout, "timeout", 0, "Give up after this long (default 30s, max 5m)")
+		_ = waitFlags.Parse(subArgs)
+		err = ctl.WaitForChange(*host, opts)
+
 	case "version":
 		err = ctl.VersionInfo(*host, *jsonOut)
 
 	case "satellites":
-		err = ctl.Satellites(*host, *jsonOut)
+		opts := ctl.SatellitesOptions{JSON: *jsonOut}
+		if len(subArgs) > 0 {
+			opts.Action = subArgs[0]
+		}
+		if len(subArgs) > 1 {
+			opts.Name = subArgs[1]
+		}
+		if len(subArgs) > 2 {
+			opts.Value = subArgs[2]
+		}
+		if opts.Action == "add" {
+			addFlags := pflag.NewFlagSet("satellites add", pflag.ContinueOnError)
+			addFlags.IntVar(&opts.NoradID, "norad", 0, "NORAD catalog number")
+			addFlags.IntVar(&opts.FreqHz, "freq", 0, "Downlink frequency in Hz")
+			addFlags.StringVar(&opts.Mode, "mode", "apt", "Downlink mode: apt or lrpt")
+			_ = addFlags.Parse(subArgs[1:])
+			opts.Name = addFlags.Arg(0)
+		}
+		err = ctl.Satellites(*host, opts)
+
+	case "sat":
+		opts := ctl.SatOptions{JSON: *jsonOut}
+		satFlags := pflag.NewFlagSet("sat", pflag.ContinueOnError)
+		satFlags.IntVar(&opts.Count, "count", 0, "Number of passes and captures shown (default 5)")
+		_ = satFlags.Parse(subArgs)
+		opts.Name = satFlags.Arg(0)
+		err = ctl.Sat(*host, opts)
 
 	case "config":
-		err = ctl.Config(*host, *jsonOut)
+		opts := ctl.ConfigOptions{JSON: *jsonOut}
+		cfgFlags := pflag.NewFlagSet("config", pflag.ContinueOnError)
+		cfgFlags.BoolVar(&opts.Resolved, "resolved", false, "Re-resolve the config file and its includes from disk")
+		cfgFlags.BoolVar(&opts.ShowSecrets, "show-secrets", false, "Show secret values (local daemon only)")
+		cfgFlags.StringVar(&opts.Token, "token", "", "Operator token configured in [debug] token")
+		_ = cfgFlags.Parse(subArgs)
+		err = ctl.Config(*host, opts)
 
 	case "config-list":
 		err = ctl.ConfigList(*host, *jsonOut)
@@ -58,6 +157,10 @@ func main() {
 		passFlags := pflag.NewFlagSet("passes", pflag.ContinueOnError)
 		passFlags.IntVar(&opts.Count, "count", 0, "Limit number of passes shown")
 		passFlags.StringVar(&opts.Satellite, "satellite", "", "Filter by satellite name")
+		passFlags.Float64Var(&opts.MinElev, "min-elev", 0, "Only passes peaking at or above this elevation (degrees)")
+		passFlags.StringVar(&opts.Direction, "direction", "", "Only northbound (N) or southbound (S) passes")
+		passFlags.StringVar(&opts.TZ, "tz", "", `Show times in this zone (IANA name, or "station")`)
+		passFlags.BoolVar(&opts.Score, "score", false, "Show how the scheduler scores each pass")
 		_ = passFlags.Parse(subArgs)
 		err = ctl.Passes(*host, opts)
 
@@ -65,16 +168,74 @@ func main() {
 		opts := ctl.NextPassOptions{JSON: *jsonOut}
 		npFlags := pflag.NewFlagSet("next-pass", pflag.ContinueOnError)
 		npFlags.StringVar(&opts.Satellite, "satellite", "", "Filter by satellite name")
+		npFlags.StringVar(&opts.TZ, "tz", "", `Show times in this zone (IANA name, or "station")`)
+		npFlags.BoolVar(&opts.Plot, "plot", false, "Draw the pass on a polar sky chart")
 		_ = npFlags.Parse(subArgs)
 		err = ctl.NextPass(*host, opts)
 
+	case "pass-track":
+		opts := ctl.PassTrackOptions{JSON: *jsonOut}
+		ptFlags := pflag.NewFlagSet("pass-track", pflag.ContinueOnError)
+		ptFlags.IntVar(&opts.Step, "step", 0, "Seconds between points (default 10)")
+		_ = ptFlags.Parse(subArgs)
+		opts.ID = ptFlags.Arg(0)
+		err = ctl.PassTrack(*host, opts)
+
+	case "summary":
+		opts := ctl.SummaryOptions{JSON: *jsonOut}
+		sumFlags := pflag.NewFlagSet("summary", pflag.ContinueOnError)
+		sumFlags.StringVar(&opts.TZ, "tz", "", `Show times in this zone (IANA name, or "station")`)
+		sumFlags.StringVar(&opts.PNG, "png", "", "Save the e-paper rendering to this file instead")
+		sumFlags.IntVar(&opts.Width, "width", 0, "Width of the rendering in pixels (default 296)")
+		sumFlags.IntVar(&opts.Height, "height", 0, "Height of the rendering in pixels (default 128)")
+		_ = sumFlags.Parse(subArgs)
+		err = ctl.Summary(*host, opts)
+
 	case "captures":
 		opts := ctl.CapturesOptions{JSON: *jsonOut}
 		capFlags := pflag.NewFlagSet("captures", pflag.ContinueOnError)
-		capFlags.StringVar(&opts.Delete, "delete", "", "Delete a capture file by name")
+		capFlags.StringVar(&opts.Delete, "delete", "", "Delete a capture file by name, to the trash")
+		capFlags.StringVar(&opts.Restore, "restore", "", "Restore a deleted capture from the trash by name")
+		capFlags.BoolVar(&opts.Trash, "trash", false, "List deleted captures that can still be restored")
+		capFlags.StringVar(&opts.Download, "download", "", "Download a capture file by name, verifying its checksum")
+		capFlags.StringVar(&opts.Dest, "to", "", "Where to save a download (default: the capture's filename)")
+		capFlags.BoolVar(&opts.NoVerify, "no-verify", false, "Download even if the daemon reports a checksum mismatch")
+		capFlags.StringVar(&opts.Tag, "tag", "", "Change the tags of a capture by name (with --add/--remove)")
+		capFlags.StringSliceVar(&opts.AddTags, "add", nil, "Tags to add with --tag (comma-separated)")
+		capFlags.StringSliceVar(&opts.RemoveTags, "remove", nil, "Tags to remove with --tag (comma-separated)")
+		capFlags.StringVar(&opts.Upload, "upload", "", "Queue a capture by name for upload to object storage again")
+		capFlags.StringArrayVar(&opts.Import, "import", nil, "Import recordings and images from a file or directory on the daemon's host (repeatable)")
+		capFlags.StringVar(&opts.Satellite, "satellite", "", "Satellite assumed for imported files whose names do not say")
+		capFlags.BoolVar(&opts.Move, "move", false, "Move imported files instead of copying them")
+		capFlags.BoolVar(&opts.LocalTime, "local-time", false, "Times in imported file names are local time, not UTC")
+		capFlags.BoolVar(&opts.DryRun, "dry-run", false, "Show what would be imported without importing it")
 		_ = capFlags.Parse(subArgs)
 		err = ctl.Captures(*host, opts)
 
+	case "history":
+		opts := ctl.HistoryOptions{JSON: *jsonOut}
+		histFlags := pflag.NewFlagSet("history", pflag.ContinueOnError)
+		histFlags.StringVar(&opts.Satellite, "satellite", "", "Filter by satellite name")
+		histFlags.StringVar(&opts.Outcome, "outcome", "", "Filter by outcome (captured, failed, cancelled, skipped)")
+		histFlags.StringVar(&opts.Station, "station", "", "Filter by station profile")
+		histFlags.StringVar(&opts.Since, "since", "", "Only passes from this RFC 3339 time or duration ago (e.g. 168h)")
+		histFlags.StringVar(&opts.Until, "until", "", "Only passes before this RFC 3339 time or duration ago")
+		histFlags.Float64Var(&opts.MinElev, "min-elev", 0, "Only passes peaking at or above this elevation (degrees)")
+		histFlags.IntVar(&opts.Limit, "limit", 0, "Limit number of passes shown")
+		_ = histFlags.Parse(subArgs)
+		err = ctl.History(*host, opts)
+
+	case "events":
+		opts := ctl.EventsOptions{JSON: *jsonOut}
+		evFlags := pflag.NewFlagSet("events", pflag.ContinueOnError)
+		evFlags.StringSliceVar(&opts.Types, "type", nil, "Event types to show (comma-separated, e.g. state,pass_scheduled)")
+		evFlags.StringVar(&opts.Satellite, "satellite", "", "Only events naming this satellite")
+		evFlags.StringVar(&opts.Since, "since", "", "Only events from this RFC 3339 time or duration ago (e.g. 12h)")
+		evFlags.StringVar(&opts.Until, "until", "", "Only events before this RFC 3339 time or duration ago")
+		evFlags.IntVar(&opts.Limit, "limit", 0, "Show at most this many of the newest matching events (default 500)")
+		_ = evFlags.Parse(subArgs)
+		err = ctl.Events(*host, opts)
+
 	case "tle-info":
 		err = ctl.TLEInfo(*host, *jsonOut)
 
@@ -93,6 +254,47 @@ func main() {
 	case "system-info":
 		err = ctl.SystemInfo(*host, *jsonOut)
 
+	case "metrics":
+		err = ctl.Metrics(*host)
+
+	case "openapi":
+		err = ctl.OpenAPI(*host)
+
+	case "annotations":
+		opts := ctl.AnnotationsOptions{JSON: *jsonOut}
+		annFlags := pflag.NewFlagSet("annotations", pflag.ContinueOnError)
+		annFlags.IntVar(&opts.Limit, "limit", 0, "Limit number of annotations shown")
+		_ = annFlags.Parse(subArgs)
+		err = ctl.Annotations(*host, opts)
+
+	case "traces":
+		opts := ctl.TracesOptions{JSON: *jsonOut}
+		trFlags := pflag.NewFlagSet("traces", pflag.ContinueOnError)
+		trFlags.IntVar(&opts.Limit, "limit", 0, "Limit number of traces shown")
+		_ = trFlags.Parse(subArgs)
+		err = ctl.Traces(*host, opts)
+
+	case "plugins":
+		err = ctl.Plugins(*host, *jsonOut)
+
+	case "blackouts":
+		err = ctl.Blackouts(*host, *jsonOut)
+
+	case "rules":
+		err = ctl.Rules(*host, *jsonOut)
+
+	case "scripts":
+		err = ctl.Scripts(*host, *jsonOut)
+
+	case "goroutines":
+		opts := ctl.GoroutinesOptions{JSON: *jsonOut}
+		grFlags := pflag.NewFlagSet("goroutines", pflag.ContinueOnError)
+		grFlags.IntVar(&opts.Limit, "limit", 0, "Limit number of stack groups shown")
+		grFlags.StringVar(&opts.Token, "token", "", "Debug token configured in [debug] token")
+		grFlags.BoolVar(&opts.Stack, "stack", false, "Print the full stack of each group")
+		_ = grFlags.Parse(subArgs)
+		err = ctl.Goroutines(*host, opts)
+
 	// ── Control commands ──────────────────────────────────────────
 	case "trigger":
 		opts := ctl.TriggerOptions{JSON: *jsonOut}
@@ -117,6 +319,16 @@ func main() {
 	case "skip":
 		err = ctl.Skip(*host, *jsonOut)
 
+	case "schedule":
+		opts := ctl.ScheduleOptions{JSON: *jsonOut}
+		if len(subArgs) > 0 {
+			opts.Action = subArgs[0]
+		}
+		if len(subArgs) > 1 {
+			opts.ID = subArgs[1]
+		}
+		err = ctl.Schedule(*host, opts)
+
 	case "cancel":
 		err = ctl.Cancel(*host, *jsonOut)
 
@@ -127,18 +339,140 @@ func main() {
 		_ = reloadFlags.Parse(subArgs)
 		err = ctl.Reload(*host, opts)
 
+	case "mode":
+		opts := ctl.ModeOptions{JSON: *jsonOut}
+		modeFlags := pflag.NewFlagSet("mode", pflag.ContinueOnError)
+		modeFlags.BoolVar(&opts.Force, "force", false, "Switch even if a capture is in progress (aborts it)")
+		_ = modeFlags.Parse(subArgs)
+		opts.Set = modeFlags.Arg(0)
+		err = ctl.Mode(*host, opts)
+
+	case "station":
+		opts := ctl.StationOptions{JSON: *jsonOut}
+		stationFlags := pflag.NewFlagSet("station", pflag.ContinueOnError)
+		stationFlags.BoolVar(&opts.Base, "base", false, "Use the bare [station] location instead of a profile")
+		stationFlags.BoolVar(&opts.Force, "force", false, "Switch even if a capture is in progress (aborts it)")
+		_ = stationFlags.Parse(subArgs)
+		opts.Set = stationFlags.Arg(0)
+		err = ctl.Station(*host, opts)
+
+	case "consistency":
+		err = ctl.Consistency(*host, *jsonOut)
+
+	case "scrub":
+		opts := ctl.ScrubOptions{JSON: *jsonOut}
+		scrubFlags := pflag.NewFlagSet("scrub", pflag.ContinueOnError)
+		scrubFlags.BoolVar(&opts.Run, "run", false, "Start an integrity scrub now")
+		_ = scrubFlags.Parse(subArgs)
+		err = ctl.Scrub(*host, opts)
+
+	case "retention":
+		opts := ctl.RetentionOptions{JSON: *jsonOut}
+		retFlags := pflag.NewFlagSet("retention", pflag.ContinueOnError)
+		retFlags.BoolVar(&opts.Run, "run", false, "Start a retention sweep now")
+		retFlags.BoolVar(&opts.DryRun, "dry-run", false, "Show what a sweep would prune, changing nothing")
+		_ = retFlags.Parse(subArgs)
+		err = ctl.Retention(*host, opts)
+
+	case "gallery":
+		opts := ctl.GalleryOptions{JSON: *jsonOut}
+		galFlags := pflag.NewFlagSet("gallery", pflag.ContinueOnError)
+		galFlags.BoolVar(&opts.Export, "export", false, "Export the static gallery now")
+		_ = galFlags.Parse(subArgs)
+		err = ctl.Gallery(*host, opts)
+
+	case "notify":
+		opts := ctl.NotifyOptions{JSON: *jsonOut}
+		notifyFlags := pflag.NewFlagSet("notify", pflag.ContinueOnError)
+		notifyFlags.BoolVar(&opts.Test, "test", false, "Send a test message to every enabled backend")
+		notifyFlags.StringVar(&opts.Kind, "kind", "", "With --test, send a pass, failure or summary from its template")
+		notifyFlags.BoolVar(&opts.DryRun, "dry-run", false, "With --kind, show the message without sending it")
+		notifyFlags.BoolVar(&opts.Summary, "summary", false, "Send the daily summary of the last 24 hours now")
+		_ = notifyFlags.Parse(subArgs)
+		err = ctl.Notify(*host, opts)
+
+	case "replay":
+		opts := ctl.ReplayOptions{JSON: *jsonOut}
+		replayFlags := pflag.NewFlagSet("replay", pflag.ContinueOnError)
+		replayFlags.Float64Var(&opts.Speed, "speed", 1, "Replay this many times faster than real time (max 1000)")
+		replayFlags.BoolVar(&opts.Stop, "stop", false, "Stop the replay in progress")
+		_ = replayFlags.Parse(subArgs)
+		if replayFlags.NArg() > 0 {
+			opts.PassID = replayFlags.Arg(0)
+		}
+		err = ctl.Replay(*host, opts)
+
+	case "wake-schedule":
+		opts := ctl.WakeScheduleOptions{JSON: *jsonOut}
+		wakeFlags := pflag.NewFlagSet("wake-schedule", pflag.ContinueOnError)
+		wakeFlags.StringVar(&opts.Format, "format", "", "Print an rtcwake list or a systemd timer unit instead")
+		wakeFlags.IntVar(&opts.Lead, "lead", 0, "Seconds to wake before AOS (default 120)")
+		_ = wakeFlags.Parse(subArgs)
+		err = ctl.WakeSchedule(*host, opts)
+
+	case "resume-hook":
+		err = ctl.ResumeHook(*host, *jsonOut)
+
+	case "share":
+		opts := ctl.ShareOptions{JSON: *jsonOut}
+		shareFlags := pflag.NewFlagSet("share", pflag.ContinueOnError)
+		shareFlags.IntVar(&opts.Hours, "hours", 0, "Hours the link lasts (default 24)")
+		shareFlags.StringVar(&opts.Label, "label", "", "Who the link is for, shown in events")
+		_ = shareFlags.Parse(subArgs)
+		opts.Revoke = shareFlags.Arg(0) == "revoke"
+		err = ctl.Share(*host, opts)
+
+	case "catalog-sync":
+		opts := ctl.CatalogSyncOptions{JSON: *jsonOut}
+		syncFlags := pflag.NewFlagSet("catalog-sync", pflag.ContinueOnError)
+		syncFlags.BoolVar(&opts.Run, "run", false, "Sync the catalog with SatNOGS DB now")
+		_ = syncFlags.Parse(subArgs)
+		err = ctl.CatalogSync(*host, opts)
+
+	case "config-persist":
+		opts := ctl.ConfigPersistOptions{JSON: *jsonOut}
+		var lat, lon, alt float64
+		var ppm int
+		cpFlags := pflag.NewFlagSet("config-persist", pflag.ContinueOnError)
+		cpFlags.BoolVar(&opts.GPSD, "gpsd", false, "Persist the station position from gpsd")
+		cpFlags.Float64Var(&lat, "lat", 0, "Station latitude in degrees")
+		cpFlags.Float64Var(&lon, "lon", 0, "Station longitude in degrees")
+		cpFlags.Float64Var(&alt, "alt", 0, "Station altitude in meters")
+		cpFlags.IntVar(&ppm, "ppm", 0, "Measured SDR ppm correction")
+		_ = cpFlags.Parse(subArgs)
+		if cpFlags.Changed("lat") {
+			opts.Latitude = &lat
+		}
+		if cpFlags.Changed("lon") {
+			opts.Longitude = &lon
+		}
+		if cpFlags.Changed("alt") {
+			opts.Altitude = &alt
+		}
+		if cpFlags.Changed("ppm") {
+			opts.PPMCorrection = &ppm
+		}
+		err = ctl.ConfigPersist(*host, opts)
+
 	// ── Live streaming ────────────────────────────────────────────
 	case "watch":
-		err = ctl.Watch(*host, ctl.WatchOptions{
-			Filter: *filter,
-			JSON:   *jsonOut,
-		})
+		opts := ctl.WatchOptions{Filter: *filter, JSON: *jsonOut}
+		watchFlags := pflag.NewFlagSet("watch", pflag.ContinueOnError)
+		watchFlags.StringSliceVar(&opts.Filter, "filter", opts.Filter, "Event types to show (comma-separated)")
+		watchFlags.Float64Var(&opts.Sample, "sample", 0, "Fraction of debug events to receive (e.g. 0.1)")
+		_ = watchFlags.Parse(subArgs)
+		err = ctl.Watch(*host, opts)
 
 	default:
 		usage()
 		os.Exit(2)
 	}
 
+	if out != nil {
+		if cerr := out.Close(err == nil); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "error:", err)
 		os.Exit(1)
@@ -155,17 +489,38 @@ func usage() {
   COMMANDS (query)
     status          Show daemon state, uptime, and current activity
     health          Check daemon and component health
+    ready           Check readiness, including scheduler liveness
+    health-history  Show recent health samples and flapping checks
     version         Show CLI and daemon version information
     satellites      List the satellite catalog
+    sat NAME        Show one satellite's passes, captures, TLE, and success rate
     config          Show the daemon's running configuration
     config-list     List available config profiles
     passes          List upcoming satellite passes
     next-pass       Show the next upcoming pass
-    captures        List recorded capture files
+    pass-track [ID] Show a pass's sky and ground track (default: the next pass)
+    blackouts       List blackout windows and which are in force
+    schedule        Show the computed schedule and why passes were dropped
+    wake-schedule   Show when to wake a station that sleeps between passes,
+                    or print it for rtcwake or as a systemd timer
+    consistency     Show what the startup check of captures against the history repaired
+    summary         Show the e-paper summary, or save it as a PNG
+    captures        List, download, import, tag, upload, delete, or restore captures
+    history         Show past pass attempts and how each ended
+    events          Show logged events, such as during a missed pass
     tle-info        Show TLE cache status and freshness
     stats           Show aggregate capture statistics
     logs            Show recent daemon log messages
     system-info     Show runtime and hardware information
+    metrics         Print Prometheus metrics exposed at /metrics
+    openapi         Print the OpenAPI 3 document of the HTTP API
+    annotations     List capture-window and failure annotations
+    traces          Show recent pass pipeline traces and stage timings
+    plugins         List event plugins and whether they are running
+    rules           List automation rules and how often each fired
+    scripts         List Starlark scripts and how often each ran
+    goroutines      Show goroutine and memory diagnostics (requires [debug])
+    batch PATH...   Fetch several API paths in one request (JSON output)
 
   COMMANDS (control)
     trigger         Force an immediate satellite capture
@@ -173,27 +528,128 @@ func usage() {
     pause           Pause automatic pass scheduling
     resume          Resume pass scheduling
     skip            Skip the current/next scheduled pass
+    schedule skip|force|clear ID
+                    Keep one pass out of the schedule, record it whatever
+                    the filters say, or clear that again (persisted)
     cancel          Abort an in-progress capture
     reload          Reload configuration from disk
+    mode [MODE]     Show or switch demo/live mode without a restart
+    station [NAME]  Show or switch the active station profile
+    scrub           Show or start the capture integrity scrub
+    retention       Show, preview, or start the capture retention sweep
+    gallery         Show or export the static image gallery
+    notify          Show notification backends or send a test or summary
+    catalog-sync    Show or start the SatNOGS DB catalog sync
+    resume-hook     Recompute the schedule after the system wakes from sleep
+    share [revoke]  Mint a time-limited guest link for the public listener,
+                    or revoke every link handed out
+    replay [PASS_ID]
+                    Re-broadcast a past pass's logged events, or show the replay
+    satellites enable|disable NAME
+                    Take a satellite in or out of the schedule (persisted)
+    satellites offset NAME HZ
+                    Tune a satellite's recordings off its catalog frequency
+    satellites add NAME
+                    Add a satellite to the catalog (persisted)
+    satellites remove NAME
+                    Remove an added satellite from the catalog
+    config-persist  Save gpsd position or ppm correction to the config file
 
   COMMANDS (live)
     watch           Stream live events from the daemon (Ctrl-C to stop)
+    wait-for-change Block until the state or tracked pass changes
 
   GLOBAL FLAGS
-    -H, --host URL      Daemon base URL (default: http://127.0.0.1:8080)
-        --json          Output raw JSON instead of formatted text
-        --filter TYPE   Event types to show in watch (comma-separated)
+    -H, --host URL          Daemon base URL (default: http://127.0.0.1:8080)
+        --json              Output raw JSON instead of formatted text
+    -o, --output-file PATH  Write output to PATH (replaced only on success)
+    -q, --quiet             Suppress formatted output (errors still go to stderr)
+        --color WHEN        Colorize output: auto (default), always, never.
+                            auto honors NO_COLOR and disables color when
+                            output is not a terminal
+        --lang LANG         Output language, e.g. de or pt_BR (default:
+                            $EPHCTL_LANG, then the locale). Translations
+                            are read from ~/.config/ephemeris/locale/LANG.toml
+        --token TOKEN       API token for control commands, one of the
+                            daemon's server.api_tokens (default: $EPHCTL_TOKEN)
+        --filter TYPE       Event types to show in watch (comma-separated)
 
   COMMAND FLAGS
+    config:
+        --resolved          Show the merged config file and its includes
+        --show-secrets      Show secret values (local daemon only)
+        --token TOKEN       Operator token configured in [debug] token
+
+    satellites add:
+        --norad ID          NORAD catalog ID (required)
+        --freq HZ           Downlink frequency in Hz (required)
+        --mode MODE         Downlink mode: apt (default) or lrpt
+
+    sat:
+        --count N           Passes and captures shown (default: 5)
+
+    wait-for-change:
+        --state STATE       Baseline state (default: the current state)
+        --pass TOKEN        Baseline pass_token from an earlier response
+        --timeout DUR       Give up after DUR, exiting 1 (default: 30s)
+
     passes:
         --count N           Limit number of passes shown
         --satellite NAME    Filter by satellite name
+        --min-elev DEG      Only passes peaking at or above DEG
+        --direction N|S     Only northbound or southbound passes
+        --tz ZONE           Show times in ZONE as the daemon renders them,
+                            or in station.timezone with "station"
+        --score             Show how the scheduler scores each pass and
+                            what the score is made of
 
     next-pass:
         --satellite NAME    Filter by satellite name
+        --tz ZONE           As for passes
+        --plot              Draw the pass on a polar sky chart
+
+    pass-track:
+        --step SECS         Seconds between points (default 10, max 300)
+
+    summary:
+        --tz ZONE           As for passes
+        --png FILE          Save the black and white rendering to FILE
+        --width N           Width of the rendering (default 296)
+        --height N          Height of the rendering (default 128)
 
     captures:
-        --delete NAME       Delete a capture file by name
+        --delete NAME       Delete a capture file by name, to the trash
+        --restore NAME      Restore a deleted capture from the trash
+        --trash             List deleted captures and when they are purged
+        --download NAME     Download a capture, verifying its SHA-256
+        --to PATH           Save the download to PATH (default: its filename)
+        --no-verify         Download even if the checksum no longer matches
+        --tag NAME          Change a capture's tags, with --add and --remove
+        --add TAGS          Tags to add (comma-separated)
+        --remove TAGS       Tags to remove (comma-separated)
+        --upload NAME       Upload a capture to object storage again
+        --import PATH       Import recordings and images made by another tool
+                            from PATH on the daemon's host (repeatable)
+        --satellite NAME    Satellite for imported files whose names omit it
+        --move              Move imported files instead of copying them
+        --local-time        Times in imported file names are local, not UTC
+        --dry-run           Show what --import would do without doing it
+
+    history:
+        --satellite NAME    Filter by satellite name
+        --outcome OUTCOME   captured, failed, cancelled, or skipped
+        --station NAME      Filter by station profile
+        --since WHEN        From an RFC 3339 time, or a duration ago (168h)
+        --until WHEN        Before an RFC 3339 time, or a duration ago
+        --min-elev DEG      Only passes peaking at or above DEG
+        --limit N           Limit number of passes shown
+
+    events:
+        --type TYPE         Event types to show (comma-separated)
+        --satellite NAME    Only events naming this satellite
+        --since WHEN        From an RFC 3339 time, or a duration ago (12h)
+        --until WHEN        Before an RFC 3339 time, or a duration ago
+        --limit N           Newest N matching events (default 500)
 
     trigger:
         --norad-id ID       NORAD catalog ID (alternative to satellite name)
@@ -204,31 +660,133 @@ func usage() {
         --limit N           Limit number of log entries shown
         --tail              Stream live log events
 
+    watch:
+        --filter TYPE       Event types to show (comma-separated)
+        --sample FRACTION   With --filter debug, receive only this fraction
+                            of debug events, e.g. 0.1
+
+    health-history:
+        --limit N           Limit number of samples shown
+
+    annotations:
+        --limit N           Limit number of annotations shown
+
+    traces:
+        --limit N           Limit number of traces shown
+
+    goroutines:
+        --limit N           Limit number of stack groups shown
+        --token TOKEN       Debug token configured in [debug] token
+        --stack             Print the full stack of each group
+
     reload:
         --profile NAME      Switch to a named config profile
 
+    mode:
+        --force             Switch even if a capture is in progress
+
+    station:
+        --base              Use the bare [station] location, no profile
+        --force             Switch even if a capture is in progress
+
+    scrub:
+        --run               Re-verify every capture's checksum now
+
+    retention:
+        --run               Archive or delete captures past the limits now
+        --dry-run           Show what a sweep would prune, changing nothing
+
+    gallery:
+        --export            Write the gallery's pages and images now
+
+    notify:
+        --test              Send a test message to every enabled backend
+        --kind KIND         With --test, send a pass, failure or summary from its template
+        --dry-run           With --kind, show the message without sending it
+        --summary           Send the daily summary of the last 24 hours now
+
+    catalog-sync:
+        --run               Look the catalog up in SatNOGS DB now
+
+    wake-schedule:
+        --format FORMAT     rtcwake (one line per wake-up, Unix time first)
+                            or systemd (an ephemeris-wake.timer unit)
+        --lead SECS         Seconds to wake before AOS (default 120)
+
+    share:
+        --hours N           Hours the link lasts (default 24, at most
+                            server.share_max_hours)
+        --label TEXT        Who the link is for, shown in events
+
+    replay:
+        --speed N           Times faster than real time (default 1, max 1000)
+        --stop              Stop the replay in progress
+
+    config-persist:
+        --gpsd              Persist the station position from gpsd
+        --lat / --lon DEG   Persist a station latitude / longitude
+        --alt METERS        Persist a station altitude
+        --ppm N             Persist a measured SDR ppm correction
+
   EXAMPLES
     ephctl status
     ephctl --json status
+    ephctl --json -o /var/lib/ephemeris/passes.json passes
+    ephctl -q trigger NOAA-19
+    ephctl --color=always watch | less -R
+    ephctl --lang de status
     ephctl --host http://192.168.8.1:8080 watch
     ephctl passes --satellite NOAA-19 --count 5
+    ephctl passes --min-elev 40 --direction N
+    ephctl passes --score
     ephctl next-pass
+    ephctl next-pass --plot
+    ephctl pass-track 33591-1792267200 --step 30
+    ephctl summary --png /var/www/html/station.png --width 400 --height 300
+    ephctl sat NOAA-19
     ephctl captures
+    ephctl captures --download NOAA-19_20260215T143022Z.wav
+    ephctl captures --tag NOAA-19_20260215T143022Z.wav --add best,clear-sky
+    ephctl captures --import ~/raspberry-noaa/audio --local-time --dry-run
+    ephctl history --outcome failed --since 168h
     ephctl trigger NOAA-19 --duration 600
     ephctl tle-refresh
     ephctl tle-info
     ephctl logs --level error --limit 20
     ephctl logs --tail
+    ephctl health-history --limit 30
     ephctl pause
     ephctl resume
     ephctl skip
+    ephctl schedule skip 33591-1792267200
+    ephctl wake-schedule --format systemd > /etc/systemd/system/ephemeris-wake.timer
+    ephctl share --hours 6 --label "club night"
     ephctl cancel
+    ephctl config --resolved
     ephctl config-list
     ephctl system-info
     ephctl stats
     ephctl reload
     ephctl reload --profile example
+    ephctl mode live
+    ephctl station field
+    ephctl satellites disable NOAA-15
+    ephctl satellites offset NOAA-18 -1200
+    ephctl satellites add METEOR-M2-3 --norad 57166 --freq 137900000 --mode lrpt
+    ephctl satellites remove METEOR-M2-3
+    ephctl scrub --run
+    ephctl consistency
+    ephctl catalog-sync --run
+    ephctl replay 42 --speed 20
+    ephctl plugins
+    ephctl rules
+    ephctl scripts
+    ephctl batch /api/status /api/next-pass /api/stats
+    ephctl wait-for-change --state IDLE --timeout 5m
+    ephctl config-persist --gpsd
+    ephctl config-persist --ppm 3
     ephctl watch --filter state,log,pass_scheduled
+    ephctl watch --filter debug --sample 0.25
 
 `)
 }
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/decode"
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+	"github.com/large-farva/ephemeris-engine/internal/ws"
+)
+
+// benchStage is the time and memory one decode stage took, summed over
+// the runs.
+type benchStage struct {
+	name    string
+	elapsed time.Duration
+	alloc   uint64 // bytes allocated
+}
+
+// runBenchDecode implements `ephemerisd bench-decode FILE.wav`, which
+// decodes a capture the way the daemon would and reports how long each
+// stage took and how much memory it used. Images are encoded but not
+// written, and the capture's sidecar is left alone.
+func runBenchDecode(args []string) error {
+	fs := pflag.NewFlagSet("bench-decode", pflag.ContinueOnError)
+	fs.SetInterspersed(false)
+	configPath := fs.StringP("config", "c", "", "Path to config TOML (auto-discovers if omitted)")
+	enhance := fs.StringSlice("enhance", nil, "Enhancements to make (equalized, false_color, overlay); default decode.enhancements, \"none\" for only A and B")
+	runs := fs.IntP("runs", "n", 1, "Decode this many times and report the average")
+	cpuProfile := fs.String("cpuprofile", "", "Write a CPU profile of the decodes to this file")
+	memProfile := fs.String("memprofile", "", "Write a heap profile to this file after the last decode")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ephemerisd bench-decode [flags] FILE.wav")
+		fmt.Fprintln(os.Stderr, "\nDecodes FILE.wav without writing anything and reports the time and")
+		fmt.Fprintln(os.Stderr, "memory each stage takes, to judge which enhancements this machine can")
+		fmt.Fprintln(os.Stderr, "afford after each pass.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() != 1 || *runs < 1 {
+		fs.Usage()
+		return errors.New("one FILE.wav and --runs of at least 1 are required")
+	}
+	path := fs.Arg(0)
+
+	cfg := config.Default()
+	if file := *configPath; file != "" || config.FindConfigFile() != "" {
+		if file == "" {
+			file = config.FindConfigFile()
+		}
+		var err error
+		if cfg, err = config.Load(file); err != nil {
+			return err
+		}
+	}
+	opts := decode.Options{
+		Enhance: cfg.Decode.Enhancements,
+		Shapes:  cfg.Decode.OverlayShapes,
+		Discard: true,
+	}
+	if fs.Changed("enhance") {
+		opts.Enhance = nil
+		for _, e := range *enhance {
+			if e != "none" {
+				opts.Enhance = append(opts.Enhance, e)
+			}
+		}
+	}
+	for _, e := range opts.Enhance {
+		if !slices.Contains(config.ImageEnhancements, e) {
+			return fmt.Errorf("unknown enhancement %q (use %s, or none)", e, strings.Join(config.ImageEnhancements, ", "))
+		}
+	}
+	// The overlay needs the satellite's orbit, found from the sidecar's
+	// NORAD ID and the configured TLE source.
+	if m, err := capture.ReadMetadata(path); err == nil && m.NoradID != 0 && slices.Contains(opts.Enhance, decode.EnhanceOverlay) {
+		predictor := predict.NewPredictor(ws.NewHub(), cfg, slog.New(slog.DiscardHandler))
+		if track, err := predictor.Track(m.NoradID); err == nil {
+			opts.Track = track
+		} else {
+			fmt.Fprintf(os.Stderr, "no ground track for the overlay: %v\n", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return err
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	var (
+		stages   []*benchStage
+		byName   = map[string]*benchStage{}
+		res      decode.Result
+		total    time.Duration
+		peakHeap uint64
+		ms       runtime.MemStats
+	)
+	runtime.GC()
+	runtime.ReadMemStats(&ms)
+	gcStart := ms.NumGC
+	for range *runs {
+		runtime.ReadMemStats(&ms)
+		lastAlloc := ms.TotalAlloc
+		start := time.Now()
+		last := start
+		opts.Stage = func(name string) {
+			now := time.Now()
+			runtime.ReadMemStats(&ms)
+			st := byName[name]
+			if st == nil {
+				st = &benchStage{name: name}
+				byName[name] = st
+				stages = append(stages, st)
+			}
+			st.elapsed += now.Sub(last)
+			st.alloc += ms.TotalAlloc - lastAlloc
+			peakHeap = max(peakHeap, ms.HeapInuse)
+			lastAlloc = ms.TotalAlloc
+			// Reading the stats stops the world; leave that out.
+			last = time.Now()
+			total += now.Sub(start)
+			start = last
+		}
+		var err error
+		if res, err = decode.Capture(ctx, "", path, opts); err != nil {
+			return err
+		}
+	}
+	runtime.ReadMemStats(&ms)
+
+	if *memProfile != "" {
+		f, err := os.Create(*memProfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			return err
+		}
+	}
+
+	n := time.Duration(*runs)
+	// APT sends two lines a second.
+	audio := time.Duration(res.Lines) * time.Second / 2
+	fmt.Printf("%s: %d lines (%s of signal), %d%% in sync, SNR %.1f dB, score %d\n", path, res.Lines, audio.Round(time.Second), res.SyncPercent(), res.SNR, res.Score())
+	fmt.Printf("%s/%s, %d CPUs, GOMAXPROCS %d, %d run(s)\n\n", runtime.GOOS, runtime.GOARCH, runtime.NumCPU(), runtime.GOMAXPROCS(0), *runs)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "STAGE\tTIME\tSHARE\tALLOCATED\t")
+	for _, st := range stages {
+		share := 0.0
+		if total > 0 {
+			share = 100 * float64(st.elapsed) / float64(total)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%.0f%%\t%s\t\n", st.name, roundDuration(st.elapsed/n), share, formatBytes(st.alloc/uint64(*runs)))
+	}
+	fmt.Fprintf(tw, "total\t%s\t\t\t\n", roundDuration(total/n))
+	tw.Flush()
+
+	fmt.Println()
+	if total > 0 && audio > 0 {
+		fmt.Printf("%.0fx faster than real time\n", float64(audio)/float64(total/n))
+	}
+	fmt.Printf("peak heap in use %s, %d GC cycles, %s obtained from the OS\n", formatBytes(peakHeap), ms.NumGC-gcStart, formatBytes(ms.Sys))
+	for _, note := range res.Notes {
+		fmt.Printf("note: %s\n", note)
+	}
+	return nil
+}
+
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(100 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
+
+// formatBytes formats n in binary units.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGT"[exp])
+}
@@ -3,25 +3,56 @@
 // It loads configuration, starts the HTTP/WebSocket server, and runs either
 // the real satellite scheduler or a demo loop depending on config. Shutdown
 // is handled gracefully on SIGINT or SIGTERM.
+//
+// `ephemerisd migrate --from raspberry-noaa PATH` instead converts another
+// tool's settings into a config file and exits,
+// `ephemerisd bench-decode FILE.wav` times each stage of decoding a capture,
+// and `ephemerisd selftest` runs a pass through a fake SDR end to end.
 package main
 
 import (
 	"context"
 	"errors"
-	"log"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
+	// Station time zones work on hosts without a zone database.
+	_ "time/tzdata"
 
 	"github.com/spf13/pflag"
 
 	"github.com/large-farva/ephemeris-engine/internal/app"
 	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/logging"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "ephemerisd migrate: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "selftest" {
+		if err := runSelftest(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "ephemerisd selftest: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "bench-decode" {
+		if err := runBenchDecode(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "ephemerisd bench-decode: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var (
 		configPath = pflag.StringP("config", "c", "", "Path to config TOML (auto-discovers if omitted)")
 		bind       = pflag.String("bind", "0.0.0.0:8080", "HTTP bind address")
@@ -34,28 +65,44 @@ func main() {
 		cfgFile = config.FindConfigFile()
 	}
 
-	logger := log.New(os.Stdout, "ephemerisd ", log.LstdFlags|log.Lmicroseconds)
-
-	var cfg config.Config
-	if cfgFile == "" {
-		cfg = config.Default()
-		logger.Printf("no config file found, using defaults")
-		logger.Printf("create %s/config.toml to customize", config.DefaultConfigDir())
-	} else {
+	cfg := config.Default()
+	if cfgFile != "" {
 		var err error
 		cfg, err = config.Load(cfgFile)
 		if err != nil {
-			log.Fatalf("config load failed: %v", err)
+			fmt.Fprintf(os.Stderr, "ephemerisd: config load failed: %v\n", err)
+			os.Exit(1)
 		}
-		logger.Printf("loaded config from %s", cfgFile)
 	}
-
 	if err := config.EnsureDirectories(cfg); err != nil {
-		log.Fatalf("directory setup: %v", err)
+		fmt.Fprintf(os.Stderr, "ephemerisd: directory setup: %v\n", err)
+		os.Exit(1)
+	}
+
+	logger, logOut, err := logging.New(cfg.Logging, cfg.Data.Root)
+	if err != nil {
+		// Keep logging to stdout rather than refuse to start.
+		fileErr := err
+		cfg.Logging.File = false
+		logger, logOut, _ = logging.New(cfg.Logging, cfg.Data.Root)
+		logger.Warn("log file disabled", "err", fileErr)
+	}
+	defer logOut.Close()
+	slog.SetDefault(logger)
+
+	if cfgFile == "" {
+		logger.Info("no config file found, using defaults")
+		logger.Info("create " + config.DefaultConfigDir() + "/config.toml to customize")
+	} else {
+		logger.Info("loaded config", "path", cfgFile)
+	}
+	if path := logOut.Path(); path != "" {
+		logger.Info("logging to file", "path", path)
 	}
 
 	a := app.New(app.Options{
 		Logger:     logger,
+		LogOutput:  logOut,
 		Cfg:        cfg,
 		Bind:       *bind,
 		ConfigPath: cfgFile,
@@ -64,8 +111,20 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	// SIGHUP re-reads the config file, like POST /api/reload.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			logger.Info("SIGHUP received, reloading config")
+			a.Reload()
+		}
+	}()
+
 	if err := a.Run(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		logger.Fatalf("ephemerisd failed: %v", err)
+		logger.Error("ephemerisd failed", "err", err)
+		logOut.Close()
+		os.Exit(1)
 	}
 
 	// Brief pause so in-flight log writes can flush before exit.
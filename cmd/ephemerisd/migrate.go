@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// runMigrate implements `ephemerisd migrate --from TOOL PATH`, which turns
+// another tool's settings into an ephemeris config. The config goes to
+// stdout, or to --out, which is never overwritten without --force.
+func runMigrate(args []string) error {
+	fs := pflag.NewFlagSet("migrate", pflag.ContinueOnError)
+	from := fs.String("from", "", "Tool to migrate from (raspberry-noaa)")
+	out := fs.StringP("out", "o", "", "Write the config to this file instead of stdout")
+	force := fs.Bool("force", false, "Overwrite --out if it exists")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ephemerisd migrate --from raspberry-noaa [--out FILE] PATH")
+		fmt.Fprintln(os.Stderr, "\nPATH is the tool's settings file or the directory holding it.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *from == "" || fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("--from and one PATH are required")
+	}
+
+	m, err := config.MigrateFrom(*from, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	b, err := m.TOML(time.Now())
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if *force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(*out, flags, 0o644)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%s exists; use --force to overwrite it", *out)
+		}
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s from %s (%d settings)\n", *out, m.Source, len(m.Updates))
+	for _, n := range m.Notes {
+		fmt.Fprintf(os.Stderr, "  note: %s\n", n)
+	}
+	return nil
+}
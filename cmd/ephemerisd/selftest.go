@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/large-farva/ephemeris-engine/internal/harness"
+)
+
+// runSelftest implements `ephemerisd selftest`: it starts a throwaway
+// daemon with a fake rtl_fm and follows a triggered pass from capture to
+// decode to the capture index, reporting each step.
+func runSelftest(args []string) error {
+	fs := pflag.NewFlagSet("selftest", pflag.ContinueOnError)
+	fs.SetInterspersed(false)
+	satellite := fs.String("satellite", "NOAA-19", "Satellite to trigger")
+	seconds := fs.Float64("seconds", 30, "Seconds of canned APT audio to record")
+	keep := fs.String("keep", "", "Run in this directory and keep it, instead of a temporary one")
+	verbose := fs.BoolP("verbose", "v", false, "Print the daemon's log")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Give up after this long")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: ephemerisd selftest [flags]")
+		fmt.Fprintln(os.Stderr, "\nRuns a pass through a throwaway daemon with a fake SDR, from trigger to")
+		fmt.Fprintln(os.Stderr, "capture, decode and the capture index. Needs no radio or network, and")
+		fmt.Fprintln(os.Stderr, "leaves the configured data.root alone.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	opts := harness.Options{Dir: *keep, AudioSeconds: *seconds}
+	if *verbose {
+		opts.Logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	}
+	if *keep != "" {
+		if err := os.MkdirAll(*keep, 0o755); err != nil {
+			return err
+		}
+	}
+	st, err := harness.Start(opts)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+	fmt.Printf("daemon up at %s, data in %s\n", st.URL, st.Cfg.Data.Root)
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	rep, err := harness.Pipeline(ctx, st, *satellite)
+	for _, s := range rep.Steps {
+		fmt.Printf("  ok  %-8s %8s  %s\n", s.Name, s.Elapsed.Round(time.Millisecond), s.Detail)
+	}
+	if err != nil {
+		fmt.Printf("  FAIL %v\n", err)
+		return errors.New("pipeline failed")
+	}
+	fmt.Printf("pipeline ok: %s, %d lines, %d images\n", rep.Capture, rep.Lines, len(rep.Images))
+	return nil
+}
@@ -0,0 +1,103 @@
+// Package api defines the JSON bodies of the daemon's HTTP API that are
+// frozen under /api/v1. ephemerisd encodes them and ephctl decodes them, so
+// a field cannot be renamed on one side and silently read as empty on the
+// other.
+//
+// Within v1 a response only ever gains fields: existing fields keep their
+// name, type and meaning, and are not removed. A client must ignore fields
+// it does not know. A change that breaks this belongs in /api/v2.
+//
+// The unversioned paths, such as /api/status, serve the same bodies and
+// remain for existing clients.
+//
+// The package also builds the OpenAPI document of the whole HTTP surface
+// from the body types of each route; see Document.
+package api
+
+// Version is the frozen API version served under /api/v1.
+const Version = "v1"
+
+// StatusResponse is the body of GET /api/v1/status.
+type StatusResponse struct {
+	Name  string `json:"name"`  // always "ephemeris-engine"
+	State string `json:"state"` // BOOTING, IDLE, WAITING_FOR_PASS, RECORDING, DECODING, ...
+	// UptimeSeconds counts from daemon start; BootID changes on every
+	// start, like the heartbeat's boot_id.
+	UptimeSeconds int64  `json:"uptime_seconds"`
+	BootID        string `json:"boot_id"`
+	// DataRoot and ArchiveDir are left out on the public listener.
+	DataRoot    string     `json:"data_root,omitempty"`
+	ArchiveDir  string     `json:"archive_dir,omitempty"`
+	DemoEnabled bool       `json:"demo_enabled"`
+	Mode        string     `json:"mode"`   // "demo" or "live"
+	Paused      bool       `json:"paused"` // automatic scheduling paused; always false in demo mode
+	URLs        StatusURLs `json:"urls"`
+	// CurrentPass is the pass being waited for, recorded or decoded, if
+	// any.
+	CurrentPass *PassInfo `json:"current_pass,omitempty"`
+	// Disk is the data root's filesystem, when it could be read.
+	Disk *DiskUsage `json:"disk,omitempty"`
+	// ReadOnly is set while the data root's filesystem refuses writes.
+	// Nothing is recorded until it is writable again.
+	ReadOnly bool `json:"read_only,omitempty"`
+	// Clock is the daemon's time, in UTC and in the station's zone.
+	Clock *Clock `json:"clock,omitempty"`
+}
+
+// Clock is a moment in UTC and in a time zone, rendered by the daemon so
+// clients without a time zone database can show local times.
+type Clock struct {
+	UTC      string `json:"utc"`      // RFC 3339
+	Local    string `json:"local"`    // RFC 3339 with the zone's offset
+	Timezone string `json:"timezone"` // IANA name, such as Europe/London, or "Local"
+	Abbrev   string `json:"abbrev"`   // such as BST
+	OffsetS  int    `json:"offset_s"` // seconds east of UTC
+}
+
+// StatusURLs are where clients reach the daemon, as seen through any
+// reverse proxy.
+type StatusURLs struct {
+	API string `json:"api"`
+	WS  string `json:"ws"`
+}
+
+// PassInfo describes the pass the scheduler is working on.
+type PassInfo struct {
+	Satellite string  `json:"satellite"`
+	NoradID   int     `json:"norad_id"`
+	FreqHz    int     `json:"freq_hz"`
+	AOS       string  `json:"aos"` // RFC 3339, UTC
+	LOS       string  `json:"los"` // RFC 3339, UTC
+	MaxElev   float64 `json:"max_elev"`
+	Stage     string  `json:"stage"`             // waiting, recording or decoding
+	Station   string  `json:"station,omitempty"` // station profile, if any
+	Manual    bool    `json:"manual,omitempty"`  // started by a trigger
+	// AOSLocal and LOSLocal are AOS and LOS in the station's time zone,
+	// and AOSUnix and LOSUnix the same in Unix seconds. Only /api/status
+	// sets them.
+	AOSLocal string `json:"aos_local,omitempty"`
+	LOSLocal string `json:"los_local,omitempty"`
+	AOSUnix  int64  `json:"aos_unix,omitempty"`
+	LOSUnix  int64  `json:"los_unix,omitempty"`
+}
+
+// DiskUsage is the size and free space of a filesystem, in bytes.
+type DiskUsage struct {
+	TotalBytes     uint64 `json:"total_bytes"`
+	UsedBytes      uint64 `json:"used_bytes"`
+	AvailableBytes uint64 `json:"available_bytes"`
+}
+
+// ErrorResponse is the body of every error answered with JSON, on any
+// path.
+type ErrorResponse struct {
+	OK    bool   `json:"ok"` // always false
+	Error string `json:"error"`
+}
+
+// OKResponse is the body of an action that reports only that it was done
+// or started.
+type OKResponse struct {
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,496 @@
+package api
+
+import (
+	"encoding"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// OpenAPIVersion is the OpenAPI specification version of Document.
+const OpenAPIVersion = "3.0.3"
+
+// Route describes one operation of the HTTP API. The daemon keeps a table
+// of them next to its mux, and Document turns the Go types of their bodies
+// into JSON schemas, so the published document follows the structs the
+// handlers encode.
+type Route struct {
+	Method  string
+	Path    string // may hold {name} path parameters
+	Tag     string
+	Summary string
+	Query   []Param
+	// Request and Response are zero values of the JSON body types; nil
+	// means no JSON body. A Response of nil with
+	// ContentType set is a non-JSON body, such as image/png.
+	Request     any
+	Response    any
+	ContentType string
+	Status      int // success status; 0 means 200
+	// Control marks an operation that needs an API token when
+	// server.api_tokens is set.
+	Control bool
+}
+
+// Param is a query parameter of a Route.
+type Param struct {
+	Name        string
+	Type        string // JSON schema type: string, integer, number or boolean
+	Description string
+}
+
+// OpenAPI is an OpenAPI 3 document, limited to what Document produces.
+type OpenAPI struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Servers    []Server            `json:"servers,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+	Tags       []map[string]string `json:"tags,omitempty"`
+}
+
+// Info is the document's title and the daemon version it describes.
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Server is a base URL the API is reached at.
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem maps lowercase HTTP methods to operations.
+type PathItem map[string]*Operation
+
+// Operation is one method on one path.
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter.
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is an operation's JSON body.
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is one status of an operation.
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType holds the schema of a body.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components holds the named schemas referred to by $ref.
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how API tokens are sent.
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+// Schema is the subset of JSON Schema used by OpenAPI 3.0 that Document
+// produces.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// Document builds the OpenAPI document for routes. Named Go structs become
+// components; where two share a name the package name is prefixed. Every
+// operation also documents the ErrorResponse its failures answer with.
+func Document(info Info, servers []string, routes []Route) OpenAPI {
+	g := newSchemaGen()
+	for _, rt := range routes {
+		for _, v := range []any{rt.Request, rt.Response} {
+			if v != nil {
+				g.collect(reflect.TypeOf(v))
+			}
+		}
+	}
+	g.collect(reflect.TypeOf(ErrorResponse{}))
+
+	doc := OpenAPI{
+		OpenAPI: OpenAPIVersion,
+		Info:    info,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas: g.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				"apiToken": {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+	for _, s := range servers {
+		doc.Servers = append(doc.Servers, Server{URL: s})
+	}
+	errRef := g.schema(reflect.TypeOf(ErrorResponse{}))
+
+	seenTags := map[string]bool{}
+	for _, rt := range routes {
+		op := &Operation{
+			OperationID: operationID(rt.Method, rt.Path),
+			Summary:     rt.Summary,
+			Responses:   map[string]Response{},
+		}
+		if rt.Tag != "" {
+			op.Tags = []string{rt.Tag}
+			if !seenTags[rt.Tag] {
+				seenTags[rt.Tag] = true
+				doc.Tags = append(doc.Tags, map[string]string{"name": rt.Tag})
+			}
+		}
+		for _, name := range pathParams(rt.Path) {
+			op.Parameters = append(op.Parameters, Parameter{
+				Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"},
+			})
+		}
+		for _, p := range rt.Query {
+			typ := p.Type
+			if typ == "" {
+				typ = "string"
+			}
+			op.Parameters = append(op.Parameters, Parameter{
+				Name: p.Name, In: "query", Description: p.Description, Schema: &Schema{Type: typ},
+			})
+		}
+		if rt.Request != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: g.schema(reflect.TypeOf(rt.Request))}},
+			}
+		}
+
+		status := rt.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		ok := Response{Description: http.StatusText(status)}
+		switch {
+		case rt.Response != nil:
+			ok.Content = map[string]MediaType{"application/json": {Schema: g.schema(reflect.TypeOf(rt.Response))}}
+		case rt.ContentType != "":
+			ok.Content = map[string]MediaType{rt.ContentType: {Schema: &Schema{Type: "string", Format: "binary"}}}
+		}
+		op.Responses[strconv.Itoa(status)] = ok
+		op.Responses["default"] = Response{
+			Description: "Error",
+			Content:     map[string]MediaType{"application/json": {Schema: errRef}},
+		}
+		if rt.Control {
+			op.Security = []map[string][]string{{"apiToken": {}}}
+		}
+
+		item := doc.Paths[rt.Path]
+		if item == nil {
+			item = PathItem{}
+			doc.Paths[rt.Path] = item
+		}
+		item[strings.ToLower(rt.Method)] = op
+	}
+	return doc
+}
+
+var pathParamRe = regexp.MustCompile(`\{([^}]+)\}`)
+
+// pathParams returns the {name} parameters of path, in order.
+func pathParams(path string) []string {
+	var names []string
+	for _, m := range pathParamRe.FindAllStringSubmatch(path, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// operationID derives a stable camelCase ID such as getApiCapturesFile
+// from a method and path.
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	upper := true
+	for _, r := range path {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// schemaGen turns Go types into schemas the way encoding/json encodes
+// them.
+type schemaGen struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+	// byName counts named structs per component name, to find clashes.
+	byName map[string][]reflect.Type
+}
+
+func newSchemaGen() *schemaGen {
+	return &schemaGen{
+		schemas: map[string]*Schema{},
+		names:   map[reflect.Type]string{},
+		byName:  map[string][]reflect.Type{},
+	}
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawType       = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textType      = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// collect walks t to find every named struct before any is named, so a
+// clash is prefixed on both sides rather than only the second.
+func (g *schemaGen) collect(t reflect.Type) {
+	seen := map[reflect.Type]bool{}
+	var walk func(reflect.Type)
+	walk = func(t reflect.Type) {
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if seen[t] || customJSON(t) {
+			return
+		}
+		seen[t] = true
+		switch t.Kind() {
+		case reflect.Slice, reflect.Array, reflect.Map:
+			walk(t.Elem())
+		case reflect.Struct:
+			if t.Name() != "" {
+				name := componentName(t.Name())
+				if !containsType(g.byName[name], t) {
+					g.byName[name] = append(g.byName[name], t)
+				}
+			}
+			for _, f := range jsonFields(t) {
+				walk(f.typ)
+			}
+		}
+	}
+	walk(t)
+}
+
+func containsType(ts []reflect.Type, t reflect.Type) bool {
+	for _, x := range ts {
+		if x == t {
+			return true
+		}
+	}
+	return false
+}
+
+// componentName exports a Go type name and drops a JSON suffix, so the
+// handlers' passJSON is published as Pass.
+func componentName(name string) string {
+	if trimmed := strings.TrimSuffix(name, "JSON"); trimmed != "" {
+		name = trimmed
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// nameOf returns the component name of a named struct.
+func (g *schemaGen) nameOf(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := componentName(t.Name())
+	if len(g.byName[name]) > 1 {
+		pkg := t.PkgPath()
+		pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+		name = componentName(pkg) + name
+	}
+	g.names[t] = name
+	return name
+}
+
+// customJSON reports whether t marshals itself, so its fields say nothing
+// about its JSON.
+func customJSON(t reflect.Type) bool {
+	if t == timeType || t == rawType {
+		return true
+	}
+	return t.Implements(marshalerType) || t.Implements(textType) ||
+		reflect.PointerTo(t).Implements(marshalerType) || reflect.PointerTo(t).Implements(textType)
+}
+
+// schema returns the schema of t, a $ref for a named struct.
+func (g *schemaGen) schema(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawType:
+		return &Schema{}
+	case customJSON(t):
+		// A type such as config.Secret that marshals to its own kind.
+		if s := basicSchema(t.Kind()); s != nil {
+			return s
+		}
+		if t.Implements(textType) || reflect.PointerTo(t).Implements(textType) {
+			return &Schema{Type: "string"}
+		}
+		return &Schema{}
+	}
+
+	if s := basicSchema(t.Kind()); s != nil {
+		s.Nullable = nullable
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte", Nullable: nullable}
+		}
+		// encoding/json writes a nil slice as null.
+		return &Schema{Type: "array", Items: g.schema(t.Elem()), Nullable: t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem()), Nullable: true}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := g.nameOf(t)
+		if _, ok := g.schemas[name]; !ok {
+			// Reserve the name first so recursive types terminate.
+			g.schemas[name] = nil
+			g.schemas[name] = g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	// interface{} and anything else: any JSON value.
+	return &Schema{}
+}
+
+func basicSchema(k reflect.Kind) *Schema {
+	switch k {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	}
+	return nil
+}
+
+// structSchema describes a struct's JSON object. Fields without omitempty
+// are always present, so they are listed as required.
+func (g *schemaGen) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for _, f := range jsonFields(t) {
+		fs := g.schema(f.typ)
+		if f.asString {
+			fs = &Schema{Type: "string"}
+		}
+		s.Properties[f.name] = fs
+		if !f.omitempty {
+			s.Required = append(s.Required, f.name)
+		}
+	}
+	sort.Strings(s.Required)
+	return s
+}
+
+// jsonField is a struct field as encoding/json sees it.
+type jsonField struct {
+	name      string
+	typ       reflect.Type
+	omitempty bool
+	asString  bool
+}
+
+// jsonFields lists the fields encoding/json writes for t, with embedded
+// structs without a tag flattened into it.
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := f.Type
+		if f.Anonymous && name == "" {
+			et := ft
+			if et.Kind() == reflect.Pointer {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(et)...)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, jsonField{
+			name:      name,
+			typ:       ft,
+			omitempty: strings.Contains(","+opts+",", ",omitempty,") || strings.Contains(","+opts+",", ",omitzero,"),
+			asString:  strings.Contains(","+opts+",", ",string,"),
+		})
+	}
+	return fields
+}
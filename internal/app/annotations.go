@@ -0,0 +1,216 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+const annotationBufCap = 500
+
+// annotation marks a capture window or failure. Time and TimeEnd are Unix
+// milliseconds, matching Grafana's annotation model; TimeEnd is 0 while a
+// capture is still in progress.
+type annotation struct {
+	ID      int      `json:"id"`
+	Time    int64    `json:"time"`
+	TimeEnd int64    `json:"time_end,omitempty"`
+	Title   string   `json:"title"`
+	Text    string   `json:"text"`
+	Tags    []string `json:"tags"`
+}
+
+// annotationLog keeps recent annotations in a ring buffer and tracks the
+// currently open capture region.
+type annotationLog struct {
+	mu     sync.Mutex
+	buf    []annotation
+	nextID int
+	open   int // ID of the in-progress capture region, or 0
+}
+
+// startRegion opens a capture region for satellite. Any region left open by
+// a capture that never reported completion is closed first.
+func (l *annotationLog) startRegion(now time.Time, satellite string, tags []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.open != 0 {
+		l.closeLocked(l.open, now, "", nil)
+	}
+
+	l.nextID++
+	l.appendLocked(annotation{
+		ID:    l.nextID,
+		Time:  now.UnixMilli(),
+		Title: satellite + " capture",
+		Text:  "capture in progress",
+		Tags:  append(append([]string{}, tags...), "capture", satellite),
+	})
+	l.open = l.nextID
+}
+
+// endRegion closes the open capture region and returns it, or false if no
+// region was open.
+func (l *annotationLog) endRegion(now time.Time, text string, extraTags ...string) (annotation, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.open == 0 {
+		return annotation{}, false
+	}
+	id := l.open
+	l.open = 0
+	return l.closeLocked(id, now, text, extraTags)
+}
+
+func (l *annotationLog) closeLocked(id int, now time.Time, text string, extraTags []string) (annotation, bool) {
+	for i := range l.buf {
+		if l.buf[i].ID != id {
+			continue
+		}
+		l.buf[i].TimeEnd = now.UnixMilli()
+		if text != "" {
+			l.buf[i].Text = text
+		}
+		l.buf[i].Tags = append(l.buf[i].Tags, extraTags...)
+		if id == l.open {
+			l.open = 0
+		}
+		return l.buf[i], true
+	}
+	return annotation{}, false
+}
+
+func (l *annotationLog) appendLocked(a annotation) {
+	if len(l.buf) >= annotationBufCap {
+		l.buf = l.buf[1:]
+	}
+	l.buf = append(l.buf, a)
+}
+
+// query returns annotations overlapping [from, to] in Unix milliseconds.
+// A zero bound is treated as open-ended.
+func (l *annotationLog) query(from, to int64) []annotation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := []annotation{}
+	for _, a := range l.buf {
+		end := a.TimeEnd
+		if end == 0 {
+			end = a.Time
+		}
+		if from > 0 && end < from {
+			continue
+		}
+		if to > 0 && a.Time > to {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// onCaptureStart is called by the scheduler when a capture begins.
+func (a *App) onCaptureStart(satellite string) {
+	cfg := a.getConfig()
+	tags := cfg.Annotations.Tags
+	if cfg.Station.Active != "" {
+		tags = append(append([]string{}, tags...), "station:"+cfg.Station.Active)
+	}
+	a.annotations.startRegion(time.Now(), satellite, tags)
+}
+
+// failCaptureAnnotation closes the open capture region as a failure.
+func (a *App) failCaptureAnnotation(satellite string, err error) {
+	ann, ok := a.annotations.endRegion(time.Now(), fmt.Sprintf("%s capture failed: %v", satellite, err), "failure")
+	if ok {
+		a.pushAnnotation(ann)
+	}
+}
+
+// closeCaptureAnnotation finalizes the open capture region after a
+// successful capture.
+func (a *App) closeCaptureAnnotation(satellite string, bytesWritten int64) {
+	ann, ok := a.annotations.endRegion(time.Now(), fmt.Sprintf("%s captured, %s written", satellite, humanBytes(bytesWritten)))
+	if ok {
+		a.pushAnnotation(ann)
+	}
+}
+
+// pushAnnotation sends a completed annotation to Grafana when configured.
+// Delivery runs in its own goroutine so a slow Grafana never delays the
+// scheduler callback that produced it.
+func (a *App) pushAnnotation(ann annotation) {
+	cfg := a.getConfig().Annotations
+	if cfg.GrafanaURL == "" {
+		return
+	}
+	go func() {
+		if err := postGrafanaAnnotation(cfg, ann); err != nil {
+			a.emit("ephemerisd", map[string]any{
+				"type":    "log",
+				"level":   "warn",
+				"message": "grafana annotation failed: " + err.Error(),
+			})
+		}
+	}()
+}
+
+// postGrafanaAnnotation creates a region annotation through Grafana's
+// POST /api/annotations endpoint.
+func postGrafanaAnnotation(cfg config.AnnotationsConfig, ann annotation) error {
+	body := map[string]any{
+		"time": ann.Time,
+		"tags": ann.Tags,
+		"text": ann.Title + ": " + ann.Text,
+	}
+	if ann.TimeEnd != 0 {
+		body["timeEnd"] = ann.TimeEnd
+	}
+	if cfg.DashboardUID != "" {
+		body["dashboardUID"] = cfg.DashboardUID
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	url := strings.TrimRight(cfg.GrafanaURL, "/") + "/api/annotations"
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.GrafanaToken != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.GrafanaToken.Value())
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %s from %s", resp.Status, url)
+	}
+	return nil
+}
+
+// humanBytes renders a byte count for annotation text.
+func humanBytes(b int64) string {
+	switch {
+	case b >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(b)/float64(1<<20))
+	case b >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(b)/float64(1<<10))
+	default:
+		return fmt.Sprintf("%d B", b)
+	}
+}
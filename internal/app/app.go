@@ -5,25 +5,42 @@ package app
 
 import (
 	"context"
-	"log"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
 	"net"
 	"net/http"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/large-farva/ephemeris-engine/internal/config"
-	"github.com/large-farva/ephemeris-engine/internal/demo"
+	"github.com/large-farva/ephemeris-engine/internal/logging"
+	"github.com/large-farva/ephemeris-engine/internal/notify"
+	"github.com/large-farva/ephemeris-engine/internal/plugin"
+	"github.com/large-farva/ephemeris-engine/internal/rules"
 	"github.com/large-farva/ephemeris-engine/internal/scheduler"
+	"github.com/large-farva/ephemeris-engine/internal/script"
+	"github.com/large-farva/ephemeris-engine/internal/store"
+	"github.com/large-farva/ephemeris-engine/internal/tracing"
+	"github.com/large-farva/ephemeris-engine/internal/upload"
 	"github.com/large-farva/ephemeris-engine/internal/ws"
 )
 
 // Options holds everything the App needs from the caller.
 type Options struct {
-	Logger     *log.Logger
+	Logger *slog.Logger
+	// LogOutput, when set, is the output Logger writes to, so the App can
+	// scrub secrets from it and apply the logging levels on reload.
+	LogOutput  *logging.Output
 	Cfg        config.Config
 	Bind       string
 	ConfigPath string
+	// Listener, when set, is served instead of listening on Bind, as the
+	// end-to-end harness does to take a free port.
+	Listener net.Listener
 }
 
 // logEntry is a single log message stored in the ring buffer.
@@ -34,59 +51,114 @@ type logEntry struct {
 	Component string `json:"component"`
 }
 
-// stats tracks aggregate capture statistics.
-type stats struct {
-	mu            sync.Mutex
-	TotalCaptures int            `json:"total_captures"`
-	TotalBytes    int64          `json:"total_bytes"`
-	CapturesBySat map[string]int `json:"captures_by_satellite"`
-	LastCaptureAt string         `json:"last_capture_at,omitempty"`
-}
-
 // App is the top-level daemon process. It manages the HTTP server, the
 // WebSocket event hub, and the active runner (scheduler or demo).
 type App struct {
-	log        *log.Logger
-	cfg        config.Config
-	cfgMu      sync.RWMutex // protects cfg for hot-reload
-	configPath string
-	bind       string
-	server     *http.Server
+	log         *slog.Logger
+	logOut      *logging.Output // nil when the caller built its own logger
+	cfg         config.Config
+	cfgMu       sync.RWMutex // protects cfg for hot-reload
+	cfgLoadedAt time.Time    // when cfg was last loaded, for Last-Modified
+	configPath  string
+	bind        string
+	listener    net.Listener
+	server      *http.Server
 
 	startedAt time.Time
 	state     atomic.Value // current state string (BOOTING, IDLE, etc.)
 
+	// bootID identifies this daemon process and heartbeatSeq numbers its
+	// heartbeats, so clients can tell a restart from a dropped event.
+	bootID       string
+	heartbeatSeq atomic.Int64
+
 	wsHub       *ws.Hub
-	scheduler   *scheduler.Runner // nil in demo mode
-	currentPass atomic.Value      // *scheduler.PassInfo or nil
+	tracer      *tracing.Tracer // nil when tracing is disabled
+	currentPass atomic.Value    // *scheduler.PassInfo or nil
+	changes     changeNotifier  // wakes /api/wait-for-change on state or pass changes
+
+	// The active runner (demo or live scheduler) can be swapped at runtime;
+	// runMu serializes swaps and runCtx is the daemon context runners derive
+	// from.
+	active atomic.Pointer[runner]
+	runMu  sync.Mutex
+	runCtx context.Context
 
 	// Log ring buffer.
 	logBuf    []logEntry
 	logBufMu  sync.Mutex
 	logBufCap int
 
-	captureStats stats
+	history     *store.Store
+	health      *healthTracker
+	annotations annotationLog
+	watchdog    watchdog
+	scrub       scrubber
+	storage     storageMonitor
+	consistency atomic.Pointer[consistencyReport]
+	janitor     janitor
+	uploader    *upload.Uploader
+	gallery     galleryExporter
+	compress    compressor
+	notifier    *notify.Notifier
+	telegram    *notify.Telegram
+	catalogSync catalogSyncer
+	replay      replayer
+	secrets     secretSet
+	shareKeys   shareKeys
+	plugins     *plugin.Manager // nil until Run
+	rules       *rules.Engine   // nil until Run
+	scripts     *script.Engine  // nil until Run
+	mux         http.Handler    // API routes, for plugin and script control calls
 }
 
 // New creates an App in the BOOTING state. Call Run to start serving.
 func New(opts Options) *App {
 	a := &App{
-		log:        opts.Logger,
-		cfg:        opts.Cfg,
-		configPath: opts.ConfigPath,
-		bind:       opts.Bind,
-		startedAt:  time.Now(),
-		wsHub:      ws.NewHub(),
-		logBufCap:  500,
-		captureStats: stats{
-			CapturesBySat: make(map[string]int),
-		},
+		log:         opts.Logger,
+		logOut:      opts.LogOutput,
+		cfg:         opts.Cfg,
+		configPath:  opts.ConfigPath,
+		bind:        opts.Bind,
+		listener:    opts.Listener,
+		startedAt:   time.Now(),
+		cfgLoadedAt: time.Now(),
+		bootID:      newBootID(),
+		wsHub:       ws.NewHub(),
+		logBufCap:   500,
 	}
 	a.logBuf = make([]logEntry, 0, a.logBufCap)
 	a.state.Store("BOOTING")
+
+	// Scrub secret config values from everything the daemon logs.
+	a.secrets.update(opts.Cfg)
+	if a.logOut != nil {
+		a.logOut.SetRedact(a.secrets.redact)
+	}
+	a.applyCatalog(opts.Cfg)
+	a.history = a.openHistory(opts.Cfg.Data.Root)
+	a.health = a.openHealthHistory(opts.Cfg.Data.Root)
+	a.uploader = a.newUploader()
+	a.notifier, a.telegram = a.newNotifier()
+	a.gallery.wake = make(chan struct{}, 1)
+	a.compress.queue = make(chan string, compressQueueSize)
+	a.wsHub.SetLimits(wsLimits(opts.Cfg))
+	a.wsHub.SetLogFilter(a.logEventEnabled)
 	return a
 }
 
+// wsLimits returns the event stream's connection limits from [server].
+// Clients behind a reverse proxy are counted by their forwarded address.
+func wsLimits(cfg config.Config) ws.Limits {
+	return ws.Limits{
+		AllowedOrigins:  cfg.Server.WSAllowedOrigins,
+		MaxClients:      cfg.Server.WSMaxClients,
+		MaxClientsPerIP: cfg.Server.WSMaxClientsPerIP,
+		ClientIP:        clientIP,
+		PongTimeout:     time.Duration(cfg.Server.WSPongTimeoutSeconds) * time.Second,
+	}
+}
+
 // Run starts the HTTP server, WebSocket hub, heartbeat ticker, and either the
 // live scheduler or demo runner. It blocks until the context is cancelled or
 // the server returns an error.
@@ -103,66 +175,143 @@ func (a *App) Run(ctx context.Context) error {
 
 	// Core endpoints.
 	mux.HandleFunc("/healthz", a.handleHealthz)
+	mux.HandleFunc("/readyz", a.handleReadyz)
 	mux.HandleFunc("/api/status", a.handleStatus)
+	mux.HandleFunc("/api/v1/status", a.handleStatus)
 	mux.HandleFunc("/api/version", a.handleVersion)
+	mux.HandleFunc("/api/openapi.json", a.handleOpenAPI)
 	mux.HandleFunc("/api/satellites", a.handleSatellites)
+	mux.HandleFunc("/api/satellite", a.handleSatellite)
+	mux.HandleFunc("/api/satellites/", a.handleSatelliteToggle)
 	mux.HandleFunc("/api/config", a.handleConfig)
 	mux.HandleFunc("/api/passes", a.handlePasses)
+	mux.HandleFunc("/api/passes/", a.handlePassTrack)
+	mux.HandleFunc("/api/blackouts", a.handleBlackouts)
 	mux.HandleFunc("/api/trigger", a.handleTrigger)
 	mux.HandleFunc("/api/tle-refresh", a.handleTLERefresh)
+	mux.HandleFunc("/api/share", a.handleShare)
 	mux.Handle("/ws", a.wsHub.Handler())
 
 	// Data management.
 	mux.HandleFunc("/api/captures", a.handleCaptures)
+	mux.HandleFunc("/api/captures/file", a.handleCaptureFile)
+	mux.HandleFunc("/api/captures/import", a.handleCaptureImport)
+	mux.HandleFunc("/api/captures/tag", a.handleCaptureTag)
+	mux.HandleFunc("/api/captures/trash", a.handleCaptureTrash)
+	mux.HandleFunc("/api/captures/restore", a.handleCaptureRestore)
+	mux.HandleFunc("/api/captures/upload", a.handleCaptureUpload)
+	mux.HandleFunc("/api/scrub", a.handleScrub)
+	mux.HandleFunc("/api/consistency", a.handleConsistency)
+	mux.HandleFunc("/api/retention", a.handleRetention)
+	mux.HandleFunc("/api/retention/run", a.handleRetentionRun)
+	mux.HandleFunc("/api/gallery", a.handleGallery)
+	mux.HandleFunc("/api/gallery/export", a.handleGalleryExport)
+	mux.HandleFunc("/api/notify", a.handleNotify)
+	mux.HandleFunc("/api/notify/test", a.handleNotifyTest)
+	mux.HandleFunc("/api/notify/summary", a.handleNotifySummary)
+	mux.HandleFunc("/api/catalog-sync", a.handleCatalogSync)
 	mux.HandleFunc("/api/config/profiles", a.handleConfigProfiles)
 
 	// Informational.
 	mux.HandleFunc("/api/tle-info", a.handleTLEInfo)
 	mux.HandleFunc("/api/next-pass", a.handleNextPass)
+	mux.HandleFunc("/api/summary", a.handleSummary)
+	mux.HandleFunc("/api/summary.png", a.handleSummaryPNG)
+	mux.HandleFunc("/api/wait-for-change", a.handleWaitForChange)
+	mux.HandleFunc("/api/batch", a.batchHandler(mux))
 	mux.HandleFunc("/api/system", a.handleSystem)
 	mux.HandleFunc("/api/logs", a.handleLogs)
 	mux.HandleFunc("/api/stats", a.handleStats)
+	mux.HandleFunc("/api/history", a.handleHistory)
+	mux.HandleFunc("/api/events/history", a.handleEventHistory)
+	mux.HandleFunc("/api/replay", a.handleReplay)
+	mux.HandleFunc("/api/health/history", a.handleHealthHistory)
+	mux.HandleFunc("/metrics", a.handleMetrics)
+	mux.HandleFunc("/api/annotations", a.handleAnnotations)
+	mux.HandleFunc("/api/traces", a.handleTraces)
+	mux.HandleFunc("/api/plugins", a.handlePlugins)
+	mux.HandleFunc("/api/rules", a.handleRules)
+	mux.HandleFunc("/api/scripts", a.handleScripts)
 
 	// Scheduler controls + reload.
 	mux.HandleFunc("/api/pause", a.handlePause)
 	mux.HandleFunc("/api/resume", a.handleResume)
 	mux.HandleFunc("/api/skip", a.handleSkip)
+	mux.HandleFunc("/api/schedule", a.handleSchedule)
+	mux.HandleFunc("/api/schedule/", a.handleScheduleOverride)
+	mux.HandleFunc("/api/schedule/wake", a.handleWakeSchedule)
+	mux.HandleFunc("/api/resumed", a.handleResumed)
 	mux.HandleFunc("/api/cancel", a.handleCancel)
 	mux.HandleFunc("/api/reload", a.handleReload)
+	mux.HandleFunc("/api/mode", a.handleMode)
+	mux.HandleFunc("/api/station", a.handleStation)
+	mux.HandleFunc("/api/config/persist", a.handleConfigPersist)
+
+	// Runtime diagnostics (gated by [debug]).
+	a.registerDebug(mux)
+	a.mux = mux
 
 	a.server = &http.Server{
 		Addr:              bind,
-		Handler:           mux,
+		Handler:           a.recoverHTTP(a.withBasePath(a.tapHTTP(a.requireAPIToken(mux)))),
 		ReadHeaderTimeout: 5 * time.Second,
 	}
 
-	ln, err := net.Listen("tcp", bind)
-	if err != nil {
-		return err
+	ln := a.listener
+	if ln != nil {
+		bind = ln.Addr().String()
+	} else {
+		var err error
+		if ln, err = net.Listen("tcp", bind); err != nil {
+			return err
+		}
 	}
 
-	a.log.Printf("listening on http://%s", bind)
+	a.log.Info("listening", "url", "http://"+bind)
 
-	go a.wsHub.Run(ctx)
-	a.transition("IDLE")
-	go a.heartbeatLoop(ctx)
-
-	if a.cfg.Demo.Enabled {
-		r := demo.New(a.wsHub)
-		if a.cfg.Demo.IntervalSeconds > 0 {
-			r.Interval = time.Duration(a.cfg.Demo.IntervalSeconds) * time.Second
-		}
-		go r.Run(ctx, a.setStateFromDemo)
-	} else {
-		a.scheduler = scheduler.New(a.wsHub, a.cfg, a.log)
-		a.scheduler.SetPassCallback(a.onPassUpdate)
-		a.scheduler.SetCaptureCallback(a.onCaptureComplete)
-		go a.scheduler.Run(ctx, a.setStateFromScheduler)
+	if err := a.startPublic(ctx); err != nil {
+		_ = ln.Close()
+		return fmt.Errorf("public listener: %w", err)
 	}
 
+	// Background loops are supervised so a panic is reported and the loop
+	// restarted instead of silently stopping.
+	go a.supervise(ctx, "ws hub", a.wsHub.Run)
+	go a.supervise(ctx, "event log", a.eventLogLoop)
+	a.checkConsistency(a.getConfig().Data.Root)
+	a.transition("IDLE")
+	go a.supervise(ctx, "heartbeat", a.heartbeatLoop)
+	go a.supervise(ctx, "health", a.healthLoop)
+	go a.supervise(ctx, "scrub", a.scrubLoop)
+	go a.supervise(ctx, "retention", a.retentionLoop)
+	go a.supervise(ctx, "trash", a.trashLoop)
+	go a.supervise(ctx, "storage", a.storageLoop)
+	go a.supervise(ctx, "debug tap", a.debugTapLoop)
+	go a.supervise(ctx, "upload", a.uploader.Run)
+	go a.supervise(ctx, "gallery", a.galleryLoop)
+	go a.supervise(ctx, "compress", a.compressLoop)
+	go a.supervise(ctx, "notify", a.notifier.Run)
+	go a.supervise(ctx, "daily summary", a.summaryLoop)
+	go a.supervise(ctx, "telegram", func(ctx context.Context) { a.telegram.Poll(ctx, a.botCommand) })
+	go a.supervise(ctx, "catalog sync", a.catalogSyncLoop)
+	a.startPlugins(bind)
+	go a.supervise(ctx, "plugins", a.plugins.Run)
+	a.startRules()
+	go a.supervise(ctx, "rules", a.rules.Run)
+	a.startScripts()
+	go a.supervise(ctx, "scripts", a.scripts.Run)
+
+	a.tracer = tracing.New(a.cfg.Tracing, a.log)
+	go a.tracer.Run(ctx)
+
+	a.runMu.Lock()
+	a.runCtx = ctx
+	a.startRunner(a.cfg.Demo.Enabled)
+	a.runMu.Unlock()
+
 	go func() {
 		<-ctx.Done()
-		a.log.Printf("shutdown requested")
+		a.log.Info("shutdown requested")
 		_ = a.server.Shutdown(context.Background())
 	}()
 
@@ -189,14 +338,23 @@ func (a *App) transition(newState string) {
 
 	// Clear current pass when returning to IDLE.
 	if newState == "IDLE" {
-		a.currentPass.Store((*scheduler.PassInfo)(nil))
+		a.setCurrentPass(nil)
 	}
+	a.changes.notify()
 }
 
+// heartbeatInterval is how often heartbeat events are sent.
+const heartbeatInterval = 10 * time.Second
+
 // heartbeatLoop sends a periodic heartbeat event so clients can detect
-// connectivity and track uptime without polling.
+// connectivity and track uptime without polling. Each heartbeat carries
+// the boot ID and a sequence number that starts at 1, so a client that
+// sees the sequence go backwards or the boot ID change knows the daemon
+// restarted, and one that sees a gap knows it missed events; either way
+// it should refresh its state. server_time_ms is the daemon's wall clock
+// for estimating clock drift.
 func (a *App) heartbeatLoop(ctx context.Context) {
-	t := time.NewTicker(10 * time.Second)
+	t := time.NewTicker(heartbeatInterval)
 	defer t.Stop()
 
 	for {
@@ -204,17 +362,29 @@ func (a *App) heartbeatLoop(ctx context.Context) {
 		case <-ctx.Done():
 			return
 		case <-t.C:
+			now := time.Now()
 			ev := map[string]any{
-				"type":           "heartbeat",
-				"ts":             time.Now().UTC().Format(time.RFC3339Nano),
-				"uptime_seconds": int64(time.Since(a.startedAt).Seconds()),
-				"state":          a.state.Load().(string),
+				"type":             "heartbeat",
+				"ts":               now.UTC().Format(time.RFC3339Nano),
+				"seq":              a.heartbeatSeq.Add(1),
+				"boot_id":          a.bootID,
+				"server_time_ms":   now.UnixMilli(),
+				"interval_seconds": int(heartbeatInterval / time.Second),
+				"uptime_seconds":   int64(time.Since(a.startedAt).Seconds()),
+				"state":            a.state.Load().(string),
 			}
 			a.wsHub.BroadcastJSON(ev)
 		}
 	}
 }
 
+// newBootID returns a random identifier for this daemon process.
+func newBootID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
 func (a *App) setStateFromDemo(newState string) {
 	a.transition(newState)
 }
@@ -225,17 +395,45 @@ func (a *App) setStateFromScheduler(newState string) {
 
 // onPassUpdate is called by the scheduler when tracking a pass.
 func (a *App) onPassUpdate(info *scheduler.PassInfo) {
-	a.currentPass.Store(info)
+	a.setCurrentPass(info)
 }
 
-// onCaptureComplete is called when a capture finishes, to update stats.
+// onCaptureComplete is called when a capture finishes, to close the
+// capture's annotation region. The pass history is updated by
+// onPassOutcome.
 func (a *App) onCaptureComplete(satellite string, bytesWritten int64) {
-	a.captureStats.mu.Lock()
-	defer a.captureStats.mu.Unlock()
-	a.captureStats.TotalCaptures++
-	a.captureStats.TotalBytes += bytesWritten
-	a.captureStats.CapturesBySat[satellite]++
-	a.captureStats.LastCaptureAt = time.Now().UTC().Format(time.RFC3339)
+	a.closeCaptureAnnotation(satellite, bytesWritten)
+}
+
+// onCaptureProcessed is called by the scheduler once a capture has been
+// recorded and decoded. A recording to be compressed per data.compress
+// goes to the compress loop, which finishes it once compressed; any other
+// is finished here.
+func (a *App) onCaptureProcessed(path string) {
+	if !a.queueCompression(path) {
+		a.captureReady(path)
+	}
+}
+
+// captureReady sends the result of the capture at path, and queues it for
+// upload and the gallery for export.
+func (a *App) captureReady(path string) {
+	cfg := a.getConfig()
+	a.notifyPass(path)
+	a.requestGallery("decode")
+	if !cfg.Upload.Enabled {
+		return
+	}
+	if err := a.queueUpload(cfg.Data.Root, filepath.Base(path)); err != nil {
+		a.log.Warn("could not queue upload", "component", "upload", "file", filepath.Base(path), "err", err)
+	}
+}
+
+// onCaptureFailed is called by the scheduler when a capture fails.
+func (a *App) onCaptureFailed(satellite string, err error) {
+	a.noteWrite(err)
+	a.failCaptureAnnotation(satellite, err)
+	a.notifyFailure(satellite, err)
 }
 
 // appendLog adds a log entry to the ring buffer.
@@ -248,6 +446,25 @@ func (a *App) appendLog(entry logEntry) {
 	a.logBuf = append(a.logBuf, entry)
 }
 
+// logEventEnabled reports whether a log event of component at level is
+// kept, in /api/logs and on the WebSocket. Only the components listed in
+// [logging.components] are filtered; log events of the others are all
+// kept, whatever logging.level says.
+func (a *App) logEventEnabled(component, level string) bool {
+	if a.logOut == nil {
+		return true
+	}
+	min, ok := a.logOut.ComponentLevel(component)
+	if !ok {
+		return true
+	}
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return true
+	}
+	return l >= min
+}
+
 // getConfig returns the current config (thread-safe for reload).
 func (a *App) getConfig() config.Config {
 	a.cfgMu.RLock()
@@ -261,11 +478,17 @@ func (a *App) emit(component string, payload map[string]any) {
 	ts := time.Now().UTC().Format(time.RFC3339Nano)
 	payload["ts"] = ts
 	payload["component"] = component
+	if msg, ok := payload["message"].(string); ok {
+		payload["message"] = a.secrets.redact(msg)
+	}
 	a.wsHub.BroadcastJSON(payload)
 
 	// Buffer log-type events for the /api/logs endpoint.
 	if t, ok := payload["type"].(string); ok && t == "log" {
 		level, _ := payload["level"].(string)
+		if !a.logEventEnabled(component, level) {
+			return
+		}
 		msg, _ := payload["message"].(string)
 		a.appendLog(logEntry{
 			TS:        ts,
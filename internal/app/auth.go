@@ -0,0 +1,39 @@
+package app
+
+import "net/http"
+
+// readOnlyRequest reports whether r cannot change daemon state. A batch is
+// a POST, but only runs GETs against the same mux.
+func readOnlyRequest(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead ||
+		(r.Method == http.MethodPost && r.URL.Path == "/api/batch")
+}
+
+// requireAPIToken guards the control endpoints: when server.api_tokens is
+// set, a request that can change state must carry one of them as a bearer
+// token. Reads, including /healthz and /ws, are left open. Tokens are
+// checked against the current config, so a reload rotates them.
+func (a *App) requireAPIToken(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens := a.getConfig().Server.APITokens
+		if len(tokens) == 0 || readOnlyRequest(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		got := bearerToken(r)
+		ok := false
+		for _, t := range tokens {
+			// Compare against every token so timing does not reveal
+			// which one matched.
+			if tokenMatches(got, t.Value()) {
+				ok = true
+			}
+		}
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ephemerisd"`)
+			jsonError(w, "invalid or missing API token (see server.api_tokens; ephctl --token)", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
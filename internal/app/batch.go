@@ -0,0 +1,161 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// maxBatchRequests caps how many operations one batch may carry.
+const maxBatchRequests = 20
+
+// batchExcluded are paths a batch may not call: streams and long-polls
+// would hold the whole batch open, and batches do not nest.
+var batchExcluded = map[string]bool{
+	"/ws":                  true,
+	"/api/batch":           true,
+	"/api/wait-for-change": true,
+}
+
+// batchRequest is the body of POST /api/batch.
+type batchRequest struct {
+	Requests []batchOp `json:"requests"`
+}
+
+// batchOp is one GET in a batch, by path and query.
+type batchOp struct {
+	Path string `json:"path"`
+}
+
+// batchResponses is the reply to a batch, in request order.
+type batchResponses struct {
+	Responses []batchResponse `json:"responses"`
+}
+
+// batchResponse is the result of one operation in a batch. JSON bodies are
+// embedded as-is; anything else is returned as a string.
+type batchResponse struct {
+	Path   string          `json:"path"`
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// batchRecorder captures a handler's response in memory.
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *batchRecorder) Header() http.Header { return rec.header }
+
+func (rec *batchRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.body.Write(b)
+}
+
+func (rec *batchRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+}
+
+// batchHandler returns POST /api/batch for mux: it runs a list of GET
+// requests against mux concurrently and returns every response in one
+// reply, in request order. This turns a dashboard's initial load into a
+// single round trip on high-latency links.
+//
+//	{"requests": [{"path": "/api/status"}, {"path": "/api/passes?count=3"}]}
+func (a *App) batchHandler(mux http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req batchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			jsonError(w, "bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(req.Requests) == 0 {
+			jsonError(w, "requests must not be empty", http.StatusBadRequest)
+			return
+		}
+		if len(req.Requests) > maxBatchRequests {
+			jsonError(w, fmt.Sprintf("at most %d requests per batch", maxBatchRequests), http.StatusBadRequest)
+			return
+		}
+
+		targets := make([]*url.URL, len(req.Requests))
+		for i, op := range req.Requests {
+			u, err := url.Parse(op.Path)
+			if err != nil || u.IsAbs() || u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+				jsonError(w, fmt.Sprintf("requests[%d]: path must be an absolute path like /api/status", i), http.StatusBadRequest)
+				return
+			}
+			if batchExcluded[u.Path] {
+				jsonError(w, fmt.Sprintf("requests[%d]: %s cannot be batched", i, u.Path), http.StatusBadRequest)
+				return
+			}
+			targets[i] = u
+		}
+
+		responses := make([]batchResponse, len(targets))
+		var wg sync.WaitGroup
+		for i, u := range targets {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				responses[i] = a.runBatchOp(mux, r, u, req.Requests[i].Path)
+			}()
+		}
+		wg.Wait()
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(batchResponses{Responses: responses})
+	}
+}
+
+// runBatchOp serves one GET through mux, carrying over the outer request's
+// headers (so a bearer token still applies) and client address. Conditional
+// headers are dropped: each operation always returns its full body.
+func (a *App) runBatchOp(mux http.Handler, outer *http.Request, u *url.URL, path string) batchResponse {
+	sub, err := http.NewRequestWithContext(outer.Context(), http.MethodGet, u.String(), nil)
+	if err != nil {
+		return batchResponse{Path: path, Status: http.StatusBadRequest, Body: jsonString(err.Error())}
+	}
+	sub.Header = outer.Header.Clone()
+	for _, h := range []string{"Content-Type", "Content-Length", "If-None-Match", "If-Modified-Since"} {
+		sub.Header.Del(h)
+	}
+	sub.RemoteAddr = outer.RemoteAddr
+	sub.Host = outer.Host
+	sub.TLS = outer.TLS
+
+	// Operations run on their own goroutines, outside the server's panic
+	// recovery, so each one gets its own.
+	rec := &batchRecorder{header: make(http.Header)}
+	a.recoverHTTP(mux).ServeHTTP(rec, sub)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+
+	body := bytes.TrimSpace(rec.body.Bytes())
+	if !strings.HasPrefix(rec.header.Get("Content-Type"), "application/json") || !json.Valid(body) {
+		body = jsonString(string(body))
+	}
+	return batchResponse{Path: path, Status: rec.status, Body: body}
+}
+
+// jsonString encodes s as a JSON string value.
+func jsonString(s string) json.RawMessage {
+	b, _ := json.Marshal(s)
+	return b
+}
@@ -0,0 +1,75 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+// blackoutsResponse is the body of GET /api/blackouts.
+type blackoutsResponse struct {
+	Timezone string           `json:"timezone"`
+	Active   bool             `json:"active"` // some window is in force now
+	Windows  []blackoutWindow `json:"windows"`
+}
+
+// blackoutWindow is one [[schedule.blackout]] entry and when it is next in
+// force: now, if Active, until End.
+type blackoutWindow struct {
+	Name   string   `json:"name"`
+	Window string   `json:"window"`
+	Days   []string `json:"days"`
+	Active bool     `json:"active"`
+	Start  string   `json:"start"` // RFC 3339, in the station's zone
+	End    string   `json:"end"`
+}
+
+// handleBlackouts lists the blackout windows and which are in force.
+func (a *App) handleBlackouts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cfg := a.getConfig()
+	loc := cfg.Station.Location()
+	now := time.Now().In(loc)
+	resp := blackoutsResponse{Timezone: loc.String(), Windows: []blackoutWindow{}}
+	for _, bc := range cfg.Schedule.Blackout {
+		b, err := bc.Parse()
+		if err != nil {
+			continue
+		}
+		days := bc.Days
+		if days == nil {
+			days = []string{}
+		}
+		start, end := b.Next(now)
+		win := blackoutWindow{
+			Name:   b.Name,
+			Window: bc.Window,
+			Days:   days,
+			Active: !start.After(now),
+			Start:  start.Format(time.RFC3339),
+			End:    end.Format(time.RFC3339),
+		}
+		resp.Active = resp.Active || win.Active
+		resp.Windows = append(resp.Windows, win)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// passBlackout returns the name of the blackout window that keeps p out of
+// the schedule, or "".
+func passBlackout(cfg config.Config, p predict.Pass) string {
+	loc := cfg.Station.Location()
+	for _, b := range cfg.Schedule.Blackouts() {
+		if _, _, ok := b.Overlaps(p.RecordAOS.In(loc), p.RecordLOS); ok {
+			return b.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// writeJSONCached encodes v and serves it with an ETag derived from the
+// body, so pollers can send If-None-Match and get 304 Not Modified when
+// nothing changed. A non-zero lastMod also enables If-Modified-Since.
+func writeJSONCached(w http.ResponseWriter, r *http.Request, v any, lastMod time.Time) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSONTagged(w, r, body, `"`+shortHash(body)+`"`, lastMod)
+}
+
+// weakETag builds a weak ETag from the values that identify a resource's
+// version.
+func weakETag(parts ...any) string {
+	return `W/"` + shortHash([]byte(fmt.Sprint(parts...))) + `"`
+}
+
+// shortHash returns the first 64 bits of the SHA-256 of b, in hex.
+func shortHash(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:8])
+}
+
+// writeJSONTagged serves a pre-encoded JSON body under the given ETag. Use
+// it with a weak ETag (W/"...") when the body carries values, such as
+// ages, that change without the underlying resource changing.
+func writeJSONTagged(w http.ResponseWriter, r *http.Request, body []byte, etag string, lastMod time.Time) {
+	h := w.Header()
+	h.Set("Content-Type", "application/json")
+	h.Set("ETag", etag)
+	// Clients may keep a copy but must revalidate before each use.
+	h.Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, "", lastMod, bytes.NewReader(append(body, '\n')))
+}
@@ -0,0 +1,322 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/api"
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/satnogs"
+)
+
+const (
+	// catalogSyncCheckInterval is how often catalogSyncLoop checks whether
+	// a sync is due.
+	catalogSyncCheckInterval = 10 * time.Minute
+	// catalogSyncFile keeps the last sync under data.root, so synced
+	// frequencies survive a restart without the network.
+	catalogSyncFile = ".catalog-sync.json"
+	// satnogsTimeout bounds each SatNOGS DB request.
+	satnogsTimeout = 15 * time.Second
+)
+
+// catalogSyncEntry is what one catalog sync learned about one satellite.
+// A failed lookup keeps the previous result along with the error.
+type catalogSyncEntry struct {
+	Satellite   string `json:"satellite"`
+	NoradID     int    `json:"norad_id"`
+	Name        string `json:"satnogs_name,omitempty"`
+	Status      string `json:"status,omitempty"`
+	FreqHz      int    `json:"freq_hz,omitempty"` // active downlink in the satellite's mode
+	Transmitter string `json:"transmitter,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// catalogSyncReport summarizes one catalog sync.
+type catalogSyncReport struct {
+	SyncedAt   string             `json:"synced_at"`
+	Source     string             `json:"source"` // "schedule" or "api"
+	Satellites []catalogSyncEntry `json:"satellites"`
+}
+
+// catalogSyncer tracks the SatNOGS DB catalog sync.
+type catalogSyncer struct {
+	mu      sync.Mutex
+	running bool
+	last    *catalogSyncReport
+}
+
+func (s *catalogSyncer) report() (*catalogSyncReport, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last, s.running
+}
+
+// catalogSyncLoop syncs the catalog with SatNOGS DB whenever
+// catalog.sync_hours have passed since the last sync.
+func (a *App) catalogSyncLoop(ctx context.Context) {
+	a.loadCatalogSync()
+
+	t := time.NewTicker(catalogSyncCheckInterval)
+	defer t.Stop()
+	for {
+		if due, ok := a.nextCatalogSync(); ok && !time.Now().Before(due) {
+			a.runCatalogSync(ctx, "schedule")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// nextCatalogSync returns when the next sync is due; ok is false when
+// catalog sync is off.
+func (a *App) nextCatalogSync() (time.Time, bool) {
+	cfg := a.getConfig()
+	if !cfg.Catalog.Sync {
+		return time.Time{}, false
+	}
+	last, _ := a.catalogSync.report()
+	if last == nil {
+		return time.Time{}, true
+	}
+	synced, err := time.Parse(time.RFC3339, last.SyncedAt)
+	if err != nil {
+		return time.Time{}, true
+	}
+	return synced.Add(time.Duration(cfg.Catalog.SyncHours) * time.Hour), true
+}
+
+// runCatalogSync looks every catalog satellite up in SatNOGS DB and
+// applies what it finds. A satellite whose active transmitter in its mode
+// has moved is retuned, and the schedule recomputed; one SatNOGS DB no
+// longer lists as alive is warned about once. It returns false without
+// doing anything if a sync is already running.
+func (a *App) runCatalogSync(ctx context.Context, source string) bool {
+	a.catalogSync.mu.Lock()
+	if a.catalogSync.running {
+		a.catalogSync.mu.Unlock()
+		return false
+	}
+	a.catalogSync.running = true
+	prev := a.catalogSync.last
+	a.catalogSync.mu.Unlock()
+
+	cfg := a.getConfig()
+	previous := map[int]catalogSyncEntry{}
+	if prev != nil {
+		for _, e := range prev.Satellites {
+			previous[e.NoradID] = e
+		}
+	}
+
+	before := capture.Catalog()
+	rep := &catalogSyncReport{Source: source, Satellites: []catalogSyncEntry{}}
+	failed := 0
+	for _, sat := range before {
+		if ctx.Err() != nil {
+			break
+		}
+		entry := catalogSyncEntry{Satellite: sat.Name, NoradID: sat.NoradID}
+		found, err := satnogs.Lookup(cfg.Catalog.SatNOGSURL, sat.NoradID, satnogsTimeout)
+		if err != nil {
+			failed++
+			old := previous[sat.NoradID]
+			entry.Name, entry.Status, entry.FreqHz, entry.Transmitter = old.Name, old.Status, old.FreqHz, old.Transmitter
+			entry.Error = err.Error()
+			rep.Satellites = append(rep.Satellites, entry)
+			continue
+		}
+		entry.Name, entry.Status = found.Name, found.Status
+		configured := sat.Freq
+		if sat.CatalogFreq != 0 {
+			configured = sat.CatalogFreq
+		}
+		if tx, ok := found.Downlink(sat.Mode, configured); ok {
+			entry.FreqHz, entry.Transmitter = tx.DownlinkHz, tx.Description
+		}
+		rep.Satellites = append(rep.Satellites, entry)
+
+		if entry.Status != "" && entry.Status != "alive" && entry.Status != previous[sat.NoradID].Status {
+			a.emit("ephemerisd", map[string]any{
+				"type":    "log",
+				"level":   "warn",
+				"message": fmt.Sprintf("SatNOGS DB lists %s as %s", sat.Name, entry.Status),
+			})
+		}
+	}
+	if ctx.Err() != nil {
+		a.catalogSync.mu.Lock()
+		a.catalogSync.running = false
+		a.catalogSync.mu.Unlock()
+		return true
+	}
+	rep.SyncedAt = time.Now().UTC().Format(time.RFC3339)
+
+	a.catalogSync.mu.Lock()
+	a.catalogSync.last = rep
+	a.catalogSync.running = false
+	a.catalogSync.mu.Unlock()
+	a.saveCatalogSync(cfg.Data.Root, rep)
+	a.applySynced(cfg.Catalog.Sync)
+
+	changed := []map[string]any{}
+	after := capture.Catalog()
+	for i, sat := range after {
+		if i >= len(before) || before[i].NoradID != sat.NoradID || before[i].Freq == sat.Freq {
+			continue
+		}
+		changed = append(changed, map[string]any{
+			"satellite":   sat.Name,
+			"freq_hz":     sat.Freq,
+			"old_freq_hz": before[i].Freq,
+		})
+		a.emit("ephemerisd", map[string]any{
+			"type":  "log",
+			"level": "info",
+			"message": fmt.Sprintf("%s now records at %.4f MHz (was %.4f MHz), following SatNOGS DB",
+				sat.Name, float64(sat.Freq)/1e6, float64(before[i].Freq)/1e6),
+		})
+	}
+	if len(changed) > 0 {
+		if s := a.sched(); s != nil {
+			s.Reschedule()
+		}
+	}
+
+	level := "info"
+	msg := fmt.Sprintf("catalog synced with SatNOGS DB: %d satellites, %d retuned", len(rep.Satellites)-failed, len(changed))
+	if failed > 0 {
+		level = "warn"
+		msg += fmt.Sprintf(", %d lookups failed", failed)
+	}
+	a.log.Info(msg, "component", "catalog")
+	a.emit("ephemerisd", map[string]any{
+		"type":       "catalog_synced",
+		"satellites": len(rep.Satellites),
+		"failed":     failed,
+		"changed":    changed,
+		"source":     source,
+	})
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   level,
+		"message": msg,
+	})
+	return true
+}
+
+// applySynced hands the last sync's results to the catalog, or drops them
+// when sync is off.
+func (a *App) applySynced(enabled bool) {
+	last, _ := a.catalogSync.report()
+	if !enabled || last == nil {
+		capture.SetSynced(nil)
+		return
+	}
+	m := make(map[int]capture.Synced, len(last.Satellites))
+	for _, e := range last.Satellites {
+		m[e.NoradID] = capture.Synced{Status: e.Status, Freq: e.FreqHz}
+	}
+	capture.SetSynced(m)
+}
+
+// loadCatalogSync restores the last catalog sync from data.root.
+func (a *App) loadCatalogSync() {
+	cfg := a.getConfig()
+	b, err := os.ReadFile(filepath.Join(cfg.Data.Root, catalogSyncFile))
+	if err != nil {
+		return
+	}
+	var rep catalogSyncReport
+	if err := json.Unmarshal(b, &rep); err != nil {
+		a.log.Warn("ignoring saved sync", "component", "catalog", "file", catalogSyncFile, "err", err)
+		return
+	}
+	a.catalogSync.mu.Lock()
+	if a.catalogSync.last == nil {
+		a.catalogSync.last = &rep
+	}
+	a.catalogSync.mu.Unlock()
+	a.applySynced(cfg.Catalog.Sync)
+}
+
+func (a *App) saveCatalogSync(root string, rep *catalogSyncReport) {
+	b, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return
+	}
+	path := filepath.Join(root, catalogSyncFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		a.log.Warn("could not save sync", "component", "catalog", "err", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		a.log.Warn("could not save sync", "component", "catalog", "err", err)
+	}
+}
+
+// catalogSyncResponse is the body of GET /api/catalog-sync.
+type catalogSyncResponse struct {
+	Enabled    bool               `json:"enabled"`
+	SatNOGSURL string             `json:"satnogs_url"`
+	SyncHours  int                `json:"sync_hours"`
+	Running    bool               `json:"running"`
+	Last       *catalogSyncReport `json:"last"`
+	NextDue    string             `json:"next_due,omitempty"` // RFC 3339
+}
+
+// handleCatalogSync shows the last SatNOGS DB catalog sync and when the
+// next is due, or starts one now on POST.
+func (a *App) handleCatalogSync(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if !a.getConfig().Catalog.Sync {
+			jsonError(w, "catalog sync is off; set sync = true under [catalog]", http.StatusConflict)
+			return
+		}
+		if _, running := a.catalogSync.report(); running {
+			jsonError(w, "a catalog sync is already running", http.StatusConflict)
+			return
+		}
+		a.runMu.Lock()
+		ctx := a.runCtx
+		a.runMu.Unlock()
+		go a.runCatalogSync(ctx, "api")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.OKResponse{OK: true, Message: "catalog sync started"})
+		return
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	cfg := a.getConfig()
+	last, running := a.catalogSync.report()
+	resp := catalogSyncResponse{
+		Enabled:    cfg.Catalog.Sync,
+		SatNOGSURL: cfg.Catalog.SatNOGSURL,
+		SyncHours:  cfg.Catalog.SyncHours,
+		Running:    running,
+		Last:       last,
+	}
+	if due, ok := a.nextCatalogSync(); ok {
+		if due.Before(time.Now()) {
+			due = time.Now()
+		}
+		resp.NextDue = due.UTC().Format(time.RFC3339)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
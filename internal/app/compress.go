@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+)
+
+// compressQueueSize is how many decoded captures may wait for the compress
+// loop. One that finds the queue full is kept as a WAV.
+const compressQueueSize = 16
+
+// compressor hands decoded captures from the scheduler to the compress
+// loop, so transcoding never holds up the next pass.
+type compressor struct {
+	queue   chan string
+	pending atomic.Int32 // queued or being compressed
+}
+
+// queueCompression hands the capture at path to the compress loop if
+// data.compress wants it compressed, and reports whether it did.
+func (a *App) queueCompression(path string) bool {
+	if a.getConfig().Data.Compress != capture.CompressionFLAC || filepath.Ext(path) != ".wav" {
+		return false
+	}
+	a.compress.pending.Add(1)
+	select {
+	case a.compress.queue <- path:
+		return true
+	default:
+		a.compress.pending.Add(-1)
+		a.log.Warn("compression queue is full; keeping the WAV", "component", "capture", "file", filepath.Base(path))
+		return false
+	}
+}
+
+// compressLoop compresses the captures queued by queueCompression and
+// then finishes them, until ctx is cancelled. Captures still queued then
+// are finished as WAVs.
+func (a *App) compressLoop(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case path := <-a.compress.queue:
+					a.captureReady(path)
+					a.compress.pending.Add(-1)
+				default:
+					return
+				}
+			}
+		case path := <-a.compress.queue:
+			if path = a.compressCapture(path); path != "" {
+				a.captureReady(path)
+			}
+			a.compress.pending.Add(-1)
+		}
+	}
+}
+
+// compressCapture transcodes the decoded capture at path per
+// data.compress, moves its history record to the new file, and returns
+// the path the capture is now at. That is path itself when compression is
+// off or fails; a failure is logged and the WAV kept. It is "" when the
+// capture was deleted while it was being compressed.
+func (a *App) compressCapture(path string) string {
+	cfg := a.getConfig()
+	if cfg.Data.Compress != capture.CompressionFLAC || filepath.Ext(path) != ".wav" {
+		return path
+	}
+	name := filepath.Base(path)
+	start := time.Now()
+	out, err := a.replaceWithFLAC(path, cfg.Data.FsyncOnFinalize)
+	if errors.Is(err, fs.ErrNotExist) && out == "" {
+		a.log.Info("capture was deleted while it was compressed; FLAC discarded", "component", "capture", "file", name)
+		return ""
+	}
+	if out == "" {
+		a.log.Warn("could not compress capture", "component", "capture", "file", name, "err", err)
+		a.emit("ephemerisd", map[string]any{
+			"type":    "log",
+			"level":   "warn",
+			"message": fmt.Sprintf("could not compress %s: %v; WAV kept", name, err),
+		})
+		return path
+	}
+	if err != nil {
+		a.log.Warn("compressed capture but could not finish replacing the WAV", "component", "capture", "file", name, "err", err)
+	}
+
+	meta, _ := capture.ReadMetadata(out)
+	a.emit("ephemerisd", map[string]any{
+		"type":  "log",
+		"level": "info",
+		"message": fmt.Sprintf("compressed %s to %s, %s to %s, in %s", name, filepath.Base(out),
+			humanBytes(meta.WAVBytes), humanBytes(meta.Bytes), time.Since(start).Round(100*time.Millisecond)),
+	})
+	return out
+}
+
+// replaceWithFLAC encodes the WAV at path and swaps it for the FLAC, with
+// its history record, under trashMu. The WAV is checked for again there,
+// so a capture deleted while it was encoded stays deleted.
+func (a *App) replaceWithFLAC(path string, sync bool) (string, error) {
+	tmp, err := capture.EncodeFLAC(path, sync)
+	if err != nil {
+		return "", err
+	}
+	trashMu.Lock()
+	defer trashMu.Unlock()
+	out, err := capture.ReplaceWithFLAC(path, tmp, sync)
+	if out == "" {
+		return "", err
+	}
+	meta, _ := capture.ReadMetadata(out)
+	if _, herr := a.history.ReplaceFile(filepath.Base(path), filepath.Base(out), meta.Bytes); herr != nil {
+		a.log.Warn("could not record compressed capture", "component", "history", "file", filepath.Base(out), "err", herr)
+	}
+	return out, err
+}
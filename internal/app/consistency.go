@@ -0,0 +1,263 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/store"
+)
+
+// Kinds of consistencyIssue.
+const (
+	// issueTempFile is a temp file left by an interrupted write, such as
+	// a copy out of staging or a state file; it is removed.
+	issueTempFile = "temp_file"
+	// issueStagedRecording is a recording left in data.staging by a
+	// crash; it is moved into data.root.
+	issueStagedRecording = "staged_recording"
+	// issueUnfinishedRecording is a recording cut short by a crash before
+	// its header was finalized; the header is repaired.
+	issueUnfinishedRecording = "unfinished_recording"
+	// issueOrphanFile is a capture on disk the history does not know; it
+	// is added to the history.
+	issueOrphanFile = "orphan_file"
+	// issueMissingFile is a capture in the history whose file is gone; it
+	// is marked deleted.
+	issueMissingFile = "missing_file"
+	// issueOrphanSidecar is a sidecar whose capture is gone. It is left
+	// in place for the operator to look at.
+	issueOrphanSidecar = "orphan_sidecar"
+	// issueInterruptedCompression is a recording left both as a WAV and
+	// as a FLAC by a crash while it was compressed; whichever the sidecar
+	// does not describe is removed.
+	issueInterruptedCompression = "interrupted_compression"
+)
+
+// consistencyReport is the result of the consistency check run at startup,
+// before the scheduler starts, between the history and the files under
+// data.root.
+type consistencyReport struct {
+	CheckedAt string             `json:"checked_at"`
+	Captures  int                `json:"captures"` // on disk after repairs
+	Repaired  int                `json:"repaired"`
+	Issues    []consistencyIssue `json:"issues"`
+}
+
+// consistencyIssue is one discrepancy the check found.
+type consistencyIssue struct {
+	Kind     string `json:"kind"`
+	File     string `json:"file"`
+	Detail   string `json:"detail"`
+	Repaired bool   `json:"repaired"`
+}
+
+// add records an issue, counting it as repaired when err is nil.
+func (rep *consistencyReport) add(kind, file, detail string, err error) {
+	issue := consistencyIssue{Kind: kind, File: file, Detail: detail, Repaired: err == nil}
+	if err != nil {
+		issue.Detail += ": " + err.Error()
+	} else {
+		rep.Repaired++
+	}
+	rep.Issues = append(rep.Issues, issue)
+}
+
+// checkConsistency reconciles the history with the capture files under
+// root after a restart, repairs what it can, and reports the rest with a
+// consistency event. It must run before the scheduler starts, while no
+// capture can be writing to root or data.staging.
+func (a *App) checkConsistency(root string) {
+	rep := &consistencyReport{
+		CheckedAt: time.Now().UTC().Format(time.RFC3339),
+		Issues:    []consistencyIssue{},
+	}
+
+	// Temp files from a write the crash interrupted. The file they were
+	// to replace, or the staged recording they copied, is intact.
+	temps, _ := filepath.Glob(filepath.Join(root, "*.tmp"))
+	for _, path := range temps {
+		rep.add(issueTempFile, filepath.Base(path), "removed", os.Remove(path))
+	}
+
+	if staging := a.getConfig().Data.Staging; staging != "" && filepath.Clean(staging) != filepath.Clean(root) {
+		staged, _ := filepath.Glob(filepath.Join(staging, "*.wav"))
+		for _, path := range staged {
+			_, err := capture.RecoverStaged(path, root)
+			if errors.Is(err, fs.ErrExist) {
+				err = fmt.Errorf("%s already has a file of that name", root)
+			}
+			rep.add(issueStagedRecording, filepath.Base(path), "moved from "+staging, err)
+		}
+	}
+
+	recordings := settleCompressions(rep, capture.Recordings(root))
+	onDisk := make(map[string]bool, len(recordings))
+	for _, path := range recordings {
+		name := filepath.Base(path)
+		onDisk[name] = true
+
+		// Finished recordings have a sidecar; one without may have been
+		// cut short, and left with the placeholder header.
+		if _, err := os.Stat(capture.MetadataPath(path)); errors.Is(err, fs.ErrNotExist) && filepath.Ext(path) == ".wav" {
+			if fixed, err := capture.RepairWAVHeader(path); fixed || err != nil {
+				rep.add(issueUnfinishedRecording, name, "header repaired", err)
+			}
+		}
+
+		has, err := a.history.HasFile(name)
+		if err != nil {
+			rep.add(issueOrphanFile, name, "not checked against the history", err)
+			continue
+		}
+		if has {
+			continue
+		}
+		if ok, err := a.history.MarkRestored(name); ok || err != nil {
+			rep.add(issueOrphanFile, name, "history entry marked present again", err)
+			continue
+		}
+		// A crash after a recording was compressed but before its history
+		// entry was moved leaves the entry on the WAV.
+		if wav := strings.TrimSuffix(name, filepath.Ext(name)) + ".wav"; wav != name {
+			info, err := os.Stat(path)
+			if err == nil {
+				var ok bool
+				if ok, err = a.history.ReplaceFile(wav, name, info.Size()); ok || err != nil {
+					rep.add(issueOrphanFile, name, "history entry moved from "+wav, err)
+					continue
+				}
+			}
+		}
+		rep.add(issueOrphanFile, name, "added to the history", a.recordCaptureFile(path, store.SourceBackfill))
+	}
+	rep.Captures = len(onDisk)
+
+	recs, err := a.history.Captures()
+	if err != nil {
+		rep.add(issueMissingFile, historyFile, "history not checked for missing files", err)
+	}
+	var missing []string
+	for _, rec := range recs {
+		if !onDisk[rec.File] {
+			missing = append(missing, rec.File)
+		}
+	}
+	// A data root whose every capture is gone is more likely a disk that
+	// did not mount than captures deleted by hand; the history is kept for
+	// when it is back.
+	unmounted := len(missing) > 1 && len(onDisk) == 0
+	for _, name := range missing {
+		if unmounted {
+			rep.add(issueMissingFile, name, "not marked deleted", fmt.Errorf("no capture in %s is on disk; is it mounted?", root))
+			continue
+		}
+		_, err := a.history.MarkDeleted(name)
+		rep.add(issueMissingFile, name, "marked deleted in the history", err)
+	}
+
+	sidecars, _ := filepath.Glob(filepath.Join(root, "*.json"))
+	for _, path := range sidecars {
+		name := filepath.Base(path)
+		if strings.HasPrefix(name, ".") {
+			continue // the daemon's own state files
+		}
+		base := strings.TrimSuffix(name, ".json")
+		if !slices.ContainsFunc(capture.RecordingExts, func(ext string) bool { return onDisk[base+ext] }) {
+			rep.add(issueOrphanSidecar, name, "left in place", fmt.Errorf("%s.wav is gone", base))
+		}
+	}
+
+	a.consistency.Store(rep)
+	a.reportConsistency(rep)
+}
+
+// settleCompressions finds the recordings in paths that a crash left both
+// as a WAV and as a FLAC, keeps the one the sidecar describes, and returns
+// paths without the other. The sidecar is updated only once the FLAC is
+// complete, so until then the WAV is the one kept.
+func settleCompressions(rep *consistencyReport, paths []string) []string {
+	present := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		present[path] = true
+	}
+	out := make([]string, 0, len(paths))
+	for _, path := range paths {
+		ext := filepath.Ext(path)
+		base := strings.TrimSuffix(path, ext)
+		switch {
+		case ext == ".flac" && present[base+".wav"]:
+			continue // settled with its WAV
+		case ext != ".wav" || !present[base+".flac"]:
+			out = append(out, path)
+			continue
+		}
+		keep, drop := path, base+".flac"
+		if meta, err := capture.ReadMetadata(path); err == nil && meta.Compression == capture.CompressionFLAC {
+			keep, drop = drop, keep
+		}
+		rep.add(issueInterruptedCompression, filepath.Base(drop), "removed; "+filepath.Base(keep)+" kept", os.Remove(drop))
+		out = append(out, keep)
+	}
+	return out
+}
+
+// reportConsistency logs the check's result and sends a consistency event.
+func (a *App) reportConsistency(rep *consistencyReport) {
+	unrepaired := len(rep.Issues) - rep.Repaired
+	for _, issue := range rep.Issues {
+		if !issue.Repaired {
+			a.log.Warn("consistency check", "component", "history", "kind", issue.Kind, "file", issue.File, "detail", issue.Detail)
+		}
+	}
+
+	level := "info"
+	msg := fmt.Sprintf("consistency check: %d captures on disk, no discrepancies", rep.Captures)
+	if len(rep.Issues) > 0 {
+		msg = fmt.Sprintf("consistency check: %d captures on disk, %d discrepancies, %d repaired", rep.Captures, len(rep.Issues), rep.Repaired)
+	}
+	if unrepaired > 0 {
+		level = "warn"
+		msg += fmt.Sprintf(", %d need attention (see /api/consistency)", unrepaired)
+	}
+	kinds := map[string]int{}
+	for _, issue := range rep.Issues {
+		kinds[issue.Kind]++
+	}
+	a.emit("ephemerisd", map[string]any{
+		"type":       "consistency",
+		"captures":   rep.Captures,
+		"issues":     len(rep.Issues),
+		"repaired":   rep.Repaired,
+		"unrepaired": unrepaired,
+		"kinds":      kinds,
+	})
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   level,
+		"message": msg,
+	})
+}
+
+// handleConsistency returns the report of the startup consistency check.
+func (a *App) handleConsistency(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	rep := a.consistency.Load()
+	if rep == nil {
+		jsonError(w, "the consistency check has not run yet", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(rep)
+}
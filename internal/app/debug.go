@@ -0,0 +1,199 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	rpprof "runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// registerDebug mounts net/http/pprof, the diagnostics API and the /ws
+// test page. The routes
+// are always registered so a config reload can enable them; each request is
+// checked against the current [debug] section.
+func (a *App) registerDebug(mux *http.ServeMux) {
+	mux.HandleFunc("/debug/pprof/", a.debugGuard(pprof.Index))
+	mux.HandleFunc("/debug/pprof/cmdline", a.debugGuard(pprof.Cmdline))
+	mux.HandleFunc("/debug/pprof/profile", a.debugGuard(pprof.Profile))
+	mux.HandleFunc("/debug/pprof/symbol", a.debugGuard(pprof.Symbol))
+	mux.HandleFunc("/debug/pprof/trace", a.debugGuard(pprof.Trace))
+	mux.HandleFunc("/api/debug/goroutines", a.debugGuard(a.handleDebugGoroutines))
+	mux.HandleFunc("/debug/ws", a.debugGuard(a.handleWSClient))
+}
+
+// debugGuard rejects requests unless debug endpoints are enabled and the
+// request carries a debug token. Without a [debug] token, any of
+// server.api_tokens is accepted instead, so the endpoints are only open
+// when neither is set. Reads are not exempt as they are for the API: a
+// profile or goroutine dump is as sensitive as a control call.
+func (a *App) debugGuard(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cfg := a.getConfig()
+		if !cfg.Debug.Enabled {
+			jsonError(w, "debug endpoints are disabled (set [debug] enabled = true)", http.StatusNotFound)
+			return
+		}
+		tokens := cfg.Server.APITokens
+		if cfg.Debug.Token != "" {
+			tokens = []config.Secret{cfg.Debug.Token}
+		}
+		if len(tokens) > 0 {
+			got := r.URL.Query().Get("token")
+			if b := bearerToken(r); b != "" {
+				got = b
+			}
+			ok := false
+			for _, t := range tokens {
+				if tokenMatches(got, t.Value()) {
+					ok = true
+				}
+			}
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="ephemerisd"`)
+				jsonError(w, "invalid or missing debug token ([debug] token, or one of server.api_tokens)", http.StatusUnauthorized)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// bearerToken returns the token from an "Authorization: Bearer" header, or
+// an empty string.
+func bearerToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return ""
+}
+
+// tokenMatches compares a presented token in constant time.
+func tokenMatches(got, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// goroutineGroup is a set of goroutines sharing an identical stack.
+type goroutineGroup struct {
+	Count    int      `json:"count"`
+	Function string   `json:"function"`
+	Stack    []string `json:"stack"`
+}
+
+// goroutinesResponse is the body of GET /api/debug/goroutines.
+type goroutinesResponse struct {
+	Goroutines int              `json:"goroutines"`
+	GroupCount int              `json:"group_count"`
+	Groups     []goroutineGroup `json:"groups"`
+	Memory     memoryStats      `json:"memory"`
+}
+
+// memoryStats is a subset of runtime.MemStats.
+type memoryStats struct {
+	HeapAllocBytes  uint64 `json:"heap_alloc_bytes"`
+	HeapInuseBytes  uint64 `json:"heap_inuse_bytes"`
+	HeapObjects     uint64 `json:"heap_objects"`
+	SysBytes        uint64 `json:"sys_bytes"`
+	StackInuseBytes uint64 `json:"stack_inuse_bytes"`
+	NumGC           uint32 `json:"num_gc"`
+}
+
+func (a *App) handleDebugGoroutines(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if n, err := strconv.Atoi(limitStr); err == nil && n > 0 {
+			limit = n
+		}
+	}
+
+	groups := goroutineGroups()
+	total := len(groups)
+	if limit < len(groups) {
+		groups = groups[:limit]
+	}
+
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(goroutinesResponse{
+		Goroutines: runtime.NumGoroutine(),
+		GroupCount: total,
+		Groups:     groups,
+		Memory: memoryStats{
+			HeapAllocBytes:  ms.HeapAlloc,
+			HeapInuseBytes:  ms.HeapInuse,
+			HeapObjects:     ms.HeapObjects,
+			SysBytes:        ms.Sys,
+			StackInuseBytes: ms.StackInuse,
+			NumGC:           ms.NumGC,
+		},
+	})
+}
+
+// goroutineGroups parses the aggregated goroutine profile (debug=1 format)
+// into groups sorted by count, largest first.
+func goroutineGroups() []goroutineGroup {
+	var buf bytes.Buffer
+	_ = rpprof.Lookup("goroutine").WriteTo(&buf, 1)
+
+	var groups []goroutineGroup
+	var cur *goroutineGroup
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case strings.Contains(line, " @ 0x"):
+			// "3 @ 0x43e5ce 0x44f2d8 ..." starts a new group.
+			n, err := strconv.Atoi(strings.Fields(line)[0])
+			if err != nil {
+				cur = nil
+				continue
+			}
+			groups = append(groups, goroutineGroup{Count: n})
+			cur = &groups[len(groups)-1]
+		case strings.HasPrefix(line, "#\t") && cur != nil:
+			// "#\t0x4a1b2c\tpkg.func+0x2c\t\t/path/file.go:123"; the
+			// location column is padded with a variable number of tabs.
+			fields := strings.Split(line, "\t")
+			if len(fields) < 4 {
+				continue
+			}
+			frame := fields[2] + " " + strings.TrimSpace(strings.Join(fields[3:], ""))
+			cur.Stack = append(cur.Stack, frame)
+			if cur.Function == "" && !isRuntimeFrame(fields[2]) {
+				cur.Function = trimOffset(fields[2])
+			}
+		}
+	}
+
+	for i := range groups {
+		if groups[i].Function == "" && len(groups[i].Stack) > 0 {
+			groups[i].Function = trimOffset(strings.Fields(groups[i].Stack[0])[0])
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Count > groups[j].Count })
+	return groups
+}
+
+// isRuntimeFrame reports whether a frame belongs to the scheduler/runtime
+// plumbing that every blocked goroutine shares.
+func isRuntimeFrame(fn string) bool {
+	return strings.HasPrefix(fn, "runtime.") || strings.HasPrefix(fn, "internal/")
+}
+
+// trimOffset strips the "+0x2c" suffix from a profile frame.
+func trimOffset(fn string) string {
+	if i := strings.LastIndex(fn, "+0x"); i > 0 {
+		return fn[:i]
+	}
+	return fn
+}
@@ -0,0 +1,50 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// TestDebugWSRequiresAPIToken checks that with only server.api_tokens set,
+// the /debug/ws page still needs one of them even though it is a GET.
+func TestDebugWSRequiresAPIToken(t *testing.T) {
+	a := &App{}
+	a.cfg.Debug.Enabled = true
+	a.cfg.Server.APITokens = []config.Secret{"s3cret"}
+	mux := http.NewServeMux()
+	a.registerDebug(mux)
+
+	for _, tc := range []struct {
+		name   string
+		target string
+		bearer string
+		want   int
+	}{
+		{"no token", "/debug/ws", "", http.StatusUnauthorized},
+		{"wrong token", "/debug/ws?token=nope", "", http.StatusUnauthorized},
+		{"query token", "/debug/ws?token=s3cret", "", http.StatusOK},
+		{"bearer token", "/debug/ws", "s3cret", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		if tc.bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.bearer)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.name, rec.Code, tc.want)
+		}
+	}
+
+	// A [debug] token takes the place of the API tokens.
+	a.cfg.Debug.Token = "dbg"
+	req := httptest.NewRequest(http.MethodGet, "/debug/ws?token=s3cret", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("API token with a debug token set: status %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+}
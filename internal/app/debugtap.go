@@ -0,0 +1,102 @@
+package app
+
+import (
+	"context"
+	"net/http"
+	"runtime"
+	"time"
+)
+
+// debugTapInterval is how often the runtime is reported to the debug tap
+// while a client subscribes to it.
+const debugTapInterval = 5 * time.Second
+
+// debugTapLoop reports goroutines, memory and garbage collection to the
+// debug tap. Nothing is read while no client subscribes to it.
+func (a *App) debugTapLoop(ctx context.Context) {
+	t := time.NewTicker(debugTapInterval)
+	defer t.Stop()
+	var lastGC uint32
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if !a.wsHub.Tapped() {
+				continue
+			}
+			var ms runtime.MemStats
+			runtime.ReadMemStats(&ms)
+			fields := map[string]any{
+				"goroutines": runtime.NumGoroutine(),
+				"memory": memoryStats{
+					HeapAllocBytes:  ms.HeapAlloc,
+					HeapInuseBytes:  ms.HeapInuse,
+					HeapObjects:     ms.HeapObjects,
+					SysBytes:        ms.Sys,
+					StackInuseBytes: ms.StackInuse,
+					NumGC:           ms.NumGC,
+				},
+				"gc_pause_total_ms": float64(ms.PauseTotalNs) / 1e6,
+			}
+			if ms.NumGC > 0 && ms.NumGC != lastGC {
+				fields["gc_last_pause_ms"] = float64(ms.PauseNs[(ms.NumGC+255)%256]) / 1e6
+			}
+			lastGC = ms.NumGC
+			if r := a.sched(); r != nil {
+				fields["scheduler_commands"] = len(r.Commands)
+			}
+			a.wsHub.Debug("runtime", fields)
+		}
+	}
+}
+
+// tapRecorder notes the status and size of a response for the debug tap.
+type tapRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *tapRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *tapRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *tapRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// tapHTTP reports every API request, with its status, response size and
+// how long it took, to the debug tap while a client subscribes to it. The
+// WebSocket upgrade is left alone, as it needs the connection itself.
+func (a *App) tapHTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.wsHub.Tapped() || r.URL.Path == "/ws" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		rec := &tapRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		a.wsHub.Debug("http", map[string]any{
+			"method":      r.Method,
+			"path":        r.URL.Path,
+			"status":      rec.status,
+			"bytes":       rec.bytes,
+			"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+		})
+	})
+}
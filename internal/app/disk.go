@@ -1,19 +1,22 @@
 package app
 
-import "syscall"
+import (
+	"syscall"
+
+	"github.com/large-farva/ephemeris-engine/internal/api"
+)
 
 // diskUsage returns disk usage stats for the given path, or nil on error.
-func diskUsage(path string) map[string]any {
+func diskUsage(path string) *api.DiskUsage {
 	var stat syscall.Statfs_t
 	if err := syscall.Statfs(path, &stat); err != nil {
 		return nil
 	}
 	total := stat.Blocks * uint64(stat.Bsize)
 	free := stat.Bfree * uint64(stat.Bsize)
-	used := total - free
-	return map[string]any{
-		"total_bytes":     total,
-		"used_bytes":      used,
-		"available_bytes": free,
+	return &api.DiskUsage{
+		TotalBytes:     total,
+		UsedBytes:      total - free,
+		AvailableBytes: free,
 	}
 }
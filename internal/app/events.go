@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/eventlog"
+)
+
+// eventLogBuffer is how many events the event log may fall behind the hub
+// before it starts missing them, as a slow SD card might during a burst.
+const eventLogBuffer = 1024
+
+// Limits on /api/events/history results.
+const (
+	eventHistoryDefaultLimit = 500
+	eventHistoryMaxLimit     = 10000
+)
+
+// eventLogDir is where the event log lives under data.root.
+func eventLogDir(root string) string {
+	return filepath.Join(root, "events")
+}
+
+// eventLogLoop appends every broadcast event to the daily event log while
+// [events] persist is on, except those re-broadcast by a replay. The setting is re-read for each event, so a
+// reload turns the log on or off and follows a new data.root.
+func (a *App) eventLogLoop(ctx context.Context) {
+	events, cancel := a.wsHub.Subscribe(eventLogBuffer)
+	defer cancel()
+
+	var w eventlog.Writer
+	defer w.Close()
+	var lastErr string
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-events:
+			cfg := a.getConfig()
+			if !cfg.Events.Persist {
+				_ = w.Close()
+				continue
+			}
+			var ev struct {
+				Type   string `json:"type"`
+				Replay bool   `json:"replay"`
+			}
+			_ = json.Unmarshal(msg, &ev)
+			if ev.Replay || slices.Contains(cfg.Events.Exclude, ev.Type) {
+				continue
+			}
+			// Report a failure once rather than for every event, since
+			// logging it broadcasts yet another event.
+			err := w.Write(eventLogDir(cfg.Data.Root), cfg.Events.RetentionDays, msg, time.Now())
+			switch {
+			case err != nil && err.Error() != lastErr:
+				lastErr = err.Error()
+				a.log.Warn("event log write failed", "err", err)
+			case err == nil && lastErr != "":
+				lastErr = ""
+				a.log.Info("event log writing again")
+			}
+		}
+	}
+}
+
+// eventHistoryResponse is the body of GET /api/events/history. Events are
+// as they were broadcast on /ws.
+type eventHistoryResponse struct {
+	Events  []json.RawMessage `json:"events"`
+	Count   int               `json:"count"`
+	Persist bool              `json:"persist"` // events.persist is on
+}
+
+// handleEventHistory serves events from the event log, oldest first. Every
+// parameter is optional: type takes a comma-separated list, since and
+// until take RFC 3339 times or a duration back from now, and limit keeps
+// the newest matches (default 500).
+//
+//	GET /api/events/history?type=pass_scheduled,state&since=12h&satellite=NOAA-19
+func (a *App) handleEventHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	f := eventlog.Filter{
+		Satellite: q.Get("satellite"),
+		Limit:     eventHistoryDefaultLimit,
+	}
+	for _, t := range strings.Split(q.Get("type"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			f.Types = append(f.Types, t)
+		}
+	}
+	var err error
+	if f.Since, err = parseHistoryTime(q.Get("since")); err != nil {
+		jsonError(w, "since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if f.Until, err = parseHistoryTime(q.Get("until")); err != nil {
+		jsonError(w, "until: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 1 || f.Limit > eventHistoryMaxLimit {
+			jsonError(w, "limit must be between 1 and "+strconv.Itoa(eventHistoryMaxLimit), http.StatusBadRequest)
+			return
+		}
+	}
+
+	cfg := a.getConfig()
+	events, err := eventlog.Query(eventLogDir(cfg.Data.Root), f)
+	if err != nil {
+		jsonError(w, "read event log: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []json.RawMessage{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(eventHistoryResponse{
+		Events:  events,
+		Count:   len(events),
+		Persist: cfg.Events.Persist,
+	})
+}
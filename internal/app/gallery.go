@@ -0,0 +1,212 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/gallery"
+)
+
+// galleryCheckInterval is how often a requested gallery export is retried
+// while a pass is being captured.
+const galleryCheckInterval = 30 * time.Second
+
+// galleryReport describes one gallery export.
+type galleryReport struct {
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at"`
+	Dir        string `json:"dir"`
+	gallery.Result
+	Error  string `json:"error,omitempty"`
+	Source string `json:"source"` // "decode", "retention", "startup" or "api"
+}
+
+// galleryExporter tracks the static gallery export.
+type galleryExporter struct {
+	mu      sync.Mutex
+	running bool
+	pending string // source of a requested export, or ""
+	last    *galleryReport
+	wake    chan struct{}
+}
+
+func (g *galleryExporter) report() (*galleryReport, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.last, g.running
+}
+
+// requestGallery asks the gallery loop to export the gallery once no pass
+// is being captured. It never blocks.
+func (a *App) requestGallery(source string) {
+	if !a.getConfig().Gallery.Enabled {
+		return
+	}
+	a.gallery.mu.Lock()
+	a.gallery.pending = source
+	a.gallery.mu.Unlock()
+	select {
+	case a.gallery.wake <- struct{}{}:
+	default:
+	}
+}
+
+// galleryLoop exports the gallery when one is requested, waiting out any
+// capture in progress, until ctx is cancelled.
+func (a *App) galleryLoop(ctx context.Context) {
+	a.requestGallery("startup")
+	t := time.NewTicker(galleryCheckInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-a.gallery.wake:
+		case <-t.C:
+		}
+		a.gallery.mu.Lock()
+		source := a.gallery.pending
+		a.gallery.mu.Unlock()
+		if source != "" && !a.capturing() {
+			a.exportGallery(source)
+		}
+	}
+}
+
+// exportGallery writes the static gallery for the captures in the
+// history and announces the result. It returns nil without doing anything
+// if an export is already running.
+func (a *App) exportGallery(source string) *galleryReport {
+	a.gallery.mu.Lock()
+	if a.gallery.running {
+		a.gallery.mu.Unlock()
+		return nil
+	}
+	a.gallery.running = true
+	a.gallery.pending = ""
+	a.gallery.mu.Unlock()
+
+	cfg := a.getConfig()
+	start := time.Now()
+	recs, err := a.history.Captures()
+	var caps []gallery.Capture
+	for _, rec := range recs {
+		meta, err := capture.ReadMetadata(filepath.Join(cfg.Data.Root, rec.File))
+		if err != nil || len(meta.Images) == 0 {
+			continue
+		}
+		caps = append(caps, gallery.Capture{
+			Satellite: rec.Satellite,
+			AOS:       rec.AOS,
+			MaxElev:   rec.MaxElev,
+			Images:    meta.Images,
+		})
+	}
+	var res gallery.Result
+	if err == nil {
+		res, err = gallery.Export(gallery.Options{
+			Root:     cfg.Data.Root,
+			Dir:      cfg.Gallery.Dir,
+			Title:    cfg.Gallery.Title,
+			Location: cfg.Station.Location(),
+		}, caps)
+	}
+	rep := &galleryReport{
+		StartedAt:  start.UTC().Format(time.RFC3339),
+		FinishedAt: time.Now().UTC().Format(time.RFC3339),
+		Dir:        cfg.Gallery.Dir,
+		Result:     res,
+		Source:     source,
+	}
+	if err != nil {
+		rep.Error = err.Error()
+	}
+
+	a.gallery.mu.Lock()
+	a.gallery.running = false
+	a.gallery.last = rep
+	a.gallery.mu.Unlock()
+
+	ev := map[string]any{
+		"type":     "gallery",
+		"dir":      rep.Dir,
+		"days":     res.Days,
+		"captures": res.Captures,
+		"images":   res.Images,
+		"copied":   res.Copied,
+		"removed":  res.Removed,
+	}
+	if err != nil {
+		ev["error"] = rep.Error
+		a.emit("ephemerisd", map[string]any{
+			"type":    "log",
+			"level":   "error",
+			"message": "gallery export failed: " + rep.Error,
+		})
+	}
+	a.emit("ephemerisd", ev)
+	return rep
+}
+
+// galleryResponse is the body of GET /api/gallery.
+type galleryResponse struct {
+	Enabled bool           `json:"enabled"`
+	Dir     string         `json:"dir"`
+	Title   string         `json:"title"`
+	Running bool           `json:"running"`
+	Last    *galleryReport `json:"last"`
+}
+
+// galleryExportResponse is the report of an export run on request.
+type galleryExportResponse struct {
+	OK     bool           `json:"ok"`
+	Report *galleryReport `json:"report"`
+}
+
+// handleGallery reports the gallery settings and the last export.
+func (a *App) handleGallery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cfg := a.getConfig()
+	last, running := a.gallery.report()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(galleryResponse{
+		Enabled: cfg.Gallery.Enabled,
+		Dir:     cfg.Gallery.Dir,
+		Title:   cfg.Gallery.Title,
+		Running: running,
+		Last:    last,
+	})
+}
+
+// handleGalleryExport exports the gallery now and returns the report.
+//
+//	POST /api/gallery/export
+func (a *App) handleGalleryExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !a.getConfig().Gallery.Enabled {
+		jsonError(w, "the gallery is disabled; set gallery.enabled", http.StatusConflict)
+		return
+	}
+	rep := a.exportGallery("api")
+	if rep == nil {
+		jsonError(w, "a gallery export is already running", http.StatusConflict)
+		return
+	}
+	if rep.Error != "" {
+		jsonError(w, rep.Error, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(galleryExportResponse{OK: true, Report: rep})
+}
@@ -1,21 +1,30 @@
 package app
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/large-farva/ephemeris-engine/internal/api"
 	"github.com/large-farva/ephemeris-engine/internal/capture"
 	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/decode"
 	"github.com/large-farva/ephemeris-engine/internal/predict"
 	"github.com/large-farva/ephemeris-engine/internal/scheduler"
+	"github.com/large-farva/ephemeris-engine/internal/store"
+	"github.com/large-farva/ephemeris-engine/internal/upload"
 )
 
 // ---------------------------------------------------------------------------
@@ -32,74 +41,241 @@ func (a *App) handleHealthz(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write([]byte("ok\n"))
 }
 
-func (a *App) handleStatus(w http.ResponseWriter, _ *http.Request) {
+// handleStatus serves the daemon summary, at /api/status and, frozen as
+// api.StatusResponse, at /api/v1/status.
+func (a *App) handleStatus(w http.ResponseWriter, r *http.Request) {
 	cfg := a.getConfig()
-
-	resp := map[string]any{
-		"name":           "ephemeris-engine",
-		"state":          a.state.Load().(string),
-		"uptime_seconds": int64(time.Since(a.startedAt).Seconds()),
-		"data_root":      cfg.Data.Root,
-		"archive_dir":    cfg.Data.Archive,
-		"demo_enabled":   cfg.Demo.Enabled,
+	tz, err := requestLocation(r, cfg)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	if cfg.Demo.Enabled {
-		resp["mode"] = "demo"
-	} else {
-		resp["mode"] = "live"
+	resp := api.StatusResponse{
+		Name:          "ephemeris-engine",
+		State:         a.state.Load().(string),
+		UptimeSeconds: int64(time.Since(a.startedAt).Seconds()),
+		BootID:        a.bootID,
+		DemoEnabled:   a.isDemo(),
+		Mode:          modeName(a.isDemo()),
+		URLs: api.StatusURLs{
+			API: a.externalURL(r, "/api", false),
+			WS:  a.externalURL(r, "/ws", true),
+		},
+		Disk:  diskUsage(cfg.Data.Root),
+		Clock: clockAt(time.Now(), tz),
 	}
-
-	// Include current pass info if available.
-	if pi, ok := a.currentPass.Load().(*scheduler.PassInfo); ok && pi != nil {
-		resp["current_pass"] = pi
+	// The tracked pass is shared; render local times on a copy.
+	if info := a.currentPassInfo(); info != nil {
+		pass := *info
+		pass.AOSLocal = localRFC3339(pass.AOS, tz)
+		pass.LOSLocal = localRFC3339(pass.LOS, tz)
+		pass.AOSUnix = unixSeconds(pass.AOS)
+		pass.LOSUnix = unixSeconds(pass.LOS)
+		resp.CurrentPass = &pass
 	}
 
-	// Disk usage for data root.
-	if du := diskUsage(cfg.Data.Root); du != nil {
-		resp["disk"] = du
+	// Filesystem paths are not shared with the public dashboard.
+	if !isPublic(r) {
+		resp.DataRoot = cfg.Data.Root
+		resp.ArchiveDir = cfg.Data.Archive
 	}
 
 	// Scheduler paused state.
-	if a.scheduler != nil {
-		resp["paused"] = a.scheduler.IsPaused()
+	if s := a.sched(); s != nil {
+		resp.Paused = s.IsPaused()
 	}
+	resp.ReadOnly, _, _ = a.storage.status()
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
+// versionResponse is the body of GET /api/version.
+type versionResponse struct {
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version"`
+	BuiltAt   string `json:"built_at"`
+}
+
 func (a *App) handleVersion(w http.ResponseWriter, _ *http.Request) {
-	resp := map[string]any{
-		"version":    Version,
-		"go_version": GoVersion,
-		"built_at":   BuiltAt,
+	resp := versionResponse{
+		Version:   Version,
+		GoVersion: GoVersion,
+		BuiltAt:   BuiltAt,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
-func (a *App) handleSatellites(w http.ResponseWriter, _ *http.Request) {
-	type satJSON struct {
-		Name    string `json:"name"`
-		NoradID int    `json:"norad_id"`
-		FreqHz  int    `json:"freq_hz"`
+// satelliteJSON is a catalog satellite with its settings in cfg.
+type satelliteJSON struct {
+	Name         string  `json:"name"`
+	NoradID      int     `json:"norad_id"`
+	FreqHz       int     `json:"freq_hz"`
+	Enabled      bool    `json:"enabled"`
+	FreqOffsetHz int     `json:"freq_offset_hz"` // added to FreqHz when recording
+	Priority     int     `json:"priority"`       // ranks overlapping passes
+	MinElevation float64 `json:"min_elevation"`  // lowest peak scheduled
+	Mode         string  `json:"mode"`
+	Builtin      bool    `json:"builtin"` // false for satellites added in the config
+	// Status and CatalogFreqHz come from the catalog sync: the SatNOGS
+	// DB status, and the configured frequency when FreqHz replaced it.
+	Status        string `json:"status,omitempty"`
+	CatalogFreqHz int    `json:"catalog_freq_hz,omitempty"`
+}
+
+// newSatelliteJSON renders s with its settings in cfg.
+func newSatelliteJSON(s capture.Satellite, cfg config.Config) satelliteJSON {
+	return satelliteJSON{
+		Name:          s.Name,
+		NoradID:       s.NoradID,
+		FreqHz:        s.Freq,
+		Enabled:       cfg.SatelliteEnabled(s.Name),
+		FreqOffsetHz:  cfg.SatelliteFreqOffset(s.Name),
+		Priority:      cfg.SatellitePriority(s.Name),
+		MinElevation:  cfg.SatelliteMinElevation(s.Name),
+		Mode:          s.Mode,
+		Builtin:       capture.IsBuiltin(s.Name),
+		Status:        s.Status,
+		CatalogFreqHz: s.CatalogFreq,
 	}
-	sats := make([]satJSON, len(capture.Satellites))
-	for i, s := range capture.Satellites {
-		sats[i] = satJSON{Name: s.Name, NoradID: s.NoradID, FreqHz: s.Freq}
+}
+
+// satellitesResponse is the body of GET /api/satellites.
+type satellitesResponse struct {
+	Satellites []satelliteJSON `json:"satellites"`
+}
+
+// handleSatellites lists the satellite catalog, or adds to it on POST.
+func (a *App) handleSatellites(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPost {
+		a.handleSatelliteAdd(w, r)
+		return
 	}
+	cfg := a.getConfig()
+	catalog := capture.Catalog()
+	sats := make([]satelliteJSON, len(catalog))
+	for i, s := range catalog {
+		sats[i] = newSatelliteJSON(s, cfg)
+	}
+	writeJSONCached(w, r, satellitesResponse{Satellites: sats}, time.Time{})
+}
+
+// handleConfig serves the running configuration as a read-only view. Secret
+// fields and URL credentials are redacted unless ?show_secrets=true is sent
+// by an authorized local operator.
+func (a *App) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		jsonError(w, "configuration is read-only; edit the file and POST /api/reload", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.URL.Query().Get("show_secrets") == "true" {
+		a.handleConfigRevealed(w, r)
+		return
+	}
+	if r.URL.Query().Get("resolved") == "true" {
+		a.handleConfigResolved(w)
+		return
+	}
+	a.cfgMu.RLock()
+	cfg, loadedAt := a.cfg, a.cfgLoadedAt
+	a.cfgMu.RUnlock()
+	writeJSONCached(w, r, cfg.Redacted(), loadedAt)
+}
+
+// handleConfigRevealed serves the running configuration with secrets in the
+// clear, for local troubleshooting.
+func (a *App) handleConfigRevealed(w http.ResponseWriter, r *http.Request) {
+	if ok, reason := a.operatorAuthorized(r); !ok {
+		jsonError(w, reason, http.StatusForbidden)
+		return
+	}
+	revealed, err := a.getConfig().Reveal()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a.log.Warn("config secrets shown", "remote", r.RemoteAddr)
+	w.Header().Set("Cache-Control", "no-store")
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]any{"satellites": sats})
+	_ = json.NewEncoder(w).Encode(revealed)
 }
 
-func (a *App) handleConfig(w http.ResponseWriter, _ *http.Request) {
+// configResolvedResponse is the body of GET /api/config?resolved=true.
+type configResolvedResponse struct {
+	Path    string        `json:"path"`
+	Sources []string      `json:"sources"` // files applied, in order
+	Config  config.Config `json:"config"`
+}
+
+// handleConfigResolved re-reads the config file and its includes from disk
+// and returns the merged result with the files applied, in order. This shows
+// what a reload would produce and where inherited values come from.
+func (a *App) handleConfigResolved(w http.ResponseWriter) {
+	a.cfgMu.RLock()
+	path := a.configPath
+	a.cfgMu.RUnlock()
+
+	if path == "" {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(configResolvedResponse{
+			Sources: []string{},
+			Config:  a.getConfig().Redacted(),
+		})
+		return
+	}
+
+	cfg, sources, err := config.LoadResolved(path)
+	if err != nil {
+		jsonError(w, "resolve config: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(a.getConfig())
+	_ = json.NewEncoder(w).Encode(configResolvedResponse{
+		Path:    path,
+		Sources: sources,
+		Config:  cfg.Redacted(),
+	})
+}
+
+// stationJSON is the location passes were predicted for.
+type stationJSON struct {
+	Profile     string  `json:"profile"`
+	Lat         float64 `json:"lat"`
+	Lon         float64 `json:"lon"`
+	Alt         float64 `json:"alt"`
+	Source      string  `json:"source"`      // config, gpsd or geoip
+	Approximate bool    `json:"approximate"` // located by IP address
+}
+
+// newStationJSON renders loc, resolved for the active profile.
+func newStationJSON(profile string, loc predict.Location) stationJSON {
+	return stationJSON{
+		Profile:     profile,
+		Lat:         loc.Lat,
+		Lon:         loc.Lon,
+		Alt:         loc.Alt,
+		Source:      loc.Source,
+		Approximate: loc.Approximate(),
+	}
+}
+
+// passesResponse is the body of GET /api/passes.
+type passesResponse struct {
+	Passes  []passJSON  `json:"passes"`
+	Clock   *api.Clock  `json:"clock"`
+	Station stationJSON `json:"station"`
 }
 
 func (a *App) handlePasses(w http.ResponseWriter, r *http.Request) {
 	cfg := a.getConfig()
+	tz, err := requestLocation(r, cfg)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	predictor := predict.NewPredictor(a.wsHub, cfg, a.log)
 	passes, err := predictor.ComputePasses()
 	if err != nil {
@@ -120,6 +296,45 @@ func (a *App) handlePasses(w http.ResponseWriter, r *http.Request) {
 		passes = filtered
 	}
 
+	if minStr := r.URL.Query().Get("min_elev"); minStr != "" {
+		minElev, err := strconv.ParseFloat(minStr, 64)
+		if err != nil || minElev < 0 || minElev > 90 {
+			jsonError(w, "min_elev must be a number between 0 and 90", http.StatusBadRequest)
+			return
+		}
+		var filtered []predict.Pass
+		for _, p := range passes {
+			if p.MaxElev >= minElev {
+				filtered = append(filtered, p)
+			}
+		}
+		passes = filtered
+	}
+
+	if dirStr := r.URL.Query().Get("direction"); dirStr != "" {
+		dir, err := predict.ParseDirection(dirStr)
+		if err != nil {
+			jsonError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var filtered []predict.Pass
+		for _, p := range passes {
+			if p.Direction() == dir {
+				filtered = append(filtered, p)
+			}
+		}
+		passes = filtered
+	}
+
+	withScore := false
+	if s := r.URL.Query().Get("score"); s != "" {
+		withScore, err = strconv.ParseBool(s)
+		if err != nil {
+			jsonError(w, "score must be true or false", http.StatusBadRequest)
+			return
+		}
+	}
+
 	countStr := r.URL.Query().Get("count")
 	if countStr != "" {
 		if n, err := strconv.Atoi(countStr); err == nil && n > 0 && n < len(passes) {
@@ -127,38 +342,47 @@ func (a *App) handlePasses(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	result := passesToJSON(passes)
+	result := passesToJSON(passes, tz)
+	for i := range result {
+		result[i].Disabled = !cfg.SatelliteEnabled(result[i].Satellite)
+		result[i].Blackout = passBlackout(cfg, passes[i])
+		result[i].LowQuality = passes[i].Quality < cfg.Predict.MinQuality
+		if withScore {
+			result[i].Score = newPassScoreJSON(cfg, passes[i])
+		}
+	}
 
 	loc, _ := predictor.ResolveLocation()
-	resp := map[string]any{
-		"passes": result,
-		"station": map[string]any{
-			"lat": loc.Lat,
-			"lon": loc.Lon,
-			"alt": loc.Alt,
-		},
+	resp := passesResponse{
+		Passes:  result,
+		Clock:   clockAt(time.Now(), tz),
+		Station: newStationJSON(cfg.Station.Active, loc),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
+// triggerRequest is the body of POST /api/trigger. The satellite is given
+// by NORAD ID or by name.
+type triggerRequest struct {
+	Satellite       string `json:"satellite"`
+	NoradID         int    `json:"norad_id"`
+	DurationSeconds int    `json:"duration_seconds"` // default 600
+}
+
 func (a *App) handleTrigger(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	if a.scheduler == nil {
+	if a.sched() == nil {
 		jsonError(w, "not available in demo mode", http.StatusConflict)
 		return
 	}
 
-	var req struct {
-		Satellite       string `json:"satellite"`
-		NoradID         int    `json:"norad_id"`
-		DurationSeconds int    `json:"duration_seconds"`
-	}
+	var req triggerRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
 		return
@@ -180,6 +404,22 @@ func (a *App) handleTrigger(w http.ResponseWriter, r *http.Request) {
 		req.DurationSeconds = 600
 	}
 
+	// A trigger during a capture would queue behind it and record a second
+	// time. Repeating a trigger for the satellite already on air is a no-op;
+	// another satellite has to wait until the SDR is free.
+	if pi, ok := a.currentPass.Load().(*scheduler.PassInfo); ok && pi != nil && pi.Stage == "recording" {
+		if pi.NoradID != sat.NoradID {
+			jsonError(w, fmt.Sprintf("SDR busy recording %s until %s", pi.Satellite, pi.LOS), http.StatusConflict)
+			return
+		}
+		writeCommandResult(w, scheduler.CommandResult{
+			OK:         true,
+			Message:    fmt.Sprintf("%s is already being recorded (until %s)", sat.Name, pi.LOS),
+			Resolution: scheduler.TriggerAlreadyRecording,
+		})
+		return
+	}
+
 	payload, _ := json.Marshal(map[string]any{
 		"norad_id":         sat.NoradID,
 		"duration_seconds": req.DurationSeconds,
@@ -195,7 +435,7 @@ func (a *App) handleTLERefresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if a.scheduler == nil {
+	if a.sched() == nil {
 		jsonError(w, "not available in demo mode", http.StatusConflict)
 		return
 	}
@@ -208,22 +448,37 @@ func (a *App) handleTLERefresh(w http.ResponseWriter, r *http.Request) {
 // Phase 2: Captures + Config Profiles
 // ---------------------------------------------------------------------------
 
+// capturesResponse is the body of GET /api/captures.
+type capturesResponse struct {
+	Captures []captureInfo `json:"captures"`
+}
+
 func (a *App) handleCaptures(w http.ResponseWriter, r *http.Request) {
 	cfg := a.getConfig()
 
 	if r.Method == http.MethodDelete {
+		// Held from resolving the name to marking it deleted, so a
+		// compression finishing meanwhile cannot swap the WAV for a FLAC
+		// the delete then misses.
+		trashMu.Lock()
+		defer trashMu.Unlock()
 		name := r.URL.Query().Get("name")
-		if name == "" {
-			jsonError(w, "name parameter required", http.StatusBadRequest)
+		path, ok := capturePath(w, cfg.Data.Root, name)
+		if !ok {
 			return
 		}
-		// Prevent path traversal.
-		if strings.Contains(name, "/") || strings.Contains(name, "..") {
-			jsonError(w, "invalid filename", http.StatusBadRequest)
-			return
+		name = filepath.Base(path)
+		msg := "deleted " + name
+		var err error
+		if cfg.Data.TrashDays > 0 {
+			// Deleted captures go to the trash first, so a slip can be
+			// undone with POST /api/captures/restore.
+			_, err = trashCapture(cfg.Data.Root, name)
+			msg = fmt.Sprintf("moved %s to the trash; it can be restored for %d days", name, cfg.Data.TrashDays)
+		} else {
+			err = removeCapture(cfg.Data.Root, path)
 		}
-		path := filepath.Join(cfg.Data.Root, name)
-		if err := os.Remove(path); err != nil {
+		if err != nil {
 			if os.IsNotExist(err) {
 				jsonError(w, "file not found", http.StatusNotFound)
 			} else {
@@ -231,41 +486,316 @@ func (a *App) handleCaptures(w http.ResponseWriter, r *http.Request) {
 			}
 			return
 		}
+		if _, err := a.history.MarkDeleted(name); err != nil {
+			a.log.Warn("could not mark capture deleted", "component", "history", "file", name, "err", err)
+		}
 		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true, "message": "deleted " + name})
+		_ = json.NewEncoder(w).Encode(api.OKResponse{OK: true, Message: msg})
 		return
 	}
 
 	// GET: list captures.
-	matches, _ := filepath.Glob(filepath.Join(cfg.Data.Root, "*.wav"))
+	captures, err := a.listCaptures(r, cfg.Data.Root)
+	if err != nil {
+		jsonError(w, "read history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(capturesResponse{Captures: captures})
+}
+
+// captureInfo is one capture in a listing. Decode is its decode status,
+// one of pending, done, failed or skipped; a done decode also carries how
+// clean the signal was. Products are the decoded images with links to them.
+type captureInfo struct {
+	Filename  string `json:"filename"`
+	Satellite string `json:"satellite"`
+	Timestamp string `json:"timestamp"`
+	Size      int64  `json:"size"`
+	Station   string `json:"station,omitempty"`
+	SHA256    string `json:"sha256,omitempty"`
+	Corrupt   bool   `json:"corrupt,omitempty"`
+	// Imported is the original path of a capture brought in from another
+	// tool.
+	Imported string   `json:"imported_from,omitempty"`
+	Images   []string `json:"images,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	// Upload is how the capture's upload to object storage stands.
+	Upload *upload.Status `json:"upload,omitempty"`
+
+	URL         string           `json:"url,omitempty"`
+	Decode      string           `json:"decode_status"`
+	DecodeError string           `json:"decode_error,omitempty"`
+	Products    []captureProduct `json:"products,omitempty"`
+	ImageScore  *int             `json:"image_score,omitempty"`
+	SNR         *float64         `json:"snr_db,omitempty"`
+	SyncPercent *int             `json:"sync_percent,omitempty"`
+}
+
+// captureProduct is a file decoded from a capture, relative to data.root.
+type captureProduct struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// fileURL is the address of name, a capture or one of its images, on the
+// daemon as r reached it.
+func (a *App) fileURL(r *http.Request, name string) string {
+	return a.externalURL(r, "/api/captures/file?name="+url.QueryEscape(name), false)
+}
 
-	type captureInfo struct {
-		Filename  string `json:"filename"`
-		Satellite string `json:"satellite"`
-		Timestamp string `json:"timestamp"`
-		Size      int64  `json:"size"`
+// capturePath resolves a capture filename from a request to its path under
+// root, writing a 400 and returning false if it is missing or would escape
+// root. A WAV name finds the capture after it has been compressed, so the
+// path may have another extension than name; filepath.Base of it is the
+// capture's name from then on.
+func capturePath(w http.ResponseWriter, root, name string) (string, bool) {
+	if name == "" {
+		jsonError(w, "name parameter required", http.StatusBadRequest)
+		return "", false
+	}
+	// Prevent path traversal.
+	if strings.Contains(name, "/") || strings.Contains(name, "..") {
+		jsonError(w, "invalid filename", http.StatusBadRequest)
+		return "", false
 	}
+	return capture.ResolveRecording(filepath.Join(root, name)), true
+}
 
-	captures := make([]captureInfo, 0, len(matches))
-	for _, m := range matches {
-		base := filepath.Base(m)
-		info, err := os.Stat(m)
-		if err != nil {
+// captureTagRequest is the body of POST /api/captures/tag.
+type captureTagRequest struct {
+	Name   string   `json:"name"`
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+}
+
+// captureTagResponse reports a capture's tags after a change.
+type captureTagResponse struct {
+	OK   bool     `json:"ok"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// handleCaptureTag adds and removes free-form tags on a capture, kept in
+// its sidecar.
+func (a *App) handleCaptureTag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req captureTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	cfg := a.getConfig()
+	path, ok := capturePath(w, cfg.Data.Root, req.Name)
+	if !ok {
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		jsonError(w, "file not found", http.StatusNotFound)
+		return
+	}
+	meta, err := capture.ReadMetadata(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	drop := map[string]bool{}
+	for _, t := range req.Remove {
+		drop[strings.TrimSpace(t)] = true
+	}
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, t := range append(meta.Tags, req.Add...) {
+		t = strings.TrimSpace(t)
+		if t == "" || drop[t] || seen[t] {
 			continue
 		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	meta.Tags = tags
+	if err := capture.WriteMetadata(path, meta, cfg.Data.FsyncOnFinalize); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(captureTagResponse{OK: true, Name: filepath.Base(path), Tags: tags})
+}
 
-		// Parse satellite name and timestamp from "NOAA-19_20260215T143022Z.wav".
-		sat, ts := parseCaptureName(base)
-		captures = append(captures, captureInfo{
-			Filename:  base,
-			Satellite: sat,
-			Timestamp: ts,
-			Size:      info.Size(),
-		})
+// captureImportRequest is the body of POST /api/captures/import.
+type captureImportRequest struct {
+	Paths     []string `json:"paths"` // absolute, on the daemon's host
+	Satellite string   `json:"satellite"`
+	Move      bool     `json:"move"`
+	LocalTime bool     `json:"local_time"`
+	DryRun    bool     `json:"dry_run"`
+}
+
+// captureImportResponse lists what an import brought in and left out.
+type captureImportResponse struct {
+	DryRun bool `json:"dry_run"`
+	capture.ImportResult
+}
+
+// handleCaptureImport registers recordings and images made by another tool
+// (paths on the daemon's host) in the capture index. It runs synchronously
+// and refuses while a pass is being captured, since a bulk copy competes
+// with the recording for the disk.
+func (a *App) handleCaptureImport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req captureImportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Paths) == 0 {
+		jsonError(w, "paths required", http.StatusBadRequest)
+		return
+	}
+	for _, p := range req.Paths {
+		if !filepath.IsAbs(p) {
+			jsonError(w, fmt.Sprintf("path %q must be absolute", p), http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Satellite != "" && capture.SatelliteByName(req.Satellite) == nil {
+		jsonError(w, fmt.Sprintf("unknown satellite %q", req.Satellite), http.StatusBadRequest)
+		return
+	}
+	if a.capturing() {
+		jsonError(w, "a capture is in progress; import later", http.StatusConflict)
+		return
 	}
 
+	cfg := a.getConfig()
+	res, err := capture.Import(cfg.Data.Root, capture.ImportOptions{
+		Paths:     req.Paths,
+		Satellite: req.Satellite,
+		Move:      req.Move,
+		LocalTime: req.LocalTime,
+		DryRun:    req.DryRun,
+		Sync:      cfg.Data.FsyncOnFinalize,
+	})
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !req.DryRun {
+		for _, imp := range res.Imported {
+			if err := a.recordCaptureFile(filepath.Join(cfg.Data.Root, imp.Filename), store.SourceImport); err != nil {
+				a.log.Warn("could not record import", "component", "history", "file", imp.Filename, "err", err)
+			}
+		}
+		a.emit("ephemerisd", map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": fmt.Sprintf("imported %d captures from %s (%d files skipped)", len(res.Imported), strings.Join(req.Paths, ", "), len(res.Skipped)),
+		})
+	}
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]any{"captures": captures})
+	_ = json.NewEncoder(w).Encode(captureImportResponse{DryRun: req.DryRun, ImportResult: res})
+}
+
+// handleCaptureFile downloads a capture. A file with a recorded checksum
+// is re-hashed first and refused with 409 if it no longer matches, unless
+// verify=false; the checksum is then sent as a Repr-Digest header (RFC
+// 9530) so the client can check the transfer too. A name under images/
+// serves that decoded image instead.
+func (a *App) handleCaptureFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	root := a.getConfig().Data.Root
+	if img, ok := strings.CutPrefix(r.URL.Query().Get("name"), decode.ImagesDir+"/"); ok {
+		serveImage(w, r, root, img)
+		return
+	}
+	path, ok := capturePath(w, root, r.URL.Query().Get("name"))
+	if !ok {
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			jsonError(w, "file not found", http.StatusNotFound)
+		} else {
+			jsonError(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Skipping verification also drops the digest, since the file is
+	// wanted as it is now rather than as it was recorded.
+	meta, _ := capture.ReadMetadata(path)
+	if r.URL.Query().Get("verify") == "false" {
+		meta.SHA256 = ""
+	}
+	if meta.SHA256 != "" {
+		if _, err := capture.VerifyChecksum(path); err != nil {
+			a.emit("ephemerisd", map[string]any{
+				"type":    "log",
+				"level":   "error",
+				"message": fmt.Sprintf("refusing download of %s: %v", filepath.Base(path), err),
+			})
+			jsonError(w, err.Error()+" (add verify=false to download anyway)", http.StatusConflict)
+			return
+		}
+	}
+	if sum, err := hex.DecodeString(meta.SHA256); err == nil && len(sum) == sha256.Size {
+		w.Header().Set("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum)+":")
+	}
+	ctype := "audio/wav"
+	if filepath.Ext(path) == ".flac" {
+		ctype = "audio/flac"
+	}
+	w.Header().Set("Content-Type", ctype)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(path)))
+	http.ServeContent(w, r, filepath.Base(path), info.ModTime(), f)
+}
+
+// serveImage serves the decoded image name from root's images directory.
+func serveImage(w http.ResponseWriter, r *http.Request, root, name string) {
+	if name == "" || strings.Contains(name, "/") || strings.Contains(name, "..") || filepath.Ext(name) != ".png" {
+		jsonError(w, "invalid image name", http.StatusBadRequest)
+		return
+	}
+	f, err := os.Open(filepath.Join(root, decode.ImagesDir, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			jsonError(w, "file not found", http.StatusNotFound)
+		} else {
+			jsonError(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	http.ServeContent(w, r, name, info.ModTime(), f)
+}
+
+// profilesResponse is the body of GET /api/config/profiles.
+type profilesResponse struct {
+	ConfigDir string               `json:"config_dir"`
+	Profiles  []config.ProfileInfo `json:"profiles"`
 }
 
 func (a *App) handleConfigProfiles(w http.ResponseWriter, _ *http.Request) {
@@ -278,9 +808,9 @@ func (a *App) handleConfigProfiles(w http.ResponseWriter, _ *http.Request) {
 		profiles = []config.ProfileInfo{}
 	}
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]any{
-		"config_dir": config.DefaultConfigDir(),
-		"profiles":   profiles,
+	_ = json.NewEncoder(w).Encode(profilesResponse{
+		ConfigDir: config.DefaultConfigDir(),
+		Profiles:  profiles,
 	})
 }
 
@@ -288,16 +818,49 @@ func (a *App) handleConfigProfiles(w http.ResponseWriter, _ *http.Request) {
 // Phase 3: TLE Info + Next Pass + System Info
 // ---------------------------------------------------------------------------
 
-func (a *App) handleTLEInfo(w http.ResponseWriter, _ *http.Request) {
+func (a *App) handleTLEInfo(w http.ResponseWriter, r *http.Request) {
 	cfg := a.getConfig()
-	store := predict.NewTLEStore(cfg.Predict.TLEURL, cfg.Data.Root, cfg.Predict.TLERefreshHours)
+	store := predict.NewTLEStore(cfg)
 	info := store.CacheInfo()
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(info)
+	body, err := json.Marshal(info)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// age_s ticks every second, so the tag covers only the cache file and
+	// its settings: a weak ETag that holds until the cache is rewritten or
+	// goes stale, or an element set's epoch grows too old.
+	stale := 0
+	for _, el := range info.Elements {
+		if el.Stale {
+			stale++
+		}
+	}
+	etag := weakETag(info.Exists, info.ModTime, info.Size, info.Fresh, info.Sources, info.SatelliteURLs, info.MaxAgeH, info.MaxEpochAgeD, stale)
+	var lastMod time.Time
+	if t, err := time.Parse(time.RFC3339, info.ModTime); err == nil {
+		lastMod = t
+	}
+	writeJSONTagged(w, r, body, etag, lastMod)
+}
+
+// nextPassResponse is the body of GET /api/next-pass. Pass is null when
+// no pass is predicted.
+type nextPassResponse struct {
+	Pass       *passJSON   `json:"pass"`
+	CountdownS int         `json:"countdown_s,omitempty"` // seconds to AOS
+	Clock      *api.Clock  `json:"clock"`
+	Station    stationJSON `json:"station"`
 }
 
 func (a *App) handleNextPass(w http.ResponseWriter, r *http.Request) {
 	cfg := a.getConfig()
+	tz, err := requestLocation(r, cfg)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	predictor := predict.NewPredictor(a.wsHub, cfg, a.log)
 	passes, err := predictor.ComputePasses()
 	if err != nil {
@@ -327,45 +890,51 @@ func (a *App) handleNextPass(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	resp := map[string]any{"pass": nil}
+	resp := nextPassResponse{Clock: clockAt(now, tz)}
 	if next != nil {
-		pj := passesToJSON([]predict.Pass{*next})
-		resp["pass"] = pj[0]
-		resp["countdown_s"] = int(time.Until(next.AOS).Seconds())
+		pj := passesToJSON([]predict.Pass{*next}, tz)
+		resp.Pass = &pj[0]
+		resp.CountdownS = int(time.Until(next.AOS).Seconds())
 	}
 
 	loc, _ := predictor.ResolveLocation()
-	resp["station"] = map[string]any{
-		"lat": loc.Lat,
-		"lon": loc.Lon,
-		"alt": loc.Alt,
-	}
+	resp.Station = newStationJSON(cfg.Station.Active, loc)
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
+// systemResponse is the body of GET /api/system.
+type systemResponse struct {
+	GoVersion    string         `json:"go_version"`
+	OS           string         `json:"os"`
+	Arch         string         `json:"arch"`
+	DataRoot     string         `json:"data_root"`
+	ConfigDir    string         `json:"config_dir"`
+	SDRBackend   string         `json:"sdr_backend"`
+	SDRAvailable bool           `json:"sdr_available"`
+	SDRError     string         `json:"sdr_error,omitempty"`
+	Disk         *api.DiskUsage `json:"disk,omitempty"`
+}
+
 func (a *App) handleSystem(w http.ResponseWriter, _ *http.Request) {
 	cfg := a.getConfig()
 
-	resp := map[string]any{
-		"go_version": runtime.Version(),
-		"os":         runtime.GOOS,
-		"arch":       runtime.GOARCH,
-		"data_root":  cfg.Data.Root,
-		"config_dir": config.DefaultConfigDir(),
+	resp := systemResponse{
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		DataRoot:   cfg.Data.Root,
+		ConfigDir:  config.DefaultConfigDir(),
+		SDRBackend: cfg.SDR.Backend,
+		Disk:       diskUsage(cfg.Data.Root),
 	}
 
-	// Check for rtl_fm.
-	if _, err := exec.LookPath("rtl_fm"); err == nil {
-		resp["sdr_available"] = true
+	// Check that the SDR backend can run.
+	if err := capture.CheckBackend(cfg.SDR); err == nil {
+		resp.SDRAvailable = true
 	} else {
-		resp["sdr_available"] = false
-	}
-
-	// Disk usage.
-	if du := diskUsage(cfg.Data.Root); du != nil {
-		resp["disk"] = du
+		resp.SDRError = err.Error()
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -376,6 +945,11 @@ func (a *App) handleSystem(w http.ResponseWriter, _ *http.Request) {
 // Phase 4: Logs + Stats + Enhanced Health
 // ---------------------------------------------------------------------------
 
+// logsResponse is the body of GET /api/logs.
+type logsResponse struct {
+	Logs []logEntry `json:"logs"`
+}
+
 func (a *App) handleLogs(w http.ResponseWriter, r *http.Request) {
 	a.logBufMu.Lock()
 	entries := make([]logEntry, len(a.logBuf))
@@ -402,90 +976,117 @@ func (a *App) handleLogs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]any{"logs": entries})
+	_ = json.NewEncoder(w).Encode(logsResponse{Logs: entries})
+}
+
+// statsResponse is the body of GET /api/stats.
+type statsResponse struct {
+	TotalCaptures       int            `json:"total_captures"`
+	TotalBytes          int64          `json:"total_bytes"`
+	CapturesBySatellite map[string]int `json:"captures_by_satellite"`
+	FailuresBySatellite map[string]int `json:"failures_by_satellite"`
+	LastCaptureAt       string         `json:"last_capture_at"` // RFC 3339, or "" before the first
+	UptimeSeconds       int64          `json:"uptime_seconds"`
 }
 
 func (a *App) handleStats(w http.ResponseWriter, _ *http.Request) {
-	a.captureStats.mu.Lock()
-	resp := map[string]any{
-		"total_captures":        a.captureStats.TotalCaptures,
-		"total_bytes":           a.captureStats.TotalBytes,
-		"captures_by_satellite": a.captureStats.CapturesBySat,
-		"last_capture_at":       a.captureStats.LastCaptureAt,
-		"uptime_seconds":        int64(time.Since(a.startedAt).Seconds()),
+	sum, err := a.history.Summary()
+	if err != nil {
+		jsonError(w, "read history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	last := ""
+	if !sum.LastCapture.IsZero() {
+		last = sum.LastCapture.UTC().Format(time.RFC3339)
+	}
+	resp := statsResponse{
+		TotalCaptures:       sum.TotalCaptures,
+		TotalBytes:          sum.TotalBytes,
+		CapturesBySatellite: sum.CapturesBySat,
+		FailuresBySatellite: sum.FailuresBySat,
+		LastCaptureAt:       last,
+		UptimeSeconds:       int64(time.Since(a.startedAt).Seconds()),
 	}
-	a.captureStats.mu.Unlock()
 
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
-func (a *App) handleHealthDetailed(w http.ResponseWriter, _ *http.Request) {
-	cfg := a.getConfig()
+// healthResponse is the body of /healthz with Accept: application/json.
+// Each check is an object with at least "ok".
+type healthResponse struct {
+	Healthy bool           `json:"healthy"`
+	Checks  map[string]any `json:"checks"`
+}
 
-	checks := map[string]any{}
-	allOK := true
+func (a *App) handleHealthDetailed(w http.ResponseWriter, _ *http.Request) {
+	allOK, checks := a.evaluateHealth()
 
-	// Check data directory.
-	tmpPath := filepath.Join(cfg.Data.Root, ".healthcheck")
-	if err := os.WriteFile(tmpPath, []byte("ok"), 0o644); err != nil {
-		checks["data_dir"] = map[string]any{"ok": false, "error": err.Error()}
-		allOK = false
-	} else {
-		os.Remove(tmpPath)
-		checks["data_dir"] = map[string]any{"ok": true, "path": cfg.Data.Root}
+	// Annotate checks that are currently suppressed by flap detection.
+	for name, v := range checks {
+		if m, ok := v.(map[string]any); ok && a.health.isFlapping(name) {
+			m["flapping"] = true
+		}
 	}
 
-	// Check TLE cache.
-	tlePath := filepath.Join(cfg.Data.Root, "weather_tle.txt")
-	if info, err := os.Stat(tlePath); err != nil {
-		checks["tle_cache"] = map[string]any{"ok": false, "error": "cache file not found"}
-		allOK = false
-	} else {
-		age := time.Since(info.ModTime())
-		maxAge := time.Duration(cfg.Predict.TLERefreshHours) * time.Hour
-		fresh := age < maxAge
-		if !fresh {
-			allOK = false
-		}
-		checks["tle_cache"] = map[string]any{
-			"ok":    fresh,
-			"age_s": int(age.Seconds()),
-			"fresh": fresh,
-		}
+	status := http.StatusOK
+	if !allOK {
+		status = http.StatusServiceUnavailable
 	}
 
-	// Check SDR (only in live mode).
-	if !cfg.Demo.Enabled {
-		if _, err := exec.LookPath("rtl_fm"); err != nil {
-			checks["sdr"] = map[string]any{"ok": false, "error": "rtl_fm not found in PATH"}
-			allOK = false
-		} else {
-			checks["sdr"] = map[string]any{"ok": true}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(healthResponse{Healthy: allOK, Checks: checks})
+}
+
+// healthHistoryResponse is the body of GET /api/health/history.
+type healthHistoryResponse struct {
+	IntervalS int            `json:"interval_s"`
+	History   []healthRecord `json:"history"`
+	Flapping  []string       `json:"flapping"`
+}
+
+func (a *App) handleHealthHistory(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if n, err := strconv.Atoi(limitStr); err == nil && n > 0 {
+			limit = n
 		}
 	}
 
-	// Config file readable.
-	if a.configPath != "" {
-		if _, err := os.Stat(a.configPath); err != nil {
-			checks["config_file"] = map[string]any{"ok": false, "error": err.Error()}
-			allOK = false
-		} else {
-			checks["config_file"] = map[string]any{"ok": true, "path": a.configPath}
-		}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(healthHistoryResponse{
+		IntervalS: int(healthCheckInterval.Seconds()),
+		History:   a.health.snapshot(limit),
+		Flapping:  a.health.flappingChecks(),
+	})
+}
+
+// annotationsResponse is the body of GET /api/annotations.
+type annotationsResponse struct {
+	Annotations []annotation `json:"annotations"`
+}
+
+func (a *App) handleAnnotations(w http.ResponseWriter, r *http.Request) {
+	// from/to are Unix milliseconds, as sent by Grafana's JSON datasources.
+	var from, to int64
+	if v := r.URL.Query().Get("from"); v != "" {
+		from, _ = strconv.ParseInt(v, 10, 64)
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		to, _ = strconv.ParseInt(v, 10, 64)
 	}
 
-	status := http.StatusOK
-	if !allOK {
-		status = http.StatusServiceUnavailable
+	entries := a.annotations.query(from, to)
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if n, err := strconv.Atoi(limitStr); err == nil && n > 0 && n < len(entries) {
+			entries = entries[len(entries)-n:]
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(map[string]any{
-		"healthy": allOK,
-		"checks":  checks,
-	})
+	_ = json.NewEncoder(w).Encode(annotationsResponse{Annotations: entries})
 }
 
 // ---------------------------------------------------------------------------
@@ -497,7 +1098,7 @@ func (a *App) handlePause(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if a.scheduler == nil {
+	if a.sched() == nil {
 		jsonError(w, "not available in demo mode", http.StatusConflict)
 		return
 	}
@@ -510,7 +1111,7 @@ func (a *App) handleResume(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if a.scheduler == nil {
+	if a.sched() == nil {
 		jsonError(w, "not available in demo mode", http.StatusConflict)
 		return
 	}
@@ -523,7 +1124,7 @@ func (a *App) handleSkip(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if a.scheduler == nil {
+	if a.sched() == nil {
 		jsonError(w, "not available in demo mode", http.StatusConflict)
 		return
 	}
@@ -536,7 +1137,7 @@ func (a *App) handleCancel(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
-	if a.scheduler == nil {
+	if a.sched() == nil {
 		jsonError(w, "not available in demo mode", http.StatusConflict)
 		return
 	}
@@ -544,19 +1145,31 @@ func (a *App) handleCancel(w http.ResponseWriter, r *http.Request) {
 	writeCommandResult(w, result)
 }
 
+// reloadRequest is the optional body of POST /api/reload, naming a
+// profile to switch to, such as {"profile": "palmdale"}.
+type reloadRequest struct {
+	Profile string `json:"profile"`
+}
+
+// reloadResponse lists what a reload changed.
+type reloadResponse struct {
+	OK      bool                 `json:"ok"`
+	Message string               `json:"message"`
+	Changes []config.FieldChange `json:"changes"`
+}
+
 func (a *App) handleReload(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Accept optional profile name in body: {"profile": "palmdale"}
-	var body struct {
-		Profile string `json:"profile"`
-	}
+	var body reloadRequest
 	_ = json.NewDecoder(r.Body).Decode(&body)
 
+	a.cfgMu.RLock()
 	loadPath := a.configPath
+	a.cfgMu.RUnlock()
 	if body.Profile != "" {
 		// Resolve profile name to a file in the config directory.
 		candidate := filepath.Join(config.DefaultConfigDir(), body.Profile+".toml")
@@ -572,27 +1185,17 @@ func (a *App) handleReload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	newCfg, err := config.Load(loadPath)
+	changes, err := a.reloadConfig(loadPath, "api")
 	if err != nil {
 		jsonError(w, "config reload failed: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	a.cfgMu.Lock()
-	a.cfg = newCfg
-	a.configPath = loadPath
-	a.cfgMu.Unlock()
-
-	a.emit("ephemerisd", map[string]any{
-		"type":    "log",
-		"level":   "info",
-		"message": fmt.Sprintf("config reloaded from %s", loadPath),
-	})
-
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(map[string]any{
-		"ok":      true,
-		"message": "configuration reloaded from " + loadPath,
+	_ = json.NewEncoder(w).Encode(reloadResponse{
+		OK:      true,
+		Message: "configuration reloaded from " + loadPath,
+		Changes: changes,
 	})
 }
 
@@ -600,25 +1203,36 @@ func (a *App) handleReload(w http.ResponseWriter, r *http.Request) {
 // Helpers
 // ---------------------------------------------------------------------------
 
-// sendSchedulerCommand sends a command to the scheduler and waits for the reply.
+// sendSchedulerCommand sends a command to the scheduler and waits for the
+// reply. It gives up if the scheduler is stopped by a mode switch meanwhile.
 func (a *App) sendSchedulerCommand(cmdType string, payload json.RawMessage) scheduler.CommandResult {
+	r := a.active.Load()
+	if r == nil || r.sched == nil {
+		return scheduler.CommandResult{Error: "not available in demo mode"}
+	}
 	reply := make(chan scheduler.CommandResult, 1)
-	a.scheduler.Commands <- scheduler.Command{
+	select {
+	case r.sched.Commands <- scheduler.Command{
 		Type:    cmdType,
 		Payload: payload,
 		Reply:   reply,
+	}:
+	case <-r.done:
+		return scheduler.CommandResult{Error: "scheduler stopped"}
+	}
+	select {
+	case res := <-reply:
+		return res
+	case <-r.done:
+		return scheduler.CommandResult{Error: "scheduler stopped"}
 	}
-	return <-reply
 }
 
 // jsonError writes a JSON error response.
 func jsonError(w http.ResponseWriter, msg string, code int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(code)
-	_ = json.NewEncoder(w).Encode(map[string]any{
-		"ok":    false,
-		"error": msg,
-	})
+	_ = json.NewEncoder(w).Encode(api.ErrorResponse{Error: msg})
 }
 
 // writeCommandResult writes a scheduler.CommandResult as JSON.
@@ -631,40 +1245,117 @@ func writeCommandResult(w http.ResponseWriter, result scheduler.CommandResult) {
 }
 
 type passJSON struct {
+	// ID names the pass in /api/passes/{id}/track and /api/schedule.
+	ID          string  `json:"id"`
 	Satellite   string  `json:"satellite"`
 	NoradID     int     `json:"norad_id"`
 	FreqHz      int     `json:"freq_hz"`
 	AOS         string  `json:"aos"`
 	LOS         string  `json:"los"`
+	AOSUnix     int64   `json:"aos_unix"`
+	LOSUnix     int64   `json:"los_unix"`
 	MaxElev     float64 `json:"max_elev"`
 	MaxElevTime string  `json:"max_elev_time"`
 	AOSAzimuth  float64 `json:"aos_azimuth"`
 	LOSAzimuth  float64 `json:"los_azimuth"`
+	Direction   string  `json:"direction"`
 	DurationS   int     `json:"duration_s"`
+	SunSep      float64 `json:"sun_separation"`
+	SunFlag     bool    `json:"sun_interference"`
+	Daylight    bool    `json:"daylight"`
+	CloudCover  *int    `json:"cloud_cover,omitempty"`
+	Quality     int     `json:"quality"`
+	LowQuality  bool    `json:"low_quality,omitempty"` // scores below predict.min_quality
+	Disabled    bool    `json:"disabled,omitempty"`    // satellite is not scheduled
+	Blackout    string  `json:"blackout,omitempty"`    // the blackout window that keeps it out of the schedule
+	// Score is how the scheduler rates the pass, with ?score=true.
+	Score *passScoreJSON `json:"score,omitempty"`
+	// RecordAOS and RecordLOS are set when station.usable_elevation trims
+	// the recording to part of the pass.
+	RecordAOS     string `json:"record_aos,omitempty"`
+	RecordLOS     string `json:"record_los,omitempty"`
+	RecordAOSUnix int64  `json:"record_aos_unix,omitempty"`
+	RecordLOSUnix int64  `json:"record_los_unix,omitempty"`
+	// The same times in the station's zone, or the one asked for with
+	// ?tz=, for clients without a time zone database.
+	AOSLocal         string `json:"aos_local"`
+	LOSLocal         string `json:"los_local"`
+	MaxElevTimeLocal string `json:"max_elev_time_local"`
+	RecordAOSLocal   string `json:"record_aos_local,omitempty"`
+	RecordLOSLocal   string `json:"record_los_local,omitempty"`
 }
 
-func passesToJSON(passes []predict.Pass) []passJSON {
+// passScoreJSON is how the scheduler rates a pass: the score that decides
+// conflicts under predict.conflict_policy, which policy is in force, and
+// the points of the quality score that predict.min_quality and the
+// energy budget go by.
+type passScoreJSON struct {
+	Policy string `json:"policy"`
+	scheduler.PassScore
+	Quality predict.QualityFactors `json:"quality"`
+}
+
+// newPassScoreJSON rates p with cfg's satellite priorities, rounded for
+// display.
+func newPassScoreJSON(cfg config.Config, p predict.Pass) *passScoreJSON {
+	round := func(v float64) float64 { return math.Round(v*100) / 100 }
+	s := scheduler.ScorePass(p, cfg.SatellitePriority(p.Satellite.Name))
+	s.Value = round(s.Value)
+	s.Factors.PriorityPoints = round(s.Factors.PriorityPoints)
+	s.Factors.ElevationPoints = round(s.Factors.ElevationPoints)
+	s.Factors.LengthPoints = round(s.Factors.LengthPoints)
+	q := p.QualityFactors
+	q.ElevationPoints = round(q.ElevationPoints)
+	q.HighPoints = round(q.HighPoints)
+	q.LengthPoints = round(q.LengthPoints)
+	return &passScoreJSON{Policy: cfg.Predict.ConflictPolicy, PassScore: s, Quality: q}
+}
+
+// passesToJSON renders passes with their times in UTC and in loc.
+func passesToJSON(passes []predict.Pass, loc *time.Location) []passJSON {
 	result := make([]passJSON, len(passes))
 	for i, p := range passes {
 		result[i] = passJSON{
+			ID:          scheduler.PassID(p),
 			Satellite:   p.Satellite.Name,
 			NoradID:     p.Satellite.NoradID,
 			FreqHz:      p.Satellite.Freq,
 			AOS:         p.AOS.Format("2006-01-02T15:04:05Z07:00"),
 			LOS:         p.LOS.Format("2006-01-02T15:04:05Z07:00"),
+			AOSUnix:     p.AOS.Unix(),
+			LOSUnix:     p.LOS.Unix(),
 			MaxElev:     p.MaxElev,
 			MaxElevTime: p.MaxElevTime.Format("2006-01-02T15:04:05Z07:00"),
 			AOSAzimuth:  p.AOSAzimuth,
 			LOSAzimuth:  p.LOSAzimuth,
+			Direction:   p.Direction(),
 			DurationS:   int(p.Duration.Seconds()),
+			SunSep:      math.Round(p.SunSeparation*10) / 10,
+			SunFlag:     p.SunInterference,
+			Daylight:    p.Daylight,
+			CloudCover:  p.CloudCover,
+			Quality:     p.Quality,
+
+			AOSLocal:         p.AOS.In(loc).Format(time.RFC3339),
+			LOSLocal:         p.LOS.In(loc).Format(time.RFC3339),
+			MaxElevTimeLocal: p.MaxElevTime.In(loc).Format(time.RFC3339),
+		}
+		if p.Trimmed() {
+			result[i].RecordAOS = p.RecordAOS.Format("2006-01-02T15:04:05Z07:00")
+			result[i].RecordLOS = p.RecordLOS.Format("2006-01-02T15:04:05Z07:00")
+			result[i].RecordAOSUnix = p.RecordAOS.Unix()
+			result[i].RecordLOSUnix = p.RecordLOS.Unix()
+			result[i].RecordAOSLocal = p.RecordAOS.In(loc).Format(time.RFC3339)
+			result[i].RecordLOSLocal = p.RecordLOS.In(loc).Format(time.RFC3339)
 		}
 	}
 	return result
 }
 
-// parseCaptureName extracts satellite and timestamp from "NOAA-19_20260215T143022Z.wav".
+// parseCaptureName extracts satellite and timestamp from "NOAA-19_20260215T143022Z.wav",
+// or the same name compressed to ".flac".
 func parseCaptureName(filename string) (satellite, timestamp string) {
-	name := strings.TrimSuffix(filename, ".wav")
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
 	idx := strings.LastIndex(name, "_")
 	if idx < 0 {
 		return name, ""
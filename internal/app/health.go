@@ -0,0 +1,471 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+)
+
+// Health history tuning. Checks are sampled once a minute and a day of
+// samples is retained, in memory and in healthHistoryFile so a restart
+// does not lose them. A check that changes state flapThreshold times
+// within flapWindow is considered flapping and its change notifications
+// are suppressed until it has been stable for a full window.
+const (
+	healthCheckInterval = time.Minute
+	healthHistoryCap    = 1440
+	healthRetention     = healthHistoryCap * healthCheckInterval
+	flapWindow          = 30 * time.Minute
+	flapThreshold       = 4
+)
+
+// healthHistoryFile is the health history under data.root, one JSON
+// healthRecord per line, oldest first.
+const healthHistoryFile = "health.jsonl"
+
+// healthRecord is a single health evaluation stored in the history.
+type healthRecord struct {
+	TS      string         `json:"ts"`
+	Healthy bool           `json:"healthy"`
+	Checks  map[string]any `json:"checks"`
+}
+
+// checkState tracks the recent state changes of one named check.
+type checkState struct {
+	ok       bool
+	changes  []time.Time
+	flapping bool
+}
+
+// healthChange describes a check transition worth announcing. Kind is
+// "changed", "flapping", or "stable".
+type healthChange struct {
+	Check string
+	OK    bool
+	Kind  string
+}
+
+// healthTracker keeps the health evaluations of the last
+// healthRetention, and per-check flap detection state. Each evaluation is
+// appended to a JSON Lines file, which is rewritten with only the
+// retained records once it holds twice as many lines.
+type healthTracker struct {
+	mu      sync.Mutex
+	history []healthRecord
+	cap     int
+	checks  map[string]*checkState
+	path    string   // "" keeps the history in memory only
+	f       *os.File // open for appending to path
+	lines   int      // lines in path
+}
+
+// newHealthTracker loads the health history at path, drops the records
+// older than healthRetention at now, and opens it for appending. An empty
+// path gives a tracker that keeps the history in memory only. A line that
+// cannot be parsed, such as one cut short by a power loss, is skipped.
+// The checks of the newest record are the baseline for the next
+// evaluation, so a check that failed before a restart and has since
+// recovered is announced.
+func newHealthTracker(path string, capacity int, now time.Time) (*healthTracker, error) {
+	t := &healthTracker{
+		history: make([]healthRecord, 0, capacity),
+		cap:     capacity,
+		checks:  make(map[string]*checkState),
+		path:    path,
+	}
+	if path == "" {
+		return t, nil
+	}
+
+	lines := 0
+	cutoff := now.Add(-healthRetention)
+	if f, err := os.Open(path); err == nil {
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 64*1024), 1024*1024)
+		for sc.Scan() {
+			lines++
+			var rec healthRecord
+			if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+				continue
+			}
+			if ts, err := time.Parse(time.RFC3339, rec.TS); err != nil || ts.Before(cutoff) {
+				continue
+			}
+			if len(t.history) >= t.cap {
+				t.history = t.history[1:]
+			}
+			t.history = append(t.history, rec)
+		}
+		err := sc.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if n := len(t.history); n > 0 {
+		for name, v := range t.history[n-1].Checks {
+			t.checks[name] = &checkState{ok: checkOK(v)}
+		}
+	}
+
+	if lines != len(t.history) {
+		if err := t.rewrite(); err != nil {
+			return nil, fmt.Errorf("compact %s: %w", path, err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	t.f, t.lines = f, len(t.history)
+	return t, nil
+}
+
+// openHealthHistory opens the health history under root, keeping it in
+// memory for this run if it cannot be opened, as openHistory does.
+func (a *App) openHealthHistory(root string) *healthTracker {
+	t, err := newHealthTracker(filepath.Join(root, healthHistoryFile), healthHistoryCap, time.Now())
+	if err != nil {
+		a.log.Error("keeping health history in memory until restart", "component", "ephemerisd", "err", err)
+		t, _ = newHealthTracker("", healthHistoryCap, time.Now())
+	}
+	return t
+}
+
+// persist appends rec to the history file, first rewriting the file with
+// the retained records if it has grown to twice their number. If the
+// rewrite or an append fails, the file is left closed and the next call
+// rewrites it again, so saving resumes once the disk recovers. Callers
+// hold mu.
+func (t *healthTracker) persist(rec healthRecord) error {
+	if t.path == "" {
+		return nil
+	}
+	if t.f == nil || t.lines >= 2*t.cap {
+		if t.f != nil {
+			t.f.Close()
+			t.f = nil
+		}
+		if err := t.rewrite(); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(t.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		t.f, t.lines = f, len(t.history)
+		return nil // the rewrite included rec
+	}
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := t.f.Write(append(b, '\n')); err != nil {
+		// A partial line is skipped on load; the rewrite drops it.
+		t.f.Close()
+		t.f = nil
+		return err
+	}
+	t.lines++
+	return nil
+}
+
+// rewrite replaces the history file with the records in memory, through
+// a temp file so a crash leaves the old or the new one. Callers hold mu,
+// except newHealthTracker.
+func (t *healthTracker) rewrite() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, rec := range t.history {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, t.path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// record appends an evaluation to the history and updates flap state. It
+// returns the transitions that should be announced; plain state changes on
+// a flapping check are swallowed. An error writing the history file does
+// not stop the evaluation being kept in memory.
+func (t *healthTracker) record(now time.Time, healthy bool, checks map[string]any) ([]healthChange, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	rec := healthRecord{
+		TS:      now.UTC().Format(time.RFC3339),
+		Healthy: healthy,
+		Checks:  checks,
+	}
+	cutoff := now.Add(-healthRetention).UTC().Format(time.RFC3339)
+	drop := 0
+	for drop < len(t.history) && (len(t.history)-drop >= t.cap || t.history[drop].TS < cutoff) {
+		drop++
+	}
+	t.history = append(t.history[drop:], rec)
+	err := t.persist(rec)
+
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []healthChange
+	for _, name := range names {
+		ok := checkOK(checks[name])
+		st, seen := t.checks[name]
+		if !seen {
+			// First observation establishes the baseline; nothing to announce.
+			t.checks[name] = &checkState{ok: ok}
+			continue
+		}
+
+		changed := ok != st.ok
+		if changed {
+			st.ok = ok
+			st.changes = append(st.changes, now)
+		}
+
+		// Drop changes that have aged out of the window.
+		cutoff := now.Add(-flapWindow)
+		kept := st.changes[:0]
+		for _, ts := range st.changes {
+			if ts.After(cutoff) {
+				kept = append(kept, ts)
+			}
+		}
+		st.changes = kept
+
+		wasFlapping := st.flapping
+		if !st.flapping && len(st.changes) >= flapThreshold {
+			st.flapping = true
+		} else if st.flapping && len(st.changes) == 0 {
+			st.flapping = false
+		}
+
+		switch {
+		case st.flapping && !wasFlapping:
+			out = append(out, healthChange{Check: name, OK: ok, Kind: "flapping"})
+		case !st.flapping && wasFlapping:
+			out = append(out, healthChange{Check: name, OK: ok, Kind: "stable"})
+		case changed && !st.flapping:
+			out = append(out, healthChange{Check: name, OK: ok, Kind: "changed"})
+		}
+	}
+	return out, err
+}
+
+// isFlapping reports whether the named check is currently flapping.
+func (t *healthTracker) isFlapping(name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st, ok := t.checks[name]
+	return ok && st.flapping
+}
+
+// flappingChecks returns the names of all currently flapping checks.
+func (t *healthTracker) flappingChecks() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	names := []string{}
+	for name, st := range t.checks {
+		if st.flapping {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// snapshot returns a copy of the most recent limit records (all if limit <= 0).
+func (t *healthTracker) snapshot(limit int) []healthRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entries := t.history
+	if limit > 0 && limit < len(entries) {
+		entries = entries[len(entries)-limit:]
+	}
+	out := make([]healthRecord, len(entries))
+	copy(out, entries)
+	return out
+}
+
+// latest returns the most recent record, or false if none has been taken.
+func (t *healthTracker) latest() (healthRecord, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.history) == 0 {
+		return healthRecord{}, false
+	}
+	return t.history[len(t.history)-1], true
+}
+
+// checkOK extracts the "ok" flag from a check result map.
+func checkOK(v any) bool {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	b, _ := m["ok"].(bool)
+	return b
+}
+
+// evaluateHealth runs every component check against the current config and
+// reports whether all of them passed.
+func (a *App) evaluateHealth() (bool, map[string]any) {
+	cfg := a.getConfig()
+
+	checks := map[string]any{}
+	allOK := true
+
+	// Check data directory. A read-only one stops captures until it is
+	// writable again.
+	err := probeStorage(cfg.Data.Root)
+	a.noteWrite(err)
+	if err != nil {
+		check := map[string]any{"ok": false, "error": err.Error()}
+		if readOnly, since, _ := a.storage.status(); readOnly {
+			check["read_only"] = true
+			check["since"] = since.Format(time.RFC3339)
+		}
+		checks["data_dir"] = check
+		allOK = false
+	} else {
+		checks["data_dir"] = map[string]any{"ok": true, "path": cfg.Data.Root}
+	}
+
+	// Check TLE cache.
+	tlePath := filepath.Join(cfg.Data.Root, "weather_tle.txt")
+	if info, err := os.Stat(tlePath); err != nil {
+		checks["tle_cache"] = map[string]any{"ok": false, "error": "cache file not found"}
+		allOK = false
+	} else {
+		age := time.Since(info.ModTime())
+		maxAge := time.Duration(cfg.Predict.TLERefreshHours) * time.Hour
+		fresh := age < maxAge
+		if !fresh {
+			allOK = false
+		}
+		checks["tle_cache"] = map[string]any{
+			"ok":    fresh,
+			"age_s": int(age.Seconds()),
+			"fresh": fresh,
+		}
+	}
+
+	// Check SDR (only in live mode).
+	if !a.isDemo() {
+		if err := capture.CheckBackend(cfg.SDR); err != nil {
+			checks["sdr"] = map[string]any{"ok": false, "backend": cfg.SDR.Backend, "error": err.Error()}
+			allOK = false
+		} else {
+			checks["sdr"] = map[string]any{"ok": true, "backend": cfg.SDR.Backend}
+		}
+	}
+
+	// Captures the last integrity scrub found corrupt.
+	if last, _ := a.scrub.report(); last != nil {
+		corrupt := a.corruptCaptures(cfg.Data.Root)
+		if len(corrupt) > 0 {
+			allOK = false
+		}
+		checks["captures"] = map[string]any{
+			"ok":         len(corrupt) == 0,
+			"corrupt":    corrupt,
+			"last_scrub": last.FinishedAt,
+		}
+	}
+
+	// Config file readable.
+	a.cfgMu.RLock()
+	configPath := a.configPath
+	a.cfgMu.RUnlock()
+	if configPath != "" {
+		if _, err := os.Stat(configPath); err != nil {
+			checks["config_file"] = map[string]any{"ok": false, "error": err.Error()}
+			allOK = false
+		} else {
+			checks["config_file"] = map[string]any{"ok": true, "path": configPath}
+		}
+	}
+
+	return allOK, checks
+}
+
+// healthLoop samples component health on a fixed interval, records it in
+// the history buffer, and announces check transitions that are not
+// suppressed by flap detection.
+func (a *App) healthLoop(ctx context.Context) {
+	a.sampleHealth()
+
+	t := time.NewTicker(healthCheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			a.sampleHealth()
+		}
+	}
+}
+
+func (a *App) sampleHealth() {
+	healthy, checks := a.evaluateHealth()
+	changes, err := a.health.record(time.Now(), healthy, checks)
+	if err != nil {
+		a.log.Warn("could not save health sample", "component", "ephemerisd", "file", healthHistoryFile, "err", err)
+	}
+	for _, c := range changes {
+		level := "info"
+		var msg string
+		switch c.Kind {
+		case "flapping":
+			level = "warn"
+			msg = fmt.Sprintf("health check %s is flapping, suppressing notifications", c.Check)
+		case "stable":
+			msg = fmt.Sprintf("health check %s stopped flapping (ok=%t)", c.Check, c.OK)
+		default:
+			if c.OK {
+				msg = fmt.Sprintf("health check %s recovered", c.Check)
+			} else {
+				level = "warn"
+				msg = fmt.Sprintf("health check %s failed", c.Check)
+			}
+		}
+
+		a.emit("ephemerisd", map[string]any{
+			"type":     "health",
+			"check":    c.Check,
+			"ok":       c.OK,
+			"flapping": c.Kind == "flapping",
+			"message":  msg,
+		})
+		a.emit("ephemerisd", map[string]any{
+			"type":    "log",
+			"level":   level,
+			"message": msg,
+		})
+	}
+}
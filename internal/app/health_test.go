@@ -0,0 +1,109 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHealthHistoryPersists checks that the health history survives a
+// restart, trimmed to the retention window, and that the last sample
+// before it is the baseline for announcing changes.
+func TestHealthHistoryPersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), healthHistoryFile)
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	ok := map[string]any{"sdr": map[string]any{"ok": true}}
+	failing := map[string]any{"sdr": map[string]any{"ok": false}}
+
+	tr, err := newHealthTracker(path, healthHistoryCap, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 10 {
+		if _, err := tr.record(start.Add(time.Duration(i)*time.Hour), true, ok); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := tr.record(start.Add(10*time.Hour), false, failing); err != nil {
+		t.Fatal(err)
+	}
+	tr.f.Close()
+
+	// Restarted 30.5 hours on, the first 7 samples are past the window.
+	now := start.Add(30*time.Hour + 30*time.Minute)
+	tr, err = newHealthTracker(path, healthHistoryCap, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tr.f.Close()
+	got := tr.snapshot(0)
+	if len(got) != 4 || got[0].TS != start.Add(7*time.Hour).Format(time.RFC3339) || got[3].Healthy {
+		t.Fatalf("history after restart = %+v, want the samples from 7h to 10h", got)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(b), "\n"); n != 4 {
+		t.Errorf("file has %d lines after the restart, want 4", n)
+	}
+
+	changes, err := tr.record(now, true, ok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].Check != "sdr" || !changes[0].OK || changes[0].Kind != "changed" {
+		t.Errorf("changes = %+v, want sdr recovered", changes)
+	}
+	if got := tr.snapshot(0); len(got) != 5 {
+		t.Errorf("history has %d samples, want 5", len(got))
+	}
+}
+
+// TestHealthHistoryRetriesRewrite checks that a failed compaction does
+// not stop the history being saved: the next sample rewrites the file
+// once it can.
+func TestHealthHistoryRetriesRewrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), healthHistoryFile)
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	ok := map[string]any{"sdr": map[string]any{"ok": true}}
+
+	tr, err := newHealthTracker(path, 2, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(i int) time.Time { return start.Add(time.Duration(i) * time.Minute) }
+	for i := range 4 {
+		if _, err := tr.record(at(i), true, ok); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// A directory where the temp file goes makes the rewrite fail.
+	if err := os.Mkdir(path+".tmp", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 4; i < 6; i++ {
+		if _, err := tr.record(at(i), true, ok); err == nil {
+			t.Fatalf("sample %d saved with the rewrite failing", i)
+		}
+	}
+
+	if err := os.Remove(path + ".tmp"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.record(at(6), true, ok); err != nil {
+		t.Fatalf("sample after recovery: %v", err)
+	}
+	defer tr.f.Close()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[1], at(6).Format(time.RFC3339)) {
+		t.Errorf("file after recovery = %q, want the last 2 samples", lines)
+	}
+}
@@ -0,0 +1,212 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/store"
+)
+
+// historyFile is the pass history database under data.root.
+const historyFile = "history.db"
+
+// legacyHistoryFile is the JSON Lines pass history of earlier versions,
+// imported into historyFile once and then renamed with an .imported
+// suffix.
+const legacyHistoryFile = "history.jsonl"
+
+// openHistory opens the pass history under root. If it cannot be opened
+// the daemon keeps the history in memory for this run rather than not
+// starting.
+func (a *App) openHistory(root string) *store.Store {
+	h, err := store.Open(filepath.Join(root, historyFile))
+	if err != nil {
+		a.log.Error("keeping history in memory until restart", "component", "history", "err", err)
+		h, _ = store.Open("")
+		return h
+	}
+	legacy := filepath.Join(root, legacyHistoryFile)
+	if _, err := os.Stat(legacy); err != nil {
+		return h
+	}
+	n, err := h.ImportJSONL(legacy)
+	if err == nil {
+		err = os.Rename(legacy, legacy+".imported")
+	}
+	if err != nil {
+		a.log.Error("could not import the old pass history", "component", "history", "file", legacyHistoryFile, "err", err)
+	} else {
+		a.log.Info("imported the old pass history", "component", "history", "file", legacyHistoryFile, "records", n)
+	}
+	return h
+}
+
+// onPassOutcome records how a pass attempt ended.
+func (a *App) onPassOutcome(rec store.Record) {
+	if _, err := a.history.Add(rec); err != nil {
+		a.log.Warn("could not record pass", "component", "history", "satellite", rec.Satellite, "err", err)
+	}
+}
+
+// recordCaptureFile adds the capture at path to the history from its file
+// and sidecar.
+func (a *App) recordCaptureFile(path, source string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(path)
+	rec := store.Record{
+		File:       name,
+		Bytes:      info.Size(),
+		Outcome:    store.OutcomeCaptured,
+		Source:     source,
+		RecordedAt: info.ModTime().UTC(),
+	}
+	var ts string
+	rec.Satellite, ts = parseCaptureName(name)
+	rec.AOS, _ = time.Parse("20060102T150405Z", ts)
+	if meta, err := capture.ReadMetadata(path); err == nil {
+		rec.NoradID, rec.MaxElev, rec.Station = meta.NoradID, meta.MaxElev, meta.Station
+		if meta.Satellite != "" {
+			rec.Satellite = meta.Satellite
+		}
+		if t, err := time.Parse(time.RFC3339, meta.AOS); err == nil {
+			rec.AOS = t
+		}
+		rec.LOS, _ = time.Parse(time.RFC3339, meta.LOS)
+		if t, err := time.Parse(time.RFC3339, meta.RecordedAt); err == nil {
+			rec.RecordedAt = t
+		}
+	}
+	_, err = a.history.Add(rec)
+	return err
+}
+
+// listCaptures returns the captures in the history whose files are still
+// in root, in filename order, with details from each sidecar. Links are
+// made for r, and left out on the public listener, which serves no files.
+func (a *App) listCaptures(r *http.Request, root string) ([]captureInfo, error) {
+	recs, err := a.history.Captures()
+	if err != nil {
+		return nil, err
+	}
+	captures := make([]captureInfo, 0, len(recs))
+	for _, rec := range recs {
+		path := filepath.Join(root, rec.File)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		meta, _ := capture.ReadMetadata(path)
+		_, ts := parseCaptureName(rec.File)
+		c := captureInfo{
+			Filename:  rec.File,
+			Satellite: rec.Satellite,
+			Timestamp: ts,
+			Size:      info.Size(),
+			Station:   meta.Station,
+			SHA256:    meta.SHA256,
+			Corrupt:   meta.Corrupt,
+			Imported:  meta.ImportedFrom,
+			Images:    meta.Images,
+			Tags:      meta.Tags,
+			Upload:    a.uploadStatus(rec.File, rec.RemoteURL),
+			Decode:    meta.DecodeStatus(),
+		}
+		if d := meta.Decode; d != nil && d.Status == capture.DecodeDone {
+			c.SyncPercent, c.SNR, c.ImageScore = &d.SyncPercent, &d.SNR, &d.Score
+		} else if d != nil {
+			c.DecodeError = d.Error
+		}
+		if !isPublic(r) {
+			c.URL = a.fileURL(r, rec.File)
+			for _, img := range meta.Images {
+				c.Products = append(c.Products, captureProduct{Name: img, URL: a.fileURL(r, img)})
+			}
+		}
+		captures = append(captures, c)
+	}
+	return captures, nil
+}
+
+// historyResponse is the body of GET /api/history.
+type historyResponse struct {
+	History []store.Record `json:"history"`
+	Count   int            `json:"count"`
+}
+
+// handleHistory serves the pass history, newest first. Every parameter is
+// optional; since and until take RFC 3339 times or a duration back from
+// now, such as 72h.
+//
+//	GET /api/history?satellite=NOAA-19&outcome=failed&since=168h&min_elev=30&limit=50
+func (a *App) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	f := store.Filter{
+		Satellite: q.Get("satellite"),
+		Outcome:   q.Get("outcome"),
+		Station:   q.Get("station"),
+	}
+	if f.Outcome != "" && !slices.Contains(store.Outcomes, f.Outcome) {
+		jsonError(w, fmt.Sprintf("unknown outcome %q (use %s)", f.Outcome, strings.Join(store.Outcomes, ", ")), http.StatusBadRequest)
+		return
+	}
+	var err error
+	if f.Since, err = parseHistoryTime(q.Get("since")); err != nil {
+		jsonError(w, "since: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if f.Until, err = parseHistoryTime(q.Get("until")); err != nil {
+		jsonError(w, "until: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if v := q.Get("min_elev"); v != "" {
+		if f.MinElev, err = strconv.ParseFloat(v, 64); err != nil {
+			jsonError(w, "min_elev must be a number of degrees", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 0 {
+			jsonError(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+
+	recs, err := a.history.Query(f)
+	if err != nil {
+		jsonError(w, "read history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(historyResponse{History: recs, Count: len(recs)})
+}
+
+// parseHistoryTime reads an RFC 3339 time, or a duration meaning that long
+// ago. An empty string is the zero time.
+func parseHistoryTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("%q is neither an RFC 3339 time nor a duration such as 72h", s)
+	}
+	return time.Now().Add(-d), nil
+}
@@ -0,0 +1,222 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+	"github.com/large-farva/ephemeris-engine/internal/ws"
+)
+
+// knownStates lists every daemon state so the state metric can expose a
+// full enum (exactly one series set to 1) rather than a single string label.
+var knownStates = []string{"BOOTING", "IDLE", "WAITING_FOR_PASS", "RECORDING", "DECODING"}
+
+// metricsWriter accumulates Prometheus text exposition output.
+type metricsWriter struct {
+	buf bytes.Buffer
+}
+
+// family writes the HELP and TYPE lines for a metric.
+func (m *metricsWriter) family(name, typ, help string) {
+	fmt.Fprintf(&m.buf, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(&m.buf, "# TYPE %s %s\n", name, typ)
+}
+
+// sample writes one series. labels alternates key, value.
+func (m *metricsWriter) sample(name string, value float64, labels ...string) {
+	m.buf.WriteString(name)
+	if len(labels) > 0 {
+		m.buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				m.buf.WriteByte(',')
+			}
+			fmt.Fprintf(&m.buf, "%s=%q", labels[i], escapeLabel(labels[i+1]))
+		}
+		m.buf.WriteByte('}')
+	}
+	fmt.Fprintf(&m.buf, " %g\n", value)
+}
+
+// escapeLabel prepares a label value for %q formatting. Prometheus only
+// recognises \\, \" and \n escapes, so strip anything else non-printable.
+func escapeLabel(v string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\n' {
+			return -1
+		}
+		return r
+	}, v)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// handleMetrics serves daemon metrics in the Prometheus text format. Series
+// carry satellite and check labels so alert rules such as "no capture in
+// 24h", "TLE stale", and "disk low" can be written directly against them.
+func (a *App) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	cfg := a.getConfig()
+	var m metricsWriter
+
+	m.family("ephemeris_build_info", "gauge", "Build information for the running daemon.")
+	m.sample("ephemeris_build_info", 1, "version", Version, "go_version", GoVersion)
+
+	m.family("ephemeris_uptime_seconds", "gauge", "Seconds since the daemon started.")
+	m.sample("ephemeris_uptime_seconds", time.Since(a.startedAt).Seconds())
+
+	state := a.state.Load().(string)
+	m.family("ephemeris_state", "gauge", "Current scheduler phase; exactly one state is 1.")
+	for _, s := range knownStates {
+		m.sample("ephemeris_state", boolValue(s == state), "state", s)
+	}
+
+	mode := modeName(a.isDemo())
+	m.family("ephemeris_mode", "gauge", "Operating mode; exactly one mode is 1.")
+	m.sample("ephemeris_mode", boolValue(mode == "live"), "mode", "live")
+	m.sample("ephemeris_mode", boolValue(mode == "demo"), "mode", "demo")
+
+	if s := a.sched(); s != nil {
+		m.family("ephemeris_scheduler_paused", "gauge", "Whether automatic pass scheduling is paused.")
+		m.sample("ephemeris_scheduler_paused", boolValue(s.IsPaused()))
+
+		hung, last, _ := a.schedulerLiveness(time.Now())
+		m.family("ephemeris_scheduler_hung", "gauge", "Whether the scheduler loop has missed its heartbeat deadline.")
+		m.sample("ephemeris_scheduler_hung", boolValue(hung))
+		m.family("ephemeris_scheduler_last_beat_timestamp_seconds", "gauge", "Unix time of the scheduler loop's last heartbeat.")
+		m.sample("ephemeris_scheduler_last_beat_timestamp_seconds", float64(last.Unix()))
+	}
+
+	hub := a.wsHub.Stats()
+	m.family("ephemeris_ws_clients", "gauge", "Open WebSocket event stream connections.")
+	m.sample("ephemeris_ws_clients", float64(hub.Clients))
+	m.family("ephemeris_ws_rejected_total", "counter", "WebSocket connections refused by origin or connection limits.")
+	for _, reason := range []string{"origin", "max_clients", "max_clients_per_ip"} {
+		m.sample("ephemeris_ws_rejected_total", float64(hub.Rejected[reason]), "reason", reason)
+	}
+	m.family("ephemeris_ws_connections_total", "counter", "WebSocket event stream connections accepted since start.")
+	m.sample("ephemeris_ws_connections_total", float64(hub.Accepted))
+	m.family("ephemeris_ws_evictions_total", "counter", "WebSocket clients dropped for not answering pings or not keeping up with writes.")
+	for _, reason := range []string{"pong_timeout", "write_failed"} {
+		m.sample("ephemeris_ws_evictions_total", float64(hub.Evicted[reason]), "reason", reason)
+	}
+	m.family("ephemeris_ws_backlog", "gauge", "Events queued for delivery to WebSocket clients and subscribers.")
+	m.sample("ephemeris_ws_backlog", float64(hub.Backlog))
+	m.family("ephemeris_ws_dropped_total", "counter", "Events dropped because the delivery queue was full.")
+	m.sample("ephemeris_ws_dropped_total", float64(hub.Dropped))
+	m.family("ephemeris_ws_throttle_rate_hz", "gauge", "Highest rate each stream of progress, waterfall and position events is currently sent at.")
+	m.sample("ephemeris_ws_throttle_rate_hz", 1/hub.ThrottleInterval.Seconds())
+	m.family("ephemeris_ws_subscribed_clients", "gauge", "WebSocket clients that receive only the event types they subscribed to.")
+	m.sample("ephemeris_ws_subscribed_clients", float64(hub.Subscribed))
+	m.family("ephemeris_ws_withheld_total", "counter", "Events not sent to WebSocket clients because they did not subscribe to their type.")
+	m.sample("ephemeris_ws_withheld_total", float64(hub.Withheld))
+	m.family("ephemeris_ws_debug_clients", "gauge", "WebSocket clients subscribed to the debug tap.")
+	m.sample("ephemeris_ws_debug_clients", float64(hub.DebugClients))
+	m.family("ephemeris_ws_coalesced_total", "counter", "Throttled events replaced by a newer update before they were sent.")
+	types := make([]string, 0, len(ws.ThrottledTypes))
+	for typ := range ws.ThrottledTypes {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	for _, typ := range types {
+		m.sample("ephemeris_ws_coalesced_total", float64(hub.Coalesced[typ]), "type", typ)
+	}
+
+	// Capture counters, one series per catalog satellite so absent data
+	// reads as zero rather than a missing series. Zeros from a history
+	// that cannot be read would look like a counter reset, so the scrape
+	// fails instead.
+	sum, err := a.history.Summary()
+	if err != nil {
+		http.Error(w, "read history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	bySat, failedBySat, lastBySat := sum.CapturesBySat, sum.FailuresBySat, sum.LastBySat
+	totalBytes := sum.TotalBytes
+
+	catalog := capture.Catalog()
+	sats := make([]string, 0, len(catalog))
+	seen := map[string]bool{}
+	for _, s := range catalog {
+		sats = append(sats, s.Name)
+		seen[s.Name] = true
+	}
+	for name := range bySat {
+		if !seen[name] {
+			sats = append(sats, name)
+		}
+	}
+	sort.Strings(sats)
+
+	m.family("ephemeris_captures_total", "counter", "Completed captures in the pass history.")
+	for _, name := range sats {
+		m.sample("ephemeris_captures_total", float64(bySat[name]), "satellite", name)
+	}
+
+	m.family("ephemeris_capture_failures_total", "counter", "Failed captures in the pass history.")
+	for _, name := range sats {
+		m.sample("ephemeris_capture_failures_total", float64(failedBySat[name]), "satellite", name)
+	}
+
+	m.family("ephemeris_last_capture_timestamp_seconds", "gauge", "Unix time of the last completed capture, 0 if none.")
+	for _, name := range sats {
+		var ts float64
+		if t, ok := lastBySat[name]; ok {
+			ts = float64(t.Unix())
+		}
+		m.sample("ephemeris_last_capture_timestamp_seconds", ts, "satellite", name)
+	}
+
+	m.family("ephemeris_capture_bytes_total", "counter", "Bytes written by completed captures in the pass history.")
+	m.sample("ephemeris_capture_bytes_total", float64(totalBytes))
+
+	// Health checks from the most recent periodic sample.
+	if rec, ok := a.health.latest(); ok {
+		names := make([]string, 0, len(rec.Checks))
+		for name := range rec.Checks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		m.family("ephemeris_health_check_ok", "gauge", "Result of the last health check sample (1 = passing).")
+		for _, name := range names {
+			m.sample("ephemeris_health_check_ok", boolValue(checkOK(rec.Checks[name])), "check", name)
+		}
+		m.family("ephemeris_health_check_flapping", "gauge", "Whether a health check is currently flapping.")
+		for _, name := range names {
+			m.sample("ephemeris_health_check_flapping", boolValue(a.health.isFlapping(name)), "check", name)
+		}
+	}
+
+	// TLE cache freshness.
+	tle := predict.NewTLEStore(cfg).CacheInfo()
+	m.family("ephemeris_tle_cache_exists", "gauge", "Whether the TLE cache file exists.")
+	m.sample("ephemeris_tle_cache_exists", boolValue(tle.Exists))
+	if tle.Exists {
+		m.family("ephemeris_tle_cache_age_seconds", "gauge", "Age of the TLE cache file.")
+		m.sample("ephemeris_tle_cache_age_seconds", float64(tle.AgeS))
+	}
+	m.family("ephemeris_tle_cache_max_age_seconds", "gauge", "Configured TLE refresh interval.")
+	m.sample("ephemeris_tle_cache_max_age_seconds", float64(tle.MaxAgeH*3600))
+
+	// Disk usage for the data root.
+	if du := diskUsage(cfg.Data.Root); du != nil {
+		m.family("ephemeris_disk_total_bytes", "gauge", "Total size of the data root filesystem.")
+		m.sample("ephemeris_disk_total_bytes", float64(du.TotalBytes))
+		m.family("ephemeris_disk_available_bytes", "gauge", "Free space on the data root filesystem.")
+		m.sample("ephemeris_disk_available_bytes", float64(du.AvailableBytes))
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write(m.buf.Bytes())
+}
@@ -0,0 +1,241 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/demo"
+	"github.com/large-farva/ephemeris-engine/internal/scheduler"
+)
+
+// runnerStopTimeout bounds how long a mode switch waits for the outgoing
+// runner to exit after its context is cancelled.
+const runnerStopTimeout = 30 * time.Second
+
+// runner is the active pass runner: the demo simulator or the live
+// scheduler, together with the context that stops it.
+type runner struct {
+	demo   bool
+	sched  *scheduler.Runner // nil for the demo runner
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	done   chan struct{} // closed once the runner is told to stop
+	stop   sync.Once
+}
+
+// isDemo reports whether the demo runner is active. Before Run starts a
+// runner it reflects the configured mode.
+func (a *App) isDemo() bool {
+	if r := a.active.Load(); r != nil {
+		return r.demo
+	}
+	return a.getConfig().Demo.Enabled
+}
+
+// sched returns the live scheduler, or nil in demo mode.
+func (a *App) sched() *scheduler.Runner {
+	if r := a.active.Load(); r != nil {
+		return r.sched
+	}
+	return nil
+}
+
+// startRunner launches the demo or live runner under a context derived from
+// the daemon's, so it can be stopped on its own. Callers hold runMu.
+func (a *App) startRunner(demoMode bool) {
+	ctx, cancel := context.WithCancel(a.runCtx)
+	r := &runner{demo: demoMode, cancel: cancel, done: make(chan struct{})}
+	cfg := a.getConfig()
+
+	if demoMode {
+		d := demo.New(a.wsHub)
+		if cfg.Demo.IntervalSeconds > 0 {
+			d.Interval = time.Duration(cfg.Demo.IntervalSeconds) * time.Second
+		}
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			a.supervise(ctx, "demo", func(ctx context.Context) {
+				d.Run(ctx, a.setStateFromDemo)
+			})
+		}()
+	} else {
+		s := scheduler.New(a.wsHub, cfg, a.log)
+		s.SetPassCallback(a.onPassUpdate)
+		s.SetCaptureCallback(a.onCaptureComplete)
+		s.SetCaptureStartCallback(a.onCaptureStart)
+		s.SetCaptureFailedCallback(a.onCaptureFailed)
+		s.SetOutcomeCallback(a.onPassOutcome)
+		s.SetProcessedCallback(a.onCaptureProcessed)
+		s.SetTracer(a.tracer)
+		s.SetSatelliteFilter(func(name string) bool { return a.getConfig().SatelliteEnabled(name) })
+		s.SetFreqOffset(func(name string) int { return a.getConfig().SatelliteFreqOffset(name) })
+		s.SetSatellitePriority(func(name string) int { return a.getConfig().SatellitePriority(name) })
+		s.SetMinElevation(func(name string) float64 { return a.getConfig().SatelliteMinElevation(name) })
+		s.SetBlackouts(func() []config.Blackout { return a.getConfig().Schedule.Blackouts() })
+		s.SetEnergyBudget(func() config.ScheduleConfig { return a.getConfig().Schedule })
+		s.SetMinQuality(func() int { return a.getConfig().Predict.MinQuality })
+		s.SetTLEMaxEpochAge(func() int { return a.getConfig().Predict.TLEMaxEpochAgeDays })
+		s.SetCaptureGate(a.captureGate)
+		r.sched = s
+		r.wg.Add(2)
+		go func() {
+			defer r.wg.Done()
+			a.supervise(ctx, "scheduler", func(ctx context.Context) {
+				// A crash may have left us mid-pass; start again from IDLE.
+				a.transition("IDLE")
+				s.Run(ctx, a.setStateFromScheduler)
+			})
+		}()
+		go func() {
+			defer r.wg.Done()
+			a.supervise(ctx, "watchdog", a.watchdogLoop)
+		}()
+	}
+	a.active.Store(r)
+}
+
+// stopRunner cancels the active runner and waits for it to exit. Callers
+// hold runMu.
+func (a *App) stopRunner() error {
+	r := a.active.Load()
+	if r == nil {
+		return nil
+	}
+	r.stop.Do(func() {
+		r.cancel()
+		close(r.done)
+	})
+
+	exited := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(runnerStopTimeout):
+		return fmt.Errorf("runner did not stop within %s", runnerStopTimeout)
+	}
+
+	a.active.Store(nil)
+	a.watchdog.hung.Store(false)
+	a.setCurrentPass(nil)
+	return nil
+}
+
+// setMode swaps the active runner to demo or live. It is a no-op when the
+// requested mode is already running. The config file is not changed, so a
+// restart returns to the configured mode.
+func (a *App) setMode(demoMode bool, source string) (changed bool, err error) {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+
+	if a.active.Load() == nil {
+		return false, fmt.Errorf("daemon is not running yet")
+	}
+	if a.isDemo() == demoMode {
+		return false, nil
+	}
+
+	from, to := modeName(!demoMode), modeName(demoMode)
+	if err := a.stopRunner(); err != nil {
+		return false, err
+	}
+	a.transition("IDLE")
+	a.startRunner(demoMode)
+
+	message := fmt.Sprintf("switched from %s to %s mode", from, to)
+	a.log.Info(message, "component", "mode", "source", source)
+	a.emit("ephemerisd", map[string]any{
+		"type":   "mode_changed",
+		"from":   from,
+		"to":     to,
+		"source": source,
+	})
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   "info",
+		"message": message,
+	})
+	return true, nil
+}
+
+// modeName returns "demo" or "live".
+func modeName(demoMode bool) string {
+	if demoMode {
+		return "demo"
+	}
+	return "live"
+}
+
+// modeResponse is the body of GET /api/mode.
+type modeResponse struct {
+	Mode       string `json:"mode"`       // running now: demo or live
+	Configured string `json:"configured"` // from demo.enabled
+}
+
+// modeRequest is the body of POST /api/mode.
+type modeRequest struct {
+	Demo  *bool `json:"demo"`
+	Force bool  `json:"force"` // switch even mid-capture
+}
+
+// modeSwitchResponse reports the mode after a switch.
+type modeSwitchResponse struct {
+	OK      bool   `json:"ok"`
+	Mode    string `json:"mode"`
+	Changed bool   `json:"changed"`
+}
+
+// handleMode reports the operating mode on GET and switches it on POST.
+func (a *App) handleMode(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(modeResponse{
+			Mode:       modeName(a.isDemo()),
+			Configured: modeName(a.getConfig().Demo.Enabled),
+		})
+	case http.MethodPost:
+		a.handleModeSwitch(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleModeSwitch swaps runners for {"demo": bool}. Switching while a
+// capture is in progress aborts it, so that requires {"force": true}.
+func (a *App) handleModeSwitch(w http.ResponseWriter, r *http.Request) {
+	var req modeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Demo == nil {
+		jsonError(w, `missing "demo" field`, http.StatusBadRequest)
+		return
+	}
+	state := a.state.Load().(string)
+	if !req.Force && *req.Demo != a.isDemo() && (state == "RECORDING" || state == "DECODING") {
+		jsonError(w, fmt.Sprintf("a capture is in progress (%s); use force to abort it", state), http.StatusConflict)
+		return
+	}
+
+	changed, err := a.setMode(*req.Demo, "api")
+	if err != nil {
+		jsonError(w, "mode switch failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(modeSwitchResponse{
+		OK:      true,
+		Mode:    modeName(a.isDemo()),
+		Changed: changed,
+	})
+}
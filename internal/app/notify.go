@@ -0,0 +1,452 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/display"
+	"github.com/large-farva/ephemeris-engine/internal/notify"
+	"github.com/large-farva/ephemeris-engine/internal/notify/tmpl"
+	"github.com/large-farva/ephemeris-engine/internal/store"
+)
+
+// botHelp is the reply to /help and /start.
+const botHelp = `/status - station state, next pass and last image
+/next - the next pass
+/pause - stop scheduling passes
+/resume - schedule passes again`
+
+// summaryImages is how many of the day's best images a summary carries.
+const summaryImages = 3
+
+// newNotifier returns the notifier for [notify] and its Telegram backend.
+// The backends read the config afresh for every message.
+func (a *App) newNotifier() (*notify.Notifier, *notify.Telegram) {
+	tg := notify.NewTelegram(func() config.TelegramConfig { return a.getConfig().Notify.Telegram }, a.log)
+	em := notify.NewEmail(func() config.EmailConfig { return a.getConfig().Notify.Email }, a.log)
+	nt := notify.NewNtfy(func() config.NtfyConfig { return a.getConfig().Notify.Ntfy })
+	gt := notify.NewGotify(func() config.GotifyConfig { return a.getConfig().Notify.Gotify })
+	loc := func() *time.Location { return a.getConfig().Station.Location() }
+	return notify.New(a.log, loc, a.onNotifyDone, tg, em, nt, gt), tg
+}
+
+// onNotifyDone reports how sending a notification ended, or that a
+// backend held it back.
+func (a *App) onNotifyDone(m notify.Message, res notify.Result) {
+	ev := map[string]any{
+		"type":    "notify",
+		"backend": res.Backend,
+		"kind":    m.Kind,
+	}
+	if res.Error != "" {
+		ev["error"] = res.Error
+	}
+	if res.Held != "" {
+		ev["held"] = res.Held
+	}
+	a.emit("ephemerisd", ev)
+}
+
+// notifyPass sends the result of the decoded capture at path, with its
+// best image.
+func (a *App) notifyPass(path string) {
+	m, err := a.passMessage(path)
+	if m.Kind == "" {
+		return
+	}
+	a.warnTemplate(m.Kind, err)
+	a.notifier.Notify(m)
+}
+
+// notifyFailure sends a failed capture of satellite. Failures with the same
+// error count as the same news, whichever satellite they hit, so a fault
+// that fails every pass is reported once per dedupe window.
+func (a *App) notifyFailure(satellite string, err error) {
+	m, terr := a.failureMessage(tmpl.Failure{
+		Satellite: satellite,
+		Time:      time.Now().In(a.getConfig().Station.Location()),
+		Error:     err.Error(),
+	})
+	m.Key = notify.KindFailure + ": " + err.Error()
+	a.warnTemplate(m.Kind, terr)
+	a.notifier.Notify(m)
+}
+
+// passMessage is the message for the decoded capture at path. Its Kind is
+// "" if the capture's sidecar cannot be read. The error is that of a
+// notify.templates.pass that failed, in which case the built-in text is
+// used.
+func (a *App) passMessage(path string) (notify.Message, error) {
+	meta, err := capture.ReadMetadata(path)
+	if err != nil {
+		return notify.Message{}, nil
+	}
+	cfg := a.getConfig()
+	loc := cfg.Station.Location()
+	data := tmpl.Pass{
+		Satellite: meta.Satellite,
+		Station:   meta.Station,
+		MaxElev:   meta.MaxElev,
+		File:      filepath.Base(path),
+		Images:    meta.Images,
+	}
+	if t, err := time.Parse(time.RFC3339, meta.AOS); err == nil {
+		data.AOS = t.In(loc)
+	}
+	if t, err := time.Parse(time.RFC3339, meta.LOS); err == nil {
+		data.LOS = t.In(loc)
+	}
+	m := notify.Message{Kind: notify.KindPass}
+	if len(meta.Images) > 0 {
+		data.Image = bestImage(meta.Images)
+		data.ImageURL = a.imageURL(data.Image)
+		m.Images = []string{filepath.Join(cfg.Data.Root, data.Image)}
+	}
+	m.Text, err = a.render(tmpl.KindPass, data)
+	return m, err
+}
+
+// failureMessage is the message for a failed capture. The error is that
+// of a notify.templates.failure that failed, in which case the built-in
+// text is used.
+func (a *App) failureMessage(data tmpl.Failure) (notify.Message, error) {
+	text, err := a.render(tmpl.KindFailure, data)
+	return notify.Message{Kind: notify.KindFailure, Text: text}, err
+}
+
+// render renders the notify.templates template for kind over data. If it
+// fails, the built-in template is rendered instead and the error returned
+// with its text.
+func (a *App) render(kind string, data any) (string, error) {
+	text, err := tmpl.Render(kind, a.getConfig().Notify.Templates.Get(kind), data)
+	if err != nil {
+		text, _ = tmpl.Render(kind, "", data)
+	}
+	return text, err
+}
+
+// warnTemplate logs a notification template that failed to render.
+func (a *App) warnTemplate(kind string, err error) {
+	if err != nil {
+		a.log.Warn("notification template failed; sent the built-in text", "component", "notify", "kind", kind, "err", err)
+	}
+}
+
+// imageURL is the address of the image name on the daemon, or "" if
+// notify.base_url is not set.
+func (a *App) imageURL(name string) string {
+	base := a.getConfig().Notify.BaseURL
+	if base == "" {
+		return ""
+	}
+	return strings.TrimRight(base, "/") + "/api/captures/file?name=" + url.QueryEscape(name)
+}
+
+// summaryLoop sends the daily summary at notify.summary_time, in the
+// station's time zone, until ctx is cancelled. A daemon started after
+// that time sends the next day's.
+func (a *App) summaryLoop(ctx context.Context) {
+	t := time.NewTicker(time.Minute)
+	defer t.Stop()
+	last, first := "", true // last is the local date of the last summary
+	for {
+		cfg := a.getConfig()
+		if at, err := time.Parse("15:04", cfg.Notify.SummaryTime); err == nil {
+			now := time.Now()
+			local := now.In(cfg.Station.Location())
+			day := local.Format(time.DateOnly)
+			if local.Hour()*60+local.Minute() >= at.Hour()*60+at.Minute() && day != last {
+				if !first {
+					m, err := a.dailySummary(now)
+					a.warnTemplate(m.Kind, err)
+					a.notifier.Notify(m)
+				}
+				last = day
+			}
+		}
+		first = false
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// dailySummary describes the passes of the 24 hours before now, with the
+// best images of the highest passes and the count of notifications held
+// back. The error is that of a notify.templates.summary that failed, in
+// which case the built-in text is used. A history that cannot be read is
+// logged, and the summary has no passes.
+func (a *App) dailySummary(now time.Time) (notify.Message, error) {
+	cfg := a.getConfig()
+	loc := cfg.Station.Location()
+	data := tmpl.Summary{Since: now.Add(-24 * time.Hour).In(loc), Until: now.In(loc)}
+	data.HeldBy = a.notifier.Held(data.Since)
+	for _, n := range data.HeldBy {
+		data.Held += n
+	}
+	recs, err := a.history.Query(store.Filter{Since: data.Since, Until: now})
+	if err != nil {
+		a.log.Warn("could not read the passes for the daily summary", "component", "history", "err", err)
+	}
+
+	var best []tmpl.SummaryPass
+	for i := len(recs) - 1; i >= 0; i-- { // oldest first
+		r := recs[i]
+		if r.Source == store.SourceImport {
+			continue
+		}
+		p := tmpl.SummaryPass{
+			Satellite: r.Satellite,
+			AOS:       r.AOS.In(loc),
+			MaxElev:   r.MaxElev,
+			Outcome:   r.Outcome,
+			Error:     r.Error,
+		}
+		switch r.Outcome {
+		case store.OutcomeCaptured:
+			data.Captured++
+			if !r.HasFile() {
+				break
+			}
+			meta, err := capture.ReadMetadata(filepath.Join(cfg.Data.Root, r.File))
+			if err == nil && len(meta.Images) > 0 {
+				p.Image = bestImage(meta.Images)
+				p.ImageURL = a.imageURL(p.Image)
+				best = append(best, p)
+			}
+		case store.OutcomeFailed:
+			data.Failed++
+		}
+		data.Passes = append(data.Passes, p)
+	}
+	sort.SliceStable(best, func(i, j int) bool { return best[i].MaxElev > best[j].MaxElev })
+	m := notify.Message{
+		Kind:    notify.KindSummary,
+		Subject: fmt.Sprintf("Ephemeris Engine daily summary: %d captured, %d failed", data.Captured, data.Failed),
+	}
+	for i := 0; i < len(best) && i < summaryImages; i++ {
+		m.Images = append(m.Images, filepath.Join(cfg.Data.Root, best[i].Image))
+	}
+	data.Attached = len(m.Images)
+	text, err := a.render(tmpl.KindSummary, data)
+	m.Text = text
+	return m, err
+}
+
+// botCommand answers a Telegram bot command.
+func (a *App) botCommand(cmd string) string {
+	switch cmd {
+	case "status", "next":
+		out, err := a.callAPI(http.MethodGet, "/api/summary", nil)
+		if err != nil {
+			return "Error: " + err.Error()
+		}
+		var s display.Summary
+		if err := json.Unmarshal(out, &s); err != nil {
+			return "Error: " + err.Error()
+		}
+		if cmd == "next" {
+			return nextPassText(s)
+		}
+		lines := []string{"State: " + s.State, nextPassText(s)}
+		if c := s.LastCapture; c != nil {
+			lines = append(lines, fmt.Sprintf("Last image: %s, %s", c.Satellite, localTime(c.AOSLocal)))
+		}
+		if s.DiskFreePercent != nil {
+			lines = append(lines, fmt.Sprintf("Disk free: %d%%", *s.DiskFreePercent))
+		}
+		return strings.Join(lines, "\n")
+	case "pause", "resume":
+		out, err := a.callAPI(http.MethodPost, "/api/"+cmd, nil)
+		if err != nil {
+			return "Error: " + err.Error()
+		}
+		var res struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(out, &res)
+		if res.Message == "" {
+			return "OK"
+		}
+		return res.Message
+	case "help", "start":
+		return botHelp
+	}
+	return "Unknown command /" + cmd + "\n\n" + botHelp
+}
+
+// nextPassText describes the next pass in s.
+func nextPassText(s display.Summary) string {
+	p := s.NextPass
+	if p == nil {
+		if s.NextPassError != "" {
+			return "Next pass: unknown (" + s.NextPassError + ")"
+		}
+		return "Next pass: none predicted"
+	}
+	wait := "under a minute"
+	if d := (time.Duration(p.CountdownS) * time.Second).Round(time.Minute); d > 0 {
+		wait = strings.TrimSuffix(d.String(), "0s")
+	}
+	return fmt.Sprintf("Next pass: %s at %s (in %s), max elevation %.0f°, %s",
+		p.Satellite, localTime(p.AOSLocal), wait, p.MaxElev, p.Direction)
+}
+
+// localTime renders an RFC 3339 time from a summary for a chat message.
+func localTime(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	return t.Format("Mon 15:04")
+}
+
+// notifyResponse is the body of GET /api/notify.
+type notifyResponse struct {
+	Backends []notify.Status `json:"backends"`
+}
+
+// notifyPreview is a message rendered by POST /api/notify/test with
+// dry_run, without sending it.
+type notifyPreview struct {
+	Kind    string   `json:"kind"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text"`
+	Images  []string `json:"images"`
+}
+
+// notifySendResponse reports how each backend took a message sent on
+// request; OK is false if any failed.
+type notifySendResponse struct {
+	OK      bool            `json:"ok"`
+	Results []notify.Result `json:"results"`
+}
+
+// handleNotify reports each notification backend and what it has sent.
+func (a *App) handleNotify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(notifyResponse{Backends: a.notifier.Status()})
+}
+
+// handleNotifyTest sends a test message to every enabled backend now and
+// reports how each went; ok is false if any failed. With kind, it instead
+// fires a message of that kind from its template, over the latest pass or
+// failure in the history, or example data if there is none, to the
+// backends that take the kind. With dry_run it only renders the message.
+//
+//	POST /api/notify/test[?kind=pass|failure|summary[&dry_run=true]]
+func (a *App) handleNotifyTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	kind := r.URL.Query().Get("kind")
+	if kind == "" {
+		host, _ := os.Hostname()
+		a.sendNotificationNow(w, r, notify.Message{
+			Kind: notify.KindTest,
+			Text: "Test notification from ephemerisd on " + host,
+		}, "no notification backend is enabled")
+		return
+	}
+	if !slices.Contains(tmpl.Kinds, kind) {
+		jsonError(w, fmt.Sprintf("unknown kind %q (use %s)", kind, strings.Join(tmpl.Kinds, ", ")), http.StatusBadRequest)
+		return
+	}
+	m, err := a.exampleMessage(kind)
+	if err != nil {
+		jsonError(w, "notify.templates."+kind+": "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if r.URL.Query().Get("dry_run") == "true" {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(notifyPreview{
+			Kind:    m.Kind,
+			Subject: m.Subject,
+			Text:    m.Text,
+			Images:  m.Images,
+		})
+		return
+	}
+	a.sendNotificationNow(w, r, m, "no enabled notification backend takes "+kind+" messages")
+}
+
+// exampleMessage is a message of kind for trying its template: over the
+// latest decoded pass or failure in the history, or over example data if
+// there is none or the history cannot be read. The error is that of the
+// template.
+func (a *App) exampleMessage(kind string) (notify.Message, error) {
+	cfg := a.getConfig()
+	switch kind {
+	case tmpl.KindSummary:
+		return a.dailySummary(time.Now())
+	case tmpl.KindPass:
+		recs, _ := a.history.Query(store.Filter{Outcome: store.OutcomeCaptured})
+		for _, r := range recs {
+			if !r.HasFile() {
+				continue
+			}
+			if m, err := a.passMessage(filepath.Join(cfg.Data.Root, r.File)); m.Kind != "" {
+				return m, err
+			}
+		}
+		text, err := a.render(kind, tmpl.Sample(kind))
+		return notify.Message{Kind: notify.KindPass, Text: text}, err
+	}
+	data := tmpl.Sample(kind).(tmpl.Failure)
+	if recs, _ := a.history.Query(store.Filter{Outcome: store.OutcomeFailed, Limit: 1}); len(recs) > 0 {
+		data = tmpl.Failure{
+			Satellite: recs[0].Satellite,
+			Time:      recs[0].RecordedAt.In(cfg.Station.Location()),
+			Error:     recs[0].Error,
+		}
+	}
+	return a.failureMessage(data)
+}
+
+// handleNotifySummary sends the summary of the last day now, to the
+// backends that take summaries.
+//
+//	POST /api/notify/summary
+func (a *App) handleNotifySummary(w http.ResponseWriter, r *http.Request) {
+	m, err := a.dailySummary(time.Now())
+	a.warnTemplate(m.Kind, err)
+	a.sendNotificationNow(w, r, m, "no enabled notification backend sends summaries")
+}
+
+// sendNotificationNow sends m for a POST request and reports how each
+// backend went, or answers 409 with none if no backend takes m.
+func (a *App) sendNotificationNow(w http.ResponseWriter, r *http.Request, m notify.Message, none string) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	results := a.notifier.SendNow(r.Context(), m)
+	if len(results) == 0 {
+		jsonError(w, none, http.StatusConflict)
+		return
+	}
+	ok := true
+	for _, res := range results {
+		ok = ok && res.Error == ""
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(notifySendResponse{OK: ok, Results: results})
+}
@@ -0,0 +1,205 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/api"
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/display"
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+	"github.com/large-farva/ephemeris-engine/internal/scheduler"
+)
+
+// Query parameters shared by several routes.
+var (
+	tzParam    = api.Param{Name: "tz", Description: `IANA zone for the local times, or the station's by default`}
+	limitParam = api.Param{Name: "limit", Type: "integer", Description: "Keep the newest N entries"}
+	sinceParam = api.Param{Name: "since", Description: "RFC 3339 time, or a duration back from now such as 72h"}
+	untilParam = api.Param{Name: "until", Description: "RFC 3339 time, or a duration back from now"}
+)
+
+// apiRoutes describes every operation served on the main listener, for
+// /api/openapi.json. Keep it in step with the mux in Run: a new endpoint
+// is added here with the types its handler decodes and encodes.
+func apiRoutes() []api.Route {
+	return []api.Route{
+		// Status.
+		{Method: "GET", Path: "/healthz", Tag: "status", Summary: `Liveness: "ok" as text, or component checks with Accept: application/json`, Response: healthResponse{}},
+		{Method: "GET", Path: "/readyz", Tag: "status", Summary: "Readiness, including scheduler liveness; 503 when not ready", Response: readyResponse{}},
+		{Method: "GET", Path: "/api/v1/status", Tag: "status", Summary: "Daemon state and current pass (frozen v1 schema)", Query: []api.Param{tzParam}, Response: api.StatusResponse{}},
+		{Method: "GET", Path: "/api/status", Tag: "status", Summary: "Same as /api/v1/status", Query: []api.Param{tzParam}, Response: api.StatusResponse{}},
+		{Method: "GET", Path: "/api/version", Tag: "status", Summary: "Daemon version", Response: versionResponse{}},
+		{Method: "GET", Path: "/api/summary", Tag: "status", Summary: "Compact station summary for thin clients", Query: []api.Param{tzParam}, Response: display.Summary{}},
+		{Method: "GET", Path: "/api/summary.png", Tag: "status", Summary: "Station summary drawn in black and white for e-paper", ContentType: "image/png", Query: []api.Param{
+			tzParam,
+			{Name: "w", Type: "integer", Description: "Width in pixels (default 296)"},
+			{Name: "h", Type: "integer", Description: "Height in pixels (default 128)"},
+		}},
+		{Method: "GET", Path: "/api/system", Tag: "status", Summary: "Runtime, SDR backend and disk information", Response: systemResponse{}},
+		{Method: "GET", Path: "/api/stats", Tag: "status", Summary: "Aggregate capture statistics", Response: statsResponse{}},
+		{Method: "GET", Path: "/api/wait-for-change", Tag: "status", Summary: "Long-poll until the state or tracked pass changes", Response: waitChangeResponse{}, Query: []api.Param{
+			{Name: "state", Description: "Baseline state (default: the current state)"},
+			{Name: "pass", Description: "Baseline pass_token (default: the current pass)"},
+			{Name: "timeout", Description: "Duration such as 30s, or seconds (default 30s, maximum 5m)"},
+		}},
+		{Method: "GET", Path: "/ws", Tag: "status", Summary: "WebSocket event stream", Status: http.StatusSwitchingProtocols},
+
+		// Satellites.
+		{Method: "GET", Path: "/api/satellites", Tag: "satellites", Summary: "Satellite catalog with per-satellite settings", Response: satellitesResponse{}},
+		{Method: "POST", Path: "/api/satellites", Tag: "satellites", Summary: "Add a satellite to the catalog (persisted)", Request: satelliteAddRequest{}, Response: satelliteAddResponse{}, Control: true},
+		{Method: "GET", Path: "/api/satellite", Tag: "satellites", Summary: "One satellite's passes, captures, TLE and success rate", Response: satelliteResponse{}, Query: []api.Param{
+			{Name: "name", Description: "Satellite name"},
+			{Name: "count", Type: "integer", Description: "Passes and captures shown (default 5)"},
+			tzParam,
+		}},
+		{Method: "POST", Path: "/api/satellites/{id}/enable", Tag: "satellites", Summary: "Schedule a satellite's passes (persisted)", Response: satelliteToggleResponse{}, Control: true},
+		{Method: "POST", Path: "/api/satellites/{id}/disable", Tag: "satellites", Summary: "Stop scheduling a satellite's passes (persisted)", Response: satelliteToggleResponse{}, Control: true},
+		{Method: "POST", Path: "/api/satellites/{id}/offset", Tag: "satellites", Summary: "Set a satellite's frequency offset (persisted)", Request: satelliteOffsetRequest{}, Response: satelliteToggleResponse{}, Control: true},
+		{Method: "DELETE", Path: "/api/satellites/{id}", Tag: "satellites", Summary: "Remove an added satellite from the catalog", Response: satelliteRemoveResponse{}, Control: true},
+		{Method: "GET", Path: "/api/tle-info", Tag: "satellites", Summary: "TLE cache status and the element set of each satellite", Response: predict.TLECacheInfo{}},
+		{Method: "POST", Path: "/api/tle-refresh", Tag: "satellites", Summary: "Fetch TLEs from the network now", Response: scheduler.CommandResult{}, Control: true},
+		{Method: "GET", Path: "/api/catalog-sync", Tag: "satellites", Summary: "Last SatNOGS DB catalog sync", Response: catalogSyncResponse{}},
+		{Method: "POST", Path: "/api/catalog-sync", Tag: "satellites", Summary: "Start a catalog sync now", Response: api.OKResponse{}, Control: true},
+
+		// Passes.
+		{Method: "GET", Path: "/api/passes", Tag: "passes", Summary: "Upcoming passes", Response: passesResponse{}, Query: []api.Param{
+			{Name: "satellite", Description: "Only this satellite"},
+			{Name: "min_elev", Type: "number", Description: "Only passes peaking at or above this elevation"},
+			{Name: "direction", Description: "N or S"},
+			{Name: "count", Type: "integer", Description: "Limit number of passes"},
+			{Name: "score", Type: "boolean", Description: "Add how the scheduler scores each pass, with the factors of the score"},
+			tzParam,
+		}},
+		{Method: "GET", Path: "/api/passes/{id}/track", Tag: "passes", Summary: "Sky track and ground track of an upcoming pass", Response: passTrackResponse{}, Query: []api.Param{
+			{Name: "step", Type: "integer", Description: "Seconds between points (default 10, max 300)"},
+		}},
+		{Method: "GET", Path: "/api/next-pass", Tag: "passes", Summary: "The next upcoming pass", Response: nextPassResponse{}, Query: []api.Param{
+			{Name: "satellite", Description: "Only this satellite"},
+			tzParam,
+		}},
+		{Method: "GET", Path: "/api/blackouts", Tag: "passes", Summary: "Blackout windows and which are in force", Response: blackoutsResponse{}},
+
+		// Captures.
+		{Method: "GET", Path: "/api/captures", Tag: "captures", Summary: "Capture listing", Response: capturesResponse{}},
+		{Method: "DELETE", Path: "/api/captures", Tag: "captures", Summary: "Delete a capture and its images, to the trash unless data.trash_days is 0", Response: api.OKResponse{}, Control: true, Query: []api.Param{
+			{Name: "name", Description: "Capture file name"},
+		}},
+		{Method: "GET", Path: "/api/captures/file", Tag: "captures", Summary: "Download a capture, as WAV or, once compressed, FLAC, verified against its checksum, or a decoded image under images/", ContentType: "audio/wav", Query: []api.Param{
+			{Name: "name", Description: "Capture file name, or images/ and an image file name"},
+			{Name: "verify", Type: "boolean", Description: "false to download even if the checksum no longer matches"},
+		}},
+		{Method: "POST", Path: "/api/captures/import", Tag: "captures", Summary: "Import recordings and images made by another tool", Request: captureImportRequest{}, Response: captureImportResponse{}, Control: true},
+		{Method: "POST", Path: "/api/captures/tag", Tag: "captures", Summary: "Add and remove capture tags", Request: captureTagRequest{}, Response: captureTagResponse{}, Control: true},
+		{Method: "GET", Path: "/api/captures/trash", Tag: "captures", Summary: "Deleted captures that can still be restored", Response: trashResponse{}},
+		{Method: "POST", Path: "/api/captures/restore", Tag: "captures", Summary: "Restore a deleted capture from the trash", Request: captureRestoreRequest{}, Response: api.OKResponse{}, Control: true},
+		{Method: "POST", Path: "/api/captures/upload", Tag: "captures", Summary: "Queue a capture for upload again", Request: captureUploadRequest{}, Response: captureUploadResponse{}, Control: true},
+		{Method: "GET", Path: "/api/history", Tag: "captures", Summary: "Pass history, newest first", Response: historyResponse{}, Query: []api.Param{
+			{Name: "satellite", Description: "Only this satellite"},
+			{Name: "outcome", Description: "captured, failed, cancelled or skipped"},
+			{Name: "station", Description: "Only this station profile"},
+			sinceParam, untilParam,
+			{Name: "min_elev", Type: "number", Description: "Only passes peaking at or above this elevation"},
+			limitParam,
+		}},
+		{Method: "GET", Path: "/api/scrub", Tag: "captures", Summary: "Integrity scrub schedule and last result", Response: scrubResponse{}},
+		{Method: "POST", Path: "/api/scrub", Tag: "captures", Summary: "Start an integrity scrub now", Response: api.OKResponse{}, Control: true},
+		{Method: "GET", Path: "/api/consistency", Tag: "captures", Summary: "What the startup consistency check found and repaired", Response: consistencyReport{}},
+		{Method: "GET", Path: "/api/retention", Tag: "captures", Summary: "Retention policy and last sweep", Response: retentionResponse{}},
+		{Method: "POST", Path: "/api/retention/run", Tag: "captures", Summary: "Start a retention sweep, or preview one with dry_run", Response: retentionRunResponse{}, Control: true, Query: []api.Param{
+			{Name: "dry_run", Type: "boolean", Description: "Report what would be pruned, changing nothing"},
+		}},
+		{Method: "GET", Path: "/api/gallery", Tag: "captures", Summary: "Gallery settings and last export", Response: galleryResponse{}},
+		{Method: "POST", Path: "/api/gallery/export", Tag: "captures", Summary: "Export the gallery now", Response: galleryExportResponse{}, Control: true},
+
+		// Control.
+		{Method: "POST", Path: "/api/trigger", Tag: "control", Summary: "Start a capture now", Request: triggerRequest{}, Response: scheduler.CommandResult{}, Control: true},
+		{Method: "POST", Path: "/api/pause", Tag: "control", Summary: "Pause automatic scheduling", Response: scheduler.CommandResult{}, Control: true},
+		{Method: "POST", Path: "/api/resume", Tag: "control", Summary: "Resume automatic scheduling", Response: scheduler.CommandResult{}, Control: true},
+		{Method: "POST", Path: "/api/skip", Tag: "control", Summary: "Skip the current or next pass", Response: scheduler.CommandResult{}, Control: true},
+		{Method: "GET", Path: "/api/schedule", Tag: "control", Summary: "The computed schedule, with dropped passes and overrides", Response: scheduleResponse{}},
+		{Method: "POST", Path: "/api/schedule/{id}/skip", Tag: "control", Summary: "Keep one pass out of the schedule (persisted)", Response: scheduler.CommandResult{}, Control: true},
+		{Method: "POST", Path: "/api/schedule/{id}/force", Tag: "control", Summary: "Record one pass whatever the filters say (persisted)", Response: scheduler.CommandResult{}, Control: true},
+		{Method: "POST", Path: "/api/schedule/{id}/clear", Tag: "control", Summary: "Clear a pass's skip or force", Response: scheduler.CommandResult{}, Control: true},
+		{Method: "GET", Path: "/api/schedule/wake", Tag: "control", Summary: "Wake-up times of the upcoming schedule, for stations that sleep between passes", Response: wakeScheduleResponse{}, Query: []api.Param{
+			{Name: "format", Type: "string", Description: "json (default), rtcwake (one line per wake-up, Unix time first) or systemd (a timer unit)"},
+			{Name: "lead", Type: "integer", Description: "Seconds to wake before AOS (default 120, maximum 3600)"},
+		}},
+		{Method: "POST", Path: "/api/resumed", Tag: "control", Summary: "Resume hook: recompute the schedule after the system wakes", Response: scheduler.CommandResult{}, Control: true},
+		{Method: "POST", Path: "/api/cancel", Tag: "control", Summary: "Abort the capture in progress", Response: scheduler.CommandResult{}, Control: true},
+		{Method: "GET", Path: "/api/mode", Tag: "control", Summary: "Operating mode", Response: modeResponse{}},
+		{Method: "POST", Path: "/api/mode", Tag: "control", Summary: "Switch between demo and live mode", Request: modeRequest{}, Response: modeSwitchResponse{}, Control: true},
+		{Method: "GET", Path: "/api/station", Tag: "control", Summary: "Station profiles and the location in effect", Response: stationResponse{}},
+		{Method: "POST", Path: "/api/station", Tag: "control", Summary: "Switch the active station profile (persisted)", Request: stationRequest{}, Response: stationSwitchResponse{}, Control: true},
+		{Method: "GET", Path: "/api/replay", Tag: "control", Summary: "The replay in progress", Response: replayResponse{}},
+		{Method: "POST", Path: "/api/replay", Tag: "control", Summary: "Re-broadcast a past pass's logged events", Status: http.StatusAccepted, Response: replayStartResponse{}, Control: true, Query: []api.Param{
+			{Name: "pass_id", Type: "integer", Description: "Pass history ID"},
+			{Name: "speed", Type: "number", Description: "Times faster than real time (default 1, maximum 1000)"},
+		}},
+		{Method: "DELETE", Path: "/api/replay", Tag: "control", Summary: "Stop the replay in progress", Response: api.OKResponse{}, Control: true},
+		{Method: "POST", Path: "/api/share", Tag: "control", Summary: "Mint a time-limited read-only token for the public listener", Request: shareRequest{}, Response: shareResponse{}, Control: true},
+		{Method: "DELETE", Path: "/api/share", Tag: "control", Summary: "Revoke every share token", Response: api.OKResponse{}, Control: true},
+
+		// Configuration.
+		{Method: "GET", Path: "/api/config", Tag: "config", Summary: "Running configuration, secrets redacted", Response: config.Config{}, Query: []api.Param{
+			{Name: "resolved", Type: "boolean", Description: "Re-read the file and its includes; the body is then {path, sources, config}"},
+			{Name: "show_secrets", Type: "boolean", Description: "Show secrets (local operator only)"},
+		}},
+		{Method: "GET", Path: "/api/config/profiles", Tag: "config", Summary: "Config profiles in the config directory", Response: profilesResponse{}},
+		{Method: "POST", Path: "/api/reload", Tag: "config", Summary: "Reload the config file, or switch to a profile", Request: reloadRequest{}, Response: reloadResponse{}, Control: true},
+		{Method: "POST", Path: "/api/config/persist", Tag: "config", Summary: "Write the gpsd position or measured values to the config file", Request: configPersistRequest{}, Response: configPersistResponse{}, Control: true},
+
+		// Notifications.
+		{Method: "GET", Path: "/api/notify", Tag: "notify", Summary: "Notification backends and what each has sent", Response: notifyResponse{}},
+		{Method: "POST", Path: "/api/notify/test", Tag: "notify", Summary: "Send a test message, or one kind from its template", Response: notifySendResponse{}, Control: true, Query: []api.Param{
+			{Name: "kind", Description: "pass, failure or summary"},
+			{Name: "dry_run", Type: "boolean", Description: "With kind, render the message without sending it"},
+		}},
+		{Method: "POST", Path: "/api/notify/summary", Tag: "notify", Summary: "Send the daily summary now", Response: notifySendResponse{}, Control: true},
+
+		// Diagnostics.
+		{Method: "GET", Path: "/api/logs", Tag: "diagnostics", Summary: "Recent daemon log messages", Response: logsResponse{}, Query: []api.Param{
+			{Name: "level", Description: "Only this level"},
+			limitParam,
+		}},
+		{Method: "GET", Path: "/api/events/history", Tag: "diagnostics", Summary: "Persisted events, oldest first", Response: eventHistoryResponse{}, Query: []api.Param{
+			{Name: "type", Description: "Comma-separated event types"},
+			{Name: "satellite", Description: "Only events naming this satellite"},
+			sinceParam, untilParam,
+			{Name: "limit", Type: "integer", Description: "Newest N matches (default 500)"},
+		}},
+		{Method: "GET", Path: "/api/health/history", Tag: "diagnostics", Summary: "Recent health samples and flapping checks", Response: healthHistoryResponse{}, Query: []api.Param{limitParam}},
+		{Method: "GET", Path: "/metrics", Tag: "diagnostics", Summary: "Prometheus metrics", ContentType: "text/plain"},
+		{Method: "GET", Path: "/api/annotations", Tag: "diagnostics", Summary: "Capture-window and failure annotations", Response: annotationsResponse{}, Query: []api.Param{
+			{Name: "from", Type: "integer", Description: "Unix milliseconds"},
+			{Name: "to", Type: "integer", Description: "Unix milliseconds"},
+			limitParam,
+		}},
+		{Method: "GET", Path: "/api/traces", Tag: "diagnostics", Summary: "Recent pass pipeline traces", Response: tracesResponse{}, Query: []api.Param{limitParam}},
+		{Method: "GET", Path: "/api/plugins", Tag: "diagnostics", Summary: "Event plugins and whether they are running", Response: pluginsResponse{}},
+		{Method: "GET", Path: "/api/rules", Tag: "diagnostics", Summary: "Automation rules and their hit counters", Response: rulesResponse{}},
+		{Method: "GET", Path: "/api/scripts", Tag: "diagnostics", Summary: "Starlark scripts and their run counters", Response: scriptsResponse{}},
+		{Method: "GET", Path: "/api/debug/goroutines", Tag: "diagnostics", Summary: "Goroutine and memory diagnostics (requires [debug])", Response: goroutinesResponse{}, Query: []api.Param{
+			limitParam,
+			{Name: "token", Description: "Debug token, if not sent as a bearer token"},
+		}},
+		{Method: "POST", Path: "/api/batch", Tag: "diagnostics", Summary: "Run several GETs in one request", Request: batchRequest{}, Response: batchResponses{}},
+		{Method: "GET", Path: "/api/openapi.json", Tag: "diagnostics", Summary: "This document", Response: json.RawMessage(nil)},
+	}
+}
+
+// handleOpenAPI serves the OpenAPI document of the HTTP API, with the
+// server URL as the client sees it through any reverse proxy.
+func (a *App) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	doc := api.Document(api.Info{
+		Title:       "ephemerisd",
+		Version:     Version,
+		Description: "HTTP API of the Ephemeris Engine daemon. Paths under /api/v1 are frozen; other bodies may gain fields.",
+	}, []string{a.externalURL(r, "", false)}, apiRoutes())
+	writeJSONCached(w, r, doc, time.Time{})
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/large-farva/ephemeris-engine/internal/plugin"
+)
+
+// startPlugins creates the plugin manager for the configured [[plugins]].
+// Plugins get EPHEMERIS_URL in their environment, a loopback URL for the
+// API on bind, so they can query status as well as receive events.
+func (a *App) startPlugins(bind string) {
+	host, port, err := net.SplitHostPort(bind)
+	if err == nil && (host == "" || host == "0.0.0.0" || host == "::") {
+		host = "127.0.0.1"
+	}
+	var env []string
+	if err == nil {
+		env = append(env, "EPHEMERIS_URL=http://"+net.JoinHostPort(host, port))
+	}
+	a.plugins = plugin.NewManager(a.wsHub, a.log, a.emit, a.pluginControl, env)
+	a.plugins.Update(a.getConfig().Plugins)
+}
+
+// pluginsResponse is the body of GET /api/plugins.
+type pluginsResponse struct {
+	Plugins []plugin.Status `json:"plugins"`
+}
+
+// handlePlugins lists the configured plugins and whether they are running.
+func (a *App) handlePlugins(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	statuses := []plugin.Status{}
+	if a.plugins != nil {
+		statuses = a.plugins.Status()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(pluginsResponse{Plugins: statuses})
+}
+
+// controlMethods is the control API open to plugins with control = true
+// and to scripts. Each method is served by the matching HTTP endpoint, so
+// it behaves exactly as it does for ephctl; params become the request
+// body.
+var controlMethods = map[string]struct {
+	method, path string
+}{
+	"status":      {http.MethodGet, "/api/status"},
+	"next_pass":   {http.MethodGet, "/api/next-pass"},
+	"captures":    {http.MethodGet, "/api/captures"},
+	"stats":       {http.MethodGet, "/api/stats"},
+	"pause":       {http.MethodPost, "/api/pause"},
+	"resume":      {http.MethodPost, "/api/resume"},
+	"skip":        {http.MethodPost, "/api/skip"},
+	"trigger":     {http.MethodPost, "/api/trigger"},
+	"tag_capture": {http.MethodPost, "/api/captures/tag"},
+}
+
+// pluginControl serves one control API request from a plugin.
+func (a *App) pluginControl(name, method string, params json.RawMessage) (json.RawMessage, error) {
+	return a.control("plugin", name, method, params)
+}
+
+// control serves one control API request from the plugin or script name,
+// as kind says. Actions are logged with the caller's name, so automated
+// pauses and triggers can be traced back to their source.
+func (a *App) control(kind, name, method string, params json.RawMessage) (json.RawMessage, error) {
+	target, ok := controlMethods[method]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", plugin.ErrUnknownMethod, method)
+	}
+	out, err := a.callAPI(target.method, target.path, params)
+	if err != nil {
+		return nil, err
+	}
+	if target.method == http.MethodPost {
+		a.emit("ephemerisd", map[string]any{
+			"type":    "log",
+			"level":   "info",
+			kind:      name,
+			"message": fmt.Sprintf("%s %s called %s %s", kind, name, method, strings.TrimSpace(string(params))),
+		})
+	}
+	return out, nil
+}
+
+// callAPI serves an API request in-process, as plugins and rules act
+// through the same endpoints as ephctl. body is sent with POST requests.
+// Error responses become errors carrying the endpoint's message.
+func (a *App) callAPI(method, path string, body []byte) (json.RawMessage, error) {
+	if method != http.MethodPost {
+		body = nil
+	}
+	req, err := http.NewRequest(method, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "127.0.0.1:0"
+
+	rec := &batchRecorder{header: make(http.Header)}
+	a.recoverHTTP(a.mux).ServeHTTP(rec, req)
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	out := bytes.TrimSpace(rec.body.Bytes())
+	if rec.status >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(out, &e) == nil && e.Error != "" {
+			return nil, errors.New(e.Error)
+		}
+		return nil, fmt.Errorf("%s: HTTP %d: %s", path, rec.status, strings.TrimSpace(string(out)))
+	}
+	if !json.Valid(out) {
+		out = jsonString(string(out))
+	}
+	return out, nil
+}
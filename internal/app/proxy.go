@@ -0,0 +1,99 @@
+package app
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// withBasePath serves h under server.base_path (e.g. "/ephemeris"), so the
+// daemon can share a host with other services behind nginx or Caddy.
+// Unprefixed paths keep working for proxies that strip the prefix
+// themselves and for local clients.
+func (a *App) withBasePath(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		base := a.getConfig().Server.BasePath
+		if base != "" {
+			if p, ok := trimBasePath(r.URL.Path, base); ok {
+				r2 := r.Clone(r.Context())
+				r2.URL.Path = p
+				r2.URL.RawPath = ""
+				r = r2
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// trimBasePath strips base from path when path is base itself or below it.
+func trimBasePath(path, base string) (string, bool) {
+	if path == base {
+		return "/", true
+	}
+	if rest, ok := strings.CutPrefix(path, base); ok && strings.HasPrefix(rest, "/") {
+		return rest, true
+	}
+	return path, false
+}
+
+// externalURL builds the URL a client should use to reach path on this
+// daemon, as ws:// or wss:// when websocket is set. It honors
+// X-Forwarded-Proto, X-Forwarded-Host, and X-Forwarded-Prefix from a
+// reverse proxy, falling back to the request and server.base_path.
+func (a *App) externalURL(r *http.Request, path string, websocket bool) string {
+	scheme := forwardedValue(r, "X-Forwarded-Proto")
+	if scheme == "" {
+		scheme = "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+	}
+	if websocket {
+		if scheme == "https" {
+			scheme = "wss"
+		} else {
+			scheme = "ws"
+		}
+	}
+
+	host := forwardedValue(r, "X-Forwarded-Host")
+	if host == "" {
+		host = r.Host
+	}
+
+	prefix := forwardedValue(r, "X-Forwarded-Prefix")
+	if prefix == "" {
+		prefix = a.getConfig().Server.BasePath
+	}
+	return scheme + "://" + host + strings.TrimRight(prefix, "/") + path
+}
+
+// forwardedValue returns the first entry of a comma-separated forwarding
+// header, as set by the proxy closest to the client.
+func forwardedValue(r *http.Request, name string) string {
+	v, _, _ := strings.Cut(r.Header.Get(name), ",")
+	return strings.TrimSpace(v)
+}
+
+// viaProxy reports whether r was relayed by a reverse proxy, in which case
+// its loopback source address says nothing about the real client.
+func viaProxy(r *http.Request) bool {
+	return r.Header.Get("X-Forwarded-For") != "" || r.Header.Get("Forwarded") != ""
+}
+
+// clientIP returns the address of the client behind r. A request relayed
+// from the local host by a reverse proxy is attributed to the first
+// X-Forwarded-For entry; otherwise the forwarding headers are ignored, so
+// a remote client cannot pick its own address.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		if fwd := forwardedValue(r, "X-Forwarded-For"); fwd != "" {
+			return fwd
+		}
+	}
+	return host
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// publicEvents are the event types streamed to public read-only clients.
+// Logs, crash reports, config changes, and watchdog alerts stay private.
+var publicEvents = map[string]bool{
+	"heartbeat":      true,
+	"state":          true,
+	"progress":       true,
+	"pass_scheduled": true,
+	"health":         true,
+	"mode_changed":   true,
+}
+
+// publicKey marks requests that arrived on the public listener.
+type publicKey struct{}
+
+// isPublic reports whether r came in on the public read-only listener, so
+// handlers can leave out details such as filesystem paths.
+func isPublic(r *http.Request) bool {
+	v, _ := r.Context().Value(publicKey{}).(bool)
+	return v
+}
+
+// publicMux serves the read-only subset of the API: status, predictions,
+// statistics, and the capture list, plus a filtered event stream. Nothing
+// that changes state or reveals configuration, paths, or URLs is reachable.
+func (a *App) publicMux() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", a.handleHealthz)
+	mux.HandleFunc("/readyz", a.handleReadyz)
+	mux.HandleFunc("/api/status", a.handleStatus)
+	mux.HandleFunc("/api/v1/status", a.handleStatus)
+	mux.HandleFunc("/api/version", a.handleVersion)
+	mux.HandleFunc("/api/satellites", a.handleSatellites)
+	mux.HandleFunc("/api/satellite", a.handleSatellite)
+	mux.HandleFunc("/api/passes", a.handlePasses)
+	mux.HandleFunc("/api/passes/", a.handlePassTrack)
+	mux.HandleFunc("/api/next-pass", a.handleNextPass)
+	mux.HandleFunc("/api/summary", a.handleSummary)
+	mux.HandleFunc("/api/summary.png", a.handleSummaryPNG)
+	mux.HandleFunc("/api/wait-for-change", a.handleWaitForChange)
+	mux.HandleFunc("/api/batch", a.batchHandler(mux))
+	mux.HandleFunc("/api/captures", a.handleCaptures)
+	mux.HandleFunc("/api/stats", a.handleStats)
+	mux.Handle("/ws", a.wsHub.FilteredHandler(func(eventType string) bool {
+		return publicEvents[eventType]
+	}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !readOnlyRequest(r) {
+			w.Header().Set("Allow", "GET, HEAD")
+			jsonError(w, "read-only endpoint", http.StatusMethodNotAllowed)
+			return
+		}
+		mux.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), publicKey{}, true)))
+	})
+}
+
+// startPublic opens the public read-only listener when
+// server.public_readonly is set. It is shut down with the daemon.
+func (a *App) startPublic(ctx context.Context) error {
+	cfg := a.getConfig()
+	if !cfg.Server.PublicReadonly {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", cfg.Server.PublicBind)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:           a.recoverHTTP(a.withBasePath(a.requireShare(a.publicMux()))),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	a.log.Info("public read-only listener", "url", "http://"+cfg.Server.PublicBind)
+
+	go func() {
+		<-ctx.Done()
+		_ = srv.Shutdown(context.Background())
+	}()
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			a.log.Error("public listener failed", "err", err)
+		}
+	}()
+	return nil
+}
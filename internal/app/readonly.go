@@ -0,0 +1,150 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/notify"
+)
+
+// storageProbeInterval is how often a read-only data root is tried for
+// writes again. While it is writable the health check, once a minute, and
+// failed captures are what notice it going read-only.
+const storageProbeInterval = 15 * time.Second
+
+// storageMonitor tracks whether data.root has gone read-only, as SD cards
+// do when the kernel remounts them after I/O errors. While it is, the
+// daemon keeps predicting passes and serving the API but records nothing.
+type storageMonitor struct {
+	mu       sync.Mutex
+	readOnly bool
+	since    time.Time
+	err      string
+}
+
+// status returns whether data.root is read-only, since when, and the
+// write error that showed it.
+func (m *storageMonitor) status() (bool, time.Time, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.readOnly, m.since, m.err
+}
+
+// isReadOnly reports whether err is a write refused by a read-only
+// filesystem.
+func isReadOnly(err error) bool {
+	return errors.Is(err, syscall.EROFS)
+}
+
+// probeStorage writes and removes a small file in root.
+func probeStorage(root string) error {
+	path := filepath.Join(root, ".healthcheck")
+	if err := os.WriteFile(path, []byte("ok"), 0o644); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// noteWrite is told how a write to data.root went. A write refused as
+// read-only degrades the daemon; any write that succeeds while degraded
+// recovers it. Other errors say nothing either way.
+func (a *App) noteWrite(err error) {
+	switch {
+	case err == nil:
+		a.leaveReadOnly()
+	case isReadOnly(err):
+		a.enterReadOnly(err)
+	}
+}
+
+// enterReadOnly degrades the daemon after err showed data.root read-only.
+func (a *App) enterReadOnly(err error) {
+	m := &a.storage
+	m.mu.Lock()
+	if m.readOnly {
+		m.mu.Unlock()
+		return
+	}
+	m.readOnly, m.since, m.err = true, time.Now().UTC(), err.Error()
+	m.mu.Unlock()
+
+	root := a.getConfig().Data.Root
+	msg := fmt.Sprintf("%s is read-only (%v); captures are stopped until it is writable again", root, err)
+	a.log.Error("data root is read-only; captures stopped", "path", root, "err", err)
+	a.emit("ephemerisd", map[string]any{
+		"type":      "storage",
+		"read_only": true,
+		"path":      root,
+		"error":     err.Error(),
+		"message":   msg,
+	})
+	a.notifier.Notify(notify.Message{
+		Kind:    notify.KindFailure,
+		Subject: "Station storage is read-only",
+		Text:    msg + ".",
+		Key:     notify.KindFailure + ": read-only",
+	})
+}
+
+// leaveReadOnly recovers the daemon once data.root is writable again.
+func (a *App) leaveReadOnly() {
+	m := &a.storage
+	m.mu.Lock()
+	if !m.readOnly {
+		m.mu.Unlock()
+		return
+	}
+	since := m.since
+	m.readOnly, m.since, m.err = false, time.Time{}, ""
+	m.mu.Unlock()
+
+	root := a.getConfig().Data.Root
+	down := time.Since(since).Truncate(time.Second)
+	msg := fmt.Sprintf("%s is writable again after %s; captures resume", root, down)
+	a.log.Info("data root is writable again; captures resume", "path", root, "read_only_for", down.String())
+	a.emit("ephemerisd", map[string]any{
+		"type":      "storage",
+		"read_only": false,
+		"path":      root,
+		"message":   msg,
+	})
+	a.notifier.Notify(notify.Message{
+		Kind:    notify.KindFailure,
+		Subject: "Station storage is writable again",
+		Text:    msg + ".",
+		Key:     notify.KindFailure + ": writable",
+	})
+}
+
+// captureGate is the scheduler's capture gate: nothing is recorded while
+// data.root is read-only.
+func (a *App) captureGate() error {
+	readOnly, since, _ := a.storage.status()
+	if !readOnly {
+		return nil
+	}
+	return fmt.Errorf("%s has been read-only since %s", a.getConfig().Data.Root, since.Format(time.RFC3339))
+}
+
+// storageLoop tries data.root for writes while it is read-only, so the
+// daemon recovers as soon as the filesystem is remounted read-write.
+func (a *App) storageLoop(ctx context.Context) {
+	t := time.NewTicker(storageProbeInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if readOnly, _, _ := a.storage.status(); readOnly {
+				a.noteWrite(probeStorage(a.getConfig().Data.Root))
+			}
+		}
+	}
+}
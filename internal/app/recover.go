@@ -0,0 +1,106 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+)
+
+// crashRestartDelay is how long a supervised loop waits before restarting
+// after a panic, so a deterministic crash does not spin the CPU.
+const crashRestartDelay = 5 * time.Second
+
+// recoverHTTP wraps a handler so a panic in one request is logged and
+// reported instead of tearing down the connection without a response.
+func (a *App) recoverHTTP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if v := recover(); v != nil {
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+				a.reportCrash("http "+r.Method+" "+r.URL.Path, v, debug.Stack())
+				jsonError(w, "internal server error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// supervise runs fn in the calling goroutine and restarts it if it panics.
+// A normal return (typically on context cancellation) ends supervision.
+func (a *App) supervise(ctx context.Context, name string, fn func(context.Context)) {
+	for {
+		if !a.runRecovered(ctx, name, fn) {
+			return
+		}
+		a.emit("ephemerisd", map[string]any{
+			"type":    "log",
+			"level":   "error",
+			"message": fmt.Sprintf("%s crashed, restarting in %s", name, crashRestartDelay),
+		})
+		t := time.NewTimer(crashRestartDelay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// runRecovered calls fn and reports whether it panicked.
+func (a *App) runRecovered(ctx context.Context, name string, fn func(context.Context)) (panicked bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			a.reportCrash(name, v, debug.Stack())
+			panicked = true
+		}
+	}()
+	fn(ctx)
+	return false
+}
+
+// reportCrash logs a recovered panic with its stack, broadcasts a crash
+// event, and writes a crash report under data.root/crashes.
+func (a *App) reportCrash(component string, v any, stack []byte) {
+	now := time.Now().UTC()
+	a.log.Error("panic", "component", component, "panic", fmt.Sprint(v), "stack", string(stack))
+
+	report := a.writeCrashReport(now, component, v, stack)
+
+	a.emit("ephemerisd", map[string]any{
+		"type":    "crash",
+		"source":  component,
+		"message": fmt.Sprint(v),
+		"report":  report,
+	})
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   "error",
+		"message": fmt.Sprintf("panic in %s: %v", component, v),
+	})
+}
+
+// writeCrashReport saves the panic details and returns the report path, or
+// an empty string if the file could not be written.
+func (a *App) writeCrashReport(now time.Time, component string, v any, stack []byte) string {
+	dir := filepath.Join(a.getConfig().Data.Root, "crashes")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		a.log.Error("could not write crash report", "err", err)
+		return ""
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("crash_%s.txt", now.Format("20060102T150405.000Z")))
+	body := fmt.Sprintf("time:      %s\ncomponent: %s\nversion:   %s\nstate:     %s\npanic:     %v\n\n%s",
+		now.Format(time.RFC3339Nano), component, Version, a.state.Load().(string), v, stack)
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		a.log.Error("could not write crash report", "err", err)
+		return ""
+	}
+	return path
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// minRedactLen is the shortest secret scrubbed from log output. Shorter
+// values would match too much unrelated text to be useful.
+const minRedactLen = 4
+
+// secretSet holds the current sensitive config values, longest first so a
+// secret containing another is replaced whole.
+type secretSet struct {
+	values atomic.Pointer[[]string]
+}
+
+// update replaces the tracked values with those in cfg. Called at startup
+// and on every reload.
+func (s *secretSet) update(cfg config.Config) {
+	var vals []string
+	for _, v := range cfg.SecretValues() {
+		if len(v) >= minRedactLen {
+			vals = append(vals, v)
+		}
+	}
+	sort.Slice(vals, func(i, j int) bool { return len(vals[i]) > len(vals[j]) })
+	s.values.Store(&vals)
+}
+
+// redact replaces any known secret in msg.
+func (s *secretSet) redact(msg string) string {
+	vals := s.values.Load()
+	if vals == nil {
+		return msg
+	}
+	for _, v := range *vals {
+		msg = strings.ReplaceAll(msg, v, "(redacted)")
+	}
+	return msg
+}
+
+// operatorAuthorized reports whether r may see unredacted secrets. Such
+// requests must come from the local host, and when a [debug] token is
+// configured they must also present it as a bearer token.
+func (a *App) operatorAuthorized(r *http.Request) (bool, string) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+		return false, "secrets can only be shown to clients on the local host"
+	}
+	if viaProxy(r) {
+		return false, "secrets are not shown through a reverse proxy"
+	}
+	token := a.getConfig().Debug.Token.Value()
+	if token != "" && !tokenMatches(bearerToken(r), token) {
+		return false, "invalid or missing operator token (the [debug] token)"
+	}
+	return true, ""
+}
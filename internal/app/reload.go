@@ -0,0 +1,87 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// reloadConfig loads loadPath, swaps it in as the running config, and
+// broadcasts a config_changed event listing every field that changed.
+// source records what asked for the reload ("api" or "sighup").
+func (a *App) reloadConfig(loadPath, source string) ([]config.FieldChange, error) {
+	newCfg, err := config.Load(loadPath)
+	if err != nil {
+		return nil, err
+	}
+
+	a.cfgMu.Lock()
+	oldCfg := a.cfg
+	a.cfg = newCfg
+	a.cfgLoadedAt = time.Now()
+	a.secrets.update(newCfg)
+	a.configPath = loadPath
+	a.cfgMu.Unlock()
+
+	changes := config.Diff(oldCfg, newCfg)
+	a.applyCatalog(newCfg)
+	if a.logOut != nil {
+		a.logOut.SetLevel(newCfg.Logging.Level)
+		a.logOut.SetComponentLevels(newCfg.Logging.Components)
+	}
+	a.wsHub.SetLimits(wsLimits(newCfg))
+	if a.plugins != nil {
+		a.plugins.Update(newCfg.Plugins)
+	}
+	if a.rules != nil {
+		a.updateRules(newCfg)
+	}
+	if a.scripts != nil {
+		a.updateScripts(newCfg)
+	}
+	for _, c := range changes {
+		if s := a.sched(); s != nil && (strings.HasPrefix(c.Key, "schedule.") || c.Key == "predict.min_quality" || c.Key == "predict.tle_max_epoch_age_days") {
+			s.Reschedule()
+			break
+		}
+	}
+
+	a.emit("ephemerisd", map[string]any{
+		"type":    "config_changed",
+		"source":  source,
+		"path":    loadPath,
+		"changes": changes,
+	})
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   "info",
+		"message": fmt.Sprintf("config reloaded from %s (%d changed)", loadPath, len(changes)),
+	})
+	for _, c := range changes {
+		a.log.Info("config changed", "key", c.Key, "old", c.Old, "new", c.New)
+	}
+	return changes, nil
+}
+
+// Reload re-reads the current config file, as on SIGHUP. Failures are
+// logged and broadcast; the running config is left untouched.
+func (a *App) Reload() {
+	a.cfgMu.RLock()
+	path := a.configPath
+	a.cfgMu.RUnlock()
+
+	if path == "" {
+		a.log.Warn("reload requested but no config file is in use")
+		return
+	}
+	if _, err := a.reloadConfig(path, "sighup"); err != nil {
+		a.emit("ephemerisd", map[string]any{
+			"type":    "log",
+			"level":   "error",
+			"message": "config reload failed: " + err.Error(),
+		})
+		a.log.Error("config reload failed", "err", err)
+	}
+}
@@ -0,0 +1,264 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/api"
+	"github.com/large-farva/ephemeris-engine/internal/eventlog"
+	"github.com/large-farva/ephemeris-engine/internal/store"
+)
+
+// A replay covers the logged events from replayLead before a pass's AOS to
+// replayTail after its LOS, which takes in scheduling before the pass and
+// decoding after it.
+const (
+	replayLead     = 2 * time.Minute
+	replayTail     = 10 * time.Minute
+	replayMaxSpeed = 1000
+)
+
+// replayStatus describes the replay in progress.
+type replayStatus struct {
+	PassID    int64     `json:"pass_id"`
+	Satellite string    `json:"satellite"`
+	Speed     float64   `json:"speed"`
+	Events    int       `json:"events"`
+	Sent      int       `json:"sent"`
+	StartedAt time.Time `json:"started_at"`
+	// Duration is how long the whole replay takes at Speed.
+	Duration float64 `json:"duration_seconds"`
+}
+
+// replayResponse is the body of GET /api/replay.
+type replayResponse struct {
+	Running bool          `json:"running"`
+	Replay  *replayStatus `json:"replay"`
+}
+
+// replayStartResponse is the reply to POST /api/replay.
+type replayStartResponse struct {
+	OK     bool         `json:"ok"`
+	Replay replayStatus `json:"replay"`
+}
+
+// replayer tracks the one replay that may run at a time.
+type replayer struct {
+	mu     sync.Mutex
+	cur    *replayStatus
+	cancel context.CancelFunc
+}
+
+func (r *replayer) status() *replayStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cur == nil {
+		return nil
+	}
+	st := *r.cur
+	return &st
+}
+
+// replayEvents returns the logged events of pass rec, oldest first. Events
+// naming another satellite are left out; events naming none, such as state
+// changes, are kept.
+func replayEvents(dir string, rec store.Record) ([]json.RawMessage, error) {
+	events, err := eventlog.Query(dir, eventlog.Filter{
+		Since: rec.AOS.Add(-replayLead),
+		Until: rec.LOS.Add(replayTail),
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := events[:0]
+	for _, raw := range events {
+		var ev struct {
+			Satellite string `json:"satellite"`
+			Replay    bool   `json:"replay"`
+		}
+		if json.Unmarshal(raw, &ev) != nil || ev.Replay {
+			continue
+		}
+		if ev.Satellite == "" || strings.EqualFold(ev.Satellite, rec.Satellite) {
+			out = append(out, raw)
+		}
+	}
+	return out, nil
+}
+
+// eventTime returns an event's ts.
+func eventTime(raw json.RawMessage) time.Time {
+	var ev struct {
+		TS time.Time `json:"ts"`
+	}
+	_ = json.Unmarshal(raw, &ev)
+	return ev.TS
+}
+
+// runReplay re-broadcasts events with their original spacing divided by
+// speed. Each carries "replay": true and the pass ID, and its original ts
+// moves to original_ts, so clients and rules can tell it from a live event.
+// Replayed events are not written to the event log again.
+func (a *App) runReplay(ctx context.Context, st replayStatus, events []json.RawMessage) {
+	a.emit("ephemerisd", map[string]any{
+		"type":             "replay",
+		"phase":            "started",
+		"pass_id":          st.PassID,
+		"satellite":        st.Satellite,
+		"events":           st.Events,
+		"speed":            st.Speed,
+		"duration_seconds": st.Duration,
+	})
+
+	phase := "finished"
+	start := time.Now()
+	first := eventTime(events[0])
+	for i, raw := range events {
+		due := start.Add(time.Duration(float64(eventTime(raw).Sub(first)) / st.Speed))
+		if wait := time.Until(due); wait > 0 {
+			t := time.NewTimer(wait)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+			case <-t.C:
+			}
+		}
+		if ctx.Err() != nil {
+			phase = "stopped"
+			break
+		}
+		var ev map[string]any
+		if json.Unmarshal(raw, &ev) != nil {
+			continue
+		}
+		ev["original_ts"] = ev["ts"]
+		ev["ts"] = time.Now().UTC().Format(time.RFC3339Nano)
+		ev["replay"] = true
+		ev["replay_pass_id"] = st.PassID
+		a.wsHub.BroadcastJSON(ev)
+
+		a.replay.mu.Lock()
+		a.replay.cur.Sent = i + 1
+		a.replay.mu.Unlock()
+	}
+
+	a.replay.mu.Lock()
+	sent := a.replay.cur.Sent
+	a.replay.cur, a.replay.cancel = nil, nil
+	a.replay.mu.Unlock()
+
+	a.emit("ephemerisd", map[string]any{
+		"type":      "replay",
+		"phase":     phase,
+		"pass_id":   st.PassID,
+		"satellite": st.Satellite,
+		"events":    st.Events,
+		"sent":      sent,
+	})
+}
+
+// handleReplay re-broadcasts the logged events of a past pass, for testing
+// dashboards and integrations against a realistic sequence. pass_id is a
+// history record ID; speed defaults to 1 (real time) and may be up to
+// 1000. Only one replay runs at a time: GET reports it and DELETE stops it.
+//
+//	POST /api/replay?pass_id=42&speed=10
+func (a *App) handleReplay(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		st := a.replay.status()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(replayResponse{Running: st != nil, Replay: st})
+		return
+	case http.MethodDelete:
+		a.replay.mu.Lock()
+		cancel := a.replay.cancel
+		a.replay.mu.Unlock()
+		if cancel == nil {
+			jsonError(w, "no replay is running", http.StatusConflict)
+			return
+		}
+		cancel()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.OKResponse{OK: true, Message: "replay stopped"})
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	id, err := strconv.ParseInt(q.Get("pass_id"), 10, 64)
+	if err != nil {
+		jsonError(w, "pass_id must be a pass history ID", http.StatusBadRequest)
+		return
+	}
+	speed := 1.0
+	if v := q.Get("speed"); v != "" {
+		if speed, err = strconv.ParseFloat(v, 64); err != nil || speed <= 0 || speed > replayMaxSpeed {
+			jsonError(w, fmt.Sprintf("speed must be above 0 and at most %d", replayMaxSpeed), http.StatusBadRequest)
+			return
+		}
+	}
+	rec, ok, err := a.history.Get(id)
+	if err != nil {
+		jsonError(w, "read history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		jsonError(w, fmt.Sprintf("no pass with ID %d in the history", id), http.StatusNotFound)
+		return
+	}
+	if rec.AOS.IsZero() || rec.LOS.IsZero() {
+		jsonError(w, fmt.Sprintf("pass %d has no recorded AOS and LOS to replay", id), http.StatusUnprocessableEntity)
+		return
+	}
+
+	events, err := replayEvents(eventLogDir(a.getConfig().Data.Root), rec)
+	if err != nil {
+		jsonError(w, "read event log: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(events) == 0 {
+		jsonError(w, fmt.Sprintf("no logged events for pass %d; is [events] persist on?", id), http.StatusNotFound)
+		return
+	}
+	span := eventTime(events[len(events)-1]).Sub(eventTime(events[0]))
+	st := replayStatus{
+		PassID:    id,
+		Satellite: rec.Satellite,
+		Speed:     speed,
+		Events:    len(events),
+		StartedAt: time.Now().UTC(),
+		Duration:  span.Seconds() / speed,
+	}
+
+	a.runMu.Lock()
+	ctx := a.runCtx
+	a.runMu.Unlock()
+	a.replay.mu.Lock()
+	if busy := a.replay.cur; busy != nil {
+		a.replay.mu.Unlock()
+		jsonError(w, fmt.Sprintf("pass %d is already being replayed", busy.PassID), http.StatusConflict)
+		return
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	cur := st
+	a.replay.cur, a.replay.cancel = &cur, cancel
+	a.replay.mu.Unlock()
+	go func() {
+		defer cancel()
+		a.runReplay(ctx, st, events)
+	}()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(replayStartResponse{OK: true, Replay: st})
+}
@@ -0,0 +1,487 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/api"
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+const (
+	// retentionCheckInterval is how often the janitor checks whether a
+	// sweep is due.
+	retentionCheckInterval = time.Minute
+	// keepTag exempts a capture from retention.
+	keepTag = "keep"
+)
+
+// prunedCapture is one capture a retention sweep archived or deleted, or
+// would have in a dry run. Reason names the limit it was pruned for.
+type prunedCapture struct {
+	File   string `json:"file"`
+	Reason string `json:"reason"`
+	Bytes  int64  `json:"bytes"`
+	Error  string `json:"error,omitempty"`
+}
+
+// retentionReport summarizes one retention sweep. TotalBytes and Kept
+// describe the captures left in data.root afterwards.
+type retentionReport struct {
+	StartedAt   string          `json:"started_at"`
+	FinishedAt  string          `json:"finished_at"`
+	Action      string          `json:"action"`
+	DryRun      bool            `json:"dry_run,omitempty"`
+	Pruned      []prunedCapture `json:"pruned"`
+	FreedBytes  int64           `json:"freed_bytes"`
+	TotalBytes  int64           `json:"total_bytes"`
+	Kept        int             `json:"kept"`
+	Warnings    []string        `json:"warnings,omitempty"`
+	Interrupted string          `json:"interrupted,omitempty"` // why the run stopped early
+	Source      string          `json:"source"`                // "schedule" or "api"
+}
+
+// janitor tracks the background retention sweep.
+type janitor struct {
+	mu      sync.Mutex
+	running bool
+	last    *retentionReport
+	lastRun time.Time // of the last real sweep, not a dry run
+}
+
+func (j *janitor) report() (*retentionReport, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.last, j.running
+}
+
+// retentionLoop sweeps data.root every retention.interval_minutes while a
+// retention limit is set, until ctx is cancelled. The policy is re-read on
+// each check, so a reload can change or turn it off.
+func (a *App) retentionLoop(ctx context.Context) {
+	t := time.NewTicker(retentionCheckInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			if due, ok := a.nextRetention(); ok && !now.Before(due) && !a.capturing() {
+				a.runRetention(ctx, "schedule", false)
+			}
+		}
+	}
+}
+
+// nextRetention returns when the next sweep is due; ok is false when no
+// retention limit is set.
+func (a *App) nextRetention() (time.Time, bool) {
+	rc := a.getConfig().Retention
+	if !rc.Enabled() {
+		return time.Time{}, false
+	}
+	a.janitor.mu.Lock()
+	last := a.janitor.lastRun
+	a.janitor.mu.Unlock()
+	if last.IsZero() {
+		return a.startedAt.Add(retentionCheckInterval), true
+	}
+	return last.Add(time.Duration(rc.IntervalMinutes) * time.Minute), true
+}
+
+// retainedCapture is a capture in data.root with the files that go with it.
+type retainedCapture struct {
+	name  string
+	at    time.Time
+	bytes int64
+	keep  bool
+	files []string // relative to data.root, the recording first
+}
+
+// retainedCaptures lists the captures in root, oldest first, dated by AOS
+// or, without a sidecar, by the recording's modification time.
+func retainedCaptures(root string) []retainedCapture {
+	matches := capture.Recordings(root)
+	out := make([]retainedCapture, 0, len(matches))
+	for _, path := range matches {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		files, bytes, meta := captureFiles(root, path, info)
+		c := retainedCapture{
+			name:  filepath.Base(path),
+			at:    info.ModTime(),
+			bytes: bytes,
+			files: files,
+		}
+		if meta != nil {
+			if aos, err := time.Parse(time.RFC3339, meta.AOS); err == nil {
+				c.at = aos
+			}
+			c.keep = slices.Contains(meta.Tags, keepTag)
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].at.Before(out[j].at) })
+	return out
+}
+
+// captureFiles lists the files of the capture recorded at path, relative
+// to root with the recording first, and their total size. info is the
+// recording's. It also returns the capture's sidecar, or nil if it has
+// none.
+func captureFiles(root, path string, info os.FileInfo) ([]string, int64, *capture.Metadata) {
+	files := []string{filepath.Base(path)}
+	bytes := info.Size()
+	if fi, err := os.Stat(capture.MetadataPath(path)); err == nil {
+		bytes += fi.Size()
+		files = append(files, filepath.Base(capture.MetadataPath(path)))
+	}
+	meta, err := capture.ReadMetadata(path)
+	if err != nil {
+		return files, bytes, nil
+	}
+	for _, img := range meta.Images {
+		// Image paths are relative to data.root; cleaning them against "/"
+		// keeps them inside it.
+		rel := filepath.Clean("/" + img)[1:]
+		if fi, err := os.Stat(filepath.Join(root, rel)); err == nil {
+			bytes += fi.Size()
+			files = append(files, rel)
+		}
+	}
+	return files, bytes, &meta
+}
+
+// planRetention picks the captures rc prunes, oldest first: those past
+// max_age_days, then as many more as bring the total under
+// max_total_bytes, then as many more as free min_free_bytes. Captures
+// tagged "keep" are never picked but count towards the total.
+func planRetention(rc config.RetentionConfig, caps []retainedCapture, now time.Time, free int64, freesSpace bool) ([]prunedCapture, []string) {
+	var plan []prunedCapture
+	var warnings []string
+	var total int64
+	for _, c := range caps {
+		total += c.bytes
+	}
+	next := 0
+	prune := func(c retainedCapture, reason string) {
+		plan = append(plan, prunedCapture{File: c.name, Reason: reason, Bytes: c.bytes})
+		total -= c.bytes
+		if freesSpace {
+			free += c.bytes
+		}
+	}
+	for ; next < len(caps); next++ {
+		c := caps[next]
+		if rc.MaxAgeDays <= 0 || now.Sub(c.at) <= time.Duration(rc.MaxAgeDays)*24*time.Hour {
+			break
+		}
+		if !c.keep {
+			prune(c, "max_age_days")
+		}
+	}
+	for ; next < len(caps) && rc.MaxTotalBytes > 0 && total > rc.MaxTotalBytes; next++ {
+		if !caps[next].keep {
+			prune(caps[next], "max_total_bytes")
+		}
+	}
+	if rc.MaxTotalBytes > 0 && total > rc.MaxTotalBytes {
+		warnings = append(warnings, fmt.Sprintf("captures still take %d bytes, over max_total_bytes; the rest are tagged %q", total, keepTag))
+	}
+	if rc.MinFreeBytes > 0 && free < rc.MinFreeBytes {
+		if !freesSpace {
+			return plan, append(warnings, "data.archive is on the same filesystem as data.root, so archiving cannot meet min_free_bytes; set action = \"delete\" or move the archive")
+		}
+		for ; next < len(caps) && free < rc.MinFreeBytes; next++ {
+			if !caps[next].keep {
+				prune(caps[next], "min_free_bytes")
+			}
+		}
+		if free < rc.MinFreeBytes {
+			warnings = append(warnings, fmt.Sprintf("only %d bytes free after pruning, under min_free_bytes", free))
+		}
+	}
+	return plan, warnings
+}
+
+// runRetention applies the retention policy to data.root, archiving or
+// deleting each capture it prunes along with its sidecar and images, and
+// marking it deleted in the history. A dry run only reports what it would
+// prune. The run stops early if a capture starts. It returns nil without
+// doing anything if a sweep is already running.
+func (a *App) runRetention(ctx context.Context, source string, dryRun bool) *retentionReport {
+	a.janitor.mu.Lock()
+	if a.janitor.running {
+		a.janitor.mu.Unlock()
+		return nil
+	}
+	a.janitor.running = true
+	a.janitor.mu.Unlock()
+
+	cfg := a.getConfig()
+	rc := cfg.Retention
+	start := time.Now()
+	rep := &retentionReport{
+		StartedAt: start.UTC().Format(time.RFC3339),
+		Action:    rc.Action,
+		DryRun:    dryRun,
+		Pruned:    []prunedCapture{},
+		Source:    source,
+	}
+
+	caps := retainedCaptures(cfg.Data.Root)
+	var free int64
+	if d := diskUsage(cfg.Data.Root); d != nil {
+		free = int64(d.AvailableBytes)
+	}
+	freesSpace := rc.Action == "delete" || !sameFilesystem(cfg.Data.Root, cfg.Data.Archive)
+	plan, warnings := planRetention(rc, caps, start, free, freesSpace)
+	rep.Warnings = warnings
+
+	byName := map[string]retainedCapture{}
+	for _, c := range caps {
+		byName[c.name] = c
+		rep.TotalBytes += c.bytes
+	}
+	rep.Kept = len(caps)
+	for _, p := range plan {
+		if !dryRun {
+			if ctx.Err() != nil {
+				rep.Interrupted = "shutdown"
+				break
+			}
+			if a.capturing() {
+				rep.Interrupted = "capture started"
+				break
+			}
+			if err := a.pruneCapture(cfg, byName[p.File]); err != nil {
+				p.Error = err.Error()
+				rep.Pruned = append(rep.Pruned, p)
+				a.log.Warn("could not prune capture", "component", "retention", "action", rc.Action, "file", p.File, "err", err)
+				continue
+			}
+			a.emit("ephemerisd", map[string]any{
+				"type":   "capture_pruned",
+				"file":   p.File,
+				"action": rc.Action,
+				"reason": p.Reason,
+				"bytes":  p.Bytes,
+			})
+		}
+		rep.Pruned = append(rep.Pruned, p)
+		rep.FreedBytes += p.Bytes
+		rep.TotalBytes -= p.Bytes
+		rep.Kept--
+	}
+	rep.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+
+	a.janitor.mu.Lock()
+	a.janitor.running = false
+	if !dryRun {
+		a.janitor.last = rep
+		a.janitor.lastRun = start
+	}
+	a.janitor.mu.Unlock()
+	if dryRun {
+		return rep
+	}
+	if len(rep.Pruned) > 0 {
+		a.requestGallery("retention")
+	}
+
+	a.emit("ephemerisd", map[string]any{
+		"type":        "retention",
+		"action":      rc.Action,
+		"pruned":      len(rep.Pruned),
+		"freed_bytes": rep.FreedBytes,
+		"total_bytes": rep.TotalBytes,
+		"warnings":    rep.Warnings,
+		"interrupted": rep.Interrupted,
+	})
+	for _, w := range rep.Warnings {
+		a.emit("ephemerisd", map[string]any{
+			"type":    "log",
+			"level":   "warn",
+			"message": "retention: " + w,
+		})
+	}
+	if len(rep.Pruned) > 0 || rep.Interrupted != "" {
+		msg := fmt.Sprintf("retention sweep %s %d captures (%d bytes) in %s", pastTense(rc.Action), len(rep.Pruned), rep.FreedBytes, time.Since(start).Truncate(time.Millisecond))
+		if rep.Interrupted != "" {
+			msg += " (stopped early: " + rep.Interrupted + ")"
+		}
+		a.emit("ephemerisd", map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": msg,
+		})
+	}
+	return rep
+}
+
+func pastTense(action string) string {
+	if action == "delete" {
+		return "deleted"
+	}
+	return "archived"
+}
+
+// pruneCapture archives or deletes c's files, the recording first, and
+// marks it deleted in the history. Once the recording is gone the rest is
+// best effort, so a capture is never left half in data.root.
+func (a *App) pruneCapture(cfg config.Config, c retainedCapture) error {
+	for i, rel := range c.files {
+		src := filepath.Join(cfg.Data.Root, rel)
+		var err error
+		if cfg.Retention.Action == "delete" {
+			err = os.Remove(src)
+		} else {
+			err = moveFile(src, filepath.Join(cfg.Data.Archive, rel))
+		}
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			if i == 0 {
+				return err
+			}
+			a.log.Warn("could not prune file", "component", "retention", "action", cfg.Retention.Action, "file", rel, "err", err)
+		}
+	}
+	if _, err := a.history.MarkDeleted(c.name); err != nil {
+		a.log.Warn("could not mark capture deleted", "component", "history", "file", c.name, "err", err)
+	}
+	return nil
+}
+
+// moveFile renames src to dst, copying it across filesystems, since the
+// archive is often on a separate disk.
+func moveFile(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	err := os.Rename(src, dst)
+	if !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// sameFilesystem reports whether a and b are on the same filesystem, or
+// b does not exist yet and would be created beside a.
+func sameFilesystem(a, b string) bool {
+	var sa, sb syscall.Stat_t
+	if syscall.Stat(a, &sa) != nil || syscall.Stat(b, &sb) != nil {
+		return true
+	}
+	return sa.Dev == sb.Dev
+}
+
+// retentionResponse is the body of GET /api/retention.
+type retentionResponse struct {
+	Enabled bool                   `json:"enabled"`
+	Policy  config.RetentionConfig `json:"policy"`
+	Running bool                   `json:"running"`
+	Last    *retentionReport       `json:"last"`
+	NextDue string                 `json:"next_due,omitempty"` // RFC 3339
+}
+
+// retentionRunResponse is the report of a dry run.
+type retentionRunResponse struct {
+	OK     bool             `json:"ok"`
+	Report *retentionReport `json:"report"`
+}
+
+// handleRetention reports the retention policy and the last sweep.
+func (a *App) handleRetention(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cfg := a.getConfig()
+	last, running := a.janitor.report()
+	resp := retentionResponse{
+		Enabled: cfg.Retention.Enabled(),
+		Policy:  cfg.Retention,
+		Running: running,
+		Last:    last,
+	}
+	if due, ok := a.nextRetention(); ok {
+		if due.Before(time.Now()) {
+			due = time.Now()
+		}
+		resp.NextDue = due.UTC().Format(time.RFC3339)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// handleRetentionRun starts a sweep in the background, or with
+// ?dry_run=true returns what one would prune right away. It works even
+// with no limit set, which prunes nothing.
+//
+//	POST /api/retention/run?dry_run=true
+func (a *App) handleRetentionRun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, running := a.janitor.report(); running {
+		jsonError(w, "a retention sweep is already running", http.StatusConflict)
+		return
+	}
+	a.runMu.Lock()
+	ctx := a.runCtx
+	a.runMu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Query().Get("dry_run") == "true" {
+		rep := a.runRetention(ctx, "api", true)
+		if rep == nil {
+			jsonError(w, "a retention sweep is already running", http.StatusConflict)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(retentionRunResponse{OK: true, Report: rep})
+		return
+	}
+	if a.capturing() {
+		jsonError(w, "a capture is in progress; retention sweep postponed", http.StatusConflict)
+		return
+	}
+	go a.runRetention(ctx, "api", false)
+	_ = json.NewEncoder(w).Encode(api.OKResponse{OK: true, Message: "retention sweep started"})
+}
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/rules"
+)
+
+// ruleActionPaths are the endpoints behind rule actions other than notify.
+var ruleActionPaths = map[string]string{
+	"pause":  "/api/pause",
+	"resume": "/api/resume",
+	"skip":   "/api/skip",
+}
+
+// startRules creates the rules engine for the configured [[rules]].
+func (a *App) startRules() {
+	a.rules = rules.NewEngine(a.wsHub, a.log, a.emit, a.ruleAction, a.ruleStats)
+	a.updateRules(a.getConfig())
+}
+
+// updateRules hands cfg's rules to the engine. They were checked when the
+// config was loaded, so an error here is only logged.
+func (a *App) updateRules(cfg config.Config) {
+	rs := make([]rules.Rule, len(cfg.Rules))
+	for i, r := range cfg.Rules {
+		rs[i] = r.Rule()
+	}
+	if err := a.rules.Update(rs); err != nil {
+		a.log.Error("could not update rules", "component", "rules", "err", err)
+	}
+}
+
+// ruleAction performs a scheduler action for a rule.
+func (a *App) ruleAction(rule, action string) error {
+	_, err := a.callAPI(http.MethodPost, ruleActionPaths[action], nil)
+	return err
+}
+
+// ruleStats gathers the station statistics rules may test (rules.StatNames).
+func (a *App) ruleStats() map[string]any {
+	cfg := a.getConfig()
+	s := map[string]any{
+		"state":          a.state.Load().(string),
+		"mode":           modeName(a.isDemo()),
+		"uptime_seconds": time.Since(a.startedAt).Seconds(),
+		"paused":         false,
+	}
+	if sc := a.sched(); sc != nil {
+		s["paused"] = sc.IsPaused()
+	}
+	if du := diskUsage(cfg.Data.Root); du != nil {
+		total, free := du.TotalBytes, du.AvailableBytes
+		s["disk_total"] = total
+		s["disk_free"] = free
+		if total > 0 {
+			s["disk_used_percent"] = 100 * float64(total-free) / float64(total)
+		}
+	}
+
+	if sum, err := a.history.Summary(); err == nil {
+		s["captures_total"] = sum.TotalCaptures
+		failed := 0
+		for _, n := range sum.FailuresBySat {
+			failed += n
+		}
+		s["captures_failed"] = failed
+	}
+	return s
+}
+
+// rulesResponse is the body of GET /api/rules.
+type rulesResponse struct {
+	Rules []rules.Status `json:"rules"`
+}
+
+// handleRules lists the configured rules with their hit counters.
+func (a *App) handleRules(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	statuses := []rules.Status{}
+	if a.rules != nil {
+		statuses = a.rules.Status()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(rulesResponse{Rules: statuses})
+}
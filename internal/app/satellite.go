@@ -0,0 +1,501 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/api"
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+// satelliteResponse is the body of GET /api/satellite.
+type satelliteResponse struct {
+	Satellite satelliteJSON `json:"satellite"`
+	// PassesError is set when prediction failed; the rest of the view is
+	// still served.
+	PassesError string         `json:"passes_error,omitempty"`
+	Passes      []passJSON     `json:"passes"`
+	Clock       *api.Clock     `json:"clock"`
+	TLE         *tleAgeJSON    `json:"tle"` // null when the TLE cache lacks the satellite
+	Captures    []captureInfo  `json:"captures"`
+	Stats       satelliteStats `json:"stats"`
+}
+
+// tleAgeJSON is the epoch of a satellite's TLE and its age.
+type tleAgeJSON struct {
+	Epoch string `json:"epoch"` // RFC 3339
+	AgeS  int    `json:"age_s"`
+}
+
+// satelliteStats is a satellite's capture record over the pass history.
+type satelliteStats struct {
+	Captures      int      `json:"captures"`
+	Failures      int      `json:"failures"`
+	SuccessRate   *float64 `json:"success_rate"` // null before any attempt
+	LastCaptureAt string   `json:"last_capture_at,omitempty"`
+}
+
+// handleSatellite serves a consolidated view of one satellite: its next
+// passes, recent captures, TLE epoch, and capture success rate.
+//
+//	GET /api/satellite?name=NOAA-19&count=5
+func (a *App) handleSatellite(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	var sat *capture.Satellite
+	catalog := capture.Catalog()
+	names := make([]string, 0, len(catalog))
+	for i, s := range catalog {
+		names = append(names, s.Name)
+		if strings.EqualFold(s.Name, name) {
+			sat = &catalog[i]
+		}
+	}
+	if sat == nil {
+		jsonError(w, fmt.Sprintf("unknown satellite %q (known: %s)", name, strings.Join(names, ", ")), http.StatusNotFound)
+		return
+	}
+
+	count := 5
+	if n, err := strconv.Atoi(r.URL.Query().Get("count")); err == nil && n > 0 {
+		count = n
+	}
+
+	cfg := a.getConfig()
+	tz, err := requestLocation(r, cfg)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := satelliteResponse{Satellite: newSatelliteJSON(*sat, cfg)}
+
+	// Next passes. A prediction failure still returns the rest of the view.
+	passes := []predict.Pass{}
+	all, err := predict.NewPredictor(a.wsHub, cfg, a.log).ComputePasses()
+	if err != nil {
+		resp.PassesError = err.Error()
+	}
+	for _, p := range all {
+		if p.Satellite.NoradID == sat.NoradID && len(passes) < count {
+			passes = append(passes, p)
+		}
+	}
+	resp.Passes = passesToJSON(passes, tz)
+	resp.Clock = clockAt(time.Now(), tz)
+
+	// TLE epoch.
+	if tles, err := predict.NewTLEStore(cfg).Fetch(); err == nil {
+		if tle, ok := tles[sat.NoradID]; ok {
+			epoch := predict.TLEEpoch(tle)
+			resp.TLE = &tleAgeJSON{
+				Epoch: epoch.Format(time.RFC3339),
+				AgeS:  int(time.Since(epoch).Seconds()),
+			}
+		}
+	}
+
+	// Recent captures, newest first.
+	recent, err := a.listCaptures(r, cfg.Data.Root)
+	if err != nil {
+		jsonError(w, "read history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	captures := []captureInfo{}
+	for _, c := range recent {
+		if strings.EqualFold(c.Satellite, sat.Name) {
+			captures = append(captures, c)
+		}
+	}
+	sort.Slice(captures, func(i, j int) bool { return captures[i].Timestamp > captures[j].Timestamp })
+	if len(captures) > count {
+		captures = captures[:count]
+	}
+	resp.Captures = captures
+
+	// Success rate over the pass history.
+	sum, err := a.history.Summary()
+	if err != nil {
+		jsonError(w, "read history: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ok := sum.CapturesBySat[sat.Name]
+	failed := sum.FailuresBySat[sat.Name]
+	last, hasLast := sum.LastBySat[sat.Name]
+
+	resp.Stats = satelliteStats{Captures: ok, Failures: failed}
+	if ok+failed > 0 {
+		rate := float64(ok) / float64(ok+failed)
+		resp.Stats.SuccessRate = &rate
+	}
+	if hasLast {
+		resp.Stats.LastCaptureAt = last.Format(time.RFC3339)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// satelliteOffsetRequest is the body of POST /api/satellites/{id}/offset.
+type satelliteOffsetRequest struct {
+	Hz *int `json:"hz"`
+}
+
+// satelliteToggleResponse reports a satellite's setting after a change:
+// Enabled for enable and disable, the offset and tuned frequency for
+// offset.
+type satelliteToggleResponse struct {
+	OK           bool   `json:"ok"`
+	Satellite    string `json:"satellite"`
+	Enabled      *bool  `json:"enabled,omitempty"`
+	FreqOffsetHz *int   `json:"freq_offset_hz,omitempty"`
+	FreqHz       *int   `json:"freq_hz,omitempty"`
+	Changed      bool   `json:"changed"`
+}
+
+// handleSatelliteToggle takes a satellite in or out of the schedule, sets
+// its frequency offset, or removes an added satellite from the catalog:
+//
+//	POST /api/satellites/NOAA-15/disable
+//	POST /api/satellites/25338/enable
+//	POST /api/satellites/NOAA-18/offset  {"hz": -1200}
+//	DELETE /api/satellites/METEOR-M2-3
+//
+// The satellite is named or given by NORAD ID. The change is written to
+// [satellites.NAME] in the config file so it survives a restart. A capture
+// of the satellite already in progress is not interrupted; a new offset
+// applies from its next recording.
+func (a *App) handleSatelliteToggle(w http.ResponseWriter, r *http.Request) {
+	id, action, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/satellites/"), "/")
+	if !ok && id != "" && r.Method == http.MethodDelete {
+		a.handleSatelliteRemove(w, id)
+		return
+	}
+	if !ok || (action != "enable" && action != "disable" && action != "offset") {
+		jsonError(w, "not found; use POST /api/satellites/{name or norad id}/enable, /disable or /offset, or DELETE /api/satellites/{name}", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sat := capture.SatelliteByName(id)
+	if n, err := strconv.Atoi(id); err == nil {
+		sat = capture.SatelliteByNoradID(n)
+	}
+	if sat == nil {
+		jsonError(w, fmt.Sprintf("unknown satellite %q", id), http.StatusNotFound)
+		return
+	}
+
+	var body satelliteOffsetRequest
+	if action == "offset" {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Hz == nil {
+			jsonError(w, `request body must be {"hz": N}`, http.StatusBadRequest)
+			return
+		}
+		if *body.Hz < -config.MaxFreqOffsetHz || *body.Hz > config.MaxFreqOffsetHz {
+			jsonError(w, fmt.Sprintf("hz must be between -%d and %d", config.MaxFreqOffsetHz, config.MaxFreqOffsetHz), http.StatusBadRequest)
+			return
+		}
+	}
+
+	path, ok := a.writableConfig(w)
+	if !ok {
+		return
+	}
+
+	cfg := a.getConfig()
+	resp := satelliteToggleResponse{OK: true, Satellite: sat.Name}
+	var err error
+	if action == "offset" {
+		resp.Changed = cfg.SatelliteFreqOffset(sat.Name) != *body.Hz
+		if resp.Changed {
+			err = a.setSatelliteFreqOffset(path, sat.Name, *body.Hz, "api")
+		}
+		freq := sat.Freq + *body.Hz
+		resp.FreqOffsetHz, resp.FreqHz = body.Hz, &freq
+	} else {
+		enabled := action == "enable"
+		resp.Changed = cfg.SatelliteEnabled(sat.Name) != enabled
+		if resp.Changed {
+			err = a.setSatelliteEnabled(path, sat.Name, enabled, "api")
+		}
+		resp.Enabled = &enabled
+	}
+	if err != nil {
+		jsonError(w, action+" failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// writableConfig returns the config file that API changes are written to.
+// When the daemon runs on built-in defaults it answers 409 and reports
+// false.
+func (a *App) writableConfig(w http.ResponseWriter) (string, bool) {
+	a.cfgMu.RLock()
+	path := a.configPath
+	a.cfgMu.RUnlock()
+	if path == "" {
+		jsonError(w, "daemon is running on built-in defaults; there is no config file to record the change", http.StatusConflict)
+		return "", false
+	}
+	return path, true
+}
+
+// applyCatalog installs the satellites cfg adds to the catalog, and the
+// last catalog sync if it is on. Entries that clash with a built-in
+// satellite are logged and left out.
+func (a *App) applyCatalog(cfg config.Config) {
+	for _, err := range capture.SetCatalog(cfg) {
+		a.log.Warn("ignoring satellite", "component", "satellites", "err", err)
+	}
+	a.applySynced(cfg.Catalog.Sync)
+}
+
+// satelliteNameRe is what a satellite added through the API may be called:
+// a bare [satellites.NAME] table key, as used in capture file names.
+var satelliteNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9.-]*$`)
+
+// satelliteAddRequest is the body of POST /api/satellites.
+type satelliteAddRequest struct {
+	Name    string `json:"name"`
+	NoradID int    `json:"norad_id"`
+	FreqHz  int    `json:"freq_hz"`
+	Mode    string `json:"mode"` // apt (default) or lrpt
+}
+
+// satelliteAddResponse echoes the satellite added.
+type satelliteAddResponse struct {
+	OK        bool   `json:"ok"`
+	Satellite string `json:"satellite"`
+	NoradID   int    `json:"norad_id"`
+	FreqHz    int    `json:"freq_hz"`
+	Mode      string `json:"mode"`
+}
+
+// satelliteRemoveResponse names the satellite removed.
+type satelliteRemoveResponse struct {
+	OK        bool   `json:"ok"`
+	Satellite string `json:"satellite"`
+}
+
+// handleSatelliteAdd adds a satellite to the catalog by writing a
+// [satellites.NAME] table with its NORAD ID, frequency, and mode to the
+// config file. Passes are predicted for it once the TLE source has it.
+//
+//	POST /api/satellites  {"name": "METEOR-M2-3", "norad_id": 57166, "freq_hz": 137900000, "mode": "lrpt"}
+func (a *App) handleSatelliteAdd(w http.ResponseWriter, r *http.Request) {
+	var body satelliteAddRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, "invalid JSON body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if body.Mode == "" {
+		body.Mode = capture.ModeAPT
+	}
+	if !satelliteNameRe.MatchString(body.Name) {
+		jsonError(w, "name must be letters, digits, dots and dashes, such as METEOR-M2-3", http.StatusBadRequest)
+		return
+	}
+	entry := config.SatelliteConfig{NoradID: body.NoradID, FreqHz: body.FreqHz, Mode: body.Mode}
+	if body.NoradID == 0 {
+		jsonError(w, "norad_id is required", http.StatusBadRequest)
+		return
+	}
+	if err := config.ValidateCatalogEntry(body.Name, entry); err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s := capture.SatelliteByName(body.Name); s != nil {
+		jsonError(w, fmt.Sprintf("satellite %s is already in the catalog", s.Name), http.StatusConflict)
+		return
+	}
+	if s := capture.SatelliteByNoradID(body.NoradID); s != nil {
+		jsonError(w, fmt.Sprintf("NORAD %d is already in the catalog as %s", body.NoradID, s.Name), http.StatusConflict)
+		return
+	}
+
+	path, ok := a.writableConfig(w)
+	if !ok {
+		return
+	}
+	section := "satellites." + a.getConfig().SatelliteKey(body.Name)
+	updates := []config.FileUpdate{
+		{Section: section, Key: "norad_id", Value: body.NoradID},
+		{Section: section, Key: "freq_hz", Value: body.FreqHz},
+		{Section: section, Key: "mode", Value: body.Mode},
+	}
+	if err := config.WriteBack(path, updates, "api", time.Now()); err != nil {
+		jsonError(w, "add failed: write config: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, err := a.reloadConfig(path, "satellite"); err != nil {
+		jsonError(w, "add failed: config written but reload failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if s := a.sched(); s != nil {
+		s.Reschedule()
+	}
+
+	message := fmt.Sprintf("satellite %s (NORAD %d, %.4f MHz %s) added to the catalog", body.Name, body.NoradID, float64(body.FreqHz)/1e6, body.Mode)
+	a.log.Info(message, "component", "satellites", "source", "api")
+	a.emit("ephemerisd", map[string]any{
+		"type":      "satellite_added",
+		"satellite": body.Name,
+		"norad_id":  body.NoradID,
+		"freq_hz":   body.FreqHz,
+		"mode":      body.Mode,
+		"source":    "api",
+	})
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   "info",
+		"message": message,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(satelliteAddResponse{
+		OK:        true,
+		Satellite: body.Name,
+		NoradID:   body.NoradID,
+		FreqHz:    body.FreqHz,
+		Mode:      body.Mode,
+	})
+}
+
+// handleSatelliteRemove removes a satellite added to the catalog, deleting
+// its [satellites.NAME] table from the config file. Built-in satellites
+// cannot be removed, only disabled. Its existing captures are kept.
+//
+//	DELETE /api/satellites/METEOR-M2-3
+func (a *App) handleSatelliteRemove(w http.ResponseWriter, id string) {
+	sat := capture.SatelliteByName(id)
+	if n, err := strconv.Atoi(id); err == nil {
+		sat = capture.SatelliteByNoradID(n)
+	}
+	if sat == nil {
+		jsonError(w, fmt.Sprintf("unknown satellite %q", id), http.StatusNotFound)
+		return
+	}
+	if capture.IsBuiltin(sat.Name) {
+		jsonError(w, fmt.Sprintf("%s is built in and cannot be removed; disable it instead", sat.Name), http.StatusConflict)
+		return
+	}
+
+	path, ok := a.writableConfig(w)
+	if !ok {
+		return
+	}
+	found, err := config.RemoveSection(path, "satellites."+a.getConfig().SatelliteKey(sat.Name))
+	if err == nil && !found {
+		err = fmt.Errorf("no [satellites.%s] table in %s; it may come from an included file", sat.Name, path)
+	}
+	if err != nil {
+		jsonError(w, "remove failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, err := a.reloadConfig(path, "satellite"); err != nil {
+		jsonError(w, "remove failed: config written but reload failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if s := a.sched(); s != nil {
+		s.Reschedule()
+	}
+
+	message := fmt.Sprintf("satellite %s removed from the catalog", sat.Name)
+	a.log.Info(message, "component", "satellites", "source", "api")
+	a.emit("ephemerisd", map[string]any{
+		"type":      "satellite_removed",
+		"satellite": sat.Name,
+		"norad_id":  sat.NoradID,
+		"source":    "api",
+	})
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   "info",
+		"message": message,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(satelliteRemoveResponse{OK: true, Satellite: sat.Name})
+}
+
+// writeSatellite persists one [satellites.NAME] setting and reloads the
+// config.
+func (a *App) writeSatellite(path, name, key string, value any, source string) error {
+	update := config.FileUpdate{
+		Section: "satellites." + a.getConfig().SatelliteKey(name),
+		Key:     key,
+		Value:   value,
+	}
+	if err := config.WriteBack(path, []config.FileUpdate{update}, source, time.Now()); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	if _, err := a.reloadConfig(path, "satellite"); err != nil {
+		return fmt.Errorf("config written but reload failed: %w", err)
+	}
+	return nil
+}
+
+// setSatelliteEnabled persists a satellite's enabled flag, reloads the
+// config, and has the live scheduler recompute its schedule.
+func (a *App) setSatelliteEnabled(path, name string, enabled bool, source string) error {
+	if err := a.writeSatellite(path, name, "enabled", enabled, source); err != nil {
+		return err
+	}
+	if s := a.sched(); s != nil {
+		s.Reschedule()
+	}
+
+	message := fmt.Sprintf("satellite %s enabled", name)
+	if !enabled {
+		message = fmt.Sprintf("satellite %s disabled; its passes will not be scheduled", name)
+	}
+	a.log.Info(message, "component", "satellites", "source", source)
+	a.emit("ephemerisd", map[string]any{
+		"type":      "satellite_changed",
+		"satellite": name,
+		"enabled":   enabled,
+		"source":    source,
+	})
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   "info",
+		"message": message,
+	})
+	return nil
+}
+
+// setSatelliteFreqOffset persists a satellite's frequency offset and
+// reloads the config. The capturer reads the live config, so the next
+// recording of the satellite is tuned with it.
+func (a *App) setSatelliteFreqOffset(path, name string, hz int, source string) error {
+	if err := a.writeSatellite(path, name, "freq_offset_hz", hz, source); err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("satellite %s frequency offset set to %+d Hz", name, hz)
+	a.log.Info(message, "component", "satellites", "source", source)
+	a.emit("ephemerisd", map[string]any{
+		"type":           "satellite_changed",
+		"satellite":      name,
+		"enabled":        a.getConfig().SatelliteEnabled(name),
+		"freq_offset_hz": hz,
+		"source":         source,
+	})
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   "info",
+		"message": message,
+	})
+	return nil
+}
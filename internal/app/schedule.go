@@ -0,0 +1,76 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/scheduler"
+)
+
+// scheduleResponse is the body of GET /api/schedule.
+type scheduleResponse struct {
+	ComputedAt string                    `json:"computed_at,omitempty"`
+	Paused     bool                      `json:"paused"`
+	Energy     *scheduler.EnergyBudget   `json:"energy,omitempty"`
+	Passes     []scheduler.ScheduledPass `json:"passes"`
+}
+
+// handleSchedule lists the schedule the scheduler last computed, with the
+// passes it dropped and why, and the operator's overrides.
+func (a *App) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s := a.sched()
+	if s == nil {
+		jsonError(w, "not available in demo mode", http.StatusConflict)
+		return
+	}
+	at, passes := s.Schedule()
+	resp := scheduleResponse{Paused: s.IsPaused(), Energy: s.Energy(), Passes: passes}
+	if resp.Passes == nil {
+		resp.Passes = []scheduler.ScheduledPass{}
+	}
+	if !at.IsZero() {
+		resp.ComputedAt = at.Format(time.RFC3339)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// handleScheduleOverride skips or forces one pass of the schedule, or
+// clears that again, by the ID GET /api/schedule lists it under:
+//
+//	POST /api/schedule/33591-1792267200/skip
+//	POST /api/schedule/33591-1792267200/force
+//	POST /api/schedule/33591-1792267200/clear
+func (a *App) handleScheduleOverride(w http.ResponseWriter, r *http.Request) {
+	id, action, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/schedule/"), "/")
+	if !ok || (action != scheduler.OverrideSkip && action != scheduler.OverrideForce && action != scheduler.OverrideClear) {
+		jsonError(w, "not found; use POST /api/schedule/{pass id}/skip, /force or /clear", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s := a.sched()
+	if s == nil {
+		jsonError(w, "not available in demo mode", http.StatusConflict)
+		return
+	}
+	_, passes := s.Schedule()
+	found := false
+	for _, p := range passes {
+		found = found || p.ID == id
+	}
+	if !found {
+		jsonError(w, "no pass "+id+" in the schedule; see GET /api/schedule", http.StatusNotFound)
+		return
+	}
+	payload, _ := json.Marshal(map[string]string{"id": id, "action": action})
+	writeCommandResult(w, a.sendSchedulerCommand("override", payload))
+}
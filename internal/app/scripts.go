@@ -0,0 +1,47 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/script"
+)
+
+// startScripts creates the script engine for the configured [[scripts]].
+func (a *App) startScripts() {
+	a.scripts = script.NewEngine(a.wsHub, a.log, a.emit, a.scriptControl)
+	a.updateScripts(a.getConfig())
+}
+
+// updateScripts loads cfg's scripts. One that fails to load is logged and
+// listed with its error by GET /api/scripts.
+func (a *App) updateScripts(cfg config.Config) {
+	if err := a.scripts.Update(cfg.Scripts); err != nil {
+		a.log.Error("could not load scripts", "component", "scripts", "err", err)
+	}
+}
+
+// scriptControl serves one control API request from a script.
+func (a *App) scriptControl(name, method string, params json.RawMessage) (json.RawMessage, error) {
+	return a.control("script", name, method, params)
+}
+
+// scriptsResponse is the body of GET /api/scripts.
+type scriptsResponse struct {
+	Scripts []script.Status `json:"scripts"`
+}
+
+// handleScripts lists the configured scripts with their run counters.
+func (a *App) handleScripts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	statuses := []script.Status{}
+	if a.scripts != nil {
+		statuses = a.scripts.Status()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(scriptsResponse{Scripts: statuses})
+}
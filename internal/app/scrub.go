@@ -0,0 +1,335 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/api"
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+)
+
+const (
+	// scrubCheckInterval is how often the scrub loop checks whether a
+	// scrub is due.
+	scrubCheckInterval = time.Minute
+	// scrubFirstDelay holds off the first scrub on a station that has never
+	// run one, so it does not compete with startup.
+	scrubFirstDelay = 10 * time.Minute
+	// scrubStateFile keeps the last report under data.root, so the
+	// schedule and the list of corrupt files survive restarts.
+	scrubStateFile = ".scrub.json"
+)
+
+// scrubReport summarizes one integrity scrub. Unverified counts captures
+// without a recorded checksum, which cannot be checked.
+type scrubReport struct {
+	StartedAt   string   `json:"started_at"`
+	FinishedAt  string   `json:"finished_at"`
+	Checked     int      `json:"checked"`
+	Unverified  int      `json:"unverified"`
+	Corrupt     []string `json:"corrupt"`
+	Interrupted string   `json:"interrupted,omitempty"` // why the run stopped early
+	Source      string   `json:"source"`                // "schedule" or "api"
+}
+
+// scrubber tracks the background integrity scrub.
+type scrubber struct {
+	mu      sync.Mutex
+	running bool
+	last    *scrubReport
+}
+
+func (s *scrubber) report() (*scrubReport, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last, s.running
+}
+
+// scrubLoop runs the integrity scrub every data.scrub_interval_hours until
+// ctx is cancelled. The interval is re-read on each check, so a reload can
+// turn the scrub on or off.
+func (a *App) scrubLoop(ctx context.Context) {
+	a.loadScrubState()
+
+	t := time.NewTicker(scrubCheckInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			if due, ok := a.nextScrub(); ok && !now.Before(due) && !a.capturing() {
+				a.runScrub(ctx, "schedule")
+			}
+		}
+	}
+}
+
+// nextScrub returns when the next scheduled scrub is due; ok is false when
+// the scrub is disabled. An interrupted scrub is due again straight away.
+func (a *App) nextScrub() (time.Time, bool) {
+	hours := a.getConfig().Data.ScrubIntervalHours
+	if hours <= 0 {
+		return time.Time{}, false
+	}
+	last, _ := a.scrub.report()
+	if last == nil {
+		return a.startedAt.Add(scrubFirstDelay), true
+	}
+	if last.Interrupted != "" {
+		return time.Time{}, true
+	}
+	started, err := time.Parse(time.RFC3339, last.StartedAt)
+	if err != nil {
+		return time.Time{}, true
+	}
+	return started.Add(time.Duration(hours) * time.Hour), true
+}
+
+// capturing reports whether a pass is being recorded, decoded or
+// compressed. Scrubbing then would compete with the capture for the SD
+// card, and could catch a compression half done.
+func (a *App) capturing() bool {
+	state := a.state.Load().(string)
+	return state == "RECORDING" || state == "DECODING" || a.compress.pending.Load() > 0
+}
+
+// runScrub re-hashes every capture that has a recorded checksum. A file
+// that no longer matches is flagged corrupt in its sidecar and announced
+// once with a capture_corrupt event; a flagged file that matches again is
+// unflagged. The run stops early if a capture starts. It returns false
+// without doing anything if a scrub is already running.
+func (a *App) runScrub(ctx context.Context, source string) bool {
+	a.scrub.mu.Lock()
+	if a.scrub.running {
+		a.scrub.mu.Unlock()
+		return false
+	}
+	a.scrub.running = true
+	a.scrub.mu.Unlock()
+
+	cfg := a.getConfig()
+	start := time.Now()
+	rep := &scrubReport{
+		StartedAt: start.UTC().Format(time.RFC3339),
+		Corrupt:   []string{},
+		Source:    source,
+	}
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   "info",
+		"message": "integrity scrub started",
+	})
+
+	checked := map[string]bool{}
+	for _, path := range capture.Recordings(cfg.Data.Root) {
+		if ctx.Err() != nil {
+			rep.Interrupted = "shutdown"
+			break
+		}
+		if a.capturing() {
+			rep.Interrupted = "capture started"
+			break
+		}
+
+		meta, err := capture.ReadMetadata(path)
+		if err != nil || meta.SHA256 == "" {
+			rep.Unverified++
+			continue
+		}
+		name := filepath.Base(path)
+		_, err = capture.VerifyChecksum(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue // deleted while we ran
+		}
+		rep.Checked++
+		checked[name] = true
+
+		if err == nil {
+			if meta.Corrupt {
+				meta.Corrupt = false
+				if werr := capture.WriteMetadata(path, meta, cfg.Data.FsyncOnFinalize); werr != nil {
+					a.log.Warn("could not unflag capture", "component", "scrub", "file", name, "err", werr)
+				}
+				a.emit("ephemerisd", map[string]any{
+					"type":    "log",
+					"level":   "info",
+					"message": fmt.Sprintf("capture %s matches its checksum again", name),
+				})
+			}
+			continue
+		}
+
+		// A read error is as much a sign of a failing card as a mismatch.
+		rep.Corrupt = append(rep.Corrupt, name)
+		if meta.Corrupt {
+			continue
+		}
+		meta.Corrupt = true
+		if werr := capture.WriteMetadata(path, meta, cfg.Data.FsyncOnFinalize); werr != nil {
+			a.log.Warn("could not flag capture", "component", "scrub", "file", name, "err", werr)
+		}
+		a.emit("ephemerisd", map[string]any{
+			"type":  "capture_corrupt",
+			"file":  name,
+			"error": err.Error(),
+		})
+		a.emit("ephemerisd", map[string]any{
+			"type":    "log",
+			"level":   "error",
+			"message": fmt.Sprintf("capture %s is corrupt: %v", name, err),
+		})
+	}
+	rep.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+
+	a.scrub.mu.Lock()
+	// A partial run keeps earlier findings for files it did not reach.
+	if rep.Interrupted != "" && a.scrub.last != nil {
+		for _, name := range a.scrub.last.Corrupt {
+			if !checked[name] {
+				rep.Corrupt = append(rep.Corrupt, name)
+			}
+		}
+	}
+	a.scrub.last = rep
+	a.scrub.running = false
+	a.scrub.mu.Unlock()
+	a.saveScrubState(cfg.Data.Root, rep)
+
+	level := "info"
+	msg := fmt.Sprintf("integrity scrub checked %d captures in %s: %d corrupt, %d without a checksum",
+		rep.Checked, time.Since(start).Truncate(time.Second), len(rep.Corrupt), rep.Unverified)
+	if len(rep.Corrupt) > 0 {
+		level = "warn"
+	}
+	if rep.Interrupted != "" {
+		msg += " (stopped early: " + rep.Interrupted + ")"
+	}
+	a.emit("ephemerisd", map[string]any{
+		"type":        "scrub",
+		"checked":     rep.Checked,
+		"unverified":  rep.Unverified,
+		"corrupt":     rep.Corrupt,
+		"interrupted": rep.Interrupted,
+		"duration_s":  int(time.Since(start).Seconds()),
+	})
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   level,
+		"message": msg,
+	})
+	return true
+}
+
+// loadScrubState restores the last scrub report from data.root.
+func (a *App) loadScrubState() {
+	b, err := os.ReadFile(filepath.Join(a.getConfig().Data.Root, scrubStateFile))
+	if err != nil {
+		return
+	}
+	var rep scrubReport
+	if err := json.Unmarshal(b, &rep); err != nil {
+		a.log.Warn("ignoring saved state", "component", "scrub", "file", scrubStateFile, "err", err)
+		return
+	}
+	a.scrub.mu.Lock()
+	if a.scrub.last == nil {
+		a.scrub.last = &rep
+	}
+	a.scrub.mu.Unlock()
+}
+
+func (a *App) saveScrubState(root string, rep *scrubReport) {
+	b, err := json.MarshalIndent(rep, "", "  ")
+	if err != nil {
+		return
+	}
+	path := filepath.Join(root, scrubStateFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		a.log.Warn("could not save state", "component", "scrub", "err", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		a.log.Warn("could not save state", "component", "scrub", "err", err)
+	}
+}
+
+// corruptCaptures returns the captures the last scrub found corrupt that
+// are still present, so deleting or replacing a bad file clears the
+// health check.
+func (a *App) corruptCaptures(root string) []string {
+	last, _ := a.scrub.report()
+	if last == nil {
+		return nil
+	}
+	out := []string{}
+	for _, name := range last.Corrupt {
+		path := filepath.Join(root, name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if meta, err := capture.ReadMetadata(path); err == nil && meta.Corrupt {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// scrubResponse is the body of GET /api/scrub.
+type scrubResponse struct {
+	IntervalHours int          `json:"interval_hours"`
+	Running       bool         `json:"running"`
+	Last          *scrubReport `json:"last"`
+	NextDue       string       `json:"next_due,omitempty"` // RFC 3339
+}
+
+// handleScrub reports the integrity scrub's schedule and last result on
+// GET, and starts a scrub in the background on POST.
+func (a *App) handleScrub(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if a.capturing() {
+			jsonError(w, "a capture is in progress; scrub postponed", http.StatusConflict)
+			return
+		}
+		if _, running := a.scrub.report(); running {
+			jsonError(w, "a scrub is already running", http.StatusConflict)
+			return
+		}
+		a.runMu.Lock()
+		ctx := a.runCtx
+		a.runMu.Unlock()
+		go a.runScrub(ctx, "api")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(api.OKResponse{OK: true, Message: "integrity scrub started"})
+		return
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	last, running := a.scrub.report()
+	resp := scrubResponse{
+		IntervalHours: a.getConfig().Data.ScrubIntervalHours,
+		Running:       running,
+		Last:          last,
+	}
+	if due, ok := a.nextScrub(); ok {
+		if due.Before(time.Now()) {
+			due = time.Now()
+		}
+		resp.NextDue = due.UTC().Format(time.RFC3339)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
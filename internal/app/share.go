@@ -0,0 +1,270 @@
+package app
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// shareKeyFile holds the key share tokens are signed with, under data.root.
+// Replacing it, as DELETE /api/share does, revokes every token signed with
+// the old one.
+const shareKeyFile = ".share_key"
+
+// shareClaims is the signed body of a share token.
+type shareClaims struct {
+	ID      string `json:"id"`
+	Expires int64  `json:"exp"` // Unix seconds
+	Label   string `json:"label,omitempty"`
+}
+
+// shareKeys caches the signing key read from, or written to, data.root.
+type shareKeys struct {
+	mu   sync.Mutex
+	root string
+	key  []byte
+}
+
+// get returns the signing key under root, creating it on first use.
+func (k *shareKeys) get(root string) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.key != nil && k.root == root {
+		return k.key, nil
+	}
+	path := filepath.Join(root, shareKeyFile)
+	if b, err := os.ReadFile(path); err == nil {
+		if key, err := hex.DecodeString(strings.TrimSpace(string(b))); err == nil && len(key) >= 32 {
+			k.root, k.key = root, key
+			return key, nil
+		}
+	}
+	return k.rotateLocked(root)
+}
+
+// rotate replaces the signing key under root with a new one.
+func (k *shareKeys) rotate(root string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	_, err := k.rotateLocked(root)
+	return err
+}
+
+func (k *shareKeys) rotateLocked(root string) ([]byte, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(root, shareKeyFile), []byte(hex.EncodeToString(key)+"\n"), 0o600); err != nil {
+		return nil, err
+	}
+	k.root, k.key = root, key
+	return key, nil
+}
+
+// signShare encodes claims as a token: the base64 JSON claims and their
+// HMAC-SHA256, joined by a dot.
+func signShare(key []byte, c shareClaims) (string, error) {
+	body, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(body) + "." + enc.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Reasons a share token is refused.
+var (
+	errShareMissing = errors.New("a share token is required")
+	errShareInvalid = errors.New("invalid share token")
+	errShareExpired = errors.New("share link expired")
+)
+
+// verifyShare checks token's signature against key and that it has not
+// expired at now, and returns its claims.
+func verifyShare(key []byte, token string, now time.Time) (shareClaims, error) {
+	var c shareClaims
+	if token == "" {
+		return c, errShareMissing
+	}
+	enc := base64.RawURLEncoding
+	b64body, b64sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return c, errShareInvalid
+	}
+	body, err := enc.DecodeString(b64body)
+	if err != nil {
+		return c, errShareInvalid
+	}
+	sig, err := enc.DecodeString(b64sig)
+	if err != nil {
+		return c, errShareInvalid
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return c, errShareInvalid
+	}
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&c); err != nil {
+		return c, errShareInvalid
+	}
+	if now.Unix() >= c.Expires {
+		return c, errShareExpired
+	}
+	return c, nil
+}
+
+// shareToken returns the share token a request carries, as ?share= or a
+// bearer token; browsers cannot set headers on a WebSocket, hence the
+// query.
+func shareToken(r *http.Request) string {
+	if t := r.URL.Query().Get("share"); t != "" {
+		return t
+	}
+	return bearerToken(r)
+}
+
+// requireShare guards the public listener when server.public_share_only is
+// set: every request but the health probes must carry a share token that
+// has not expired or been revoked.
+func (a *App) requireShare(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cfg := a.getConfig()
+		if !cfg.Server.PublicShareOnly || r.URL.Path == "/healthz" || r.URL.Path == "/readyz" {
+			h.ServeHTTP(w, r)
+			return
+		}
+		key, err := a.shareKeys.get(cfg.Data.Root)
+		if err != nil {
+			jsonError(w, "share tokens unavailable: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if _, err := verifyShare(key, shareToken(r), time.Now()); err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ephemerisd share"`)
+			jsonError(w, err.Error()+"; ask the station operator for a new link", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// shareRequest is the body of POST /api/share.
+type shareRequest struct {
+	Hours int    `json:"hours"` // 0 for 24, at most server.share_max_hours
+	Label string `json:"label"` // who the link is for, shown in events
+}
+
+// shareResponse is a new share token and when it expires. URL is a guess
+// at the public listener's status URL with the token in it, built from
+// the host the request was made to; empty while the listener is off.
+type shareResponse struct {
+	OK          bool   `json:"ok"`
+	ID          string `json:"id"`
+	Label       string `json:"label,omitempty"`
+	Token       string `json:"token"`
+	Expires     string `json:"expires"`
+	ExpiresUnix int64  `json:"expires_unix"`
+	URL         string `json:"url,omitempty"`
+}
+
+// defaultShareHours is how long a share token lasts when the request does
+// not say.
+const defaultShareHours = 24
+
+// handleShare mints a read-only guest token for the public listener, or
+// with DELETE revokes every token minted so far:
+//
+//	POST /api/share {"hours": 6, "label": "club night"}
+//	DELETE /api/share
+func (a *App) handleShare(w http.ResponseWriter, r *http.Request) {
+	cfg := a.getConfig()
+	switch r.Method {
+	case http.MethodPost:
+	case http.MethodDelete:
+		if err := a.shareKeys.rotate(cfg.Data.Root); err != nil {
+			jsonError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		a.emit("share", map[string]any{"type": "share_revoked"})
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"ok": true})
+		return
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req shareRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			jsonError(w, "invalid JSON body", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Hours == 0 {
+		req.Hours = min(defaultShareHours, cfg.Server.ShareMaxHours)
+	}
+	if req.Hours < 1 || req.Hours > cfg.Server.ShareMaxHours {
+		jsonError(w, "hours must be between 1 and server.share_max_hours", http.StatusBadRequest)
+		return
+	}
+	req.Label = strings.TrimSpace(req.Label)
+
+	key, err := a.shareKeys.get(cfg.Data.Root)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	id := make([]byte, 6)
+	_, _ = rand.Read(id)
+	expires := time.Now().Add(time.Duration(req.Hours) * time.Hour).Truncate(time.Second)
+	claims := shareClaims{ID: hex.EncodeToString(id), Expires: expires.Unix(), Label: req.Label}
+	token, err := signShare(key, claims)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := shareResponse{
+		OK:          true,
+		ID:          claims.ID,
+		Label:       claims.Label,
+		Token:       token,
+		Expires:     expires.UTC().Format(time.RFC3339),
+		ExpiresUnix: claims.Expires,
+	}
+	if cfg.Server.PublicReadonly {
+		host, port, _ := net.SplitHostPort(cfg.Server.PublicBind)
+		if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+			host = r.Host
+			if h, _, err := net.SplitHostPort(r.Host); err == nil {
+				host = h
+			}
+		}
+		resp.URL = "http://" + net.JoinHostPort(host, port) + cfg.Server.BasePath + "/api/status?share=" + token
+	}
+
+	a.emit("share", map[string]any{
+		"type":         "share_created",
+		"id":           claims.ID,
+		"label":        claims.Label,
+		"expires":      resp.Expires,
+		"expires_unix": resp.ExpiresUnix,
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
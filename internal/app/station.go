@@ -0,0 +1,165 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// stationResponse is the body of GET /api/station.
+type stationResponse struct {
+	Active   string              `json:"active"` // "" for the bare [station] location
+	Profiles []string            `json:"profiles"`
+	Location stationLocationJSON `json:"location"`
+}
+
+// stationLocationJSON is the station location in effect, with the active
+// profile applied.
+type stationLocationJSON struct {
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	Altitude     float64 `json:"altitude"`
+	MinElevation float64 `json:"min_elevation"`
+	UseGPSD      bool    `json:"use_gpsd"`
+}
+
+// stationRequest is the body of POST /api/station.
+type stationRequest struct {
+	Profile *string `json:"profile"` // "" for the bare [station] location
+	Force   bool    `json:"force"`   // switch even mid-capture
+}
+
+// stationSwitchResponse reports the active profile after a switch.
+type stationSwitchResponse struct {
+	OK      bool   `json:"ok"`
+	Active  string `json:"active"`
+	Changed bool   `json:"changed"`
+}
+
+// handleStation lists the station profiles on GET and switches the active
+// one on POST.
+func (a *App) handleStation(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		st := a.getConfig().Station
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(stationResponse{
+			Active:   st.Active,
+			Profiles: st.ProfileNames(),
+			Location: stationLocationJSON{
+				Latitude:     st.Latitude,
+				Longitude:    st.Longitude,
+				Altitude:     st.Altitude,
+				MinElevation: st.MinElevation,
+				UseGPSD:      st.UseGPSD,
+			},
+		})
+	case http.MethodPost:
+		a.handleStationSwitch(w, r)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleStationSwitch makes {"profile": "field"} the active station
+// profile; an empty profile goes back to the bare [station] location. The
+// choice is written to station.active in the config file so it survives a
+// restart, and the live scheduler is restarted to predict from the new
+// location. Switching mid-capture aborts it, so that requires
+// {"force": true}.
+func (a *App) handleStationSwitch(w http.ResponseWriter, r *http.Request) {
+	var req stationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "bad request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Profile == nil {
+		jsonError(w, `missing "profile" field`, http.StatusBadRequest)
+		return
+	}
+
+	a.cfgMu.RLock()
+	path := a.configPath
+	a.cfgMu.RUnlock()
+	if path == "" {
+		jsonError(w, "daemon is running on built-in defaults; there is no config file to hold station profiles", http.StatusConflict)
+		return
+	}
+
+	st := a.getConfig().Station
+	name := *req.Profile
+	if _, ok := st.Profiles[name]; name != "" && !ok {
+		jsonError(w, fmt.Sprintf("no station profile %q (have %v)", name, st.ProfileNames()), http.StatusNotFound)
+		return
+	}
+	if name == st.Active {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(stationSwitchResponse{OK: true, Active: name})
+		return
+	}
+	state := a.state.Load().(string)
+	if !req.Force && (state == "RECORDING" || state == "DECODING") {
+		jsonError(w, fmt.Sprintf("a capture is in progress (%s); use force to abort it", state), http.StatusConflict)
+		return
+	}
+
+	if err := a.setStation(path, st.Active, name, "api"); err != nil {
+		jsonError(w, "station switch failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(stationSwitchResponse{OK: true, Active: name, Changed: true})
+}
+
+// setStation persists name as station.active, reloads the config, and
+// restarts the live scheduler so predictions use the new location.
+func (a *App) setStation(path, from, to, source string) error {
+	update := config.FileUpdate{Section: "station", Key: "active", Value: to}
+	if err := config.WriteBack(path, []config.FileUpdate{update}, source, time.Now()); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	if _, err := a.reloadConfig(path, "station"); err != nil {
+		return fmt.Errorf("config written but reload failed: %w", err)
+	}
+
+	a.runMu.Lock()
+	if a.active.Load() != nil && !a.isDemo() {
+		if err := a.stopRunner(); err != nil {
+			a.runMu.Unlock()
+			return err
+		}
+		a.transition("IDLE")
+		a.startRunner(false)
+	}
+	a.runMu.Unlock()
+
+	st := a.getConfig().Station
+	message := fmt.Sprintf("station profile %s -> %s (%.4f, %.4f)", stationLabel(from), stationLabel(to), st.Latitude, st.Longitude)
+	a.log.Info(message, "component", "station", "source", source)
+	a.emit("ephemerisd", map[string]any{
+		"type":      "station_changed",
+		"from":      from,
+		"to":        to,
+		"latitude":  st.Latitude,
+		"longitude": st.Longitude,
+		"altitude":  st.Altitude,
+		"source":    source,
+	})
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   "info",
+		"message": message,
+	})
+	return nil
+}
+
+// stationLabel names a station profile for log messages.
+func stationLabel(name string) string {
+	if name == "" {
+		return "(base)"
+	}
+	return name
+}
@@ -0,0 +1,156 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/display"
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+// handleSummary serves the compact station summary for thin clients: the
+// state, the next pass, and the last decoded image.
+//
+//	GET /api/summary?tz=Europe/Berlin
+func (a *App) handleSummary(w http.ResponseWriter, r *http.Request) {
+	s, ok := a.summary(w, r, false)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s)
+}
+
+// handleSummaryPNG serves the summary drawn in black and white at the
+// panel's resolution, by default the 296×128 of a 2.9" e-paper display.
+//
+//	GET /api/summary.png?w=400&h=300
+func (a *App) handleSummaryPNG(w http.ResponseWriter, r *http.Request) {
+	width, height := 296, 128
+	for _, dim := range []struct {
+		name string
+		v    *int
+	}{{"w", &width}, {"h", &height}} {
+		raw := r.URL.Query().Get(dim.name)
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < display.MinSize || n > display.MaxSize {
+			jsonError(w, dim.name+" must be a number of pixels from "+strconv.Itoa(display.MinSize)+" to "+strconv.Itoa(display.MaxSize), http.StatusBadRequest)
+			return
+		}
+		*dim.v = n
+	}
+	s, ok := a.summary(w, r, true)
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, display.Render(s, width, height)); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// A display can send If-None-Match and skip the refresh, which is slow
+	// and visible on e-paper, while the picture is unchanged.
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("ETag", `"`+shortHash(buf.Bytes())+`"`)
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(buf.Bytes()))
+}
+
+// summary gathers the station summary, with the last image loaded as its
+// thumbnail if thumb is set. It writes a 400 and returns false for a bad
+// ?tz=.
+func (a *App) summary(w http.ResponseWriter, r *http.Request, thumb bool) (display.Summary, bool) {
+	cfg := a.getConfig()
+	tz, err := requestLocation(r, cfg)
+	if err != nil {
+		jsonError(w, err.Error(), http.StatusBadRequest)
+		return display.Summary{}, false
+	}
+	now := time.Now()
+	s := display.Summary{
+		State: a.state.Load().(string),
+		Clock: clockAt(now, tz),
+	}
+
+	passes, err := predict.NewPredictor(a.wsHub, cfg, a.log).ComputePasses()
+	if err != nil {
+		s.NextPassError = err.Error()
+	}
+	for _, p := range passes {
+		if !p.AOS.After(now) || !cfg.SatelliteEnabled(p.Satellite.Name) {
+			continue
+		}
+		s.NextPass = &display.NextPass{
+			Satellite:  p.Satellite.Name,
+			AOS:        p.AOS.UTC().Format(time.RFC3339),
+			AOSLocal:   p.AOS.In(tz).Format(time.RFC3339),
+			LOS:        p.LOS.UTC().Format(time.RFC3339),
+			LOSLocal:   p.LOS.In(tz).Format(time.RFC3339),
+			AOSUnix:    p.AOS.Unix(),
+			LOSUnix:    p.LOS.Unix(),
+			MaxElev:    p.MaxElev,
+			Direction:  p.Direction(),
+			CountdownS: int(p.AOS.Sub(now).Seconds()),
+		}
+		break
+	}
+
+	// The newest capture that decoded to an image, preferring channel A.
+	// None is shown if the history cannot be read.
+	var newest time.Time
+	recs, _ := a.history.Captures()
+	for _, rec := range recs {
+		if !rec.AOS.After(newest) {
+			continue
+		}
+		meta, err := capture.ReadMetadata(filepath.Join(cfg.Data.Root, rec.File))
+		if err != nil || len(meta.Images) == 0 {
+			continue
+		}
+		img := bestImage(meta.Images)
+		newest = rec.AOS
+		s.LastCapture = &display.LastCapture{
+			Satellite: rec.Satellite,
+			AOS:       rec.AOS.UTC().Format(time.RFC3339),
+			AOSLocal:  rec.AOS.In(tz).Format(time.RFC3339),
+			File:      rec.File,
+			Image:     img,
+		}
+	}
+	if thumb && s.LastCapture != nil {
+		if f, err := os.Open(filepath.Join(cfg.Data.Root, s.LastCapture.Image)); err == nil {
+			s.Thumbnail, _, _ = image.Decode(f)
+			f.Close()
+		}
+	}
+
+	if d := diskUsage(cfg.Data.Root); d != nil && d.TotalBytes > 0 {
+		free := int(d.AvailableBytes * 100 / d.TotalBytes)
+		s.DiskFreePercent = &free
+	}
+	return s, true
+}
+
+// bestImage picks the image of a capture to show on its own, preferring
+// channel A.
+func bestImage(images []string) string {
+	img := images[0]
+	for _, name := range images {
+		if strings.HasSuffix(name, "-A.png") {
+			img = name
+		}
+	}
+	return img
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/api"
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// requestLocation returns the zone local times in a response are rendered
+// in: ?tz=, an IANA name such as America/Denver, or station.timezone.
+func requestLocation(r *http.Request, cfg config.Config) (*time.Location, error) {
+	tz := r.URL.Query().Get("tz")
+	if tz == "" {
+		return cfg.Station.Location(), nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("tz: unknown time zone %q", tz)
+	}
+	return loc, nil
+}
+
+// clockAt renders t in UTC and in loc.
+func clockAt(t time.Time, loc *time.Location) *api.Clock {
+	local := t.In(loc)
+	abbrev, offset := local.Zone()
+	return &api.Clock{
+		UTC:      t.UTC().Format(time.RFC3339),
+		Local:    local.Format(time.RFC3339),
+		Timezone: loc.String(),
+		Abbrev:   abbrev,
+		OffsetS:  offset,
+	}
+}
+
+// localRFC3339 re-renders an RFC 3339 time in loc, or returns "" if s is
+// not one.
+func localRFC3339(s string, loc *time.Location) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return ""
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
+
+// unixSeconds returns an RFC 3339 time as Unix seconds, or 0 if s is not
+// one.
+func unixSeconds(s string) int64 {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0
+	}
+	return t.Unix()
+}
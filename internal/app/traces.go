@@ -0,0 +1,101 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/tracing"
+)
+
+// traceSpanJSON is one span within a trace, timed relative to the root.
+type traceSpanJSON struct {
+	Name       string         `json:"name"`
+	SpanID     string         `json:"span_id"`
+	ParentID   string         `json:"parent_id,omitempty"`
+	OffsetMS   int64          `json:"offset_ms"`
+	DurationMS int64          `json:"duration_ms"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// traceJSON groups the spans of one trace under its root span.
+type traceJSON struct {
+	TraceID    string          `json:"trace_id"`
+	Name       string          `json:"name"`
+	Start      string          `json:"start"`
+	DurationMS int64           `json:"duration_ms"`
+	Error      string          `json:"error,omitempty"`
+	Spans      []traceSpanJSON `json:"spans"`
+}
+
+// groupTraces assembles finished spans into traces, oldest first. Traces
+// whose root span has not finished yet are omitted.
+func groupTraces(spans []tracing.Span) []traceJSON {
+	byTrace := map[string][]tracing.Span{}
+	var order []string
+	for _, s := range spans {
+		if _, ok := byTrace[s.TraceID]; !ok {
+			order = append(order, s.TraceID)
+		}
+		byTrace[s.TraceID] = append(byTrace[s.TraceID], s)
+	}
+
+	traces := make([]traceJSON, 0, len(order))
+	for _, id := range order {
+		group := byTrace[id]
+		var root *tracing.Span
+		for i := range group {
+			if group[i].ParentID == "" {
+				root = &group[i]
+			}
+		}
+		if root == nil {
+			continue
+		}
+
+		t := traceJSON{
+			TraceID:    id,
+			Name:       root.Name,
+			Start:      root.StartTime.UTC().Format(time.RFC3339),
+			DurationMS: root.EndTime.Sub(root.StartTime).Milliseconds(),
+			Error:      root.Error,
+		}
+		for _, s := range group {
+			t.Spans = append(t.Spans, traceSpanJSON{
+				Name:       s.Name,
+				SpanID:     s.SpanID,
+				ParentID:   s.ParentID,
+				OffsetMS:   s.StartTime.Sub(root.StartTime).Milliseconds(),
+				DurationMS: s.EndTime.Sub(s.StartTime).Milliseconds(),
+				Attributes: s.Attrs,
+				Error:      s.Error,
+			})
+		}
+		traces = append(traces, t)
+	}
+	return traces
+}
+
+// tracesResponse is the body of GET /api/traces.
+type tracesResponse struct {
+	Enabled bool        `json:"enabled"`
+	Traces  []traceJSON `json:"traces"`
+}
+
+func (a *App) handleTraces(w http.ResponseWriter, r *http.Request) {
+	traces := groupTraces(a.tracer.Recent())
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if n, err := strconv.Atoi(limitStr); err == nil && n > 0 && n < len(traces) {
+			traces = traces[len(traces)-n:]
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(tracesResponse{
+		Enabled: a.tracer != nil,
+		Traces:  traces,
+	})
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+	"github.com/large-farva/ephemeris-engine/internal/scheduler"
+)
+
+// Bounds of the ?step= of GET /api/passes/{id}/track, in seconds.
+const (
+	defaultTrackStep = 10
+	maxTrackStep     = 300
+)
+
+// passTrackResponse is the body of GET /api/passes/{id}/track.
+type passTrackResponse struct {
+	ID        string  `json:"id"`
+	Satellite string  `json:"satellite"`
+	NoradID   int     `json:"norad_id"`
+	FreqHz    int     `json:"freq_hz"`
+	AOS       string  `json:"aos"`
+	LOS       string  `json:"los"`
+	AOSUnix   int64   `json:"aos_unix"`
+	LOSUnix   int64   `json:"los_unix"`
+	MaxElev   float64 `json:"max_elev"`
+	StepS     int     `json:"step_s"`
+	// Horizon is the station's horizon mask, for plots to draw under the
+	// track; it is empty without one.
+	Horizon []config.HorizonPoint `json:"horizon"`
+	Points  []trackPointJSON      `json:"points"`
+}
+
+// trackPointJSON is one step of the track: where the satellite is in the
+// station's sky, its range and Doppler shift, and the point below it.
+type trackPointJSON struct {
+	Time         string  `json:"time"`
+	Unix         int64   `json:"unix"`
+	Azimuth      float64 `json:"azimuth"`
+	Elevation    float64 `json:"elevation"`
+	RangeKm      float64 `json:"range_km"`
+	RangeRateKmS float64 `json:"range_rate_km_s"`
+	DopplerHz    float64 `json:"doppler_hz"`
+	Lat          float64 `json:"lat"`
+	Lon          float64 `json:"lon"`
+	AltKm        float64 `json:"alt_km"`
+}
+
+// handlePassTrack returns the sky track and ground track of one upcoming
+// pass, by the ID GET /api/passes lists it under, sampled every ?step=
+// seconds:
+//
+//	GET /api/passes/33591-1792267200/track?step=5
+func (a *App) handlePassTrack(w http.ResponseWriter, r *http.Request) {
+	id, rest, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/passes/"), "/")
+	if !ok || rest != "track" {
+		jsonError(w, "not found; use GET /api/passes/{pass id}/track", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	step := defaultTrackStep
+	if s := r.URL.Query().Get("step"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxTrackStep {
+			jsonError(w, "step must be a whole number of seconds between 1 and "+strconv.Itoa(maxTrackStep), http.StatusBadRequest)
+			return
+		}
+		step = n
+	}
+
+	cfg := a.getConfig()
+	predictor := predict.NewPredictor(a.wsHub, cfg, a.log)
+	passes, err := predictor.ComputePasses()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var pass *predict.Pass
+	for i := range passes {
+		if scheduler.PassID(passes[i]) == id {
+			pass = &passes[i]
+			break
+		}
+	}
+	if pass == nil {
+		jsonError(w, "no upcoming pass "+id+"; see GET /api/passes", http.StatusNotFound)
+		return
+	}
+
+	points, err := predictor.PassTrack(*pass, time.Duration(step)*time.Second)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	horizon, _ := cfg.Station.Horizon()
+	if horizon == nil {
+		horizon = []config.HorizonPoint{}
+	}
+	resp := passTrackResponse{
+		ID:        id,
+		Satellite: pass.Satellite.Name,
+		NoradID:   pass.Satellite.NoradID,
+		FreqHz:    pass.Satellite.Freq,
+		AOS:       pass.AOS.Format(time.RFC3339),
+		LOS:       pass.LOS.Format(time.RFC3339),
+		AOSUnix:   pass.AOS.Unix(),
+		LOSUnix:   pass.LOS.Unix(),
+		MaxElev:   pass.MaxElev,
+		StepS:     step,
+		Horizon:   horizon,
+		Points:    make([]trackPointJSON, len(points)),
+	}
+	round := func(v float64, places int) float64 {
+		scale := math.Pow(10, float64(places))
+		return math.Round(v*scale) / scale
+	}
+	for i, p := range points {
+		resp.Points[i] = trackPointJSON{
+			Time:         p.Time.UTC().Format(time.RFC3339),
+			Unix:         p.Time.Unix(),
+			Azimuth:      round(p.Azimuth, 2),
+			Elevation:    round(p.Elevation, 2),
+			RangeKm:      round(p.RangeKm, 1),
+			RangeRateKmS: round(p.RangeRate, 3),
+			DopplerHz:    math.Round(p.DopplerHz),
+			Lat:          round(p.Lat, 4),
+			Lon:          round(p.Lon, 4),
+			AltKm:        round(p.AltKm, 1),
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,282 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/api"
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+)
+
+const (
+	// trashDir holds deleted captures under data.root, one directory per
+	// capture named for it, until data.trash_days have passed.
+	trashDir = ".trash"
+	// trashEntryFile in each of those directories records what was
+	// deleted and when.
+	trashEntryFile = "deleted.json"
+	// trashPurgeInterval is how often expired captures are purged.
+	trashPurgeInterval = time.Hour
+)
+
+var (
+	// errNotInTrash is returned when restoring a capture the trash does
+	// not hold.
+	errNotInTrash = errors.New("not in the trash")
+	// errCaptureExists is returned when restoring a capture whose name is
+	// taken again.
+	errCaptureExists = errors.New("a capture of that name exists")
+)
+
+// trashMu serializes deleting captures, moving them out of the trash, and
+// a compressed capture's FLAC replacing its WAV, so neither a delete nor a
+// restore sees a capture half renamed.
+var trashMu sync.Mutex
+
+// trashEntry is one deleted capture in the trash.
+type trashEntry struct {
+	Name      string `json:"name"`
+	DeletedAt string `json:"deleted_at"`
+	// Files are the capture, its sidecar and its images, relative to
+	// data.root, which is also where they are kept under the entry's
+	// directory.
+	Files   []string `json:"files"`
+	Bytes   int64    `json:"bytes"`
+	PurgeAt string   `json:"purge_at,omitempty"` // set in listings
+}
+
+// trashCapture moves the capture name, its sidecar and its images into the
+// trash. A capture of that name already there is replaced. The caller
+// holds trashMu.
+func trashCapture(root, name string) (trashEntry, error) {
+	path := filepath.Join(root, name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return trashEntry{}, err
+	}
+	files := []string{name}
+	if meta, err := capture.ReadMetadata(path); err == nil {
+		files = append(files, filepath.Base(capture.MetadataPath(path)))
+		for _, img := range meta.Images {
+			// Image paths are relative to data.root; cleaning them
+			// against "/" keeps them inside it.
+			files = append(files, filepath.Clean("/" + img)[1:])
+		}
+	}
+
+	dir := filepath.Join(root, trashDir, name)
+	if err := os.RemoveAll(dir); err != nil {
+		return trashEntry{}, err
+	}
+	entry := trashEntry{Name: name, DeletedAt: time.Now().UTC().Format(time.RFC3339), Bytes: info.Size()}
+	for i, f := range files {
+		dst := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return trashEntry{}, err
+		}
+		if err := os.Rename(filepath.Join(root, f), dst); err != nil {
+			if i == 0 || !errors.Is(err, fs.ErrNotExist) {
+				return trashEntry{}, err
+			}
+			continue
+		}
+		entry.Files = append(entry.Files, f)
+	}
+	b, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return trashEntry{}, err
+	}
+	return entry, os.WriteFile(filepath.Join(dir, trashEntryFile), append(b, '\n'), 0o644)
+}
+
+// removeCapture deletes the capture at path, its sidecar and its images
+// outright, as when the trash is turned off. The caller holds trashMu.
+func removeCapture(root, path string) error {
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	if meta, err := capture.ReadMetadata(path); err == nil {
+		for _, img := range meta.Images {
+			// Image paths are relative to data.root; cleaning them
+			// against "/" keeps them inside it.
+			_ = os.Remove(filepath.Join(root, filepath.Clean("/"+img)))
+		}
+	}
+	_ = os.Remove(capture.MetadataPath(path))
+	return nil
+}
+
+// restoreCapture moves the capture name and what was deleted with it back
+// out of the trash. It refuses to overwrite a capture of the same name.
+func restoreCapture(root, name string) (trashEntry, error) {
+	trashMu.Lock()
+	defer trashMu.Unlock()
+
+	dir := filepath.Join(root, trashDir, name)
+	entry, err := readTrashEntry(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return trashEntry{}, errNotInTrash
+		}
+		return trashEntry{}, err
+	}
+	if _, err := os.Stat(filepath.Join(root, name)); err == nil {
+		return trashEntry{}, errCaptureExists
+	}
+	for _, f := range entry.Files {
+		dst := filepath.Join(root, f)
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			return trashEntry{}, err
+		}
+		if err := os.Rename(filepath.Join(dir, f), dst); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return trashEntry{}, err
+		}
+	}
+	return entry, os.RemoveAll(dir)
+}
+
+// trashName returns the name the trash holds a capture under. Like
+// capturePath for live captures, it finds a capture asked for by its WAV
+// name under the FLAC name it was deleted with once compressed.
+func trashName(root, name string) string {
+	return filepath.Base(capture.ResolveRecording(filepath.Join(root, trashDir, name)))
+}
+
+func readTrashEntry(dir string) (trashEntry, error) {
+	var entry trashEntry
+	b, err := os.ReadFile(filepath.Join(dir, trashEntryFile))
+	if err != nil {
+		return entry, err
+	}
+	err = json.Unmarshal(b, &entry)
+	return entry, err
+}
+
+// listTrash returns the captures in the trash, most recently deleted
+// first, with when each is due to be purged after days.
+func listTrash(root string, days int) []trashEntry {
+	dirs, _ := filepath.Glob(filepath.Join(root, trashDir, "*"))
+	out := []trashEntry{}
+	for _, dir := range dirs {
+		entry, err := readTrashEntry(dir)
+		if err != nil {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339, entry.DeletedAt); err == nil {
+			entry.PurgeAt = t.AddDate(0, 0, days).Format(time.RFC3339)
+		}
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].DeletedAt > out[j].DeletedAt })
+	return out
+}
+
+// purgeTrash removes the captures deleted more than data.trash_days ago,
+// or all of them once the trash is turned off.
+func (a *App) purgeTrash(now time.Time) {
+	cfg := a.getConfig()
+	trashMu.Lock()
+	defer trashMu.Unlock()
+	for _, entry := range listTrash(cfg.Data.Root, cfg.Data.TrashDays) {
+		purgeAt, err := time.Parse(time.RFC3339, entry.PurgeAt)
+		if err != nil || purgeAt.After(now) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(cfg.Data.Root, trashDir, entry.Name)); err != nil {
+			a.log.Warn("could not purge the trash", "file", entry.Name, "err", err)
+			continue
+		}
+		a.log.Info("purged deleted capture", "file", entry.Name, "deleted_at", entry.DeletedAt)
+	}
+}
+
+// trashLoop purges expired captures from the trash every hour.
+func (a *App) trashLoop(ctx context.Context) {
+	a.purgeTrash(time.Now())
+	t := time.NewTicker(trashPurgeInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			a.purgeTrash(now)
+		}
+	}
+}
+
+// trashResponse is the body of GET /api/captures/trash.
+type trashResponse struct {
+	TrashDays int          `json:"trash_days"`
+	Captures  []trashEntry `json:"captures"`
+}
+
+// handleCaptureTrash lists the deleted captures that can still be
+// restored.
+func (a *App) handleCaptureTrash(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cfg := a.getConfig()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(trashResponse{
+		TrashDays: cfg.Data.TrashDays,
+		Captures:  listTrash(cfg.Data.Root, cfg.Data.TrashDays),
+	})
+}
+
+// captureRestoreRequest is the body of POST /api/captures/restore.
+type captureRestoreRequest struct {
+	Name string `json:"name"`
+}
+
+// handleCaptureRestore brings a deleted capture back from the trash, with
+// its sidecar and images, and unmarks it deleted in the history.
+func (a *App) handleCaptureRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req captureRestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	cfg := a.getConfig()
+	if _, ok := capturePath(w, cfg.Data.Root, req.Name); !ok {
+		return
+	}
+	name := trashName(cfg.Data.Root, req.Name)
+	_, err := restoreCapture(cfg.Data.Root, name)
+	switch {
+	case errors.Is(err, errNotInTrash):
+		jsonError(w, req.Name+" is not in the trash", http.StatusNotFound)
+		return
+	case errors.Is(err, errCaptureExists):
+		jsonError(w, name+" exists; delete or rename it before restoring", http.StatusConflict)
+		return
+	case err != nil:
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if _, err := a.history.MarkRestored(name); err != nil {
+		a.log.Warn("could not mark capture restored", "component", "history", "file", name, "err", err)
+	}
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   "info",
+		"message": fmt.Sprintf("restored %s from the trash", name),
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(api.OKResponse{OK: true, Message: "restored " + name})
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// TestRestoreByWAVName checks that a capture compressed to FLAC, deleted
+// and then restored by the WAV name it was recorded under comes back with
+// its sidecar.
+func TestRestoreByWAVName(t *testing.T) {
+	root := t.TempDir()
+	cfg := config.Default()
+	cfg.Data.Root = root
+	cfg.Data.TrashDays = 7
+	a := New(Options{Logger: slog.New(slog.NewTextHandler(io.Discard, nil)), Cfg: cfg})
+	defer a.history.Close()
+
+	const wav, flac, sidecar = "NOAA-19_20261016T112134Z.wav", "NOAA-19_20261016T112134Z.flac", "NOAA-19_20261016T112134Z.json"
+	for name, body := range map[string]string{flac: "fLaC", sidecar: `{"satellite":"NOAA-19"}`} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	a.handleCaptures(rec, httptest.NewRequest(http.MethodDelete, "/api/captures?name="+wav, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete: status %d: %s", rec.Code, rec.Body)
+	}
+	if _, err := os.Stat(filepath.Join(root, flac)); !os.IsNotExist(err) {
+		t.Fatalf("%s still in data.root after delete", flac)
+	}
+
+	rec = httptest.NewRecorder()
+	a.handleCaptureRestore(rec, httptest.NewRequest(http.MethodPost, "/api/captures/restore", strings.NewReader(`{"name":"`+wav+`"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("restore: status %d: %s", rec.Code, rec.Body)
+	}
+	for _, name := range []string{flac, sidecar} {
+		if _, err := os.Stat(filepath.Join(root, name)); err != nil {
+			t.Errorf("%s not restored: %v", name, err)
+		}
+	}
+	if entries := listTrash(root, cfg.Data.TrashDays); len(entries) != 0 {
+		t.Errorf("trash still holds %d captures", len(entries))
+	}
+}
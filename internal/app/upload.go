@@ -0,0 +1,121 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/upload"
+)
+
+// newUploader returns the uploader for [upload], which reads the config
+// afresh for every capture so a reload takes effect for the next one.
+func (a *App) newUploader() *upload.Uploader {
+	return upload.New(func() config.UploadConfig { return a.getConfig().Upload }, a.log, a.onUploadDone)
+}
+
+// queueUpload queues capture name in root for upload with its sidecar and
+// images.
+func (a *App) queueUpload(root, name string) error {
+	path := filepath.Join(root, name)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	files, _, _ := captureFiles(root, path, info)
+	a.uploader.Enqueue(root, name, files)
+	return nil
+}
+
+// onUploadDone records where an uploaded capture went and reports how the
+// upload ended.
+func (a *App) onUploadDone(name string, st upload.Status) {
+	ev := map[string]any{
+		"type":     "upload",
+		"file":     name,
+		"state":    st.State,
+		"attempts": st.Attempts,
+	}
+	if st.State == upload.StateUploaded {
+		if _, err := a.history.SetRemoteURL(name, st.URL); err != nil {
+			a.log.Warn("could not record upload", "component", "history", "file", name, "err", err)
+		}
+		var bytes int64
+		for _, o := range st.Objects {
+			bytes += o.Bytes
+		}
+		ev["url"], ev["objects"], ev["bytes"] = st.URL, len(st.Objects), bytes
+	} else {
+		ev["error"] = st.Error
+		a.emit("ephemerisd", map[string]any{
+			"type":    "log",
+			"level":   "error",
+			"message": "upload of " + name + " failed: " + st.Error,
+		})
+	}
+	a.emit("ephemerisd", ev)
+}
+
+// uploadStatus returns the upload status of capture name: this run's if it
+// was queued since the daemon started, or uploaded if the history has its
+// remote URL.
+func (a *App) uploadStatus(name, remoteURL string) *upload.Status {
+	if st, ok := a.uploader.Status(name); ok {
+		return &st
+	}
+	if remoteURL != "" {
+		return &upload.Status{State: upload.StateUploaded, URL: remoteURL}
+	}
+	return nil
+}
+
+// captureUploadRequest is the body of POST /api/captures/upload.
+type captureUploadRequest struct {
+	Name string `json:"name"`
+}
+
+// captureUploadResponse is the capture's upload status once queued.
+type captureUploadResponse struct {
+	OK     bool          `json:"ok"`
+	Name   string        `json:"name"`
+	Upload upload.Status `json:"upload"`
+}
+
+// handleCaptureUpload queues a capture for upload again, after a failure
+// or a change of bucket.
+//
+//	POST /api/captures/upload {"name": "NOAA-19_20240101T120000Z.wav"}
+func (a *App) handleCaptureUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req captureUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		jsonError(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	cfg := a.getConfig()
+	if !cfg.Upload.Enabled {
+		jsonError(w, "upload is disabled; set upload.enabled", http.StatusConflict)
+		return
+	}
+	path, ok := capturePath(w, cfg.Data.Root, req.Name)
+	if !ok {
+		return
+	}
+	if _, err := os.Stat(path); err != nil {
+		jsonError(w, "file not found", http.StatusNotFound)
+		return
+	}
+	name := filepath.Base(path)
+	if err := a.queueUpload(cfg.Data.Root, name); err != nil {
+		jsonError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	st, _ := a.uploader.Status(name)
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(captureUploadResponse{OK: true, Name: name, Upload: st})
+}
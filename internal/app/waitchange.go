@@ -0,0 +1,145 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/scheduler"
+)
+
+const (
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitTimeout     = 5 * time.Minute
+)
+
+// changeNotifier wakes long-poll waiters. Each change closes the current
+// channel and installs a fresh one, so any number of waiters can select on
+// it without registering.
+type changeNotifier struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+// wait returns a channel that is closed on the next change.
+func (n *changeNotifier) wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ch == nil {
+		n.ch = make(chan struct{})
+	}
+	return n.ch
+}
+
+// notify wakes every current waiter.
+func (n *changeNotifier) notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ch != nil {
+		close(n.ch)
+		n.ch = nil
+	}
+}
+
+// setCurrentPass records the pass being tracked (nil for none) and wakes
+// long-poll waiters.
+func (a *App) setCurrentPass(info *scheduler.PassInfo) {
+	a.currentPass.Store(info)
+	a.changes.notify()
+}
+
+// currentPassInfo returns the pass being tracked, or nil.
+func (a *App) currentPassInfo() *scheduler.PassInfo {
+	info, _ := a.currentPass.Load().(*scheduler.PassInfo)
+	return info
+}
+
+// passToken identifies the tracked pass by satellite and AOS, so stage
+// updates within one pass do not count as a change.
+func passToken(info *scheduler.PassInfo) string {
+	if info == nil {
+		return ""
+	}
+	return info.Satellite + "@" + info.AOS
+}
+
+// waitChangeResponse is the body of GET /api/wait-for-change.
+type waitChangeResponse struct {
+	Changed     bool                `json:"changed"` // false on timeout
+	State       string              `json:"state"`
+	CurrentPass *scheduler.PassInfo `json:"current_pass"`
+	PassToken   string              `json:"pass_token"`
+}
+
+// handleWaitForChange blocks until the daemon state differs from ?state= or
+// the tracked pass differs from ?pass= (the pass_token from an earlier
+// response), or until ?timeout= elapses. Omitted parameters default to the
+// current values, so a bare request waits for the next change. This gives
+// shell scripts a curl-friendly alternative to the WebSocket stream.
+func (a *App) handleWaitForChange(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	timeout := defaultWaitTimeout
+	if v := q.Get("timeout"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			secs, serr := strconv.Atoi(v)
+			if serr != nil {
+				jsonError(w, "timeout must be a duration like 30s or a number of seconds", http.StatusBadRequest)
+				return
+			}
+			d = time.Duration(secs) * time.Second
+		}
+		if d <= 0 || d > maxWaitTimeout {
+			jsonError(w, "timeout must be between 0 and "+maxWaitTimeout.String(), http.StatusBadRequest)
+			return
+		}
+		timeout = d
+	}
+
+	current := func() (string, *scheduler.PassInfo) {
+		info, _ := a.currentPass.Load().(*scheduler.PassInfo)
+		return a.state.Load().(string), info
+	}
+
+	state, pass := current()
+	wantState, wantPass := state, passToken(pass)
+	if q.Has("state") {
+		wantState = q.Get("state")
+	}
+	if q.Has("pass") {
+		wantPass = q.Get("pass")
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	changed := false
+	for {
+		// Take the channel before checking, so a change in between is not
+		// missed.
+		wake := a.changes.wait()
+		state, pass = current()
+		if state != wantState || passToken(pass) != wantPass {
+			changed = true
+			break
+		}
+		select {
+		case <-wake:
+			continue
+		case <-timer.C:
+		case <-r.Context().Done():
+			return
+		}
+		break
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(waitChangeResponse{
+		Changed:     changed,
+		State:       state,
+		CurrentPass: pass,
+		PassToken:   passToken(pass),
+	})
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/scheduler"
+)
+
+// Bounds of the ?lead= of GET /api/schedule/wake, in seconds: how long
+// before AOS the station is woken, to boot or resume, sync its clock and
+// open the SDR.
+const (
+	defaultWakeLead = 120
+	maxWakeLead     = 3600
+)
+
+// wakeWindow is one span the station must be awake for: from Wake, lead
+// seconds before the first pass's AOS, until the last pass's LOS. Passes
+// whose wake-up falls before the previous one's LOS share a window.
+// Decoding follows Until, so a station should not sleep until it is idle.
+type wakeWindow struct {
+	Wake       string   `json:"wake"`
+	WakeUnix   int64    `json:"wake_unix"`
+	Until      string   `json:"until"`
+	UntilUnix  int64    `json:"until_unix"`
+	Passes     []string `json:"passes"`
+	Satellites []string `json:"satellites"`
+}
+
+// wakeScheduleResponse is the body of GET /api/schedule/wake.
+type wakeScheduleResponse struct {
+	ComputedAt string       `json:"computed_at,omitempty"`
+	LeadS      int          `json:"lead_s"`
+	Wakes      []wakeWindow `json:"wakes"`
+}
+
+// wakeWindows groups the scheduled passes still to come into the windows
+// the station must be awake for, waking lead before each AOS.
+func wakeWindows(passes []scheduler.ScheduledPass, lead time.Duration, now time.Time) []wakeWindow {
+	out := []wakeWindow{}
+	var untilAt time.Time
+	for _, p := range passes {
+		if p.Status != scheduler.PassScheduled {
+			continue
+		}
+		wake := time.Unix(p.AOSUnix, 0).Add(-lead).UTC()
+		los := time.Unix(p.LOSUnix, 0).UTC()
+		if !wake.After(now) {
+			continue
+		}
+		if n := len(out); n > 0 && !wake.After(untilAt) {
+			w := &out[n-1]
+			if los.After(untilAt) {
+				untilAt = los
+				w.Until, w.UntilUnix = los.Format(time.RFC3339), los.Unix()
+			}
+			w.Passes = append(w.Passes, p.ID)
+			w.Satellites = append(w.Satellites, p.Satellite)
+			continue
+		}
+		untilAt = los
+		out = append(out, wakeWindow{
+			Wake:       wake.Format(time.RFC3339),
+			WakeUnix:   wake.Unix(),
+			Until:      los.Format(time.RFC3339),
+			UntilUnix:  los.Unix(),
+			Passes:     []string{p.ID},
+			Satellites: []string{p.Satellite},
+		})
+	}
+	return out
+}
+
+// handleWakeSchedule exports the upcoming schedule as wake-up times, for
+// stations that sleep between passes to save a battery or solar budget.
+// ?format= picks JSON (the default), "rtcwake", one line per wake-up
+// starting with its Unix time, or "systemd", a timer unit that wakes the
+// system:
+//
+//	GET /api/schedule/wake?format=rtcwake&lead=180
+func (a *App) handleWakeSchedule(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	lead := defaultWakeLead
+	if s := q.Get("lead"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > maxWakeLead {
+			jsonError(w, "lead must be a whole number of seconds between 0 and "+strconv.Itoa(maxWakeLead), http.StatusBadRequest)
+			return
+		}
+		lead = n
+	}
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "rtcwake" && format != "systemd" {
+		jsonError(w, "format must be json, rtcwake or systemd", http.StatusBadRequest)
+		return
+	}
+	s := a.sched()
+	if s == nil {
+		jsonError(w, "not available in demo mode", http.StatusConflict)
+		return
+	}
+
+	at, passes := s.Schedule()
+	now := time.Now()
+	resp := wakeScheduleResponse{
+		LeadS: lead,
+		Wakes: wakeWindows(passes, time.Duration(lead)*time.Second, now),
+	}
+	if !at.IsZero() {
+		resp.ComputedAt = at.Format(time.RFC3339)
+	}
+
+	switch format {
+	case "rtcwake":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintf(w, "# ephemerisd wake-ups, %ds before AOS, as of %s\n", lead, now.UTC().Format(time.RFC3339))
+		fmt.Fprintf(w, "# unix time, wake-up (UTC), awake until, satellites\n")
+		for _, wk := range resp.Wakes {
+			fmt.Fprintf(w, "%d %s %s %s\n", wk.WakeUnix, wk.Wake, wk.Until, strings.Join(wk.Satellites, ","))
+		}
+	case "systemd":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintf(w, "# ephemeris-wake.timer, generated by ephemerisd at %s.\n", now.UTC().Format(time.RFC3339))
+		fmt.Fprintf(w, "# Wakes the system %ds before each pass and starts ephemeris-wake.service.\n", lead)
+		fmt.Fprintf(w, "# Regenerate it after the schedule changes.\n")
+		fmt.Fprintf(w, "[Unit]\nDescription=Wake the station for upcoming satellite passes\n\n[Timer]\nWakeSystem=true\nAccuracySec=1s\n")
+		for _, wk := range resp.Wakes {
+			t := time.Unix(wk.WakeUnix, 0).UTC()
+			fmt.Fprintf(w, "# %s\nOnCalendar=%s UTC\n", strings.Join(wk.Satellites, ", "), t.Format("2006-01-02 15:04:05"))
+		}
+		fmt.Fprintf(w, "\n[Install]\nWantedBy=timers.target\n")
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// handleResumed is the resume hook of a station that sleeps between
+// passes: run after the system wakes, it has the scheduler recompute the
+// schedule at once instead of when its timers, which stop while the system
+// is suspended, catch up.
+func (a *App) handleResumed(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s := a.sched()
+	if s == nil {
+		jsonError(w, "not available in demo mode", http.StatusConflict)
+		return
+	}
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   "info",
+		"message": "system resumed; recomputing the schedule",
+	})
+	s.Reschedule()
+	writeCommandResult(w, scheduler.CommandResult{OK: true, Message: "recomputing schedule"})
+}
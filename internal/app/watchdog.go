@@ -0,0 +1,120 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// watchdogInterval is how often the scheduler's liveness is checked.
+const watchdogInterval = 15 * time.Second
+
+// watchdog tracks whether the scheduler loop has missed its heartbeat
+// deadline. Only transitions are announced, so a hung loop produces one
+// event rather than one per check.
+type watchdog struct {
+	hung atomic.Bool
+}
+
+// schedulerLiveness reports whether the scheduler loop looks hung, along
+// with its last heartbeat. In demo mode there is no scheduler and the
+// result is always healthy.
+func (a *App) schedulerLiveness(now time.Time) (hung bool, last time.Time, overdue time.Duration) {
+	s := a.sched()
+	if s == nil {
+		return false, time.Time{}, 0
+	}
+	last, deadline := s.Liveness()
+	if now.After(deadline) {
+		return true, last, now.Sub(deadline)
+	}
+	return false, last, 0
+}
+
+// watchdogLoop periodically checks scheduler liveness until ctx is
+// cancelled.
+func (a *App) watchdogLoop(ctx context.Context) {
+	tick := time.NewTicker(watchdogInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+			a.checkWatchdog(time.Now())
+		}
+	}
+}
+
+// checkWatchdog emits a watchdog event and log line when the scheduler
+// becomes hung or recovers.
+func (a *App) checkWatchdog(now time.Time) {
+	hung, last, overdue := a.schedulerLiveness(now)
+	if a.watchdog.hung.Swap(hung) == hung {
+		return
+	}
+
+	level, message := "info", "scheduler loop recovered"
+	if hung {
+		level = "error"
+		message = fmt.Sprintf("scheduler loop appears hung: no progress since %s (%s overdue)",
+			last.UTC().Format(time.RFC3339), overdue.Truncate(time.Second))
+	}
+	if hung {
+		a.log.Error(message, "component", "watchdog")
+	} else {
+		a.log.Info(message, "component", "watchdog")
+	}
+	a.emit("ephemerisd", map[string]any{
+		"type":      "watchdog",
+		"hung":      hung,
+		"last_beat": last.UTC().Format(time.RFC3339Nano),
+		"message":   message,
+	})
+	a.emit("ephemerisd", map[string]any{
+		"type":    "log",
+		"level":   level,
+		"message": message,
+	})
+}
+
+// readyResponse is the body of /readyz. Each check is an object with at
+// least "ok".
+type readyResponse struct {
+	Ready  bool           `json:"ready"`
+	Checks map[string]any `json:"checks"`
+}
+
+// handleReadyz reports whether the daemon is ready to do work: it has
+// finished booting and the scheduler loop is making progress. Unlike
+// /healthz it returns 503 when not ready so orchestrators can act on it.
+func (a *App) handleReadyz(w http.ResponseWriter, _ *http.Request) {
+	now := time.Now()
+	state := a.state.Load().(string)
+	hung, last, overdue := a.schedulerLiveness(now)
+
+	checks := map[string]any{
+		"booted": map[string]any{"ok": state != "BOOTING", "state": state},
+	}
+	if a.sched() != nil {
+		sched := map[string]any{
+			"ok":              !hung,
+			"last_beat":       last.UTC().Format(time.RFC3339Nano),
+			"since_last_beat": int(now.Sub(last).Seconds()),
+		}
+		if hung {
+			sched["overdue_s"] = int(overdue.Seconds())
+		}
+		checks["scheduler"] = sched
+	}
+
+	ready := state != "BOOTING" && !hung
+	w.Header().Set("Content-Type", "application/json")
+	if !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(readyResponse{Ready: ready, Checks: checks})
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+// gpsdFixMaxAge is how old a cached gpsd fix may be before persisting it
+// queries the receiver again.
+const gpsdFixMaxAge = 15 * time.Minute
+
+// configPersistRequest is the body of POST /api/config/persist. Explicit
+// values take precedence over the gpsd fix.
+type configPersistRequest struct {
+	GPSD          bool     `json:"gpsd"`
+	Latitude      *float64 `json:"latitude"`
+	Longitude     *float64 `json:"longitude"`
+	Altitude      *float64 `json:"altitude"`
+	PPMCorrection *int     `json:"ppm_correction"`
+}
+
+// configPersistResponse reports what a write-back changed.
+type configPersistResponse struct {
+	OK      bool                 `json:"ok"`
+	Message string               `json:"message"`
+	Path    string               `json:"path"`
+	Changes []config.FieldChange `json:"changes"`
+}
+
+// handleConfigPersist writes runtime-discovered values back into the active
+// config file, then reloads it.
+//
+//	POST /api/config/persist
+//	{"gpsd": true}                       station position from gpsd
+//	{"latitude": 34.58, "longitude": -118.1, "altitude": 810}
+//	{"ppm_correction": 3}                e.g. from a kalibrate run
+//
+// Explicit values take precedence over the gpsd fix.
+func (a *App) handleConfigPersist(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var body configPersistRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	a.cfgMu.RLock()
+	path := a.configPath
+	a.cfgMu.RUnlock()
+	if path == "" {
+		jsonError(w, "daemon is running on built-in defaults; there is no config file to write", http.StatusConflict)
+		return
+	}
+
+	var updates []config.FileUpdate
+	source := "ephctl"
+
+	// With a station profile active, the location lives in its table.
+	station := "station"
+	if active := a.getConfig().Station.Active; active != "" {
+		station += "." + active
+	}
+
+	if body.GPSD {
+		fix, ok := predict.LastGPSDFix()
+		if !ok || time.Since(fix.At) > gpsdFixMaxAge {
+			// Kept under ephctl's 5s request timeout; a receiver that
+			// already has a fix reports one within a second or two.
+			loc, err := predict.LocationFromGPSD(a.getConfig().Station.GPSDHost, 4*time.Second)
+			if err != nil {
+				jsonError(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			fix = predict.Fix{Location: loc, At: time.Now()}
+		}
+		// Six decimals is ~0.1 m, well past consumer GPS accuracy.
+		updates = append(updates,
+			config.FileUpdate{Section: station, Key: "latitude", Value: round(fix.Lat, 6)},
+			config.FileUpdate{Section: station, Key: "longitude", Value: round(fix.Lon, 6)},
+			config.FileUpdate{Section: station, Key: "altitude", Value: round(fix.Alt, 1)},
+		)
+		source = "gpsd"
+	}
+
+	for _, v := range []struct {
+		key string
+		val *float64
+	}{{"latitude", body.Latitude}, {"longitude", body.Longitude}, {"altitude", body.Altitude}} {
+		if v.val != nil {
+			updates = setUpdate(updates, config.FileUpdate{Section: station, Key: v.key, Value: *v.val})
+		}
+	}
+	if body.PPMCorrection != nil {
+		updates = setUpdate(updates, config.FileUpdate{Section: "sdr", Key: "ppm_correction", Value: *body.PPMCorrection})
+	}
+
+	if len(updates) == 0 {
+		jsonError(w, "nothing to persist: set gpsd, latitude/longitude/altitude, or ppm_correction", http.StatusBadRequest)
+		return
+	}
+
+	if err := config.WriteBack(path, updates, source, time.Now()); err != nil {
+		jsonError(w, "write config: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	changes, err := a.reloadConfig(path, "writeback")
+	if err != nil {
+		jsonError(w, "config written but reload failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(configPersistResponse{
+		OK:      true,
+		Message: fmt.Sprintf("persisted %d value(s) from %s to %s", len(updates), source, path),
+		Path:    path,
+		Changes: changes,
+	})
+}
+
+// setUpdate replaces any update for the same key, or appends u.
+func setUpdate(updates []config.FileUpdate, u config.FileUpdate) []config.FileUpdate {
+	for i := range updates {
+		if updates[i].Section == u.Section && updates[i].Key == u.Key {
+			updates[i] = u
+			return updates
+		}
+	}
+	return append(updates, u)
+}
+
+func round(v float64, places int) float64 {
+	p := math.Pow(10, float64(places))
+	return math.Round(v*p) / p
+}
@@ -0,0 +1,26 @@
+package app
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed wsclient.html
+var wsClientPage []byte
+
+// handleWSClient serves a small page that connects to /ws from a browser,
+// shows every frame the hub sends, and sends subscriptions or any other
+// text frame back, for debugging the event protocol without other tools.
+// It is a debug endpoint, gated like /debug/pprof/.
+func (a *App) handleWSClient(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	h := w.Header()
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	h.Set("Cache-Control", "no-store")
+	h.Set("Content-Security-Policy", "default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; connect-src 'self' ws: wss:")
+	h.Set("X-Frame-Options", "DENY")
+	_, _ = w.Write(wsClientPage)
+}
@@ -0,0 +1,426 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+const (
+	// bandChannelHalfWidth is the room kept on each side of a channel: the
+	// APT signal's 17 kHz, Doppler shift, and a margin.
+	bandChannelHalfWidth = 25000
+	// bandUsable is the part of the sample rate flat enough to hold
+	// channels; an RTL-SDR's filters roll off towards the edges.
+	bandUsable = 0.8
+)
+
+// BandResult is how one pass of a band capture ended. Path is empty if the
+// pass was not recorded, with Err saying why unless the capture was
+// stopped.
+type BandResult struct {
+	Path string
+	Err  error
+}
+
+// bandCenter returns the frequency to tune to so that every one of freqs
+// can be recorded at once at rate, or false if they do not fit. Channels
+// are kept clear of each other and of the centre, where an RTL-SDR has a
+// DC spike.
+func bandCenter(rate int, freqs []int) (int, bool) {
+	if len(freqs) == 0 {
+		return 0, false
+	}
+	fs := slices.Sorted(slices.Values(freqs))
+	for i := 1; i < len(fs); i++ {
+		if fs[i]-fs[i-1] < 2*bandChannelHalfWidth {
+			return 0, false
+		}
+	}
+	lo, hi := fs[0], fs[len(fs)-1]
+	center := lo + (hi-lo)/2
+	for _, f := range fs {
+		// At most one channel can sit this close to the centre.
+		if d := f - center; d > -bandChannelHalfWidth && d < bandChannelHalfWidth {
+			center = f - bandChannelHalfWidth
+			break
+		}
+	}
+	edge := int(float64(rate) * bandUsable / 2)
+	if center-lo+bandChannelHalfWidth > edge || hi-center+bandChannelHalfWidth > edge {
+		return 0, false
+	}
+	return center, true
+}
+
+// withOffsets returns reqs with each one's frequency offset filled in, as
+// Capture does.
+func (r *Runner) withOffsets(reqs []CaptureRequest) []CaptureRequest {
+	reqs = slices.Clone(reqs)
+	if r.FreqOffset != nil {
+		for i := range reqs {
+			reqs[i].FreqOffsetHz = r.FreqOffset(reqs[i].Satellite.Name)
+		}
+	}
+	return reqs
+}
+
+func tunedFreqs(reqs []CaptureRequest) []int {
+	freqs := make([]int, len(reqs))
+	for i, req := range reqs {
+		freqs[i] = req.TunedFreq()
+	}
+	return freqs
+}
+
+// BandFits reports whether reqs can be recorded together by CaptureBand
+// at sdr.band_sample_rate.
+func (r *Runner) BandFits(reqs []CaptureRequest) bool {
+	_, ok := bandCenter(r.Cfg.SDR.BandSampleRate, tunedFreqs(r.withOffsets(reqs)))
+	return ok
+}
+
+// CaptureBand records passes that overlap in time with one SDR. The dongle
+// is tuned between their frequencies at sdr.band_sample_rate and each
+// pass's channel is mixed down, filtered and demodulated from the wideband
+// IQ, so each gets its own WAV file and sidecar just as from Capture. A
+// channel records from its AOS to its LOS; the method blocks until the
+// last LOS or context cancellation. The results are in the order of reqs.
+// Band captures always use the SDR, even when Simulate is set.
+func (r *Runner) CaptureBand(ctx context.Context, reqs []CaptureRequest, setState func(string)) []BandResult {
+	setState("RECORDING")
+	reqs = r.withOffsets(reqs)
+	results := make([]BandResult, len(reqs))
+	fail := func(err error) []BandResult {
+		for i := range results {
+			results[i].Err = err
+		}
+		return results
+	}
+
+	rate := r.Cfg.SDR.BandSampleRate
+	center, ok := bandCenter(rate, tunedFreqs(reqs))
+	if !ok {
+		return fail(fmt.Errorf("%s do not fit in a band capture at %d S/s", bandNames(reqs), rate))
+	}
+	span := reqs[0]
+	for i := range reqs {
+		reqs[i].BandCenterHz = center
+		if reqs[i].LOS.After(span.LOS) {
+			span.LOS = reqs[i].LOS
+		}
+	}
+
+	r.broadcast(map[string]any{
+		"type":    "log",
+		"level":   "info",
+		"message": fmt.Sprintf("starting band capture of %s, tuned to %d Hz at %d S/s", bandNames(reqs), center, rate),
+	})
+
+	if r.Cfg.SDR.Backend != config.BackendRtlTCP {
+		release, err := r.claimSDR(ctx, span)
+		if err != nil {
+			return fail(err)
+		}
+		defer release()
+	}
+
+	chans := make([]*bandChannel, len(reqs))
+	for i, req := range reqs {
+		chans[i] = &bandChannel{req: req}
+	}
+	_, err := r.retryWhileBusy(ctx, span, func() (int64, error) {
+		return 0, r.runBand(ctx, span.LOS, center, chans)
+	})
+
+	for i, ch := range chans {
+		switch {
+		case ch.rec != nil && !ch.done:
+			r.closeChannel(ch)
+		case ch.rec == nil && err != nil:
+			ch.err = err
+		case ch.rec == nil && ctx.Err() == nil:
+			ch.err = errors.New("band capture ended before the pass began")
+		}
+		results[i] = BandResult{Path: ch.path, Err: ch.err}
+	}
+	return results
+}
+
+func bandNames(reqs []CaptureRequest) string {
+	names := make([]string, len(reqs))
+	for i, req := range reqs {
+		names[i] = req.Satellite.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// runBand opens the SDR at center and feeds its IQ to chans until the last
+// of them is done or los arrives. It returns an error only if the SDR
+// could not be opened or failed before delivering any samples; errSDRBusy
+// is retried by the caller.
+func (r *Runner) runBand(ctx context.Context, los time.Time, center int, chans []*bandChannel) error {
+	losCtx, losCancel := context.WithDeadline(ctx, los)
+	defer losCancel()
+
+	src, rate, err := r.openBand(losCtx, center)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if rate != r.Cfg.SDR.BandSampleRate {
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": fmt.Sprintf("SDR runs at %d S/s for the band capture (asked for %d)", rate, r.Cfg.SDR.BandSampleRate),
+		})
+	}
+	for _, ch := range chans {
+		ch.tune(center, rate, r.Cfg.SDR.SampleRate)
+	}
+
+	iq := make([]complex64, 16384)
+	received := false
+	for losCtx.Err() == nil && slices.ContainsFunc(chans, func(ch *bandChannel) bool { return !ch.done }) {
+		n, err := src.ReadIQ(iq)
+		if err != nil {
+			if losCtx.Err() != nil {
+				break
+			}
+			if !received {
+				return err
+			}
+			r.Log.Error("band read failed", "err", err)
+			break
+		}
+		received = received || n > 0
+		now := time.Now()
+		for _, ch := range chans {
+			r.feedChannel(ch, iq[:n], now)
+		}
+	}
+	return nil
+}
+
+// openBand opens the configured SDR backend tuned to center at
+// sdr.band_sample_rate, returning the rate it actually runs at. The
+// rtl_fm backend reads raw IQ from rtl_sdr instead.
+func (r *Runner) openBand(ctx context.Context, center int) (iqSource, int, error) {
+	sdr := r.Cfg.SDR
+	switch sdr.Backend {
+	case config.BackendSoapy:
+		sdr.SoapySampleRate = sdr.BandSampleRate
+		return openSoapy(sdr, center)
+	case config.BackendRtlTCP:
+		sdr.TCPSampleRate = sdr.BandSampleRate
+		src, err := openRtlTCP(sdr, center)
+		return src, sdr.BandSampleRate, err
+	}
+	src, err := startRtlSDR(ctx, sdr, center)
+	return src, sdr.BandSampleRate, err
+}
+
+// bandChannel is one pass being recorded out of a band capture.
+type bandChannel struct {
+	req CaptureRequest
+
+	rot, step complex128 // oscillator mixing the channel down to 0 Hz
+	filter    *cicDecimator
+	demod     *fmDemod
+	mixed     []complex64
+	ifBuf     []complex64
+	pcm       []byte
+
+	rec        *recording
+	written    int64
+	lastReport time.Time
+	done       bool
+	path       string
+	err        error
+}
+
+// tune sets the channel up for IQ centred on center at rate.
+func (ch *bandChannel) tune(center, rate, outRate int) {
+	offset := float64(ch.req.TunedFreq() - center)
+	ch.rot = 1
+	ch.step = cmplx.Rect(1, -2*math.Pi*offset/float64(rate))
+	decim := max(rate/max(outRate, minIFRate), 1)
+	ch.filter = newCICDecimator(decim)
+	// The IF rate is rarely a whole number, so the resampling step is
+	// set directly rather than through newFMDemod.
+	ch.demod = &fmDemod{decim: 1, step: float64(rate) / float64(decim) / float64(outRate)}
+}
+
+// feedChannel passes a block of band IQ received at now to ch, starting
+// its recording at AOS and finishing it at LOS.
+func (r *Runner) feedChannel(ch *bandChannel, iq []complex64, now time.Time) {
+	if ch.done || now.Before(ch.req.AOS) {
+		return
+	}
+	if !now.Before(ch.req.LOS) {
+		r.closeChannel(ch)
+		return
+	}
+	if ch.rec == nil {
+		outPath := r.capturePath(ch.req)
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": fmt.Sprintf("starting band channel for %s at %d Hz%s, %+d Hz from centre -> %s", ch.req.Satellite.Name, ch.req.TunedFreq(), describeOffset(ch.req.FreqOffsetHz), ch.req.TunedFreq()-ch.req.BandCenterHz, outPath),
+		})
+		rec, err := r.createRecording(outPath, ch.req)
+		if err != nil {
+			ch.done, ch.err = true, err
+			return
+		}
+		ch.rec, ch.lastReport = rec, now
+	}
+
+	ch.mixed = slices.Grow(ch.mixed[:0], len(iq))[:len(iq)]
+	rot := ch.rot
+	for i, s := range iq {
+		ch.mixed[i] = s * complex64(rot)
+		rot *= ch.step
+	}
+	ch.rot = rot / complex(cmplx.Abs(rot), 0)
+	ch.ifBuf = ch.filter.process(ch.mixed, ch.ifBuf[:0])
+	ch.pcm = ch.demod.process(ch.ifBuf, ch.pcm[:0])
+
+	n, err := ch.rec.w.Write(ch.pcm)
+	ch.written += int64(n)
+	if err != nil {
+		r.Log.Error("write failed", "err", err)
+		r.closeChannel(ch)
+		return
+	}
+
+	if now.Sub(ch.lastReport) >= 2*time.Second {
+		pct := now.Sub(ch.req.AOS).Seconds() / ch.req.LOS.Sub(ch.req.AOS).Seconds() * 100
+		r.broadcast(map[string]any{
+			"type":      "progress",
+			"stage":     "recording",
+			"satellite": ch.req.Satellite.Name, // one stream per channel
+			"percent":   int(min(pct, 100)),
+			"detail":    fmt.Sprintf("%s band capture: %d bytes", ch.req.Satellite.Name, ch.written),
+		})
+		ch.lastReport = now
+	}
+}
+
+// closeChannel finishes ch's recording, if it started one.
+func (r *Runner) closeChannel(ch *bandChannel) {
+	ch.done = true
+	if ch.rec != nil {
+		ch.path, ch.err = r.finishRecording(ch.rec, ch.req, ch.written)
+	}
+}
+
+// cicDecimator is a second-order CIC filter: two running sums of decim
+// samples, keeping every decim'th output. Its nulls fall on each multiple
+// of the output rate, exactly where other channels in the band would
+// alias onto this one. The gain of decim² is left in; the discriminator
+// only looks at phase.
+type cicDecimator struct {
+	decim      int
+	ring1      []complex128
+	ring2      []complex128
+	sum1, sum2 complex128
+	i, n       int
+}
+
+func newCICDecimator(decim int) *cicDecimator {
+	return &cicDecimator{
+		decim: decim,
+		ring1: make([]complex128, decim),
+		ring2: make([]complex128, decim),
+	}
+}
+
+// process filters in and appends the decimated samples to out.
+func (c *cicDecimator) process(in, out []complex64) []complex64 {
+	for _, s := range in {
+		x := complex128(s)
+		c.sum1 += x - c.ring1[c.i]
+		c.ring1[c.i] = x
+		c.sum2 += c.sum1 - c.ring2[c.i]
+		c.ring2[c.i] = c.sum1
+		if c.i++; c.i == c.decim {
+			c.i = 0
+		}
+		if c.n++; c.n == c.decim {
+			c.n = 0
+			out = append(out, complex64(c.sum2))
+		}
+	}
+	return out
+}
+
+// rtlSDRSource reads raw IQ from an rtl_sdr process.
+type rtlSDRSource struct {
+	cmd      *exec.Cmd
+	out      io.ReadCloser
+	stderr   tailBuffer
+	u8       u8IQ
+	received bool
+}
+
+// startRtlSDR runs rtl_sdr tuned to freq at sdr.band_sample_rate, writing
+// IQ to its stdout. It stops when ctx is done.
+func startRtlSDR(ctx context.Context, sdr config.SDRConfig, freq int) (iqSource, error) {
+	s := &rtlSDRSource{}
+	s.cmd = exec.CommandContext(ctx, "rtl_sdr",
+		"-f", fmt.Sprintf("%d", freq),
+		"-s", fmt.Sprintf("%d", sdr.BandSampleRate),
+		"-g", fmt.Sprintf("%.1f", sdr.Gain),
+		"-p", fmt.Sprintf("%d", sdr.PPMCorrection),
+		"-d", fmt.Sprintf("%d", sdr.DeviceIndex),
+		"-",
+	)
+	s.cmd.Stderr = &s.stderr
+	out, err := s.cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdout pipe: %w", err)
+	}
+	if err := s.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start rtl_sdr: %w", err)
+	}
+	s.out = out
+	return s, nil
+}
+
+// ReadIQ blocks until rtl_sdr writes. When rtl_sdr exits before sending
+// anything its last line of stderr is returned as the error, as runRtlFm
+// does.
+func (s *rtlSDRSource) ReadIQ(buf []complex64) (int, error) {
+	n, err := s.u8.read(buf, s.out.Read)
+	s.received = s.received || n > 0
+	if err == nil || n > 0 {
+		return n, nil
+	}
+	_ = s.cmd.Wait()
+	msg := s.stderr.lastLine()
+	if !s.received && (strings.Contains(msg, "usb_claim_interface") || strings.Contains(msg, "Failed to open rtlsdr device")) {
+		return 0, fmt.Errorf("%w: rtl_sdr: %s", errSDRBusy, msg)
+	}
+	if msg == "" {
+		msg = err.Error()
+	}
+	return 0, fmt.Errorf("rtl_sdr exited: %s", msg)
+}
+
+func (s *rtlSDRSource) Close() error {
+	if s.cmd.Process != nil {
+		_ = s.cmd.Process.Kill()
+	}
+	_ = s.cmd.Wait()
+	return nil
+}
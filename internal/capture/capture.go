@@ -3,13 +3,15 @@ package capture
 import (
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/large-farva/ephemeris-engine/internal/config"
@@ -22,39 +24,58 @@ type CaptureRequest struct {
 	AOS       time.Time // acquisition of signal
 	LOS       time.Time // loss of signal
 	MaxElev   float64   // peak elevation in degrees
+	Station   string    // active station profile, recorded in the metadata
+	// CloudCover is the forecast cloud cover in percent, if known.
+	CloudCover *int
+	// FreqOffsetHz is added to the satellite's catalog frequency. Capture
+	// fills it in from Runner.FreqOffset when recording starts.
+	FreqOffsetHz int
+	// BandCenterHz is where the dongle was tuned when CaptureBand recorded
+	// this pass along with others, and 0 for a capture of its own.
+	BandCenterHz int
+}
+
+// TunedFreq returns the frequency to record at, in Hz.
+func (req CaptureRequest) TunedFreq() int {
+	return req.Satellite.Freq + req.FreqOffsetHz
 }
 
 // Runner records satellite passes to WAV files. When Simulate is true it
-// generates a synthetic tone instead of spawning rtl_fm, allowing the full
+// generates a synthetic tone instead of opening the SDR, allowing the full
 // pipeline to be tested without SDR hardware.
 type Runner struct {
 	Hub      *ws.Hub
 	Cfg      config.Config
-	Log      *log.Logger
+	Log      *slog.Logger
 	Simulate bool
+	// FreqOffset, when set, returns the configured frequency offset for a
+	// satellite. It is consulted at the start of every capture, so a
+	// change applies from the next pass on.
+	FreqOffset func(satellite string) int
 }
 
 // New creates a capture runner. Set simulate to true when no SDR hardware
 // is available; the runner will generate a synthetic WAV file instead.
-func New(hub *ws.Hub, cfg config.Config, logger *log.Logger, simulate bool) *Runner {
+func New(hub *ws.Hub, cfg config.Config, logger *slog.Logger, simulate bool) *Runner {
 	return &Runner{
 		Hub:      hub,
 		Cfg:      cfg,
-		Log:      logger,
+		Log:      logger.With("component", "capture"),
 		Simulate: simulate,
 	}
 }
 
 // Capture runs a single recording session. It creates a timestamped WAV file
-// under the configured data root and either records from rtl_fm or generates
+// under the configured data root and either records from the SDR or generates
 // a synthetic tone, depending on the Simulate flag. The method blocks until
 // LOS or context cancellation.
 func (r *Runner) Capture(ctx context.Context, req CaptureRequest, setState func(string)) (string, error) {
 	setState("RECORDING")
+	if r.FreqOffset != nil {
+		req.FreqOffsetHz = r.FreqOffset(req.Satellite.Name)
+	}
 
-	ts := req.AOS.UTC().Format("20060102T150405Z")
-	filename := fmt.Sprintf("%s_%s.wav", req.Satellite.Name, ts)
-	outPath := filepath.Join(r.Cfg.Data.Root, filename)
+	outPath := r.capturePath(req)
 
 	mode := "live"
 	if r.Simulate {
@@ -63,33 +84,122 @@ func (r *Runner) Capture(ctx context.Context, req CaptureRequest, setState func(
 	r.broadcast(map[string]any{
 		"type":    "log",
 		"level":   "info",
-		"message": fmt.Sprintf("starting %s capture for %s at %d Hz -> %s", mode, req.Satellite.Name, req.Satellite.Freq, outPath),
+		"message": fmt.Sprintf("starting %s capture for %s at %d Hz%s -> %s", mode, req.Satellite.Name, req.TunedFreq(), describeOffset(req.FreqOffsetHz), outPath),
 	})
 
-	f, err := os.Create(outPath)
-	if err != nil {
-		return "", fmt.Errorf("create wav: %w", err)
+	// An rtl_tcp radio is on another machine, out of reach of the local
+	// lock and process checks; the server itself takes one client at a
+	// time.
+	if !r.Simulate && r.Cfg.SDR.Backend != config.BackendRtlTCP {
+		release, err := r.claimSDR(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		defer release()
 	}
-	defer f.Close()
 
-	if err := writeWAVHeader(f, uint32(r.Cfg.SDR.SampleRate), 0); err != nil {
-		return "", fmt.Errorf("write wav header: %w", err)
+	rec, err := r.createRecording(outPath, req)
+	if err != nil {
+		return "", err
 	}
 
 	var bytesWritten int64
 	if r.Simulate {
-		bytesWritten = r.simulateCapture(ctx, f, req)
+		bytesWritten = r.simulateCapture(ctx, rec.w, req)
 	} else {
 		var captureErr error
-		bytesWritten, captureErr = r.rtlCapture(ctx, f, req)
+		bytesWritten, captureErr = r.sdrCapture(ctx, rec.w, req)
 		if captureErr != nil {
+			rec.abort()
 			return "", captureErr
 		}
 	}
+	return r.finishRecording(rec, req, bytesWritten)
+}
+
+// capturePath returns where the recording of req is saved: a file named
+// for the satellite and AOS under the data root.
+func (r *Runner) capturePath(req CaptureRequest) string {
+	ts := req.AOS.UTC().Format("20060102T150405Z")
+	return filepath.Join(r.Cfg.Data.Root, fmt.Sprintf("%s_%s.wav", req.Satellite.Name, ts))
+}
+
+// recording is a WAV file being written by a capture.
+type recording struct {
+	outPath string
+	recPath string // where it is written until LOS; outPath unless staging
+	f       *os.File
+	w       io.Writer // f, with periodic syncs if configured
+}
+
+// createRecording creates the WAV file for outPath, in the staging
+// directory if one is configured, and writes its header.
+func (r *Runner) createRecording(outPath string, req CaptureRequest) (*recording, error) {
+	recPath := r.recordPath(outPath, req)
+	if recPath != outPath {
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": fmt.Sprintf("recording to staging path %s until LOS", recPath),
+		})
+	}
+	f, err := os.Create(recPath)
+	if err != nil {
+		return nil, fmt.Errorf("create wav: %w", err)
+	}
+
+	if err := writeWAVHeader(f, uint32(r.Cfg.SDR.SampleRate), 0); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("write wav header: %w", err)
+	}
+
+	// Periodic syncs only matter on real storage; staging is in RAM.
+	var w io.Writer = f
+	if n := r.Cfg.Data.FsyncIntervalSeconds; n > 0 && recPath == outPath {
+		w = &periodicSyncer{f: f, interval: time.Duration(n) * time.Second, last: time.Now(), log: r.Log}
+	}
+	return &recording{outPath: outPath, recPath: recPath, f: f, w: w}, nil
+}
+
+// abort closes and deletes a recording that failed.
+func (rec *recording) abort() {
+	rec.f.Close()
+	os.Remove(rec.recPath)
+}
+
+// finishRecording finalizes the WAV header, moves the file out of staging
+// and writes its metadata sidecar. It returns the final path.
+func (r *Runner) finishRecording(rec *recording, req CaptureRequest, bytesWritten int64) (string, error) {
+	defer rec.f.Close()
+	outPath := rec.outPath
+	filename := filepath.Base(outPath)
 
 	if bytesWritten > 0 {
-		if err := fixWAVHeader(f); err != nil {
-			r.Log.Printf("capture: failed to finalize WAV header: %v", err)
+		if err := fixWAVHeader(rec.f); err != nil {
+			r.Log.Error("finalize WAV header failed", "file", filename, "err", err)
+		}
+	}
+	if r.Cfg.Data.FsyncOnFinalize && rec.recPath == outPath {
+		if err := rec.f.Sync(); err != nil {
+			r.Log.Error("fsync failed", "file", filename, "err", err)
+		}
+	}
+	if rec.recPath != outPath {
+		rec.f.Close()
+		if err := moveCapture(rec.recPath, outPath); err != nil {
+			return "", fmt.Errorf("move %s from staging: %w (recording left at %s)", filename, err, rec.recPath)
+		}
+	}
+	sum, err := FileSHA256(outPath)
+	if err != nil {
+		r.Log.Error("checksum failed", "file", filename, "err", err)
+	}
+	if err := r.writeMetadata(outPath, req, bytesWritten, sum); err != nil {
+		r.Log.Error("write metadata failed", "file", filename, "err", err)
+	}
+	if r.Cfg.Data.FsyncOnFinalize {
+		if err := syncDir(r.Cfg.Data.Root); err != nil {
+			r.Log.Error("fsync failed", "dir", r.Cfg.Data.Root, "err", err)
 		}
 	}
 
@@ -102,15 +212,67 @@ func (r *Runner) Capture(ctx context.Context, req CaptureRequest, setState func(
 	return outPath, nil
 }
 
-// rtlCapture records a pass by running rtl_fm as a subprocess. The process
-// is killed automatically when the LOS deadline arrives or the context is
-// cancelled.
-func (r *Runner) rtlCapture(ctx context.Context, f *os.File, req CaptureRequest) (int64, error) {
+// CheckBackend reports why captures cannot run with the configured SDR
+// backend, or nil if they can.
+func CheckBackend(sdr config.SDRConfig) error {
+	switch sdr.Backend {
+	case config.BackendSoapy:
+		return soapyAvailable()
+	case config.BackendRtlTCP:
+		return rtlTCPAvailable(sdr.TCPAddr)
+	}
+	if _, err := exec.LookPath("rtl_fm"); err != nil {
+		return errors.New("rtl_fm not found in PATH")
+	}
+	if _, err := exec.LookPath("rtl_sdr"); err != nil && sdr.BandCapture {
+		return errors.New("rtl_sdr not found in PATH; band captures need it")
+	}
+	return nil
+}
+
+// sdrCapture records a pass with the configured SDR backend.
+func (r *Runner) sdrCapture(ctx context.Context, f io.Writer, req CaptureRequest) (int64, error) {
+	run := r.runRtlFm
+	switch r.Cfg.SDR.Backend {
+	case config.BackendSoapy:
+		run = r.runSoapy
+	case config.BackendRtlTCP:
+		run = r.runRtlTCP
+	}
+	return r.retryWhileBusy(ctx, req, func() (int64, error) { return run(ctx, f, req) })
+}
+
+// retryWhileBusy runs a capture attempt. The recording stops automatically
+// when the LOS deadline arrives or the context is cancelled. If the backend
+// cannot claim the device, held by a program not in sdr.competing_processes,
+// it is retried until LOS like claimSDR does.
+func (r *Runner) retryWhileBusy(ctx context.Context, req CaptureRequest, attempt func() (int64, error)) (int64, error) {
+	for announced := false; ; announced = true {
+		n, err := attempt()
+		if !errors.Is(err, errSDRBusy) {
+			return n, err
+		}
+		if !announced {
+			r.emitSDRBusy(req, []sdrHolder{{Name: "unidentified program"}}, "waiting")
+		}
+		if !r.waitSDRRetry(ctx, req) {
+			return 0, err
+		}
+	}
+}
+
+// runRtlFm runs rtl_fm once. When it exits before producing any audio its
+// last line of stderr is returned as the error, since rtl_fm reports
+// device problems only there.
+func (r *Runner) runRtlFm(ctx context.Context, f io.Writer, req CaptureRequest) (int64, error) {
 	losCtx, losCancel := context.WithDeadline(ctx, req.LOS)
 	defer losCancel()
 
-	args := buildRtlFmArgs(r.Cfg.SDR, req.Satellite.Freq)
+	args := buildRtlFmArgs(r.Cfg.SDR, req.TunedFreq())
+	r.Log.Debug("starting rtl_fm", "satellite", req.Satellite.Name, "args", strings.Join(args, " "), "until", req.LOS.UTC().Format(time.RFC3339))
 	cmd := exec.CommandContext(losCtx, "rtl_fm", args...)
+	var stderr tailBuffer
+	cmd.Stderr = &stderr
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -129,9 +291,40 @@ func (r *Runner) rtlCapture(ctx context.Context, f *os.File, req CaptureRequest)
 	}
 	_ = cmd.Wait()
 
+	if bytesWritten == 0 && losCtx.Err() == nil {
+		msg := stderr.lastLine()
+		if strings.Contains(msg, "usb_claim_interface") || strings.Contains(msg, "Failed to open rtlsdr device") {
+			return 0, fmt.Errorf("%w: rtl_fm: %s", errSDRBusy, msg)
+		}
+		if msg == "" {
+			msg = "no output"
+		}
+		return 0, fmt.Errorf("rtl_fm exited before recording: %s", msg)
+	}
 	return bytesWritten, nil
 }
 
+// tailBuffer keeps the last 4 KiB written to it, enough for the final
+// lines of rtl_fm's stderr.
+type tailBuffer struct {
+	b []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	const limit = 4096
+	t.b = append(t.b, p...)
+	if len(t.b) > limit {
+		t.b = t.b[len(t.b)-limit:]
+	}
+	return len(p), nil
+}
+
+// lastLine returns the last non-empty line written.
+func (t *tailBuffer) lastLine() string {
+	lines := strings.Split(strings.TrimSpace(string(t.b)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
 // simulateCapture writes a synthetic 2400 Hz sine wave (the APT subcarrier
 // frequency) in buffered chunks. Duration is scaled by demo.interval_seconds
 // so a real 12-minute pass can simulate in a few seconds.
@@ -177,7 +370,7 @@ func (r *Runner) simulateCapture(ctx context.Context, f io.Writer, req CaptureRe
 		written += int64(nw)
 		samplesWritten += n
 		if err != nil {
-			r.Log.Printf("capture: simulated write error: %v", err)
+			r.Log.Error("simulated write failed", "err", err)
 			return written
 		}
 
@@ -201,7 +394,7 @@ func (r *Runner) simulateCapture(ctx context.Context, f io.Writer, req CaptureRe
 	return written
 }
 
-// streamWithProgress copies PCM data from a reader (typically rtl_fm stdout)
+// streamWithProgress copies PCM data from a reader (rtl_fm stdout or a pcmReader)
 // to the WAV file, broadcasting progress events every 2 seconds.
 func (r *Runner) streamWithProgress(ctx context.Context, dst io.Writer, src io.Reader, req CaptureRequest, totalDuration time.Duration) int64 {
 	buf := make([]byte, 8192)
@@ -221,7 +414,7 @@ func (r *Runner) streamWithProgress(ctx context.Context, dst io.Writer, src io.R
 			nw, writeErr := dst.Write(buf[:n])
 			written += int64(nw)
 			if writeErr != nil {
-				r.Log.Printf("capture: write error: %v", writeErr)
+				r.Log.Error("write failed", "satellite", req.Satellite.Name, "err", writeErr)
 				return written
 			}
 		}
@@ -245,7 +438,7 @@ func (r *Runner) streamWithProgress(ctx context.Context, dst io.Writer, src io.R
 			return written
 		}
 		if readErr != nil {
-			r.Log.Printf("capture: read error: %v", readErr)
+			r.Log.Error("read failed", "satellite", req.Satellite.Name, "err", readErr)
 			return written
 		}
 	}
@@ -266,6 +459,15 @@ func buildRtlFmArgs(sdr config.SDRConfig, freq int) []string {
 	}
 }
 
+// describeOffset formats a frequency offset for the capture log, or
+// returns "" for none.
+func describeOffset(hz int) string {
+	if hz == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (offset %+d Hz)", hz)
+}
+
 func (r *Runner) broadcast(v map[string]any) {
 	v["ts"] = time.Now().UTC().Format(time.RFC3339Nano)
 	v["component"] = "capture"
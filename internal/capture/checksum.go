@@ -0,0 +1,51 @@
+package capture
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ErrNoChecksum is returned by VerifyChecksum for captures whose sidecar
+// has no checksum, such as those recorded before checksums were added.
+var ErrNoChecksum = errors.New("no checksum recorded")
+
+// FileSHA256 returns the hex-encoded SHA-256 of the file at path.
+func FileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// VerifyChecksum re-hashes a capture and compares it with the checksum in
+// its sidecar, returning the checksum it computed. A mismatch means the
+// file changed after it was recorded, which on an SD card usually means
+// corruption.
+func VerifyChecksum(capturePath string) (string, error) {
+	meta, err := ReadMetadata(capturePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if meta.SHA256 == "" {
+		return "", ErrNoChecksum
+	}
+	sum, err := FileSHA256(capturePath)
+	if err != nil {
+		return "", err
+	}
+	if sum != meta.SHA256 {
+		return sum, fmt.Errorf("checksum mismatch: recorded %s, file is now %s", meta.SHA256, sum)
+	}
+	return sum, nil
+}
@@ -0,0 +1,687 @@
+package capture
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CompressionFLAC is the data.compress format that transcodes finished
+// recordings to FLAC. It is lossless; what it saves depends on how noisy
+// the recording is.
+const CompressionFLAC = "flac"
+
+// flacBlockSize is the number of samples per channel in a FLAC frame, the
+// reference encoder's default.
+const flacBlockSize = 4096
+
+// flacMaxRice is the largest Rice parameter used; 15 is the escape code.
+const flacMaxRice = 14
+
+// pcmFormat is the sample format of a WAV recording.
+type pcmFormat struct {
+	channels   int
+	sampleRate int
+	bits       int
+}
+
+// CompressFLAC transcodes the WAV recording at wavPath to FLAC beside it,
+// and returns the FLAC's path. It is EncodeFLAC followed by
+// ReplaceWithFLAC; callers that must keep other work from touching the
+// capture while the FLAC replaces the WAV call the two themselves.
+func CompressFLAC(wavPath string, sync bool) (string, error) {
+	tmp, err := EncodeFLAC(wavPath, sync)
+	if err != nil {
+		return "", err
+	}
+	return ReplaceWithFLAC(wavPath, tmp, sync)
+}
+
+// EncodeFLAC transcodes the WAV recording at wavPath to a temporary FLAC
+// beside it, reads it back and checks it against the WAV's samples, and
+// returns its path. sync flushes it to disk. The WAV and its sidecar are
+// not touched.
+func EncodeFLAC(wavPath string, sync bool) (string, error) {
+	if _, err := ReadMetadata(wavPath); err != nil {
+		return "", fmt.Errorf("reading sidecar: %w", err)
+	}
+	flacPath := flacPathFor(wavPath)
+	if _, err := os.Stat(flacPath); err == nil {
+		return "", fmt.Errorf("%s already exists", filepath.Base(flacPath))
+	}
+	tmp := flacPath + ".tmp"
+	sum, err := encodeFLAC(wavPath, tmp, sync)
+	if err == nil {
+		err = checkFLAC(tmp, sum)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
+
+// ReplaceWithFLAC moves the FLAC that EncodeFLAC wrote to tmp into place
+// of the WAV at wavPath, and returns the FLAC's path. The sidecar is
+// updated with the FLAC's size and checksum, and the WAV removed. sync
+// flushes the FLAC, the sidecar and their directory to disk, as
+// data.fsync_on_finalize does for recordings. A WAV deleted since it was
+// encoded is not brought back: tmp is removed and an error wrapping
+// fs.ErrNotExist returned. On other errors before the FLAC is in place,
+// the WAV and its sidecar are left as they were.
+func ReplaceWithFLAC(wavPath, tmp string, sync bool) (string, error) {
+	meta, err := ReadMetadata(wavPath)
+	var wavInfo os.FileInfo
+	if err == nil {
+		wavInfo, err = os.Stat(wavPath)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	flacPath := flacPathFor(wavPath)
+	if err := os.Rename(tmp, flacPath); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+
+	// From here the FLAC holds the recording. A crash before the WAV is
+	// removed leaves both, which the consistency check settles by what
+	// the sidecar says.
+	info, err := os.Stat(flacPath)
+	if err == nil {
+		meta.SHA256, err = FileSHA256(flacPath)
+	}
+	if err == nil {
+		meta.Bytes = info.Size()
+		meta.WAVBytes = wavInfo.Size()
+		meta.Compression = CompressionFLAC
+		meta.Corrupt = false
+		err = WriteMetadata(flacPath, meta, sync)
+	}
+	if err != nil {
+		os.Remove(flacPath)
+		return "", err
+	}
+	if err := os.Remove(wavPath); err != nil {
+		return flacPath, err
+	}
+	if sync {
+		if err := syncDir(filepath.Dir(flacPath)); err != nil {
+			return flacPath, err
+		}
+	}
+	return flacPath, nil
+}
+
+// flacPathFor returns the path a recording's FLAC is kept at.
+func flacPathFor(wavPath string) string {
+	return strings.TrimSuffix(wavPath, filepath.Ext(wavPath)) + ".flac"
+}
+
+// readWAVFormat reads the header of a WAV file of size bytes up to its
+// data chunk, and returns the sample format and the length of the data.
+// A data chunk whose size is unset or runs past the end of the file, as
+// in a recording cut short, is taken to run to the end.
+func readWAVFormat(r io.Reader, size int64) (pcmFormat, int64, error) {
+	var f pcmFormat
+	var riff [12]byte
+	if _, err := io.ReadFull(r, riff[:]); err != nil {
+		return f, 0, errors.New("not a WAV file")
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return f, 0, errors.New("not a WAV file")
+	}
+	pos := int64(len(riff))
+	for {
+		var ch [8]byte
+		if _, err := io.ReadFull(r, ch[:]); err != nil {
+			return f, 0, errors.New("WAV file has no data chunk")
+		}
+		pos += int64(len(ch))
+		n := int64(binary.LittleEndian.Uint32(ch[4:8]))
+		switch string(ch[0:4]) {
+		case "fmt ":
+			if n < 16 {
+				return f, 0, errors.New("WAV fmt chunk is too short")
+			}
+			b := make([]byte, n+n&1)
+			if _, err := io.ReadFull(r, b); err != nil {
+				return f, 0, err
+			}
+			pos += int64(len(b))
+			format := binary.LittleEndian.Uint16(b[0:2])
+			f.channels = int(binary.LittleEndian.Uint16(b[2:4]))
+			f.sampleRate = int(binary.LittleEndian.Uint32(b[4:8]))
+			f.bits = int(binary.LittleEndian.Uint16(b[14:16]))
+			if format != 1 && format != 0xfffe {
+				return f, 0, fmt.Errorf("WAV format %#x is not PCM", format)
+			}
+		case "data":
+			if f.channels == 0 {
+				return f, 0, errors.New("WAV data chunk comes before its fmt chunk")
+			}
+			if n == 0 || pos+n > size {
+				n = size - pos
+			}
+			return f, n, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, n+n&1); err != nil {
+				return f, 0, err
+			}
+			pos += n + n&1
+		}
+	}
+}
+
+// encodeFLAC writes the WAV recording at wavPath as a FLAC file at path,
+// and returns the MD5 of its samples, as recorded in the FLAC's
+// STREAMINFO. Each channel of each frame is coded as a constant, with the
+// fixed predictor of the order that fits it best and Rice-coded
+// residuals, or verbatim, whichever is smallest.
+func encodeFLAC(wavPath, path string, sync bool) ([]byte, error) {
+	in, err := os.Open(wavPath)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReaderSize(in, 1<<16)
+	pcm, dataSize, err := readWAVFormat(br, info.Size())
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case pcm.bits != 8 && pcm.bits != 16:
+		return nil, fmt.Errorf("%d-bit WAVs are not compressed; only 8 and 16 bits are", pcm.bits)
+	case pcm.channels < 1 || pcm.channels > 8:
+		return nil, fmt.Errorf("WAV has %d channels; FLAC takes 1 to 8", pcm.channels)
+	case pcm.sampleRate < 1 || pcm.sampleRate >= 1<<20:
+		return nil, fmt.Errorf("WAV sample rate %d is out of FLAC's range", pcm.sampleRate)
+	}
+
+	out, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer out.Close()
+	bw := bufio.NewWriterSize(out, 1<<16)
+
+	// STREAMINFO is written again once the totals are known.
+	if _, err := bw.Write(streamInfo(pcm, 0, 0, 0, 0, nil)); err != nil {
+		return nil, err
+	}
+
+	width := pcm.bits / 8
+	raw := make([]byte, flacBlockSize*pcm.channels*width)
+	chans := make([][]int32, pcm.channels)
+	for c := range chans {
+		chans[c] = make([]int32, flacBlockSize)
+	}
+	sum := md5.New()
+	var fw bitWriter
+	var total, frame int64
+	minFrame, maxFrame := 0, 0
+	for remaining := dataSize; remaining >= int64(pcm.channels*width); {
+		n := int(min(int64(len(raw)), remaining))
+		n -= n % (pcm.channels * width)
+		if _, err := io.ReadFull(br, raw[:n]); err != nil {
+			return nil, fmt.Errorf("reading WAV: %w", err)
+		}
+		remaining -= int64(n)
+		samples := n / (pcm.channels * width)
+		for i := range samples {
+			for c := range pcm.channels {
+				off := (i*pcm.channels + c) * width
+				if width == 1 {
+					chans[c][i] = int32(raw[off]) - 128
+				} else {
+					chans[c][i] = int32(int16(binary.LittleEndian.Uint16(raw[off:])))
+				}
+			}
+		}
+		hashSamples(sum, chans, samples, width)
+
+		fw.reset()
+		writeFrame(&fw, pcm, chans, samples, frame)
+		if _, err := bw.Write(fw.buf); err != nil {
+			return nil, err
+		}
+		if minFrame == 0 || len(fw.buf) < minFrame {
+			minFrame = len(fw.buf)
+		}
+		maxFrame = max(maxFrame, len(fw.buf))
+		total += int64(samples)
+		frame++
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+
+	md := sum.Sum(nil)
+	block := flacBlockSize
+	if total < flacBlockSize {
+		block = max(int(total), 16)
+	}
+	if _, err := out.WriteAt(streamInfo(pcm, block, minFrame, maxFrame, total, md), 0); err != nil {
+		return nil, err
+	}
+	if sync {
+		if err := out.Sync(); err != nil {
+			return nil, err
+		}
+	}
+	return md, out.Close()
+}
+
+// streamInfo returns the "fLaC" marker and the STREAMINFO block, the only
+// metadata block written.
+func streamInfo(pcm pcmFormat, block, minFrame, maxFrame int, total int64, md []byte) []byte {
+	var w bitWriter
+	w.buf = append(w.buf, "fLaC"...)
+	w.bits(1, 1) // last metadata block
+	w.bits(0, 7) // STREAMINFO
+	w.bits(34, 24)
+	w.bits(uint64(block), 16)
+	w.bits(uint64(block), 16)
+	w.bits(uint64(minFrame), 24)
+	w.bits(uint64(maxFrame), 24)
+	w.bits(uint64(pcm.sampleRate), 20)
+	w.bits(uint64(pcm.channels-1), 3)
+	w.bits(uint64(pcm.bits-1), 5)
+	w.bits(uint64(total)>>32, 4)
+	w.bits(uint64(total)&0xffffffff, 32)
+	if md == nil {
+		md = make([]byte, md5.Size)
+	}
+	w.buf = append(w.buf, md...)
+	return w.buf
+}
+
+// hashSamples adds n samples of each channel to the MD5 of a stream, as
+// signed little-endian values width bytes wide, interleaved.
+func hashSamples(h hash.Hash, chans [][]int32, n, width int) {
+	b := make([]byte, 0, n*len(chans)*width)
+	for i := range n {
+		for _, ch := range chans {
+			if width == 1 {
+				b = append(b, byte(ch[i]))
+			} else {
+				b = binary.LittleEndian.AppendUint16(b, uint16(ch[i]))
+			}
+		}
+	}
+	h.Write(b)
+}
+
+// writeFrame codes n samples of each channel as FLAC frame number frame.
+func writeFrame(w *bitWriter, pcm pcmFormat, chans [][]int32, n int, frame int64) {
+	w.bits(0x3ffe, 14) // sync code
+	w.bits(0, 1)
+	w.bits(0, 1) // fixed block size
+	w.bits(7, 4) // block size in 16 bits after the frame number
+	w.bits(0, 4) // sample rate from STREAMINFO
+	w.bits(uint64(pcm.channels-1), 4)
+	if pcm.bits == 8 {
+		w.bits(1, 3)
+	} else {
+		w.bits(4, 3)
+	}
+	w.bits(0, 1)
+	w.utf8(uint64(frame))
+	w.bits(uint64(n-1), 16)
+	w.bits(uint64(flacCRC8(w.buf)), 8)
+
+	for _, ch := range chans {
+		writeSubframe(w, ch[:n], pcm.bits)
+	}
+	w.align()
+	w.bits(uint64(flacCRC16(w.buf)), 16)
+}
+
+// writeSubframe codes one channel's samples x, each bps bits wide.
+func writeSubframe(w *bitWriter, x []int32, bps int) {
+	constant := true
+	for _, v := range x[1:] {
+		if v != x[0] {
+			constant = false
+			break
+		}
+	}
+	if constant {
+		w.bits(0, 8) // padding, CONSTANT, no wasted bits
+		w.bits(uint64(x[0]), uint(bps))
+		return
+	}
+
+	// Residuals of the fixed predictors of order 0 to 4, which are the
+	// successive differences of the signal; the order with the smallest
+	// total is kept.
+	n := len(x)
+	res := make([]int64, n)
+	diff := make([]int64, n)
+	for i, v := range x {
+		diff[i] = int64(v)
+	}
+	bestOrder, bestSum := 0, uint64(0)
+	for order := 0; order <= min(4, n-1); order++ {
+		if order > 0 {
+			for i := n - 1; i >= order; i-- {
+				diff[i] -= diff[i-1]
+			}
+		}
+		var sum uint64
+		for _, d := range diff[order:] {
+			sum += zigzag(d)
+		}
+		if order == 0 || sum < bestSum {
+			bestOrder, bestSum = order, sum
+			copy(res, diff)
+		}
+	}
+
+	count := uint64(n - bestOrder)
+	k := uint(0)
+	for k < flacMaxRice && count<<k < bestSum {
+		k++
+	}
+	cost := uint64(bestOrder*bps) + 10 + count*uint64(k+1)
+	for _, d := range res[bestOrder:] {
+		cost += zigzag(d) >> k
+	}
+	if cost >= uint64(n*bps) {
+		w.bits(0x02, 8) // padding, VERBATIM, no wasted bits
+		for _, v := range x {
+			w.bits(uint64(v), uint(bps))
+		}
+		return
+	}
+
+	w.bits(uint64(0x08|bestOrder)<<1, 8) // padding, FIXED, no wasted bits
+	for _, v := range x[:bestOrder] {
+		w.bits(uint64(v), uint(bps))
+	}
+	w.bits(0, 2) // Rice coding with 4-bit parameters
+	w.bits(0, 4) // one partition
+	w.bits(uint64(k), 4)
+	for _, d := range res[bestOrder:] {
+		u := zigzag(d)
+		w.zeros(u >> k)
+		w.bits(1, 1)
+		w.bits(u, k)
+	}
+}
+
+// zigzag folds a signed residual onto the non-negative integers, as Rice
+// coding needs.
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+// bitWriter packs values most significant bit first.
+type bitWriter struct {
+	buf  []byte
+	acc  uint64
+	nacc uint
+}
+
+func (w *bitWriter) reset() {
+	w.buf, w.acc, w.nacc = w.buf[:0], 0, 0
+}
+
+// bits writes the low n bits of v; n is at most 32.
+func (w *bitWriter) bits(v uint64, n uint) {
+	if n == 0 {
+		return
+	}
+	w.acc = w.acc<<n | v&(1<<n-1)
+	w.nacc += n
+	for w.nacc >= 8 {
+		w.nacc -= 8
+		w.buf = append(w.buf, byte(w.acc>>w.nacc))
+	}
+}
+
+// zeros writes n zero bits, the unary part of a Rice code.
+func (w *bitWriter) zeros(n uint64) {
+	for ; n > 32; n -= 32 {
+		w.bits(0, 32)
+	}
+	w.bits(0, uint(n))
+}
+
+// align pads with zero bits to a byte boundary.
+func (w *bitWriter) align() {
+	if w.nacc > 0 {
+		w.bits(0, 8-w.nacc)
+	}
+}
+
+// utf8 writes v in the extended UTF-8 coding FLAC frame numbers use.
+func (w *bitWriter) utf8(v uint64) {
+	if v < 0x80 {
+		w.bits(v, 8)
+		return
+	}
+	n := 2
+	for v >= 1<<(5*n+1) {
+		n++
+	}
+	w.bits(uint64(0xff00>>n)&0xff|v>>(6*(n-1)), 8)
+	for i := n - 2; i >= 0; i-- {
+		w.bits(0x80|(v>>(6*i))&0x3f, 8)
+	}
+}
+
+// CRC tables of FLAC's frame header CRC-8 (polynomial 0x07) and frame
+// CRC-16 (polynomial 0x8005).
+var (
+	flacCRC8Table  [256]uint8
+	flacCRC16Table [256]uint16
+)
+
+func init() {
+	for i := range 256 {
+		c8, c16 := uint8(i), uint16(i)<<8
+		for range 8 {
+			c8 = c8<<1 ^ 0x07*(c8>>7)
+			c16 = c16<<1 ^ 0x8005*(c16>>15)
+		}
+		flacCRC8Table[i], flacCRC16Table[i] = c8, c16
+	}
+}
+
+func flacCRC8(b []byte) uint8 {
+	var c uint8
+	for _, v := range b {
+		c = flacCRC8Table[c^v]
+	}
+	return c
+}
+
+func flacCRC16(b []byte) uint16 {
+	var c uint16
+	for _, v := range b {
+		c = c<<8 ^ flacCRC16Table[byte(c>>8)^v]
+	}
+	return c
+}
+
+// checkFLAC decodes the FLAC file at path, as encodeFLAC writes them, and
+// checks each frame's CRC and the MD5 of the samples against sum, so no
+// recording is replaced by a FLAC that would not give it back.
+func checkFLAC(path string, sum []byte) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	br := bufio.NewReaderSize(f, 1<<16)
+
+	head := make([]byte, 42)
+	if _, err := io.ReadFull(br, head); err != nil || string(head[:4]) != "fLaC" || head[4] != 0x80 {
+		return errors.New("FLAC check: bad stream header")
+	}
+	si := head[8:]
+	channels := int(si[12]>>1&7) + 1
+	bps := int(si[12]&1<<4|si[13]>>4) + 1
+	total := int64(si[13]&0x0f)<<32 | int64(binary.BigEndian.Uint32(si[14:18]))
+	if !bytes.Equal(si[18:34], sum) {
+		return errors.New("FLAC check: STREAMINFO MD5 does not match the WAV")
+	}
+
+	h := md5.New()
+	chans := make([][]int32, channels)
+	for c := range chans {
+		chans[c] = make([]int32, flacBlockSize)
+	}
+	r := &bitReader{r: br}
+	var decoded int64
+	for {
+		if _, err := br.Peek(1); err == io.EOF {
+			break
+		}
+		r.crc = nil
+		r.record = true
+		if r.read(16) != 0xfff8 || r.read(4) != 7 || r.read(4) != 0 ||
+			int(r.read(4))+1 != channels || r.read(4) != map[int]uint64{8: 2, 16: 8}[bps] {
+			return fmt.Errorf("FLAC check: bad frame header at sample %d", decoded)
+		}
+		for c := r.read(8); c&0xc0 == 0xc0; c <<= 1 {
+			r.read(8)
+		}
+		n := int(r.read(16)) + 1
+		if n > flacBlockSize {
+			return fmt.Errorf("FLAC check: frame of %d samples", n)
+		}
+		r.read(8)
+		for c := range channels {
+			if err := readSubframe(r, chans[c][:n], bps); err != nil {
+				return fmt.Errorf("FLAC check: %w at sample %d", err, decoded)
+			}
+		}
+		r.align()
+		want := flacCRC16(r.crc)
+		if got := uint16(r.read(16)); r.err == nil && got != want {
+			return fmt.Errorf("FLAC check: frame CRC mismatch at sample %d", decoded)
+		}
+		if r.err != nil {
+			return fmt.Errorf("FLAC check: %w", r.err)
+		}
+		hashSamples(h, chans, n, bps/8)
+		decoded += int64(n)
+	}
+	if decoded != total {
+		return fmt.Errorf("FLAC check: %d samples decoded, %d expected", decoded, total)
+	}
+	if !bytes.Equal(h.Sum(nil), sum) {
+		return errors.New("FLAC check: decoded samples do not match the WAV")
+	}
+	return nil
+}
+
+// readSubframe decodes one channel of a frame into x.
+func readSubframe(r *bitReader, x []int32, bps int) error {
+	kind := r.read(8)
+	switch {
+	case kind == 0x00:
+		v := r.signed(uint(bps))
+		for i := range x {
+			x[i] = v
+		}
+	case kind == 0x02:
+		for i := range x {
+			x[i] = r.signed(uint(bps))
+		}
+	case kind >= 0x08<<1 && kind <= 0x0c<<1 && kind&1 == 0:
+		order := int(kind>>1) & 7
+		for i := range order {
+			x[i] = r.signed(uint(bps))
+		}
+		if r.read(2) != 0 || r.read(4) != 0 {
+			return errors.New("unexpected residual coding")
+		}
+		k := uint(r.read(4))
+		for i := order; i < len(x) && r.err == nil; i++ {
+			u := r.unary()<<k | r.read(k)
+			d := int64(u>>1) ^ -int64(u&1)
+			switch order {
+			case 1:
+				d += int64(x[i-1])
+			case 2:
+				d += 2*int64(x[i-1]) - int64(x[i-2])
+			case 3:
+				d += 3*int64(x[i-1]) - 3*int64(x[i-2]) + int64(x[i-3])
+			case 4:
+				d += 4*int64(x[i-1]) - 6*int64(x[i-2]) + 4*int64(x[i-3]) - int64(x[i-4])
+			}
+			x[i] = int32(d)
+		}
+	default:
+		return fmt.Errorf("unexpected subframe type %#x", kind)
+	}
+	return r.err
+}
+
+// bitReader reads values most significant bit first, keeping the bytes
+// it reads for a CRC while record is set.
+type bitReader struct {
+	r      *bufio.Reader
+	acc    uint64
+	nacc   uint
+	crc    []byte
+	record bool
+	err    error
+}
+
+// read returns the next n bits; n is at most 32.
+func (r *bitReader) read(n uint) uint64 {
+	for r.nacc < n {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			if r.err == nil {
+				r.err = io.ErrUnexpectedEOF
+			}
+			return 0
+		}
+		if r.record {
+			r.crc = append(r.crc, b)
+		}
+		r.acc = r.acc<<8 | uint64(b)
+		r.nacc += 8
+	}
+	r.nacc -= n
+	return r.acc >> r.nacc & (1<<n - 1)
+}
+
+// signed returns the next n bits as a two's complement value.
+func (r *bitReader) signed(n uint) int32 {
+	return int32(int64(r.read(n)<<(64-n)) >> (64 - n))
+}
+
+// unary returns the number of zero bits before the next one bit.
+func (r *bitReader) unary() uint64 {
+	var q uint64
+	for r.read(1) == 0 && r.err == nil {
+		q++
+	}
+	return q
+}
+
+// align skips to the next byte boundary and stops recording, so the
+// frame's own CRC is not part of it.
+func (r *bitReader) align() {
+	r.read(r.nacc % 8)
+	r.record = false
+}
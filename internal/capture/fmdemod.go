@@ -0,0 +1,124 @@
+package capture
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// iqSource delivers complex baseband samples from an SDR opened in-process.
+type iqSource interface {
+	// ReadIQ fills buf and returns how many samples it read. It returns
+	// 0, nil when none arrived within the driver's timeout.
+	ReadIQ(buf []complex64) (int, error)
+	Close() error
+}
+
+// minIFRate is the lowest rate IQ samples are decimated to before FM
+// demodulation. The APT signal occupies about 34 kHz, so 60 kHz keeps all
+// of it, as rtl_fm's own oversampling does.
+const minIFRate = 60000
+
+// pcmScale converts a phase step in half-turns to 16-bit PCM. It matches
+// rtl_fm's (1<<14)/pi per radian so recordings from either backend have
+// the same level.
+const pcmScale = 1 << 14
+
+// dcBlockTaps sets the time constant of the DC blocking filter, in IF
+// samples; a tuning offset shows up as DC after demodulation.
+const dcBlockTaps = 4096
+
+// fmDemod turns IQ samples into mono 16-bit PCM: boxcar decimation to an
+// IF rate, a polar discriminator, DC removal, and linear interpolation down
+// to the output rate. It keeps its state across calls so a stream can be
+// fed in blocks of any size.
+type fmDemod struct {
+	decim int     // IQ samples summed per IF sample
+	step  float64 // IF samples per output sample
+
+	n    int       // IQ samples in acc
+	acc  complex64 // running boxcar sum
+	prev complex64 // previous IF sample
+	dc   float64   // DC estimate
+	last float64   // previous demodulated sample
+	pos  float64   // offset of the next output sample after last, in IF samples
+}
+
+func newFMDemod(inRate, outRate int) *fmDemod {
+	decim := max(inRate/max(outRate, minIFRate), 1)
+	return &fmDemod{
+		decim: decim,
+		step:  float64(inRate) / float64(decim) / float64(outRate),
+	}
+}
+
+// process demodulates iq and appends the resulting little-endian PCM
+// samples to out.
+func (d *fmDemod) process(iq []complex64, out []byte) []byte {
+	for _, s := range iq {
+		d.acc += s
+		if d.n++; d.n < d.decim {
+			continue
+		}
+		z := d.acc
+		d.acc, d.n = 0, 0
+
+		p := z * complex(real(d.prev), -imag(d.prev))
+		d.prev = z
+		x := math.Atan2(float64(imag(p)), float64(real(p))) / math.Pi
+		d.dc += (x - d.dc) / dcBlockTaps
+		x -= d.dc
+
+		for ; d.pos < 1; d.pos += d.step {
+			out = appendPCM(out, d.last+(x-d.last)*d.pos)
+		}
+		d.pos--
+		d.last = x
+	}
+	return out
+}
+
+func appendPCM(out []byte, x float64) []byte {
+	v := math.Round(x * pcmScale)
+	v = math.Max(math.Min(v, math.MaxInt16), math.MinInt16)
+	return binary.LittleEndian.AppendUint16(out, uint16(int16(v)))
+}
+
+// pcmReader demodulates an iqSource on demand, so an in-process SDR can be
+// recorded by streamWithProgress just like rtl_fm's stdout. It reports EOF
+// once ctx is done.
+type pcmReader struct {
+	ctx   context.Context
+	src   iqSource
+	demod *fmDemod
+	iq    []complex64
+	buf   []byte
+	pcm   []byte // unread part of buf
+}
+
+func newPCMReader(ctx context.Context, src iqSource, inRate, outRate int) *pcmReader {
+	return &pcmReader{
+		ctx:   ctx,
+		src:   src,
+		demod: newFMDemod(inRate, outRate),
+		iq:    make([]complex64, 16384),
+	}
+}
+
+func (p *pcmReader) Read(b []byte) (int, error) {
+	for len(p.pcm) == 0 {
+		if p.ctx.Err() != nil {
+			return 0, io.EOF
+		}
+		n, err := p.src.ReadIQ(p.iq)
+		if err != nil {
+			return 0, err
+		}
+		p.buf = p.demod.process(p.iq[:n], p.buf[:0])
+		p.pcm = p.buf
+	}
+	n := copy(b, p.pcm)
+	p.pcm = p.pcm[n:]
+	return n, nil
+}
@@ -0,0 +1,58 @@
+package capture
+
+import (
+	"log/slog"
+	"os"
+	"time"
+)
+
+// periodicSyncer writes through to a capture file and flushes it to disk
+// every interval, per data.fsync_interval_seconds.
+type periodicSyncer struct {
+	f        *os.File
+	interval time.Duration
+	last     time.Time
+	log      *slog.Logger
+}
+
+func (p *periodicSyncer) Write(b []byte) (int, error) {
+	n, err := p.f.Write(b)
+	if time.Since(p.last) >= p.interval {
+		if syncErr := p.f.Sync(); syncErr != nil {
+			p.log.Error("fsync failed", "file", p.f.Name(), "err", syncErr)
+		}
+		p.last = time.Now()
+	}
+	return n, err
+}
+
+// syncDir flushes a directory so that files created or renamed in it
+// survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// writeFileSync is os.WriteFile, optionally syncing the file before it is
+// closed.
+func writeFileSync(path string, data []byte, sync bool) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if sync {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
@@ -0,0 +1,471 @@
+package capture
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ImportOptions configures Import.
+type ImportOptions struct {
+	Paths     []string // files or directories, walked recursively
+	Satellite string   // assumed for files whose names do not say
+	Move      bool     // move files into data.root instead of copying them
+	LocalTime bool     // timestamps in names are local time rather than UTC
+	DryRun    bool     // report what would be imported without touching anything
+	Sync      bool     // fsync imported files and sidecars
+}
+
+// ImportedCapture is a recording registered by Import.
+type ImportedCapture struct {
+	Source    string   `json:"source"`
+	Filename  string   `json:"filename"`
+	Satellite string   `json:"satellite"`
+	AOS       string   `json:"aos"`
+	Images    []string `json:"images,omitempty"`
+	// TimeFromMtime is set when the name carried no timestamp and AOS was
+	// worked out from the file's modification time and duration.
+	TimeFromMtime bool `json:"time_from_mtime,omitempty"`
+}
+
+// ImportSkip is a file Import left alone, and why.
+type ImportSkip struct {
+	Source string `json:"source"`
+	Reason string `json:"reason"`
+}
+
+// ImportResult lists what Import did with each file it found.
+type ImportResult struct {
+	Imported []ImportedCapture `json:"imported"`
+	Skipped  []ImportSkip      `json:"skipped"`
+}
+
+// imageAttachWindow is how far an image's timestamp may be from a
+// recording's AOS and still be taken as decoded from it. Tools name both
+// after the pass, but not always to the second.
+const imageAttachWindow = 2 * time.Minute
+
+var (
+	// importSatRe finds the satellite in names like "NOAA19-...",
+	// "noaa_18_..." or "NOAA-15_...".
+	importSatRe = regexp.MustCompile(`(?i)noaa[ _-]?(1[589])`)
+	// importTimeRe finds a timestamp like "20210306-093012",
+	// "20260215T143022Z" or "2021-03-06_09-30". Seconds are optional.
+	importTimeRe = regexp.MustCompile(`(\d{4})-?(\d{2})-?(\d{2})[T_ -]?(\d{2})[:-]?(\d{2})(?:[:-]?(\d{2}))?Z?`)
+)
+
+// importName is what could be read from an external file's name.
+type importName struct {
+	sat    *Satellite
+	ts     time.Time // zero if the name has no timestamp
+	suffix string    // whatever follows the timestamp, e.g. "MCIR"
+}
+
+// Import registers recordings and decoded images made by another tool in
+// the capture index. Satellite and time are parsed from file names on a
+// best-effort basis; recordings are copied (or moved) into root under the
+// usual SAT_YYYYMMDDTHHMMSSZ.wav name with a sidecar that records the
+// checksum and where the file came from. Images are attached to the
+// recording of the same pass, whether imported now or already in root;
+// an image with no recording to attach to is skipped.
+func Import(root string, opts ImportOptions) (ImportResult, error) {
+	res := ImportResult{Imported: []ImportedCapture{}, Skipped: []ImportSkip{}}
+	var fallback *Satellite
+	if opts.Satellite != "" {
+		if fallback = SatelliteByName(opts.Satellite); fallback == nil {
+			return res, fmt.Errorf("unknown satellite %q", opts.Satellite)
+		}
+	}
+	if !opts.DryRun {
+		if err := os.MkdirAll(root, 0o755); err != nil {
+			return res, err
+		}
+	}
+	absRoot, _ := filepath.Abs(root)
+	loc := time.UTC
+	if opts.LocalTime {
+		loc = time.Local
+	}
+
+	recordings, images := collectImportFiles(opts.Paths, absRoot, &res)
+
+	// Recordings first, so images can attach to them.
+	known := existingCaptures(root)
+	for _, src := range recordings {
+		name := parseImportName(filepath.Base(src), loc)
+		if name.sat == nil {
+			name.sat = fallback
+		}
+		if name.sat == nil {
+			res.Skipped = append(res.Skipped, ImportSkip{src, "satellite not in file name (set satellite)"})
+			continue
+		}
+		imp, aos, err := importRecording(root, src, name, opts)
+		if err != nil {
+			res.Skipped = append(res.Skipped, ImportSkip{src, err.Error()})
+			continue
+		}
+		res.Imported = append(res.Imported, imp)
+		known = append(known, knownCapture{name.sat.Name, aos, imp.Filename, len(res.Imported) - 1})
+	}
+
+	for _, src := range images {
+		name := parseImportName(filepath.Base(src), loc)
+		if name.sat == nil {
+			name.sat = fallback
+		}
+		if name.sat == nil || name.ts.IsZero() {
+			res.Skipped = append(res.Skipped, ImportSkip{src, "cannot tell which pass the image is from"})
+			continue
+		}
+		kc, ok := matchCapture(known, name.sat.Name, name.ts)
+		if !ok {
+			res.Skipped = append(res.Skipped, ImportSkip{src, "no recording of this pass to attach it to"})
+			continue
+		}
+		dst, err := importImage(root, src, kc.filename, name.suffix, opts)
+		if err != nil {
+			res.Skipped = append(res.Skipped, ImportSkip{src, err.Error()})
+			continue
+		}
+		if kc.imported >= 0 {
+			res.Imported[kc.imported].Images = append(res.Imported[kc.imported].Images, dst)
+		}
+	}
+	return res, nil
+}
+
+// collectImportFiles walks paths for WAV recordings and PNG/JPEG images,
+// in name order. Other files are ignored; files already under root and
+// paths that cannot be read are reported as skipped.
+func collectImportFiles(paths []string, absRoot string, res *ImportResult) (recordings, images []string) {
+	seen := map[string]bool{}
+	for _, p := range paths {
+		err := filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				res.Skipped = append(res.Skipped, ImportSkip{path, err.Error()})
+				return nil
+			}
+			if d.IsDir() || strings.HasPrefix(d.Name(), ".") || !d.Type().IsRegular() {
+				return nil
+			}
+			abs, _ := filepath.Abs(path)
+			if seen[abs] {
+				return nil
+			}
+			seen[abs] = true
+			kind := strings.ToLower(filepath.Ext(path))
+			if kind != ".wav" && kind != ".png" && kind != ".jpg" && kind != ".jpeg" {
+				return nil
+			}
+			if filepath.Dir(abs) == absRoot {
+				res.Skipped = append(res.Skipped, ImportSkip{abs, "already in data.root"})
+				return nil
+			}
+			if kind == ".wav" {
+				recordings = append(recordings, abs)
+			} else {
+				images = append(images, abs)
+			}
+			return nil
+		})
+		if err != nil {
+			res.Skipped = append(res.Skipped, ImportSkip{p, err.Error()})
+		}
+	}
+	sort.Strings(recordings)
+	sort.Strings(images)
+	return recordings, images
+}
+
+// parseImportName reads the satellite, start time and trailing suffix from
+// an external file name. Any of them may be missing.
+func parseImportName(base string, loc *time.Location) importName {
+	var n importName
+	rest := strings.TrimSuffix(base, filepath.Ext(base))
+	if m := importSatRe.FindStringSubmatchIndex(rest); m != nil {
+		n.sat = SatelliteByName("NOAA-" + rest[m[2]:m[3]])
+		// Drop the satellite so its number is not read as part of a date.
+		rest = rest[:m[0]] + " " + rest[m[1]:]
+	}
+	if m := importTimeRe.FindStringSubmatchIndex(rest); m != nil {
+		sec := "00"
+		if m[12] >= 0 {
+			sec = rest[m[12]:m[13]]
+		}
+		stamp := rest[m[2]:m[3]] + rest[m[4]:m[5]] + rest[m[6]:m[7]] + rest[m[8]:m[9]] + rest[m[10]:m[11]] + sec
+		if ts, err := time.ParseInLocation("20060102150405", stamp, loc); err == nil {
+			n.ts = ts
+			n.suffix = strings.Trim(rest[m[1]:], " _-.")
+		}
+	}
+	return n
+}
+
+// knownCapture is a recording in the index that images can attach to.
+// imported is its index in ImportResult.Imported, or -1 if it was already
+// in data.root.
+type knownCapture struct {
+	sat      string
+	aos      time.Time
+	filename string
+	imported int
+}
+
+// existingCaptures lists the recordings already in root.
+func existingCaptures(root string) []knownCapture {
+	matches := Recordings(root)
+	out := make([]knownCapture, 0, len(matches))
+	for _, m := range matches {
+		name := strings.TrimSuffix(filepath.Base(m), filepath.Ext(m))
+		idx := strings.LastIndex(name, "_")
+		if idx < 0 {
+			continue
+		}
+		ts, err := time.Parse("20060102T150405Z", name[idx+1:])
+		if err != nil {
+			continue
+		}
+		out = append(out, knownCapture{name[:idx], ts, filepath.Base(m), -1})
+	}
+	return out
+}
+
+// matchCapture returns the recording of sat whose AOS is nearest ts, if
+// one is within imageAttachWindow.
+func matchCapture(known []knownCapture, sat string, ts time.Time) (knownCapture, bool) {
+	var best knownCapture
+	bestGap := time.Duration(math.MaxInt64)
+	for _, kc := range known {
+		if kc.sat != sat {
+			continue
+		}
+		gap := kc.aos.Sub(ts)
+		if gap < 0 {
+			gap = -gap
+		}
+		if gap <= imageAttachWindow && gap < bestGap {
+			best, bestGap = kc, gap
+		}
+	}
+	return best, bestGap <= imageAttachWindow
+}
+
+// importRecording copies or moves one external recording into root and
+// writes its sidecar. It also returns the recording's AOS.
+func importRecording(root, src string, name importName, opts ImportOptions) (ImportedCapture, time.Time, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return ImportedCapture{}, time.Time{}, err
+	}
+	wi, err := readWAVInfo(src)
+	if err != nil {
+		return ImportedCapture{}, time.Time{}, err
+	}
+
+	aos := name.ts
+	fromMtime := aos.IsZero()
+	if fromMtime {
+		// The file was last written at LOS.
+		aos = info.ModTime().Add(-wi.duration).Truncate(time.Second)
+	}
+	filename := fmt.Sprintf("%s_%s.wav", name.sat.Name, aos.UTC().Format("20060102T150405Z"))
+	dst := filepath.Join(root, filename)
+	imp := ImportedCapture{
+		Source:        src,
+		Filename:      filename,
+		Satellite:     name.sat.Name,
+		AOS:           aos.UTC().Format(time.RFC3339),
+		TimeFromMtime: fromMtime,
+	}
+	if _, err := os.Stat(dst); err == nil {
+		return imp, time.Time{}, fmt.Errorf("%s is already in the index", filename)
+	}
+	if opts.DryRun {
+		return imp, aos, nil
+	}
+
+	if opts.Move {
+		err = moveCapture(src, dst)
+	} else {
+		err = copyFile(src, dst, opts.Sync)
+	}
+	if err != nil {
+		return imp, time.Time{}, err
+	}
+	sum, err := FileSHA256(dst)
+	if err != nil {
+		return imp, time.Time{}, err
+	}
+	m := Metadata{
+		Satellite:    name.sat.Name,
+		NoradID:      name.sat.NoradID,
+		FreqHz:       name.sat.Freq,
+		AOS:          aos.UTC().Format(time.RFC3339),
+		LOS:          aos.Add(wi.duration).UTC().Format(time.RFC3339),
+		SampleRate:   wi.sampleRate,
+		Bytes:        wi.dataBytes,
+		SHA256:       sum,
+		RecordedAt:   info.ModTime().UTC().Format(time.RFC3339),
+		ImportedFrom: src,
+	}
+	if err := WriteMetadata(dst, m, opts.Sync); err != nil {
+		return imp, time.Time{}, err
+	}
+	return imp, aos, nil
+}
+
+// importImage copies or moves an image beside the recording it was
+// decoded from, as "<recording>-<suffix>.<ext>", and lists it in the
+// recording's sidecar. It returns the new file name.
+func importImage(root, src, recording, suffix string, opts ImportOptions) (string, error) {
+	if suffix == "" {
+		suffix = "image"
+	}
+	ext := strings.ToLower(filepath.Ext(src))
+	if ext == ".jpeg" {
+		ext = ".jpg"
+	}
+	filename := strings.TrimSuffix(recording, filepath.Ext(recording)) + "-" + suffix + ext
+	dst := filepath.Join(root, filename)
+	if _, err := os.Stat(dst); err == nil {
+		return "", fmt.Errorf("%s is already in the index", filename)
+	}
+	if opts.DryRun {
+		return filename, nil
+	}
+
+	var err error
+	if opts.Move {
+		err = moveCapture(src, dst)
+	} else {
+		err = copyFile(src, dst, opts.Sync)
+	}
+	if err != nil {
+		return "", err
+	}
+	recPath := filepath.Join(root, recording)
+	m, err := ReadMetadata(recPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return filename, err
+	}
+	m.Images = append(m.Images, filename)
+	return filename, WriteMetadata(recPath, m, opts.Sync)
+}
+
+// copyFile copies src to dst through a temp file, so an interrupted
+// import never leaves a partial file under its final name.
+func copyFile(src, dst string, sync bool) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if sync {
+		if err := out.Sync(); err != nil {
+			out.Close()
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// wavInfo is what Import needs from a WAV header.
+type wavInfo struct {
+	sampleRate int
+	dataBytes  int64
+	duration   time.Duration
+}
+
+// readWAVInfo reads the sample rate and audio length of any PCM WAV file,
+// not just the 16-bit mono layout this package writes. A data chunk whose
+// size was never filled in (as streaming tools leave it) runs to the end
+// of the file.
+func readWAVInfo(path string) (wavInfo, error) {
+	var wi wavInfo
+	f, err := os.Open(path)
+	if err != nil {
+		return wi, err
+	}
+	defer f.Close()
+	st, err := f.Stat()
+	if err != nil {
+		return wi, err
+	}
+
+	var riff [12]byte
+	if _, err := io.ReadFull(f, riff[:]); err != nil || string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return wi, errors.New("not a WAV file")
+	}
+	var blockAlign int
+	offset := int64(12)
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(f, hdr[:]); err != nil {
+			return wi, errors.New("WAV file has no data chunk")
+		}
+		offset += 8
+		id := string(hdr[0:4])
+		size := int64(binary.LittleEndian.Uint32(hdr[4:8]))
+		switch id {
+		case "fmt ":
+			var fmtChunk [16]byte
+			if size < 16 {
+				return wi, errors.New("WAV fmt chunk too short")
+			}
+			if _, err := io.ReadFull(f, fmtChunk[:]); err != nil {
+				return wi, err
+			}
+			wi.sampleRate = int(binary.LittleEndian.Uint32(fmtChunk[4:8]))
+			blockAlign = int(binary.LittleEndian.Uint16(fmtChunk[12:14]))
+			if _, err := f.Seek(offset+size+size%2, io.SeekStart); err != nil {
+				return wi, err
+			}
+		case "data":
+			if wi.sampleRate <= 0 || blockAlign <= 0 {
+				return wi, errors.New("WAV data chunk before fmt chunk")
+			}
+			if rest := st.Size() - offset; size == 0 || size == 0xFFFFFFFF || size > rest {
+				size = rest
+			}
+			wi.dataBytes = size
+			frames := size / int64(blockAlign)
+			wi.duration = time.Duration(frames) * time.Second / time.Duration(wi.sampleRate)
+			return wi, nil
+		default:
+			if _, err := f.Seek(offset+size+size%2, io.SeekStart); err != nil {
+				return wi, err
+			}
+		}
+		offset += size + size%2
+	}
+}
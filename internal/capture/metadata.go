@@ -0,0 +1,181 @@
+package capture
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Metadata is the JSON sidecar written beside each capture, recording what
+// was received and from where. For "NOAA-19_20260215T143022Z.wav" it is
+// "NOAA-19_20260215T143022Z.json".
+type Metadata struct {
+	Satellite    string  `json:"satellite"`
+	NoradID      int     `json:"norad_id"`
+	FreqHz       int     `json:"freq_hz"`                  // as tuned, including any offset
+	FreqOffsetHz int     `json:"freq_offset_hz,omitempty"` // included in FreqHz
+	BandCenterHz int     `json:"band_center_hz,omitempty"` // dongle tuning in a band capture
+	AOS          string  `json:"aos"`
+	LOS          string  `json:"los"`
+	MaxElev      float64 `json:"max_elev"`
+	Station      string  `json:"station,omitempty"` // station profile, if any
+	Latitude     float64 `json:"latitude"`
+	Longitude    float64 `json:"longitude"`
+	Altitude     float64 `json:"altitude"`
+	CloudCover   *int    `json:"cloud_cover,omitempty"` // forecast, percent
+	SampleRate   int     `json:"sample_rate"`
+	Simulated    bool    `json:"simulated"`
+	Bytes        int64   `json:"bytes"`
+	SHA256       string  `json:"sha256,omitempty"` // of the recording as stored
+	RecordedAt   string  `json:"recorded_at"`
+	// Compression is the format the recording was transcoded to once
+	// decoded, per data.compress, and WAVBytes the size of the WAV it
+	// replaced; Bytes and SHA256 are then those of the compressed file.
+	Compression string `json:"compression,omitempty"`
+	WAVBytes    int64  `json:"wav_bytes,omitempty"`
+	// Corrupt is set by the integrity scrub when the file no longer
+	// matches SHA256, and cleared if a later scrub finds it intact.
+	Corrupt bool `json:"corrupt,omitempty"`
+	// ImportedFrom is the original path of a capture brought in by Import
+	// from another tool; such captures have no station or elevation.
+	ImportedFrom string   `json:"imported_from,omitempty"`
+	Images       []string `json:"images,omitempty"` // decoded images, relative to data.root
+	Tags         []string `json:"tags,omitempty"`   // set by the operator or plugins
+	// Decode records how the last decode of the capture went; captures
+	// decoded before it was kept have none.
+	Decode *DecodeInfo `json:"decode,omitempty"`
+}
+
+// Decode states of a capture.
+const (
+	DecodePending = "pending" // not decoded yet
+	DecodeDone    = "done"
+	DecodeFailed  = "failed"
+	DecodeSkipped = "skipped" // the satellite's mode is not decoded
+)
+
+// DecodeInfo records how decoding a capture went. SNR is in dB, measured
+// on the sync pulses, and Score rates the image from 0 to 100.
+type DecodeInfo struct {
+	Status      string  `json:"status"`
+	Error       string  `json:"error,omitempty"`
+	Lines       int     `json:"lines,omitempty"`
+	SyncPercent int     `json:"sync_percent,omitempty"`
+	SNR         float64 `json:"snr_db,omitempty"`
+	Score       int     `json:"image_score,omitempty"`
+	At          string  `json:"decoded_at,omitempty"`
+}
+
+// DecodeStatus is the decode state of the capture: that of Decode, or for
+// a sidecar without one, done if it lists images and pending if not.
+func (m Metadata) DecodeStatus() string {
+	switch {
+	case m.Decode != nil:
+		return m.Decode.Status
+	case len(m.Images) > 0:
+		return DecodeDone
+	}
+	return DecodePending
+}
+
+// RecordingExts are the extensions of the recordings in data.root: WAVs as
+// captured, and FLACs once compressed per data.compress.
+var RecordingExts = []string{".wav", ".flac"}
+
+// Recordings returns the paths of the recordings in dir, sorted.
+func Recordings(dir string) []string {
+	var out []string
+	for _, ext := range RecordingExts {
+		matches, _ := filepath.Glob(filepath.Join(dir, "*"+ext))
+		out = append(out, matches...)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// ResolveRecording returns path, or, when path names a recording that is
+// not there, a sibling under another of RecordingExts that is. That way a
+// capture asked for by its WAV name is still found once compressed.
+func ResolveRecording(path string) string {
+	ext := filepath.Ext(path)
+	if !slices.Contains(RecordingExts, ext) {
+		return path
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return path
+	}
+	base := strings.TrimSuffix(path, ext)
+	for _, other := range RecordingExts {
+		if _, err := os.Stat(base + other); other != ext && err == nil {
+			return base + other
+		}
+	}
+	return path
+}
+
+// MetadataPath returns the sidecar path for a capture file.
+func MetadataPath(capturePath string) string {
+	return strings.TrimSuffix(capturePath, filepath.Ext(capturePath)) + ".json"
+}
+
+// ReadMetadata loads the sidecar for a capture file. Captures made before
+// sidecars existed have none; callers should treat os.ErrNotExist as
+// "unknown" rather than an error.
+func ReadMetadata(capturePath string) (Metadata, error) {
+	var m Metadata
+	b, err := os.ReadFile(MetadataPath(capturePath))
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(b, &m)
+	return m, err
+}
+
+// writeMetadata records the sidecar for a finished capture.
+func (r *Runner) writeMetadata(capturePath string, req CaptureRequest, bytesWritten int64, sum string) error {
+	m := Metadata{
+		Satellite:    req.Satellite.Name,
+		NoradID:      req.Satellite.NoradID,
+		FreqHz:       req.TunedFreq(),
+		FreqOffsetHz: req.FreqOffsetHz,
+		BandCenterHz: req.BandCenterHz,
+		AOS:          req.AOS.UTC().Format(time.RFC3339),
+		LOS:          req.LOS.UTC().Format(time.RFC3339),
+		MaxElev:      req.MaxElev,
+		Station:      req.Station,
+		Latitude:     r.Cfg.Station.Latitude,
+		Longitude:    r.Cfg.Station.Longitude,
+		Altitude:     r.Cfg.Station.Altitude,
+		CloudCover:   req.CloudCover,
+		SampleRate:   r.Cfg.SDR.SampleRate,
+		Simulated:    r.Simulate,
+		Bytes:        bytesWritten,
+		SHA256:       sum,
+		RecordedAt:   time.Now().UTC().Format(time.RFC3339),
+	}
+	return WriteMetadata(capturePath, m, r.Cfg.Data.FsyncOnFinalize)
+}
+
+// WriteMetadata replaces the sidecar for a capture file. It is written to a
+// temp file, optionally synced, and renamed so readers never see a partial
+// document.
+func WriteMetadata(capturePath string, m Metadata, sync bool) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := MetadataPath(capturePath)
+	tmp := path + ".tmp"
+	if err := writeFileSync(tmp, append(b, '\n'), sync); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
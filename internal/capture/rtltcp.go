@@ -0,0 +1,168 @@
+package capture
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// rtl_tcp commands: a command byte followed by a big-endian uint32.
+const (
+	rtlTCPSetFreq       = 0x01
+	rtlTCPSetSampleRate = 0x02
+	rtlTCPSetGainMode   = 0x03 // 0 automatic, 1 manual
+	rtlTCPSetGain       = 0x04 // tenths of a dB
+	rtlTCPSetFreqCorr   = 0x05 // ppm
+)
+
+const (
+	// rtlTCPDialTimeout bounds connecting to the server.
+	rtlTCPDialTimeout = 5 * time.Second
+	// rtlTCPGreetTimeout is how long the server has to send its header.
+	// rtl_tcp serves one client at a time and leaves others waiting
+	// unanswered, so silence means the radio is busy.
+	rtlTCPGreetTimeout = 5 * time.Second
+	// rtlTCPReadTimeout bounds each read, so a stalled link cannot hold
+	// the capture past LOS.
+	rtlTCPReadTimeout = time.Second
+)
+
+// u8Levels maps an unsigned 8-bit RTL-SDR sample to -1..1.
+var u8Levels = func() (t [256]float32) {
+	for i := range t {
+		t[i] = (float32(i) - 127.5) / 127.5
+	}
+	return t
+}()
+
+// u8IQ converts the interleaved unsigned 8-bit IQ that rtl_tcp and rtl_sdr
+// send.
+type u8IQ struct {
+	raw []byte
+	// An odd byte left over from the last read, the I half of a sample.
+	half    byte
+	hasHalf bool
+}
+
+// read fills buf from a single call to read and returns how many samples
+// it got, along with read's error.
+func (u *u8IQ) read(buf []complex64, read func([]byte) (int, error)) (int, error) {
+	if cap(u.raw) < 2*len(buf) {
+		u.raw = make([]byte, 2*len(buf))
+	}
+	raw := u.raw[:2*len(buf)]
+	n := 0
+	if u.hasHalf {
+		raw[0], n = u.half, 1
+	}
+	m, err := read(raw[n:])
+	n += m
+
+	samples := n / 2
+	for i := range samples {
+		buf[i] = complex(u8Levels[raw[2*i]], u8Levels[raw[2*i+1]])
+	}
+	u.hasHalf = n%2 == 1
+	if u.hasHalf {
+		u.half = raw[n-1]
+	}
+	return samples, err
+}
+
+// rtlTCPSource streams IQ samples from an rtl_tcp server.
+type rtlTCPSource struct {
+	conn net.Conn
+	u8   u8IQ
+}
+
+// rtlTCPAvailable reports whether the rtl_tcp server at addr accepts
+// connections.
+func rtlTCPAvailable(addr string) error {
+	conn, err := net.DialTimeout("tcp", addr, rtlTCPDialTimeout)
+	if err != nil {
+		return fmt.Errorf("rtl_tcp server %s: %w", addr, err)
+	}
+	return conn.Close()
+}
+
+// openRtlTCP connects to the rtl_tcp server named by sdr.tcp_addr and
+// tunes it to freq.
+func openRtlTCP(sdr config.SDRConfig, freq int) (iqSource, error) {
+	conn, err := net.DialTimeout("tcp", sdr.TCPAddr, rtlTCPDialTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("rtl_tcp: %w", err)
+	}
+
+	// "RTL0", then the tuner type and its number of gain steps.
+	var hdr [12]byte
+	_ = conn.SetReadDeadline(time.Now().Add(rtlTCPGreetTimeout))
+	if _, err := io.ReadFull(conn, hdr[:]); err != nil {
+		conn.Close()
+		var ne net.Error
+		if errors.As(err, &ne) && ne.Timeout() {
+			return nil, fmt.Errorf("%w: rtl_tcp server %s did not answer; another client is probably connected", errSDRBusy, sdr.TCPAddr)
+		}
+		return nil, fmt.Errorf("rtl_tcp: read header: %w", err)
+	}
+	if !bytes.Equal(hdr[:4], []byte("RTL0")) {
+		conn.Close()
+		return nil, fmt.Errorf("rtl_tcp: %s is not an rtl_tcp server", sdr.TCPAddr)
+	}
+
+	cmds := [][2]uint32{
+		{rtlTCPSetSampleRate, uint32(sdr.TCPSampleRate)},
+		{rtlTCPSetFreqCorr, uint32(int32(sdr.PPMCorrection))},
+		{rtlTCPSetFreq, uint32(freq)},
+		{rtlTCPSetGainMode, 1},
+		{rtlTCPSetGain, uint32(int32(sdr.Gain * 10))},
+	}
+	for _, c := range cmds {
+		var b [5]byte
+		b[0] = byte(c[0])
+		binary.BigEndian.PutUint32(b[1:], c[1])
+		if _, err := conn.Write(b[:]); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("rtl_tcp: send command: %w", err)
+		}
+	}
+	return &rtlTCPSource{conn: conn}, nil
+}
+
+func (s *rtlTCPSource) ReadIQ(buf []complex64) (int, error) {
+	_ = s.conn.SetReadDeadline(time.Now().Add(rtlTCPReadTimeout))
+	n, err := s.u8.read(buf, s.conn.Read)
+	if err != nil {
+		var ne net.Error
+		if !errors.As(err, &ne) || !ne.Timeout() {
+			return 0, fmt.Errorf("rtl_tcp: %w", err)
+		}
+	}
+	return n, nil
+}
+
+func (s *rtlTCPSource) Close() error {
+	return s.conn.Close()
+}
+
+// runRtlTCP records once from an rtl_tcp server, demodulating FM
+// in-process. It returns errSDRBusy while another client has the server.
+func (r *Runner) runRtlTCP(ctx context.Context, f io.Writer, req CaptureRequest) (int64, error) {
+	losCtx, losCancel := context.WithDeadline(ctx, req.LOS)
+	defer losCancel()
+
+	src, err := openRtlTCP(r.Cfg.SDR, req.TunedFreq())
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+
+	pcm := newPCMReader(losCtx, src, r.Cfg.SDR.TCPSampleRate, r.Cfg.SDR.SampleRate)
+	return r.streamWithProgress(losCtx, f, pcm, req, req.LOS.Sub(req.AOS)), nil
+}
@@ -2,30 +2,136 @@
 // from a real RTL-SDR dongle or via synthetic tone generation for testing.
 package capture
 
-import "strings"
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
 
-// Satellite describes a NOAA APT bird: its common name, NORAD catalog
-// number, and downlink frequency in hertz.
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// Downlink modes. Only APT recordings are decoded into images.
+const (
+	ModeAPT  = "apt"
+	ModeLRPT = "lrpt"
+)
+
+// Satellite describes a weather satellite: its common name, NORAD catalog
+// number, downlink frequency in hertz, and downlink mode.
 type Satellite struct {
 	Name    string
 	NoradID int
-	Freq    int // downlink frequency in Hz
+	Freq    int    // downlink frequency in Hz
+	Mode    string // ModeAPT or ModeLRPT
+	// Status is the satellite's SatNOGS DB status, such as "alive" or
+	// "dead", when the catalog is synced.
+	Status string
+	// CatalogFreq is the configured downlink frequency when a synced
+	// transmitter has replaced it in Freq, and 0 otherwise.
+	CatalogFreq int
+}
+
+// Synced is what a catalog sync learned about one satellite.
+type Synced struct {
+	Status string
+	Freq   int // active downlink in the satellite's mode; 0 if none found
 }
 
-// Satellites is the catalog of active NOAA APT satellites. All three
-// transmit on frequencies in the 137 MHz VHF band.
+// Satellites is the built-in catalog of active NOAA APT satellites. All
+// three transmit on frequencies in the 137 MHz VHF band. Catalog adds the
+// satellites defined in the config.
 var Satellites = []Satellite{
-	{Name: "NOAA-15", NoradID: 25338, Freq: 137620000},
-	{Name: "NOAA-18", NoradID: 28654, Freq: 137912500},
-	{Name: "NOAA-19", NoradID: 33591, Freq: 137100000},
+	{Name: "NOAA-15", NoradID: 25338, Freq: 137620000, Mode: ModeAPT},
+	{Name: "NOAA-18", NoradID: 28654, Freq: 137912500, Mode: ModeAPT},
+	{Name: "NOAA-19", NoradID: 33591, Freq: 137100000, Mode: ModeAPT},
+}
+
+var (
+	catalogMu sync.RWMutex
+	added     []Satellite    // from [satellites.NAME] tables with norad_id set
+	synced    map[int]Synced // by NORAD ID, from the last catalog sync
+)
+
+// Catalog returns the built-in satellites followed by those added in the
+// config in name order, with the results of the last catalog sync applied.
+func Catalog() []Satellite {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	out := make([]Satellite, 0, len(Satellites)+len(added))
+	out = append(out, Satellites...)
+	out = append(out, added...)
+	for i, s := range out {
+		sy, ok := synced[s.NoradID]
+		if !ok {
+			continue
+		}
+		out[i].Status = sy.Status
+		if sy.Freq != 0 && sy.Freq != s.Freq {
+			out[i].CatalogFreq = s.Freq
+			out[i].Freq = sy.Freq
+		}
+	}
+	return out
+}
+
+// SetSynced replaces the catalog sync results, keyed by NORAD ID. nil
+// drops them, returning every satellite to its configured frequency.
+func SetSynced(m map[int]Synced) {
+	catalogMu.Lock()
+	synced = m
+	catalogMu.Unlock()
+}
+
+// SetCatalog replaces the added satellites with those defined in cfg. An
+// entry that reuses a built-in name or NORAD ID is skipped and reported
+// in the returned errors; the built-in wins.
+func SetCatalog(cfg config.Config) []error {
+	var sats []Satellite
+	var errs []error
+	for name, s := range cfg.Satellites {
+		if s.NoradID == 0 {
+			continue
+		}
+		if b := builtin(name, s.NoradID); b != nil {
+			errs = append(errs, fmt.Errorf("satellites.%s: %s (NORAD %d) is built in", name, b.Name, b.NoradID))
+			continue
+		}
+		mode := s.Mode
+		if mode == "" {
+			mode = ModeAPT
+		}
+		sats = append(sats, Satellite{Name: name, NoradID: s.NoradID, Freq: s.FreqHz, Mode: mode})
+	}
+	sort.Slice(sats, func(i, j int) bool { return sats[i].Name < sats[j].Name })
+
+	catalogMu.Lock()
+	added = sats
+	catalogMu.Unlock()
+	return errs
+}
+
+// IsBuiltin reports whether the satellite named name is in the built-in
+// catalog rather than added in the config.
+func IsBuiltin(name string) bool {
+	return builtin(name, 0) != nil
+}
+
+func builtin(name string, noradID int) *Satellite {
+	for i, s := range Satellites {
+		if strings.EqualFold(s.Name, name) || s.NoradID == noradID {
+			return &Satellites[i]
+		}
+	}
+	return nil
 }
 
 // SatelliteByNoradID returns the satellite with the given NORAD catalog ID,
 // or nil if not found.
 func SatelliteByNoradID(id int) *Satellite {
-	for i := range Satellites {
-		if Satellites[i].NoradID == id {
-			return &Satellites[i]
+	for _, s := range Catalog() {
+		if s.NoradID == id {
+			return &s
 		}
 	}
 	return nil
@@ -34,10 +140,9 @@ func SatelliteByNoradID(id int) *Satellite {
 // SatelliteByName returns the satellite with the given name (case-insensitive),
 // or nil if not found.
 func SatelliteByName(name string) *Satellite {
-	upper := strings.ToUpper(name)
-	for i := range Satellites {
-		if strings.ToUpper(Satellites[i].Name) == upper {
-			return &Satellites[i]
+	for _, s := range Catalog() {
+		if strings.EqualFold(s.Name, name) {
+			return &s
 		}
 	}
 	return nil
@@ -0,0 +1,258 @@
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// sdrRetryInterval is how often a busy SDR is checked again before LOS.
+const sdrRetryInterval = 10 * time.Second
+
+// killGrace is how long a competing process has to exit after SIGTERM
+// before it is sent SIGKILL.
+const killGrace = 5 * time.Second
+
+// errSDRBusy reports that the capture backend could not claim the device.
+var errSDRBusy = errors.New("SDR device is in use")
+
+// sdrHolder is a process found holding, or likely holding, the SDR.
+type sdrHolder struct {
+	PID  int
+	Name string
+}
+
+func (h sdrHolder) String() string {
+	if h.PID == 0 {
+		return h.Name
+	}
+	return fmt.Sprintf("%s (pid %d)", h.Name, h.PID)
+}
+
+// sdrLockPath returns the lock file for an RTL-SDR device index. It lives
+// in the system temp directory rather than under data.root so that daemons
+// with different data roots still exclude each other.
+func sdrLockPath(device int) string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("ephemeris-sdr%d.lock", device))
+}
+
+// lockSDR takes an exclusive, non-blocking flock on the device's lock file
+// and records our pid in it. If another process holds the lock it returns
+// that process as the holder. The lock is released when the file is closed,
+// including when the process dies.
+//
+// The temp directory is shared with other users, so the file is opened
+// without following symlinks and checked with checkLockFile before it is
+// truncated; a file planted by someone else is an error, not a target.
+func lockSDR(device int) (*os.File, *sdrHolder, error) {
+	path := sdrLockPath(device)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|syscall.O_NOFOLLOW, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open sdr lock: %w", err)
+	}
+	if err := checkLockFile(f); err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("sdr lock %s: %w", path, err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		b := make([]byte, 16)
+		n, _ := f.ReadAt(b, 0)
+		b = b[:n]
+		f.Close()
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		pid, _ := strconv.Atoi(strings.TrimSpace(string(b)))
+		return nil, &sdrHolder{PID: pid, Name: "ephemerisd"}, nil
+	}
+	_ = f.Truncate(0)
+	_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return f, nil, nil
+}
+
+// checkLockFile makes sure an opened lock file is a regular file that we
+// own and that has no other links, so it cannot be another file reached
+// through a hard link.
+func checkLockFile(f *os.File) error {
+	var st syscall.Stat_t
+	if err := syscall.Fstat(int(f.Fd()), &st); err != nil {
+		return err
+	}
+	switch {
+	case st.Mode&syscall.S_IFMT != syscall.S_IFREG:
+		return errors.New("not a regular file")
+	case int(st.Uid) != os.Geteuid():
+		return fmt.Errorf("owned by uid %d, not this user", st.Uid)
+	case st.Nlink != 1:
+		return errors.New("has other hard links")
+	}
+	return nil
+}
+
+// findCompeting returns running processes whose name is in names. It reads
+// /proc, so it finds nothing on systems without one. Kernel process names
+// are cut to 15 bytes, and names are compared the same way.
+func findCompeting(names []string) []sdrHolder {
+	if len(names) == 0 {
+		return nil
+	}
+	want := make(map[string]bool, len(names))
+	for _, n := range names {
+		if len(n) > 15 {
+			n = n[:15]
+		}
+		want[n] = true
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+	self := os.Getpid()
+	var holders []sdrHolder
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		comm, err := os.ReadFile(filepath.Join("/proc", e.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		if name := strings.TrimSpace(string(comm)); want[name] {
+			holders = append(holders, sdrHolder{PID: pid, Name: name})
+		}
+	}
+	return holders
+}
+
+// terminate sends SIGTERM to each process, then SIGKILL to any still
+// running after killGrace.
+func terminate(procs []sdrHolder) {
+	for _, p := range procs {
+		_ = syscall.Kill(p.PID, syscall.SIGTERM)
+	}
+	deadline := time.Now().Add(killGrace)
+	for _, p := range procs {
+		for time.Now().Before(deadline) && syscall.Kill(p.PID, 0) == nil {
+			time.Sleep(200 * time.Millisecond)
+		}
+		if syscall.Kill(p.PID, 0) == nil {
+			_ = syscall.Kill(p.PID, syscall.SIGKILL)
+		}
+	}
+}
+
+// claimSDR makes sure nothing else is using the dongle before a capture.
+// It takes the device lock and looks for the processes listed in
+// sdr.competing_processes. While the SDR is held it emits an sdr_busy
+// event and retries every sdrRetryInterval until the pass LOS, so a pass
+// still records if the other program exits partway through. With
+// sdr.kill_competing set, listed processes are terminated instead; another
+// ephemerisd holding the lock is always waited for. The returned func
+// releases the lock.
+func (r *Runner) claimSDR(ctx context.Context, req CaptureRequest) (func(), error) {
+	device := r.Cfg.SDR.DeviceIndex
+	announced := ""
+	for {
+		lock, holder, err := lockSDR(device)
+		if err != nil {
+			// A lock we cannot create must not cost a pass.
+			r.Log.Warn("continuing without the device lock", "device", device, "err", err)
+		}
+		release := func() {
+			if lock != nil {
+				lock.Close()
+			}
+		}
+
+		var holders []sdrHolder
+		if holder != nil {
+			holders = []sdrHolder{*holder}
+		} else if procs := findCompeting(r.Cfg.SDR.CompetingProcesses); len(procs) > 0 {
+			if r.Cfg.SDR.KillCompeting {
+				r.emitSDRBusy(req, procs, "killing")
+				terminate(procs)
+				// Anything left could not be killed (another user's
+				// process, say) and is waited for like any other.
+				procs = findCompeting(r.Cfg.SDR.CompetingProcesses)
+			}
+			holders = procs
+		}
+
+		if len(holders) == 0 {
+			if announced != "" {
+				r.broadcast(map[string]any{
+					"type":    "log",
+					"level":   "info",
+					"message": fmt.Sprintf("SDR device %d is free again, starting capture", device),
+				})
+			}
+			return release, nil
+		}
+		release()
+
+		key := fmt.Sprint(holders)
+		if key != announced {
+			r.emitSDRBusy(req, holders, "waiting")
+			announced = key
+		}
+		if !r.waitSDRRetry(ctx, req) {
+			return nil, fmt.Errorf("SDR device %d busy until LOS: held by %s", device, joinHolders(holders))
+		}
+	}
+}
+
+// waitSDRRetry sleeps until the next claim attempt. It returns false when
+// the context is done or the next attempt would be past LOS.
+func (r *Runner) waitSDRRetry(ctx context.Context, req CaptureRequest) bool {
+	if time.Now().Add(sdrRetryInterval).After(req.LOS) {
+		return false
+	}
+	t := time.NewTimer(sdrRetryInterval)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+func (r *Runner) emitSDRBusy(req CaptureRequest, holders []sdrHolder, action string) {
+	r.broadcast(map[string]any{
+		"type":        "sdr_busy",
+		"device":      r.Cfg.SDR.DeviceIndex,
+		"satellite":   req.Satellite.Name,
+		"holders":     holderNames(holders),
+		"action":      action,
+		"retry_until": req.LOS.UTC().Format(time.RFC3339),
+	})
+	verb := "waiting until it is free"
+	if action == "killing" {
+		verb = "terminating it (sdr.kill_competing)"
+	}
+	r.broadcast(map[string]any{
+		"type":    "log",
+		"level":   "warn",
+		"message": fmt.Sprintf("SDR device %d is in use by %s; %s", r.Cfg.SDR.DeviceIndex, joinHolders(holders), verb),
+	})
+}
+
+func holderNames(holders []sdrHolder) []string {
+	names := make([]string, len(holders))
+	for i, h := range holders {
+		names[i] = h.String()
+	}
+	return names
+}
+
+func joinHolders(holders []sdrHolder) string {
+	return strings.Join(holderNames(holders), ", ")
+}
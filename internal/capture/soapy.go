@@ -0,0 +1,31 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// runSoapy records once through SoapySDR, demodulating FM in-process.
+// Like runRtlFm, it returns errSDRBusy if the device could not be opened
+// because something else has it.
+func (r *Runner) runSoapy(ctx context.Context, f io.Writer, req CaptureRequest) (int64, error) {
+	losCtx, losCancel := context.WithDeadline(ctx, req.LOS)
+	defer losCancel()
+
+	src, rate, err := openSoapy(r.Cfg.SDR, req.TunedFreq())
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	if rate != r.Cfg.SDR.SoapySampleRate {
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": fmt.Sprintf("SoapySDR device runs at %d S/s (asked for %d)", rate, r.Cfg.SDR.SoapySampleRate),
+		})
+	}
+
+	pcm := newPCMReader(losCtx, src, rate, r.Cfg.SDR.SampleRate)
+	return r.streamWithProgress(losCtx, f, pcm, req, req.LOS.Sub(req.AOS)), nil
+}
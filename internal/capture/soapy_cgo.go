@@ -0,0 +1,126 @@
+//go:build soapy && cgo
+
+package capture
+
+/*
+#cgo pkg-config: SoapySDR
+#include <stdlib.h>
+#include <SoapySDR/Constants.h>
+#include <SoapySDR/Device.h>
+#include <SoapySDR/Errors.h>
+
+// readCF32 reads one channel of samples; cgo cannot pass Go memory as the
+// array of buffers readStream expects.
+static int readCF32(SoapySDRDevice *dev, SoapySDRStream *stream, void *buf, size_t n, long timeoutUs) {
+	void *buffs[] = {buf};
+	int flags = 0;
+	long long timeNs = 0;
+	return SoapySDRDevice_readStream(dev, stream, buffs, n, &flags, &timeNs, timeoutUs);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// soapyReadTimeout bounds each read so a stalled device cannot hold the
+// capture past LOS, in microseconds.
+const soapyReadTimeout = 100_000
+
+type soapySource struct {
+	dev    *C.SoapySDRDevice
+	stream *C.SoapySDRStream
+}
+
+func soapyAvailable() error { return nil }
+
+// soapyError wraps SoapySDR's last error for op. Drivers report a device
+// claimed by another program in their own words, so a few common phrasings
+// are recognized as errSDRBusy.
+func soapyError(op string) error {
+	msg := C.GoString(C.SoapySDRDevice_lastError())
+	lower := strings.ToLower(msg)
+	for _, s := range []string{"busy", "in use", "claim", "resource temporarily unavailable"} {
+		if strings.Contains(lower, s) {
+			return fmt.Errorf("%w: soapysdr %s: %s", errSDRBusy, op, msg)
+		}
+	}
+	return fmt.Errorf("soapysdr %s: %s", op, msg)
+}
+
+// openSoapy opens the device named by sdr.soapy_args, tunes it to freq and
+// starts a complex float stream. It returns the sample rate the device
+// actually settled on.
+func openSoapy(sdr config.SDRConfig, freq int) (iqSource, int, error) {
+	args := C.CString(sdr.SoapyArgs)
+	defer C.free(unsafe.Pointer(args))
+	dev := C.SoapySDRDevice_makeStrArgs(args)
+	if dev == nil {
+		return nil, 0, soapyError("open device")
+	}
+	ok := false
+	defer func() {
+		if !ok {
+			C.SoapySDRDevice_unmake(dev)
+		}
+	}()
+
+	if C.SoapySDRDevice_setSampleRate(dev, C.SOAPY_SDR_RX, 0, C.double(sdr.SoapySampleRate)) != 0 {
+		return nil, 0, soapyError("set sample rate")
+	}
+	rate := int(C.SoapySDRDevice_getSampleRate(dev, C.SOAPY_SDR_RX, 0))
+	if rate < sdr.SampleRate {
+		return nil, 0, fmt.Errorf("soapysdr: device sample rate %d S/s is below sdr.sample_rate", rate)
+	}
+	if C.SoapySDRDevice_setFrequency(dev, C.SOAPY_SDR_RX, 0, C.double(freq), nil) != 0 {
+		return nil, 0, soapyError("set frequency")
+	}
+	// Not every driver supports a correction; an error only means the
+	// device is left uncorrected.
+	if sdr.PPMCorrection != 0 {
+		C.SoapySDRDevice_setFrequencyCorrection(dev, C.SOAPY_SDR_RX, 0, C.double(sdr.PPMCorrection))
+	}
+	C.SoapySDRDevice_setGainMode(dev, C.SOAPY_SDR_RX, 0, false)
+	if C.SoapySDRDevice_setGain(dev, C.SOAPY_SDR_RX, 0, C.double(sdr.Gain)) != 0 {
+		return nil, 0, soapyError("set gain")
+	}
+
+	format := C.CString("CF32")
+	defer C.free(unsafe.Pointer(format))
+	stream := C.SoapySDRDevice_setupStream(dev, C.SOAPY_SDR_RX, format, nil, 0, nil)
+	if stream == nil {
+		return nil, 0, soapyError("set up stream")
+	}
+	if C.SoapySDRDevice_activateStream(dev, stream, 0, 0, 0) != 0 {
+		err := soapyError("activate stream")
+		C.SoapySDRDevice_closeStream(dev, stream)
+		return nil, 0, err
+	}
+	ok = true
+	return &soapySource{dev: dev, stream: stream}, rate, nil
+}
+
+func (s *soapySource) ReadIQ(buf []complex64) (int, error) {
+	n := C.readCF32(s.dev, s.stream, unsafe.Pointer(&buf[0]), C.size_t(len(buf)), soapyReadTimeout)
+	switch {
+	case n >= 0:
+		return int(n), nil
+	case n == C.SOAPY_SDR_TIMEOUT, n == C.SOAPY_SDR_OVERFLOW:
+		// A dropped block costs a line of the image; keep going.
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("soapysdr read: %s", C.GoString(C.SoapySDR_errToStr(n)))
+	}
+}
+
+func (s *soapySource) Close() error {
+	C.SoapySDRDevice_deactivateStream(s.dev, s.stream, 0, 0)
+	C.SoapySDRDevice_closeStream(s.dev, s.stream)
+	C.SoapySDRDevice_unmake(s.dev)
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !soapy || !cgo
+
+package capture
+
+import (
+	"errors"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+func soapyAvailable() error {
+	return errors.New("this build has no SoapySDR support; rebuild with -tags soapy")
+}
+
+func openSoapy(config.SDRConfig, int) (iqSource, int, error) {
+	return nil, 0, soapyAvailable()
+}
@@ -0,0 +1,138 @@
+package capture
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// stagingHeadroom is kept free beyond a capture's expected size, both on
+// the staging filesystem and in available memory, so a staged recording
+// never pushes the system into swapping or the OOM killer.
+const stagingHeadroom = 64 << 20
+
+// recordPath picks where a capture is written while it records. With
+// data.staging set it is the staging directory, provided the whole pass
+// fits there and in available memory (tmpfs pages are RAM); otherwise it
+// is outPath under data.root.
+func (r *Runner) recordPath(outPath string, req CaptureRequest) string {
+	dir := r.Cfg.Data.Staging
+	if dir == "" {
+		return outPath
+	}
+
+	need := uint64(req.LOS.Sub(req.AOS)/time.Second)*uint64(r.Cfg.SDR.SampleRate)*2 + 44 + stagingHeadroom
+	skip := func(why string) string {
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "warn",
+			"message": fmt.Sprintf("not staging %s in %s: %s; recording to %s", req.Satellite.Name, dir, why, r.Cfg.Data.Root),
+		})
+		return outPath
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return skip(err.Error())
+	}
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return skip(err.Error())
+	}
+	if free := stat.Bavail * uint64(stat.Bsize); free < need {
+		return skip(fmt.Sprintf("%d MB free, pass needs %d MB", free>>20, need>>20))
+	}
+	if avail, ok := memAvailable(); ok && avail < need {
+		return skip(fmt.Sprintf("%d MB of memory available, pass needs %d MB", avail>>20, need>>20))
+	}
+	return filepath.Join(dir, filepath.Base(outPath))
+}
+
+// memAvailable returns MemAvailable from /proc/meminfo in bytes. ok is
+// false where it cannot be read, in which case only the filesystem check
+// applies.
+func memAvailable() (uint64, bool) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) >= 2 && fields[0] == "MemAvailable:" {
+			kb, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0, false
+			}
+			return kb << 10, true
+		}
+	}
+	return 0, false
+}
+
+// moveCapture moves a finished staged recording into place. Staging is
+// normally on another filesystem, so this falls back to copying into a
+// temp file beside dst, syncing it, and renaming it over dst; a crash
+// part-way through leaves the staged copy intact.
+func moveCapture(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// RecoverStaged moves a recording left in the staging directory by a crash
+// into root and repairs its header, so what was recorded before the crash
+// is kept. It returns the new path, and fs.ErrExist if root already has a
+// file of that name.
+func RecoverStaged(path, root string) (string, error) {
+	dst := filepath.Join(root, filepath.Base(path))
+	if _, err := os.Stat(dst); err == nil {
+		return "", fs.ErrExist
+	}
+	if err := moveCapture(path, dst); err != nil {
+		return "", err
+	}
+	_, err := RepairWAVHeader(dst)
+	return dst, err
+}
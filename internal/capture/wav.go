@@ -84,3 +84,35 @@ func fixWAVHeader(f *os.File) error {
 	}
 	return binary.Write(f, binary.LittleEndian, dataSize)
 }
+
+// RepairWAVHeader patches the sizes in the header of the WAV file at path
+// to match its length, as fixWAVHeader would have at LOS had the recording
+// not been cut short by a crash. It reports whether the header needed it.
+// Files whose header is not the 44-byte one captures are written with are
+// left alone.
+func RepairWAVHeader(path string) (bool, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	var h [44]byte
+	if _, err := io.ReadFull(f, h[:]); err != nil {
+		return false, nil
+	}
+	if string(h[0:4]) != "RIFF" || string(h[8:12]) != "WAVE" || string(h[36:40]) != "data" {
+		return false, nil
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if int64(binary.LittleEndian.Uint32(h[40:44])) == info.Size()-44 {
+		return false, nil
+	}
+	if err := fixWAVHeader(f); err != nil {
+		return false, err
+	}
+	return true, f.Sync()
+}
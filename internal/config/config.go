@@ -4,38 +4,148 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/pelletier/go-toml/v2"
+
+	"github.com/large-farva/ephemeris-engine/internal/notify/tmpl"
+	"github.com/large-farva/ephemeris-engine/internal/rules"
 )
 
 // Config is the top-level configuration, mirroring the TOML sections.
 type Config struct {
-	Data    DataConfig    `toml:"data"    json:"data"`
-	Logging LoggingConfig `toml:"logging" json:"logging"`
-	Server  ServerConfig  `toml:"server"  json:"server"`
-	Demo    DemoConfig    `toml:"demo"    json:"demo"`
-	Station StationConfig `toml:"station" json:"station"`
-	SDR     SDRConfig     `toml:"sdr"     json:"sdr"`
-	Predict PredictConfig `toml:"predict" json:"predict"`
+	Data        DataConfig        `toml:"data"        json:"data"`
+	Logging     LoggingConfig     `toml:"logging"     json:"logging"`
+	Server      ServerConfig      `toml:"server"      json:"server"`
+	Demo        DemoConfig        `toml:"demo"        json:"demo"`
+	Station     StationConfig     `toml:"station"     json:"station"`
+	SDR         SDRConfig         `toml:"sdr"         json:"sdr"`
+	Predict     PredictConfig     `toml:"predict"     json:"predict"`
+	Schedule    ScheduleConfig    `toml:"schedule"    json:"schedule"`
+	Weather     WeatherConfig     `toml:"weather"     json:"weather"`
+	Catalog     CatalogConfig     `toml:"catalog"     json:"catalog"`
+	Decode      DecodeConfig      `toml:"decode"      json:"decode"`
+	Annotations AnnotationsConfig `toml:"annotations" json:"annotations"`
+	Tracing     TracingConfig     `toml:"tracing"     json:"tracing"`
+	Debug       DebugConfig       `toml:"debug"       json:"debug"`
+	Events      EventsConfig      `toml:"events"      json:"events"`
+	Retention   RetentionConfig   `toml:"retention"   json:"retention"`
+	Upload      UploadConfig      `toml:"upload"      json:"upload"`
+	Gallery     GalleryConfig     `toml:"gallery"     json:"gallery"`
+	Notify      NotifyConfig      `toml:"notify"      json:"notify"`
+	Plugins     []PluginConfig    `toml:"plugins"     json:"plugins"`
+	Rules       []RuleConfig      `toml:"rules"       json:"rules"`
+	Scripts     []ScriptConfig    `toml:"scripts"     json:"scripts"`
+	// Satellites holds per-satellite settings as [satellites.NAME] tables,
+	// such as [satellites.NOAA-15]. Names match the catalog ignoring case.
+	Satellites map[string]SatelliteConfig `toml:"satellites" json:"satellites,omitempty"`
 }
 
 type DataConfig struct {
 	Root    string `toml:"root"    json:"root"`
 	Archive string `toml:"archive" json:"archive"`
+	// Staging, when set, is a directory (usually on tmpfs, such as
+	// /dev/shm/ephemeris) that captures are recorded into before being
+	// moved to Root, sparing slow SD cards the sustained writes. It is
+	// skipped for a pass that would not fit in it or in free memory.
+	Staging string `toml:"staging" json:"staging"`
+	// FsyncIntervalSeconds flushes a capture to disk this often while it
+	// records (0 disables), bounding what a power cut can lose to that
+	// window. FsyncOnFinalize syncs the finished file, its sidecar, and
+	// their directory, since ext4 otherwise holds them for up to its
+	// commit interval after LOS.
+	FsyncIntervalSeconds int  `toml:"fsync_interval_seconds" json:"fsync_interval_seconds"`
+	FsyncOnFinalize      bool `toml:"fsync_on_finalize"      json:"fsync_on_finalize"`
+	// ScrubIntervalHours is how often every capture is re-read and checked
+	// against its recorded checksum; 0 disables the background scrub,
+	// which battery-powered stations may prefer.
+	ScrubIntervalHours int `toml:"scrub_interval_hours" json:"scrub_interval_hours"`
+	// TrashDays is how long a deleted capture is kept in .trash under
+	// Root, from where it can be restored, before it is removed for good;
+	// 0 deletes captures at once.
+	TrashDays int `toml:"trash_days" json:"trash_days"`
+	// Compress, when set, transcodes each recording once it is decoded,
+	// replacing the WAV; "flac" is the one format. Empty keeps WAVs.
+	Compress string `toml:"compress" json:"compress"`
 }
 
+// LoggingConfig controls the daemon's log. Records at Level and above go
+// to stdout and, with File on, to ephemerisd.log under data.root/logs,
+// which is rotated at MaxSizeMB keeping MaxFiles old files. Format is
+// "text" (key=value) or "json". Components sets the level of single
+// components, such as "scheduler" = "debug", for their records and for
+// their log events in /api/logs and on the WebSocket. A reload changes the
+// levels only.
 type LoggingConfig struct {
-	Level string `toml:"level" json:"level"`
+	Level      string            `toml:"level"       json:"level"`
+	Format     string            `toml:"format"      json:"format"`
+	File       bool              `toml:"file"        json:"file"`
+	MaxSizeMB  int               `toml:"max_size_mb" json:"max_size_mb"`
+	MaxFiles   int               `toml:"max_files"   json:"max_files"`
+	Components map[string]string `toml:"components"  json:"components"`
+}
+
+// LogLevels and LogFormats are the accepted values of logging.level and
+// logging.format.
+var (
+	LogLevels  = []string{"debug", "info", "warn", "error"}
+	LogFormats = []string{"text", "json"}
+)
+
+// Compressions are the accepted values of data.compress, besides empty.
+var Compressions = []string{"flac"}
+
+// LogComponents are the components logging.components can set the level
+// of: the component attribute of records and events.
+var LogComponents = []string{
+	"capture", "catalog", "demo", "email", "ephemerisd", "history", "mode",
+	"notify", "plugin", "predict", "retention", "rules", "satellites",
+	"scheduler", "scrub", "station", "telegram", "tracing", "upload",
+	"watchdog",
 }
 
 type ServerConfig struct {
 	Bind string `toml:"bind" json:"bind"`
+
+	// BasePath is the URL prefix the daemon is served under behind a
+	// reverse proxy, e.g. "/ephemeris". Empty serves from the root.
+	BasePath string `toml:"base_path" json:"base_path"`
+
+	// PublicReadonly opens a second listener on PublicBind that serves only
+	// status GETs and a filtered event stream, for sharing a dashboard.
+	PublicReadonly bool   `toml:"public_readonly" json:"public_readonly"`
+	PublicBind     string `toml:"public_bind"     json:"public_bind"`
+	// PublicShareOnly makes the public listener require a share token,
+	// minted by POST /api/share, on every request but the health probes.
+	// ShareMaxHours caps how long a token may last.
+	PublicShareOnly bool `toml:"public_share_only" json:"public_share_only"`
+	ShareMaxHours   int  `toml:"share_max_hours"   json:"share_max_hours"`
+
+	// WSAllowedOrigins lists the browser origins allowed to open /ws;
+	// empty allows any. WSMaxClients and WSMaxClientsPerIP cap open
+	// connections in total and per client address (0 = unlimited).
+	WSAllowedOrigins  []string `toml:"ws_allowed_origins"    json:"ws_allowed_origins"`
+	WSMaxClients      int      `toml:"ws_max_clients"        json:"ws_max_clients"`
+	WSMaxClientsPerIP int      `toml:"ws_max_clients_per_ip" json:"ws_max_clients_per_ip"`
+	// WSPongTimeoutSeconds evicts a /ws client that has not answered a
+	// ping, or sent anything else, for this long.
+	WSPongTimeoutSeconds int `toml:"ws_pong_timeout_seconds" json:"ws_pong_timeout_seconds"`
+
+	// APITokens, when any are set, must be presented as a bearer token to
+	// call endpoints that change state (every POST and DELETE). Reads,
+	// /healthz and the event stream stay open.
+	APITokens []Secret `toml:"api_tokens" json:"api_tokens"`
 }
 
 type DemoConfig struct {
@@ -50,19 +160,741 @@ type StationConfig struct {
 	MinElevation float64 `toml:"min_elevation" json:"min_elevation"`
 	UseGPSD      bool    `toml:"use_gpsd"      json:"use_gpsd"`
 	GPSDHost     string  `toml:"gpsd_host"     json:"gpsd_host"`
+	// UsableElevation trims each recording to the part of the pass above
+	// it, leaving out the noise near the horizon. 0 records from AOS to
+	// LOS.
+	UsableElevation float64 `toml:"usable_elevation" json:"usable_elevation"`
+	// HorizonMask is the local horizon, as [azimuth, elevation] points in
+	// degrees, such as [[120, 5], [150, 25], [210, 25], [240, 5]] for trees
+	// to the south. Between points the elevation is interpolated, around
+	// north too. Passes are trimmed to the part above it. HorizonFile
+	// reads the points from a CSV file of azimuth,elevation lines instead.
+	HorizonMask [][]float64 `toml:"horizon_mask" json:"horizon_mask,omitempty"`
+	HorizonFile string      `toml:"horizon_file" json:"horizon_file,omitempty"`
+	// Timezone is the station's IANA time zone, such as "Europe/London",
+	// in which the API renders local times next to UTC. Empty uses the
+	// daemon host's zone.
+	Timezone string `toml:"timezone" json:"timezone"`
+	// GeoIP falls back to an approximate position from IP geolocation
+	// when latitude and longitude are both unset and gpsd has no fix.
+	GeoIP    bool   `toml:"geoip"     json:"geoip"`
+	GeoIPURL string `toml:"geoip_url" json:"geoip_url"`
+
+	// Active names the profile in Profiles whose values override the ones
+	// above. Empty means the bare [station] values are used as-is.
+	Active string `toml:"active" json:"active"`
+	// Profiles are the [station.NAME] tables, such as [station.home] and
+	// [station.field]. They are read by loadStationProfiles because a
+	// struct cannot capture arbitrary sub-table names.
+	Profiles map[string]StationProfile `toml:"-" json:"profiles,omitempty"`
+}
+
+// StationProfile is one named receive location. Fields left unset keep the
+// value from the bare [station] table, so a profile usually holds just
+// coordinates.
+type StationProfile struct {
+	Latitude     *float64 `toml:"latitude"      json:"latitude,omitempty"`
+	Longitude    *float64 `toml:"longitude"     json:"longitude,omitempty"`
+	Altitude     *float64 `toml:"altitude"      json:"altitude,omitempty"`
+	MinElevation *float64 `toml:"min_elevation" json:"min_elevation,omitempty"`
+	UseGPSD      *bool    `toml:"use_gpsd"      json:"use_gpsd,omitempty"`
+	GPSDHost     *string  `toml:"gpsd_host"     json:"gpsd_host,omitempty"`
+
+	UsableElevation *float64     `toml:"usable_elevation" json:"usable_elevation,omitempty"`
+	Timezone        *string      `toml:"timezone"         json:"timezone,omitempty"`
+	HorizonMask     *[][]float64 `toml:"horizon_mask"     json:"horizon_mask,omitempty"`
+	HorizonFile     *string      `toml:"horizon_file"     json:"horizon_file,omitempty"`
+}
+
+// apply overrides st's location fields with the ones p sets.
+func (p StationProfile) apply(st *StationConfig) {
+	if p.Latitude != nil {
+		st.Latitude = *p.Latitude
+	}
+	if p.Longitude != nil {
+		st.Longitude = *p.Longitude
+	}
+	if p.Altitude != nil {
+		st.Altitude = *p.Altitude
+	}
+	if p.MinElevation != nil {
+		st.MinElevation = *p.MinElevation
+	}
+	if p.UseGPSD != nil {
+		st.UseGPSD = *p.UseGPSD
+	}
+	if p.GPSDHost != nil {
+		st.GPSDHost = *p.GPSDHost
+	}
+	if p.UsableElevation != nil {
+		st.UsableElevation = *p.UsableElevation
+	}
+	if p.Timezone != nil {
+		st.Timezone = *p.Timezone
+	}
+	// A profile's mask replaces the bare one, whichever form either is in.
+	if p.HorizonMask != nil || p.HorizonFile != nil {
+		st.HorizonMask, st.HorizonFile = nil, ""
+		if p.HorizonMask != nil {
+			st.HorizonMask = *p.HorizonMask
+		}
+		if p.HorizonFile != nil {
+			st.HorizonFile = *p.HorizonFile
+		}
+	}
+}
+
+// Location returns the station's time zone: Timezone, or the host's zone
+// when it is unset or, which validation prevents, unknown.
+func (st StationConfig) Location() *time.Location {
+	if st.Timezone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(st.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// ProfileNames returns the defined station profile names, sorted.
+func (st StationConfig) ProfileNames() []string {
+	names := make([]string, 0, len(st.Profiles))
+	for name := range st.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 type SDRConfig struct {
+	// Backend selects how captures talk to the radio: "rtl_fm" runs the
+	// rtl_fm tool, "soapy" opens the device through SoapySDR and
+	// demodulates in-process, for Airspy, HackRF, SDRplay and the like,
+	// and "rtl_tcp" streams from an rtl_tcp server on another machine.
+	Backend       string  `toml:"backend"        json:"backend"`
 	DeviceIndex   int     `toml:"device_index"   json:"device_index"`
 	Gain          float64 `toml:"gain"           json:"gain"`
 	PPMCorrection int     `toml:"ppm_correction" json:"ppm_correction"`
 	SampleRate    int     `toml:"sample_rate"    json:"sample_rate"`
+	// CompetingProcesses names programs known to hold the dongle open
+	// (SDR++, gqrx, rtl_tcp, ...). A capture waits for them to exit, or
+	// terminates them when KillCompeting is set.
+	CompetingProcesses []string `toml:"competing_processes" json:"competing_processes"`
+	KillCompeting      bool     `toml:"kill_competing"      json:"kill_competing"`
+	// SoapyArgs picks the SoapySDR device, such as "driver=airspy" or
+	// "driver=sdrplay,serial=1234"; empty opens the first one found.
+	// SoapySampleRate is the IQ rate requested from it, which is
+	// decimated down to SampleRate after demodulation.
+	SoapyArgs       string `toml:"soapy_args"        json:"soapy_args"`
+	SoapySampleRate int    `toml:"soapy_sample_rate" json:"soapy_sample_rate"`
+	// TCPAddr is the rtl_tcp server as host:port. TCPSampleRate is the IQ
+	// rate it is asked to stream, which the network must carry at two
+	// bytes per sample.
+	TCPAddr       string `toml:"tcp_addr"        json:"tcp_addr"`
+	TCPSampleRate int    `toml:"tcp_sample_rate" json:"tcp_sample_rate"`
+	// BandCapture records passes that overlap in time together when their
+	// frequencies fit in BandSampleRate: the dongle is tuned between them
+	// and each satellite's channel is demodulated from the wideband IQ.
+	// With the rtl_fm backend the IQ comes from rtl_sdr.
+	BandCapture    bool `toml:"band_capture"     json:"band_capture"`
+	BandSampleRate int  `toml:"band_sample_rate" json:"band_sample_rate"`
 }
 
+// SDR backends accepted in sdr.backend.
+const (
+	BackendRtlFm  = "rtl_fm"
+	BackendSoapy  = "soapy"
+	BackendRtlTCP = "rtl_tcp"
+)
+
 type PredictConfig struct {
-	TLEURL          string `toml:"tle_url"           json:"tle_url"`
-	TLERefreshHours int    `toml:"tle_refresh_hours" json:"tle_refresh_hours"`
-	LookaheadHours  int    `toml:"lookahead_hours"   json:"lookahead_hours"`
+	TLEURL string `toml:"tle_url" json:"tle_url" redact:"userinfo"`
+	// TLEURLs are more TLE sources, tried after TLEURL in order: a source
+	// that fails, or lacks a satellite, is made up for by the next.
+	TLEURLs         []string `toml:"tle_urls"          json:"tle_urls"          redact:"userinfo"`
+	TLERefreshHours int      `toml:"tle_refresh_hours" json:"tle_refresh_hours"`
+	LookaheadHours  int      `toml:"lookahead_hours"   json:"lookahead_hours"`
+	// TLEMaxEpochAgeDays is how old a satellite's element set may be, by
+	// its epoch, before a tle_stale warning is sent; 0 disables it. A
+	// source that stops updating a satellite otherwise goes unnoticed
+	// while its pass times drift.
+	TLEMaxEpochAgeDays int `toml:"tle_max_epoch_age_days" json:"tle_max_epoch_age_days"`
+	// SunAvoidDegrees flags passes whose track comes this close to the sun;
+	// 0 disables the check. SunPolicy is "flag", "deprioritize" (drop a
+	// flagged pass that overlaps a clean one), or "skip".
+	SunAvoidDegrees float64 `toml:"sun_avoid_degrees" json:"sun_avoid_degrees"`
+	SunPolicy       string  `toml:"sun_policy"        json:"sun_policy"`
+	// ConflictPolicy picks which of two passes that overlap is recorded:
+	// "score" the one scoring higher on satellite priority, elevation and
+	// length, "first" the one rising first.
+	ConflictPolicy string `toml:"conflict_policy" json:"conflict_policy"`
+	// MinQuality keeps passes whose quality score, from 0 to 100, is
+	// below it out of the schedule; 0 schedules every pass. Applied on
+	// reload.
+	MinQuality int `toml:"min_quality" json:"min_quality"`
+}
+
+// ScheduleConfig limits when the scheduler records passes. Each
+// [[schedule.blackout]] entry is a recurring window in which none is: a
+// pass whose recording would overlap it is skipped. Triggered captures
+// are not affected.
+type ScheduleConfig struct {
+	Blackout []BlackoutConfig `toml:"blackout" json:"blackout"`
+	// EnergyBudgetWh is how many watt-hours captures may use a day, in
+	// the station's time zone, for a station on solar or battery power;
+	// 0 records every pass. Within it the scheduler keeps the passes
+	// scoring highest, each costing CaptureWh plus CaptureWhPerMinute for
+	// every minute recorded. Triggered captures count against it too.
+	EnergyBudgetWh     float64 `toml:"energy_budget_wh"      json:"energy_budget_wh"`
+	CaptureWh          float64 `toml:"capture_wh"            json:"capture_wh"`
+	CaptureWhPerMinute float64 `toml:"capture_wh_per_minute" json:"capture_wh_per_minute"`
+}
+
+// CaptureEnergy estimates the watt-hours a capture recording for d uses.
+func (s ScheduleConfig) CaptureEnergy(d time.Duration) float64 {
+	return s.CaptureWh + s.CaptureWhPerMinute*d.Minutes()
+}
+
+// BlackoutConfig is one [[schedule.blackout]] entry. Window is a time of
+// day in the station's time zone, such as "02:00-04:00", and Days the
+// days it starts on, as "mon" to "sun"; every day when empty. Name, which
+// defaults to the window, is what events and listings call it.
+type BlackoutConfig struct {
+	Name   string   `toml:"name"   json:"name"`
+	Window string   `toml:"window" json:"window"`
+	Days   []string `toml:"days"   json:"days"`
+}
+
+// Blackout is a parsed [[schedule.blackout]] entry.
+type Blackout struct {
+	Name   string
+	Window DailyWindow
+	Days   []time.Weekday // every day when empty
+}
+
+// weekdays maps the day names a blackout takes.
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Parse checks the entry and returns it as a Blackout.
+func (b BlackoutConfig) Parse() (Blackout, error) {
+	w, err := ParseDailyWindow(b.Window)
+	if err != nil {
+		return Blackout{}, err
+	}
+	out := Blackout{Name: b.Name, Window: w}
+	if out.Name == "" {
+		out.Name = strings.TrimSpace(b.Window)
+	}
+	for _, d := range b.Days {
+		wd, ok := weekdays[strings.ToLower(strings.TrimSpace(d))]
+		if !ok {
+			return Blackout{}, fmt.Errorf("unknown day %q (want mon to sun)", d)
+		}
+		out.Days = append(out.Days, wd)
+	}
+	return out, nil
+}
+
+// Blackouts returns the blackout windows, which Validate has checked.
+func (s ScheduleConfig) Blackouts() []Blackout {
+	var out []Blackout
+	for _, b := range s.Blackout {
+		if bo, err := b.Parse(); err == nil {
+			out = append(out, bo)
+		}
+	}
+	return out
+}
+
+// Next returns the first time b is in force that ends after t, in t's
+// location. A window that runs past midnight belongs to the day it starts.
+func (b Blackout) Next(t time.Time) (start, end time.Time) {
+	y, m, d := t.Date()
+	for i := -1; i <= 7; i++ {
+		day := time.Date(y, m, d+i, 0, 0, 0, 0, t.Location())
+		if len(b.Days) > 0 && !slices.Contains(b.Days, day.Weekday()) {
+			continue
+		}
+		start = time.Date(y, m, d+i, b.Window.Start/60, b.Window.Start%60, 0, 0, t.Location())
+		endDay := d + i
+		if b.Window.End < b.Window.Start {
+			endDay++
+		}
+		end = time.Date(y, m, endDay, b.Window.End/60, b.Window.End%60, 0, 0, t.Location())
+		if end.After(t) {
+			return start, end
+		}
+	}
+	return time.Time{}, time.Time{}
+}
+
+// Overlaps reports whether b is in force at any time between from and to,
+// and if so from when until when, in from's location.
+func (b Blackout) Overlaps(from, to time.Time) (start, end time.Time, ok bool) {
+	start, end = b.Next(from)
+	return start, end, !start.IsZero() && start.Before(to)
+}
+
+// WeatherConfig attaches a cloud-cover forecast to each predicted pass.
+// When SkipOvercastPercent is above zero, daylight passes forecast at or
+// above that cover are not recorded.
+type WeatherConfig struct {
+	Enabled             bool   `toml:"enabled"               json:"enabled"`
+	URL                 string `toml:"url"                   json:"url"`
+	SkipOvercastPercent int    `toml:"skip_overcast_percent" json:"skip_overcast_percent"`
+}
+
+// CatalogConfig controls syncing the satellite catalog with SatNOGS DB.
+// With Sync on, each catalog satellite's status and active transmitter in
+// its mode are looked up every SyncHours, and a transmitter found on
+// another frequency replaces the catalog one.
+type CatalogConfig struct {
+	Sync       bool   `toml:"sync"        json:"sync"`
+	SatNOGSURL string `toml:"satnogs_url" json:"satnogs_url"`
+	SyncHours  int    `toml:"sync_hours"  json:"sync_hours"`
+}
+
+// DecodeConfig controls the images made from each capture besides the raw
+// A and B channels. Enhancements names them: "equalized", "false_color"
+// and "overlay". OverlayShapes is a GeoJSON file of coastlines and
+// borders for the overlay; without it the overlay has only the lat/lon
+// grid and the station.
+type DecodeConfig struct {
+	Enhancements  []string `toml:"enhancements"   json:"enhancements"`
+	OverlayShapes string   `toml:"overlay_shapes" json:"overlay_shapes"`
+}
+
+// ImageEnhancements are the valid decode.enhancements.
+var ImageEnhancements = []string{"equalized", "false_color", "overlay"}
+
+// AnnotationsConfig controls where pass and failure annotations are sent.
+// Annotations are always served from /api/annotations; when GrafanaURL is
+// set they are also pushed to Grafana's HTTP annotations API.
+type AnnotationsConfig struct {
+	GrafanaURL   string   `toml:"grafana_url"   json:"grafana_url"   redact:"userinfo"`
+	GrafanaToken Secret   `toml:"grafana_token" json:"grafana_token"`
+	DashboardUID string   `toml:"dashboard_uid" json:"dashboard_uid"`
+	Tags         []string `toml:"tags"          json:"tags"`
+}
+
+// TracingConfig enables span recording for the pass pipeline. Spans are
+// kept in memory for /api/traces and, when OTLPEndpoint is set, exported to
+// an OpenTelemetry collector over OTLP/HTTP (JSON).
+type TracingConfig struct {
+	Enabled      bool   `toml:"enabled"       json:"enabled"`
+	OTLPEndpoint string `toml:"otlp_endpoint" json:"otlp_endpoint" redact:"userinfo"`
+	ServiceName  string `toml:"service_name"  json:"service_name"`
+}
+
+// DebugConfig gates the runtime diagnostics endpoints (/debug/pprof/,
+// /debug/ws and /api/debug/*). Requests must present Token, or when it is
+// empty one of server.api_tokens, as a bearer token or a ?token= query
+// parameter. With neither set the endpoints are open.
+type DebugConfig struct {
+	Enabled bool   `toml:"enabled" json:"enabled"`
+	Token   Secret `toml:"token"   json:"token"`
+}
+
+// EventsConfig controls the on-disk event log. With Persist on, every
+// broadcast event is appended to a daily NDJSON file under
+// data.root/events, except the types in Exclude. Files older than
+// RetentionDays are deleted; 0 keeps them all.
+type EventsConfig struct {
+	Persist       bool     `toml:"persist"        json:"persist"`
+	RetentionDays int      `toml:"retention_days" json:"retention_days"`
+	Exclude       []string `toml:"exclude"        json:"exclude"`
+}
+
+// RetentionConfig bounds how much the captures in data.root keep. When a
+// capture is older than MaxAgeDays, or the captures together take more
+// than MaxTotalBytes, or the disk has less than MinFreeBytes free, the
+// janitor prunes captures oldest first, with their sidecars and images,
+// until every limit is met. Zero turns a limit off. Action "archive"
+// moves them to data.archive and "delete" removes them. Captures tagged
+// "keep" are never pruned.
+type RetentionConfig struct {
+	MaxAgeDays    int    `toml:"max_age_days"    json:"max_age_days"`
+	MaxTotalBytes int64  `toml:"max_total_bytes" json:"max_total_bytes"`
+	MinFreeBytes  int64  `toml:"min_free_bytes"  json:"min_free_bytes"`
+	Action        string `toml:"action"          json:"action"`
+	// IntervalMinutes is how often the janitor sweeps.
+	IntervalMinutes int `toml:"interval_minutes" json:"interval_minutes"`
+}
+
+// Enabled reports whether any retention limit is set.
+func (r RetentionConfig) Enabled() bool {
+	return r.MaxAgeDays > 0 || r.MaxTotalBytes > 0 || r.MinFreeBytes > 0
+}
+
+// RetentionActions are the accepted values of retention.action.
+var RetentionActions = []string{"archive", "delete"}
+
+// UploadConfig copies each capture, once decoded, to an S3-compatible
+// bucket under Prefix: AWS S3, MinIO, Backblaze B2 and the like. Endpoint
+// is the service's base URL, such as https://s3.eu-west-1.amazonaws.com.
+// PathStyle addresses the bucket as endpoint/bucket rather than as
+// bucket.endpoint, which MinIO needs. PublicURL, when set, replaces the
+// endpoint and bucket in the URLs recorded in the history, for a bucket
+// served through a CDN or website host.
+type UploadConfig struct {
+	Enabled         bool   `toml:"enabled"           json:"enabled"`
+	Endpoint        string `toml:"endpoint"          json:"endpoint"`
+	Region          string `toml:"region"            json:"region"`
+	Bucket          string `toml:"bucket"            json:"bucket"`
+	Prefix          string `toml:"prefix"            json:"prefix"`
+	AccessKeyID     string `toml:"access_key_id"     json:"access_key_id"`
+	SecretAccessKey Secret `toml:"secret_access_key" json:"secret_access_key"`
+	PathStyle       bool   `toml:"path_style"        json:"path_style"`
+	PublicURL       string `toml:"public_url"        json:"public_url"`
+	// Concurrency is how many captures upload at once.
+	Concurrency int `toml:"concurrency" json:"concurrency"`
+}
+
+// MaxUploadConcurrency bounds upload.concurrency.
+const MaxUploadConcurrency = 16
+
+// GalleryConfig exports the decoded images as a static web site in Dir
+// after each decode: an index of days and a page per day, in the station's
+// time zone. Dir holds nothing else the daemon needs, so it can be served
+// as it is or synced to a host such as GitHub Pages.
+type GalleryConfig struct {
+	Enabled bool   `toml:"enabled" json:"enabled"`
+	Dir     string `toml:"dir"     json:"dir"`
+	Title   string `toml:"title"   json:"title"`
+}
+
+// NotifyConfig sends pass results, failed captures and a daily summary to
+// chat services, push services and email. SummaryTime is when the summary
+// of the last day is sent, as HH:MM in the station's time zone, or "" for
+// none. Each backend's Events lists the NotifyEvents it is sent; test
+// messages go to every enabled backend. BaseURL is the daemon's address as
+// seen by whoever reads the messages, for links to images.
+//
+// Each backend also holds back pass and failure messages during its
+// QuietHours, a daily window such as "22:00-07:00" in the station's time
+// zone, and a message repeating one it was sent in the last DedupeMinutes.
+// MaxPerHour caps what it is sent in any hour; 0 is no cap. Summaries and
+// tests are never held back.
+type NotifyConfig struct {
+	SummaryTime string          `toml:"summary_time" json:"summary_time"`
+	BaseURL     string          `toml:"base_url"     json:"base_url"`
+	Templates   NotifyTemplates `toml:"templates"    json:"templates"`
+	Telegram    TelegramConfig  `toml:"telegram"     json:"telegram"`
+	Email       EmailConfig     `toml:"email"        json:"email"`
+	Ntfy        NtfyConfig      `toml:"ntfy"         json:"ntfy"`
+	Gotify      GotifyConfig    `toml:"gotify"       json:"gotify"`
+}
+
+// NotifyTemplates replaces the text of each kind of notification with a Go
+// template over the message's data, such as {{.Satellite}}. An empty one
+// keeps the built-in text. See the README for the fields.
+type NotifyTemplates struct {
+	Pass    string `toml:"pass"    json:"pass"`
+	Failure string `toml:"failure" json:"failure"`
+	Summary string `toml:"summary" json:"summary"`
+}
+
+// Get returns the template for kind, or "" for the built-in one.
+func (t NotifyTemplates) Get(kind string) string {
+	for _, k := range t.list() {
+		if k.kind == kind {
+			return k.text
+		}
+	}
+	return ""
+}
+
+func (t NotifyTemplates) list() []struct{ kind, text string } {
+	return []struct{ kind, text string }{
+		{tmpl.KindPass, t.Pass},
+		{tmpl.KindFailure, t.Failure},
+		{tmpl.KindSummary, t.Summary},
+	}
+}
+
+// NotifyEvents are the kinds of notification a backend can be sent: a
+// decoded pass, a failed capture and the daily summary.
+var NotifyEvents = []string{"pass", "failure", "summary"}
+
+// TelegramConfig sends each decoded pass, with its image unless Images is
+// off, and each failed capture to a Telegram chat through a bot made with
+// @BotFather. ChatID is the chat's numeric ID or a channel's @username.
+// With Commands set the bot also answers /status, /next, /pause and
+// /resume, but only from the numeric user IDs in AllowedUsers. APIURL is
+// the Bot API server, which may be a self-hosted one.
+type TelegramConfig struct {
+	Enabled      bool     `toml:"enabled"       json:"enabled"`
+	BotToken     Secret   `toml:"bot_token"     json:"bot_token"`
+	ChatID       string   `toml:"chat_id"       json:"chat_id"`
+	Images       bool     `toml:"images"        json:"images"`
+	Commands     bool     `toml:"commands"      json:"commands"`
+	AllowedUsers []int64  `toml:"allowed_users" json:"allowed_users"`
+	APIURL       string   `toml:"api_url"       json:"api_url"`
+	Events       []string `toml:"events"        json:"events"`
+
+	QuietHours    string `toml:"quiet_hours"    json:"quiet_hours"`
+	MaxPerHour    int    `toml:"max_per_hour"   json:"max_per_hour"`
+	DedupeMinutes int    `toml:"dedupe_minutes" json:"dedupe_minutes"`
+}
+
+// EmailConfig sends the daily summary, with the day's best images attached
+// unless Images is off, and each failed capture by email to To through the
+// SMTP server at Host. TLS is "starttls" to upgrade a plain connection,
+// usually on port 587, "tls" to connect over TLS, usually on port 465, or
+// "none". Username and Password are sent only over TLS, or to localhost.
+type EmailConfig struct {
+	Enabled  bool     `toml:"enabled"  json:"enabled"`
+	Host     string   `toml:"host"     json:"host"`
+	Port     int      `toml:"port"     json:"port"`
+	TLS      string   `toml:"tls"      json:"tls"`
+	Username string   `toml:"username" json:"username"`
+	Password Secret   `toml:"password" json:"password"`
+	From     string   `toml:"from"     json:"from"`
+	To       []string `toml:"to"       json:"to"`
+	Images   bool     `toml:"images"   json:"images"`
+	Events   []string `toml:"events"   json:"events"`
+
+	QuietHours    string `toml:"quiet_hours"    json:"quiet_hours"`
+	MaxPerHour    int    `toml:"max_per_hour"   json:"max_per_hour"`
+	DedupeMinutes int    `toml:"dedupe_minutes" json:"dedupe_minutes"`
+}
+
+// EmailTLSModes are the accepted values of notify.email.tls.
+var EmailTLSModes = []string{"starttls", "tls", "none"}
+
+// NtfyConfig publishes notifications to Topic on an ntfy server, such as
+// ntfy.sh or a self-hosted one, for its phone and desktop apps. A topic
+// with access control needs Token, an access token, or Username and
+// Password. Priority is 1 (min) to 5 (max), or 0 for the server's default.
+// With Images set the first image is attached, which the server must
+// allow.
+type NtfyConfig struct {
+	Enabled  bool     `toml:"enabled"  json:"enabled"`
+	Server   string   `toml:"server"   json:"server"`
+	Topic    string   `toml:"topic"    json:"topic"`
+	Token    Secret   `toml:"token"    json:"token"`
+	Username string   `toml:"username" json:"username"`
+	Password Secret   `toml:"password" json:"password"`
+	Priority int      `toml:"priority" json:"priority"`
+	Images   bool     `toml:"images"   json:"images"`
+	Events   []string `toml:"events"   json:"events"`
+
+	QuietHours    string `toml:"quiet_hours"    json:"quiet_hours"`
+	MaxPerHour    int    `toml:"max_per_hour"   json:"max_per_hour"`
+	DedupeMinutes int    `toml:"dedupe_minutes" json:"dedupe_minutes"`
+}
+
+// GotifyConfig pushes notifications to a Gotify server as the application
+// whose token is Token. Priority is 0 to 10, where Gotify's apps alert from
+// 4 and up by default.
+type GotifyConfig struct {
+	Enabled  bool     `toml:"enabled"  json:"enabled"`
+	Server   string   `toml:"server"   json:"server"`
+	Token    Secret   `toml:"token"    json:"token"`
+	Priority int      `toml:"priority" json:"priority"`
+	Events   []string `toml:"events"   json:"events"`
+
+	QuietHours    string `toml:"quiet_hours"    json:"quiet_hours"`
+	MaxPerHour    int    `toml:"max_per_hour"   json:"max_per_hour"`
+	DedupeMinutes int    `toml:"dedupe_minutes" json:"dedupe_minutes"`
+}
+
+// DailyWindow is a span of every day, in minutes after midnight. One whose
+// End is before its Start runs past midnight, as 22:00-07:00 does.
+type DailyWindow struct {
+	Start, End int
+}
+
+// ParseDailyWindow parses a window written as "HH:MM-HH:MM".
+func ParseDailyWindow(s string) (DailyWindow, error) {
+	from, to, ok := strings.Cut(s, "-")
+	start, err1 := time.Parse("15:04", strings.TrimSpace(from))
+	end, err2 := time.Parse("15:04", strings.TrimSpace(to))
+	if !ok || err1 != nil || err2 != nil {
+		return DailyWindow{}, fmt.Errorf("%q is not a window of the day such as 22:00-07:00", s)
+	}
+	w := DailyWindow{Start: start.Hour()*60 + start.Minute(), End: end.Hour()*60 + end.Minute()}
+	if w.Start == w.End {
+		return DailyWindow{}, fmt.Errorf("%q starts and ends at the same time", s)
+	}
+	return w, nil
+}
+
+// Contains reports whether the wall-clock time of t, in its own location,
+// falls in the window. The start is inside and the end is not.
+func (w DailyWindow) Contains(t time.Time) bool {
+	m := t.Hour()*60 + t.Minute()
+	if w.Start < w.End {
+		return m >= w.Start && m < w.End
+	}
+	return m >= w.Start || m < w.End
+}
+
+// MaxFreqOffsetHz bounds satellites.NAME.freq_offset_hz. Doppler and
+// transmitter drift are a few kHz; anything near this is a typo that would
+// tune off the signal entirely.
+const MaxFreqOffsetHz = 50000
+
+// MaxSatellitePriority bounds satellites.NAME.priority either way.
+const MaxSatellitePriority = 10
+
+// SatelliteConfig is the [satellites.NAME] table for one satellite.
+type SatelliteConfig struct {
+	// Enabled = false keeps the satellite out of the schedule. It is set by
+	// POST /api/satellites/NAME/disable and /enable.
+	Enabled *bool `toml:"enabled" json:"enabled,omitempty"`
+	// FreqOffsetHz is added to the catalog downlink frequency when
+	// recording, for a satellite that drifts or transmits off its nominal
+	// frequency. It is set by POST /api/satellites/NAME/offset.
+	FreqOffsetHz int `toml:"freq_offset_hz" json:"freq_offset_hz,omitempty"`
+	// Priority ranks the satellite when its pass overlaps another under
+	// predict.conflict_policy = "score"; each step outweighs any difference
+	// in elevation or length. The default is 0.
+	Priority int `toml:"priority" json:"priority,omitempty"`
+	// MinElevation replaces station.min_elevation for this satellite's
+	// passes, in degrees. Nil keeps the station's.
+	MinElevation *float64 `toml:"min_elevation" json:"min_elevation,omitempty"`
+
+	// TLEURL is where this satellite's TLE is fetched from ahead of the
+	// predict sources, for one they do not serve, such as a METEOR from a
+	// CelesTrak group other than NOAA. Empty leaves it to predict.tle_url.
+	TLEURL string `toml:"tle_url" json:"tle_url,omitempty" redact:"userinfo"`
+
+	// NoradID, FreqHz and Mode add a satellite to the built-in NOAA
+	// catalog. A table with norad_id set defines one; they are written by
+	// POST /api/satellites and removed with the table by DELETE.
+	NoradID int    `toml:"norad_id" json:"norad_id,omitempty"`
+	FreqHz  int    `toml:"freq_hz"  json:"freq_hz,omitempty"`
+	Mode    string `toml:"mode"     json:"mode,omitempty"`
+}
+
+// SatelliteModes are the valid satellites.NAME.mode values. Only APT
+// recordings are decoded into images; others are kept as recorded.
+var SatelliteModes = []string{"apt", "lrpt"}
+
+// Tuning range of an RTL-SDR dongle, which bounds satellites.NAME.freq_hz.
+const (
+	MinSatelliteFreqHz = 24000000
+	MaxSatelliteFreqHz = 1766000000
+)
+
+// ValidateCatalogEntry checks the catalog fields of [satellites.name].
+func ValidateCatalogEntry(name string, sat SatelliteConfig) error {
+	if sat.NoradID == 0 {
+		if sat.FreqHz != 0 || sat.Mode != "" {
+			return fmt.Errorf("satellites.%s: freq_hz and mode need norad_id to add a satellite", name)
+		}
+		return nil
+	}
+	if sat.NoradID < 0 {
+		return fmt.Errorf("satellites.%s.norad_id must be > 0", name)
+	}
+	if sat.FreqHz < MinSatelliteFreqHz || sat.FreqHz > MaxSatelliteFreqHz {
+		return fmt.Errorf("satellites.%s.freq_hz must be between %d and %d", name, MinSatelliteFreqHz, MaxSatelliteFreqHz)
+	}
+	if sat.Mode != "" && !contains(SatelliteModes, sat.Mode) {
+		return fmt.Errorf("satellites.%s.mode: unknown mode %q (use %s)", name, sat.Mode, strings.Join(SatelliteModes, ", "))
+	}
+	return nil
+}
+
+// TLESources returns the TLE sources in the order they are tried:
+// tle_url, then tle_urls, without repeats or empty entries.
+func (p PredictConfig) TLESources() []string {
+	var out []string
+	for _, u := range append([]string{p.TLEURL}, p.TLEURLs...) {
+		if u != "" && !contains(out, u) {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// SatelliteKey returns the [satellites] table name used for satellite
+// name, which may differ from it in case, or name itself if it has none.
+func (c Config) SatelliteKey(name string) string {
+	for key := range c.Satellites {
+		if strings.EqualFold(key, name) {
+			return key
+		}
+	}
+	return name
+}
+
+// SatelliteEnabled reports whether satellite name may be scheduled.
+func (c Config) SatelliteEnabled(name string) bool {
+	if s, ok := c.Satellites[c.SatelliteKey(name)]; ok && s.Enabled != nil {
+		return *s.Enabled
+	}
+	return true
+}
+
+// SatelliteFreqOffset returns the frequency offset in Hz applied when
+// recording satellite name.
+func (c Config) SatelliteFreqOffset(name string) int {
+	return c.Satellites[c.SatelliteKey(name)].FreqOffsetHz
+}
+
+// SatellitePriority returns the conflict priority of satellite name.
+func (c Config) SatellitePriority(name string) int {
+	return c.Satellites[c.SatelliteKey(name)].Priority
+}
+
+// SatelliteMinElevation returns the lowest peak elevation, in degrees, at
+// which a pass of satellite name is predicted.
+func (c Config) SatelliteMinElevation(name string) float64 {
+	if e := c.Satellites[c.SatelliteKey(name)].MinElevation; e != nil {
+		return *e
+	}
+	return c.Station.MinElevation
+}
+
+// PluginConfig is one [[plugins]] entry: a program the daemon keeps running
+// that receives telemetry events as JSON-RPC notifications on its stdin.
+type PluginConfig struct {
+	Name    string   `toml:"name"    json:"name"`
+	Command []string `toml:"command" json:"command"` // program and arguments
+	// Events limits delivery to these event types; empty means all.
+	Events []string `toml:"events" json:"events"`
+	// Control lets the plugin call the control API (pause, trigger, tag
+	// captures, ...) over the same channel.
+	Control bool `toml:"control" json:"control"`
+}
+
+// RuleConfig is one [[rules]] entry: when the condition holds, the daemon
+// takes the actions. See internal/rules for the condition syntax.
+type RuleConfig struct {
+	Name    string   `toml:"name"    json:"name"`
+	When    string   `toml:"when"    json:"when"`    // e.g. "disk_free < 2GB"
+	Actions []string `toml:"actions" json:"actions"` // notify, pause, resume, skip
+	Message string   `toml:"message" json:"message"` // for notify
+	// CooldownMinutes is the least time between two firings of the rule.
+	CooldownMinutes int `toml:"cooldown_minutes" json:"cooldown_minutes"`
+}
+
+// Rule returns the rule as the rules engine takes it.
+func (r RuleConfig) Rule() rules.Rule {
+	return rules.Rule{
+		Name:     r.Name,
+		When:     r.When,
+		Actions:  r.Actions,
+		Message:  r.Message,
+		Cooldown: time.Duration(r.CooldownMinutes) * time.Minute,
+	}
+}
+
+// ScriptConfig is one [[scripts]] entry: a Starlark file whose on_event
+// function the daemon calls with each event. See internal/script.
+type ScriptConfig struct {
+	Name string `toml:"name" json:"name"`
+	Path string `toml:"path" json:"path"`
+	// Events limits the events passed to on_event to these types; empty
+	// means all.
+	Events []string `toml:"events" json:"events"`
 }
 
 // DefaultConfigDir returns the XDG-compliant config directory for Ephemeris.
@@ -159,14 +991,26 @@ func Default() Config {
 	dataDir := DefaultDataDir()
 	return Config{
 		Data: DataConfig{
-			Root:    dataDir,
-			Archive: filepath.Join(dataDir, "archive"),
+			Root:               dataDir,
+			Archive:            filepath.Join(dataDir, "archive"),
+			FsyncOnFinalize:    true,
+			ScrubIntervalHours: 168,
+			TrashDays:          7,
 		},
 		Logging: LoggingConfig{
-			Level: "info",
+			Level:     "info",
+			Format:    "text",
+			File:      true,
+			MaxSizeMB: 10,
+			MaxFiles:  5,
 		},
 		Server: ServerConfig{
-			Bind: "0.0.0.0:8080",
+			Bind:                 "0.0.0.0:8080",
+			PublicBind:           "0.0.0.0:8081",
+			WSMaxClients:         64,
+			WSMaxClientsPerIP:    8,
+			WSPongTimeoutSeconds: 60,
+			ShareMaxHours:        168,
 		},
 		Demo: DemoConfig{
 			Enabled:         true,
@@ -179,45 +1023,248 @@ func Default() Config {
 			MinElevation: 10,
 			UseGPSD:      false,
 			GPSDHost:     "localhost:2947",
+			GeoIPURL:     "https://ipapi.co/json/",
 		},
 		SDR: SDRConfig{
+			Backend:       BackendRtlFm,
 			DeviceIndex:   0,
 			Gain:          40.0,
 			PPMCorrection: 0,
 			SampleRate:    48000,
+			CompetingProcesses: []string{
+				"sdrpp", "gqrx", "CubicSDR", "sdrangel", "openwebrx",
+				"rtl_tcp", "rtl_fm", "rtl_sdr", "rtl_power", "rtl_433",
+				"dump1090", "dump1090-fa", "readsb",
+			},
+			SoapySampleRate: 2_500_000,
+			TCPSampleRate:   1_024_000,
+			BandSampleRate:  2_048_000,
 		},
 		Predict: PredictConfig{
 			TLEURL:          "https://celestrak.org/NORAD/elements/gp.php?GROUP=noaa&FORMAT=tle",
 			TLERefreshHours: 24,
 			LookaheadHours:  24,
+			SunAvoidDegrees: 5,
+			SunPolicy:       "flag",
+			ConflictPolicy:  "score",
+
+			TLEMaxEpochAgeDays: 7,
+		},
+		Schedule: ScheduleConfig{
+			CaptureWh:          1,
+			CaptureWhPerMinute: 0.1,
+		},
+		Weather: WeatherConfig{
+			URL: "https://api.open-meteo.com/v1/forecast",
+		},
+		Catalog: CatalogConfig{
+			SatNOGSURL: "https://db.satnogs.org",
+			SyncHours:  24,
+		},
+		Decode: DecodeConfig{
+			Enhancements: []string{"equalized", "false_color", "overlay"},
+		},
+		Annotations: AnnotationsConfig{
+			Tags: []string{"ephemeris"},
+		},
+		Tracing: TracingConfig{
+			Enabled:     false,
+			ServiceName: "ephemerisd",
+		},
+		Events: EventsConfig{
+			RetentionDays: 14,
+			Exclude:       []string{"heartbeat"},
+		},
+		Retention: RetentionConfig{
+			Action:          "archive",
+			IntervalMinutes: 60,
+		},
+		Upload: UploadConfig{
+			Region:      "us-east-1",
+			Concurrency: 2,
+		},
+		Gallery: GalleryConfig{
+			Dir:   filepath.Join(dataDir, "gallery"),
+			Title: "Ephemeris Engine",
+		},
+		Notify: NotifyConfig{
+			SummaryTime: "08:00",
+			Telegram: TelegramConfig{
+				Images:        true,
+				APIURL:        "https://api.telegram.org",
+				Events:        []string{"pass", "failure"},
+				DedupeMinutes: 60,
+			},
+			Email: EmailConfig{
+				Port:          587,
+				TLS:           "starttls",
+				Images:        true,
+				Events:        []string{"failure", "summary"},
+				DedupeMinutes: 60,
+			},
+			Ntfy: NtfyConfig{
+				Server:        "https://ntfy.sh",
+				Events:        []string{"failure"},
+				DedupeMinutes: 60,
+			},
+			Gotify: GotifyConfig{
+				Priority:      5,
+				Events:        []string{"failure"},
+				DedupeMinutes: 60,
+			},
 		},
 	}
 }
 
+// maxIncludeDepth bounds include nesting so a runaway chain fails fast.
+const maxIncludeDepth = 8
+
 // Load reads the TOML file at path, layers it on top of the defaults, and
 // validates the result. Data directories are created automatically if they
-// don't exist.
+// don't exist. See LoadResolved for include handling.
 func Load(path string) (Config, error) {
-	cfg := Default()
+	cfg, _, err := LoadResolved(path)
+	return cfg, err
+}
 
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return cfg, err
-	}
+// LoadResolved is Load, additionally returning the files that contributed
+// to the result in the order they were applied.
+//
+// A file may list other files to inherit from with a top-level include key:
+//
+//	include = ["base.toml"]
+//
+// Included files are applied first, in order, and the including file then
+// overrides only the fields it sets, so a site profile can hold just its
+// differences from a shared base. Relative paths are resolved against the
+// including file's directory, and a bare profile name such as "base" means
+// "base.toml" alongside it. Includes may nest; cycles are an error.
+func LoadResolved(path string) (Config, []string, error) {
+	cfg := Default()
 
-	if err := toml.Unmarshal(b, &cfg); err != nil {
-		return cfg, err
+	var sources []string
+	if err := loadLayer(&cfg, path, nil, &sources); err != nil {
+		return cfg, sources, err
 	}
 
 	// Expand ~ in path fields so users can write "~/.local/share/..." in TOML.
 	cfg.Data.Root = expandHome(cfg.Data.Root)
 	cfg.Data.Archive = expandHome(cfg.Data.Archive)
+	cfg.Data.Staging = expandHome(cfg.Data.Staging)
+	cfg.Gallery.Dir = expandHome(cfg.Gallery.Dir)
+	cfg.Decode.OverlayShapes = expandHome(cfg.Decode.OverlayShapes)
+	for i := range cfg.Plugins {
+		if len(cfg.Plugins[i].Command) > 0 {
+			cfg.Plugins[i].Command[0] = expandHome(cfg.Plugins[i].Command[0])
+		}
+	}
+	for i := range cfg.Scripts {
+		cfg.Scripts[i].Path = expandHome(cfg.Scripts[i].Path)
+	}
+
+	// "/ephemeris/" and "/ephemeris" are the same prefix.
+	cfg.Server.BasePath = strings.TrimRight(cfg.Server.BasePath, "/")
+
+	// The active station profile replaces the bare [station] location, so
+	// everything downstream keeps reading cfg.Station as before.
+	if name := cfg.Station.Active; name != "" {
+		p, ok := cfg.Station.Profiles[name]
+		if !ok {
+			return cfg, sources, fmt.Errorf("station.active: no [station.%s] profile is defined", name)
+		}
+		p.apply(&cfg.Station)
+	}
+
+	if err := resolveSecrets(&cfg); err != nil {
+		return cfg, sources, err
+	}
 
 	if err := validate(cfg); err != nil {
-		return cfg, err
+		return cfg, sources, err
 	}
 
-	return cfg, ensureDirs(cfg)
+	return cfg, sources, ensureDirs(cfg)
+}
+
+// loadLayer applies path's includes and then path itself onto cfg. stack
+// holds the files currently being loaded, for cycle detection.
+func loadLayer(cfg *Config, path string, stack []string, sources *[]string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	for _, p := range stack {
+		if p == abs {
+			return fmt.Errorf("include cycle: %s", strings.Join(append(stack, abs), " -> "))
+		}
+	}
+	if len(stack) >= maxIncludeDepth {
+		return fmt.Errorf("%s: includes nested deeper than %d levels", path, maxIncludeDepth)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var head struct {
+		Include []string `toml:"include"`
+	}
+	if err := toml.Unmarshal(b, &head); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, inc := range head.Include {
+		incPath := expandHome(inc)
+		if filepath.Ext(incPath) == "" {
+			incPath += ".toml"
+		}
+		if !filepath.IsAbs(incPath) {
+			incPath = filepath.Join(filepath.Dir(abs), incPath)
+		}
+		if err := loadLayer(cfg, incPath, append(stack, abs), sources); err != nil {
+			return err
+		}
+	}
+
+	if err := toml.Unmarshal(b, cfg); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := loadStationProfiles(cfg, b); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	*sources = append(*sources, abs)
+	return nil
+}
+
+// loadStationProfiles merges the [station.NAME] tables in one layer's TOML
+// into cfg.Station.Profiles. A profile defined in several layers is merged
+// field by field, like the rest of the config.
+func loadStationProfiles(cfg *Config, b []byte) error {
+	var raw struct {
+		Station map[string]any `toml:"station"`
+	}
+	if err := toml.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	for name, v := range raw.Station {
+		table, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		tb, err := toml.Marshal(table)
+		if err != nil {
+			return fmt.Errorf("station.%s: %w", name, err)
+		}
+		p := cfg.Station.Profiles[name]
+		if err := toml.NewDecoder(bytes.NewReader(tb)).DisallowUnknownFields().Decode(&p); err != nil {
+			return fmt.Errorf("station.%s: %w", name, err)
+		}
+		if cfg.Station.Profiles == nil {
+			cfg.Station.Profiles = map[string]StationProfile{}
+		}
+		cfg.Station.Profiles[name] = p
+	}
+	return nil
 }
 
 // EnsureDirectories creates the XDG config dir and data directories.
@@ -251,6 +1298,18 @@ func expandHome(path string) string {
 	return filepath.Join(home, path[1:])
 }
 
+// validateTLEURL checks that u, the TLE source at key, is an http:// or
+// https:// URL. Empty is allowed: the source is not used.
+func validateTLEURL(key, u string) error {
+	if u == "" {
+		return nil
+	}
+	if pu, err := url.Parse(u); err != nil || (pu.Scheme != "http" && pu.Scheme != "https") || pu.Host == "" {
+		return fmt.Errorf("%s must be an http:// or https:// URL", key)
+	}
+	return nil
+}
+
 func validate(cfg Config) error {
 	if cfg.Data.Root == "" {
 		return errors.New("data.root must not be empty")
@@ -258,20 +1317,417 @@ func validate(cfg Config) error {
 	if cfg.Data.Archive == "" {
 		return errors.New("data.archive must not be empty")
 	}
+	if cfg.Data.FsyncIntervalSeconds < 0 {
+		return errors.New("data.fsync_interval_seconds must be >= 0")
+	}
+	if cfg.Data.ScrubIntervalHours < 0 {
+		return errors.New("data.scrub_interval_hours must be >= 0")
+	}
+	if cfg.Data.TrashDays < 0 {
+		return errors.New("data.trash_days must be >= 0")
+	}
+	if cfg.Data.Compress != "" && !contains(Compressions, cfg.Data.Compress) {
+		return fmt.Errorf("data.compress: unknown format %q (use %s, or leave it empty to keep WAVs)", cfg.Data.Compress, strings.Join(Compressions, ", "))
+	}
+	if !contains(LogLevels, cfg.Logging.Level) {
+		return fmt.Errorf("logging.level: unknown level %q (use %s)", cfg.Logging.Level, strings.Join(LogLevels, ", "))
+	}
+	if !contains(LogFormats, cfg.Logging.Format) {
+		return fmt.Errorf("logging.format: unknown format %q (use %s)", cfg.Logging.Format, strings.Join(LogFormats, " or "))
+	}
+	for component, level := range cfg.Logging.Components {
+		if !contains(LogComponents, component) {
+			return fmt.Errorf("logging.components: unknown component %q (use %s)", component, strings.Join(LogComponents, ", "))
+		}
+		if !contains(LogLevels, level) {
+			return fmt.Errorf("logging.components.%s: unknown level %q (use %s)", component, level, strings.Join(LogLevels, ", "))
+		}
+	}
+	if cfg.Logging.MaxSizeMB < 1 {
+		return errors.New("logging.max_size_mb must be >= 1")
+	}
+	if cfg.Logging.MaxFiles < 0 {
+		return errors.New("logging.max_files must be >= 0")
+	}
+	if cfg.Server.BasePath != "" && !strings.HasPrefix(cfg.Server.BasePath, "/") {
+		return errors.New(`server.base_path must start with "/"`)
+	}
+	if cfg.Server.PublicReadonly && cfg.Server.PublicBind == "" {
+		return errors.New("server.public_bind must be set when server.public_readonly is enabled")
+	}
+	if cfg.Server.ShareMaxHours < 1 {
+		return errors.New("server.share_max_hours must be >= 1")
+	}
+	if cfg.Server.WSMaxClients < 0 {
+		return errors.New("server.ws_max_clients must be >= 0")
+	}
+	if cfg.Server.WSMaxClientsPerIP < 0 {
+		return errors.New("server.ws_max_clients_per_ip must be >= 0")
+	}
+	if cfg.Server.WSPongTimeoutSeconds < 3 {
+		return errors.New("server.ws_pong_timeout_seconds must be >= 3")
+	}
+	for i, t := range cfg.Server.APITokens {
+		if t == "" {
+			return fmt.Errorf("server.api_tokens[%d] must not be empty", i)
+		}
+	}
+	for _, o := range cfg.Server.WSAllowedOrigins {
+		if o != "*" && !strings.Contains(o, "://") {
+			return fmt.Errorf("server.ws_allowed_origins: %q must be a scheme and host such as \"http://vensat.local:3000\", or \"*\"", o)
+		}
+	}
 	if cfg.Demo.IntervalSeconds < 0 {
 		return errors.New("demo.interval_seconds must be >= 0")
 	}
 	if cfg.SDR.SampleRate <= 0 {
 		return errors.New("sdr.sample_rate must be > 0")
 	}
+	switch cfg.SDR.Backend {
+	case BackendRtlFm:
+	case BackendSoapy:
+		if cfg.SDR.SoapySampleRate < cfg.SDR.SampleRate {
+			return errors.New("sdr.soapy_sample_rate must be at least sdr.sample_rate")
+		}
+	case BackendRtlTCP:
+		if _, _, err := net.SplitHostPort(cfg.SDR.TCPAddr); err != nil {
+			return fmt.Errorf("sdr.tcp_addr must be host:port of an rtl_tcp server (got %q)", cfg.SDR.TCPAddr)
+		}
+		if r := cfg.SDR.TCPSampleRate; r < cfg.SDR.SampleRate || !rtlSampleRate(r) {
+			return errors.New("sdr.tcp_sample_rate must be at least sdr.sample_rate and within 225001-300000 or 900001-3200000")
+		}
+	default:
+		return fmt.Errorf("sdr.backend must be rtl_fm, soapy, or rtl_tcp (got %q)", cfg.SDR.Backend)
+	}
+	if cfg.SDR.BandCapture {
+		if r := cfg.SDR.BandSampleRate; r < cfg.SDR.SampleRate || cfg.SDR.Backend != BackendSoapy && !rtlSampleRate(r) {
+			return errors.New("sdr.band_sample_rate must be at least sdr.sample_rate and, for an RTL-SDR, within 225001-300000 or 900001-3200000")
+		}
+	}
 	if cfg.Station.MinElevation < 0 || cfg.Station.MinElevation > 90 {
 		return errors.New("station.min_elevation must be between 0 and 90")
 	}
+	if cfg.Station.UsableElevation < 0 || cfg.Station.UsableElevation > 90 {
+		return errors.New("station.usable_elevation must be between 0 and 90")
+	}
+	if tz := cfg.Station.Timezone; tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			return fmt.Errorf("station.timezone: unknown time zone %q", tz)
+		}
+	}
+	if cfg.Station.GeoIP && cfg.Station.GeoIPURL == "" {
+		return errors.New("station.geoip_url must be set when station.geoip is enabled")
+	}
+	if len(cfg.Station.HorizonMask) > 0 && cfg.Station.HorizonFile != "" {
+		return errors.New("station: set horizon_mask or horizon_file, not both")
+	}
+	if _, err := cfg.Station.Horizon(); err != nil {
+		return fmt.Errorf("station horizon mask: %w", err)
+	}
+	for _, name := range cfg.Station.ProfileNames() {
+		if e := cfg.Station.Profiles[name].MinElevation; e != nil && (*e < 0 || *e > 90) {
+			return fmt.Errorf("station.%s.min_elevation must be between 0 and 90", name)
+		}
+		if e := cfg.Station.Profiles[name].UsableElevation; e != nil && (*e < 0 || *e > 90) {
+			return fmt.Errorf("station.%s.usable_elevation must be between 0 and 90", name)
+		}
+		if tz := cfg.Station.Profiles[name].Timezone; tz != nil {
+			if _, err := time.LoadLocation(*tz); err != nil {
+				return fmt.Errorf("station.%s.timezone: unknown time zone %q", name, *tz)
+			}
+		}
+		st := StationConfig{}
+		cfg.Station.Profiles[name].apply(&st)
+		if len(st.HorizonMask) > 0 && st.HorizonFile != "" {
+			return fmt.Errorf("station.%s: set horizon_mask or horizon_file, not both", name)
+		}
+		if _, err := st.Horizon(); err != nil {
+			return fmt.Errorf("station.%s horizon mask: %w", name, err)
+		}
+	}
+	for name, sat := range cfg.Satellites {
+		if sat.FreqOffsetHz < -MaxFreqOffsetHz || sat.FreqOffsetHz > MaxFreqOffsetHz {
+			return fmt.Errorf("satellites.%s.freq_offset_hz must be between -%d and %d", name, MaxFreqOffsetHz, MaxFreqOffsetHz)
+		}
+		if sat.Priority < -MaxSatellitePriority || sat.Priority > MaxSatellitePriority {
+			return fmt.Errorf("satellites.%s.priority must be between -%d and %d", name, MaxSatellitePriority, MaxSatellitePriority)
+		}
+		if e := sat.MinElevation; e != nil && (*e < 0 || *e > 90) {
+			return fmt.Errorf("satellites.%s.min_elevation must be between 0 and 90", name)
+		}
+		if err := ValidateCatalogEntry(name, sat); err != nil {
+			return err
+		}
+		if err := validateTLEURL("satellites."+name+".tle_url", sat.TLEURL); err != nil {
+			return err
+		}
+	}
+	names := make([]string, 0, len(cfg.Satellites))
+	for name := range cfg.Satellites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	norads := map[int]string{}
+	for _, name := range names {
+		id := cfg.Satellites[name].NoradID
+		if other, ok := norads[id]; ok && id != 0 {
+			return fmt.Errorf("satellites.%s.norad_id %d is also used by satellites.%s", name, id, other)
+		}
+		norads[id] = name
+	}
+	if err := validateTLEURL("predict.tle_url", cfg.Predict.TLEURL); err != nil {
+		return err
+	}
+	for i, u := range cfg.Predict.TLEURLs {
+		if u == "" {
+			return fmt.Errorf("predict.tle_urls[%d] is empty", i)
+		}
+		if err := validateTLEURL(fmt.Sprintf("predict.tle_urls[%d]", i), u); err != nil {
+			return err
+		}
+	}
 	if cfg.Predict.TLERefreshHours < 1 {
 		return errors.New("predict.tle_refresh_hours must be >= 1")
 	}
 	if cfg.Predict.LookaheadHours < 1 {
 		return errors.New("predict.lookahead_hours must be >= 1")
 	}
+	if cfg.Predict.TLEMaxEpochAgeDays < 0 {
+		return errors.New("predict.tle_max_epoch_age_days must be >= 0")
+	}
+	if cfg.Predict.SunAvoidDegrees < 0 || cfg.Predict.SunAvoidDegrees > 90 {
+		return errors.New("predict.sun_avoid_degrees must be between 0 and 90")
+	}
+	if cfg.Weather.Enabled && cfg.Weather.URL == "" {
+		return errors.New("weather.url must be set when weather is enabled")
+	}
+	if cfg.Catalog.Sync && cfg.Catalog.SatNOGSURL == "" {
+		return errors.New("catalog.satnogs_url must be set when catalog.sync is enabled")
+	}
+	if cfg.Catalog.SyncHours < 1 {
+		return errors.New("catalog.sync_hours must be >= 1")
+	}
+	if cfg.Weather.SkipOvercastPercent < 0 || cfg.Weather.SkipOvercastPercent > 100 {
+		return errors.New("weather.skip_overcast_percent must be between 0 and 100")
+	}
+	for i, b := range cfg.Schedule.Blackout {
+		if _, err := b.Parse(); err != nil {
+			return fmt.Errorf("schedule.blackout[%d]: %w", i, err)
+		}
+	}
+	if cfg.Schedule.EnergyBudgetWh < 0 || cfg.Schedule.CaptureWh < 0 || cfg.Schedule.CaptureWhPerMinute < 0 {
+		return errors.New("schedule: energy_budget_wh, capture_wh and capture_wh_per_minute must be >= 0")
+	}
+	if cfg.Schedule.EnergyBudgetWh > 0 && cfg.Schedule.CaptureWh == 0 && cfg.Schedule.CaptureWhPerMinute == 0 {
+		return errors.New("schedule.energy_budget_wh needs capture_wh or capture_wh_per_minute to estimate what a capture uses")
+	}
+	if cfg.Events.RetentionDays < 0 {
+		return errors.New("events.retention_days must be >= 0")
+	}
+	if cfg.Retention.MaxAgeDays < 0 || cfg.Retention.MaxTotalBytes < 0 || cfg.Retention.MinFreeBytes < 0 {
+		return errors.New("retention: max_age_days, max_total_bytes and min_free_bytes must be >= 0")
+	}
+	if !contains(RetentionActions, cfg.Retention.Action) {
+		return fmt.Errorf("retention.action: unknown action %q (use %s)", cfg.Retention.Action, strings.Join(RetentionActions, " or "))
+	}
+	if cfg.Retention.IntervalMinutes < 1 {
+		return errors.New("retention.interval_minutes must be >= 1")
+	}
+	if cfg.Upload.Concurrency < 1 || cfg.Upload.Concurrency > MaxUploadConcurrency {
+		return fmt.Errorf("upload.concurrency must be between 1 and %d", MaxUploadConcurrency)
+	}
+	if cfg.Upload.Enabled {
+		if u, err := url.Parse(cfg.Upload.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("upload.endpoint must be an http:// or https:// URL when upload is enabled")
+		}
+		if cfg.Upload.Bucket == "" {
+			return errors.New("upload.bucket must be set when upload is enabled")
+		}
+		if cfg.Upload.Region == "" {
+			return errors.New("upload.region must be set when upload is enabled")
+		}
+		if cfg.Upload.AccessKeyID == "" || cfg.Upload.SecretAccessKey == "" {
+			return errors.New("upload.access_key_id and upload.secret_access_key must be set when upload is enabled")
+		}
+	}
+	if cfg.Gallery.Enabled {
+		if cfg.Gallery.Dir == "" {
+			return errors.New("gallery.dir must be set when the gallery is enabled")
+		}
+		if filepath.Clean(cfg.Gallery.Dir) == filepath.Clean(cfg.Data.Root) {
+			return errors.New("gallery.dir must not be data.root")
+		}
+	}
+	if t := cfg.Notify.SummaryTime; t != "" {
+		if _, err := time.Parse("15:04", t); err != nil {
+			return fmt.Errorf("notify.summary_time: %q is not a time of day such as 08:00", t)
+		}
+	}
+	if u := cfg.Notify.BaseURL; u != "" {
+		if pu, err := url.Parse(u); err != nil || (pu.Scheme != "http" && pu.Scheme != "https") || pu.Host == "" {
+			return errors.New("notify.base_url must be an http:// or https:// URL")
+		}
+	}
+	for _, t := range cfg.Notify.Templates.list() {
+		if t.text == "" {
+			continue
+		}
+		if err := tmpl.Check(t.kind, t.text); err != nil {
+			return fmt.Errorf("notify.templates.%s: %w", t.kind, err)
+		}
+	}
+	if em := cfg.Notify.Email; em.Enabled {
+		if em.Host == "" {
+			return errors.New("notify.email.host must be set when email is enabled")
+		}
+		if em.Port < 1 || em.Port > 65535 {
+			return errors.New("notify.email.port must be between 1 and 65535")
+		}
+		if !contains(EmailTLSModes, em.TLS) {
+			return fmt.Errorf("notify.email.tls: unknown mode %q (use %s)", em.TLS, strings.Join(EmailTLSModes, ", "))
+		}
+		if _, err := mail.ParseAddress(em.From); err != nil {
+			return fmt.Errorf("notify.email.from: %w", err)
+		}
+		if len(em.To) == 0 {
+			return errors.New("notify.email.to must list at least one address when email is enabled")
+		}
+		for _, to := range em.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				return fmt.Errorf("notify.email.to: %q: %w", to, err)
+			}
+		}
+	}
+	if tg := cfg.Notify.Telegram; tg.Enabled {
+		if tg.BotToken == "" || tg.ChatID == "" {
+			return errors.New("notify.telegram.bot_token and notify.telegram.chat_id must be set when Telegram is enabled")
+		}
+		if u, err := url.Parse(tg.APIURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("notify.telegram.api_url must be an http:// or https:// URL")
+		}
+		if tg.Commands && len(tg.AllowedUsers) == 0 {
+			return errors.New("notify.telegram.allowed_users must list who may send commands when notify.telegram.commands is set")
+		}
+	}
+	if nt := cfg.Notify.Ntfy; nt.Enabled {
+		if nt.Topic == "" {
+			return errors.New("notify.ntfy.topic must be set when ntfy is enabled")
+		}
+		if u, err := url.Parse(nt.Server); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("notify.ntfy.server must be an http:// or https:// URL")
+		}
+		if nt.Token != "" && nt.Username != "" {
+			return errors.New("notify.ntfy: set token or username, not both")
+		}
+		if nt.Priority < 0 || nt.Priority > 5 {
+			return errors.New("notify.ntfy.priority must be between 1 and 5, or 0 for the server's default")
+		}
+	}
+	if gt := cfg.Notify.Gotify; gt.Enabled {
+		if gt.Token == "" {
+			return errors.New("notify.gotify.token must be set when Gotify is enabled")
+		}
+		if u, err := url.Parse(gt.Server); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("notify.gotify.server must be an http:// or https:// URL")
+		}
+		if gt.Priority < 0 || gt.Priority > 10 {
+			return errors.New("notify.gotify.priority must be between 0 and 10")
+		}
+	}
+	nc := cfg.Notify
+	for _, b := range []struct {
+		name       string
+		events     []string
+		quiet      string
+		perHour    int
+		dedupeMins int
+	}{
+		{"telegram", nc.Telegram.Events, nc.Telegram.QuietHours, nc.Telegram.MaxPerHour, nc.Telegram.DedupeMinutes},
+		{"email", nc.Email.Events, nc.Email.QuietHours, nc.Email.MaxPerHour, nc.Email.DedupeMinutes},
+		{"ntfy", nc.Ntfy.Events, nc.Ntfy.QuietHours, nc.Ntfy.MaxPerHour, nc.Ntfy.DedupeMinutes},
+		{"gotify", nc.Gotify.Events, nc.Gotify.QuietHours, nc.Gotify.MaxPerHour, nc.Gotify.DedupeMinutes},
+	} {
+		for _, e := range b.events {
+			if !contains(NotifyEvents, e) {
+				return fmt.Errorf("notify.%s.events: unknown event %q (use %s)", b.name, e, strings.Join(NotifyEvents, ", "))
+			}
+		}
+		if b.quiet != "" {
+			if _, err := ParseDailyWindow(b.quiet); err != nil {
+				return fmt.Errorf("notify.%s.quiet_hours: %w", b.name, err)
+			}
+		}
+		if b.perHour < 0 {
+			return fmt.Errorf("notify.%s.max_per_hour must not be negative", b.name)
+		}
+		if b.dedupeMins < 0 {
+			return fmt.Errorf("notify.%s.dedupe_minutes must not be negative", b.name)
+		}
+	}
+	for _, e := range cfg.Decode.Enhancements {
+		if !contains(ImageEnhancements, e) {
+			return fmt.Errorf("decode.enhancements: unknown enhancement %q (use %s)", e, strings.Join(ImageEnhancements, ", "))
+		}
+	}
+	seen := map[string]bool{}
+	for i, p := range cfg.Plugins {
+		if p.Name == "" {
+			return fmt.Errorf("plugins[%d].name must not be empty", i)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("plugins: name %q is used twice", p.Name)
+		}
+		seen[p.Name] = true
+		if len(p.Command) == 0 || p.Command[0] == "" {
+			return fmt.Errorf("plugins.%s.command must name a program", p.Name)
+		}
+	}
+	seen = map[string]bool{}
+	for i, r := range cfg.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("rules[%d].name must not be empty", i)
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("rules: name %q is used twice", r.Name)
+		}
+		seen[r.Name] = true
+		if r.CooldownMinutes < 0 {
+			return fmt.Errorf("rules.%s.cooldown_minutes must be >= 0", r.Name)
+		}
+		if err := rules.Check(r.Rule()); err != nil {
+			return fmt.Errorf("rules.%s: %w", r.Name, err)
+		}
+	}
+	seen = map[string]bool{}
+	for i, s := range cfg.Scripts {
+		if s.Name == "" {
+			return fmt.Errorf("scripts[%d].name must not be empty", i)
+		}
+		if seen[s.Name] {
+			return fmt.Errorf("scripts: name %q is used twice", s.Name)
+		}
+		seen[s.Name] = true
+		if s.Path == "" {
+			return fmt.Errorf("scripts.%s.path must name a Starlark file", s.Name)
+		}
+	}
+	switch cfg.Predict.SunPolicy {
+	case "flag", "deprioritize", "skip":
+	default:
+		return fmt.Errorf("predict.sun_policy must be flag, deprioritize, or skip (got %q)", cfg.Predict.SunPolicy)
+	}
+	switch cfg.Predict.ConflictPolicy {
+	case "score", "first":
+	default:
+		return fmt.Errorf("predict.conflict_policy must be score or first (got %q)", cfg.Predict.ConflictPolicy)
+	}
+	if cfg.Predict.MinQuality < 0 || cfg.Predict.MinQuality > 100 {
+		return errors.New("predict.min_quality must be between 0 and 100")
+	}
 	return nil
 }
+
+// rtlSampleRate reports whether the RTL2832 runs at rate.
+func rtlSampleRate(rate int) bool {
+	return rate > 225_000 && rate <= 300_000 || rate > 900_000 && rate <= 3_200_000
+}
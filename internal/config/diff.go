@@ -0,0 +1,61 @@
+package config
+
+import (
+	"net/url"
+	"reflect"
+)
+
+// FieldChange is one config value that differs between two configs. Key is
+// the dotted TOML key. Old and New are display values: secrets stay
+// redacted and URL passwords are masked.
+type FieldChange struct {
+	Key string `json:"key"`
+	Old any    `json:"old"`
+	New any    `json:"new"`
+}
+
+// Diff lists the fields that differ between old and new, in struct order.
+func Diff(old, new Config) []FieldChange {
+	changes := []FieldChange{}
+	newFields := map[string]reflect.Value{}
+	_ = walkFields(reflect.ValueOf(&new).Elem(), "", func(key string, _ reflect.StructField, v reflect.Value) error {
+		newFields[key] = v
+		return nil
+	})
+	_ = walkFields(reflect.ValueOf(&old).Elem(), "", func(key string, f reflect.StructField, v reflect.Value) error {
+		nv := newFields[key]
+		if reflect.DeepEqual(v.Interface(), nv.Interface()) {
+			return nil
+		}
+		changes = append(changes, FieldChange{
+			Key: key,
+			Old: displayValue(f, v),
+			New: displayValue(f, nv),
+		})
+		return nil
+	})
+	return changes
+}
+
+// displayValue returns a field's value in a form safe to broadcast.
+func displayValue(f reflect.StructField, v reflect.Value) any {
+	if f.Tag.Get("redact") == "userinfo" && v.Kind() == reflect.String {
+		if u, err := url.Parse(v.String()); err == nil && u.User != nil {
+			return u.Redacted()
+		}
+	}
+	if f.Tag.Get("redact") == "userinfo" && v.Kind() == reflect.Slice {
+		urls := make([]string, v.Len())
+		for i := range urls {
+			urls[i] = v.Index(i).String()
+			if u, err := url.Parse(urls[i]); err == nil && u.User != nil {
+				urls[i] = u.Redacted()
+			}
+		}
+		return urls
+	}
+	if sats, ok := v.Interface().(map[string]SatelliteConfig); ok {
+		return redactSatellites(sats)
+	}
+	return v.Interface()
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HorizonPoint is one point of a station's horizon mask: the elevation, in
+// degrees, below which the sky is blocked at an azimuth.
+type HorizonPoint struct {
+	Azimuth   float64 `json:"azimuth"`
+	Elevation float64 `json:"elevation"`
+}
+
+// Horizon returns the points of the station's horizon mask, sorted by
+// azimuth: those of horizon_mask, or those read from horizon_file. It
+// returns nil for a flat horizon.
+func (st StationConfig) Horizon() ([]HorizonPoint, error) {
+	var points []HorizonPoint
+	switch {
+	case st.HorizonFile != "":
+		var err error
+		if points, err = readHorizonFile(expandHome(st.HorizonFile)); err != nil {
+			return nil, err
+		}
+	case len(st.HorizonMask) > 0:
+		for i, p := range st.HorizonMask {
+			if len(p) != 2 {
+				return nil, fmt.Errorf("point %d: want [azimuth, elevation], got %d values", i+1, len(p))
+			}
+			points = append(points, HorizonPoint{Azimuth: p[0], Elevation: p[1]})
+		}
+	default:
+		return nil, nil
+	}
+	for _, p := range points {
+		if p.Azimuth < 0 || p.Azimuth >= 360 {
+			return nil, fmt.Errorf("azimuth %g must be from 0 to below 360", p.Azimuth)
+		}
+		if p.Elevation < 0 || p.Elevation > 90 {
+			return nil, fmt.Errorf("elevation %g at azimuth %g must be between 0 and 90", p.Elevation, p.Azimuth)
+		}
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Azimuth < points[j].Azimuth })
+	return points, nil
+}
+
+// readHorizonFile reads a horizon mask from a CSV file of
+// "azimuth,elevation" lines. Blank lines, lines starting with # and a
+// header line are skipped.
+func readHorizonFile(path string) ([]HorizonPoint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var points []HorizonPoint
+	sc := bufio.NewScanner(f)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		az, el, ok := strings.Cut(line, ",")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: want azimuth,elevation", path, n)
+		}
+		a, err1 := strconv.ParseFloat(strings.TrimSpace(az), 64)
+		e, err2 := strconv.ParseFloat(strings.TrimSpace(el), 64)
+		if err1 != nil || err2 != nil {
+			if len(points) == 0 && n == 1 {
+				continue // a header such as "azimuth,elevation"
+			}
+			return nil, fmt.Errorf("%s:%d: want azimuth,elevation in degrees", path, n)
+		}
+		points = append(points, HorizonPoint{Azimuth: a, Elevation: e})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, errors.New(path + " has no points")
+	}
+	return points, nil
+}
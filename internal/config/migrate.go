@@ -0,0 +1,366 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Migration is a config generated from another tool's settings. Updates
+// hold the values that carried over, in output order; Notes list what did
+// not, and any follow-up steps.
+type Migration struct {
+	Tool    string
+	Source  string // settings file that was read
+	Updates []FileUpdate
+	Notes   []string
+}
+
+// MigrateFrom reads the settings of another satellite-receiving tool at
+// path (a settings file, or the directory holding it) and converts what it
+// can into ephemeris config values. Only "raspberry-noaa" (v1 and v2) is
+// supported.
+func MigrateFrom(tool, path string) (Migration, error) {
+	switch strings.ToLower(tool) {
+	case "raspberry-noaa", "raspberry-noaa-v2":
+		return migrateRaspberryNOAA(expandHome(path))
+	default:
+		return Migration{}, fmt.Errorf("unsupported tool %q (supported: raspberry-noaa)", tool)
+	}
+}
+
+// raspberryNOAAFiles are where raspberry-noaa keeps its settings, relative
+// to a checkout or a home directory. v2 edits config/settings.yml and
+// renders it into ~/.noaa-v2.conf; v1 uses ~/.noaa.conf.
+var raspberryNOAAFiles = []string{
+	"config/settings.yml",
+	"settings.yml",
+	".noaa-v2.conf",
+	".noaa.conf",
+}
+
+// raspberryNOAASats maps raspberry-noaa's key prefixes to catalog names.
+var raspberryNOAASats = []struct{ prefix, name string }{
+	{"noaa_15", "NOAA-15"},
+	{"noaa_18", "NOAA-18"},
+	{"noaa_19", "NOAA-19"},
+}
+
+func migrateRaspberryNOAA(path string) (Migration, error) {
+	m := Migration{Tool: "raspberry-noaa"}
+
+	src := path
+	if info, err := os.Stat(path); err != nil {
+		return m, err
+	} else if info.IsDir() {
+		src = ""
+		for _, name := range raspberryNOAAFiles {
+			if _, err := os.Stat(filepath.Join(path, name)); err == nil {
+				src = filepath.Join(path, name)
+				break
+			}
+		}
+		if src == "" {
+			return m, fmt.Errorf("no raspberry-noaa settings in %s (looked for %s)", path, strings.Join(raspberryNOAAFiles, ", "))
+		}
+	}
+	m.Source, _ = filepath.Abs(src)
+
+	s, err := readSettings(src)
+	if err != nil {
+		return m, err
+	}
+
+	lat, latOK := s.float("latitude", "lat")
+	lon, lonOK := s.float("longitude", "lon")
+	if !latOK || !lonOK {
+		return m, fmt.Errorf("%s: no station latitude and longitude; is this a raspberry-noaa settings file?", src)
+	}
+	m.set("station", "latitude", lat)
+	m.set("station", "longitude", lon)
+	if alt, ok := s.float("altitude", "alt"); ok {
+		m.set("station", "altitude", alt)
+	}
+
+	// Each setting may be per satellite (v2) or global (v1); per-satellite
+	// values are only compared across the satellites that were scheduled.
+	var enabled, disabled []string
+	for _, sat := range raspberryNOAASats {
+		on, ok := s.bool(sat.prefix+"_schedule", sat.prefix+"_rec", "schedule_noaa")
+		if ok && !on {
+			disabled = append(disabled, sat.name)
+			continue
+		}
+		enabled = append(enabled, sat.name)
+	}
+	if len(disabled) > 0 {
+		m.note("%s %s not scheduled in raspberry-noaa; ephemeris records every NOAA satellite.",
+			strings.Join(disabled, ", "), pluralVerb(len(disabled)))
+	}
+	perSat := func(suffix string, global ...string) map[string]float64 {
+		vals := map[string]float64{}
+		for _, sat := range raspberryNOAASats {
+			if !contains(enabled, sat.name) {
+				continue
+			}
+			keys := append([]string{sat.prefix + "_" + suffix}, global...)
+			if v, ok := s.float(keys...); ok {
+				vals[sat.name] = v
+			}
+		}
+		return vals
+	}
+
+	if gains := perSat("gain", "gain"); len(gains) > 0 {
+		g := pickValue(gains, math.Max)
+		m.set("sdr", "gain", g)
+		if differs(gains) {
+			m.note("Gains differ per satellite (%s); sdr.gain is one value for all, so the highest, %g, is used.", describe(gains), g)
+		}
+	}
+	if mins := perSat("sat_min_elevation", "sat_min_elevation", "sat_min_elev", "min_elevation"); len(mins) > 0 {
+		e := pickValue(mins, math.Min)
+		m.set("station", "min_elevation", e)
+		if differs(mins) {
+			m.note("Minimum elevations differ per satellite (%s); the lowest, %g, is used for all.", describe(mins), e)
+		}
+	}
+	if ppms := perSat("freq_offset", "ppm_error", "ppm", "freq_offset"); len(ppms) > 0 {
+		p := pickValue(ppms, math.Max)
+		if p != math.Round(p) {
+			m.note("PPM correction %g rounded to %g; sdr.ppm_correction is a whole number.", p, math.Round(p))
+		}
+		m.set("sdr", "ppm_correction", int(math.Round(p)))
+		if differs(ppms) {
+			m.note("PPM corrections differ per satellite (%s); %d is used for all.", describe(ppms), int(math.Round(p)))
+		}
+	}
+	if devs := perSat("sdr_device_id", "sdr_device_id", "sdr_device_index"); len(devs) > 0 {
+		d := pickValue(devs, math.Min)
+		m.set("sdr", "device_index", int(d))
+		if differs(devs) {
+			m.note("Satellites use different SDRs (%s); ephemeris uses one, device %d.", describe(devs), int(d))
+		}
+	}
+
+	// A migrated station records real passes.
+	m.set("demo", "enabled", false)
+
+	for _, key := range s.keys() {
+		if strings.HasPrefix(key, "meteor") && strings.HasSuffix(key, "_schedule") {
+			if on, _ := s.bool(key); on {
+				m.note("Meteor-M reception (%s) is not supported; only NOAA APT passes are recorded.", key)
+				break
+			}
+		}
+	}
+	if _, ok := s.float("sun_min_elevation", "sun_min_elev", "noaa_15_sun_min_elevation", "noaa_18_sun_min_elevation", "noaa_19_sun_min_elevation"); ok {
+		m.note("sun_min_elevation is not carried over: ephemeris records night passes too, see predict.sun_policy for sun handling.")
+	}
+	if on, _ := s.bool("enable_bias_tee", "bias_tee"); on {
+		m.note("The bias tee was enabled; turn it on with rtl_biast before starting the daemon.")
+	}
+
+	audio := s.str("noaa_audio_output", "audio_output")
+	if audio == "" {
+		audio = "/srv/audio"
+	}
+	m.note("raspberry-noaa names recordings in local time; bring them over with `ephctl captures --import %s --local-time`.", audio)
+	return m, nil
+}
+
+func (m *Migration) set(section, key string, v any) {
+	m.Updates = append(m.Updates, FileUpdate{Section: section, Key: key, Value: v})
+}
+
+func (m *Migration) note(format string, args ...any) {
+	m.Notes = append(m.Notes, fmt.Sprintf(format, args...))
+}
+
+// TOML renders the migration as a config file, with the notes as a comment
+// block at the top. The result is checked the way Load would check it.
+func (m Migration) TOML(now time.Time) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Ephemeris Engine config migrated from %s.\n", m.Tool)
+	fmt.Fprintf(&b, "# Source: %s\n", m.Source)
+	fmt.Fprintf(&b, "# Generated: %s\n", now.UTC().Format(time.RFC3339))
+	if len(m.Notes) > 0 {
+		b.WriteString("#\n# Review before use:\n")
+		for _, n := range m.Notes {
+			fmt.Fprintf(&b, "#   - %s\n", n)
+		}
+	}
+	b.WriteString("#\n# Anything not set here keeps its default; see configs/example.toml.\n")
+
+	// Group keys by section, keeping sections in first-seen order.
+	order := map[string]int{}
+	for _, u := range m.Updates {
+		if _, ok := order[u.Section]; !ok {
+			order[u.Section] = len(order)
+		}
+	}
+	updates := append([]FileUpdate(nil), m.Updates...)
+	sort.SliceStable(updates, func(i, j int) bool { return order[updates[i].Section] < order[updates[j].Section] })
+
+	section := ""
+	for _, u := range updates {
+		if u.Section != section {
+			section = u.Section
+			fmt.Fprintf(&b, "\n[%s]\n", section)
+		}
+		v, err := formatTOMLValue(u.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", u.Section, u.Key, err)
+		}
+		fmt.Fprintf(&b, "%s = %s\n", u.Key, v)
+	}
+
+	out := []byte(b.String())
+	cfg := Default()
+	if err := toml.Unmarshal(out, &cfg); err != nil {
+		return nil, err
+	}
+	if err := validate(cfg); err != nil {
+		return nil, fmt.Errorf("migrated config is invalid: %w", err)
+	}
+	return out, nil
+}
+
+// settings are the flat key/value pairs of a raspberry-noaa settings file,
+// with keys lower-cased. Both the YAML ("key: value") and shell
+// ("KEY=value") forms are read; nested YAML is ignored.
+type settings map[string]string
+
+func readSettings(path string) (settings, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s := settings{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" || line[0] == ' ' || line[0] == '\t' || line[0] == '#' {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		sep := strings.IndexAny(line, ":=")
+		if sep <= 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:sep]))
+		val := strings.TrimSpace(line[sep+1:])
+		if i := strings.Index(val, " #"); i >= 0 {
+			val = strings.TrimSpace(val[:i])
+		}
+		s[key] = strings.Trim(val, `"'`)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(s) == 0 {
+		return nil, errors.New(path + ": no settings found")
+	}
+	return s, nil
+}
+
+// str returns the first of keys that is set.
+func (s settings) str(keys ...string) string {
+	for _, k := range keys {
+		if v, ok := s[k]; ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (s settings) float(keys ...string) (float64, bool) {
+	for _, k := range keys {
+		if v, ok := s[k]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func (s settings) bool(keys ...string) (bool, bool) {
+	for _, k := range keys {
+		switch strings.ToLower(s[k]) {
+		case "true", "yes", "on", "1":
+			return true, true
+		case "false", "no", "off", "0":
+			return false, true
+		}
+	}
+	return false, false
+}
+
+func (s settings) keys() []string {
+	out := make([]string, 0, len(s))
+	for k := range s {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// pickValue folds vals with pick, such as math.Max.
+func pickValue(vals map[string]float64, pick func(a, b float64) float64) float64 {
+	first := true
+	var out float64
+	for _, v := range vals {
+		if first {
+			out, first = v, false
+			continue
+		}
+		out = pick(out, v)
+	}
+	return out
+}
+
+func differs(vals map[string]float64) bool {
+	return pickValue(vals, math.Max) != pickValue(vals, math.Min)
+}
+
+// describe renders per-satellite values as "NOAA-15 37.2, NOAA-19 40".
+func describe(vals map[string]float64) string {
+	names := make([]string, 0, len(vals))
+	for name := range vals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%s %g", name, vals[name])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func pluralVerb(n int) string {
+	if n == 1 {
+		return "was"
+	}
+	return "were"
+}
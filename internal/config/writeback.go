@@ -0,0 +1,216 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileUpdate sets one key in a TOML config file. Value must be a bool,
+// int, float64, or string.
+type FileUpdate struct {
+	Section string
+	Key     string
+	Value   any
+}
+
+// writeBackMarker starts the comment WriteBack leaves above a line it set.
+// An existing marker is replaced rather than stacked on repeated writes.
+const writeBackMarker = "# set from "
+
+var sectionRe = regexp.MustCompile(`^\s*\[\s*([A-Za-z0-9_.-]+)\s*\]\s*(#.*)?$`)
+
+// WriteBack edits the config file at path so each update's key holds its
+// value, leaving every other line, comment, and include untouched. Changed
+// lines get a comment naming source and the time, along with the previous
+// value. The edited file is validated with LoadResolved before it
+// atomically replaces the original.
+func WriteBack(path string, updates []FileUpdate, source string, now time.Time) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+
+	for _, u := range updates {
+		value, err := formatTOMLValue(u.Value)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", u.Section, u.Key, err)
+		}
+		lines = setKey(lines, u.Section, u.Key, value, source, now)
+	}
+	return replaceConfig(path, lines)
+}
+
+// RemoveSection deletes the [section] table and its keys from the config
+// file at path, leaving every other line untouched. Comments directly
+// above the next table header stay with it. It reports false, writing
+// nothing, when the file has no such table.
+func RemoveSection(path, section string) (bool, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+	lines := strings.Split(strings.TrimRight(string(b), "\n"), "\n")
+
+	start, end := -1, len(lines) // the table is lines[start:end]
+	for i, line := range lines {
+		m := sectionRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if start >= 0 {
+			end = i
+			break
+		}
+		if m[1] == section {
+			start = i
+		}
+	}
+	if start < 0 {
+		return false, nil
+	}
+	for end < len(lines) && end > start+1 && strings.HasPrefix(strings.TrimSpace(lines[end-1]), "#") {
+		end--
+	}
+	lines = append(lines[:start:start], lines[end:]...)
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return true, replaceConfig(path, lines)
+}
+
+// replaceConfig validates lines as a config file with LoadResolved and
+// atomically replaces the file at path with them.
+func replaceConfig(path string, lines []string) error {
+	out := []byte(strings.Join(lines, "\n") + "\n")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	// The temp file sits beside the original so relative includes resolve
+	// the same way during validation and the rename stays atomic.
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(out); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	if _, _, err := LoadResolved(tmp.Name()); err != nil {
+		return fmt.Errorf("updated config would not load: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// setKey sets key in section to value, inserting the key or the section if
+// missing, and returns the edited lines.
+func setKey(lines []string, section, key, value, source string, now time.Time) []string {
+	keyRe := regexp.MustCompile(`^(\s*)` + regexp.QuoteMeta(key) + `\s*=\s*(.*)$`)
+	stamp := now.UTC().Format(time.RFC3339)
+
+	start, end := -1, len(lines) // section body is lines[start:end]
+	for i, line := range lines {
+		m := sectionRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		if start >= 0 {
+			end = i
+			break
+		}
+		if m[1] == section {
+			start = i + 1
+		}
+	}
+
+	if start < 0 {
+		if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		return append(lines,
+			"["+section+"]",
+			fmt.Sprintf("%s%s at %s", writeBackMarker, source, stamp),
+			key+" = "+value,
+		)
+	}
+
+	for i := start; i < end; i++ {
+		m := keyRe.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		old := stripInlineComment(m[2])
+		if old == value {
+			return lines
+		}
+		comment := fmt.Sprintf("%s%s%s at %s (was %s)", m[1], writeBackMarker, source, stamp, old)
+		lines[i] = m[1] + key + " = " + value
+		if i > start && strings.HasPrefix(strings.TrimSpace(lines[i-1]), writeBackMarker) {
+			lines[i-1] = comment
+			return lines
+		}
+		return insertLines(lines, i, comment)
+	}
+
+	// Key missing: add it after the section's last non-blank line.
+	at := end
+	for at > start && strings.TrimSpace(lines[at-1]) == "" {
+		at--
+	}
+	return insertLines(lines, at,
+		fmt.Sprintf("%s%s at %s", writeBackMarker, source, stamp),
+		key+" = "+value,
+	)
+}
+
+func insertLines(lines []string, at int, add ...string) []string {
+	out := make([]string, 0, len(lines)+len(add))
+	out = append(out, lines[:at]...)
+	out = append(out, add...)
+	return append(out, lines[at:]...)
+}
+
+// stripInlineComment drops a trailing "# ..." from an unquoted value.
+func stripInlineComment(v string) string {
+	if !strings.ContainsAny(v, `"'`) {
+		if i := strings.Index(v, "#"); i >= 0 {
+			v = v[:i]
+		}
+	}
+	return strings.TrimSpace(v)
+}
+
+// formatTOMLValue renders a scalar as a TOML literal. Floats always carry a
+// decimal point so they round-trip as floats.
+func formatTOMLValue(v any) (string, error) {
+	switch x := v.(type) {
+	case bool:
+		return strconv.FormatBool(x), nil
+	case int:
+		return strconv.Itoa(x), nil
+	case float64:
+		s := strconv.FormatFloat(x, 'f', -1, 64)
+		if !strings.ContainsAny(s, ".eEn") {
+			s += ".0"
+		}
+		return s, nil
+	case string:
+		return strconv.Quote(x), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", v)
+	}
+}
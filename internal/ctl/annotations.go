@@ -0,0 +1,64 @@
+package ctl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AnnotationsOptions configures the annotations command.
+type AnnotationsOptions struct {
+	Limit int
+	JSON  bool
+}
+
+// Annotations lists recent capture-window and failure annotations.
+func Annotations(baseURL string, opts AnnotationsOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	path := "/api/annotations"
+	if opts.Limit > 0 {
+		path += fmt.Sprintf("?limit=%d", opts.Limit)
+	}
+
+	var resp struct {
+		Annotations []struct {
+			ID      int      `json:"id"`
+			Time    int64    `json:"time"`
+			TimeEnd int64    `json:"time_end"`
+			Title   string   `json:"title"`
+			Text    string   `json:"text"`
+			Tags    []string `json:"tags"`
+		} `json:"annotations"`
+	}
+	if err := getJSON(baseURL, path, &resp); err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  ANNOTATIONS"))
+
+	if len(resp.Annotations) == 0 {
+		fmt.Println(colorize(dim, "  "+rule(24)))
+		fmt.Println("  No annotations recorded yet.")
+	} else {
+		t := newTable("  ", "Start", "Duration", "Title", "Text")
+		t.alignRight(1)
+		for _, a := range resp.Annotations {
+			start := time.UnixMilli(a.Time).Local().Format("2006-01-02 15:04:05")
+			dur := "open"
+			if a.TimeEnd != 0 {
+				dur = formatDuration(time.Duration(a.TimeEnd-a.Time) * time.Millisecond)
+			}
+			t.row(start, dur, a.Title, a.Text)
+		}
+		t.flush()
+	}
+
+	fmt.Println()
+	return nil
+}
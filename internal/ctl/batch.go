@@ -0,0 +1,34 @@
+package ctl
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Batch runs several GET paths in one POST /api/batch round trip and
+// prints the combined responses as JSON.
+func Batch(baseURL string, paths []string) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	type op struct {
+		Path string `json:"path"`
+	}
+	body := struct {
+		Requests []op `json:"requests"`
+	}{}
+	for _, p := range paths {
+		body.Requests = append(body.Requests, op{Path: p})
+	}
+
+	var resp struct {
+		Responses []struct {
+			Path   string          `json:"path"`
+			Status int             `json:"status"`
+			Body   json.RawMessage `json:"body"`
+		} `json:"responses"`
+	}
+	if err := postJSON(baseURL, "/api/batch", body, &resp); err != nil {
+		return err
+	}
+	return printJSON(resp)
+}
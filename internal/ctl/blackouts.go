@@ -0,0 +1,71 @@
+package ctl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Blackouts lists the [[schedule.blackout]] windows, when each is next in
+// force, and which are in force now.
+func Blackouts(baseURL string, jsonOutput bool) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	var resp struct {
+		Timezone string `json:"timezone"`
+		Active   bool   `json:"active"`
+		Windows  []struct {
+			Name   string   `json:"name"`
+			Window string   `json:"window"`
+			Days   []string `json:"days"`
+			Active bool     `json:"active"`
+			Start  string   `json:"start"`
+			End    string   `json:"end"`
+		} `json:"windows"`
+	}
+	if err := getJSON(baseURL, "/api/blackouts", &resp); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("blackouts.title")))
+	if len(resp.Windows) == 0 {
+		fmt.Printf("  %s\n", colorize(dim, rule(40)))
+		fmt.Println("  " + tr("blackouts.none"))
+		fmt.Println()
+		return nil
+	}
+	f := newFieldList("  ")
+	f.add(tr("blackouts.timezone"), resp.Timezone)
+	f.flush()
+	fmt.Println()
+
+	t := newTable("  ", tr("col.name"), tr("col.window"), tr("col.days"), tr("col.status"))
+	for _, w := range resp.Windows {
+		days := tr("blackouts.every_day")
+		if len(w.Days) > 0 {
+			days = strings.Join(w.Days, ",")
+		}
+		status := tr("blackouts.next", blackoutTime(w.Start), blackoutTime(w.End))
+		if w.Active {
+			status = colorize(yellow, tr("blackouts.active", blackoutTime(w.End)))
+		}
+		t.row(w.Name, w.Window, days, status)
+	}
+	t.flush()
+	fmt.Println()
+	return nil
+}
+
+// blackoutTime renders a time of a blackout window in the station's zone,
+// which the daemon gives it in, as the windows are written that way.
+func blackoutTime(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	return t.Format("Mon 15:04")
+}
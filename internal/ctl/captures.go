@@ -1,21 +1,116 @@
 package ctl
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"time"
 )
 
 // CapturesOptions configures the captures command.
 type CapturesOptions struct {
-	Delete string
-	JSON   bool
+	Delete   string
+	Download string
+	Dest     string // download path; defaults to the capture's filename
+	NoVerify bool   // download even if the daemon finds a checksum mismatch
+
+	// Tag adds AddTags to and removes RemoveTags from a capture.
+	Tag        string
+	AddTags    []string
+	RemoveTags []string
+
+	// Upload queues a capture for upload to object storage again.
+	Upload string
+
+	// Restore brings a deleted capture back from the trash; Trash lists
+	// what is there.
+	Restore string
+	Trash   bool
+
+	// Import registers files from another tool, at these paths on the
+	// daemon's host.
+	Import    []string
+	Satellite string // assumed for imported files whose names do not say
+	Move      bool
+	LocalTime bool
+	DryRun    bool
+
+	JSON bool
 }
 
-// Captures lists or deletes capture files on the daemon.
+// Captures lists, downloads, imports, deletes, or restores capture files
+// on the daemon.
 func Captures(baseURL string, opts CapturesOptions) error {
 	baseURL = strings.TrimRight(baseURL, "/")
 
+	if opts.Download != "" {
+		return downloadCapture(baseURL, opts)
+	}
+	if len(opts.Import) > 0 {
+		return importCaptures(baseURL, opts)
+	}
+	if opts.Tag != "" {
+		var result struct {
+			OK   bool     `json:"ok"`
+			Name string   `json:"name"`
+			Tags []string `json:"tags"`
+		}
+		body := map[string]any{"name": opts.Tag, "add": opts.AddTags, "remove": opts.RemoveTags}
+		if err := postJSON(baseURL, "/api/captures/tag", body, &result); err != nil {
+			return err
+		}
+		if opts.JSON {
+			return printJSON(result)
+		}
+		tags := strings.Join(result.Tags, ", ")
+		if tags == "" {
+			tags = colorize(dim, tr("captures.no_tags"))
+		}
+		fmt.Printf("\n  %s  %s: %s\n\n", colorize(green, tr("captures.tagged")), result.Name, tags)
+		return nil
+	}
+	if opts.Trash {
+		return captureTrash(baseURL, opts.JSON)
+	}
+	if opts.Restore != "" {
+		var result struct {
+			OK      bool   `json:"ok"`
+			Message string `json:"message"`
+		}
+		if err := postJSON(baseURL, "/api/captures/restore", map[string]any{"name": opts.Restore}, &result); err != nil {
+			return err
+		}
+		if opts.JSON {
+			return printJSON(result)
+		}
+		fmt.Printf("\n  %s  %s\n\n", colorize(green, tr("captures.restored")), result.Message)
+		return nil
+	}
+	if opts.Upload != "" {
+		var result struct {
+			OK     bool         `json:"ok"`
+			Name   string       `json:"name"`
+			Upload uploadStatus `json:"upload"`
+		}
+		if err := postJSON(baseURL, "/api/captures/upload", map[string]any{"name": opts.Upload}, &result); err != nil {
+			return err
+		}
+		if opts.JSON {
+			return printJSON(result)
+		}
+		fmt.Printf("\n  %s  %s\n\n", colorize(green, tr("captures.requeued")), result.Name)
+		return nil
+	}
+
 	// Handle deletion.
 	if opts.Delete != "" {
 		url := baseURL + "/api/captures?name=" + opts.Delete
@@ -41,9 +136,9 @@ func Captures(baseURL string, opts CapturesOptions) error {
 			return printJSON(result)
 		}
 		if result.OK {
-			fmt.Printf("\n  %s  %s\n\n", colorize(green, "DELETED"), result.Message)
+			fmt.Printf("\n  %s  %s\n\n", colorize(green, tr("captures.deleted")), result.Message)
 		} else {
-			fmt.Printf("\n  %s  %s\n\n", colorize(red, "ERROR"), result.Error)
+			fmt.Printf("\n  %s  %s\n\n", colorize(red, tr("common.error")), result.Error)
 		}
 		return nil
 	}
@@ -51,10 +146,28 @@ func Captures(baseURL string, opts CapturesOptions) error {
 	// List captures.
 	var resp struct {
 		Captures []struct {
-			Filename  string `json:"filename"`
-			Satellite string `json:"satellite"`
-			Timestamp string `json:"timestamp"`
-			Size      int64  `json:"size"`
+			Filename  string   `json:"filename"`
+			Satellite string   `json:"satellite"`
+			Timestamp string   `json:"timestamp"`
+			Size      int64    `json:"size"`
+			Station   string   `json:"station,omitempty"`
+			SHA256    string   `json:"sha256,omitempty"`
+			Corrupt   bool     `json:"corrupt,omitempty"`
+			Imported  string   `json:"imported_from,omitempty"`
+			Images    []string `json:"images,omitempty"`
+			Tags      []string `json:"tags,omitempty"`
+			// Upload is a pointer so the JSON output still omits it.
+			Upload      *uploadStatus `json:"upload,omitempty"`
+			URL         string        `json:"url,omitempty"`
+			Decode      string        `json:"decode_status"`
+			DecodeError string        `json:"decode_error,omitempty"`
+			Products    []struct {
+				Name string `json:"name"`
+				URL  string `json:"url"`
+			} `json:"products,omitempty"`
+			ImageScore  *int     `json:"image_score,omitempty"`
+			SNR         *float64 `json:"snr_db,omitempty"`
+			SyncPercent *int     `json:"sync_percent,omitempty"`
 		} `json:"captures"`
 	}
 	if err := getJSON(baseURL, "/api/captures", &resp); err != nil {
@@ -66,19 +179,296 @@ func Captures(baseURL string, opts CapturesOptions) error {
 	}
 
 	fmt.Println()
-	fmt.Println(header("  CAPTURES"))
+	fmt.Println(header("  " + tr("captures.title")))
 
 	if len(resp.Captures) == 0 {
-		fmt.Println(colorize(dim, "  ────────────────────────"))
-		fmt.Println("  No capture files found.")
+		fmt.Println(colorize(dim, "  "+rule(24)))
+		fmt.Println("  " + tr("captures.none"))
 	} else {
-		t := newTable("  ", "Satellite", "Timestamp", "Size", "Filename")
+		t := newTable("  ", tr("col.satellite"), tr("col.timestamp"), tr("col.size"), tr("col.decode"), tr("col.filename"))
 		t.alignRight(2)
 		for _, c := range resp.Captures {
-			t.row(c.Satellite, c.Timestamp, formatBytes(c.Size), c.Filename)
+			name := c.Filename
+			if c.Imported != "" {
+				name += "  " + colorize(dim, tr("captures.imported"))
+			}
+			if len(c.Tags) > 0 {
+				name += "  " + colorize(cyan, "#"+strings.Join(c.Tags, " #"))
+			}
+			if c.Corrupt {
+				name += "  " + colorize(red, tr("captures.corrupt"))
+			}
+			if c.Upload != nil {
+				name += "  " + c.Upload.label()
+			}
+			t.row(c.Satellite, c.Timestamp, formatBytes(c.Size), decodeLabel(c.Decode, c.ImageScore, c.SNR), name)
 		}
 		t.flush()
 	}
 	fmt.Println()
 	return nil
 }
+
+// captureTrash lists the deleted captures that can still be restored, and
+// when each will be purged.
+func captureTrash(baseURL string, jsonOutput bool) error {
+	var resp struct {
+		TrashDays int `json:"trash_days"`
+		Captures  []struct {
+			Name      string   `json:"name"`
+			DeletedAt string   `json:"deleted_at"`
+			Files     []string `json:"files"`
+			Bytes     int64    `json:"bytes"`
+			PurgeAt   string   `json:"purge_at,omitempty"`
+		} `json:"captures"`
+	}
+	if err := getJSON(baseURL, "/api/captures/trash", &resp); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("captures.trash_title")))
+	if resp.TrashDays == 0 {
+		fmt.Println(colorize(dim, "  "+rule(24)))
+		fmt.Println("  " + tr("captures.trash_off"))
+	}
+	if len(resp.Captures) == 0 {
+		if resp.TrashDays > 0 {
+			fmt.Println(colorize(dim, "  "+rule(24)))
+			fmt.Println("  " + tr("captures.trash_empty"))
+		}
+		fmt.Println()
+		return nil
+	}
+	t := newTable("  ", tr("col.filename"), tr("col.size"), tr("captures.col_deleted"), tr("captures.col_purge"))
+	t.alignRight(1)
+	for _, c := range resp.Captures {
+		t.row(c.Name, formatBytes(c.Bytes), trashTime(c.DeletedAt), trashTime(c.PurgeAt))
+	}
+	t.flush()
+	fmt.Println()
+	fmt.Println("  " + colorize(dim, tr("captures.trash_hint")))
+	fmt.Println()
+	return nil
+}
+
+// trashTime renders a time in the trash listing in local time.
+func trashTime(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// decodeLabel renders a capture's decode status for the captures list,
+// with the image score and SNR of a finished decode.
+func decodeLabel(status string, score *int, snr *float64) string {
+	switch status {
+	case "done":
+		if score == nil || snr == nil {
+			return colorize(green, tr("captures.decode_done"))
+		}
+		return colorize(green, tr("captures.decode_scored", *score, *snr))
+	case "failed":
+		return colorize(red, tr("captures.decode_failed"))
+	case "":
+		return "-"
+	}
+	return colorize(dim, tr("captures.decode_"+status))
+}
+
+// importCaptures asks the daemon to register recordings and images made by
+// another tool. Relative paths are made absolute here, which is only
+// meaningful when the daemon runs on this machine.
+func importCaptures(baseURL string, opts CapturesOptions) error {
+	paths := make([]string, len(opts.Import))
+	for i, p := range opts.Import {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return err
+		}
+		paths[i] = abs
+	}
+	body, err := json.Marshal(map[string]any{
+		"paths":      paths,
+		"satellite":  opts.Satellite,
+		"move":       opts.Move,
+		"local_time": opts.LocalTime,
+		"dry_run":    opts.DryRun,
+	})
+	if err != nil {
+		return err
+	}
+	// No overall timeout: the daemon copies and hashes every file before
+	// it answers.
+	client := &http.Client{Transport: tokenTransport{}}
+	resp, err := client.Post(baseURL+"/api/captures/import", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		DryRun   bool `json:"dry_run"`
+		Imported []struct {
+			Source        string   `json:"source"`
+			Filename      string   `json:"filename"`
+			Satellite     string   `json:"satellite"`
+			AOS           string   `json:"aos"`
+			Images        []string `json:"images,omitempty"`
+			TimeFromMtime bool     `json:"time_from_mtime,omitempty"`
+		} `json:"imported"`
+		Skipped []struct {
+			Source string `json:"source"`
+			Reason string `json:"reason"`
+		} `json:"skipped"`
+	}
+	if err := decodeJSON(resp, &result); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(result)
+	}
+
+	fmt.Println()
+	title := tr("captures.import_title")
+	if result.DryRun {
+		title += " " + tr("captures.dry_run")
+	}
+	fmt.Println(header("  " + title))
+	if len(result.Imported) == 0 {
+		fmt.Println(colorize(dim, "  "+rule(24)))
+		fmt.Println("  " + tr("captures.import_none"))
+	} else {
+		t := newTable("  ", tr("col.filename"), tr("col.source"))
+		for _, c := range result.Imported {
+			name := c.Filename
+			if c.TimeFromMtime {
+				name += "  " + colorize(yellow, tr("captures.time_from_mtime"))
+			}
+			t.row(name, c.Source)
+			for _, img := range c.Images {
+				t.row("  "+colorize(dim, glyph("└", "+"))+" "+img, "")
+			}
+		}
+		t.flush()
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Printf("\n  %s\n", colorize(bold, fmt.Sprintf(tr("captures.skipped"), len(result.Skipped))))
+		for _, s := range result.Skipped {
+			fmt.Printf("    %s %s  %s\n", colorize(yellow, glyph("–", "-")), s.Source, colorize(dim, s.Reason))
+		}
+	}
+	fmt.Println()
+	return nil
+}
+
+// downloadCapture fetches one capture. The daemon verifies the file
+// against its recorded checksum before sending it; the download is then
+// hashed here and compared with the Repr-Digest header, so corruption in
+// transit or on either disk is caught. A mismatched download is deleted.
+func downloadCapture(baseURL string, opts CapturesOptions) error {
+	path := "/api/captures/file?name=" + url.QueryEscape(opts.Download)
+	if opts.NoVerify {
+		path += "&verify=false"
+	}
+	// No overall timeout: a whole pass is tens of megabytes and the
+	// daemon hashes it before the first byte is sent.
+	client := &http.Client{Transport: tokenTransport{}}
+	resp, err := client.Get(baseURL + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return decodeJSON(resp, &struct{}{})
+	}
+
+	dest := opts.Dest
+	if dest == "" {
+		dest = opts.Download
+	}
+	tmp := dest + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	sum := hex.EncodeToString(h.Sum(nil))
+	want := reprDigestSHA256(resp.Header.Get("Repr-Digest"))
+	if want != "" && want != sum {
+		os.Remove(tmp)
+		return fmt.Errorf("checksum mismatch for %s: daemon has %s, received %s", opts.Download, want, sum)
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if opts.JSON {
+		return printJSON(map[string]any{
+			"file":     dest,
+			"bytes":    n,
+			"sha256":   sum,
+			"verified": want != "",
+		})
+	}
+	fmt.Println()
+	fmt.Printf("  %s  %s (%s)\n", colorize(green, tr("captures.downloaded")), dest, formatBytes(n))
+	note := tr("captures.verified")
+	if want == "" {
+		note = tr("captures.unverified")
+	}
+	fmt.Printf("  %s  %s\n", colorize(dim, "SHA-256"), sum+"  "+colorize(dim, note))
+	fmt.Println()
+	return nil
+}
+
+// reprDigestSHA256 extracts the hex SHA-256 from a Repr-Digest header
+// value such as "sha-256=:<base64>:", or returns "" if there is none.
+func reprDigestSHA256(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		alg, val, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || alg != "sha-256" {
+			continue
+		}
+		b, err := base64.StdEncoding.DecodeString(strings.Trim(val, ":"))
+		if err != nil || len(b) != sha256.Size {
+			return ""
+		}
+		return hex.EncodeToString(b)
+	}
+	return ""
+}
+
+// uploadStatus is how a capture's upload to object storage stands.
+type uploadStatus struct {
+	State    string `json:"state"`
+	URL      string `json:"url,omitempty"`
+	Error    string `json:"error,omitempty"`
+	Attempts int    `json:"attempts,omitempty"`
+}
+
+// label renders the upload state for the captures list.
+func (u uploadStatus) label() string {
+	switch u.State {
+	case "uploaded":
+		return colorize(green, tr("captures.uploaded"))
+	case "failed":
+		return colorize(red, tr("captures.upload_failed"))
+	}
+	return colorize(dim, tr("captures.upload_"+u.State))
+}
@@ -0,0 +1,112 @@
+package ctl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CatalogSyncOptions configures the catalog-sync command.
+type CatalogSyncOptions struct {
+	Run  bool // start a sync now instead of showing the last one
+	JSON bool
+}
+
+// CatalogSync shows the last SatNOGS DB catalog sync and its schedule, or
+// starts a sync via POST /api/catalog-sync.
+func CatalogSync(baseURL string, opts CatalogSyncOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	if opts.Run {
+		var result struct {
+			OK      bool   `json:"ok"`
+			Message string `json:"message"`
+		}
+		if err := postJSON(baseURL, "/api/catalog-sync", nil, &result); err != nil {
+			return err
+		}
+		if opts.JSON {
+			return printJSON(result)
+		}
+		fmt.Printf("\n  %s  %s\n", colorize(green, tr("catalog_sync.started")), result.Message)
+		fmt.Printf("  %s\n\n", colorize(dim, tr("catalog_sync.follow")))
+		return nil
+	}
+
+	var resp struct {
+		Enabled    bool   `json:"enabled"`
+		SatNOGSURL string `json:"satnogs_url"`
+		SyncHours  int    `json:"sync_hours"`
+		Running    bool   `json:"running"`
+		NextDue    string `json:"next_due,omitempty"`
+		Last       *struct {
+			SyncedAt   string `json:"synced_at"`
+			Source     string `json:"source"`
+			Satellites []struct {
+				Satellite   string `json:"satellite"`
+				NoradID     int    `json:"norad_id"`
+				Name        string `json:"satnogs_name"`
+				Status      string `json:"status"`
+				FreqHz      int    `json:"freq_hz"`
+				Transmitter string `json:"transmitter"`
+				Error       string `json:"error"`
+			} `json:"satellites"`
+		} `json:"last"`
+	}
+	if err := getJSON(baseURL, "/api/catalog-sync", &resp); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("catalog_sync.title")))
+	fmt.Printf("  %s\n", colorize(dim, rule(40)))
+	f := newFieldList("  ")
+	schedule := colorize(dim, tr("catalog_sync.off"))
+	if resp.Enabled {
+		schedule = tr("catalog_sync.schedule", resp.SyncHours, resp.NextDue)
+	}
+	f.add(tr("catalog_sync.schedule_label"), schedule)
+	f.add(tr("catalog_sync.source_label"), resp.SatNOGSURL)
+	if resp.Running {
+		f.add(tr("catalog_sync.status_label"), colorize(yellow, tr("catalog_sync.running")))
+	}
+
+	last := resp.Last
+	if last == nil {
+		f.add(tr("catalog_sync.last_label"), colorize(dim, tr("catalog_sync.never")))
+		f.flush()
+		fmt.Println()
+		return nil
+	}
+	f.add(tr("catalog_sync.last_label"), last.SyncedAt)
+	f.flush()
+	fmt.Println()
+
+	t := newTable("  ", tr("col.name"), tr("col.norad_id"), tr("col.status"), tr("col.frequency"), tr("col.transmitter"))
+	for _, s := range last.Satellites {
+		status := s.Status
+		switch {
+		case s.Error != "":
+			status = colorize(red, tr("catalog_sync.failed"))
+		case status == "alive":
+			status = colorize(green, status)
+		case status != "":
+			status = colorize(yellow, status)
+		}
+		freq := colorize(dim, "-")
+		if s.FreqHz != 0 {
+			freq = tr("pass.mhz", float64(s.FreqHz)/1e6)
+		}
+		t.row(s.Satellite, fmt.Sprintf("%d", s.NoradID), status, freq, s.Transmitter)
+	}
+	t.flush()
+	for _, s := range last.Satellites {
+		if s.Error != "" {
+			fmt.Printf("  %s %s: %s\n", colorize(red, glyph("✗", "x")), s.Satellite, colorize(dim, s.Error))
+		}
+	}
+	fmt.Println()
+	return nil
+}
@@ -10,12 +10,47 @@ import (
 	"time"
 )
 
-var httpClient = &http.Client{Timeout: 5 * time.Second}
+var httpClient = &http.Client{Timeout: 5 * time.Second, Transport: tokenTransport{}}
+
+// apiToken is sent as a bearer token with every request; see SetToken.
+var apiToken string
+
+// SetToken sets the API token (one of the daemon's server.api_tokens) sent
+// with every request. Control commands fail with 401 without it when the
+// daemon has tokens configured.
+func SetToken(token string) {
+	apiToken = token
+}
+
+// tokenTransport adds the API token to requests that do not already carry
+// their own Authorization header, such as the operator token of
+// config --show-secrets.
+type tokenTransport struct{}
+
+func (tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if apiToken != "" && req.Header.Get("Authorization") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+apiToken)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
 
 // getJSON sends a GET request and decodes the JSON response into dst.
 func getJSON(baseURL, path string, dst any) error {
+	return getJSONAuth(baseURL, path, "", dst)
+}
+
+// getJSONAuth is getJSON with an optional bearer token.
+func getJSONAuth(baseURL, path, token string, dst any) error {
 	url := strings.TrimRight(baseURL, "/") + path
-	resp, err := httpClient.Get(url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return err
 	}
@@ -3,21 +3,51 @@ package ctl
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
-// Config fetches and displays the daemon's running configuration.
-func Config(baseURL string, jsonOutput bool) error {
+// ConfigOptions configures the config command.
+type ConfigOptions struct {
+	Resolved    bool   // re-resolve the file and its includes from disk
+	ShowSecrets bool   // reveal secret values; local host only
+	Token       string // operator token, if the daemon has one configured
+	JSON        bool
+}
+
+// Config fetches and displays the daemon's running configuration, or with
+// Resolved, the merged result of the config file and its includes. Secrets
+// are redacted by the daemon unless ShowSecrets is set and the request is
+// authorized.
+func Config(baseURL string, opts ConfigOptions) error {
 	baseURL = strings.TrimRight(baseURL, "/")
 
 	// Decode into a generic map to preserve all fields for both display modes.
 	var raw json.RawMessage
-	if err := getJSON(baseURL, "/api/config", &raw); err != nil {
+	var resolved struct {
+		Path    string          `json:"path"`
+		Sources []string        `json:"sources"`
+		Config  json.RawMessage `json:"config"`
+	}
+	if opts.Resolved {
+		if err := getJSON(baseURL, "/api/config?resolved=true", &resolved); err != nil {
+			return err
+		}
+		raw = resolved.Config
+		if opts.JSON {
+			return printJSON(resolved)
+		}
+	} else if opts.ShowSecrets {
+		if err := getJSONAuth(baseURL, "/api/config?show_secrets=true", opts.Token, &raw); err != nil {
+			return err
+		}
+	} else if err := getJSON(baseURL, "/api/config", &raw); err != nil {
 		return err
 	}
 
-	if jsonOutput {
+	if opts.JSON {
 		var v any
 		_ = json.Unmarshal(raw, &v)
 		return printJSON(v)
@@ -26,63 +56,298 @@ func Config(baseURL string, jsonOutput bool) error {
 	// Decode into ordered sections for human-readable output.
 	var cfg struct {
 		Data struct {
-			Root    string `json:"root"`
-			Archive string `json:"archive"`
+			Root            string `json:"root"`
+			Archive         string `json:"archive"`
+			Staging         string `json:"staging"`
+			FsyncInterval   int    `json:"fsync_interval_seconds"`
+			FsyncOnFinalize bool   `json:"fsync_on_finalize"`
+			ScrubInterval   int    `json:"scrub_interval_hours"`
+			Compress        string `json:"compress"`
 		} `json:"data"`
 		Logging struct {
-			Level string `json:"level"`
+			Level     string `json:"level"`
+			Format    string `json:"format"`
+			File      bool   `json:"file"`
+			MaxSizeMB int    `json:"max_size_mb"`
+			MaxFiles  int    `json:"max_files"`
 		} `json:"logging"`
 		Server struct {
-			Bind string `json:"bind"`
+			Bind           string   `json:"bind"`
+			BasePath       string   `json:"base_path"`
+			PublicReadonly bool     `json:"public_readonly"`
+			PublicBind     string   `json:"public_bind"`
+			WSOrigins      []string `json:"ws_allowed_origins"`
+			WSMaxClients   int      `json:"ws_max_clients"`
+			WSMaxPerIP     int      `json:"ws_max_clients_per_ip"`
+			APITokens      []string `json:"api_tokens"`
+			WSPongTimeout  int      `json:"ws_pong_timeout_seconds"`
 		} `json:"server"`
 		Demo struct {
 			Enabled         bool `json:"enabled"`
 			IntervalSeconds int  `json:"interval_seconds"`
 		} `json:"demo"`
 		Station struct {
-			Latitude     float64 `json:"latitude"`
-			Longitude    float64 `json:"longitude"`
-			Altitude     float64 `json:"altitude"`
-			MinElevation float64 `json:"min_elevation"`
-			UseGPSD      bool    `json:"use_gpsd"`
-			GPSDHost     string  `json:"gpsd_host"`
+			Latitude        float64                   `json:"latitude"`
+			Longitude       float64                   `json:"longitude"`
+			Altitude        float64                   `json:"altitude"`
+			MinElevation    float64                   `json:"min_elevation"`
+			UsableElevation float64                   `json:"usable_elevation"`
+			UseGPSD         bool                      `json:"use_gpsd"`
+			GPSDHost        string                    `json:"gpsd_host"`
+			GeoIP           bool                      `json:"geoip"`
+			GeoIPURL        string                    `json:"geoip_url"`
+			Timezone        string                    `json:"timezone"`
+			Active          string                    `json:"active"`
+			Profiles        map[string]map[string]any `json:"profiles"`
 		} `json:"station"`
 		SDR struct {
-			DeviceIndex   int     `json:"device_index"`
-			Gain          float64 `json:"gain"`
-			PPMCorrection int     `json:"ppm_correction"`
-			SampleRate    int     `json:"sample_rate"`
+			Backend         string   `json:"backend"`
+			DeviceIndex     int      `json:"device_index"`
+			Gain            float64  `json:"gain"`
+			PPMCorrection   int      `json:"ppm_correction"`
+			SampleRate      int      `json:"sample_rate"`
+			Competing       []string `json:"competing_processes"`
+			KillCompeting   bool     `json:"kill_competing"`
+			SoapyArgs       string   `json:"soapy_args"`
+			SoapySampleRate int      `json:"soapy_sample_rate"`
+			TCPAddr         string   `json:"tcp_addr"`
+			TCPSampleRate   int      `json:"tcp_sample_rate"`
+			BandCapture     bool     `json:"band_capture"`
+			BandSampleRate  int      `json:"band_sample_rate"`
 		} `json:"sdr"`
 		Predict struct {
-			TLEURL          string `json:"tle_url"`
-			TLERefreshHours int    `json:"tle_refresh_hours"`
-			LookaheadHours  int    `json:"lookahead_hours"`
+			TLEURL          string   `json:"tle_url"`
+			TLEURLs         []string `json:"tle_urls"`
+			TLERefreshHours int      `json:"tle_refresh_hours"`
+			TLEMaxEpochAge  int      `json:"tle_max_epoch_age_days"`
+			LookaheadHours  int      `json:"lookahead_hours"`
+			SunAvoidDegrees float64  `json:"sun_avoid_degrees"`
+			SunPolicy       string   `json:"sun_policy"`
+			ConflictPolicy  string   `json:"conflict_policy"`
 		} `json:"predict"`
+		Weather struct {
+			Enabled             bool   `json:"enabled"`
+			URL                 string `json:"url"`
+			SkipOvercastPercent int    `json:"skip_overcast_percent"`
+		} `json:"weather"`
+		Schedule struct {
+			EnergyBudgetWh     float64 `json:"energy_budget_wh"`
+			CaptureWh          float64 `json:"capture_wh"`
+			CaptureWhPerMinute float64 `json:"capture_wh_per_minute"`
+		} `json:"schedule"`
+		Catalog struct {
+			Sync       bool   `json:"sync"`
+			SatNOGSURL string `json:"satnogs_url"`
+			SyncHours  int    `json:"sync_hours"`
+		} `json:"catalog"`
+		Decode struct {
+			Enhancements  []string `json:"enhancements"`
+			OverlayShapes string   `json:"overlay_shapes"`
+		} `json:"decode"`
+		Annotations struct {
+			GrafanaURL   string   `json:"grafana_url"`
+			GrafanaToken string   `json:"grafana_token"`
+			DashboardUID string   `json:"dashboard_uid"`
+			Tags         []string `json:"tags"`
+		} `json:"annotations"`
+		Tracing struct {
+			Enabled      bool   `json:"enabled"`
+			OTLPEndpoint string `json:"otlp_endpoint"`
+			ServiceName  string `json:"service_name"`
+		} `json:"tracing"`
+		Debug struct {
+			Enabled bool   `json:"enabled"`
+			Token   string `json:"token"`
+		} `json:"debug"`
+		Events struct {
+			Persist       bool     `json:"persist"`
+			RetentionDays int      `json:"retention_days"`
+			Exclude       []string `json:"exclude"`
+		} `json:"events"`
+		Retention struct {
+			MaxAgeDays      int    `json:"max_age_days"`
+			MaxTotalBytes   int64  `json:"max_total_bytes"`
+			MinFreeBytes    int64  `json:"min_free_bytes"`
+			Action          string `json:"action"`
+			IntervalMinutes int    `json:"interval_minutes"`
+		} `json:"retention"`
+		Upload struct {
+			Enabled         bool   `json:"enabled"`
+			Endpoint        string `json:"endpoint"`
+			Region          string `json:"region"`
+			Bucket          string `json:"bucket"`
+			Prefix          string `json:"prefix"`
+			AccessKeyID     string `json:"access_key_id"`
+			SecretAccessKey string `json:"secret_access_key"`
+			PathStyle       bool   `json:"path_style"`
+			PublicURL       string `json:"public_url"`
+			Concurrency     int    `json:"concurrency"`
+		} `json:"upload"`
+		Gallery struct {
+			Enabled bool   `json:"enabled"`
+			Dir     string `json:"dir"`
+			Title   string `json:"title"`
+		} `json:"gallery"`
+		Notify struct {
+			SummaryTime string `json:"summary_time"`
+			BaseURL     string `json:"base_url"`
+			Templates   struct {
+				Pass    string `json:"pass"`
+				Failure string `json:"failure"`
+				Summary string `json:"summary"`
+			} `json:"templates"`
+			Telegram struct {
+				Enabled      bool     `json:"enabled"`
+				BotToken     string   `json:"bot_token"`
+				ChatID       string   `json:"chat_id"`
+				Images       bool     `json:"images"`
+				Commands     bool     `json:"commands"`
+				AllowedUsers []int64  `json:"allowed_users"`
+				APIURL       string   `json:"api_url"`
+				Events       []string `json:"events"`
+			} `json:"telegram"`
+			Email struct {
+				Enabled  bool     `json:"enabled"`
+				Host     string   `json:"host"`
+				Port     int      `json:"port"`
+				TLS      string   `json:"tls"`
+				Username string   `json:"username"`
+				Password string   `json:"password"`
+				From     string   `json:"from"`
+				To       []string `json:"to"`
+				Images   bool     `json:"images"`
+				Events   []string `json:"events"`
+			} `json:"email"`
+			Ntfy struct {
+				Enabled  bool     `json:"enabled"`
+				Server   string   `json:"server"`
+				Topic    string   `json:"topic"`
+				Token    string   `json:"token"`
+				Username string   `json:"username"`
+				Password string   `json:"password"`
+				Priority int      `json:"priority"`
+				Images   bool     `json:"images"`
+				Events   []string `json:"events"`
+			} `json:"ntfy"`
+			Gotify struct {
+				Enabled  bool     `json:"enabled"`
+				Server   string   `json:"server"`
+				Token    string   `json:"token"`
+				Priority int      `json:"priority"`
+				Events   []string `json:"events"`
+			} `json:"gotify"`
+		} `json:"notify"`
+		Satellites map[string]struct {
+			Enabled      *bool    `json:"enabled"`
+			FreqOffsetHz int      `json:"freq_offset_hz"`
+			Priority     int      `json:"priority"`
+			MinElevation *float64 `json:"min_elevation"`
+			NoradID      int      `json:"norad_id"`
+			FreqHz       int      `json:"freq_hz"`
+			Mode         string   `json:"mode"`
+		} `json:"satellites"`
+		Plugins []struct {
+			Name    string   `json:"name"`
+			Command []string `json:"command"`
+			Events  []string `json:"events"`
+			Control bool     `json:"control"`
+		} `json:"plugins"`
+		Rules []struct {
+			Name            string   `json:"name"`
+			When            string   `json:"when"`
+			Actions         []string `json:"actions"`
+			Message         string   `json:"message"`
+			CooldownMinutes int      `json:"cooldown_minutes"`
+		} `json:"rules"`
+		Scripts []struct {
+			Name   string   `json:"name"`
+			Path   string   `json:"path"`
+			Events []string `json:"events"`
+		} `json:"scripts"`
 	}
 	if err := json.Unmarshal(raw, &cfg); err != nil {
 		return err
 	}
 
 	fmt.Println()
-	fmt.Println(header("  DAEMON CONFIGURATION"))
-	fmt.Println(colorize(dim, "  "+strings.Repeat("─", 50)))
+	if opts.Resolved {
+		fmt.Println(header("  " + tr("config.resolved_title")))
+		f := newFieldList("  ")
+		if len(resolved.Sources) == 0 {
+			f.add(tr("config.sources"), tr("config.defaults"))
+		}
+		for i, src := range resolved.Sources {
+			// Only the first source carries the label.
+			l := ""
+			if i == 0 {
+				l = tr("config.sources")
+			}
+			f.add(l, tr("config.source", i+1, src))
+		}
+		f.flush()
+	} else {
+		fmt.Println(header("  " + tr("config.title")))
+	}
+	fmt.Println(colorize(dim, "  "+rule(50)))
 
 	section := func(name string) {
 		fmt.Printf("\n  %s\n", colorize(bold, "["+name+"]"))
 	}
 	field := func(key string, val any) {
-		fmt.Printf("    %-20s %v\n", colorize(dim, key+":"), val)
+		fmt.Printf("    %s %v\n", label(key+":", 20), val)
+	}
+	secret := func(key, val string) {
+		if val != "" && !opts.ShowSecrets {
+			val = tr("config.set")
+		}
+		field(key, val)
 	}
 
 	section("data")
 	field("root", cfg.Data.Root)
 	field("archive", cfg.Data.Archive)
+	if cfg.Data.Staging != "" {
+		field("staging", cfg.Data.Staging)
+	}
+	field("fsync_interval_seconds", cfg.Data.FsyncInterval)
+	field("fsync_on_finalize", cfg.Data.FsyncOnFinalize)
+	field("scrub_interval_hours", cfg.Data.ScrubInterval)
+	if cfg.Data.Compress != "" {
+		field("compress", cfg.Data.Compress)
+	}
 
 	section("logging")
 	field("level", cfg.Logging.Level)
+	field("format", cfg.Logging.Format)
+	field("file", cfg.Logging.File)
+	field("max_size_mb", cfg.Logging.MaxSizeMB)
+	field("max_files", cfg.Logging.MaxFiles)
 
 	section("server")
 	field("bind", cfg.Server.Bind)
+	if cfg.Server.BasePath != "" {
+		field("base_path", cfg.Server.BasePath)
+	}
+	field("public_readonly", cfg.Server.PublicReadonly)
+	if cfg.Server.PublicReadonly {
+		field("public_bind", cfg.Server.PublicBind)
+	}
+	origins := tr("config.any")
+	if len(cfg.Server.WSOrigins) > 0 {
+		origins = strings.Join(cfg.Server.WSOrigins, ", ")
+	}
+	field("ws_allowed_origins", origins)
+	field("ws_max_clients", cfg.Server.WSMaxClients)
+	field("ws_max_clients_per_ip", cfg.Server.WSMaxPerIP)
+	field("ws_pong_timeout_seconds", cfg.Server.WSPongTimeout)
+	switch {
+	case len(cfg.Server.APITokens) == 0:
+		field("api_tokens", tr("config.none"))
+	case opts.ShowSecrets:
+		field("api_tokens", strings.Join(cfg.Server.APITokens, ", "))
+	default:
+		field("api_tokens", tr("config.set_count", len(cfg.Server.APITokens)))
+	}
 
 	section("demo")
 	field("enabled", cfg.Demo.Enabled)
@@ -93,19 +358,253 @@ func Config(baseURL string, jsonOutput bool) error {
 	field("longitude", cfg.Station.Longitude)
 	field("altitude", cfg.Station.Altitude)
 	field("min_elevation", cfg.Station.MinElevation)
+	field("usable_elevation", cfg.Station.UsableElevation)
 	field("use_gpsd", cfg.Station.UseGPSD)
 	field("gpsd_host", cfg.Station.GPSDHost)
+	field("geoip", cfg.Station.GeoIP)
+	field("geoip_url", cfg.Station.GeoIPURL)
+	timezone := cfg.Station.Timezone
+	if timezone == "" {
+		timezone = tr("config.system")
+	}
+	field("timezone", timezone)
+	field("active", cfg.Station.Active)
+	profiles := make([]string, 0, len(cfg.Station.Profiles))
+	for name := range cfg.Station.Profiles {
+		profiles = append(profiles, name)
+	}
+	sort.Strings(profiles)
+	for _, name := range profiles {
+		section("station." + name)
+		// A profile lists only the fields it overrides.
+		p := cfg.Station.Profiles[name]
+		for _, key := range []string{"latitude", "longitude", "altitude", "min_elevation", "usable_elevation", "use_gpsd", "gpsd_host", "timezone"} {
+			if v, ok := p[key]; ok {
+				field(key, v)
+			}
+		}
+	}
 
 	section("sdr")
+	field("backend", cfg.SDR.Backend)
 	field("device_index", cfg.SDR.DeviceIndex)
 	field("gain", cfg.SDR.Gain)
 	field("ppm_correction", cfg.SDR.PPMCorrection)
 	field("sample_rate", cfg.SDR.SampleRate)
+	field("competing_processes", strings.Join(cfg.SDR.Competing, ", "))
+	field("kill_competing", cfg.SDR.KillCompeting)
+	switch cfg.SDR.Backend {
+	case "soapy":
+		field("soapy_args", cfg.SDR.SoapyArgs)
+		field("soapy_sample_rate", cfg.SDR.SoapySampleRate)
+	case "rtl_tcp":
+		field("tcp_addr", cfg.SDR.TCPAddr)
+		field("tcp_sample_rate", cfg.SDR.TCPSampleRate)
+	}
+	field("band_capture", cfg.SDR.BandCapture)
+	if cfg.SDR.BandCapture {
+		field("band_sample_rate", cfg.SDR.BandSampleRate)
+	}
 
 	section("predict")
 	field("tle_url", cfg.Predict.TLEURL)
+	if len(cfg.Predict.TLEURLs) > 0 {
+		field("tle_urls", strings.Join(cfg.Predict.TLEURLs, ", "))
+	}
 	field("tle_refresh_hours", cfg.Predict.TLERefreshHours)
+	field("tle_max_epoch_age_days", cfg.Predict.TLEMaxEpochAge)
 	field("lookahead_hours", cfg.Predict.LookaheadHours)
+	field("sun_avoid_degrees", cfg.Predict.SunAvoidDegrees)
+	field("sun_policy", cfg.Predict.SunPolicy)
+	field("conflict_policy", cfg.Predict.ConflictPolicy)
+
+	section("weather")
+	field("enabled", cfg.Weather.Enabled)
+	field("url", cfg.Weather.URL)
+	field("skip_overcast_percent", cfg.Weather.SkipOvercastPercent)
+
+	section("schedule")
+	field("energy_budget_wh", cfg.Schedule.EnergyBudgetWh)
+	if cfg.Schedule.EnergyBudgetWh > 0 {
+		field("capture_wh", cfg.Schedule.CaptureWh)
+		field("capture_wh_per_minute", cfg.Schedule.CaptureWhPerMinute)
+	}
+
+	section("catalog")
+	field("sync", cfg.Catalog.Sync)
+	field("satnogs_url", cfg.Catalog.SatNOGSURL)
+	field("sync_hours", cfg.Catalog.SyncHours)
+
+	section("decode")
+	enhancements := tr("config.none")
+	if len(cfg.Decode.Enhancements) > 0 {
+		enhancements = strings.Join(cfg.Decode.Enhancements, ", ")
+	}
+	field("enhancements", enhancements)
+	field("overlay_shapes", cfg.Decode.OverlayShapes)
+
+	section("annotations")
+	field("grafana_url", cfg.Annotations.GrafanaURL)
+	secret("grafana_token", cfg.Annotations.GrafanaToken)
+	field("dashboard_uid", cfg.Annotations.DashboardUID)
+	field("tags", strings.Join(cfg.Annotations.Tags, ", "))
+
+	section("tracing")
+	field("enabled", cfg.Tracing.Enabled)
+	field("otlp_endpoint", cfg.Tracing.OTLPEndpoint)
+	field("service_name", cfg.Tracing.ServiceName)
+
+	section("debug")
+	field("enabled", cfg.Debug.Enabled)
+	secret("token", cfg.Debug.Token)
+
+	section("events")
+	field("persist", cfg.Events.Persist)
+	field("retention_days", cfg.Events.RetentionDays)
+	exclude := tr("config.none")
+	if len(cfg.Events.Exclude) > 0 {
+		exclude = strings.Join(cfg.Events.Exclude, ", ")
+	}
+	field("exclude", exclude)
+
+	section("retention")
+	field("max_age_days", cfg.Retention.MaxAgeDays)
+	field("max_total_bytes", cfg.Retention.MaxTotalBytes)
+	field("min_free_bytes", cfg.Retention.MinFreeBytes)
+	field("action", cfg.Retention.Action)
+	field("interval_minutes", cfg.Retention.IntervalMinutes)
+
+	section("upload")
+	field("enabled", cfg.Upload.Enabled)
+	field("endpoint", cfg.Upload.Endpoint)
+	field("region", cfg.Upload.Region)
+	field("bucket", cfg.Upload.Bucket)
+	field("prefix", cfg.Upload.Prefix)
+	field("access_key_id", cfg.Upload.AccessKeyID)
+	secret("secret_access_key", cfg.Upload.SecretAccessKey)
+	field("path_style", cfg.Upload.PathStyle)
+	field("public_url", cfg.Upload.PublicURL)
+	field("concurrency", cfg.Upload.Concurrency)
+
+	section("gallery")
+	field("enabled", cfg.Gallery.Enabled)
+	field("dir", cfg.Gallery.Dir)
+	field("title", cfg.Gallery.Title)
+
+	section("notify")
+	field("summary_time", cfg.Notify.SummaryTime)
+	field("base_url", cfg.Notify.BaseURL)
+
+	// Templates may span lines, so they are shown quoted.
+	template := func(key, val string) {
+		if val == "" {
+			field(key, tr("config.built_in"))
+			return
+		}
+		field(key, strconv.Quote(val))
+	}
+	section("notify.templates")
+	template("pass", cfg.Notify.Templates.Pass)
+	template("failure", cfg.Notify.Templates.Failure)
+	template("summary", cfg.Notify.Templates.Summary)
+
+	section("notify.telegram")
+	field("enabled", cfg.Notify.Telegram.Enabled)
+	secret("bot_token", cfg.Notify.Telegram.BotToken)
+	field("chat_id", cfg.Notify.Telegram.ChatID)
+	field("images", cfg.Notify.Telegram.Images)
+	field("commands", cfg.Notify.Telegram.Commands)
+	field("allowed_users", cfg.Notify.Telegram.AllowedUsers)
+	field("api_url", cfg.Notify.Telegram.APIURL)
+	field("events", cfg.Notify.Telegram.Events)
+
+	section("notify.email")
+	field("enabled", cfg.Notify.Email.Enabled)
+	field("host", cfg.Notify.Email.Host)
+	field("port", cfg.Notify.Email.Port)
+	field("tls", cfg.Notify.Email.TLS)
+	field("username", cfg.Notify.Email.Username)
+	secret("password", cfg.Notify.Email.Password)
+	field("from", cfg.Notify.Email.From)
+	field("to", cfg.Notify.Email.To)
+	field("images", cfg.Notify.Email.Images)
+	field("events", cfg.Notify.Email.Events)
+
+	section("notify.ntfy")
+	field("enabled", cfg.Notify.Ntfy.Enabled)
+	field("server", cfg.Notify.Ntfy.Server)
+	field("topic", cfg.Notify.Ntfy.Topic)
+	secret("token", cfg.Notify.Ntfy.Token)
+	field("username", cfg.Notify.Ntfy.Username)
+	secret("password", cfg.Notify.Ntfy.Password)
+	field("priority", cfg.Notify.Ntfy.Priority)
+	field("images", cfg.Notify.Ntfy.Images)
+	field("events", cfg.Notify.Ntfy.Events)
+
+	section("notify.gotify")
+	field("enabled", cfg.Notify.Gotify.Enabled)
+	field("server", cfg.Notify.Gotify.Server)
+	secret("token", cfg.Notify.Gotify.Token)
+	field("priority", cfg.Notify.Gotify.Priority)
+	field("events", cfg.Notify.Gotify.Events)
+
+	satNames := make([]string, 0, len(cfg.Satellites))
+	for name := range cfg.Satellites {
+		satNames = append(satNames, name)
+	}
+	sort.Strings(satNames)
+	for _, name := range satNames {
+		sat := cfg.Satellites[name]
+		section("satellites." + name)
+		if sat.Enabled != nil {
+			field("enabled", *sat.Enabled)
+		}
+		if sat.FreqOffsetHz != 0 {
+			field("freq_offset_hz", sat.FreqOffsetHz)
+		}
+		if sat.Priority != 0 {
+			field("priority", sat.Priority)
+		}
+		if sat.MinElevation != nil {
+			field("min_elevation", *sat.MinElevation)
+		}
+		if sat.NoradID != 0 {
+			field("norad_id", sat.NoradID)
+			field("freq_hz", sat.FreqHz)
+			field("mode", sat.Mode)
+		}
+	}
+
+	for _, p := range cfg.Plugins {
+		section("plugins." + p.Name)
+		field("command", strings.Join(p.Command, " "))
+		events := tr("config.all")
+		if len(p.Events) > 0 {
+			events = strings.Join(p.Events, ", ")
+		}
+		field("events", events)
+		field("control", p.Control)
+	}
+
+	for _, r := range cfg.Rules {
+		section("rules." + r.Name)
+		field("when", r.When)
+		field("actions", strings.Join(r.Actions, ", "))
+		if r.Message != "" {
+			field("message", r.Message)
+		}
+		field("cooldown_minutes", r.CooldownMinutes)
+	}
+
+	for _, s := range cfg.Scripts {
+		section("scripts." + s.Name)
+		field("path", s.Path)
+		events := tr("config.all")
+		if len(s.Events) > 0 {
+			events = strings.Join(s.Events, ", ")
+		}
+		field("events", events)
+	}
 
 	fmt.Println()
 
@@ -133,15 +632,17 @@ func ConfigList(baseURL string, jsonOutput bool) error {
 	}
 
 	fmt.Println()
-	fmt.Println(header("  CONFIG PROFILES"))
-	fmt.Printf("  %s %s\n", colorize(dim, "Directory:"), resp.ConfigDir)
+	fmt.Println(header("  " + tr("config.profiles_title")))
+	f := newFieldList("  ")
+	f.add(tr("config.directory"), resp.ConfigDir)
+	f.flush()
 
 	if len(resp.Profiles) == 0 {
-		fmt.Println(colorize(dim, "  ────────────────────────"))
-		fmt.Println("  No profiles found.")
-		fmt.Printf("\n  Create one with:\n    cp configs/example.toml %s/config.toml\n", resp.ConfigDir)
+		fmt.Println(colorize(dim, "  "+rule(24)))
+		fmt.Println("  " + tr("config.no_profiles"))
+		fmt.Printf("\n  %s\n    cp configs/example.toml %s/config.toml\n", tr("config.create_hint"), resp.ConfigDir)
 	} else {
-		t := newTable("  ", "Name", "Path", "Modified")
+		t := newTable("  ", tr("col.name"), tr("config.col_path"), tr("config.col_modified"))
 		for _, p := range resp.Profiles {
 			modTime := p.ModTime
 			if mt, err := time.Parse(time.RFC3339Nano, p.ModTime); err == nil {
@@ -0,0 +1,56 @@
+package ctl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Consistency shows what the daemon's startup consistency check found
+// between its history and the capture files, and what it repaired.
+func Consistency(baseURL string, jsonOutput bool) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	var resp struct {
+		CheckedAt string `json:"checked_at"`
+		Captures  int    `json:"captures"`
+		Repaired  int    `json:"repaired"`
+		Issues    []struct {
+			Kind     string `json:"kind"`
+			File     string `json:"file"`
+			Detail   string `json:"detail"`
+			Repaired bool   `json:"repaired"`
+		} `json:"issues"`
+	}
+	if err := getJSON(baseURL, "/api/consistency", &resp); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("consistency.title")))
+	f := newFieldList("  ")
+	f.add(tr("consistency.checked"), formatPassTime(resp.CheckedAt))
+	f.add(tr("consistency.captures"), fmt.Sprint(resp.Captures))
+	f.flush()
+
+	if len(resp.Issues) == 0 {
+		fmt.Printf("  %s\n", colorize(dim, rule(40)))
+		fmt.Println("  " + colorize(green, tr("consistency.none")))
+		fmt.Println()
+		return nil
+	}
+	fmt.Println()
+	t := newTable("  ", tr("col.check"), tr("col.file"), tr("col.status"), tr("col.detail"))
+	for _, issue := range resp.Issues {
+		status := colorize(green, tr("consistency.repaired"))
+		if !issue.Repaired {
+			status = colorize(yellow, tr("consistency.attention"))
+		}
+		t.row(issue.Kind, issue.File, status, issue.Detail)
+	}
+	t.flush()
+	fmt.Println()
+	return nil
+}
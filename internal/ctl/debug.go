@@ -0,0 +1,97 @@
+package ctl
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GoroutinesOptions configures the goroutines command.
+type GoroutinesOptions struct {
+	Limit int
+	Token string
+	Stack bool
+	JSON  bool
+}
+
+// Goroutines shows the daemon's goroutine summary and memory statistics
+// from GET /api/debug/goroutines. Debug endpoints must be enabled in the
+// daemon config.
+func Goroutines(baseURL string, opts GoroutinesOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	url := baseURL + "/api/debug/goroutines"
+	if opts.Limit > 0 {
+		url += fmt.Sprintf("?limit=%d", opts.Limit)
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+opts.Token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Goroutines int `json:"goroutines"`
+		GroupCount int `json:"group_count"`
+		Groups     []struct {
+			Count    int      `json:"count"`
+			Function string   `json:"function"`
+			Stack    []string `json:"stack"`
+		} `json:"groups"`
+		Memory struct {
+			HeapAllocBytes  int64 `json:"heap_alloc_bytes"`
+			HeapInuseBytes  int64 `json:"heap_inuse_bytes"`
+			HeapObjects     int64 `json:"heap_objects"`
+			SysBytes        int64 `json:"sys_bytes"`
+			StackInuseBytes int64 `json:"stack_inuse_bytes"`
+			NumGC           int   `json:"num_gc"`
+		} `json:"memory"`
+	}
+	if err := decodeJSON(resp, &result); err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		return printJSON(result)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  RUNTIME DIAGNOSTICS"))
+	fmt.Println("  " + rule(42))
+	fmt.Printf("  Goroutines:  %d (%d distinct stacks)\n", result.Goroutines, result.GroupCount)
+	fmt.Printf("  Heap alloc:  %s (%d objects)\n", formatBytes(result.Memory.HeapAllocBytes), result.Memory.HeapObjects)
+	fmt.Printf("  Heap inuse:  %s\n", formatBytes(result.Memory.HeapInuseBytes))
+	fmt.Printf("  Stacks:      %s\n", formatBytes(result.Memory.StackInuseBytes))
+	fmt.Printf("  Sys:         %s\n", formatBytes(result.Memory.SysBytes))
+	fmt.Printf("  GC cycles:   %d\n", result.Memory.NumGC)
+
+	fmt.Println()
+	fmt.Println(header("  GOROUTINES BY STACK"))
+	t := newTable("  ", "Count", "Function")
+	t.alignRight(0)
+	for _, g := range result.Groups {
+		t.row(fmt.Sprintf("%d", g.Count), g.Function)
+	}
+	t.flush()
+
+	if opts.Stack {
+		for _, g := range result.Groups {
+			fmt.Println()
+			fmt.Printf("  %s %s\n", colorize(bold, fmt.Sprintf("%d %s", g.Count, glyph("×", "x"))), g.Function)
+			for _, frame := range g.Stack {
+				fmt.Printf("    %s\n", colorize(dim, frame))
+			}
+		}
+	}
+
+	fmt.Println()
+	return nil
+}
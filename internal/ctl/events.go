@@ -0,0 +1,87 @@
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventsOptions filters the events command. Since and Until take RFC 3339
+// times or a duration back from now, such as 12h.
+type EventsOptions struct {
+	Types     []string
+	Satellite string
+	Since     string
+	Until     string
+	Limit     int
+	JSON      bool
+}
+
+// Events shows events from the daemon's event log, oldest first, rendered
+// the way watch shows them live.
+func Events(baseURL string, opts EventsOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	params := url.Values{}
+	for k, v := range map[string]string{
+		"type":      strings.Join(opts.Types, ","),
+		"satellite": opts.Satellite,
+		"since":     opts.Since,
+		"until":     opts.Until,
+	} {
+		if v != "" {
+			params.Set(k, v)
+		}
+	}
+	if opts.Limit > 0 {
+		params.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	path := "/api/events/history"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	var resp struct {
+		Events  []json.RawMessage `json:"events"`
+		Count   int               `json:"count"`
+		Persist bool              `json:"persist"`
+	}
+	if err := getJSON(baseURL, path, &resp); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("events.title")))
+	if !resp.Persist {
+		fmt.Println(colorize(yellow, "  "+tr("events.not_persisted")))
+	}
+	if len(resp.Events) == 0 {
+		fmt.Println(colorize(dim, "  "+tr("events.none")))
+		fmt.Println()
+		return nil
+	}
+	fmt.Println()
+
+	// Event lines only show the time of day, so mark where each day starts.
+	var day string
+	for _, raw := range resp.Events {
+		var ev struct {
+			TS time.Time `json:"ts"`
+		}
+		if json.Unmarshal(raw, &ev) == nil && !ev.TS.IsZero() {
+			if d := ev.TS.Local().Format("Mon 2006-01-02"); d != day {
+				day = d
+				fmt.Printf("  %s\n", colorize(bold, d))
+			}
+		}
+		renderEvent(raw)
+	}
+	fmt.Println()
+	return nil
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // ANSI escape codes for terminal formatting.
@@ -22,9 +24,42 @@ const (
 	white  = "\033[37m"
 )
 
-// colorEnabled reports whether stdout is a terminal. When output is piped
-// or redirected, ANSI escape codes are suppressed.
+// Color modes accepted by SetColorMode.
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+var colorMode = ColorAuto
+
+// SetColorMode selects when ANSI colors are used: "auto" (the default)
+// colors only a terminal, "always" and "never" force the choice. An
+// explicit mode overrides NO_COLOR.
+func SetColorMode(mode string) error {
+	switch mode {
+	case ColorAuto, ColorAlways, ColorNever:
+		colorMode = mode
+		return nil
+	default:
+		return fmt.Errorf("invalid --color %q (want auto, always, or never)", mode)
+	}
+}
+
+// colorEnabled applies the color mode. In auto mode it reports whether
+// stdout is a terminal, NO_COLOR (https://no-color.org) is unset, and TERM
+// is not "dumb"; when output is piped or redirected, ANSI escape codes are
+// suppressed.
 func colorEnabled() bool {
+	switch colorMode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
 	fi, err := os.Stdout.Stat()
 	if err != nil {
 		return false
@@ -70,12 +105,145 @@ func header(title string) string {
 	return title
 }
 
-// padRight pads s with spaces to reach the given width.
+// padRight pads s with spaces to reach the given display width.
 func padRight(s string, width int) string {
-	if len(s) >= width {
+	w := displayWidth(s)
+	if w >= width {
 		return s
 	}
-	return s + strings.Repeat(" ", width-len(s))
+	return s + strings.Repeat(" ", width-w)
+}
+
+// label renders a dimmed field label padded to width, for the
+// "Label:  value" blocks used by status-style output.
+func label(text string, width int) string {
+	return padRight(colorize(dim, text), width)
+}
+
+// fieldList renders "Label: value" lines with the values aligned after the
+// widest label, so translated labels of any length line up.
+type fieldList struct {
+	prefix string
+	labels []string
+	values []string
+}
+
+// newFieldList creates a field list with the given line prefix.
+func newFieldList(prefix string) *fieldList {
+	return &fieldList{prefix: prefix}
+}
+
+// add appends a labelled value.
+func (f *fieldList) add(label, value string) {
+	f.labels = append(f.labels, label)
+	f.values = append(f.values, value)
+}
+
+// flush prints the fields and resets the list.
+func (f *fieldList) flush() {
+	width := 0
+	for _, l := range f.labels {
+		if w := displayWidth(l); w > width {
+			width = w
+		}
+	}
+	for i, l := range f.labels {
+		fmt.Printf("%s%s %s\n", f.prefix, label(l, width+1), f.values[i])
+	}
+	f.labels, f.values = nil, nil
+}
+
+// displayWidth returns the number of terminal columns s occupies. ANSI
+// escape sequences take no space, combining marks and other zero-width
+// runes are skipped, and East Asian wide characters count as two columns.
+func displayWidth(s string) int {
+	w := 0
+	for i := 0; i < len(s); {
+		if s[i] == '\033' && i+1 < len(s) && s[i+1] == '[' {
+			// Skip a CSI sequence up to and including its final byte.
+			j := i + 2
+			for j < len(s) && (s[j] < 0x40 || s[j] > 0x7e) {
+				j++
+			}
+			i = j + 1
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		w += runeWidth(r)
+	}
+	return w
+}
+
+// runeWidth returns the column width of a single rune.
+func runeWidth(r rune) int {
+	switch {
+	case r < 0x20 || r == 0x7f:
+		return 0
+	case r < 0x7f:
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf):
+		return 0
+	case isWide(r):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// isWide reports whether r is rendered double-width: Hangul, CJK
+// ideographs, kana, fullwidth forms, and the common emoji blocks.
+func isWide(r rune) bool {
+	return (r >= 0x1100 && r <= 0x115f) ||
+		(r >= 0x2e80 && r <= 0x303e) ||
+		(r >= 0x3041 && r <= 0x33ff) ||
+		(r >= 0x3400 && r <= 0x4dbf) ||
+		(r >= 0x4e00 && r <= 0x9fff) ||
+		(r >= 0xa000 && r <= 0xa4cf) ||
+		(r >= 0xac00 && r <= 0xd7a3) ||
+		(r >= 0xf900 && r <= 0xfaff) ||
+		(r >= 0xfe30 && r <= 0xfe4f) ||
+		(r >= 0xff00 && r <= 0xff60) ||
+		(r >= 0xffe0 && r <= 0xffe6) ||
+		(r >= 0x1f300 && r <= 0x1f64f) ||
+		(r >= 0x1f900 && r <= 0x1f9ff) ||
+		(r >= 0x20000 && r <= 0x3fffd)
+}
+
+// unicodeOutput reports whether box-drawing and other non-ASCII glyphs can
+// be printed. A locale that explicitly names a non-UTF-8 charset (or the
+// bare "C"/"POSIX" locale) gets ASCII substitutes instead of mojibake.
+func unicodeOutput() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		v := os.Getenv(name)
+		if v == "" {
+			continue
+		}
+		u := strings.ToUpper(v)
+		if strings.Contains(u, "UTF-8") || strings.Contains(u, "UTF8") {
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// glyph returns u when the terminal can render it and ascii otherwise.
+func glyph(u, ascii string) string {
+	if unicodeOutput() {
+		return u
+	}
+	return ascii
+}
+
+// rule returns a horizontal separator n columns wide.
+func rule(n int) string {
+	return strings.Repeat(glyph("─", "-"), n)
+}
+
+// degrees formats an angle with one decimal and a degree sign.
+func degrees(v float64) string {
+	return fmt.Sprintf("%.1f%s", v, glyph("°", " deg"))
 }
 
 // formatDuration renders a time.
@@ -143,12 +311,15 @@ func (t *table) row(cols ...string) {
 func (t *table) flush() {
 	widths := make([]int, len(t.headers))
 	for i, h := range t.headers {
-		widths[i] = len(h)
+		widths[i] = displayWidth(h)
 	}
 	for _, row := range t.rows {
 		for i := range row {
-			if i < len(widths) && len(row[i]) > widths[i] {
-				widths[i] = len(row[i])
+			if i >= len(widths) {
+				continue
+			}
+			if w := displayWidth(row[i]); w > widths[i] {
+				widths[i] = w
 			}
 		}
 	}
@@ -175,7 +346,7 @@ func (t *table) flush() {
 	fmt.Println(colorize(dim, line))
 
 	// Separator.
-	fmt.Println(colorize(dim, t.prefix+strings.Repeat("─", total)))
+	fmt.Println(colorize(dim, t.prefix+rule(total)))
 
 	// Data rows.
 	for _, row := range t.rows {
@@ -200,10 +371,11 @@ func (t *table) flush() {
 
 // padLeft pads s with leading spaces to reach the given width.
 func padLeft(s string, width int) string {
-	if len(s) >= width {
+	w := displayWidth(s)
+	if w >= width {
 		return s
 	}
-	return strings.Repeat(" ", width-len(s)) + s
+	return strings.Repeat(" ", width-w) + s
 }
 
 // progressBar builds a simple ASCII bar of the given width.
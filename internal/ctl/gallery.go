@@ -0,0 +1,98 @@
+package ctl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GalleryOptions configures the gallery command.
+type GalleryOptions struct {
+	Export bool // export the gallery now instead of showing the last export
+	JSON   bool
+}
+
+// galleryReport mirrors a gallery export's report from the daemon.
+type galleryReport struct {
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at"`
+	Dir        string `json:"dir"`
+	Days       int    `json:"days"`
+	Captures   int    `json:"captures"`
+	Images     int    `json:"images"`
+	Copied     int    `json:"copied"`
+	Removed    int    `json:"removed"`
+	Error      string `json:"error,omitempty"`
+	Source     string `json:"source"`
+}
+
+// Gallery shows the static gallery's settings and last export, or exports
+// it now via POST /api/gallery/export.
+func Gallery(baseURL string, opts GalleryOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	if opts.Export {
+		var result struct {
+			OK     bool           `json:"ok"`
+			Report *galleryReport `json:"report"`
+		}
+		if err := postJSON(baseURL, "/api/gallery/export", nil, &result); err != nil {
+			return err
+		}
+		if opts.JSON {
+			return printJSON(result)
+		}
+		fmt.Printf("\n  %s  %s\n", colorize(green, tr("gallery.exported")), result.Report.Dir)
+		f := newFieldList("  ")
+		printGalleryReport(f, result.Report)
+		return nil
+	}
+
+	var resp struct {
+		Enabled bool           `json:"enabled"`
+		Dir     string         `json:"dir"`
+		Title   string         `json:"title"`
+		Running bool           `json:"running"`
+		Last    *galleryReport `json:"last"`
+	}
+	if err := getJSON(baseURL, "/api/gallery", &resp); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("gallery.title")))
+	fmt.Printf("  %s\n", colorize(dim, rule(40)))
+	f := newFieldList("  ")
+	if !resp.Enabled {
+		f.add(tr("gallery.export"), colorize(dim, tr("gallery.off")))
+	} else {
+		f.add(tr("gallery.export"), tr("gallery.after_decode"))
+	}
+	f.add(tr("gallery.dir"), resp.Dir)
+	f.add(tr("gallery.site_title"), resp.Title)
+	if resp.Running {
+		f.add(tr("gallery.status"), colorize(yellow, tr("gallery.running")))
+	}
+	if resp.Last == nil {
+		f.add(tr("gallery.last"), colorize(dim, tr("gallery.never")))
+		f.flush()
+		fmt.Println()
+		return nil
+	}
+	f.add(tr("gallery.last"), tr("gallery.last_value", resp.Last.FinishedAt, resp.Last.Source))
+	printGalleryReport(f, resp.Last)
+	return nil
+}
+
+// printGalleryReport adds what an export wrote to f and prints it.
+func printGalleryReport(f *fieldList, rep *galleryReport) {
+	if rep.Error != "" {
+		f.add(tr("gallery.error"), colorize(red, rep.Error))
+	}
+	f.add(tr("gallery.pages"), tr("gallery.pages_value", rep.Days, rep.Captures, rep.Images))
+	f.add(tr("gallery.changes"), tr("gallery.changes_value", rep.Copied, rep.Removed))
+	f.flush()
+	fmt.Println()
+}
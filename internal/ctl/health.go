@@ -1,9 +1,12 @@
 package ctl
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
+	"time"
 )
 
 // Health checks daemon liveness and optionally component health via GET /healthz.
@@ -22,9 +25,9 @@ func Health(baseURL string, jsonOutput bool) error {
 
 	fmt.Println()
 	if status == 200 {
-		fmt.Printf("  %s  ephemerisd is reachable at %s\n", colorize(green, "HEALTHY"), colorize(dim, baseURL))
+		fmt.Printf("  %s  %s\n", colorize(green, tr("health.healthy")), tr("health.reachable", colorize(dim, baseURL)))
 	} else {
-		fmt.Printf("  %s  ephemerisd returned HTTP %d at %s\n", colorize(red, "UNHEALTHY"), status, colorize(dim, baseURL))
+		fmt.Printf("  %s  %s\n", colorize(red, tr("health.unhealthy")), tr("health.http_status", status, colorize(dim, baseURL)))
 	}
 	fmt.Println()
 	return nil
@@ -55,3 +58,143 @@ func healthDetailed(baseURL string) error {
 
 	return printJSON(result)
 }
+
+// HealthHistoryOptions configures the health-history command.
+type HealthHistoryOptions struct {
+	Limit int
+	JSON  bool
+}
+
+// HealthHistory shows recent health check samples and any flapping checks.
+func HealthHistory(baseURL string, opts HealthHistoryOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	path := "/api/health/history"
+	if opts.Limit > 0 {
+		path += fmt.Sprintf("?limit=%d", opts.Limit)
+	}
+
+	var resp struct {
+		IntervalS int `json:"interval_s"`
+		History   []struct {
+			TS      string                    `json:"ts"`
+			Healthy bool                      `json:"healthy"`
+			Checks  map[string]map[string]any `json:"checks"`
+		} `json:"history"`
+		Flapping []string `json:"flapping"`
+	}
+	if err := getJSON(baseURL, path, &resp); err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("health.history_title")))
+	f := newFieldList("  ")
+	f.add(tr("health.sampled"), tr("health.every", formatDuration(time.Duration(resp.IntervalS)*time.Second)))
+	if len(resp.Flapping) > 0 {
+		f.add(tr("health.flapping"), colorize(yellow, strings.Join(resp.Flapping, ", ")))
+	}
+	f.flush()
+
+	if len(resp.History) == 0 {
+		fmt.Println(colorize(dim, "  "+rule(24)))
+		fmt.Println("  " + tr("health.no_samples"))
+	} else {
+		t := newTable("  ", tr("col.time"), tr("col.status"), tr("col.failing"))
+		for _, h := range resp.History {
+			ts := h.TS
+			if pt, err := time.Parse(time.RFC3339, h.TS); err == nil {
+				ts = pt.Local().Format("2006-01-02 15:04")
+			}
+
+			var failing []string
+			for name, c := range h.Checks {
+				if ok, _ := c["ok"].(bool); !ok {
+					failing = append(failing, name)
+				}
+			}
+			sort.Strings(failing)
+
+			status := tr("health.healthy")
+			if !h.Healthy {
+				status = tr("health.unhealthy")
+			}
+			t.row(ts, status, strings.Join(failing, ", "))
+		}
+		t.flush()
+	}
+
+	fmt.Println()
+	return nil
+}
+
+// Ready checks daemon readiness via GET /readyz, including whether the
+// scheduler loop is making progress. It returns an error when not ready so
+// scripts can rely on the exit status.
+func Ready(baseURL string, jsonOutput bool) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	status, body, err := getRaw(baseURL, "/readyz")
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Ready  bool                      `json:"ready"`
+		Checks map[string]map[string]any `json:"checks"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("HTTP %d: %s", status, strings.TrimSpace(string(body)))
+	}
+
+	if jsonOutput {
+		if err := printJSON(result); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println()
+		if result.Ready {
+			fmt.Printf("  %s  %s\n", colorize(green, tr("ready.ready")), tr("ready.is_ready"))
+		} else {
+			fmt.Printf("  %s  %s\n", colorize(red, tr("ready.not_ready")), tr("ready.is_not_ready"))
+		}
+		fmt.Println()
+
+		names := make([]string, 0, len(result.Checks))
+		for name := range result.Checks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		t := newTable("  ", tr("col.check"), tr("col.status"), tr("col.detail"))
+		for _, name := range names {
+			c := result.Checks[name]
+			state := colorize(green, tr("common.ok"))
+			if ok, _ := c["ok"].(bool); !ok {
+				state = colorize(red, tr("common.fail"))
+			}
+			detail := ""
+			if s, ok := c["state"].(string); ok {
+				detail = s
+			}
+			if since, ok := c["since_last_beat"].(float64); ok {
+				detail = tr("ready.last_beat", formatDuration(time.Duration(since)*time.Second))
+				if over, ok := c["overdue_s"].(float64); ok {
+					detail += ", " + tr("ready.overdue", formatDuration(time.Duration(over)*time.Second))
+				}
+			}
+			t.row(name, state, colorize(dim, detail))
+		}
+		t.flush()
+		fmt.Println()
+	}
+
+	if !result.Ready {
+		return fmt.Errorf("daemon not ready")
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package ctl
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// HistoryOptions filters the history command. Since and Until take RFC 3339
+// times or a duration back from now, such as 72h.
+type HistoryOptions struct {
+	Satellite string
+	Outcome   string
+	Station   string
+	Since     string
+	Until     string
+	MinElev   float64
+	Limit     int
+	JSON      bool
+}
+
+// History lists past pass attempts and how each ended, newest first.
+func History(baseURL string, opts HistoryOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	params := url.Values{}
+	for k, v := range map[string]string{
+		"satellite": opts.Satellite,
+		"outcome":   opts.Outcome,
+		"station":   opts.Station,
+		"since":     opts.Since,
+		"until":     opts.Until,
+	} {
+		if v != "" {
+			params.Set(k, v)
+		}
+	}
+	if opts.MinElev > 0 {
+		params.Set("min_elev", strconv.FormatFloat(opts.MinElev, 'f', -1, 64))
+	}
+	if opts.Limit > 0 {
+		params.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	path := "/api/history"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	var resp struct {
+		History []struct {
+			ID        int64   `json:"id"`
+			Satellite string  `json:"satellite"`
+			AOS       string  `json:"aos"`
+			MaxElev   float64 `json:"max_elev"`
+			Station   string  `json:"station"`
+			Outcome   string  `json:"outcome"`
+			File      string  `json:"file"`
+			Bytes     int64   `json:"bytes"`
+			Error     string  `json:"error"`
+			Manual    bool    `json:"manual"`
+			Source    string  `json:"source"`
+			Deleted   bool    `json:"deleted"`
+		} `json:"history"`
+		Count int `json:"count"`
+	}
+	if err := getJSON(baseURL, path, &resp); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("history.title")))
+	if len(resp.History) == 0 {
+		fmt.Println(colorize(dim, "  "+tr("history.none")))
+		fmt.Println()
+		return nil
+	}
+
+	t := newTable("  ", tr("col.id"), tr("col.aos"), tr("col.satellite"), tr("col.elev"), tr("col.outcome"), tr("col.size"), tr("col.detail"))
+	t.alignRight(0, 3, 5)
+	for _, h := range resp.History {
+		outcome := h.Outcome
+		switch outcome {
+		case "captured":
+			outcome = colorize(green, outcome)
+		case "failed":
+			outcome = colorize(red, outcome)
+		default:
+			outcome = colorize(yellow, outcome)
+		}
+		if h.Manual {
+			outcome += " " + colorize(dim, tr("history.manual"))
+		}
+
+		elev, size := colorize(dim, "-"), ""
+		if h.MaxElev > 0 {
+			elev = degrees(h.MaxElev)
+		}
+		if h.Bytes > 0 {
+			size = formatBytes(h.Bytes)
+		}
+
+		detail := h.File
+		switch {
+		case h.Error != "":
+			detail = colorize(dim, h.Error)
+		case h.Deleted:
+			detail = colorize(dim, h.File+" "+tr("history.deleted"))
+		case h.Source != "":
+			detail += " " + colorize(dim, "("+h.Source+")")
+		}
+		t.row(colorize(dim, strconv.FormatInt(h.ID, 10)), formatPassTime(h.AOS), h.Satellite, elev, outcome, size, detail)
+	}
+	t.flush()
+	fmt.Println()
+	return nil
+}
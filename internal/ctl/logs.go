@@ -56,11 +56,11 @@ func Logs(baseURL string, opts LogsOptions) error {
 	}
 
 	fmt.Println()
-	fmt.Println(header("  DAEMON LOGS"))
-	fmt.Println("  " + strings.Repeat("─", 70))
+	fmt.Println(header("  " + tr("logs.title")))
+	fmt.Println("  " + rule(70))
 
 	if len(resp.Logs) == 0 {
-		fmt.Println("  No log entries found.")
+		fmt.Println("  " + tr("logs.none"))
 	} else {
 		for _, entry := range resp.Logs {
 			ts := entry.TS
@@ -0,0 +1,171 @@
+package ctl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	toml "github.com/pelletier/go-toml/v2"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// Catalog maps message keys to translated format strings. Keys are dotted
+// ("status.title"); values may contain fmt verbs filled in by tr.
+type Catalog map[string]string
+
+// DefaultLanguage is the built-in catalog every lookup falls back to.
+const DefaultLanguage = "en"
+
+var (
+	catalogMu sync.RWMutex
+	catalogs  = map[string]Catalog{DefaultLanguage: enMessages}
+	language  = DefaultLanguage
+)
+
+// RegisterCatalog adds or extends the catalog for lang. Keys missing from a
+// translation fall back to English, so partial catalogs are fine.
+func RegisterCatalog(lang string, c Catalog) {
+	lang = normalizeLanguage(lang)
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	dst := catalogs[lang]
+	if dst == nil {
+		dst = Catalog{}
+		catalogs[lang] = dst
+	}
+	for k, v := range c {
+		dst[k] = v
+	}
+}
+
+// LoadCatalogFile reads a TOML translation file and registers it for lang.
+// Keys may be written dotted or as tables:
+//
+//	[status]
+//	title = "ESTADO DEL MOTOR EPHEMERIS"
+func LoadCatalogFile(lang, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var raw map[string]any
+	if err := toml.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	c := Catalog{}
+	if err := flattenCatalog("", raw, c); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	RegisterCatalog(lang, c)
+	return nil
+}
+
+// flattenCatalog turns nested TOML tables into dotted keys.
+func flattenCatalog(prefix string, raw map[string]any, dst Catalog) error {
+	for k, v := range raw {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case string:
+			dst[key] = v
+		case map[string]any:
+			if err := flattenCatalog(key, v, dst); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("message %q must be a string", key)
+		}
+	}
+	return nil
+}
+
+// SetLanguage selects the output language. An empty lang is detected from
+// $EPHCTL_LANG, $LC_ALL, $LC_MESSAGES, and $LANG. A catalog at
+// <config dir>/locale/<lang>.toml is loaded if present; the language falls
+// back from "pt_BR" to "pt" and finally to English.
+func SetLanguage(lang string) error {
+	if lang == "" {
+		lang = detectLanguage()
+	}
+	lang = normalizeLanguage(lang)
+
+	candidates := []string{lang}
+	if base, _, ok := strings.Cut(lang, "_"); ok {
+		candidates = append(candidates, base)
+	}
+	for _, c := range candidates {
+		path := filepath.Join(config.DefaultConfigDir(), "locale", c+".toml")
+		if err := LoadCatalogFile(c, path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	language = DefaultLanguage
+	for _, c := range candidates {
+		if _, ok := catalogs[c]; ok {
+			language = c
+			break
+		}
+	}
+	return nil
+}
+
+// Languages returns the languages with a registered catalog.
+func Languages() []string {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+	out := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		out = append(out, lang)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// detectLanguage reads the POSIX locale variables in precedence order.
+func detectLanguage() string {
+	for _, name := range []string{"EPHCTL_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return DefaultLanguage
+}
+
+// normalizeLanguage strips the charset and modifier from a locale name
+// ("de_DE.UTF-8@euro" becomes "de_DE"). "C" and "POSIX" mean English.
+func normalizeLanguage(lang string) string {
+	lang, _, _ = strings.Cut(lang, ".")
+	lang, _, _ = strings.Cut(lang, "@")
+	lang = strings.ReplaceAll(lang, "-", "_")
+	if lang == "" || lang == "C" || lang == "POSIX" {
+		return DefaultLanguage
+	}
+	return lang
+}
+
+// tr looks up key in the active catalog, falling back to English and then
+// to the key itself, and formats it with args.
+func tr(key string, args ...any) string {
+	catalogMu.RLock()
+	msg, ok := catalogs[language][key]
+	if !ok {
+		msg, ok = catalogs[DefaultLanguage][key]
+	}
+	catalogMu.RUnlock()
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
@@ -0,0 +1,610 @@
+package ctl
+
+// enMessages is the built-in English catalog and the fallback for every
+// other language. Translators copy these keys into
+// <config dir>/locale/<lang>.toml.
+var enMessages = Catalog{
+	// Shared words.
+	"common.none":  "none",
+	"common.error": "ERROR",
+	"common.ok":    "OK",
+	"common.fail":  "FAIL",
+
+	// Table column headers.
+	"col.satellite":       "Satellite",
+	"col.captures":        "Captures",
+	"col.timestamp":       "Timestamp",
+	"col.size":            "Size",
+	"col.filename":        "Filename",
+	"col.decode":          "Decode",
+	"col.source":          "Source",
+	"col.aos":             "AOS",
+	"col.los":             "LOS",
+	"col.elev":            "Elev",
+	"col.dir":             "Dir",
+	"col.duration":        "Duration",
+	"col.quality":         "Quality",
+	"col.clouds":          "Clouds",
+	"col.sun":             "Sun",
+	"col.name":            "Name",
+	"col.file":            "File",
+	"col.reason":          "Reason",
+	"col.norad_id":        "NORAD ID",
+	"col.frequency":       "Frequency",
+	"col.offset":          "Offset",
+	"col.priority":        "Priority",
+	"col.min_elev":        "Min elev",
+	"col.mode":            "Mode",
+	"col.transmitter":     "Transmitter",
+	"col.time":            "Time",
+	"col.status":          "Status",
+	"col.failing":         "Failing",
+	"col.check":           "Check",
+	"col.detail":          "Detail",
+	"col.outcome":         "Outcome",
+	"col.id":              "ID",
+	"col.window":          "Window",
+	"col.days":            "Days",
+	"col.azimuth":         "Az",
+	"col.range":           "Range",
+	"col.doppler":         "Doppler",
+	"col.lat":             "Lat",
+	"col.lon":             "Lon",
+	"col.wake":            "Wake",
+	"col.awake_until":     "Awake until",
+	"col.awake":           "Awake",
+	"col.epoch":           "Epoch",
+	"col.age":             "Age",
+	"col.element_set":     "Set",
+	"col.mean_motion":     "Rev/day",
+	"col.energy":          "Energy",
+	"col.score":           "Score",
+	"col.score_factors":   "Score factors",
+	"col.quality_factors": "Quality factors",
+
+	// Pass details shared by status and next-pass.
+	"pass.satellite":       "Satellite:",
+	"pass.satellite_norad": "%s (NORAD %d)",
+	"pass.frequency":       "Frequency:",
+	"pass.mhz":             "%.3f MHz",
+	"pass.aos":             "AOS:",
+	"pass.los":             "LOS:",
+	"pass.max_elev":        "Max elev:",
+	"pass.duration":        "Duration:",
+	"pass.stage":           "Stage:",
+	"pass.countdown":       "Countdown:",
+	"pass.status":          "Status:",
+	"pass.now":             "NOW",
+	"pass.station":         "Station:",
+	"pass.sun":             "Sun:",
+	"pass.sun_near":        "track passes within %s of the sun",
+	"pass.clouds":          "Clouds:",
+	"pass.cloud_cover":     "%.0f%%",
+	"pass.recording":       "Recording:",
+	"pass.bandwidth":       "Bandwidth:",
+	"pass.band_shared":     "shared with %s",
+
+	// status
+	"status.title":          "EPHEMERIS ENGINE STATUS",
+	"status.daemon":         "Daemon:",
+	"status.state":          "State:",
+	"status.mode":           "Mode:",
+	"status.uptime":         "Uptime:",
+	"status.data":           "Data:",
+	"status.archive":        "Archive:",
+	"status.host":           "Host:",
+	"status.scheduler":      "Scheduler:",
+	"status.paused":         "PAUSED",
+	"status.storage":        "Storage:",
+	"status.read_only":      "READ-ONLY, captures stopped",
+	"status.current_pass":   "CURRENT PASS",
+	"status.disk":           "DISK USAGE",
+	"status.disk_total":     "Total:",
+	"status.disk_used":      "Used:",
+	"status.disk_available": "Available:",
+
+	// health, health-history, ready
+	"health.healthy":       "HEALTHY",
+	"health.unhealthy":     "UNHEALTHY",
+	"health.reachable":     "ephemerisd is reachable at %s",
+	"health.http_status":   "ephemerisd returned HTTP %d at %s",
+	"health.history_title": "HEALTH HISTORY",
+	"health.sampled":       "Sampled:",
+	"health.every":         "every %s",
+	"health.flapping":      "Flapping:",
+	"health.no_samples":    "No health samples recorded yet.",
+	"ready.ready":          "READY",
+	"ready.not_ready":      "NOT READY",
+	"ready.is_ready":       "ephemerisd is ready",
+	"ready.is_not_ready":   "ephemerisd is not ready",
+	"ready.last_beat":      "last beat %s ago",
+	"ready.overdue":        "%s overdue",
+
+	// version
+	"version.title":       "EPHEMERIS VERSION",
+	"version.cli":         "CLI:",
+	"version.daemon":      "Daemon:",
+	"version.built":       "Built:",
+	"version.unreachable": "unreachable: %s",
+
+	// passes, next-pass, satellites
+	"passes.title":                "UPCOMING PASSES",
+	"passes.station":              "Station:",
+	"passes.station_position":     "%.4f, %.4f, %.0fm",
+	"passes.approximate":          "(approximate, from IP geolocation)",
+	"passes.sun_near":             "near sun (%s)",
+	"passes.none":                 "No upcoming passes found.",
+	"next_pass.title":             "NEXT PASS",
+	"summary.title":               "STATION SUMMARY",
+	"summary.state":               "State:",
+	"summary.clock":               "Station time:",
+	"summary.next":                "Next pass:",
+	"summary.next_value":          "%s at %s (%s), max %s %s",
+	"summary.in":                  "in %s",
+	"summary.last":                "Last image:",
+	"summary.last_value":          "%s at %s, %s",
+	"summary.disk_free":           "Disk free:",
+	"summary.saved":               "saved %s (%d bytes)",
+	"passes.disabled":             "(disabled)",
+	"passes.blackout":             "(blackout %s)",
+	"passes.low_quality":          "(low quality)",
+	"passes.score_factors":        "%.0f prio + %.1f elev + %.1f min",
+	"passes.quality_factors":      "%.1f elev + %.1f high + %.1f len",
+	"passes.score_hint":           "Score decides conflicts: 100 per step of satellite priority, plus degrees of peak elevation, plus minutes recorded. Quality is what min_quality and the energy budget go by.",
+	"passes.score_first":          "conflict_policy is \"first\", so conflicts go to the pass rising first; the score is shown for reference. Quality is what min_quality and the energy budget go by.",
+	"blackouts.title":             "BLACKOUT WINDOWS",
+	"blackouts.none":              "No blackout windows. Add a [[schedule.blackout]] entry to the config.",
+	"blackouts.timezone":          "Time zone:",
+	"blackouts.every_day":         "every day",
+	"blackouts.next":              "next %s to %s",
+	"blackouts.active":            "IN FORCE until %s",
+	"satellites.title":            "SATELLITE CATALOG",
+	"satellites.enabled":          "scheduled",
+	"satellites.disabled":         "disabled",
+	"satellites.need_name":        "name the satellite to %s, e.g. NOAA-15 or its NORAD ID",
+	"satellites.updated":          "UPDATED",
+	"satellites.unchanged":        "UNCHANGED",
+	"satellites.now_enabled":      "%s will be scheduled",
+	"satellites.now_disabled":     "%s will not be scheduled",
+	"satellites.need_offset":      "give the satellite and the offset in Hz, e.g. satellites offset NOAA-18 -1200",
+	"satellites.now_offset":       "%s will be recorded at %s (offset %s)",
+	"satellites.hz":               "%+d Hz",
+	"satellites.added_tag":        "(added)",
+	"satellites.need_add":         "give the name, NORAD ID and frequency, e.g. satellites add METEOR-M2-3 --norad 57166 --freq 137900000 --mode lrpt",
+	"satellites.added":            "ADDED",
+	"satellites.now_added":        "%s (NORAD %d, %s %s) is in the catalog",
+	"satellites.removed":          "REMOVED",
+	"satellites.now_removed":      "%s is no longer in the catalog; its captures are kept",
+	"satellites.synced_note":      "* frequency from SatNOGS DB; the configured one is in --json as catalog_freq_hz",
+	"catalog_sync.title":          "CATALOG SYNC",
+	"catalog_sync.off":            "off (set sync = true under [catalog])",
+	"catalog_sync.schedule":       "every %dh, next %s",
+	"catalog_sync.schedule_label": "Schedule:",
+	"catalog_sync.source_label":   "SatNOGS DB:",
+	"catalog_sync.status_label":   "Status:",
+	"catalog_sync.running":        "RUNNING",
+	"catalog_sync.last_label":     "Last sync:",
+	"catalog_sync.never":          "never",
+	"catalog_sync.failed":         "lookup failed",
+	"catalog_sync.started":        "STARTED",
+	"catalog_sync.follow":         "follow it with `ephctl watch --filter catalog_synced,log`",
+
+	// retention
+	"retention.title":          "RETENTION",
+	"retention.dry_run_title":  "RETENTION DRY RUN",
+	"retention.policy":         "Policy:",
+	"retention.policy_value":   "%s captures past %s",
+	"retention.off":            "off (set a limit under [retention])",
+	"retention.max_age":        "%d days old",
+	"retention.max_total":      "%s in total",
+	"retention.min_free":       "%s free",
+	"retention.schedule":       "Schedule:",
+	"retention.schedule_value": "every %dm, next %s",
+	"retention.status":         "Status:",
+	"retention.running":        "RUNNING",
+	"retention.last":           "Last sweep:",
+	"retention.never":          "never",
+	"retention.pruned":         "Pruned:",
+	"retention.pruned_value":   "%d captures, %s",
+	"retention.left":           "Left:",
+	"retention.left_value":     "%d captures, %s",
+	"retention.stopped_early":  "(stopped early: %s)",
+	"retention.failed":         "failed",
+	"retention.started":        "STARTED",
+	"retention.follow":         "follow it with `ephctl watch --filter retention,capture_pruned`",
+
+	// scrub
+	"scrub.title":          "INTEGRITY SCRUB",
+	"scrub.schedule":       "Schedule:",
+	"scrub.schedule_value": "every %dh, next %s",
+	"scrub.disabled":       "disabled",
+	"scrub.status":         "Status:",
+	"scrub.running":        "RUNNING",
+	"scrub.last":           "Last scrub:",
+	"scrub.never":          "never",
+	"scrub.stopped_early":  "(stopped early: %s)",
+	"scrub.checked":        "Checked:",
+	"scrub.unverified":     "Unverified:",
+	"scrub.no_checksum":    "(no checksum recorded)",
+	"scrub.corrupt":        "Corrupt:",
+	"scrub.started":        "STARTED",
+	"scrub.follow":         "follow it with `ephctl watch --filter scrub,capture_corrupt`",
+
+	// gallery
+	"gallery.title":         "GALLERY",
+	"gallery.export":        "Export:",
+	"gallery.off":           "off (set gallery.enabled)",
+	"gallery.after_decode":  "after each decode",
+	"gallery.dir":           "Directory:",
+	"gallery.site_title":    "Title:",
+	"gallery.status":        "Status:",
+	"gallery.running":       "RUNNING",
+	"gallery.last":          "Last export:",
+	"gallery.last_value":    "%s (%s)",
+	"gallery.never":         "never",
+	"gallery.error":         "Error:",
+	"gallery.pages":         "Pages:",
+	"gallery.pages_value":   "%d days, %d passes, %d images",
+	"gallery.changes":       "Changes:",
+	"gallery.changes_value": "%d images copied, %d files removed",
+	"gallery.exported":      "EXPORTED",
+
+	// notify
+	"notify.title":          "NOTIFICATIONS",
+	"notify.col_backend":    "Backend",
+	"notify.col_status":     "Status",
+	"notify.col_sent":       "Sent",
+	"notify.col_held":       "Held",
+	"notify.col_failed":     "Failed",
+	"notify.col_last_sent":  "Last Sent",
+	"notify.on":             "on",
+	"notify.off":            "off",
+	"notify.quiet":          "quiet hours",
+	"notify.dropped":        " (+%d dropped)",
+	"notify.last_error":     "last error %s: %s",
+	"notify.sent":           "SENT",
+	"notify.failed":         "FAILED",
+	"notify.test_failed":    "the test message could not be sent to every backend",
+	"notify.summary_failed": "the summary could not be sent to every backend",
+	"notify.kind":           "Kind:",
+	"notify.subject":        "Subject:",
+	"notify.image":          "Image:",
+
+	// stats
+	"stats.title":          "CAPTURE STATISTICS",
+	"stats.uptime":         "Uptime:",
+	"stats.total_captures": "Total captures:",
+	"stats.total_data":     "Total data:",
+	"stats.last_capture":   "Last capture:",
+	"stats.by_satellite":   "BY SATELLITE",
+
+	// consistency
+	"consistency.title":     "STARTUP CONSISTENCY CHECK",
+	"consistency.checked":   "Checked:",
+	"consistency.captures":  "Captures on disk:",
+	"consistency.none":      "History and capture files agree.",
+	"consistency.repaired":  "repaired",
+	"consistency.attention": "needs attention",
+
+	// wake-schedule
+	"wake.title":   "WAKE-UPS",
+	"wake.none":    "No passes scheduled to wake for.",
+	"wake.lead":    "Waking %ds before AOS. Decoding follows the awake-until time.",
+	"wake.resumed": "RESUMED",
+
+	// share
+	"share.title":          "SHARE LINK",
+	"share.id":             "ID:",
+	"share.label":          "For:",
+	"share.expires":        "Expires:",
+	"share.token":          "Token:",
+	"share.url":            "Link:",
+	"share.hint":           "Add ?share=TOKEN to any URL on the public listener, /ws included.",
+	"share.no_public":      "The public listener is off; set server.public_readonly and public_share_only to use the token.",
+	"share.revoked":        "REVOKED",
+	"share.revoked_detail": "Every share link handed out so far no longer works.",
+
+	// station
+	"station.title":          "STATION",
+	"station.position":       "Position:",
+	"station.position_value": "%.4f, %.4f",
+	"station.altitude":       "Altitude:",
+	"station.altitude_value": "%.0f m",
+	"station.min_elev":       "Min elev:",
+	"station.source":         "Source:",
+	"station.gpsd":           "gpsd",
+	"station.no_profiles":    "no [station.NAME] profiles defined",
+	"station.name_or_base":   "give a profile name or --base, not both",
+	"station.switched":       "SWITCHED",
+	"station.now":            "station profile is now %s",
+	"station.unchanged":      "UNCHANGED",
+	"station.already":        "station profile is already %s",
+	"station.base":           "(base)",
+
+	// pass-track
+	"pass_track.title":   "PASS TRACK",
+	"pass_track.step":    "Step:",
+	"pass_track.seconds": "%ds",
+	"pass_track.none":    "No upcoming pass to track.",
+
+	// next-pass --plot
+	"polar.aos":  "AOS %s",
+	"polar.max":  "max %s at %s",
+	"polar.los":  "LOS %s",
+	"polar.mask": "horizon mask",
+
+	// schedule
+	"schedule.title":          "SCHEDULE",
+	"schedule.computed":       "Computed:",
+	"schedule.scheduler":      "Scheduler:",
+	"schedule.paused":         "PAUSED",
+	"schedule.none":           "No schedule computed yet.",
+	"schedule.scheduled":      "scheduled",
+	"schedule.dropped":        "dropped (%s)",
+	"schedule.forced":         "FORCED",
+	"schedule.skipped":        "SKIPPED",
+	"schedule.hint":           "Override a pass with: ephctl schedule skip|force|clear ID",
+	"schedule.energy":         "Energy today:",
+	"schedule.energy_detail":  "%.1f Wh used, %.1f Wh scheduled, of %.1f Wh",
+	"schedule.wh":             "%.1f Wh",
+	"schedule.override_skip":  "SKIPPED",
+	"schedule.override_force": "FORCED",
+	"schedule.override_clear": "CLEARED",
+
+	// captures
+	"captures.title":            "CAPTURES",
+	"captures.none":             "No capture files found.",
+	"captures.deleted":          "DELETED",
+	"captures.restored":         "RESTORED",
+	"captures.trash_title":      "TRASH",
+	"captures.trash_empty":      "No deleted captures.",
+	"captures.trash_off":        "The trash is off (data.trash_days = 0); deletions are permanent.",
+	"captures.trash_hint":       "Restore one with: ephctl captures --restore NAME",
+	"captures.col_deleted":      "Deleted",
+	"captures.col_purge":        "Purged",
+	"captures.downloaded":       "DOWNLOADED",
+	"captures.verified":         "(verified)",
+	"captures.unverified":       "(not verified)",
+	"captures.corrupt":          "CORRUPT",
+	"captures.imported":         "imported",
+	"captures.import_title":     "IMPORTED CAPTURES",
+	"captures.dry_run":          "(dry run)",
+	"captures.import_none":      "Nothing to import.",
+	"captures.time_from_mtime":  "time from file date",
+	"captures.tagged":           "TAGGED",
+	"captures.no_tags":          "(no tags)",
+	"captures.requeued":         "QUEUED FOR UPLOAD",
+	"captures.upload_queued":    "upload queued",
+	"captures.upload_uploading": "uploading",
+	"captures.uploaded":         "uploaded",
+	"captures.upload_failed":    "UPLOAD FAILED",
+	"captures.skipped":          "Skipped (%d):",
+	"captures.decode_done":      "decoded",
+	"captures.decode_scored":    "score %d, %.0f dB",
+	"captures.decode_failed":    "FAILED",
+	"captures.decode_pending":   "pending",
+	"captures.decode_skipped":   "not decoded",
+
+	// tle-info
+	"tle.title":         "TLE CACHE INFO",
+	"tle.cache_file":    "Cache file:",
+	"tle.status":        "Status:",
+	"tle.not_found":     "NOT FOUND",
+	"tle.fresh":         "FRESH",
+	"tle.stale":         "STALE",
+	"tle.age":           "Age:",
+	"tle.last_fetch":    "Last fetch:",
+	"tle.max_age":       "Max age:",
+	"tle.hours":         "%dh",
+	"tle.size":          "Size:",
+	"tle.source":        "Source:",
+	"tle.norad_source":  "NORAD %s:",
+	"tle.from_cache":    "kept from the last cache",
+	"tle.from_embedded": "embedded",
+	"tle.max_epoch_age": "Max epoch age:",
+	"tle.days":          "%dd",
+	"tle.age_days":      "%.1fd",
+	"tle.stale_epochs":  "%d element sets are older than %d days; pass times may be off. Check the sources above.",
+
+	// sat
+	"sat.usage":             "usage: ephctl sat <satellite> [--count N]",
+	"sat.norad":             "NORAD:",
+	"sat.mhz":               "%.4f MHz",
+	"sat.tle_epoch":         "TLE epoch:",
+	"sat.tle_epoch_value":   "%s (%s)",
+	"sat.age":               "%s old",
+	"sat.age_days":          "%d days old",
+	"sat.no_tle":            "no TLE available",
+	"sat.captures":          "Captures:",
+	"sat.captures_value":    "%d ok, %d failed, %s success since daemon start",
+	"sat.rate":              "%.0f%%",
+	"sat.no_rate":           "n/a",
+	"sat.last_capture":      "Last capture:",
+	"sat.next_passes":       "NEXT PASSES",
+	"sat.prediction_failed": "prediction failed:",
+	"sat.recent_captures":   "RECENT CAPTURES",
+
+	// config, config-list
+	"config.title":          "DAEMON CONFIGURATION",
+	"config.resolved_title": "RESOLVED CONFIGURATION",
+	"config.sources":        "Sources:",
+	"config.source":         "%d. %s",
+	"config.defaults":       "(built-in defaults)",
+	"config.set":            "(set)",
+	"config.set_count":      "(%d set)",
+	"config.none":           "(none)",
+	"config.any":            "any",
+	"config.all":            "(all)",
+	"config.system":         "(system)",
+	"config.built_in":       "(built-in)",
+	"config.profiles_title": "CONFIG PROFILES",
+	"config.directory":      "Directory:",
+	"config.no_profiles":    "No profiles found.",
+	"config.create_hint":    "Create one with:",
+	"config.col_path":       "Path",
+	"config.col_modified":   "Modified",
+
+	// reload, config-persist
+	"reload.reloaded":    "RELOADED",
+	"reload.unchanged":   "No settings changed.",
+	"reload.col_setting": "SETTING",
+	"reload.col_old":     "OLD",
+	"reload.col_new":     "NEW",
+	"persist.nothing":    "nothing to persist: use --gpsd, --lat/--lon/--alt, or --ppm",
+	"persist.persisted":  "PERSISTED",
+	"persist.unchanged":  "Config already had these values.",
+
+	// rules
+	"rules.title":          "RULES",
+	"rules.none":           "No rules configured. Add a [[rules]] entry to the config.",
+	"rules.col_when":       "When",
+	"rules.col_actions":    "Actions",
+	"rules.col_hits":       "Hits",
+	"rules.col_last_fired": "Last Fired",
+	"rules.held":           " (+%d held)",
+	"rules.name":           "%s:",
+	"rules.last_error":     "last error: %s",
+
+	// plugins
+	"plugins.title":         "PLUGINS",
+	"plugins.none":          "No plugins configured. Add a [[plugins]] entry to the config.",
+	"plugins.col_events":    "Events",
+	"plugins.col_delivered": "Delivered",
+	"plugins.col_dropped":   "Dropped",
+	"plugins.col_restarts":  "Restarts",
+	"plugins.running":       "running (pid %d)",
+	"plugins.stopped":       "stopped",
+	"plugins.all_events":    "all",
+	"plugins.control":       "(control)",
+	"plugins.name":          "%s:",
+	"plugins.last_error":    "last error: %s",
+
+	// scripts
+	"scripts.title":        "SCRIPTS",
+	"scripts.none":         "No scripts configured. Add a [[scripts]] entry to the config.",
+	"scripts.col_events":   "Events",
+	"scripts.col_runs":     "Runs",
+	"scripts.col_failures": "Failures",
+	"scripts.col_last_run": "Last Run",
+	"scripts.loaded":       "loaded",
+	"scripts.not_loaded":   "not loaded",
+	"scripts.all_events":   "all",
+	"scripts.name":         "%s:",
+	"scripts.last_error":   "last error: %s",
+
+	// trigger
+	"trigger.need_satellite":    "satellite name or --norad-id required",
+	"trigger.triggered":         "TRIGGERED",
+	"trigger.merged":            "MERGED",
+	"trigger.already_recording": "ALREADY RECORDING",
+	"trigger.failed":            "FAILED",
+
+	// watch
+	"watch.bad_scheme":         "unsupported scheme: %s",
+	"watch.connected":          "connected",
+	"watch.filter":             "filter:",
+	"watch.disconnecting":      "disconnecting...",
+	"watch.resync":             "RESYNC",
+	"watch.restarted":          "daemon restarted",
+	"watch.missed":             "%d heartbeat(s) missed",
+	"watch.clock":              "CLOCK",
+	"watch.clock_synced":       "clocks back in sync",
+	"watch.clock_ahead":        "daemon clock is %s ahead of this machine",
+	"watch.clock_behind":       "daemon clock is %s behind this machine",
+	"watch.refresh_failed":     "status refresh failed: %v",
+	"watch.tracking":           ", tracking %s",
+	"watch.state":              "STATE",
+	"watch.heartbeat":          "heartbeat",
+	"watch.uptime":             "up %s",
+	"watch.health":             "HEALTH",
+	"watch.flapping":           "FLAPPING",
+	"watch.writable":           "WRITABLE",
+	"watch.read_only":          "READ-ONLY",
+	"watch.crash":              "CRASH",
+	"watch.report":             "report:",
+	"watch.config":             "CONFIG",
+	"watch.reloaded_from":      "reloaded from %s",
+	"watch.config_changes":     "(%s, %d changed)",
+	"watch.watchdog":           "WATCHDOG",
+	"watch.recovered":          "RECOVERED",
+	"watch.hung":               "HUNG",
+	"watch.mode":               "MODE",
+	"watch.station":            "STATION",
+	"watch.position":           "(%.4f, %.4f)",
+	"watch.sdr_busy":           "SDR BUSY",
+	"watch.sdr_retrying":       "retrying until %s",
+	"watch.sdr_killing":        "terminating (kill_competing)",
+	"watch.conflict":           "CONFLICT",
+	"watch.blackout":           "BLACKOUT",
+	"watch.skipped":            "%s at %s skipped",
+	"watch.tle_stale":          "STALE TLE",
+	"watch.tle_stale_detail":   "%s TLE epoch %s is %.1f days old",
+	"watch.tle_shift":          "TLE SHIFT",
+	"watch.tle_shift_detail":   "%d upcoming passes moved, AOS by up to %.1fs",
+	"watch.tle_shift_pass":     "%s at %s  %+.1fs",
+	"watch.satellite":          "SATELLITE",
+	"watch.offset":             "offset %s",
+	"watch.sat_added":          "added, %.4f MHz %s",
+	"watch.sat_removed":        "removed from catalog",
+	"watch.corrupt":            "CORRUPT",
+	"watch.consistency":        "CONSISTENCY",
+	"watch.consistency_detail": "%d captures, %d discrepancies, %d repaired",
+	"watch.scrub":              "SCRUB",
+	"watch.scrub_detail":       "%d checked, %d corrupt",
+	"watch.stopped_early":      " (stopped early: %s)",
+	"watch.uploaded":           "UPLOADED",
+	"watch.upload_failed":      "UPLOAD FAILED",
+	"watch.upload_attempts":    "after %d attempts: %s",
+	"watch.pruned":             "PRUNED",
+	"watch.pruned_delete":      "deleted",
+	"watch.pruned_archive":     "archived",
+	"watch.retention":          "RETENTION",
+	"watch.retention_detail":   "%d pruned, %s freed",
+	"watch.gallery":            "GALLERY",
+	"watch.gallery_detail":     "%d images over %d days, %d new",
+	"watch.gallery_failed":     "export failed: %s",
+	"watch.notify":             "NOTIFY",
+	"watch.notify_sent":        "%s sent to %s",
+	"watch.notify_failed":      "%s to %s failed: %s",
+	"watch.replay":             "REPLAY",
+	"watch.replay_started":     "STARTED",
+	"watch.replay_stopped":     "STOPPED",
+	"watch.replay_finished":    "FINISHED",
+	"watch.replay_detail":      "pass %d %s, %d events",
+	"watch.replay_speed":       " at %gx",
+	"watch.replay_sent":        "pass %d %s, %d of %d events sent",
+	"watch.catalog":            "CATALOG",
+	"watch.catalog_detail":     "%d synced, %d retuned",
+	"watch.catalog_failed":     ", %d failed",
+	"watch.catalog_was":        "(was %.4f MHz)",
+	"watch.pass_scheduled":     "PASS SCHEDULED",
+	"watch.debug":              "DEBUG",
+	"watch.info":               "INFO",
+	"watch.warn":               "WARN",
+
+	// logs
+	"history.title":   "PASS HISTORY",
+	"history.none":    "No pass attempts match.",
+	"history.manual":  "(manual)",
+	"history.deleted": "(deleted)",
+
+	"events.title":         "EVENT LOG",
+	"events.none":          "No logged events match.",
+	"events.not_persisted": "The event log is off ([events] persist = false); only events from when it was on are shown.",
+
+	"replay.title":        "EVENT REPLAY",
+	"replay.none":         "No replay is running.",
+	"replay.bad_id":       "%q is not a pass ID; `ephctl history` lists them",
+	"replay.started":      "REPLAY STARTED",
+	"replay.stopped":      "STOPPED",
+	"replay.follow":       "follow it with `ephctl watch`; replayed events carry \"replay\": true",
+	"replay.pass":         "Pass:",
+	"replay.speed":        "Speed:",
+	"replay.speed_value":  "%gx",
+	"replay.events":       "Events:",
+	"replay.events_value": "%d of %d sent",
+	"replay.duration":     "Duration:",
+
+	"logs.title": "DAEMON LOGS",
+	"logs.none":  "No log entries found.",
+}
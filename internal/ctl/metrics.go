@@ -0,0 +1,23 @@
+package ctl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Metrics prints the daemon's Prometheus metrics exposition as-is. The
+// text format is already line-oriented, so there is no separate JSON mode.
+func Metrics(baseURL string) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	status, body, err := getRaw(baseURL, "/metrics")
+	if err != nil {
+		return err
+	}
+	if status != 200 {
+		return fmt.Errorf("HTTP %d from /metrics", status)
+	}
+
+	fmt.Print(string(body))
+	return nil
+}
@@ -0,0 +1,69 @@
+package ctl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ModeOptions configures the mode command.
+type ModeOptions struct {
+	Set   string // "demo" or "live"; empty shows the current mode
+	Force bool
+	JSON  bool
+}
+
+// Mode shows the daemon's operating mode, or switches between the demo
+// runner and the live scheduler via POST /api/mode without a restart.
+func Mode(baseURL string, opts ModeOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	if opts.Set == "" {
+		var resp struct {
+			Mode       string `json:"mode"`
+			Configured string `json:"configured"`
+		}
+		if err := getJSON(baseURL, "/api/mode", &resp); err != nil {
+			return err
+		}
+		if opts.JSON {
+			return printJSON(resp)
+		}
+		fmt.Println()
+		fmt.Printf("  %s  %s", colorize(bold, "MODE"), colorize(cyan, resp.Mode))
+		if resp.Configured != resp.Mode {
+			fmt.Printf("  %s", colorize(dim, "(config file says "+resp.Configured+")"))
+		}
+		fmt.Println()
+		fmt.Println()
+		return nil
+	}
+
+	var demo bool
+	switch opts.Set {
+	case "demo":
+		demo = true
+	case "live":
+	default:
+		return fmt.Errorf("invalid mode %q (want demo or live)", opts.Set)
+	}
+
+	var result struct {
+		OK      bool   `json:"ok"`
+		Mode    string `json:"mode"`
+		Changed bool   `json:"changed"`
+	}
+	body := map[string]any{"demo": demo, "force": opts.Force}
+	if err := postJSON(baseURL, "/api/mode", body, &result); err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		return printJSON(result)
+	}
+	if result.Changed {
+		fmt.Printf("\n  %s  now running in %s mode\n\n", colorize(green, "SWITCHED"), result.Mode)
+	} else {
+		fmt.Printf("\n  %s  already in %s mode\n\n", colorize(dim, "UNCHANGED"), result.Mode)
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package ctl
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 )
@@ -9,20 +10,31 @@ import (
 // NextPassOptions configures the next-pass command.
 type NextPassOptions struct {
 	Satellite string
-	JSON      bool
+	TZ        string // as PassesOptions.TZ
+	// Plot draws the pass's track on a polar sky chart under the details.
+	Plot bool
+	JSON bool
 }
 
 // NextPass shows the next upcoming satellite pass.
 func NextPass(baseURL string, opts NextPassOptions) error {
 	baseURL = strings.TrimRight(baseURL, "/")
 
-	path := "/api/next-pass"
+	params := url.Values{}
 	if opts.Satellite != "" {
-		path += "?satellite=" + opts.Satellite
+		params.Set("satellite", opts.Satellite)
+	}
+	if opts.TZ != "" && opts.TZ != "station" {
+		params.Set("tz", opts.TZ)
+	}
+	path := "/api/next-pass"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
 	}
 
 	var resp struct {
 		Pass *struct {
+			ID          string  `json:"id"`
 			Satellite   string  `json:"satellite"`
 			NoradID     int     `json:"norad_id"`
 			FreqHz      int     `json:"freq_hz"`
@@ -31,12 +43,15 @@ func NextPass(baseURL string, opts NextPassOptions) error {
 			MaxElev     float64 `json:"max_elev"`
 			MaxElevTime string  `json:"max_elev_time"`
 			DurationS   int     `json:"duration_s"`
+			AOSLocal    string  `json:"aos_local"`
+			LOSLocal    string  `json:"los_local"`
 		} `json:"pass"`
 		CountdownS int `json:"countdown_s"`
 		Station    struct {
-			Lat float64 `json:"lat"`
-			Lon float64 `json:"lon"`
-			Alt float64 `json:"alt"`
+			Lat         float64 `json:"lat"`
+			Lon         float64 `json:"lon"`
+			Alt         float64 `json:"alt"`
+			Approximate bool    `json:"approximate"`
 		} `json:"station"`
 	}
 	if err := getJSON(baseURL, path, &resp); err != nil {
@@ -48,11 +63,11 @@ func NextPass(baseURL string, opts NextPassOptions) error {
 	}
 
 	fmt.Println()
-	fmt.Println(header("  NEXT PASS"))
-	fmt.Println("  " + strings.Repeat("─", 42))
+	fmt.Println(header("  " + tr("next_pass.title")))
+	fmt.Println("  " + rule(42))
 
 	if resp.Pass == nil {
-		fmt.Println("  No upcoming passes found.")
+		fmt.Println("  " + tr("passes.none"))
 		fmt.Println()
 		return nil
 	}
@@ -60,17 +75,40 @@ func NextPass(baseURL string, opts NextPassOptions) error {
 	p := resp.Pass
 	countdown := time.Duration(resp.CountdownS) * time.Second
 
-	fmt.Printf("  Satellite:  %s (NORAD %d)\n", p.Satellite, p.NoradID)
-	fmt.Printf("  Frequency:  %.3f MHz\n", float64(p.FreqHz)/1e6)
-	fmt.Printf("  AOS:        %s\n", p.AOS)
-	fmt.Printf("  LOS:        %s\n", p.LOS)
-	fmt.Printf("  Max elev:   %.1f°\n", p.MaxElev)
-	fmt.Printf("  Duration:   %s\n", formatDuration(time.Duration(p.DurationS)*time.Second))
-
+	f := newFieldList("  ")
+	f.add(tr("pass.satellite"), tr("pass.satellite_norad", p.Satellite, p.NoradID))
+	f.add(tr("pass.frequency"), tr("pass.mhz", float64(p.FreqHz)/1e6))
+	aos, los := p.AOS, p.LOS
+	if opts.TZ != "" && p.AOSLocal != "" {
+		aos, los = p.AOSLocal, p.LOSLocal
+	}
+	f.add(tr("pass.aos"), aos)
+	f.add(tr("pass.los"), los)
+	f.add(tr("pass.max_elev"), degrees(p.MaxElev))
+	f.add(tr("pass.duration"), formatDuration(time.Duration(p.DurationS)*time.Second))
 	if countdown > 0 {
-		fmt.Printf("  Countdown:  %s\n", formatDuration(countdown))
+		f.add(tr("pass.countdown"), formatDuration(countdown))
 	} else {
-		fmt.Printf("  Status:     %s\n", colorize(green, "NOW"))
+		f.add(tr("pass.status"), colorize(green, tr("pass.now")))
+	}
+	f.flush()
+	if resp.Station.Approximate {
+		fmt.Println()
+		fmt.Println("  " + colorize(yellow, tr("passes.approximate")))
+	}
+
+	if opts.Plot && p.ID != "" {
+		track, err := fetchPassTrack(baseURL, p.ID, 0)
+		if err != nil {
+			return err
+		}
+		fmt.Println()
+		for _, line := range renderPolar(track) {
+			if line != "" {
+				line = "  " + line
+			}
+			fmt.Println(line)
+		}
 	}
 
 	fmt.Println()
@@ -0,0 +1,147 @@
+package ctl
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// NotifyOptions configures the notify command.
+type NotifyOptions struct {
+	Test    bool   // send a test message instead of listing the backends
+	Kind    string // with Test, fire this kind's template instead
+	DryRun  bool   // with Kind, only show the rendered message
+	Summary bool   // send the daily summary now
+	JSON    bool
+}
+
+// Notify lists the notification backends and what each has sent, or sends
+// a test message via POST /api/notify/test or the daily summary via
+// POST /api/notify/summary.
+func Notify(baseURL string, opts NotifyOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	if opts.Kind != "" && opts.DryRun {
+		var msg struct {
+			Kind    string   `json:"kind"`
+			Subject string   `json:"subject"`
+			Text    string   `json:"text"`
+			Images  []string `json:"images"`
+		}
+		if err := postJSON(baseURL, "/api/notify/test?kind="+url.QueryEscape(opts.Kind)+"&dry_run=true", nil, &msg); err != nil {
+			return err
+		}
+		if opts.JSON {
+			return printJSON(msg)
+		}
+		fmt.Println()
+		f := newFieldList("  ")
+		f.add(tr("notify.kind"), msg.Kind)
+		if msg.Subject != "" {
+			f.add(tr("notify.subject"), msg.Subject)
+		}
+		for _, img := range msg.Images {
+			f.add(tr("notify.image"), img)
+		}
+		f.flush()
+		fmt.Println()
+		for _, line := range strings.Split(msg.Text, "\n") {
+			if line == "" {
+				fmt.Println()
+				continue
+			}
+			fmt.Println("  " + line)
+		}
+		fmt.Println()
+		return nil
+	}
+
+	if opts.Test || opts.Summary {
+		path, failed := "/api/notify/test", tr("notify.test_failed")
+		if opts.Kind != "" {
+			path += "?kind=" + url.QueryEscape(opts.Kind)
+		}
+		if opts.Summary {
+			path, failed = "/api/notify/summary", tr("notify.summary_failed")
+		}
+		var result struct {
+			OK      bool `json:"ok"`
+			Results []struct {
+				Backend string `json:"backend"`
+				Error   string `json:"error"`
+			} `json:"results"`
+		}
+		if err := postJSON(baseURL, path, nil, &result); err != nil {
+			return err
+		}
+		if opts.JSON {
+			return printJSON(result)
+		}
+		fmt.Println()
+		for _, r := range result.Results {
+			if r.Error != "" {
+				fmt.Printf("  %s  %s  %s\n", colorize(red, tr("notify.failed")), r.Backend, r.Error)
+			} else {
+				fmt.Printf("  %s  %s\n", colorize(green, tr("notify.sent")), r.Backend)
+			}
+		}
+		fmt.Println()
+		if !result.OK {
+			return errors.New(failed)
+		}
+		return nil
+	}
+
+	var resp struct {
+		Backends []struct {
+			Name        string `json:"name"`
+			Enabled     bool   `json:"enabled"`
+			Sent        int    `json:"sent"`
+			Failed      int    `json:"failed"`
+			Dropped     int    `json:"dropped"`
+			Held        int    `json:"held"`
+			Quiet       bool   `json:"quiet"`
+			LastSent    string `json:"last_sent"`
+			LastError   string `json:"last_error"`
+			LastErrorAt string `json:"last_error_at"`
+		} `json:"backends"`
+	}
+	if err := getJSON(baseURL, "/api/notify", &resp); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("notify.title")))
+	t := newTable("  ", tr("notify.col_backend"), tr("notify.col_status"), tr("notify.col_sent"), tr("notify.col_held"), tr("notify.col_failed"), tr("notify.col_last_sent"))
+	t.alignRight(2, 3, 4)
+	for _, b := range resp.Backends {
+		status := colorize(dim, tr("notify.off"))
+		switch {
+		case b.Enabled && b.Quiet:
+			status = colorize(yellow, tr("notify.quiet"))
+		case b.Enabled:
+			status = colorize(green, tr("notify.on"))
+		}
+		failed := fmt.Sprint(b.Failed)
+		if b.Dropped > 0 {
+			failed += colorize(dim, tr("notify.dropped", b.Dropped))
+		}
+		last := "-"
+		if b.LastSent != "" {
+			last = formatPassTime(b.LastSent)
+		}
+		t.row(b.Name, status, fmt.Sprint(b.Sent), fmt.Sprint(b.Held), failed, last)
+	}
+	t.flush()
+	for _, b := range resp.Backends {
+		if b.LastError != "" {
+			fmt.Printf("  %s %s\n", label(b.Name+":", 14), colorize(red, tr("notify.last_error", formatPassTime(b.LastErrorAt), b.LastError)))
+		}
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,23 @@
+package ctl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OpenAPI prints the daemon's OpenAPI document as served, for client
+// generators and API explorers.
+func OpenAPI(baseURL string) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	status, body, err := getRaw(baseURL, "/api/openapi.json")
+	if err != nil {
+		return err
+	}
+	if status != 200 {
+		return fmt.Errorf("HTTP %d from /api/openapi.json", status)
+	}
+
+	fmt.Println(strings.TrimRight(string(body), "\n"))
+	return nil
+}
@@ -0,0 +1,56 @@
+package ctl
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// OutputFile captures everything ephctl prints on stdout into a file, for
+// cron jobs and scripts. Output is written to a temporary sibling and only
+// renamed into place by Close(true), so a failed run never replaces a
+// previous good result with a partial one.
+type OutputFile struct {
+	path   string
+	tmp    *os.File
+	stdout *os.File
+}
+
+// OpenOutputFile redirects stdout to a new file that will become path.
+// Color is disabled automatically because the output is not a terminal.
+func OpenOutputFile(path string) (*OutputFile, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, err
+	}
+	o := &OutputFile{path: path, tmp: tmp, stdout: os.Stdout}
+	os.Stdout = tmp
+	return o, nil
+}
+
+// Close restores stdout and, if success is true, moves the output into
+// place. Otherwise the temporary file is discarded.
+func (o *OutputFile) Close(success bool) error {
+	os.Stdout = o.stdout
+	err := o.tmp.Close()
+	if !success || err != nil {
+		os.Remove(o.tmp.Name())
+		return err
+	}
+	return os.Rename(o.tmp.Name(), o.path)
+}
+
+// Quiet discards formatted output on stdout. Errors are still reported on
+// stderr and through the exit status.
+func Quiet() error {
+	devnull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	os.Stdout = devnull
+	return nil
+}
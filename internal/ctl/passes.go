@@ -15,7 +15,15 @@ import (
 type PassesOptions struct {
 	Count     int
 	Satellite string
-	JSON      bool
+	MinElev   float64 // degrees; 0 means no filter
+	Direction string  // N or S; empty means either
+	// TZ shows times in this zone, rendered by the daemon: an IANA name,
+	// or "station" for station.timezone. Empty uses this machine's zone.
+	TZ string
+	// Score adds how the scheduler scores each pass and what the score is
+	// made of.
+	Score bool
+	JSON  bool
 }
 
 // Passes lists upcoming satellite passes from the daemon.
@@ -30,6 +38,18 @@ func Passes(baseURL string, opts PassesOptions) error {
 	if opts.Satellite != "" {
 		params.Set("satellite", opts.Satellite)
 	}
+	if opts.MinElev > 0 {
+		params.Set("min_elev", strconv.FormatFloat(opts.MinElev, 'f', -1, 64))
+	}
+	if opts.Direction != "" {
+		params.Set("direction", opts.Direction)
+	}
+	if opts.TZ != "" && opts.TZ != "station" {
+		params.Set("tz", opts.TZ)
+	}
+	if opts.Score {
+		params.Set("score", "true")
+	}
 	path := "/api/passes"
 	if len(params) > 0 {
 		path += "?" + params.Encode()
@@ -46,18 +66,46 @@ func Passes(baseURL string, opts PassesOptions) error {
 			MaxElevTime string  `json:"max_elev_time"`
 			AOSAzimuth  float64 `json:"aos_azimuth"`
 			LOSAzimuth  float64 `json:"los_azimuth"`
+			Direction   string  `json:"direction"`
 			DurationS   int     `json:"duration_s"`
+			SunSep      float64 `json:"sun_separation"`
+			SunFlag     bool    `json:"sun_interference"`
+			Daylight    bool    `json:"daylight"`
+			CloudCover  *int    `json:"cloud_cover"`
+			Quality     int     `json:"quality"`
+			LowQuality  bool    `json:"low_quality"`
+			Disabled    bool    `json:"disabled"`
+			Blackout    string  `json:"blackout"`
+			Score       *struct {
+				Policy  string  `json:"policy"`
+				Value   float64 `json:"value"`
+				Factors struct {
+					Priority        int     `json:"priority"`
+					PriorityPoints  float64 `json:"priority_points"`
+					ElevationPoints float64 `json:"elevation_points"`
+					LengthPoints    float64 `json:"length_points"`
+				} `json:"factors"`
+				Quality struct {
+					ElevationPoints float64 `json:"elevation_points"`
+					HighS           int     `json:"high_s"`
+					HighPoints      float64 `json:"high_points"`
+					LengthPoints    float64 `json:"length_points"`
+				} `json:"quality"`
+			} `json:"score,omitempty"`
+			AOSLocal string `json:"aos_local"`
+			LOSLocal string `json:"los_local"`
 		} `json:"passes"`
 		Station struct {
-			Lat float64 `json:"lat"`
-			Lon float64 `json:"lon"`
-			Alt float64 `json:"alt"`
+			Lat         float64 `json:"lat"`
+			Lon         float64 `json:"lon"`
+			Alt         float64 `json:"alt"`
+			Approximate bool    `json:"approximate"`
 		} `json:"station"`
 	}
 
 	// Passes computation may involve TLE network fetches and SGP4 propagation,
 	// so use a longer timeout than the default 5s client.
-	passClient := &http.Client{Timeout: 60 * time.Second}
+	passClient := &http.Client{Timeout: 60 * time.Second, Transport: tokenTransport{}}
 	fullURL := baseURL + path
 	httpResp, err := passClient.Get(fullURL)
 	if err != nil {
@@ -81,32 +129,102 @@ func Passes(baseURL string, opts PassesOptions) error {
 	}
 
 	fmt.Println()
-	fmt.Println(header("  UPCOMING PASSES"))
-	fmt.Printf("  %s %.4f, %.4f, %.0fm\n",
-		colorize(dim, "Station:"),
-		resp.Station.Lat, resp.Station.Lon, resp.Station.Alt,
+	fmt.Println(header("  " + tr("passes.title")))
+	fmt.Printf("  %s %s",
+		colorize(dim, tr("passes.station")),
+		tr("passes.station_position", resp.Station.Lat, resp.Station.Lon, resp.Station.Alt),
 	)
+	if resp.Station.Approximate {
+		fmt.Printf("  %s", colorize(yellow, tr("passes.approximate")))
+	}
+	fmt.Println()
 
 	if len(resp.Passes) == 0 {
-		fmt.Println(colorize(dim, "  No upcoming passes found."))
+		fmt.Println(colorize(dim, "  "+tr("passes.none")))
 		fmt.Println()
 		return nil
 	}
 
-	t := newTable("  ", "#", "Satellite", "AOS", "LOS", "Elev", "Duration")
-	t.alignRight(0, 4)
+	// The sun and cloud columns only appear when a pass needs them.
+	sunCol, cloudCol := false, false
+	for _, p := range resp.Passes {
+		sunCol = sunCol || p.SunFlag
+		cloudCol = cloudCol || p.CloudCover != nil
+	}
+	headers := []string{"#", tr("col.satellite"), tr("col.aos"), tr("col.los"), tr("col.elev"), tr("col.dir"), tr("col.duration"), tr("col.quality")}
+	if cloudCol {
+		headers = append(headers, tr("col.clouds"))
+	}
+	if sunCol {
+		headers = append(headers, tr("col.sun"))
+	}
+	if opts.Score {
+		headers = append(headers, tr("col.score"), tr("col.score_factors"), tr("col.quality_factors"))
+	}
+	t := newTable("  ", headers...)
+	t.alignRight(0, 4, 7)
+	if opts.Score {
+		t.alignRight(len(headers) - 3)
+	}
 	for i, p := range resp.Passes {
-		t.row(
+		sat := p.Satellite
+		if p.Disabled {
+			// Disabled satellites are predicted but never scheduled.
+			sat = colorize(dim, sat+" "+tr("passes.disabled"))
+		} else if p.Blackout != "" {
+			sat = colorize(dim, sat+" "+tr("passes.blackout", p.Blackout))
+		} else if p.LowQuality {
+			sat = colorize(dim, sat+" "+tr("passes.low_quality"))
+		}
+		cells := []string{
 			fmt.Sprintf("%d", i+1),
-			p.Satellite,
-			formatPassTime(p.AOS),
-			formatPassTime(p.LOS),
-			fmt.Sprintf("%.1f°", p.MaxElev),
-			formatDuration(time.Duration(p.DurationS)*time.Second),
-		)
+			sat,
+			passTime(p.AOS, p.AOSLocal, opts.TZ),
+			passTime(p.LOS, p.LOSLocal, opts.TZ),
+			degrees(p.MaxElev),
+			directionLetter(p.Direction),
+			formatDuration(time.Duration(p.DurationS) * time.Second),
+			fmt.Sprintf("%d", p.Quality),
+		}
+		if cloudCol {
+			clouds := ""
+			if p.CloudCover != nil {
+				clouds = fmt.Sprintf("%d%%", *p.CloudCover)
+				if !p.Daylight {
+					clouds += " " + glyph("☾", "(night)")
+				}
+			}
+			cells = append(cells, clouds)
+		}
+		if sunCol {
+			sun := ""
+			if p.SunFlag {
+				sun = colorize(yellow, tr("passes.sun_near", degrees(p.SunSep)))
+			}
+			cells = append(cells, sun)
+		}
+		if opts.Score {
+			if s := p.Score; s != nil {
+				cells = append(cells,
+					fmt.Sprintf("%.1f", s.Value),
+					tr("passes.score_factors", s.Factors.PriorityPoints, s.Factors.ElevationPoints, s.Factors.LengthPoints),
+					tr("passes.quality_factors", s.Quality.ElevationPoints, s.Quality.HighPoints, s.Quality.LengthPoints))
+			} else {
+				cells = append(cells, "", "", "")
+			}
+		}
+		t.row(cells...)
 	}
 	t.flush()
 	fmt.Println()
+	if opts.Score && len(resp.Passes) > 0 && resp.Passes[0].Score != nil {
+		if resp.Passes[0].Score.Policy == "first" {
+			fmt.Println("  " + colorize(dim, tr("passes.score_first")))
+		} else {
+			fmt.Println("  " + colorize(dim, tr("passes.score_hint")))
+		}
+		fmt.Println()
+	}
 
 	return nil
 }
@@ -119,3 +237,28 @@ func formatPassTime(s string) string {
 	}
 	return t.Local().Format("2006-01-02 15:04 MST")
 }
+
+// passTime renders a pass time in this machine's zone, or as the daemon
+// rendered it in local when a --tz was asked for.
+func passTime(utc, local, tz string) string {
+	if tz == "" || local == "" {
+		return formatPassTime(utc)
+	}
+	t, err := time.Parse(time.RFC3339, local)
+	if err != nil {
+		return local
+	}
+	return t.Format("2006-01-02 15:04 -07:00")
+}
+
+// directionLetter renders a pass direction compactly for tables.
+func directionLetter(dir string) string {
+	switch dir {
+	case "northbound":
+		return "N"
+	case "southbound":
+		return "S"
+	default:
+		return dir
+	}
+}
@@ -0,0 +1,123 @@
+package ctl
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PassTrackOptions configures the pass-track command.
+type PassTrackOptions struct {
+	ID   string // pass ID as listed by passes or schedule; empty for the next pass
+	Step int    // seconds between points; 0 for the daemon's default
+	JSON bool
+}
+
+// passTrack is the body of GET /api/passes/{id}/track.
+type passTrack struct {
+	ID        string  `json:"id"`
+	Satellite string  `json:"satellite"`
+	NoradID   int     `json:"norad_id"`
+	FreqHz    int     `json:"freq_hz"`
+	AOS       string  `json:"aos"`
+	LOS       string  `json:"los"`
+	MaxElev   float64 `json:"max_elev"`
+	StepS     int     `json:"step_s"`
+	Horizon   []struct {
+		Azimuth   float64 `json:"azimuth"`
+		Elevation float64 `json:"elevation"`
+	} `json:"horizon"`
+	Points []struct {
+		Time         string  `json:"time"`
+		Azimuth      float64 `json:"azimuth"`
+		Elevation    float64 `json:"elevation"`
+		RangeKm      float64 `json:"range_km"`
+		RangeRateKmS float64 `json:"range_rate_km_s"`
+		DopplerHz    float64 `json:"doppler_hz"`
+		Lat          float64 `json:"lat"`
+		Lon          float64 `json:"lon"`
+		AltKm        float64 `json:"alt_km"`
+	} `json:"points"`
+}
+
+// fetchPassTrack gets the track of pass id, or of the next pass when id
+// is empty. It returns nil when there is no next pass.
+func fetchPassTrack(baseURL, id string, step int) (*passTrack, error) {
+	if id == "" {
+		var next struct {
+			Pass *struct {
+				ID string `json:"id"`
+			} `json:"pass"`
+		}
+		if err := getJSON(baseURL, "/api/next-pass", &next); err != nil {
+			return nil, err
+		}
+		if next.Pass == nil || next.Pass.ID == "" {
+			return nil, nil
+		}
+		id = next.Pass.ID
+	}
+	path := "/api/passes/" + url.PathEscape(id) + "/track"
+	if step > 0 {
+		path += fmt.Sprintf("?step=%d", step)
+	}
+	var track passTrack
+	if err := getJSON(baseURL, path, &track); err != nil {
+		return nil, err
+	}
+	return &track, nil
+}
+
+// PassTrack shows where the satellite will be at each step of a pass: its
+// azimuth, elevation, range and Doppler shift from the station, and the
+// point on the ground below it.
+func PassTrack(baseURL string, opts PassTrackOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	track, err := fetchPassTrack(baseURL, opts.ID, opts.Step)
+	if err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(track)
+	}
+	if track == nil {
+		fmt.Println()
+		fmt.Println("  " + tr("pass_track.none"))
+		fmt.Println()
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("pass_track.title")))
+	f := newFieldList("  ")
+	f.add(tr("pass.satellite"), tr("pass.satellite_norad", track.Satellite, track.NoradID))
+	f.add(tr("pass.frequency"), tr("pass.mhz", float64(track.FreqHz)/1e6))
+	f.add(tr("pass.aos"), formatPassTime(track.AOS))
+	f.add(tr("pass.los"), formatPassTime(track.LOS))
+	f.add(tr("pass.max_elev"), degrees(track.MaxElev))
+	f.add(tr("pass_track.step"), tr("pass_track.seconds", track.StepS))
+	f.flush()
+	fmt.Println()
+
+	t := newTable("  ", tr("col.time"), tr("col.azimuth"), tr("col.elev"), tr("col.range"), tr("col.doppler"), tr("col.lat"), tr("col.lon"))
+	t.alignRight(1, 2, 3, 4, 5, 6)
+	for _, p := range track.Points {
+		at := p.Time
+		if ts, err := time.Parse(time.RFC3339, p.Time); err == nil {
+			at = ts.Local().Format("15:04:05")
+		}
+		t.row(at,
+			degrees(p.Azimuth),
+			degrees(p.Elevation),
+			fmt.Sprintf("%.0f km", p.RangeKm),
+			fmt.Sprintf("%+.2f kHz", p.DopplerHz/1e3),
+			fmt.Sprintf("%.2f", p.Lat),
+			fmt.Sprintf("%.2f", p.Lon),
+		)
+	}
+	t.flush()
+	fmt.Println()
+	return nil
+}
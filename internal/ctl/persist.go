@@ -0,0 +1,74 @@
+package ctl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ConfigPersistOptions configures the config-persist command. Nil fields
+// are left unchanged.
+type ConfigPersistOptions struct {
+	GPSD          bool
+	Latitude      *float64
+	Longitude     *float64
+	Altitude      *float64
+	PPMCorrection *int
+	JSON          bool
+}
+
+// ConfigPersist writes runtime-discovered values (a gpsd position or a
+// measured ppm correction) into the daemon's active config file via
+// POST /api/config/persist, and shows what changed.
+func ConfigPersist(baseURL string, opts ConfigPersistOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	body := map[string]any{}
+	if opts.GPSD {
+		body["gpsd"] = true
+	}
+	if opts.Latitude != nil {
+		body["latitude"] = *opts.Latitude
+	}
+	if opts.Longitude != nil {
+		body["longitude"] = *opts.Longitude
+	}
+	if opts.Altitude != nil {
+		body["altitude"] = *opts.Altitude
+	}
+	if opts.PPMCorrection != nil {
+		body["ppm_correction"] = *opts.PPMCorrection
+	}
+	if len(body) == 0 {
+		return errors.New(tr("persist.nothing"))
+	}
+
+	var result struct {
+		OK      bool          `json:"ok"`
+		Message string        `json:"message"`
+		Error   string        `json:"error"`
+		Path    string        `json:"path"`
+		Changes []configDelta `json:"changes"`
+	}
+	if err := postJSON(baseURL, "/api/config/persist", body, &result); err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		return printJSON(result)
+	}
+
+	if !result.OK {
+		fmt.Printf("\n  %s  %s\n\n", colorize(red, tr("common.error")), result.Error)
+		return nil
+	}
+
+	fmt.Printf("\n  %s  %s\n\n", colorize(green, tr("persist.persisted")), result.Message)
+	if len(result.Changes) == 0 {
+		fmt.Printf("  %s\n\n", colorize(dim, tr("persist.unchanged")))
+		return nil
+	}
+	printConfigDeltas("  ", result.Changes)
+	fmt.Println()
+	return nil
+}
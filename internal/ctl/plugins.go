@@ -0,0 +1,74 @@
+package ctl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Plugins lists the configured event plugins and their state.
+func Plugins(baseURL string, jsonOutput bool) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	var resp struct {
+		Plugins []struct {
+			Name      string   `json:"name"`
+			Command   []string `json:"command"`
+			Events    []string `json:"events,omitempty"`
+			Control   bool     `json:"control,omitempty"`
+			Running   bool     `json:"running"`
+			PID       int      `json:"pid,omitempty"`
+			StartedAt string   `json:"started_at,omitempty"`
+			Restarts  int      `json:"restarts"`
+			Delivered uint64   `json:"delivered"`
+			Dropped   uint64   `json:"dropped"`
+			LastError string   `json:"last_error,omitempty"`
+		} `json:"plugins"`
+	}
+	if err := getJSON(baseURL, "/api/plugins", &resp); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("plugins.title")))
+	if len(resp.Plugins) == 0 {
+		fmt.Println(colorize(dim, "  "+rule(24)))
+		fmt.Println("  " + tr("plugins.none"))
+		fmt.Println()
+		return nil
+	}
+
+	t := newTable("  ", tr("col.name"), tr("col.status"), tr("plugins.col_events"), tr("plugins.col_delivered"), tr("plugins.col_dropped"), tr("plugins.col_restarts"))
+	t.alignRight(3, 4, 5)
+	for _, p := range resp.Plugins {
+		status := colorize(green, tr("plugins.running", p.PID))
+		if !p.Running {
+			status = colorize(red, tr("plugins.stopped"))
+		}
+		events := tr("plugins.all_events")
+		if len(p.Events) > 0 {
+			events = strings.Join(p.Events, ",")
+		}
+		dropped := fmt.Sprint(p.Dropped)
+		if p.Dropped > 0 {
+			dropped = colorize(yellow, dropped)
+		}
+		name := p.Name
+		if p.Control {
+			name += colorize(dim, " "+tr("plugins.control"))
+		}
+		t.row(name, status, events, fmt.Sprint(p.Delivered), dropped, fmt.Sprint(p.Restarts))
+	}
+	t.flush()
+	f := newFieldList("  ")
+	for _, p := range resp.Plugins {
+		if p.LastError != "" {
+			f.add(tr("plugins.name", p.Name), colorize(dim, tr("plugins.last_error", p.LastError)))
+		}
+	}
+	f.flush()
+	fmt.Println()
+	return nil
+}
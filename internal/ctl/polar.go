@@ -0,0 +1,147 @@
+package ctl
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Size of the polar plot: the horizon circle's radius in rows, and in
+// columns, twice as many since terminal cells are about twice as tall as
+// they are wide.
+const (
+	polarRows = 10
+	polarCols = 2 * polarRows
+)
+
+// polarCell is one character of the plot and its color, if any.
+type polarCell struct {
+	ch    string
+	color string
+}
+
+// polarPlot is a sky chart seen from below: the zenith in the middle, the
+// horizon on the circle, north up and east to the right.
+type polarPlot struct {
+	cells [2*polarRows + 1][2*polarCols + 1]polarCell
+}
+
+// at returns the cell azimuth az and elevation el fall in, or false when
+// el is below the horizon.
+func (pl *polarPlot) at(az, el float64) (row, col int, ok bool) {
+	if el < 0 {
+		return 0, 0, false
+	}
+	r := (90 - math.Min(el, 90)) / 90
+	rad := az * math.Pi / 180
+	col = polarCols + int(math.Round(math.Sin(rad)*r*polarCols))
+	row = polarRows - int(math.Round(math.Cos(rad)*r*polarRows))
+	return row, col, true
+}
+
+// set draws ch at az and el.
+func (pl *polarPlot) set(az, el float64, ch, color string) {
+	if row, col, ok := pl.at(az, el); ok {
+		pl.cells[row][col] = polarCell{ch, color}
+	}
+}
+
+// ring draws the circle of elevation el, or of the elevation elAt gives
+// for each azimuth when it is not nil.
+func (pl *polarPlot) ring(el float64, elAt func(az float64) float64, ch, color string) {
+	for az := 0.0; az < 360; az += 2 {
+		e := el
+		if elAt != nil {
+			e = elAt(az)
+		}
+		pl.set(az, e, ch, color)
+	}
+}
+
+// lines returns the plot, one string per row, with its trailing space
+// trimmed.
+func (pl *polarPlot) lines() []string {
+	out := make([]string, 0, len(pl.cells))
+	for _, row := range pl.cells {
+		var b strings.Builder
+		for _, c := range row {
+			switch {
+			case c.ch == "":
+				b.WriteString(" ")
+			case c.color != "":
+				b.WriteString(colorize(c.color, c.ch))
+			default:
+				b.WriteString(c.ch)
+			}
+		}
+		out = append(out, strings.TrimRight(b.String(), " "))
+	}
+	return out
+}
+
+// maskAt interpolates a horizon mask, sorted by azimuth, at az, wrapping
+// around north as the daemon does.
+func maskAt(mask []struct {
+	Azimuth   float64 `json:"azimuth"`
+	Elevation float64 `json:"elevation"`
+}, az float64) float64 {
+	n := len(mask)
+	if n == 0 {
+		return 0
+	}
+	for i := range n {
+		lo, hi := mask[(i+n-1)%n], mask[i]
+		span := math.Mod(hi.Azimuth-lo.Azimuth+360, 360)
+		off := math.Mod(az-lo.Azimuth+360, 360)
+		if span == 0 {
+			return hi.Elevation
+		}
+		if off <= span {
+			return lo.Elevation + (hi.Elevation-lo.Elevation)*off/span
+		}
+	}
+	return mask[0].Elevation
+}
+
+// renderPolar draws the pass of track as a polar sky chart, with the
+// station's horizon mask, and a legend of its AOS, culmination and LOS.
+func renderPolar(track *passTrack) []string {
+	var pl polarPlot
+	pl.ring(0, nil, glyph("·", "."), dim)
+	pl.ring(30, nil, glyph("·", "."), dim)
+	pl.ring(60, nil, glyph("·", "."), dim)
+	if len(track.Horizon) > 0 {
+		pl.ring(0, func(az float64) float64 { return maskAt(track.Horizon, az) }, glyph("▒", "#"), yellow)
+	}
+	pl.cells[polarRows][polarCols] = polarCell{"+", dim}
+	pl.cells[0][polarCols] = polarCell{"N", bold}
+	pl.cells[2*polarRows][polarCols] = polarCell{"S", bold}
+	pl.cells[polarRows][0] = polarCell{"W", bold}
+	pl.cells[polarRows][2*polarCols] = polarCell{"E", bold}
+
+	if len(track.Points) == 0 {
+		return pl.lines()
+	}
+	peak := 0
+	for i, p := range track.Points {
+		pl.set(p.Azimuth, math.Max(p.Elevation, 0), glyph("•", "*"), green)
+		if p.Elevation > track.Points[peak].Elevation {
+			peak = i
+		}
+	}
+	first, last := track.Points[0], track.Points[len(track.Points)-1]
+	pl.set(last.Azimuth, math.Max(last.Elevation, 0), "L", bold+red)
+	pl.set(track.Points[peak].Azimuth, track.Points[peak].Elevation, "M", bold+yellow)
+	pl.set(first.Azimuth, math.Max(first.Elevation, 0), "A", bold+green)
+
+	out := pl.lines()
+	out = append(out, "",
+		fmt.Sprintf("%s %s  %s %s  %s %s",
+			colorize(bold+green, "A"), tr("polar.aos", degrees(first.Azimuth)),
+			colorize(bold+yellow, "M"), tr("polar.max", degrees(track.Points[peak].Elevation), degrees(track.Points[peak].Azimuth)),
+			colorize(bold+red, "L"), tr("polar.los", degrees(last.Azimuth))))
+	if len(track.Horizon) > 0 {
+		out = append(out, colorize(yellow, glyph("▒", "#"))+" "+tr("polar.mask"))
+	}
+	return out
+}
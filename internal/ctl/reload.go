@@ -1,6 +1,7 @@
 package ctl
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 )
@@ -22,9 +23,10 @@ func Reload(baseURL string, opts ReloadOptions) error {
 	}
 
 	var result struct {
-		OK      bool   `json:"ok"`
-		Message string `json:"message"`
-		Error   string `json:"error"`
+		OK      bool          `json:"ok"`
+		Message string        `json:"message"`
+		Error   string        `json:"error"`
+		Changes []configDelta `json:"changes"`
 	}
 	if err := postJSON(baseURL, "/api/reload", body, &result); err != nil {
 		return err
@@ -34,10 +36,44 @@ func Reload(baseURL string, opts ReloadOptions) error {
 		return printJSON(result)
 	}
 
-	if result.OK {
-		fmt.Printf("\n  %s  %s\n\n", colorize(green, "RELOADED"), result.Message)
-	} else {
-		fmt.Printf("\n  %s  %s\n\n", colorize(red, "ERROR"), result.Error)
+	if !result.OK {
+		fmt.Printf("\n  %s  %s\n\n", colorize(red, tr("common.error")), result.Error)
+		return nil
 	}
+
+	fmt.Printf("\n  %s  %s\n\n", colorize(green, tr("reload.reloaded")), result.Message)
+	if len(result.Changes) == 0 {
+		fmt.Printf("  %s\n\n", colorize(dim, tr("reload.unchanged")))
+		return nil
+	}
+	printConfigDeltas("  ", result.Changes)
+	fmt.Println()
 	return nil
 }
+
+// configDelta is one changed field from a reload response or
+// config_changed event.
+type configDelta struct {
+	Key string `json:"key"`
+	Old any    `json:"old"`
+	New any    `json:"new"`
+}
+
+// printConfigDeltas renders changed fields as a key / old → new table.
+func printConfigDeltas(prefix string, changes []configDelta) {
+	t := newTable(prefix, tr("reload.col_setting"), tr("reload.col_old"), "", tr("reload.col_new"))
+	for _, c := range changes {
+		t.row(c.Key, colorize(red, formatConfigValue(c.Old)), glyph("→", "->"), colorize(green, formatConfigValue(c.New)))
+	}
+	t.flush()
+}
+
+// formatConfigValue renders a config value compactly, quoting strings so
+// empty values remain visible.
+func formatConfigValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
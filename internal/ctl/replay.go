@@ -0,0 +1,111 @@
+package ctl
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReplayOptions configures the replay command. With no PassID and Stop
+// unset it shows the replay in progress.
+type ReplayOptions struct {
+	PassID string // pass history ID, as shown by `ephctl history --json`
+	Speed  float64
+	Stop   bool
+	JSON   bool
+}
+
+// replayInfo mirrors the daemon's description of a replay.
+type replayInfo struct {
+	PassID    int64     `json:"pass_id"`
+	Satellite string    `json:"satellite"`
+	Speed     float64   `json:"speed"`
+	Events    int       `json:"events"`
+	Sent      int       `json:"sent"`
+	StartedAt time.Time `json:"started_at"`
+	Duration  float64   `json:"duration_seconds"`
+}
+
+// Replay starts, stops, or shows a replay of a past pass's logged events
+// via /api/replay.
+func Replay(baseURL string, opts ReplayOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	switch {
+	case opts.Stop:
+		return replayRequest(baseURL, http.MethodDelete, "/api/replay", opts.JSON)
+	case opts.PassID != "":
+		if id, err := strconv.ParseInt(opts.PassID, 10, 64); err != nil || id <= 0 {
+			return errors.New(tr("replay.bad_id", opts.PassID))
+		}
+		params := url.Values{"pass_id": {opts.PassID}}
+		if opts.Speed > 0 {
+			params.Set("speed", strconv.FormatFloat(opts.Speed, 'f', -1, 64))
+		}
+		return replayRequest(baseURL, http.MethodPost, "/api/replay?"+params.Encode(), opts.JSON)
+	}
+
+	var resp struct {
+		Running bool        `json:"running"`
+		Replay  *replayInfo `json:"replay"`
+	}
+	if err := getJSON(baseURL, "/api/replay", &resp); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(resp)
+	}
+	fmt.Println()
+	fmt.Println(header("  " + tr("replay.title")))
+	if resp.Replay == nil {
+		fmt.Printf("  %s\n\n", colorize(dim, tr("replay.none")))
+		return nil
+	}
+	printReplay(*resp.Replay)
+	fmt.Println()
+	return nil
+}
+
+// replayRequest starts or stops a replay and reports the result.
+func replayRequest(baseURL, method, path string, jsonOutput bool) error {
+	req, err := http.NewRequest(method, baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK      bool        `json:"ok"`
+		Message string      `json:"message,omitempty"`
+		Replay  *replayInfo `json:"replay,omitempty"`
+	}
+	if err := decodeJSON(resp, &result); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(result)
+	}
+	if result.Replay == nil {
+		fmt.Printf("\n  %s  %s\n\n", colorize(green, tr("replay.stopped")), result.Message)
+		return nil
+	}
+	fmt.Printf("\n  %s\n", colorize(green, tr("replay.started")))
+	printReplay(*result.Replay)
+	fmt.Printf("  %s\n\n", colorize(dim, tr("replay.follow")))
+	return nil
+}
+
+func printReplay(r replayInfo) {
+	fmt.Printf("  %s %s %s\n", label(tr("replay.pass"), 12), strconv.FormatInt(r.PassID, 10), r.Satellite)
+	fmt.Printf("  %s %s\n", label(tr("replay.speed"), 12), tr("replay.speed_value", r.Speed))
+	fmt.Printf("  %s %s\n", label(tr("replay.events"), 12), tr("replay.events_value", r.Sent, r.Events))
+	fmt.Printf("  %s %s\n", label(tr("replay.duration"), 12), formatDuration(time.Duration(r.Duration*float64(time.Second))))
+}
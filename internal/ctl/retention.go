@@ -0,0 +1,169 @@
+package ctl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RetentionOptions configures the retention command.
+type RetentionOptions struct {
+	Run    bool // start a sweep now instead of showing the last one
+	DryRun bool // show what a sweep would prune, changing nothing
+	JSON   bool
+}
+
+// retentionReport mirrors a retention sweep's report from the daemon.
+type retentionReport struct {
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at"`
+	Action     string `json:"action"`
+	DryRun     bool   `json:"dry_run,omitempty"`
+	Pruned     []struct {
+		File   string `json:"file"`
+		Reason string `json:"reason"`
+		Bytes  int64  `json:"bytes"`
+		Error  string `json:"error,omitempty"`
+	} `json:"pruned"`
+	FreedBytes  int64    `json:"freed_bytes"`
+	TotalBytes  int64    `json:"total_bytes"`
+	Kept        int      `json:"kept"`
+	Warnings    []string `json:"warnings,omitempty"`
+	Interrupted string   `json:"interrupted,omitempty"`
+	Source      string   `json:"source"`
+}
+
+// Retention shows the retention policy and the last sweep, starts a sweep
+// via POST /api/retention/run, or previews one with --dry-run.
+func Retention(baseURL string, opts RetentionOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	if opts.DryRun {
+		var result struct {
+			OK     bool             `json:"ok"`
+			Report *retentionReport `json:"report"`
+		}
+		if err := postJSON(baseURL, "/api/retention/run?dry_run=true", nil, &result); err != nil {
+			return err
+		}
+		if opts.JSON {
+			return printJSON(result)
+		}
+		fmt.Println()
+		fmt.Println(header("  " + tr("retention.dry_run_title")))
+		fmt.Printf("  %s\n", colorize(dim, rule(40)))
+		printRetentionReport(newFieldList("  "), result.Report)
+		return nil
+	}
+
+	if opts.Run {
+		var result struct {
+			OK      bool   `json:"ok"`
+			Message string `json:"message"`
+		}
+		if err := postJSON(baseURL, "/api/retention/run", nil, &result); err != nil {
+			return err
+		}
+		if opts.JSON {
+			return printJSON(result)
+		}
+		fmt.Printf("\n  %s  %s\n", colorize(green, tr("retention.started")), result.Message)
+		fmt.Printf("  %s\n\n", colorize(dim, tr("retention.follow")))
+		return nil
+	}
+
+	var resp struct {
+		Enabled bool `json:"enabled"`
+		Policy  struct {
+			MaxAgeDays      int    `json:"max_age_days"`
+			MaxTotalBytes   int64  `json:"max_total_bytes"`
+			MinFreeBytes    int64  `json:"min_free_bytes"`
+			Action          string `json:"action"`
+			IntervalMinutes int    `json:"interval_minutes"`
+		} `json:"policy"`
+		Running bool             `json:"running"`
+		NextDue string           `json:"next_due,omitempty"`
+		Last    *retentionReport `json:"last"`
+	}
+	if err := getJSON(baseURL, "/api/retention", &resp); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("retention.title")))
+	fmt.Printf("  %s\n", colorize(dim, rule(40)))
+	f := newFieldList("  ")
+	if !resp.Enabled {
+		f.add(tr("retention.policy"), colorize(dim, tr("retention.off")))
+	} else {
+		var limits []string
+		if p := resp.Policy; p.MaxAgeDays > 0 {
+			limits = append(limits, tr("retention.max_age", p.MaxAgeDays))
+		}
+		if p := resp.Policy; p.MaxTotalBytes > 0 {
+			limits = append(limits, tr("retention.max_total", formatBytes(p.MaxTotalBytes)))
+		}
+		if p := resp.Policy; p.MinFreeBytes > 0 {
+			limits = append(limits, tr("retention.min_free", formatBytes(p.MinFreeBytes)))
+		}
+		f.add(tr("retention.policy"), tr("retention.policy_value", resp.Policy.Action, strings.Join(limits, ", ")))
+		f.add(tr("retention.schedule"), tr("retention.schedule_value", resp.Policy.IntervalMinutes, resp.NextDue))
+	}
+	if resp.Running {
+		f.add(tr("retention.status"), colorize(yellow, tr("retention.running")))
+	}
+	if resp.Last == nil {
+		f.add(tr("retention.last"), colorize(dim, tr("retention.never")))
+		f.flush()
+		fmt.Println()
+		return nil
+	}
+	f.add(tr("retention.last"), resp.Last.FinishedAt)
+	printRetentionReport(f, resp.Last)
+	return nil
+}
+
+// printRetentionReport adds what a sweep pruned and what it left to f,
+// and prints it with the pruned captures.
+func printRetentionReport(f *fieldList, rep *retentionReport) {
+	if rep == nil {
+		f.flush()
+		fmt.Println()
+		return
+	}
+	freed := formatBytes(rep.FreedBytes)
+	if rep.Interrupted != "" {
+		freed += colorize(dim, " "+tr("retention.stopped_early", rep.Interrupted))
+	}
+	f.add(tr("retention.pruned"), tr("retention.pruned_value", len(rep.Pruned), freed))
+	f.add(tr("retention.left"), tr("retention.left_value", rep.Kept, formatBytes(rep.TotalBytes)))
+	f.flush()
+	fmt.Println()
+
+	if len(rep.Pruned) > 0 {
+		t := newTable("  ", tr("col.file"), tr("col.reason"), tr("col.size"))
+		t.alignRight(2)
+		for _, p := range rep.Pruned {
+			size := formatBytes(p.Bytes)
+			if p.Error != "" {
+				size = colorize(red, tr("retention.failed"))
+			}
+			t.row(p.File, p.Reason, size)
+		}
+		t.flush()
+		for _, p := range rep.Pruned {
+			if p.Error != "" {
+				fmt.Printf("  %s %s: %s\n", colorize(red, glyph("✗", "x")), p.File, colorize(dim, p.Error))
+			}
+		}
+		fmt.Println()
+	}
+	for _, w := range rep.Warnings {
+		fmt.Printf("  %s %s\n", colorize(yellow, glyph("⚠", "!")), w)
+	}
+	if len(rep.Warnings) > 0 {
+		fmt.Println()
+	}
+}
@@ -0,0 +1,67 @@
+package ctl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rules lists the configured automation rules and how often each fired.
+func Rules(baseURL string, jsonOutput bool) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	var resp struct {
+		Rules []struct {
+			Name       string   `json:"name"`
+			When       string   `json:"when"`
+			Actions    []string `json:"actions"`
+			Active     bool     `json:"active"`
+			Hits       uint64   `json:"hits"`
+			Suppressed uint64   `json:"suppressed"`
+			LastFired  string   `json:"last_fired,omitempty"`
+			LastError  string   `json:"last_error,omitempty"`
+		} `json:"rules"`
+	}
+	if err := getJSON(baseURL, "/api/rules", &resp); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("rules.title")))
+	if len(resp.Rules) == 0 {
+		fmt.Println(colorize(dim, "  "+rule(24)))
+		fmt.Println("  " + tr("rules.none"))
+		fmt.Println()
+		return nil
+	}
+
+	t := newTable("  ", tr("col.name"), tr("rules.col_when"), tr("rules.col_actions"), tr("rules.col_hits"), tr("rules.col_last_fired"))
+	t.alignRight(3)
+	for _, r := range resp.Rules {
+		name := r.Name
+		if r.Active {
+			name = colorize(yellow, name)
+		}
+		hits := fmt.Sprint(r.Hits)
+		if r.Suppressed > 0 {
+			hits += colorize(dim, tr("rules.held", r.Suppressed))
+		}
+		last := "-"
+		if r.LastFired != "" {
+			last = formatPassTime(r.LastFired)
+		}
+		t.row(name, r.When, strings.Join(r.Actions, ","), hits, last)
+	}
+	t.flush()
+	f := newFieldList("  ")
+	for _, r := range resp.Rules {
+		if r.LastError != "" {
+			f.add(tr("rules.name", r.Name), colorize(red, tr("rules.last_error", r.LastError)))
+		}
+	}
+	f.flush()
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,159 @@
+package ctl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SatOptions configures the sat command.
+type SatOptions struct {
+	Name  string
+	Count int
+	JSON  bool
+}
+
+// Sat shows one satellite's next passes, recent captures, TLE epoch, and
+// capture success rate from GET /api/satellite.
+func Sat(baseURL string, opts SatOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if opts.Name == "" {
+		return errors.New(tr("sat.usage"))
+	}
+
+	params := url.Values{"name": {opts.Name}}
+	if opts.Count > 0 {
+		params.Set("count", strconv.Itoa(opts.Count))
+	}
+
+	var resp struct {
+		Satellite struct {
+			Name    string `json:"name"`
+			NoradID int    `json:"norad_id"`
+			FreqHz  int    `json:"freq_hz"`
+		} `json:"satellite"`
+		Passes []struct {
+			AOS       string  `json:"aos"`
+			LOS       string  `json:"los"`
+			MaxElev   float64 `json:"max_elev"`
+			Direction string  `json:"direction"`
+			DurationS int     `json:"duration_s"`
+		} `json:"passes"`
+		PassesError string `json:"passes_error"`
+		TLE         *struct {
+			Epoch string `json:"epoch"`
+			AgeS  int    `json:"age_s"`
+		} `json:"tle"`
+		Captures []struct {
+			Filename  string `json:"filename"`
+			Timestamp string `json:"timestamp"`
+			Size      int64  `json:"size"`
+		} `json:"captures"`
+		Stats struct {
+			Captures      int      `json:"captures"`
+			Failures      int      `json:"failures"`
+			SuccessRate   *float64 `json:"success_rate"`
+			LastCaptureAt string   `json:"last_capture_at"`
+		} `json:"stats"`
+	}
+
+	// Pass prediction may fetch TLEs, so allow more than the default 5s.
+	client := &http.Client{Timeout: 60 * time.Second, Transport: tokenTransport{}}
+	httpResp, err := client.Get(baseURL + "/api/satellite?" + params.Encode())
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		b, _ := io.ReadAll(httpResp.Body)
+		if json.Unmarshal(b, &e) == nil && e.Error != "" {
+			return fmt.Errorf("%s", e.Error)
+		}
+		return fmt.Errorf("HTTP %s: %s", httpResp.Status, strings.TrimSpace(string(b)))
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	s := resp.Satellite
+	fmt.Println()
+	fmt.Println(header("  " + s.Name))
+	f := newFieldList("  ")
+	f.add(tr("sat.norad"), strconv.Itoa(s.NoradID))
+	f.add(tr("pass.frequency"), tr("sat.mhz", float64(s.FreqHz)/1e6))
+
+	if resp.TLE != nil {
+		age := time.Duration(resp.TLE.AgeS) * time.Second
+		ageStr := tr("sat.age", formatDuration(age))
+		if age >= 48*time.Hour {
+			ageStr = tr("sat.age_days", int(age.Hours()/24))
+		}
+		if age > 7*24*time.Hour {
+			ageStr = colorize(yellow, ageStr)
+		}
+		f.add(tr("sat.tle_epoch"), tr("sat.tle_epoch_value", formatPassTime(resp.TLE.Epoch), ageStr))
+	} else {
+		f.add(tr("sat.tle_epoch"), colorize(yellow, tr("sat.no_tle")))
+	}
+
+	st := resp.Stats
+	rate := tr("sat.no_rate")
+	if st.SuccessRate != nil {
+		rate = tr("sat.rate", *st.SuccessRate*100)
+	}
+	f.add(tr("sat.captures"), tr("sat.captures_value", st.Captures, st.Failures, rate))
+	if st.LastCaptureAt != "" {
+		f.add(tr("sat.last_capture"), formatPassTime(st.LastCaptureAt))
+	}
+	f.flush()
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("sat.next_passes")))
+	switch {
+	case resp.PassesError != "":
+		fmt.Printf("  %s %s\n", colorize(red, tr("sat.prediction_failed")), resp.PassesError)
+	case len(resp.Passes) == 0:
+		fmt.Println(colorize(dim, "  "+tr("passes.none")))
+	default:
+		t := newTable("  ", tr("col.aos"), tr("col.los"), tr("col.elev"), tr("col.dir"), tr("col.duration"))
+		t.alignRight(2)
+		for _, p := range resp.Passes {
+			t.row(
+				formatPassTime(p.AOS),
+				formatPassTime(p.LOS),
+				degrees(p.MaxElev),
+				directionLetter(p.Direction),
+				formatDuration(time.Duration(p.DurationS)*time.Second),
+			)
+		}
+		t.flush()
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("sat.recent_captures")))
+	if len(resp.Captures) == 0 {
+		fmt.Println(colorize(dim, "  "+tr("captures.none")))
+	} else {
+		t := newTable("  ", tr("col.timestamp"), tr("col.size"), tr("col.filename"))
+		t.alignRight(1)
+		for _, c := range resp.Captures {
+			t.row(c.Timestamp, formatBytes(c.Size), c.Filename)
+		}
+		t.flush()
+	}
+	fmt.Println()
+	return nil
+}
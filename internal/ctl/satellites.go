@@ -1,38 +1,229 @@
 package ctl
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"strings"
 )
 
-// Satellites lists the NOAA satellite catalog from the daemon.
-func Satellites(baseURL string, jsonOutput bool) error {
+// SatellitesOptions configures the satellites command.
+type SatellitesOptions struct {
+	Action string // "enable", "disable", "offset", "add" or "remove"; empty lists the catalog
+	Name   string // satellite name or NORAD ID for Action
+	Value  string // offset in Hz for "offset"
+	// NoradID, FreqHz and Mode describe the satellite for "add".
+	NoradID int
+	FreqHz  int
+	Mode    string
+	JSON    bool
+}
+
+// Satellites lists the satellite catalog from the daemon, enables or
+// disables scheduling of one satellite, sets its frequency offset, or adds
+// and removes catalog entries.
+func Satellites(baseURL string, opts SatellitesOptions) error {
 	baseURL = strings.TrimRight(baseURL, "/")
 
+	switch opts.Action {
+	case "offset":
+		return setSatelliteOffset(baseURL, opts)
+	case "add":
+		return addSatellite(baseURL, opts)
+	case "remove":
+		return removeSatellite(baseURL, opts)
+	}
+	if opts.Action != "" {
+		return setSatelliteEnabled(baseURL, opts)
+	}
+
 	var resp struct {
 		Satellites []struct {
-			Name    string `json:"name"`
-			NoradID int    `json:"norad_id"`
-			FreqHz  int    `json:"freq_hz"`
+			Name     string  `json:"name"`
+			NoradID  int     `json:"norad_id"`
+			FreqHz   int     `json:"freq_hz"`
+			Enabled  bool    `json:"enabled"`
+			Offset   int     `json:"freq_offset_hz"`
+			Priority int     `json:"priority"`
+			MinElev  float64 `json:"min_elevation"`
+			Mode     string  `json:"mode"`
+			Builtin  bool    `json:"builtin"`
+			Status   string  `json:"status"`
+			// CatalogFreqHz is set when a catalog sync replaced FreqHz.
+			CatalogFreqHz int `json:"catalog_freq_hz"`
 		} `json:"satellites"`
 	}
 	if err := getJSON(baseURL, "/api/satellites", &resp); err != nil {
 		return err
 	}
 
-	if jsonOutput {
+	if opts.JSON {
 		return printJSON(resp)
 	}
 
 	fmt.Println()
-	fmt.Println(header("  SATELLITE CATALOG"))
+	fmt.Println(header("  " + tr("satellites.title")))
 
-	t := newTable("  ", "Name", "NORAD ID", "Frequency")
+	t := newTable("  ", tr("col.name"), tr("col.norad_id"), tr("col.frequency"), tr("col.offset"), tr("col.priority"), tr("col.min_elev"), tr("col.mode"), tr("col.status"))
+	t.alignRight(3, 4, 5)
+	synced := false
 	for _, s := range resp.Satellites {
-		t.row(s.Name, fmt.Sprintf("%d", s.NoradID), fmt.Sprintf("%.3f MHz", float64(s.FreqHz)/1e6))
+		status := colorize(green, tr("satellites.enabled"))
+		if !s.Enabled {
+			status = colorize(yellow, tr("satellites.disabled"))
+		}
+		offset := colorize(dim, "-")
+		if s.Offset != 0 {
+			offset = tr("satellites.hz", s.Offset)
+		}
+		priority := colorize(dim, "-")
+		if s.Priority != 0 {
+			priority = fmt.Sprintf("%+d", s.Priority)
+		}
+		if s.Status != "" && s.Status != "alive" {
+			status += colorize(yellow, " ("+s.Status+")")
+		}
+		freq := tr("pass.mhz", float64(s.FreqHz)/1e6)
+		if s.CatalogFreqHz != 0 {
+			freq += colorize(dim, "*")
+			synced = true
+		}
+		mode := strings.ToUpper(s.Mode)
+		if !s.Builtin {
+			mode += colorize(dim, " "+tr("satellites.added_tag"))
+		}
+		t.row(s.Name, fmt.Sprintf("%d", s.NoradID), freq, offset, priority, degrees(s.MinElev), mode, status)
 	}
 	t.flush()
+	if synced {
+		fmt.Printf("  %s\n", colorize(dim, tr("satellites.synced_note")))
+	}
 	fmt.Println()
 
 	return nil
 }
+
+func setSatelliteEnabled(baseURL string, opts SatellitesOptions) error {
+	if opts.Action != "enable" && opts.Action != "disable" {
+		return fmt.Errorf("unknown satellites action %q (use enable, disable, offset, add or remove)", opts.Action)
+	}
+	if opts.Name == "" {
+		return errors.New(tr("satellites.need_name", opts.Action))
+	}
+
+	var result struct {
+		OK        bool   `json:"ok"`
+		Satellite string `json:"satellite"`
+		Enabled   bool   `json:"enabled"`
+		Changed   bool   `json:"changed"`
+	}
+	if err := postJSON(baseURL, "/api/satellites/"+opts.Name+"/"+opts.Action, nil, &result); err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		return printJSON(result)
+	}
+	key := "satellites.now_enabled"
+	if !result.Enabled {
+		key = "satellites.now_disabled"
+	}
+	tag := colorize(green, tr("satellites.updated"))
+	if !result.Changed {
+		tag = colorize(dim, tr("satellites.unchanged"))
+	}
+	fmt.Printf("\n  %s  %s\n\n", tag, tr(key, result.Satellite))
+	return nil
+}
+
+func setSatelliteOffset(baseURL string, opts SatellitesOptions) error {
+	if opts.Name == "" || opts.Value == "" {
+		return errors.New(tr("satellites.need_offset"))
+	}
+	hz, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(opts.Value), "hz"))
+	if err != nil {
+		return fmt.Errorf("invalid offset %q: give it in Hz, e.g. -1200", opts.Value)
+	}
+
+	var result struct {
+		OK           bool   `json:"ok"`
+		Satellite    string `json:"satellite"`
+		FreqOffsetHz int    `json:"freq_offset_hz"`
+		FreqHz       int    `json:"freq_hz"`
+		Changed      bool   `json:"changed"`
+	}
+	body := map[string]any{"hz": hz}
+	if err := postJSON(baseURL, "/api/satellites/"+opts.Name+"/offset", body, &result); err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		return printJSON(result)
+	}
+	tag := colorize(green, tr("satellites.updated"))
+	if !result.Changed {
+		tag = colorize(dim, tr("satellites.unchanged"))
+	}
+	fmt.Printf("\n  %s  %s\n\n", tag, tr("satellites.now_offset", result.Satellite,
+		tr("pass.mhz", float64(result.FreqHz)/1e6), tr("satellites.hz", result.FreqOffsetHz)))
+	return nil
+}
+
+func addSatellite(baseURL string, opts SatellitesOptions) error {
+	if opts.Name == "" || opts.NoradID == 0 || opts.FreqHz == 0 {
+		return errors.New(tr("satellites.need_add"))
+	}
+
+	var result struct {
+		OK        bool   `json:"ok"`
+		Satellite string `json:"satellite"`
+		NoradID   int    `json:"norad_id"`
+		FreqHz    int    `json:"freq_hz"`
+		Mode      string `json:"mode"`
+	}
+	body := map[string]any{
+		"name":     opts.Name,
+		"norad_id": opts.NoradID,
+		"freq_hz":  opts.FreqHz,
+		"mode":     opts.Mode,
+	}
+	if err := postJSON(baseURL, "/api/satellites", body, &result); err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		return printJSON(result)
+	}
+	fmt.Printf("\n  %s  %s\n\n", colorize(green, tr("satellites.added")), tr("satellites.now_added",
+		result.Satellite, result.NoradID, tr("pass.mhz", float64(result.FreqHz)/1e6), strings.ToUpper(result.Mode)))
+	return nil
+}
+
+func removeSatellite(baseURL string, opts SatellitesOptions) error {
+	if opts.Name == "" {
+		return errors.New(tr("satellites.need_name", opts.Action))
+	}
+	req, err := http.NewRequest(http.MethodDelete, baseURL+"/api/satellites/"+opts.Name, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		OK        bool   `json:"ok"`
+		Satellite string `json:"satellite"`
+	}
+	if err := decodeJSON(resp, &result); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(result)
+	}
+	fmt.Printf("\n  %s  %s\n\n", colorize(green, tr("satellites.removed")), tr("satellites.now_removed", result.Satellite))
+	return nil
+}
@@ -0,0 +1,130 @@
+package ctl
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ScheduleOptions configures the schedule command.
+type ScheduleOptions struct {
+	Action string // "skip", "force" or "clear"; empty lists the schedule
+	ID     string // pass ID for Action, as listed
+	JSON   bool
+}
+
+// Schedule lists the schedule the daemon computed, with the passes it
+// dropped and why, or skips or forces one pass of it.
+func Schedule(baseURL string, opts ScheduleOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	if opts.Action != "" {
+		return overridePass(baseURL, opts)
+	}
+
+	var resp struct {
+		ComputedAt string `json:"computed_at"`
+		Paused     bool   `json:"paused"`
+		Energy     *struct {
+			BudgetWh  float64 `json:"budget_wh"`
+			SpentWh   float64 `json:"spent_wh"`
+			PlannedWh float64 `json:"planned_wh"`
+		} `json:"energy"`
+		Passes []struct {
+			ID        string  `json:"id"`
+			Satellite string  `json:"satellite"`
+			NoradID   int     `json:"norad_id"`
+			AOS       string  `json:"aos"`
+			LOS       string  `json:"los"`
+			MaxElev   float64 `json:"max_elev"`
+			Quality   int     `json:"quality"`
+			Status    string  `json:"status"`
+			Reason    string  `json:"reason"`
+			Override  string  `json:"override"`
+			EnergyWh  float64 `json:"energy_wh"`
+		} `json:"passes"`
+	}
+	if err := getJSON(baseURL, "/api/schedule", &resp); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("schedule.title")))
+	f := newFieldList("  ")
+	if resp.ComputedAt != "" {
+		f.add(tr("schedule.computed"), formatPassTime(resp.ComputedAt))
+	}
+	if resp.Paused {
+		f.add(tr("schedule.scheduler"), colorize(yellow, tr("schedule.paused")))
+	}
+	if e := resp.Energy; e != nil {
+		f.add(tr("schedule.energy"), tr("schedule.energy_detail", e.SpentWh, e.PlannedWh, e.BudgetWh))
+	}
+	f.flush()
+
+	if len(resp.Passes) == 0 {
+		fmt.Println(colorize(dim, "  "+rule(24)))
+		fmt.Println("  " + tr("schedule.none"))
+		fmt.Println()
+		return nil
+	}
+	fmt.Println()
+	cols := []string{tr("col.id"), tr("col.satellite"), tr("col.aos"), tr("col.elev"), tr("col.quality")}
+	right := []int{3, 4}
+	if resp.Energy != nil {
+		cols = append(cols, tr("col.energy"))
+		right = append(right, 5)
+	}
+	t := newTable("  ", append(cols, tr("col.status"))...)
+	t.alignRight(right...)
+	for _, p := range resp.Passes {
+		status := colorize(green, tr("schedule.scheduled"))
+		if p.Status != "scheduled" {
+			status = colorize(dim, tr("schedule.dropped", p.Reason))
+		}
+		switch p.Override {
+		case "force":
+			status += "  " + colorize(cyan, tr("schedule.forced"))
+		case "skip":
+			status += "  " + colorize(yellow, tr("schedule.skipped"))
+		}
+		cells := []string{p.ID, p.Satellite, formatPassTime(p.AOS), degrees(p.MaxElev), fmt.Sprintf("%d", p.Quality)}
+		if resp.Energy != nil {
+			cells = append(cells, tr("schedule.wh", p.EnergyWh))
+		}
+		t.row(append(cells, status)...)
+	}
+	t.flush()
+	fmt.Println()
+	fmt.Println("  " + colorize(dim, tr("schedule.hint")))
+	fmt.Println()
+	return nil
+}
+
+// overridePass skips or forces the pass opts.ID, or clears that again.
+func overridePass(baseURL string, opts ScheduleOptions) error {
+	switch opts.Action {
+	case "skip", "force", "clear":
+	default:
+		return fmt.Errorf("unknown schedule action %q; use skip, force or clear", opts.Action)
+	}
+	if opts.ID == "" {
+		return fmt.Errorf("usage: ephctl schedule %s PASS_ID", opts.Action)
+	}
+	var result struct {
+		OK      bool   `json:"ok"`
+		Message string `json:"message"`
+	}
+	path := "/api/schedule/" + url.PathEscape(opts.ID) + "/" + opts.Action
+	if err := postJSON(baseURL, path, nil, &result); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(result)
+	}
+	fmt.Printf("\n  %s  %s\n\n", colorize(green, tr("schedule.override_"+opts.Action)), result.Message)
+	return nil
+}
@@ -0,0 +1,71 @@
+package ctl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Scripts lists the configured Starlark scripts and how often each ran.
+func Scripts(baseURL string, jsonOutput bool) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	var resp struct {
+		Scripts []struct {
+			Name      string   `json:"name"`
+			Path      string   `json:"path"`
+			Events    []string `json:"events,omitempty"`
+			Loaded    bool     `json:"loaded"`
+			Runs      uint64   `json:"runs"`
+			Failures  uint64   `json:"failures"`
+			LastRun   string   `json:"last_run,omitempty"`
+			LastError string   `json:"last_error,omitempty"`
+		} `json:"scripts"`
+	}
+	if err := getJSON(baseURL, "/api/scripts", &resp); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("scripts.title")))
+	if len(resp.Scripts) == 0 {
+		fmt.Println(colorize(dim, "  "+rule(24)))
+		fmt.Println("  " + tr("scripts.none"))
+		fmt.Println()
+		return nil
+	}
+
+	t := newTable("  ", tr("col.name"), tr("col.status"), tr("scripts.col_events"), tr("scripts.col_runs"), tr("scripts.col_failures"), tr("scripts.col_last_run"))
+	t.alignRight(3, 4)
+	for _, s := range resp.Scripts {
+		status := colorize(green, tr("scripts.loaded"))
+		if !s.Loaded {
+			status = colorize(red, tr("scripts.not_loaded"))
+		}
+		events := tr("scripts.all_events")
+		if len(s.Events) > 0 {
+			events = strings.Join(s.Events, ",")
+		}
+		failures := fmt.Sprint(s.Failures)
+		if s.Failures > 0 {
+			failures = colorize(yellow, failures)
+		}
+		last := "-"
+		if s.LastRun != "" {
+			last = formatPassTime(s.LastRun)
+		}
+		t.row(s.Name, status, events, fmt.Sprint(s.Runs), failures, last)
+	}
+	t.flush()
+	f := newFieldList("  ")
+	for _, s := range resp.Scripts {
+		if s.LastError != "" {
+			f.add(tr("scripts.name", s.Name), colorize(red, tr("scripts.last_error", s.LastError)))
+		}
+	}
+	f.flush()
+	fmt.Println()
+	return nil
+}
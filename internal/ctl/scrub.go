@@ -0,0 +1,98 @@
+package ctl
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ScrubOptions configures the scrub command.
+type ScrubOptions struct {
+	Run  bool // start a scrub now instead of showing the last one
+	JSON bool
+}
+
+// Scrub shows the integrity scrub's last result and schedule, or starts a
+// scrub via POST /api/scrub.
+func Scrub(baseURL string, opts ScrubOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	if opts.Run {
+		var result struct {
+			OK      bool   `json:"ok"`
+			Message string `json:"message"`
+		}
+		if err := postJSON(baseURL, "/api/scrub", nil, &result); err != nil {
+			return err
+		}
+		if opts.JSON {
+			return printJSON(result)
+		}
+		fmt.Printf("\n  %s  %s\n", colorize(green, tr("scrub.started")), result.Message)
+		fmt.Printf("  %s\n\n", colorize(dim, tr("scrub.follow")))
+		return nil
+	}
+
+	var resp struct {
+		IntervalHours int    `json:"interval_hours"`
+		Running       bool   `json:"running"`
+		NextDue       string `json:"next_due,omitempty"`
+		Last          *struct {
+			StartedAt   string   `json:"started_at"`
+			FinishedAt  string   `json:"finished_at"`
+			Checked     int      `json:"checked"`
+			Unverified  int      `json:"unverified"`
+			Corrupt     []string `json:"corrupt"`
+			Interrupted string   `json:"interrupted,omitempty"`
+			Source      string   `json:"source"`
+		} `json:"last"`
+	}
+	if err := getJSON(baseURL, "/api/scrub", &resp); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("scrub.title")))
+	fmt.Printf("  %s\n", colorize(dim, rule(40)))
+	f := newFieldList("  ")
+	schedule := tr("scrub.disabled")
+	if resp.IntervalHours > 0 {
+		schedule = tr("scrub.schedule_value", resp.IntervalHours, resp.NextDue)
+	}
+	f.add(tr("scrub.schedule"), schedule)
+	if resp.Running {
+		f.add(tr("scrub.status"), colorize(yellow, tr("scrub.running")))
+	}
+
+	last := resp.Last
+	if last == nil {
+		f.add(tr("scrub.last"), colorize(dim, tr("scrub.never")))
+		f.flush()
+		fmt.Println()
+		return nil
+	}
+	when := last.FinishedAt
+	if last.Interrupted != "" {
+		when += colorize(dim, " "+tr("scrub.stopped_early", last.Interrupted))
+	}
+	f.add(tr("scrub.last"), when)
+	f.add(tr("scrub.checked"), fmt.Sprint(last.Checked))
+	if last.Unverified > 0 {
+		f.add(tr("scrub.unverified"), fmt.Sprint(last.Unverified)+" "+colorize(dim, tr("scrub.no_checksum")))
+	}
+	if len(last.Corrupt) == 0 {
+		f.add(tr("scrub.corrupt"), colorize(green, tr("common.none")))
+		f.flush()
+		fmt.Println()
+		return nil
+	}
+	f.add(tr("scrub.corrupt"), colorize(red, fmt.Sprint(len(last.Corrupt))))
+	f.flush()
+	for _, name := range last.Corrupt {
+		fmt.Printf("    %s %s\n", colorize(red, glyph("✗", "x")), name)
+	}
+	fmt.Println()
+	return nil
+}
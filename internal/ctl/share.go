@@ -0,0 +1,83 @@
+package ctl
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ShareOptions configures the share command.
+type ShareOptions struct {
+	Revoke bool   // revoke every share token instead of minting one
+	Hours  int    // how long the token lasts; 0 for the daemon's default
+	Label  string // who the link is for
+	JSON   bool
+}
+
+// Share mints a time-limited read-only token for the public listener, or
+// revokes all of them.
+func Share(baseURL string, opts ShareOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	if opts.Revoke {
+		req, err := http.NewRequest(http.MethodDelete, baseURL+"/api/share", nil)
+		if err != nil {
+			return err
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		var result struct {
+			OK bool `json:"ok"`
+		}
+		if err := decodeJSON(resp, &result); err != nil {
+			return err
+		}
+		if opts.JSON {
+			return printJSON(result)
+		}
+		fmt.Printf("\n  %s  %s\n\n", colorize(green, tr("share.revoked")), tr("share.revoked_detail"))
+		return nil
+	}
+
+	body := map[string]any{"hours": opts.Hours, "label": opts.Label}
+	var resp struct {
+		OK          bool   `json:"ok"`
+		ID          string `json:"id"`
+		Label       string `json:"label,omitempty"`
+		Token       string `json:"token"`
+		Expires     string `json:"expires"`
+		ExpiresUnix int64  `json:"expires_unix"`
+		URL         string `json:"url,omitempty"`
+	}
+	if err := postJSON(baseURL, "/api/share", body, &resp); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("share.title")))
+	f := newFieldList("  ")
+	f.add(tr("share.id"), resp.ID)
+	if resp.Label != "" {
+		f.add(tr("share.label"), resp.Label)
+	}
+	f.add(tr("share.expires"), formatPassTime(resp.Expires))
+	f.add(tr("share.token"), resp.Token)
+	if resp.URL != "" {
+		f.add(tr("share.url"), resp.URL)
+	}
+	f.flush()
+	fmt.Println()
+	if resp.URL == "" {
+		fmt.Println("  " + colorize(dim, tr("share.no_public")))
+	} else {
+		fmt.Println("  " + colorize(dim, tr("share.hint")))
+	}
+	fmt.Println()
+	return nil
+}
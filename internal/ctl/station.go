@@ -0,0 +1,101 @@
+package ctl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// StationOptions configures the station command.
+type StationOptions struct {
+	Set   string // profile to activate; empty shows the profiles
+	Base  bool   // go back to the bare [station] location
+	Force bool
+	JSON  bool
+}
+
+// Station lists the configured station profiles, or switches the active
+// one via POST /api/station.
+func Station(baseURL string, opts StationOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	if opts.Set == "" && !opts.Base {
+		var resp struct {
+			Active   string   `json:"active"`
+			Profiles []string `json:"profiles"`
+			Location struct {
+				Latitude     float64 `json:"latitude"`
+				Longitude    float64 `json:"longitude"`
+				Altitude     float64 `json:"altitude"`
+				MinElevation float64 `json:"min_elevation"`
+				UseGPSD      bool    `json:"use_gpsd"`
+			} `json:"location"`
+		}
+		if err := getJSON(baseURL, "/api/station", &resp); err != nil {
+			return err
+		}
+		if opts.JSON {
+			return printJSON(resp)
+		}
+
+		fmt.Println()
+		fmt.Printf("  %s  %s\n", colorize(bold, tr("station.title")), colorize(cyan, profileName(resp.Active)))
+		fmt.Printf("  %s\n", colorize(dim, rule(40)))
+		loc := resp.Location
+		f := newFieldList("  ")
+		f.add(tr("station.position"), tr("station.position_value", loc.Latitude, loc.Longitude))
+		f.add(tr("station.altitude"), tr("station.altitude_value", loc.Altitude))
+		f.add(tr("station.min_elev"), degrees(loc.MinElevation))
+		if loc.UseGPSD {
+			f.add(tr("station.source"), tr("station.gpsd"))
+		}
+		f.flush()
+		fmt.Println()
+		if len(resp.Profiles) == 0 {
+			fmt.Printf("  %s\n\n", colorize(dim, tr("station.no_profiles")))
+			return nil
+		}
+		for _, name := range resp.Profiles {
+			marker := " "
+			if name == resp.Active {
+				marker = colorize(green, glyph("●", "*"))
+			}
+			fmt.Printf("  %s %s\n", marker, name)
+		}
+		fmt.Println()
+		return nil
+	}
+
+	if opts.Set != "" && opts.Base {
+		return errors.New(tr("station.name_or_base"))
+	}
+
+	var result struct {
+		OK      bool   `json:"ok"`
+		Active  string `json:"active"`
+		Changed bool   `json:"changed"`
+	}
+	body := map[string]any{"profile": opts.Set, "force": opts.Force}
+	if err := postJSON(baseURL, "/api/station", body, &result); err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		return printJSON(result)
+	}
+	if result.Changed {
+		fmt.Printf("\n  %s  %s\n\n", colorize(green, tr("station.switched")), tr("station.now", profileName(result.Active)))
+	} else {
+		fmt.Printf("\n  %s  %s\n\n", colorize(dim, tr("station.unchanged")), tr("station.already", profileName(result.Active)))
+	}
+	return nil
+}
+
+// profileName names a station profile for display; the bare [station]
+// location has no name.
+func profileName(name string) string {
+	if name == "" {
+		return tr("station.base")
+	}
+	return name
+}
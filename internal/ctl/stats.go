@@ -26,22 +26,23 @@ func Stats(baseURL string, jsonOutput bool) error {
 	}
 
 	fmt.Println()
-	fmt.Println(header("  CAPTURE STATISTICS"))
-	fmt.Println("  " + strings.Repeat("─", 42))
-	fmt.Printf("  Uptime:          %s\n", formatDuration(time.Duration(resp.UptimeSeconds)*time.Second))
-	fmt.Printf("  Total captures:  %d\n", resp.TotalCaptures)
-	fmt.Printf("  Total data:      %s\n", formatBytes(resp.TotalBytes))
-
+	fmt.Println(header("  " + tr("stats.title")))
+	fmt.Println("  " + rule(42))
+	f := newFieldList("  ")
+	f.add(tr("stats.uptime"), formatDuration(time.Duration(resp.UptimeSeconds)*time.Second))
+	f.add(tr("stats.total_captures"), fmt.Sprintf("%d", resp.TotalCaptures))
+	f.add(tr("stats.total_data"), formatBytes(resp.TotalBytes))
 	if resp.LastCaptureAt != "" {
-		fmt.Printf("  Last capture:    %s\n", resp.LastCaptureAt)
+		f.add(tr("stats.last_capture"), resp.LastCaptureAt)
 	} else {
-		fmt.Printf("  Last capture:    none\n")
+		f.add(tr("stats.last_capture"), tr("common.none"))
 	}
+	f.flush()
 
 	if len(resp.CapturesBySat) > 0 {
 		fmt.Println()
-		fmt.Println(header("  BY SATELLITE"))
-		t := newTable("  ", "Satellite", "Captures")
+		fmt.Println(header("  " + tr("stats.by_satellite")))
+		t := newTable("  ", tr("col.satellite"), tr("col.captures"))
 		t.alignRight(1)
 		for sat, count := range resp.CapturesBySat {
 			t.row(sat, fmt.Sprintf("%d", count))
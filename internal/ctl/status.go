@@ -4,33 +4,13 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/api"
 )
 
-// StatusResponse mirrors the JSON returned by GET /api/status.
-type StatusResponse struct {
-	Name          string `json:"name"`
-	State         string `json:"state"`
-	UptimeSeconds int64  `json:"uptime_seconds"`
-	DataRoot      string `json:"data_root"`
-	ArchiveDir    string `json:"archive_dir"`
-	Mode          string `json:"mode"`
-	DemoEnabled   bool   `json:"demo_enabled"`
-	Paused        bool   `json:"paused"`
-	CurrentPass   *struct {
-		Satellite string  `json:"satellite"`
-		NoradID   int     `json:"norad_id"`
-		FreqHz    int     `json:"freq_hz"`
-		AOS       string  `json:"aos"`
-		LOS       string  `json:"los"`
-		MaxElev   float64 `json:"max_elev"`
-		Stage     string  `json:"stage"`
-	} `json:"current_pass"`
-	Disk *struct {
-		TotalBytes     uint64 `json:"total_bytes"`
-		UsedBytes      uint64 `json:"used_bytes"`
-		AvailableBytes uint64 `json:"available_bytes"`
-	} `json:"disk"`
-}
+// StatusResponse is the JSON returned by GET /api/status, shared with the
+// daemon.
+type StatusResponse = api.StatusResponse
 
 // Status fetches the daemon status and prints a formatted summary.
 func Status(baseURL string, jsonOutput bool) error {
@@ -49,42 +29,48 @@ func Status(baseURL string, jsonOutput bool) error {
 	stateStr := colorize(stateColor(s.State), s.State)
 
 	fmt.Println()
-	fmt.Println(header("  EPHEMERIS ENGINE STATUS"))
-	fmt.Println(colorize(dim, "  "+strings.Repeat("─", 42)))
-	fmt.Printf("  %-12s %s\n", colorize(dim, "Daemon:"), s.Name)
-	fmt.Printf("  %-12s %s\n", colorize(dim, "State:"), stateStr)
-	fmt.Printf("  %-12s %s\n", colorize(dim, "Mode:"), s.Mode)
-	fmt.Printf("  %-12s %s\n", colorize(dim, "Uptime:"), uptime)
-	fmt.Printf("  %-12s %s\n", colorize(dim, "Data:"), s.DataRoot)
-	fmt.Printf("  %-12s %s\n", colorize(dim, "Archive:"), s.ArchiveDir)
-	fmt.Printf("  %-12s %s\n", colorize(dim, "Host:"), baseURL)
-
+	fmt.Println(header("  " + tr("status.title")))
+	fmt.Println(colorize(dim, "  "+rule(42)))
+	f := newFieldList("  ")
+	f.add(tr("status.daemon"), s.Name)
+	f.add(tr("status.state"), stateStr)
+	f.add(tr("status.mode"), s.Mode)
+	f.add(tr("status.uptime"), uptime)
+	f.add(tr("status.data"), s.DataRoot)
+	f.add(tr("status.archive"), s.ArchiveDir)
+	f.add(tr("status.host"), baseURL)
 	if s.Paused {
-		fmt.Printf("  %-12s %s\n", colorize(dim, "Scheduler:"), colorize(yellow, "PAUSED"))
+		f.add(tr("status.scheduler"), colorize(yellow, tr("status.paused")))
+	}
+	if s.ReadOnly {
+		f.add(tr("status.storage"), colorize(red, tr("status.read_only")))
 	}
+	f.flush()
 
 	// Current/next pass details.
 	if s.CurrentPass != nil {
 		cp := s.CurrentPass
 		fmt.Println()
-		fmt.Println(header("  CURRENT PASS"))
-		fmt.Println(colorize(dim, "  "+strings.Repeat("─", 42)))
-		fmt.Printf("  %-12s %s (NORAD %d)\n", colorize(dim, "Satellite:"), cp.Satellite, cp.NoradID)
-		fmt.Printf("  %-12s %.3f MHz\n", colorize(dim, "Frequency:"), float64(cp.FreqHz)/1e6)
-		fmt.Printf("  %-12s %s\n", colorize(dim, "AOS:"), cp.AOS)
-		fmt.Printf("  %-12s %s\n", colorize(dim, "LOS:"), cp.LOS)
-		fmt.Printf("  %-12s %.1f°\n", colorize(dim, "Max elev:"), cp.MaxElev)
-		fmt.Printf("  %-12s %s\n", colorize(dim, "Stage:"), colorize(stateColor(strings.ToUpper(cp.Stage)), cp.Stage))
+		fmt.Println(header("  " + tr("status.current_pass")))
+		fmt.Println(colorize(dim, "  "+rule(42)))
+		f.add(tr("pass.satellite"), tr("pass.satellite_norad", cp.Satellite, cp.NoradID))
+		f.add(tr("pass.frequency"), tr("pass.mhz", float64(cp.FreqHz)/1e6))
+		f.add(tr("pass.aos"), cp.AOS)
+		f.add(tr("pass.los"), cp.LOS)
+		f.add(tr("pass.max_elev"), degrees(cp.MaxElev))
+		f.add(tr("pass.stage"), colorize(stateColor(strings.ToUpper(cp.Stage)), cp.Stage))
+		f.flush()
 	}
 
 	// Disk usage.
 	if s.Disk != nil {
 		fmt.Println()
-		fmt.Println(header("  DISK USAGE"))
-		fmt.Println(colorize(dim, "  "+strings.Repeat("─", 42)))
-		fmt.Printf("  %-12s %s\n", colorize(dim, "Total:"), formatBytes(int64(s.Disk.TotalBytes)))
-		fmt.Printf("  %-12s %s\n", colorize(dim, "Used:"), formatBytes(int64(s.Disk.UsedBytes)))
-		fmt.Printf("  %-12s %s\n", colorize(dim, "Available:"), formatBytes(int64(s.Disk.AvailableBytes)))
+		fmt.Println(header("  " + tr("status.disk")))
+		fmt.Println(colorize(dim, "  "+rule(42)))
+		f.add(tr("status.disk_total"), formatBytes(int64(s.Disk.TotalBytes)))
+		f.add(tr("status.disk_used"), formatBytes(int64(s.Disk.UsedBytes)))
+		f.add(tr("status.disk_available"), formatBytes(int64(s.Disk.AvailableBytes)))
+		f.flush()
 	}
 
 	fmt.Println()
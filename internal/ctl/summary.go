@@ -0,0 +1,152 @@
+package ctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SummaryOptions configures the summary command.
+type SummaryOptions struct {
+	TZ string // as PassesOptions.TZ
+	// PNG saves the e-paper rendering to this file instead of printing.
+	PNG           string
+	Width, Height int // of the rendering; 0 for the daemon's default
+	JSON          bool
+}
+
+// Summary shows the compact station summary served to e-paper displays,
+// or saves its rendering.
+func Summary(baseURL string, opts SummaryOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+	params := url.Values{}
+	if opts.TZ != "" && opts.TZ != "station" {
+		params.Set("tz", opts.TZ)
+	}
+	path := "/api/summary"
+	if opts.PNG != "" {
+		path += ".png"
+		if opts.Width > 0 {
+			params.Set("w", strconv.Itoa(opts.Width))
+		}
+		if opts.Height > 0 {
+			params.Set("h", strconv.Itoa(opts.Height))
+		}
+	}
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	// The summary predicts passes, which may fetch TLEs, so allow as long
+	// as the passes command does.
+	client := &http.Client{Timeout: 60 * time.Second, Transport: tokenTransport{}}
+	httpResp, err := client.Get(baseURL + path)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+	body, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		if msg := strings.TrimSpace(string(body)); msg != "" {
+			return fmt.Errorf("HTTP %s: %s", httpResp.Status, msg)
+		}
+		return fmt.Errorf("HTTP %s from %s", httpResp.Status, path)
+	}
+
+	if opts.PNG != "" {
+		// Write beside the target and rename, so a display serving the
+		// file never reads half of it.
+		tmp, err := os.CreateTemp(filepath.Dir(opts.PNG), "."+filepath.Base(opts.PNG)+".*.tmp")
+		if err != nil {
+			return err
+		}
+		_, err = tmp.Write(body)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Chmod(tmp.Name(), 0o644)
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), opts.PNG)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+			return err
+		}
+		fmt.Println(tr("summary.saved", opts.PNG, len(body)))
+		return nil
+	}
+
+	var resp struct {
+		State string `json:"state"`
+		Clock struct {
+			Local  string `json:"local"`
+			Abbrev string `json:"abbrev"`
+		} `json:"clock"`
+		NextPass *struct {
+			Satellite  string  `json:"satellite"`
+			AOS        string  `json:"aos"`
+			AOSLocal   string  `json:"aos_local"`
+			MaxElev    float64 `json:"max_elev"`
+			Direction  string  `json:"direction"`
+			CountdownS int     `json:"countdown_s"`
+		} `json:"next_pass"`
+		NextPassError string `json:"next_pass_error"`
+		LastCapture   *struct {
+			Satellite string `json:"satellite"`
+			AOS       string `json:"aos"`
+			AOSLocal  string `json:"aos_local"`
+			Image     string `json:"image"`
+		} `json:"last_capture"`
+		DiskFreePercent *int `json:"disk_free_percent"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("summary.title")))
+	fmt.Println("  " + rule(42))
+	f := newFieldList("  ")
+	f.add(tr("summary.state"), colorize(stateColor(resp.State), resp.State))
+	if t, err := time.Parse(time.RFC3339, resp.Clock.Local); err == nil {
+		f.add(tr("summary.clock"), t.Format("2006-01-02 15:04 ")+resp.Clock.Abbrev)
+	}
+	switch {
+	case resp.NextPass != nil:
+		np := resp.NextPass
+		when := tr("pass.now")
+		if np.CountdownS > 0 {
+			when = tr("summary.in", formatDuration(time.Duration(np.CountdownS)*time.Second))
+		}
+		f.add(tr("summary.next"), tr("summary.next_value",
+			np.Satellite, passTime(np.AOS, np.AOSLocal, opts.TZ), when, degrees(np.MaxElev), directionLetter(np.Direction)))
+	case resp.NextPassError != "":
+		f.add(tr("summary.next"), colorize(yellow, resp.NextPassError))
+	default:
+		f.add(tr("summary.next"), tr("passes.none"))
+	}
+	if lc := resp.LastCapture; lc != nil {
+		f.add(tr("summary.last"), tr("summary.last_value", lc.Satellite, passTime(lc.AOS, lc.AOSLocal, opts.TZ), lc.Image))
+	}
+	if resp.DiskFreePercent != nil {
+		f.add(tr("summary.disk_free"), fmt.Sprintf("%d%%", *resp.DiskFreePercent))
+	}
+	f.flush()
+	fmt.Println()
+	return nil
+}
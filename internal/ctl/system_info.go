@@ -15,7 +15,9 @@ func SystemInfo(baseURL string, jsonOutput bool) error {
 		Arch         string `json:"arch"`
 		DataRoot     string `json:"data_root"`
 		ConfigDir    string `json:"config_dir"`
+		SDRBackend   string `json:"sdr_backend"`
 		SDRAvailable bool   `json:"sdr_available"`
+		SDRError     string `json:"sdr_error"`
 		Disk         *struct {
 			TotalBytes     uint64 `json:"total_bytes"`
 			UsedBytes      uint64 `json:"used_bytes"`
@@ -32,16 +34,24 @@ func SystemInfo(baseURL string, jsonOutput bool) error {
 
 	fmt.Println()
 	fmt.Println(header("  SYSTEM INFO"))
-	fmt.Println("  " + strings.Repeat("─", 50))
+	fmt.Println("  " + rule(50))
 	fmt.Printf("  Go version:  %s\n", resp.GoVersion)
 	fmt.Printf("  OS/Arch:     %s/%s\n", resp.OS, resp.Arch)
 	fmt.Printf("  Data root:   %s\n", resp.DataRoot)
 	fmt.Printf("  Config dir:  %s\n", resp.ConfigDir)
 
+	backend := resp.SDRBackend
+	if backend == "" {
+		backend = "rtl_fm" // daemons from before sdr.backend
+	}
 	if resp.SDRAvailable {
-		fmt.Printf("  SDR:         %s (rtl_fm found)\n", colorize(green, "AVAILABLE"))
+		fmt.Printf("  SDR:         %s (%s)\n", colorize(green, "AVAILABLE"), backend)
 	} else {
-		fmt.Printf("  SDR:         %s (rtl_fm not found)\n", colorize(yellow, "NOT FOUND"))
+		reason := resp.SDRError
+		if reason == "" {
+			reason = "rtl_fm not found"
+		}
+		fmt.Printf("  SDR:         %s (%s: %s)\n", colorize(yellow, "NOT FOUND"), backend, reason)
 	}
 
 	if resp.Disk != nil {
@@ -2,6 +2,9 @@ package ctl
 
 import (
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -11,14 +14,28 @@ func TLEInfo(baseURL string, jsonOutput bool) error {
 	baseURL = strings.TrimRight(baseURL, "/")
 
 	var resp struct {
-		Path      string `json:"path"`
-		Exists    bool   `json:"exists"`
-		Fresh     bool   `json:"fresh"`
-		ModTime   string `json:"mod_time"`
-		AgeS      int    `json:"age_s"`
-		Size      int64  `json:"size"`
-		SourceURL string `json:"source_url"`
-		MaxAgeH   int    `json:"max_age_hours"`
+		Path       string            `json:"path"`
+		Exists     bool              `json:"exists"`
+		Fresh      bool              `json:"fresh"`
+		ModTime    string            `json:"mod_time"`
+		AgeS       int               `json:"age_s"`
+		Size       int64             `json:"size"`
+		SourceURL  string            `json:"source_url"`
+		Sources    []string          `json:"sources"`
+		SatURLs    map[string]string `json:"satellite_urls"`
+		Satellites map[string]string `json:"satellites"`
+		MaxAgeH    int               `json:"max_age_hours"`
+		MaxEpochD  int               `json:"max_epoch_age_days"`
+		Elements   []struct {
+			NoradID    int     `json:"norad_id"`
+			Satellite  string  `json:"satellite"`
+			Epoch      string  `json:"epoch"`
+			AgeS       int     `json:"age_s"`
+			ElementSet int     `json:"element_set"`
+			MeanMotion float64 `json:"mean_motion"`
+			Source     string  `json:"source"`
+			Stale      bool    `json:"stale"`
+		} `json:"elements"`
 	}
 	if err := getJSON(baseURL, "/api/tle-info", &resp); err != nil {
 		return err
@@ -29,29 +46,103 @@ func TLEInfo(baseURL string, jsonOutput bool) error {
 	}
 
 	fmt.Println()
-	fmt.Println(header("  TLE CACHE INFO"))
-	fmt.Println("  " + strings.Repeat("─", 50))
-	fmt.Printf("  Cache file: %s\n", resp.Path)
+	fmt.Println(header("  " + tr("tle.title")))
+	fmt.Println("  " + rule(50))
+	f := newFieldList("  ")
+	f.add(tr("tle.cache_file"), resp.Path)
+
+	// Daemons that predate tle_urls report only source_url.
+	if len(resp.Sources) == 0 && resp.SourceURL != "" {
+		resp.Sources = []string{resp.SourceURL}
+	}
+	addSources := func() {
+		for i, u := range resp.Sources {
+			l := ""
+			if i == 0 {
+				l = tr("tle.source")
+			}
+			f.add(l, u)
+		}
+		for _, id := range slices.Sorted(maps.Keys(resp.SatURLs)) {
+			f.add(tr("tle.norad_source", id), resp.SatURLs[id])
+		}
+	}
+
+	source := func(src string) string {
+		if src == "cache" || src == "embedded" {
+			return colorize(yellow, tr("tle.from_"+src))
+		}
+		return src
+	}
+	// Daemons that predate elements report only where each came from.
+	printElements := func() {
+		if len(resp.Elements) == 0 {
+			return
+		}
+		t := newTable("  ", tr("col.norad_id"), tr("col.satellite"), tr("col.epoch"), tr("col.age"), tr("col.element_set"), tr("col.mean_motion"), tr("col.source"))
+		t.alignRight(0)
+		t.alignRight(4)
+		t.alignRight(5)
+		stale := 0
+		for _, el := range resp.Elements {
+			age := tr("tle.age_days", float64(el.AgeS)/86400)
+			if el.Stale {
+				age = colorize(yellow, age)
+				stale++
+			}
+			t.row(strconv.Itoa(el.NoradID), el.Satellite, formatPassTime(el.Epoch), age,
+				strconv.Itoa(el.ElementSet), fmt.Sprintf("%.8f", el.MeanMotion), source(el.Source))
+		}
+		t.flush()
+		fmt.Println()
+		if stale > 0 {
+			fmt.Println("  " + colorize(yellow, tr("tle.stale_epochs", stale, resp.MaxEpochD)))
+			fmt.Println()
+		}
+	}
 
 	if !resp.Exists {
-		fmt.Printf("  Status:     %s\n", colorize(red, "NOT FOUND"))
-		fmt.Printf("  Source:     %s\n", resp.SourceURL)
+		f.add(tr("tle.status"), colorize(red, tr("tle.not_found")))
+		addSources()
+		f.flush()
 		fmt.Println()
+		printElements()
 		return nil
 	}
 
 	if resp.Fresh {
-		fmt.Printf("  Status:     %s\n", colorize(green, "FRESH"))
+		f.add(tr("tle.status"), colorize(green, tr("tle.fresh")))
 	} else {
-		fmt.Printf("  Status:     %s\n", colorize(yellow, "STALE"))
+		f.add(tr("tle.status"), colorize(yellow, tr("tle.stale")))
 	}
 
 	age := time.Duration(resp.AgeS) * time.Second
-	fmt.Printf("  Age:        %s\n", formatDuration(age))
-	fmt.Printf("  Last fetch: %s\n", resp.ModTime)
-	fmt.Printf("  Max age:    %dh\n", resp.MaxAgeH)
-	fmt.Printf("  Size:       %s\n", formatBytes(resp.Size))
-	fmt.Printf("  Source:     %s\n", resp.SourceURL)
+	f.add(tr("tle.age"), formatDuration(age))
+	f.add(tr("tle.last_fetch"), resp.ModTime)
+	f.add(tr("tle.max_age"), tr("tle.hours", resp.MaxAgeH))
+	if resp.MaxEpochD > 0 {
+		f.add(tr("tle.max_epoch_age"), tr("tle.days", resp.MaxEpochD))
+	}
+	f.add(tr("tle.size"), formatBytes(resp.Size))
+	addSources()
+	f.flush()
 	fmt.Println()
+
+	if len(resp.Elements) > 0 {
+		printElements()
+	} else if len(resp.Satellites) > 0 {
+		ids := slices.SortedFunc(maps.Keys(resp.Satellites), func(a, b string) int {
+			x, _ := strconv.Atoi(a)
+			y, _ := strconv.Atoi(b)
+			return x - y
+		})
+		t := newTable("  ", tr("col.norad_id"), tr("col.source"))
+		t.alignRight(0)
+		for _, id := range ids {
+			t.row(id, source(resp.Satellites[id]))
+		}
+		t.flush()
+		fmt.Println()
+	}
 	return nil
 }
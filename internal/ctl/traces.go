@@ -0,0 +1,107 @@
+package ctl
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TracesOptions configures the traces command.
+type TracesOptions struct {
+	Limit int
+	JSON  bool
+}
+
+// traceSpan is one stage within a trace returned by GET /api/traces.
+type traceSpan struct {
+	Name       string         `json:"name"`
+	ParentID   string         `json:"parent_id"`
+	OffsetMS   int64          `json:"offset_ms"`
+	DurationMS int64          `json:"duration_ms"`
+	Attributes map[string]any `json:"attributes"`
+	Error      string         `json:"error"`
+}
+
+// Traces shows recent pass pipeline traces with per-stage timings.
+func Traces(baseURL string, opts TracesOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	path := "/api/traces"
+	if opts.Limit > 0 {
+		path += fmt.Sprintf("?limit=%d", opts.Limit)
+	}
+
+	var resp struct {
+		Enabled bool `json:"enabled"`
+		Traces  []struct {
+			TraceID    string      `json:"trace_id"`
+			Name       string      `json:"name"`
+			Start      string      `json:"start"`
+			DurationMS int64       `json:"duration_ms"`
+			Error      string      `json:"error"`
+			Spans      []traceSpan `json:"spans"`
+		} `json:"traces"`
+	}
+	if err := getJSON(baseURL, path, &resp); err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  PIPELINE TRACES"))
+
+	if !resp.Enabled {
+		fmt.Println(colorize(dim, "  "+rule(24)))
+		fmt.Println("  Tracing is disabled. Set [tracing] enabled = true in the config.")
+		fmt.Println()
+		return nil
+	}
+	if len(resp.Traces) == 0 {
+		fmt.Println(colorize(dim, "  "+rule(24)))
+		fmt.Println("  No traces recorded yet.")
+		fmt.Println()
+		return nil
+	}
+
+	for _, tr := range resp.Traces {
+		title := tr.Name
+		for _, s := range tr.Spans {
+			if sat, ok := s.Attributes["satellite"].(string); ok && s.ParentID == "" {
+				title += " " + sat
+			}
+		}
+		fmt.Println()
+		fmt.Printf("  %s  %s  %s\n",
+			colorize(bold, title),
+			formatPassTime(tr.Start),
+			formatDuration(time.Duration(tr.DurationMS)*time.Millisecond),
+		)
+		if tr.Error != "" {
+			fmt.Printf("  %s %s\n", colorize(red, "error:"), tr.Error)
+		}
+
+		if len(tr.Spans) < 2 {
+			continue
+		}
+		t := newTable("    ", "Stage", "Offset", "Duration", "Error")
+		t.alignRight(1, 2)
+		for _, s := range tr.Spans {
+			if s.ParentID == "" {
+				continue
+			}
+			t.row(
+				s.Name,
+				formatDuration(time.Duration(s.OffsetMS)*time.Millisecond),
+				formatDuration(time.Duration(s.DurationMS)*time.Millisecond),
+				s.Error,
+			)
+		}
+		t.flush()
+	}
+
+	fmt.Println()
+	return nil
+}
@@ -1,6 +1,7 @@
 package ctl
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -23,16 +24,17 @@ func Trigger(baseURL string, opts TriggerOptions) error {
 	} else if opts.Satellite != "" {
 		body["satellite"] = opts.Satellite
 	} else {
-		return fmt.Errorf("satellite name or --norad-id required")
+		return errors.New(tr("trigger.need_satellite"))
 	}
 	if opts.DurationSeconds > 0 {
 		body["duration_seconds"] = opts.DurationSeconds
 	}
 
 	var resp struct {
-		OK      bool   `json:"ok"`
-		Message string `json:"message"`
-		Error   string `json:"error"`
+		OK         bool   `json:"ok"`
+		Message    string `json:"message"`
+		Error      string `json:"error"`
+		Resolution string `json:"resolution"`
 	}
 	if err := postJSON(baseURL, "/api/trigger", body, &resp); err != nil {
 		return err
@@ -44,9 +46,16 @@ func Trigger(baseURL string, opts TriggerOptions) error {
 
 	fmt.Println()
 	if resp.OK {
-		fmt.Printf("  %s  %s\n", colorize(green, "TRIGGERED"), resp.Message)
+		label := tr("trigger.triggered")
+		switch resp.Resolution {
+		case "merged":
+			label = tr("trigger.merged")
+		case "already_recording":
+			label = tr("trigger.already_recording")
+		}
+		fmt.Printf("  %s  %s\n", colorize(green, label), resp.Message)
 	} else {
-		fmt.Printf("  %s  %s\n", colorize(red, "FAILED"), resp.Error)
+		fmt.Printf("  %s  %s\n", colorize(red, tr("trigger.failed")), resp.Error)
 	}
 	fmt.Println()
 
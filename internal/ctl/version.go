@@ -39,15 +39,17 @@ func VersionInfo(baseURL string, jsonOutput bool) error {
 	}
 
 	fmt.Println()
-	fmt.Println(header("  EPHEMERIS VERSION"))
-	fmt.Println(colorize(dim, "  "+strings.Repeat("─", 38)))
-	fmt.Printf("  %-12s %s\n", colorize(dim, "CLI:"), Version+" ("+GoVersion+")")
+	fmt.Println(header("  " + tr("version.title")))
+	fmt.Println(colorize(dim, "  "+rule(38)))
+	f := newFieldList("  ")
+	f.add(tr("version.cli"), Version+" ("+GoVersion+")")
 	if daemonErr != nil {
-		fmt.Printf("  %-12s %s\n", colorize(dim, "Daemon:"), colorize(red, "unreachable: "+daemonErr.Error()))
+		f.add(tr("version.daemon"), colorize(red, tr("version.unreachable", daemonErr.Error())))
 	} else {
-		fmt.Printf("  %-12s %s\n", colorize(dim, "Daemon:"), daemon.Version+" ("+daemon.GoVersion+")")
-		fmt.Printf("  %-12s %s\n", colorize(dim, "Built:"), daemon.BuiltAt)
+		f.add(tr("version.daemon"), daemon.Version+" ("+daemon.GoVersion+")")
+		f.add(tr("version.built"), daemon.BuiltAt)
 	}
+	f.flush()
 	fmt.Println()
 
 	return nil
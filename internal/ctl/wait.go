@@ -0,0 +1,85 @@
+package ctl
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// WaitOptions configures the wait-for-change command.
+type WaitOptions struct {
+	State   string        // baseline state; empty means the current state
+	Pass    string        // baseline pass token; empty means the current pass
+	Timeout time.Duration // 0 uses the daemon default (30s)
+	JSON    bool
+}
+
+// WaitForChange blocks on GET /api/wait-for-change until the daemon state
+// or tracked pass changes. It returns an error on timeout so scripts can
+// rely on the exit status.
+func WaitForChange(baseURL string, opts WaitOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	q := url.Values{}
+	if opts.State != "" {
+		q.Set("state", opts.State)
+	}
+	if opts.Pass != "" {
+		q.Set("pass", opts.Pass)
+	}
+	wait := 30 * time.Second
+	if opts.Timeout > 0 {
+		wait = opts.Timeout
+		q.Set("timeout", opts.Timeout.String())
+	}
+	path := "/api/wait-for-change"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+
+	// The request is held open for up to the wait timeout.
+	client := &http.Client{Timeout: wait + 10*time.Second, Transport: tokenTransport{}}
+	resp, err := client.Get(baseURL + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Changed     bool   `json:"changed"`
+		State       string `json:"state"`
+		PassToken   string `json:"pass_token"`
+		CurrentPass *struct {
+			Satellite string `json:"satellite"`
+			AOS       string `json:"aos"`
+		} `json:"current_pass"`
+	}
+	if err := decodeJSON(resp, &result); err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		if err := printJSON(result); err != nil {
+			return err
+		}
+	} else {
+		fmt.Println()
+		label := colorize(green, "CHANGED")
+		if !result.Changed {
+			label = colorize(yellow, "TIMEOUT")
+		}
+		fmt.Printf("  %s  state %s", label, colorize(stateColor(result.State), result.State))
+		if p := result.CurrentPass; p != nil {
+			fmt.Printf("  %s", colorize(dim, p.Satellite+" AOS "+formatPassTime(p.AOS)))
+		}
+		fmt.Println()
+		fmt.Println()
+	}
+
+	if !result.Changed {
+		return fmt.Errorf("no change within %s", wait)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package ctl
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WakeScheduleOptions configures the wake-schedule command.
+type WakeScheduleOptions struct {
+	Format string // "", "rtcwake" or "systemd"
+	Lead   int    // seconds to wake before AOS; 0 for the daemon's default
+	JSON   bool
+}
+
+// WakeSchedule lists when a station that sleeps between passes must wake
+// up, as a table, an rtcwake list or a systemd timer unit.
+func WakeSchedule(baseURL string, opts WakeScheduleOptions) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	params := url.Values{}
+	if opts.Lead > 0 {
+		params.Set("lead", strconv.Itoa(opts.Lead))
+	}
+	if opts.Format != "" && !opts.JSON {
+		params.Set("format", opts.Format)
+		path := "/api/schedule/wake?" + params.Encode()
+		status, body, err := getRaw(baseURL, path)
+		if err != nil {
+			return err
+		}
+		if status != 200 {
+			return fmt.Errorf("HTTP %d from /api/schedule/wake: %s", status, strings.TrimSpace(string(body)))
+		}
+		fmt.Print(string(body))
+		return nil
+	}
+
+	path := "/api/schedule/wake"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	var resp struct {
+		ComputedAt string `json:"computed_at,omitempty"`
+		LeadS      int    `json:"lead_s"`
+		Wakes      []struct {
+			Wake       string   `json:"wake"`
+			WakeUnix   int64    `json:"wake_unix"`
+			Until      string   `json:"until"`
+			UntilUnix  int64    `json:"until_unix"`
+			Passes     []string `json:"passes"`
+			Satellites []string `json:"satellites"`
+		} `json:"wakes"`
+	}
+	if err := getJSON(baseURL, path, &resp); err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(resp)
+	}
+
+	fmt.Println()
+	fmt.Println(header("  " + tr("wake.title")))
+	if len(resp.Wakes) == 0 {
+		fmt.Printf("  %s\n\n", colorize(dim, tr("wake.none")))
+		return nil
+	}
+	fmt.Printf("  %s\n\n", colorize(dim, tr("wake.lead", resp.LeadS)))
+	t := newTable("  ", tr("col.wake"), tr("col.awake_until"), tr("col.awake"), tr("col.satellite"))
+	t.alignRight(2)
+	for _, wk := range resp.Wakes {
+		awake := time.Duration(wk.UntilUnix-wk.WakeUnix) * time.Second
+		t.row(formatPassTime(wk.Wake), formatPassTime(wk.Until), formatDuration(awake), strings.Join(wk.Satellites, ", "))
+	}
+	t.flush()
+	fmt.Println()
+	return nil
+}
+
+// ResumeHook tells the daemon the system has just resumed from sleep, so
+// it recomputes its schedule at once.
+func ResumeHook(baseURL string, jsonOutput bool) error {
+	baseURL = strings.TrimRight(baseURL, "/")
+
+	var resp struct {
+		OK      bool   `json:"ok"`
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if err := postJSON(baseURL, "/api/resumed", nil, &resp); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return printJSON(resp)
+	}
+	fmt.Printf("\n  %s  %s\n\n", colorize(green, tr("wake.resumed")), resp.Message)
+	return nil
+}
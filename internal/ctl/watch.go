@@ -2,10 +2,13 @@ package ctl
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"net/url"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
 	"syscall"
 	"time"
@@ -17,6 +20,9 @@ import (
 type WatchOptions struct {
 	Filter []string // event types to show (empty = all)
 	JSON   bool     // output raw JSON per event
+	// Sample is the fraction of debug events to receive when Filter
+	// includes "debug" (0 = all).
+	Sample float64
 }
 
 // Watch connects to the daemon's WebSocket endpoint and streams events to
@@ -35,9 +41,9 @@ func Watch(baseURL string, opts WatchOptions) error {
 	case "https":
 		u.Scheme = "wss"
 	default:
-		return fmt.Errorf("unsupported scheme: %s", u.Scheme)
+		return errors.New(tr("watch.bad_scheme", u.Scheme))
 	}
-	u.Path = "/ws"
+	u.Path = strings.TrimRight(u.Path, "/") + "/ws"
 	u.RawQuery = ""
 
 	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
@@ -46,13 +52,26 @@ func Watch(baseURL string, opts WatchOptions) error {
 	}
 	defer conn.Close()
 
+	// Ask the daemon for only the filtered types, and heartbeats. Older
+	// daemons ignore this and send everything, so the filter below stays.
+	if len(opts.Filter) > 0 {
+		types := append([]string{"heartbeat"}, opts.Filter...)
+		sub := map[string]any{"subscribe": types}
+		if opts.Sample > 0 {
+			sub["sample"] = opts.Sample
+		}
+		if err := conn.WriteJSON(sub); err != nil {
+			return err
+		}
+	}
+
 	if !opts.JSON {
 		fmt.Println()
-		fmt.Printf("  %s %s\n", colorize(green, "connected"), colorize(dim, u.String()))
+		fmt.Printf("  %s %s\n", colorize(green, tr("watch.connected")), colorize(dim, u.String()))
 		if len(opts.Filter) > 0 {
-			fmt.Printf("  %s %s\n", colorize(dim, "filter:"), colorize(dim, strings.Join(opts.Filter, ", ")))
+			fmt.Printf("  %s %s\n", colorize(dim, tr("watch.filter")), colorize(dim, strings.Join(opts.Filter, ", ")))
 		}
-		fmt.Println(colorize(dim, "  "+strings.Repeat("─", 50)))
+		fmt.Println(colorize(dim, "  "+rule(50)))
 		fmt.Println()
 	}
 
@@ -62,6 +81,7 @@ func Watch(baseURL string, opts WatchOptions) error {
 		filterSet[f] = true
 	}
 
+	var beats heartbeatTracker
 	done := make(chan struct{})
 	go func() {
 		defer close(done)
@@ -71,14 +91,23 @@ func Watch(baseURL string, opts WatchOptions) error {
 				return
 			}
 
+			var ev map[string]any
+			parsed := json.Unmarshal(msg, &ev) == nil
+
+			// Heartbeats are tracked even when filtered out, so a restart
+			// or missed events still trigger a refresh.
+			if parsed && ev["type"] == "heartbeat" {
+				note := beats.observe(ev, time.Now())
+				if !opts.JSON {
+					renderHeartbeatNote(baseURL, note)
+				}
+			}
+
 			// Apply event type filter.
-			if len(filterSet) > 0 {
-				var ev map[string]any
-				if err := json.Unmarshal(msg, &ev); err == nil {
-					evType, _ := ev["type"].(string)
-					if !filterSet[evType] {
-						continue
-					}
+			if len(filterSet) > 0 && parsed {
+				evType, _ := ev["type"].(string)
+				if !filterSet[evType] {
+					continue
 				}
 			}
 
@@ -97,7 +126,7 @@ func Watch(baseURL string, opts WatchOptions) error {
 	case <-sig:
 		if !opts.JSON {
 			fmt.Println()
-			fmt.Println(colorize(dim, "  disconnecting..."))
+			fmt.Println(colorize(dim, "  "+tr("watch.disconnecting")))
 		}
 		_ = conn.WriteControl(
 			websocket.CloseMessage,
@@ -110,6 +139,102 @@ func Watch(baseURL string, opts WatchOptions) error {
 	}
 }
 
+// driftWarning is the clock offset between daemon and client worth
+// reporting; below it, network latency dominates the estimate.
+const driftWarning = 2 * time.Second
+
+// heartbeatTracker follows the daemon's heartbeat sequence to notice
+// restarts, missed events, and clock drift.
+type heartbeatTracker struct {
+	bootID string
+	seq    int64
+	drift  time.Duration // last drift reported, 0 when in sync
+}
+
+// heartbeatNote is what one heartbeat revealed.
+type heartbeatNote struct {
+	Restarted    bool
+	Missed       int64         // heartbeats skipped since the previous one
+	Drift        time.Duration // daemon clock minus local clock
+	DriftChanged bool          // drift crossed driftWarning or moved by a second
+}
+
+// observe records a heartbeat received at the given local time. Daemons
+// too old to send seq and boot_id are never reported as restarted.
+func (t *heartbeatTracker) observe(ev map[string]any, received time.Time) heartbeatNote {
+	var note heartbeatNote
+	bootID, _ := ev["boot_id"].(string)
+	seq, _ := ev["seq"].(float64)
+
+	if t.bootID != "" && bootID != "" {
+		switch {
+		case bootID != t.bootID || int64(seq) <= t.seq:
+			note.Restarted = true
+		case int64(seq) > t.seq+1:
+			note.Missed = int64(seq) - t.seq - 1
+		}
+	}
+	t.bootID, t.seq = bootID, int64(seq)
+
+	if ms, ok := ev["server_time_ms"].(float64); ok {
+		note.Drift = time.UnixMilli(int64(ms)).Sub(received)
+		drifting := note.Drift.Abs() >= driftWarning
+		switch {
+		case drifting && (t.drift == 0 || (note.Drift-t.drift).Abs() >= time.Second):
+			t.drift, note.DriftChanged = note.Drift, true
+		case !drifting && t.drift != 0:
+			t.drift, note.DriftChanged = 0, true
+		}
+	}
+	return note
+}
+
+// renderHeartbeatNote reports a restart, missed events, or clock drift,
+// and re-reads the daemon's state after the first two since events that
+// would have announced changes may have been lost.
+func renderHeartbeatNote(baseURL string, note heartbeatNote) {
+	ts := colorize(dim, time.Now().Format("15:04:05"))
+	switch {
+	case note.Restarted:
+		fmt.Printf("  %s %s  %s\n", ts, colorize(yellow, tr("watch.resync")), tr("watch.restarted"))
+		refreshState(baseURL)
+	case note.Missed > 0:
+		fmt.Printf("  %s %s  %s\n", ts, colorize(yellow, tr("watch.resync")), tr("watch.missed", note.Missed))
+		refreshState(baseURL)
+	}
+	if note.DriftChanged {
+		if note.Drift == 0 {
+			fmt.Printf("  %s %s  %s\n", ts, colorize(green, tr("watch.clock")), tr("watch.clock_synced"))
+		} else {
+			fmt.Printf("  %s %s  %s\n", ts, colorize(yellow, tr("watch.clock")),
+				driftNote(note.Drift.Abs().Round(time.Second), note.Drift > 0))
+		}
+	}
+}
+
+// driftNote describes how far the daemon clock is from this machine's.
+func driftNote(d time.Duration, ahead bool) string {
+	if ahead {
+		return tr("watch.clock_ahead", formatDuration(d))
+	}
+	return tr("watch.clock_behind", formatDuration(d))
+}
+
+// refreshState prints the daemon's current state and tracked pass.
+func refreshState(baseURL string) {
+	var st StatusResponse
+	ts := colorize(dim, time.Now().Format("15:04:05"))
+	if err := getJSON(baseURL, "/api/status", &st); err != nil {
+		fmt.Printf("  %s %s  %s\n", ts, colorize(red, tr("watch.resync")), tr("watch.refresh_failed", err))
+		return
+	}
+	detail := st.Mode
+	if st.CurrentPass != nil {
+		detail += tr("watch.tracking", st.CurrentPass.Satellite)
+	}
+	fmt.Printf("  %s %s  %s  %s\n", ts, colorize(bold, tr("watch.state")), colorize(stateColor(st.State), st.State), colorize(dim, detail))
+}
+
 // renderEvent parses a JSON event and prints it in a human-friendly format.
 // Falls back to raw JSON for unrecognized event types.
 func renderEvent(raw []byte) {
@@ -121,6 +246,13 @@ func renderEvent(raw []byte) {
 
 	evType, _ := ev["type"].(string)
 	ts := formatEventTime(ev)
+	// Replayed events show when they first happened, marked as replays.
+	if replayed, _ := ev["replay"].(bool); replayed {
+		if orig, ok := ev["original_ts"].(string); ok {
+			ev["ts"] = orig
+		}
+		ts = glyph("↺", "R") + " " + formatEventTime(ev)
+	}
 
 	switch evType {
 	case "heartbeat":
@@ -128,11 +260,11 @@ func renderEvent(raw []byte) {
 		state, _ := ev["state"].(string)
 		uptime, _ := ev["uptime_seconds"].(float64)
 		uptimeStr := formatDuration(time.Duration(uptime) * time.Second)
-		fmt.Printf("  %s %s  %s  up %s\n",
+		fmt.Printf("  %s %s  %s  %s\n",
 			colorize(dim, ts),
-			colorize(dim, "heartbeat"),
+			colorize(dim, tr("watch.heartbeat")),
 			colorize(stateColor(state), state),
-			colorize(dim, uptimeStr),
+			colorize(dim, tr("watch.uptime", uptimeStr)),
 		)
 
 	case "state":
@@ -140,7 +272,7 @@ func renderEvent(raw []byte) {
 		to, _ := ev["to"].(string)
 		fmt.Printf("  %s %s  %s %s %s\n",
 			colorize(dim, ts),
-			colorize(bold, "STATE"),
+			colorize(bold, tr("watch.state")),
 			colorize(stateColor(from), from),
 			colorize(dim, "->"),
 			colorize(stateColor(to), to),
@@ -170,6 +302,361 @@ func renderEvent(raw []byte) {
 			colorize(dim, detail),
 		)
 
+	case "health":
+		check, _ := ev["check"].(string)
+		ok, _ := ev["ok"].(bool)
+		flapping, _ := ev["flapping"].(bool)
+		label := colorize(green, tr("common.ok"))
+		if flapping {
+			label = colorize(yellow, tr("watch.flapping"))
+		} else if !ok {
+			label = colorize(red, tr("common.fail"))
+		}
+		fmt.Printf("  %s %s  %s %s\n",
+			colorize(dim, ts),
+			colorize(bold, tr("watch.health")),
+			check,
+			label,
+		)
+
+	case "storage":
+		readOnly, _ := ev["read_only"].(bool)
+		message, _ := ev["message"].(string)
+		label := colorize(green, tr("watch.writable"))
+		if readOnly {
+			label = colorize(red, tr("watch.read_only"))
+		}
+		fmt.Printf("  %s %s  %s\n", colorize(dim, ts), label, message)
+
+	case "crash":
+		source, _ := ev["source"].(string)
+		message, _ := ev["message"].(string)
+		report, _ := ev["report"].(string)
+		fmt.Printf("  %s %s  %s: %s\n",
+			colorize(dim, ts),
+			colorize(red, tr("watch.crash")),
+			source,
+			message,
+		)
+		if report != "" {
+			fmt.Printf("           %s %s\n", colorize(dim, tr("watch.report")), report)
+		}
+
+	case "config_changed":
+		source, _ := ev["source"].(string)
+		path, _ := ev["path"].(string)
+		var changes []configDelta
+		if raw, err := json.Marshal(ev["changes"]); err == nil {
+			_ = json.Unmarshal(raw, &changes)
+		}
+		fmt.Printf("  %s %s  %s %s\n",
+			colorize(dim, ts),
+			colorize(bold, tr("watch.config")),
+			tr("watch.reloaded_from", path),
+			colorize(dim, tr("watch.config_changes", source, len(changes))),
+		)
+		for _, c := range changes {
+			fmt.Printf("           %s %s %s %s\n",
+				colorize(dim, c.Key+":"),
+				formatConfigValue(c.Old),
+				glyph("→", "->"),
+				formatConfigValue(c.New),
+			)
+		}
+
+	case "watchdog":
+		hung, _ := ev["hung"].(bool)
+		message, _ := ev["message"].(string)
+		label := colorize(green, tr("watch.recovered"))
+		if hung {
+			label = colorize(red, tr("watch.hung"))
+		}
+		fmt.Printf("  %s %s  %s  %s\n",
+			colorize(dim, ts),
+			colorize(bold, tr("watch.watchdog")),
+			label,
+			message,
+		)
+
+	case "mode_changed":
+		from, _ := ev["from"].(string)
+		to, _ := ev["to"].(string)
+		fmt.Printf("  %s %s  %s %s %s\n",
+			colorize(dim, ts),
+			colorize(bold, tr("watch.mode")),
+			from,
+			glyph("→", "->"),
+			colorize(cyan, to),
+		)
+
+	case "station_changed":
+		from, _ := ev["from"].(string)
+		to, _ := ev["to"].(string)
+		lat, _ := ev["latitude"].(float64)
+		lon, _ := ev["longitude"].(float64)
+		fmt.Printf("  %s %s  %s %s %s  %s\n",
+			colorize(dim, ts),
+			colorize(bold, tr("watch.station")),
+			profileName(from),
+			glyph("→", "->"),
+			colorize(cyan, profileName(to)),
+			colorize(dim, tr("watch.position", lat, lon)),
+		)
+
+	case "sdr_busy":
+		action, _ := ev["action"].(string)
+		until, _ := ev["retry_until"].(string)
+		var holders []string
+		if hs, ok := ev["holders"].([]any); ok {
+			for _, h := range hs {
+				if name, ok := h.(string); ok {
+					holders = append(holders, name)
+				}
+			}
+		}
+		detail := tr("watch.sdr_retrying", until)
+		if action == "killing" {
+			detail = tr("watch.sdr_killing")
+		}
+		fmt.Printf("  %s %s  %s  %s\n",
+			colorize(dim, ts),
+			colorize(yellow, tr("watch.sdr_busy")),
+			strings.Join(holders, ", "),
+			colorize(dim, detail),
+		)
+
+	case "pass_conflict":
+		sat, _ := ev["satellite"].(string)
+		aos, _ := ev["aos"].(string)
+		reason, _ := ev["reason"].(string)
+		fmt.Printf("  %s %s  %s  %s\n",
+			colorize(dim, ts),
+			colorize(yellow, tr("watch.conflict")),
+			tr("watch.skipped", sat, aos),
+			colorize(dim, reason),
+		)
+
+	case "pass_blackout":
+		sat, _ := ev["satellite"].(string)
+		aos, _ := ev["aos"].(string)
+		reason, _ := ev["reason"].(string)
+		fmt.Printf("  %s %s  %s  %s\n",
+			colorize(dim, ts),
+			colorize(yellow, tr("watch.blackout")),
+			tr("watch.skipped", sat, aos),
+			colorize(dim, reason),
+		)
+
+	case "tle_stale":
+		sat, _ := ev["satellite"].(string)
+		epoch, _ := ev["epoch"].(string)
+		days, _ := ev["age_days"].(float64)
+		source, _ := ev["source"].(string)
+		fmt.Printf("  %s %s  %s  %s\n",
+			colorize(dim, ts),
+			colorize(yellow, tr("watch.tle_stale")),
+			tr("watch.tle_stale_detail", sat, epoch, days),
+			colorize(dim, source),
+		)
+
+	case "tle_shift":
+		passes, _ := ev["passes"].([]any)
+		maxShift, _ := ev["max_aos_shift_s"].(float64)
+		fmt.Printf("  %s %s  %s\n",
+			colorize(dim, ts),
+			colorize(cyan, tr("watch.tle_shift")),
+			tr("watch.tle_shift_detail", len(passes), maxShift),
+		)
+		for _, p := range passes {
+			p, _ := p.(map[string]any)
+			sat, _ := p["satellite"].(string)
+			aos, _ := p["aos"].(string)
+			shift, _ := p["aos_shift_s"].(float64)
+			fmt.Printf("             %s  %s\n", colorize(dim, "·"), tr("watch.tle_shift_pass", sat, aos, shift))
+		}
+
+	case "satellite_changed":
+		sat, _ := ev["satellite"].(string)
+		enabled, _ := ev["enabled"].(bool)
+		status := colorize(green, tr("satellites.enabled"))
+		if !enabled {
+			status = colorize(yellow, tr("satellites.disabled"))
+		}
+		if hz, ok := ev["freq_offset_hz"].(float64); ok {
+			status = tr("watch.offset", tr("satellites.hz", int(hz)))
+		}
+		fmt.Printf("  %s %s  %s %s\n",
+			colorize(dim, ts),
+			colorize(bold, tr("watch.satellite")),
+			sat,
+			status,
+		)
+
+	case "satellite_added", "satellite_removed":
+		sat, _ := ev["satellite"].(string)
+		norad, _ := ev["norad_id"].(float64)
+		status := colorize(yellow, tr("watch.sat_removed"))
+		if evType == "satellite_added" {
+			freq, _ := ev["freq_hz"].(float64)
+			mode, _ := ev["mode"].(string)
+			status = colorize(green, tr("watch.sat_added", freq/1e6, strings.ToUpper(mode)))
+		}
+		fmt.Printf("  %s %s  %s %s\n",
+			colorize(dim, ts),
+			colorize(bold, tr("watch.satellite")),
+			tr("pass.satellite_norad", sat, int(norad)),
+			status,
+		)
+
+	case "capture_corrupt":
+		file, _ := ev["file"].(string)
+		errMsg, _ := ev["error"].(string)
+		fmt.Printf("  %s %s  %s  %s\n",
+			colorize(dim, ts),
+			colorize(red, tr("watch.corrupt")),
+			file,
+			colorize(dim, errMsg),
+		)
+
+	case "consistency":
+		issues, _ := ev["issues"].(float64)
+		repaired, _ := ev["repaired"].(float64)
+		unrepaired, _ := ev["unrepaired"].(float64)
+		captures, _ := ev["captures"].(float64)
+		label := colorize(green, tr("watch.consistency"))
+		if unrepaired > 0 {
+			label = colorize(yellow, tr("watch.consistency"))
+		}
+		fmt.Printf("  %s %s  %s\n",
+			colorize(dim, ts), label, tr("watch.consistency_detail", int(captures), int(issues), int(repaired)))
+
+	case "scrub":
+		checked, _ := ev["checked"].(float64)
+		corrupt, _ := ev["corrupt"].([]any)
+		interrupted, _ := ev["interrupted"].(string)
+		label := colorize(green, tr("watch.scrub"))
+		if len(corrupt) > 0 {
+			label = colorize(red, tr("watch.scrub"))
+		}
+		detail := tr("watch.scrub_detail", int(checked), len(corrupt))
+		if interrupted != "" {
+			detail += colorize(dim, tr("watch.stopped_early", interrupted))
+		}
+		fmt.Printf("  %s %s  %s\n", colorize(dim, ts), label, detail)
+
+	case "upload":
+		file, _ := ev["file"].(string)
+		state, _ := ev["state"].(string)
+		if state == "uploaded" {
+			url, _ := ev["url"].(string)
+			bytes, _ := ev["bytes"].(float64)
+			fmt.Printf("  %s %s  %s  %s\n",
+				colorize(dim, ts),
+				colorize(green, tr("watch.uploaded")),
+				file,
+				colorize(dim, fmt.Sprintf("%s, %s", formatBytes(int64(bytes)), url)),
+			)
+		} else {
+			errMsg, _ := ev["error"].(string)
+			attempts, _ := ev["attempts"].(float64)
+			fmt.Printf("  %s %s  %s  %s\n",
+				colorize(dim, ts),
+				colorize(red, tr("watch.upload_failed")),
+				file,
+				colorize(dim, tr("watch.upload_attempts", int(attempts), errMsg)),
+			)
+		}
+
+	case "capture_pruned":
+		file, _ := ev["file"].(string)
+		action, _ := ev["action"].(string)
+		reason, _ := ev["reason"].(string)
+		bytes, _ := ev["bytes"].(float64)
+		fmt.Printf("  %s %s  %s %s  %s\n",
+			colorize(dim, ts),
+			colorize(yellow, tr("watch.pruned")),
+			file,
+			tr("watch.pruned_"+action),
+			colorize(dim, fmt.Sprintf("%s, %s", reason, formatBytes(int64(bytes)))),
+		)
+
+	case "retention":
+		pruned, _ := ev["pruned"].(float64)
+		freed, _ := ev["freed_bytes"].(float64)
+		warnings, _ := ev["warnings"].([]any)
+		interrupted, _ := ev["interrupted"].(string)
+		label := colorize(green, tr("watch.retention"))
+		if len(warnings) > 0 {
+			label = colorize(yellow, tr("watch.retention"))
+		}
+		detail := tr("watch.retention_detail", int(pruned), formatBytes(int64(freed)))
+		if interrupted != "" {
+			detail += colorize(dim, tr("watch.stopped_early", interrupted))
+		}
+		fmt.Printf("  %s %s  %s\n", colorize(dim, ts), label, detail)
+
+	case "gallery":
+		images, _ := ev["images"].(float64)
+		days, _ := ev["days"].(float64)
+		copied, _ := ev["copied"].(float64)
+		errMsg, _ := ev["error"].(string)
+		if errMsg != "" {
+			fmt.Printf("  %s %s  %s\n", colorize(dim, ts), colorize(red, tr("watch.gallery")), tr("watch.gallery_failed", errMsg))
+			break
+		}
+		fmt.Printf("  %s %s  %s\n",
+			colorize(dim, ts),
+			colorize(green, tr("watch.gallery")),
+			tr("watch.gallery_detail", int(images), int(days), int(copied)),
+		)
+
+	case "notify":
+		backend, _ := ev["backend"].(string)
+		kind, _ := ev["kind"].(string)
+		errMsg, _ := ev["error"].(string)
+		if errMsg != "" {
+			fmt.Printf("  %s %s  %s\n", colorize(dim, ts), colorize(red, tr("watch.notify")), tr("watch.notify_failed", kind, backend, errMsg))
+			break
+		}
+		fmt.Printf("  %s %s  %s\n", colorize(dim, ts), colorize(green, tr("watch.notify")), tr("watch.notify_sent", kind, backend))
+
+	case "replay":
+		phase, _ := ev["phase"].(string)
+		passID, _ := ev["pass_id"].(float64)
+		sat, _ := ev["satellite"].(string)
+		events, _ := ev["events"].(float64)
+		detail := tr("watch.replay_detail", int(passID), sat, int(events))
+		switch phase {
+		case "started":
+			speed, _ := ev["speed"].(float64)
+			detail += tr("watch.replay_speed", speed)
+		default:
+			sent, _ := ev["sent"].(float64)
+			detail = tr("watch.replay_sent", int(passID), sat, int(sent), int(events))
+		}
+		fmt.Printf("  %s %s  %s  %s\n", colorize(dim, ts), colorize(cyan, tr("watch.replay")), tr("watch.replay_"+phase), detail)
+
+	case "catalog_synced":
+		sats, _ := ev["satellites"].(float64)
+		failed, _ := ev["failed"].(float64)
+		changed, _ := ev["changed"].([]any)
+		label := colorize(green, tr("watch.catalog"))
+		if failed > 0 {
+			label = colorize(yellow, tr("watch.catalog"))
+		}
+		detail := tr("watch.catalog_detail", int(sats-failed), len(changed))
+		if failed > 0 {
+			detail += tr("watch.catalog_failed", int(failed))
+		}
+		fmt.Printf("  %s %s  %s\n", colorize(dim, ts), label, detail)
+		for _, c := range changed {
+			m, _ := c.(map[string]any)
+			sat, _ := m["satellite"].(string)
+			freq, _ := m["freq_hz"].(float64)
+			old, _ := m["old_freq_hz"].(float64)
+			fmt.Printf("      %s %s %s\n", sat, tr("sat.mhz", freq/1e6), colorize(dim, tr("watch.catalog_was", old/1e6)))
+		}
+
 	case "pass_scheduled":
 		sat, _ := ev["satellite"].(string)
 		aos, _ := ev["aos"].(string)
@@ -182,15 +669,53 @@ func renderEvent(raw []byte) {
 		durStr := formatDuration(time.Duration(durSec) * time.Second)
 
 		fmt.Println()
-		fmt.Printf("  %s %s\n", colorize(dim, ts), header("PASS SCHEDULED"))
-		fmt.Printf("    %-14s %s\n", colorize(dim, "Satellite:"), colorize(bold, sat))
-		fmt.Printf("    %-14s %.3f MHz\n", colorize(dim, "Frequency:"), freqMHz)
-		fmt.Printf("    %-14s %s\n", colorize(dim, "AOS:"), aos)
-		fmt.Printf("    %-14s %s\n", colorize(dim, "LOS:"), los)
-		fmt.Printf("    %-14s %.1f°\n", colorize(dim, "Max elev:"), maxElev)
-		fmt.Printf("    %-14s %s\n", colorize(dim, "Duration:"), durStr)
+		fmt.Printf("  %s %s\n", colorize(dim, ts), header(tr("watch.pass_scheduled")))
+		f := newFieldList("    ")
+		f.add(tr("pass.satellite"), colorize(bold, sat))
+		f.add(tr("pass.frequency"), tr("pass.mhz", freqMHz))
+		f.add(tr("pass.aos"), aos)
+		f.add(tr("pass.los"), los)
+		f.add(tr("pass.max_elev"), degrees(maxElev))
+		f.add(tr("pass.duration"), durStr)
+		if recAOS, _ := ev["record_aos"].(string); recAOS != "" {
+			recLOS, _ := ev["record_los"].(string)
+			f.add(tr("pass.recording"), recAOS+" "+glyph("→", "->")+" "+recLOS)
+		}
+		if with, _ := ev["band_with"].([]any); len(with) > 0 {
+			names := make([]string, len(with))
+			for i, n := range with {
+				names[i] = fmt.Sprint(n)
+			}
+			f.add(tr("pass.bandwidth"), tr("pass.band_shared", strings.Join(names, ", ")))
+		}
+		if station, _ := ev["station"].(string); station != "" {
+			f.add(tr("pass.station"), station)
+		}
+		if cover, ok := ev["cloud_cover"].(float64); ok {
+			f.add(tr("pass.clouds"), tr("pass.cloud_cover", cover))
+		}
+		if flagged, _ := ev["sun_interference"].(bool); flagged {
+			sep, _ := ev["sun_separation"].(float64)
+			f.add(tr("pass.sun"), colorize(yellow, tr("pass.sun_near", degrees(sep))))
+		}
+		f.flush()
 		fmt.Println()
 
+	case "debug":
+		// Debug events carry whatever their source measured; print the
+		// fields in name order on one line.
+		source, _ := ev["source"].(string)
+		var fields []string
+		for _, k := range slices.Sorted(maps.Keys(ev)) {
+			switch k {
+			case "type", "ts", "source":
+				continue
+			}
+			v, _ := json.Marshal(ev[k])
+			fields = append(fields, colorize(dim, k+"=")+string(v))
+		}
+		fmt.Printf("  %s %s %s  %s\n", colorize(dim, ts), colorize(cyan, tr("watch.debug")), padRight(source, 9), strings.Join(fields, " "))
+
 	default:
 		// Unknown event type — dump as indented JSON so nothing is lost.
 		pretty, err := json.MarshalIndent(ev, "  ", "  ")
@@ -219,11 +744,11 @@ func formatEventTime(ev map[string]any) string {
 func formatLogLevel(level string) string {
 	switch level {
 	case "info":
-		return colorize(green, "INFO ")
+		return colorize(green, padRight(tr("watch.info"), 5))
 	case "warn":
-		return colorize(yellow, "WARN ")
+		return colorize(yellow, padRight(tr("watch.warn"), 5))
 	case "error":
-		return colorize(red, "ERROR")
+		return colorize(red, padRight(tr("common.error"), 5))
 	default:
 		return padRight(level, 5)
 	}
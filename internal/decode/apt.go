@@ -0,0 +1,346 @@
+package decode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// APT frame layout. The signal carries 4160 words a second, two lines of
+// 2080 words. Each line holds channel A then channel B, and each channel
+// is a sync pulse train, a space (minute markers), the image, and a
+// telemetry wedge.
+const (
+	carrierHz = 2400
+	wordRate  = 4160
+
+	lineWords    = 2080
+	channelWords = lineWords / 2
+	syncWords    = 39
+	spaceWords   = 47
+	imageWords   = 909
+
+	// refineWords is how far a line may move from where the previous one
+	// predicts it, to follow sample clock drift.
+	refineWords = 4
+	// minSampleRate leaves room for the 2400 Hz carrier and the words.
+	minSampleRate = 8000
+	// demodBlock is how many samples demodulate reads at a time.
+	demodBlock = 1 << 16
+	// maxSNR caps syncSNR, in dB, for a signal with no measurable noise.
+	maxSNR = 60
+)
+
+// syncA is channel A's sync pulse train in words, as +1 (white) and -1
+// (black): four words of black, seven cycles of a 1040 Hz square wave,
+// then black. Its mean is removed so correlating with it ignores the
+// signal's level.
+var syncA = func() []float64 {
+	p := make([]float64, 0, syncWords)
+	for range 4 {
+		p = append(p, -1)
+	}
+	for range 7 {
+		p = append(p, 1, 1, -1, -1)
+	}
+	for len(p) < syncWords {
+		p = append(p, -1)
+	}
+	mean := 0.0
+	for _, v := range p {
+		mean += v
+	}
+	mean /= float64(len(p))
+	for i := range p {
+		p[i] -= mean
+	}
+	return p
+}()
+
+// syncTap is one term of correlateSync: a weight on the prefix sum at an
+// offset into the pattern.
+type syncTap struct {
+	offset int
+	weight float64
+}
+
+// syncTaps is syncA rewritten for prefix sums. The pattern is a few runs of
+// equal value, and a run of value v from a to b adds v·(P[b] - P[a]), so
+// only the offsets where the value changes need a term, weighted by the
+// step.
+var syncTaps = func() []syncTap {
+	var taps []syncTap
+	prev := 0.0
+	for j, p := range syncA {
+		if p != prev {
+			taps = append(taps, syncTap{j, prev - p})
+			prev = p
+		}
+	}
+	return append(taps, syncTap{len(syncA), prev})
+}()
+
+// correlateSync returns the correlation of sig with syncA at each offset,
+// from prefix sums of sig so each takes one term per change in the pattern
+// rather than one per word.
+func correlateSync(sig []float64) []float64 {
+	prefix := make([]float64, len(sig)+1)
+	for i, v := range sig {
+		prefix[i+1] = prefix[i] + v
+	}
+	corr := make([]float64, len(sig)-syncWords)
+	// Tap by tap over a block at a time: the inner loop has no dependency
+	// from one step to the next, so it pipelines, and the block stays in
+	// cache across the taps.
+	const block = 2048
+	for from := 0; from < len(corr); from += block {
+		c := corr[from:min(from+block, len(corr))]
+		for _, t := range syncTaps {
+			axpy(c, t.weight, prefix[from+t.offset:])
+		}
+	}
+	return corr
+}
+
+// demodulate recovers the amplitude of the 2400 Hz subcarrier and returns
+// it at one value per word. Amplitude comes from each pair of samples:
+// for a sinusoid at angle phi per sample,
+//
+//	A² sin²(phi) = x[n]² + x[n-1]² - 2·x[n]·x[n-1]·cos(phi)
+//
+// and the values are averaged over each word, which also low-passes them.
+// progress is called with the fraction of the file read.
+func demodulate(ctx context.Context, w *wavReader, progress func(float64)) ([]float64, error) {
+	fs := float64(w.sampleRate)
+	if w.sampleRate < minSampleRate {
+		return nil, fmt.Errorf("sample rate %d Hz is too low for APT (need at least %d)", w.sampleRate, minSampleRate)
+	}
+	phi := 2 * math.Pi * carrierHz / fs
+	cosPhi, sinPhi := math.Cos(phi), math.Sin(phi)
+	ratio := fs / wordRate
+
+	frames := w.total / int64(w.frameSize)
+	out := make([]float64, 0, int(float64(frames)/ratio)+1)
+	buf := make([]float64, demodBlock)
+	amps := make([]float64, demodBlock)
+	var prev, sum float64
+	n, i, word := 0, 0, 0
+	// Sample i belongs to word int(i/ratio); next is where the word after
+	// the current one starts.
+	next := ratio
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		got, err := w.read(buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		progress(w.progress())
+
+		block := buf[:got]
+		if i == 0 {
+			prev, block, i = block[0], block[1:], 1
+		}
+		if len(block) == 0 {
+			continue
+		}
+		envelope(amps, block, prev, cosPhi, sinPhi)
+		prev = block[len(block)-1]
+		for _, amp := range amps[:len(block)] {
+			if float64(i) >= next {
+				if n > 0 {
+					out = append(out, sum/float64(n))
+				}
+				sum, n = 0, 0
+				word++
+				next = float64(word+1) * ratio
+			}
+			sum += amp
+			n++
+			i++
+		}
+	}
+	if n > 0 {
+		out = append(out, sum/float64(n))
+	}
+	return out, nil
+}
+
+// findLines locates the start of each line by its channel A sync pulse.
+// Tracking starts from an anchor line whose sync peak agrees with those of
+// its neighbours, so a noisy start does not throw the lock off, and runs
+// forward and back from it, letting each line move up to refineWords from
+// where the last one predicts to follow sample clock drift. synced counts
+// the lines whose sync was the strongest correlation in the line, a
+// measure of signal quality.
+func findLines(sig []float64) (starts []int, synced int, err error) {
+	if len(sig) < 2*lineWords {
+		return nil, 0, errors.New("recording is too short to decode (under a second of signal)")
+	}
+	corr := correlateSync(sig)
+
+	// The strongest correlation in each line-sized window; on a clean
+	// signal these sit one line apart.
+	peaks := make([]int, len(corr)/lineWords)
+	values := make([]float64, len(peaks))
+	for k := range peaks {
+		peaks[k] = strongest(corr, k*lineWords, (k+1)*lineWords)
+		values[k] = corr[peaks[k]]
+	}
+	anchor, agree := 0, -1
+	for k := range peaks {
+		n := 0
+		for j := max(k-3, 0); j <= k+3 && j < len(peaks); j++ {
+			d := peaks[j] - peaks[k] - (j-k)*lineWords
+			if j != k && d >= -refineWords*abs(j-k) && d <= refineWords*abs(j-k) {
+				n++
+			}
+		}
+		if n > agree || (n == agree && values[k] > values[anchor]) {
+			anchor, agree = k, n
+		}
+	}
+
+	// A refined position is trusted when its correlation is at least half
+	// the typical peak.
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	threshold := sorted[len(sorted)/2] / 2
+
+	track := func(from, step int) []int {
+		var found []int
+		for expected := from; expected >= 0 && expected+lineWords <= len(sig); {
+			pos, best := expected, math.Inf(-1)
+			for i := max(expected-refineWords, 0); i <= expected+refineWords && i < len(corr); i++ {
+				if corr[i] > best {
+					best, pos = corr[i], i
+				}
+			}
+			if threshold <= 0 || best < threshold || pos+lineWords > len(sig) {
+				pos = expected
+			}
+			found = append(found, pos)
+			expected = pos + step
+		}
+		return found
+	}
+	back := track(peaks[anchor], -lineWords)
+	for i := len(back) - 1; i > 0; i-- {
+		starts = append(starts, back[i])
+	}
+	starts = append(starts, track(peaks[anchor], lineWords)...)
+
+	for _, pos := range starts {
+		if strongest(corr, pos-channelWords, pos+channelWords) == pos {
+			synced++
+		}
+	}
+	return starts, synced, nil
+}
+
+// syncSNR estimates the signal-to-noise ratio in dB from the sync pulse
+// trains at starts, the one part of a line whose content is known: the
+// signal is the contrast between the white pulses and the black around
+// them, and the noise is how much the black runs, which should be flat,
+// vary. Words next to a change of level are left out, since demodulation
+// smears them.
+func syncSNR(sig []float64, starts []int) float64 {
+	var signal, noise float64
+	lines, n := 0, 0
+	for _, pos := range starts {
+		if pos+syncWords > len(sig) {
+			continue
+		}
+		words := sig[pos : pos+syncWords]
+		var white, black []float64
+		for j := 4; j < 32; j += 4 {
+			white = append(white, words[j], words[j+1])
+		}
+		black = append(black, words[1:3]...)
+		black = append(black, words[33:38]...)
+		mw, mb := mean(white), mean(black)
+		signal += (mw - mb) * (mw - mb)
+		lines++
+		for _, v := range black {
+			noise += (v - mb) * (v - mb)
+		}
+		n += len(black) - 1
+	}
+	if n == 0 || signal == 0 {
+		return 0
+	}
+	if noise <= 0 {
+		return maxSNR
+	}
+	return min(10*math.Log10((signal/float64(lines))/(noise/float64(n))), maxSNR)
+}
+
+func mean(v []float64) float64 {
+	sum := 0.0
+	for _, x := range v {
+		sum += x
+	}
+	return sum / float64(len(v))
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// strongest returns the index of the largest value in corr[from:to],
+// clamped to its bounds.
+func strongest(corr []float64, from, to int) int {
+	from, to = max(from, 0), min(to, len(corr))
+	best := from
+	for i := from; i < to; i++ {
+		if corr[i] > corr[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+// channel extracts one channel's image words from every line, as rows of
+// imageWords values.
+func channel(sig []float64, starts []int, offset int) [][]float64 {
+	rows := make([][]float64, len(starts))
+	for i, s := range starts {
+		from := s + offset + syncWords + spaceWords
+		rows[i] = sig[from : from+imageWords]
+	}
+	return rows
+}
+
+// levels returns the values at the low and high percentiles of rows,
+// estimated from a sample, so that a few outliers (such as noise bursts
+// at the start and end of a pass) do not flatten the contrast.
+func levels(rows [][]float64, lowPct, highPct float64) (lo, hi float64) {
+	var sample []float64
+	for i, row := range rows {
+		for j := i % 7; j < len(row); j += 7 {
+			sample = append(sample, row[j])
+		}
+	}
+	if len(sample) == 0 {
+		return 0, 1
+	}
+	sort.Float64s(sample)
+	at := func(pct float64) float64 {
+		return sample[min(int(pct/100*float64(len(sample))), len(sample)-1)]
+	}
+	lo, hi = at(lowPct), at(highPct)
+	if hi <= lo {
+		hi = lo + 1
+	}
+	return lo, hi
+}
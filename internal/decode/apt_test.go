@@ -0,0 +1,176 @@
+package decode
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const (
+	// benchSeconds of signal: a tenth of a pass, so the benchmarks stay
+	// quick while each function works on several blocks.
+	benchSeconds = 60
+	// benchRate is the default sdr.sample_rate.
+	benchRate = 48000
+	// benchOffset is where the first line starts, in words, so findLines
+	// has to look for it.
+	benchOffset = 500
+)
+
+// aptWords returns seconds of APT words, 0 for black to 1 for white, with
+// some noise: channel A's sync and a gradient, channel B's a flat gray.
+func aptWords(seconds float64) []float64 {
+	rng := rand.New(rand.NewPCG(1, 2))
+	words := make([]float64, int(seconds*wordRate))
+	for i := range words {
+		pos := (i + lineWords - benchOffset) % lineWords
+		var v float64
+		switch {
+		case pos < syncWords:
+			if syncA[pos] > 0 {
+				v = 1
+			}
+		case pos < channelWords:
+			v = float64(pos) / channelWords
+		default:
+			v = 0.5
+		}
+		words[i] = v + 0.05*rng.NormFloat64()
+	}
+	return words
+}
+
+// aptWAV writes words as the 16-bit audio of the 2400 Hz subcarrier at
+// rate and returns the file's path.
+func aptWAV(tb testing.TB, words []float64, rate int) string {
+	tb.Helper()
+	n := int(float64(len(words)) * float64(rate) / wordRate)
+	data := make([]byte, 44+2*n)
+	copy(data[0:], "RIFF")
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+	copy(data[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(data[16:], 16)
+	binary.LittleEndian.PutUint16(data[20:], 1)
+	binary.LittleEndian.PutUint16(data[22:], 1)
+	binary.LittleEndian.PutUint32(data[24:], uint32(rate))
+	binary.LittleEndian.PutUint32(data[28:], uint32(2*rate))
+	binary.LittleEndian.PutUint16(data[32:], 2)
+	binary.LittleEndian.PutUint16(data[34:], 16)
+	copy(data[36:], "data")
+	binary.LittleEndian.PutUint32(data[40:], uint32(2*n))
+	for i := range n {
+		t := float64(i) / float64(rate)
+		amp := 0.05 + 0.9*math.Min(math.Max(words[int(t*wordRate)], 0), 1)
+		s := amp * math.Sin(2*math.Pi*carrierHz*t)
+		binary.LittleEndian.PutUint16(data[44+2*i:], uint16(int16(s*30000)))
+	}
+	path := filepath.Join(tb.TempDir(), "apt.wav")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		tb.Fatal(err)
+	}
+	return path
+}
+
+// demodulateFile runs demodulate over the WAV at path.
+func demodulateFile(tb testing.TB, path string) []float64 {
+	tb.Helper()
+	w, err := openWAV(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer w.Close()
+	sig, err := demodulate(context.Background(), w, func(float64) {})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return sig
+}
+
+func TestFindLines(t *testing.T) {
+	sig := demodulateFile(t, aptWAV(t, aptWords(10), benchRate))
+	if want := 10 * wordRate; len(sig) < want-2 || len(sig) > want+2 {
+		t.Fatalf("demodulated %d words, want %d", len(sig), want)
+	}
+	starts, synced, err := findLines(sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(starts) < 19 || len(starts) > 20 {
+		t.Errorf("found %d lines, want 19 or 20", len(starts))
+	}
+	for i, pos := range starts {
+		// Demodulation averages over a word, so a line may land a word late.
+		if d := (pos - benchOffset) % lineWords; d != 0 && d != 1 {
+			t.Errorf("line %d starts at %d, want %d mod %d", i, pos, benchOffset, lineWords)
+		}
+	}
+	if synced < len(starts)-1 {
+		t.Errorf("synced %d of %d lines", synced, len(starts))
+	}
+}
+
+// TestKernels checks the kernels in use, which are the vector ones in a
+// GOEXPERIMENT=simd build, against the plain Go ones, over lengths that
+// leave every remainder.
+func TestKernels(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	phi := 2 * math.Pi * carrierHz / benchRate
+	for n := range 8 {
+		x := make([]float64, n)
+		for i := range x {
+			x[i] = rng.Float64()*2 - 1
+		}
+		got, want := make([]float64, n), make([]float64, n)
+		envelope(got, x, 0.25, math.Cos(phi), math.Sin(phi))
+		envelopeGo(want, x, 0.25, math.Cos(phi), math.Sin(phi))
+		for i := range want {
+			if math.Abs(got[i]-want[i]) > 1e-9 {
+				t.Errorf("envelope, %d samples: [%d] = %g, want %g", n, i, got[i], want[i])
+			}
+		}
+
+		copy(got, x)
+		copy(want, x)
+		axpy(got, 0.5, x)
+		axpyGo(want, 0.5, x)
+		for i := range want {
+			if math.Abs(got[i]-want[i]) > 1e-9 {
+				t.Errorf("axpy, %d values: [%d] = %g, want %g", n, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func BenchmarkDemodulate(b *testing.B) {
+	path := aptWAV(b, aptWords(benchSeconds), benchRate)
+	info, err := os.Stat(path)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(info.Size())
+	for b.Loop() {
+		demodulateFile(b, path)
+	}
+}
+
+func BenchmarkCorrelateSync(b *testing.B) {
+	sig := aptWords(benchSeconds)
+	b.SetBytes(int64(8 * len(sig)))
+	for b.Loop() {
+		correlateSync(sig)
+	}
+}
+
+func BenchmarkFindLines(b *testing.B) {
+	sig := aptWords(benchSeconds)
+	b.SetBytes(int64(8 * len(sig)))
+	for b.Loop() {
+		if _, _, err := findLines(sig); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+// Package decode turns recorded NOAA APT passes into images. It
+// demodulates the 2400 Hz AM subcarrier from a capture's WAV file, finds
+// each line by its sync pulses, and writes channels A and B as grayscale
+// PNGs, optionally followed by enhanced images: equalized channels, false
+// color, and a map overlay placed using the satellite's orbit.
+package decode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+// ImagesDir is where decoded images are written, relative to data.root.
+const ImagesDir = "images"
+
+// Options controls a decode.
+type Options struct {
+	// Rotate turns the images 180 degrees. A northbound pass scans from
+	// south to north, so its images come out upside down otherwise.
+	Rotate bool
+	// Sync fsyncs the images and the updated sidecar, as
+	// data.fsync_on_finalize does for captures.
+	Sync bool
+	// Progress, when set, is called with a percentage as decoding advances.
+	Progress func(percent int)
+	// Enhance lists extra images to make (EnhanceEqualized and so on).
+	Enhance []string
+	// Track gives the satellite's position, which the overlay needs to
+	// place the image on the ground. Without it there is no overlay.
+	Track TrackFunc
+	// Shapes is a GeoJSON file of coastlines and borders for the overlay.
+	Shapes string
+	// Stage, when set, is called as each step of the decode finishes,
+	// with its name: "demodulate", "sync", then for each image its
+	// suffix as it is made and the suffix plus " png" once encoded.
+	Stage func(name string)
+	// Discard encodes the images without writing them or touching the
+	// sidecar, for benchmarking.
+	Discard bool
+}
+
+// Result describes a decoded capture.
+type Result struct {
+	Lines  int `json:"lines"`
+	Synced int `json:"synced"` // lines whose sync pulse was found
+	// SNR is the signal-to-noise ratio of the sync pulses, in dB.
+	SNR    float64  `json:"snr_db"`
+	Images []string `json:"images"` // written files, relative to data.root
+	// Notes lists requested enhancements that were skipped, and why.
+	Notes []string `json:"notes,omitempty"`
+}
+
+// SyncPercent is the share of lines whose sync pulse was found, a rough
+// measure of how clean the signal was.
+func (r Result) SyncPercent() int {
+	if r.Lines == 0 {
+		return 0
+	}
+	return 100 * r.Synced / r.Lines
+}
+
+// goodSNR is the sync SNR, in dB, from which a pass counts as clean.
+const goodSNR = 20
+
+// Score rates the decoded image from 0 to 100: the share of lines in
+// sync, scaled down in proportion when the SNR is under goodSNR. It is
+// rough, but good for ranking passes against each other.
+func (r Result) Score() int {
+	return int(math.Round(float64(r.SyncPercent()) * min(max(r.SNR, 0)/goodSNR, 1)))
+}
+
+// Info is the decode record kept in the capture's sidecar for r.
+func (r Result) Info() *capture.DecodeInfo {
+	return &capture.DecodeInfo{
+		Status:      capture.DecodeDone,
+		Lines:       r.Lines,
+		SyncPercent: r.SyncPercent(),
+		SNR:         r.SNR,
+		Score:       r.Score(),
+		At:          time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// output is one image a decode writes.
+type output struct {
+	suffix string
+	make   func() (image.Image, error)
+}
+
+// Capture decodes the capture at capturePath into images under root's
+// ImagesDir, named after the capture with -A and -B suffixes plus one per
+// enhancement, and lists them in the capture's sidecar. Decoding again
+// replaces the images. An enhancement that cannot be made is skipped and
+// noted in the result rather than failing the decode.
+func Capture(ctx context.Context, root, capturePath string, opts Options) (Result, error) {
+	var res Result
+	progress := func(pct int) {
+		if opts.Progress != nil {
+			opts.Progress(pct)
+		}
+	}
+	stage := func(name string) {
+		if opts.Stage != nil {
+			opts.Stage(name)
+		}
+	}
+
+	m, err := capture.ReadMetadata(capturePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return res, err
+	}
+
+	w, err := openWAV(capturePath)
+	if err != nil {
+		return res, err
+	}
+	sig, err := demodulate(ctx, w, func(f float64) { progress(int(f * 80)) })
+	w.Close()
+	if err != nil {
+		return res, err
+	}
+	stage("demodulate")
+
+	starts, synced, err := findLines(sig)
+	if err != nil {
+		return res, err
+	}
+	res.Lines, res.Synced = len(starts), synced
+	res.SNR = math.Round(syncSNR(sig, starts)*10) / 10
+	progress(85)
+	stage("sync")
+
+	a := channel(sig, starts, 0)
+	b := channel(sig, starts, channelWords)
+	outputs := []output{
+		{"A", func() (image.Image, error) { return render(a), nil }},
+		{"B", func() (image.Image, error) { return render(b), nil }},
+	}
+
+	// The first line went out this long after the recording started.
+	start, err := time.Parse(time.RFC3339, m.AOS)
+	timed := err == nil
+	start = start.Add(time.Duration(float64(starts[0]) / wordRate * float64(time.Second)))
+	daylight := false
+	if timed {
+		_, sunEl := predict.SunPosition(start.Add(time.Duration(len(starts)/2)*lineDuration), m.Latitude, m.Longitude)
+		daylight = sunEl > 0
+	}
+	if contains(opts.Enhance, EnhanceEqualized) {
+		outputs = append(outputs,
+			output{"A-equalized", func() (image.Image, error) { return equalize(a), nil }},
+			output{"B-equalized", func() (image.Image, error) { return equalize(b), nil }},
+		)
+	}
+	if contains(opts.Enhance, EnhanceFalseColor) {
+		if daylight {
+			outputs = append(outputs, output{"false-color", func() (image.Image, error) { return falseColor(a, b), nil }})
+		} else {
+			res.Notes = append(res.Notes, "false color skipped: channel A carries no visible image at night")
+		}
+	}
+	if contains(opts.Enhance, EnhanceOverlay) {
+		switch {
+		case opts.Track == nil:
+			res.Notes = append(res.Notes, "overlay skipped: no orbit data for the satellite")
+		case !timed:
+			res.Notes = append(res.Notes, "overlay skipped: the capture's start time is unknown")
+		default:
+			outputs = append(outputs, output{"overlay", func() (image.Image, error) {
+				var shapes [][][2]float64
+				if opts.Shapes != "" {
+					var err error
+					if shapes, err = loadShapes(opts.Shapes); err != nil {
+						return nil, err
+					}
+				}
+				g, err := locate(opts.Track, start, len(starts))
+				if err != nil {
+					return nil, err
+				}
+				img := toRGBA(equalize(b))
+				if daylight {
+					img = falseColor(a, b)
+				}
+				hasStation := m.Latitude != 0 || m.Longitude != 0
+				overlay(img, g, shapes, m.Latitude, m.Longitude, hasStation)
+				return img, nil
+			}})
+		}
+	}
+
+	dir := filepath.Join(root, ImagesDir)
+	if !opts.Discard {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return res, err
+		}
+	}
+	base := strings.TrimSuffix(filepath.Base(capturePath), filepath.Ext(capturePath))
+	for i, out := range outputs {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		img, err := out.make()
+		if err != nil {
+			res.Notes = append(res.Notes, fmt.Sprintf("%s skipped: %v", out.suffix, err))
+			continue
+		}
+		if opts.Rotate {
+			rotate180(img)
+		}
+		stage(out.suffix)
+		if opts.Discard {
+			if err := png.Encode(io.Discard, img); err != nil {
+				return res, fmt.Errorf("%s: %w", out.suffix, err)
+			}
+			stage(out.suffix + " png")
+			continue
+		}
+		rel := filepath.Join(ImagesDir, base+"-"+out.suffix+".png")
+		if err := writePNG(filepath.Join(root, rel), img, opts.Sync); err != nil {
+			return res, fmt.Errorf("%s: %w", out.suffix, err)
+		}
+		stage(out.suffix + " png")
+		res.Images = append(res.Images, rel)
+		progress(85 + 15*(i+1)/(len(outputs)+1))
+	}
+	if opts.Discard {
+		return res, nil
+	}
+
+	for _, rel := range res.Images {
+		if !contains(m.Images, rel) {
+			m.Images = append(m.Images, rel)
+		}
+	}
+	m.Decode = res.Info()
+	if err := capture.WriteMetadata(capturePath, m, opts.Sync); err != nil {
+		return res, err
+	}
+	progress(100)
+	return res, nil
+}
+
+// render scales rows to 8-bit gray, stretching between the 1st and 99th
+// percentiles.
+func render(rows [][]float64) *image.Gray {
+	lo, hi := levels(rows, 1, 99)
+	img := image.NewGray(image.Rect(0, 0, imageWords, len(rows)))
+	for y, row := range rows {
+		for x, v := range row {
+			g := (v - lo) / (hi - lo) * 255
+			img.SetGray(x, y, color.Gray{Y: uint8(min(max(g, 0), 255))})
+		}
+	}
+	return img
+}
+
+// writePNG writes img to path through a temp file, so a reader never sees
+// a partial image.
+func writePNG(path string, img image.Image, sync bool) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := png.Encode(tmp, img); err != nil {
+		tmp.Close()
+		return err
+	}
+	if sync {
+		if err := tmp.Sync(); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+package decode
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// Enhancements, the extra images a decode can make besides the raw
+// channels. Config lists them in decode.enhancements.
+const (
+	// EnhanceEqualized histogram-equalizes each channel, bringing out
+	// detail in images that use only part of the gray range.
+	EnhanceEqualized = "equalized"
+	// EnhanceFalseColor combines the visible channel A with the infrared
+	// channel B into a color image. It needs daylight.
+	EnhanceFalseColor = "false_color"
+	// EnhanceOverlay draws a lat/lon grid, coastlines and borders, and the
+	// station onto the false color image, or onto channel B at night.
+	EnhanceOverlay = "overlay"
+)
+
+// equalizeBins is the resolution of the histogram used for equalization.
+const equalizeBins = 1024
+
+// equalize maps rows to 8-bit gray so that every level is about equally
+// common. Values are first clipped to the 0.5th-99.5th percentiles, so
+// noise at the ends of the pass does not claim levels of its own.
+func equalize(rows [][]float64) *image.Gray {
+	lo, hi := levels(rows, 0.5, 99.5)
+	bin := func(v float64) int {
+		b := int((v - lo) / (hi - lo) * equalizeBins)
+		return min(max(b, 0), equalizeBins-1)
+	}
+
+	var hist [equalizeBins]int
+	total := 0
+	for _, row := range rows {
+		for _, v := range row {
+			hist[bin(v)]++
+			total++
+		}
+	}
+	var lut [equalizeBins]uint8
+	cum := 0
+	for i, n := range hist {
+		cum += n
+		lut[i] = uint8(255 * cum / max(total, 1))
+	}
+
+	img := image.NewGray(image.Rect(0, 0, imageWords, len(rows)))
+	for y, row := range rows {
+		for x, v := range row {
+			img.Pix[y*img.Stride+x] = lut[bin(v)]
+		}
+	}
+	return img
+}
+
+// falseColor combines the visible channel a with the infrared channel b.
+// Cold, bright pixels are cloud and shown white; the rest is colored by
+// its visible brightness, dark as water and brighter as land, which is
+// roughly how the eye would see it.
+func falseColor(a, b [][]float64) *image.RGBA {
+	aLo, aHi := levels(a, 1, 99)
+	bLo, bHi := levels(b, 1, 99)
+	img := image.NewRGBA(image.Rect(0, 0, imageWords, len(a)))
+	for y := range a {
+		for x := range imageWords {
+			// APT infrared is inverted: cold cloud tops are bright.
+			vis := clamp01((a[y][x] - aLo) / (aHi - aLo))
+			cold := clamp01((b[y][x] - bLo) / (bHi - bLo))
+
+			water := [3]float64{0.05, 0.15 + 0.35*vis, 0.3 + 0.5*vis}
+			land := [3]float64{0.25 + 0.55*vis, 0.35 + 0.45*vis, 0.15 + 0.25*vis}
+			ground := mix(water, land, smoothstep(0.12, 0.25, vis))
+			cloud := 0.55 + 0.45*vis
+			c := mix(ground, [3]float64{cloud, cloud, cloud}, smoothstep(0.35, 0.75, cold)*smoothstep(0.2, 0.55, vis))
+
+			img.SetRGBA(x, y, color.RGBA{R: to8(c[0]), G: to8(c[1]), B: to8(c[2]), A: 255})
+		}
+	}
+	return img
+}
+
+// toRGBA copies a gray image into an RGBA one for drawing in color.
+func toRGBA(g *image.Gray) *image.RGBA {
+	img := image.NewRGBA(g.Rect)
+	for i, v := range g.Pix {
+		img.Pix[4*i], img.Pix[4*i+1], img.Pix[4*i+2], img.Pix[4*i+3] = v, v, v, 255
+	}
+	return img
+}
+
+// rotate180 turns img upside down in place.
+func rotate180(img image.Image) {
+	var pix []byte
+	var size int
+	switch m := img.(type) {
+	case *image.Gray:
+		pix, size = m.Pix, 1
+	case *image.RGBA:
+		pix, size = m.Pix, 4
+	default:
+		return
+	}
+	for i, j := 0, len(pix)-size; i < j; i, j = i+size, j-size {
+		for k := range size {
+			pix[i+k], pix[j+k] = pix[j+k], pix[i+k]
+		}
+	}
+}
+
+func mix(a, b [3]float64, t float64) [3]float64 {
+	return [3]float64{a[0] + (b[0]-a[0])*t, a[1] + (b[1]-a[1])*t, a[2] + (b[2]-a[2])*t}
+}
+
+func smoothstep(edge0, edge1, x float64) float64 {
+	t := clamp01((x - edge0) / (edge1 - edge0))
+	return t * t * (3 - 2*t)
+}
+
+func clamp01(v float64) float64 {
+	return math.Min(math.Max(v, 0), 1)
+}
+
+func to8(v float64) uint8 {
+	return uint8(math.Round(clamp01(v) * 255))
+}
@@ -0,0 +1,349 @@
+package decode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"os"
+	"time"
+)
+
+// Imaging geometry. The AVHRR scanner sweeps ±55.37° across the track, and
+// APT sends two lines a second.
+const (
+	scanHalfAngle = 55.37
+	earthRadiusKm = 6371.0
+	lineDuration  = 500 * time.Millisecond
+
+	gridDegrees = 10   // graticule spacing
+	cellDegrees = 0.05 // resolution coastlines are rasterized at
+)
+
+var (
+	gridColor    = color.RGBA{R: 200, G: 200, B: 200, A: 255}
+	shapeColor   = color.RGBA{R: 255, G: 220, B: 0, A: 255}
+	stationColor = color.RGBA{R: 255, G: 40, B: 40, A: 255}
+)
+
+// TrackFunc returns the sub-satellite point at t, in degrees, and the
+// satellite's altitude in km.
+type TrackFunc = func(t time.Time) (lat, lon, altKm float64, err error)
+
+// geoGrid holds the ground position of every pixel of a channel image, as
+// it comes off the satellite (before any rotation). Longitudes are stored
+// relative to centerLon so a pass across the date line stays continuous.
+type geoGrid struct {
+	w, h      int
+	lat, lon  []float64
+	centerLon float64
+}
+
+// locate works out where each pixel of an image of lines lines, whose
+// first line was sent at start, lies on the ground. The leftmost pixel of
+// each line is on the right of the satellite's direction of travel.
+func locate(track TrackFunc, start time.Time, lines int) (*geoGrid, error) {
+	g := &geoGrid{w: imageWords, h: lines, lat: make([]float64, imageWords*lines), lon: make([]float64, imageWords*lines)}
+	_, mid, _, err := track(start.Add(time.Duration(lines/2) * lineDuration))
+	if err != nil {
+		return nil, err
+	}
+	g.centerLon = mid
+
+	center := float64(imageWords-1) / 2
+	for y := range lines {
+		t := start.Add(time.Duration(y) * lineDuration)
+		lat, lon, alt, err := track(t)
+		if err != nil {
+			return nil, err
+		}
+		lat2, lon2, _, err := track(t.Add(time.Second))
+		if err != nil {
+			return nil, err
+		}
+		right := bearing(lat, lon, lat2, lon2) + 90
+		ratio := (earthRadiusKm + alt) / earthRadiusKm
+		for x := range imageWords {
+			// Positive scan angles look to the right of the track.
+			scan := (center - float64(x)) / center * scanHalfAngle * math.Pi / 180 // radians
+			zenith := math.Asin(math.Min(ratio*math.Sin(math.Abs(scan)), 1))
+			dist := math.Copysign(zenith-math.Abs(scan), scan)
+			plat, plon := destination(lat, lon, right, dist)
+			g.lat[y*imageWords+x] = plat
+			g.lon[y*imageWords+x] = wrapLon(plon - g.centerLon)
+		}
+	}
+	return g, nil
+}
+
+// overlay draws the graticule, the shapes (polylines of lon/lat points),
+// and the station onto img, which is laid out like g.
+func overlay(img *image.RGBA, g *geoGrid, shapes [][][2]float64, stationLat, stationLon float64, hasStation bool) {
+	mask := newShapeMask(g, shapes)
+
+	best, bestDist := -1, math.Inf(1)
+	cosStation := math.Cos(stationLat * math.Pi / 180)
+	for y := range g.h {
+		for x := range g.w {
+			i := y*g.w + x
+			// Compare against the next pixel on each axis, or the previous
+			// one at the edges, to find the ground this pixel covers.
+			nx, ny := i+1, i+g.w
+			if x == g.w-1 {
+				nx = i - 1
+			}
+			if y == g.h-1 {
+				ny = i - g.w
+			}
+			if ny < 0 {
+				ny = i
+			}
+			latLo := math.Min(g.lat[i], math.Min(g.lat[nx], g.lat[ny]))
+			latHi := math.Max(g.lat[i], math.Max(g.lat[nx], g.lat[ny]))
+			lonLo := math.Min(g.lon[i], math.Min(g.lon[nx], g.lon[ny]))
+			lonHi := math.Max(g.lon[i], math.Max(g.lon[nx], g.lon[ny]))
+
+			switch {
+			case mask.any(latLo, latHi, lonLo, lonHi):
+				img.SetRGBA(x, y, shapeColor)
+			case crossesGrid(latLo, latHi) || crossesGrid(wrapLon(lonLo+g.centerLon), wrapLon(lonHi+g.centerLon)):
+				img.SetRGBA(x, y, blend(img.RGBAAt(x, y), gridColor))
+			}
+
+			if hasStation {
+				dlat := g.lat[i] - stationLat
+				dlon := wrapLon(g.lon[i]+g.centerLon-stationLon) * cosStation
+				if d := dlat*dlat + dlon*dlon; d < bestDist {
+					best, bestDist = i, d
+				}
+			}
+		}
+	}
+
+	// Mark the station with a cross if it is inside the image.
+	if best >= 0 && bestDist < 0.25 {
+		sx, sy := best%g.w, best/g.w
+		for d := -6; d <= 6; d++ {
+			for w := -1; w <= 1; w++ {
+				setIn(img, sx+d, sy+w, stationColor)
+				setIn(img, sx+w, sy+d, stationColor)
+			}
+		}
+	}
+}
+
+// crossesGrid reports whether a graticule line falls between lo and hi.
+// A span wider than half the globe is the date line seen from both sides.
+func crossesGrid(lo, hi float64) bool {
+	if hi-lo > 180 {
+		return true
+	}
+	return math.Floor(lo/gridDegrees) != math.Floor(hi/gridDegrees)
+}
+
+// shapeMask is a raster of the shapes over the area an image covers, with
+// a summed-area table so any lat/lon box can be tested in constant time.
+type shapeMask struct {
+	latMin, lonMin float64
+	w, h           int
+	sum            []int32 // (w+1)*(h+1) prefix sums
+}
+
+func newShapeMask(g *geoGrid, shapes [][][2]float64) *shapeMask {
+	m := &shapeMask{}
+	if len(shapes) == 0 {
+		return m
+	}
+	latMin, latMax := math.Inf(1), math.Inf(-1)
+	lonMin, lonMax := math.Inf(1), math.Inf(-1)
+	for i := range g.lat {
+		latMin, latMax = math.Min(latMin, g.lat[i]), math.Max(latMax, g.lat[i])
+		lonMin, lonMax = math.Min(lonMin, g.lon[i]), math.Max(lonMax, g.lon[i])
+	}
+	m.latMin, m.lonMin = latMin-1, lonMin-1
+	m.w = int((lonMax-lonMin+2)/cellDegrees) + 1
+	m.h = int((latMax-latMin+2)/cellDegrees) + 1
+
+	cells := make([]bool, m.w*m.h)
+	set := func(lat, lon float64) {
+		cx, cy := int((lon-m.lonMin)/cellDegrees), int((lat-m.latMin)/cellDegrees)
+		if cx >= 0 && cx < m.w && cy >= 0 && cy < m.h {
+			cells[cy*m.w+cx] = true
+		}
+	}
+	for _, line := range shapes {
+		for i := 1; i < len(line); i++ {
+			lon1, lat1 := wrapLon(line[i-1][0]-g.centerLon), line[i-1][1]
+			lon2, lat2 := wrapLon(line[i][0]-g.centerLon), line[i][1]
+			if math.Abs(lon2-lon1) > 180 {
+				continue // crosses the far side of the globe
+			}
+			if math.Max(lon1, lon2) < m.lonMin || math.Min(lon1, lon2) > m.lonMin+float64(m.w)*cellDegrees ||
+				math.Max(lat1, lat2) < m.latMin || math.Min(lat1, lat2) > m.latMin+float64(m.h)*cellDegrees {
+				continue
+			}
+			steps := int(math.Max(math.Abs(lon2-lon1), math.Abs(lat2-lat1))/(cellDegrees/2)) + 1
+			for s := 0; s <= steps; s++ {
+				f := float64(s) / float64(steps)
+				set(lat1+(lat2-lat1)*f, lon1+(lon2-lon1)*f)
+			}
+		}
+	}
+
+	m.sum = make([]int32, (m.w+1)*(m.h+1))
+	for y := range m.h {
+		for x := range m.w {
+			v := int32(0)
+			if cells[y*m.w+x] {
+				v = 1
+			}
+			m.sum[(y+1)*(m.w+1)+x+1] = v + m.sum[y*(m.w+1)+x+1] + m.sum[(y+1)*(m.w+1)+x] - m.sum[y*(m.w+1)+x]
+		}
+	}
+	return m
+}
+
+// any reports whether a shape passes through the given lat/lon box.
+func (m *shapeMask) any(latLo, latHi, lonLo, lonHi float64) bool {
+	if m.sum == nil {
+		return false
+	}
+	x0 := max(int((lonLo-m.lonMin)/cellDegrees), 0)
+	x1 := min(int((lonHi-m.lonMin)/cellDegrees)+1, m.w)
+	y0 := max(int((latLo-m.latMin)/cellDegrees), 0)
+	y1 := min(int((latHi-m.latMin)/cellDegrees)+1, m.h)
+	if x0 >= x1 || y0 >= y1 {
+		return false
+	}
+	s := m.sum[y1*(m.w+1)+x1] - m.sum[y0*(m.w+1)+x1] - m.sum[y1*(m.w+1)+x0] + m.sum[y0*(m.w+1)+x0]
+	return s > 0
+}
+
+// loadShapes reads the lines of a GeoJSON file, such as Natural Earth
+// coastlines and borders, as lists of [lon, lat] points. Polygons give
+// their rings; points are ignored.
+func loadShapes(path string) ([][][2]float64, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var root geoJSON
+	if err := json.Unmarshal(b, &root); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var lines [][][2]float64
+	if err := root.collect(&lines); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%s: no lines or polygons found", path)
+	}
+	return lines, nil
+}
+
+// geoJSON is any GeoJSON object; only the fields leading to geometry are
+// read.
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Features    []geoJSON       `json:"features"`
+	Geometry    *geoJSON        `json:"geometry"`
+	Geometries  []geoJSON       `json:"geometries"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+func (g *geoJSON) collect(lines *[][][2]float64) error {
+	switch g.Type {
+	case "FeatureCollection":
+		for i := range g.Features {
+			if err := g.Features[i].collect(lines); err != nil {
+				return err
+			}
+		}
+	case "Feature":
+		if g.Geometry != nil {
+			return g.Geometry.collect(lines)
+		}
+	case "GeometryCollection":
+		for i := range g.Geometries {
+			if err := g.Geometries[i].collect(lines); err != nil {
+				return err
+			}
+		}
+	case "LineString":
+		var c [][]float64
+		if err := json.Unmarshal(g.Coordinates, &c); err != nil {
+			return err
+		}
+		*lines = append(*lines, toPoints(c))
+	case "MultiLineString", "Polygon":
+		var c [][][]float64
+		if err := json.Unmarshal(g.Coordinates, &c); err != nil {
+			return err
+		}
+		for _, l := range c {
+			*lines = append(*lines, toPoints(l))
+		}
+	case "MultiPolygon":
+		var c [][][][]float64
+		if err := json.Unmarshal(g.Coordinates, &c); err != nil {
+			return err
+		}
+		for _, poly := range c {
+			for _, l := range poly {
+				*lines = append(*lines, toPoints(l))
+			}
+		}
+	case "Point", "MultiPoint":
+	default:
+		return errors.New("unsupported GeoJSON type " + g.Type)
+	}
+	return nil
+}
+
+func toPoints(c [][]float64) [][2]float64 {
+	pts := make([][2]float64, 0, len(c))
+	for _, p := range c {
+		if len(p) >= 2 {
+			pts = append(pts, [2]float64{p[0], p[1]})
+		}
+	}
+	return pts
+}
+
+// bearing is the initial great-circle bearing from the first point to the
+// second, in degrees.
+func bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	const rad = math.Pi / 180
+	phi1, phi2 := lat1*rad, lat2*rad
+	dLon := (lon2 - lon1) * rad
+	y := math.Sin(dLon) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLon)
+	return math.Atan2(y, x) / rad
+}
+
+// destination is the point dist radians of arc from lat, lon along the
+// bearing brng (degrees).
+func destination(lat, lon, brng, dist float64) (float64, float64) {
+	const rad = math.Pi / 180
+	phi1, theta := lat*rad, brng*rad
+	phi2 := math.Asin(math.Sin(phi1)*math.Cos(dist) + math.Cos(phi1)*math.Sin(dist)*math.Cos(theta))
+	dLon := math.Atan2(math.Sin(theta)*math.Sin(dist)*math.Cos(phi1), math.Cos(dist)-math.Sin(phi1)*math.Sin(phi2))
+	return phi2 / rad, wrapLon(lon + dLon/rad)
+}
+
+// wrapLon brings a longitude into [-180, 180).
+func wrapLon(lon float64) float64 {
+	return math.Mod(math.Mod(lon+180, 360)+360, 360) - 180
+}
+
+func blend(a, b color.RGBA) color.RGBA {
+	return color.RGBA{R: uint8((int(a.R) + int(b.R)) / 2), G: uint8((int(a.G) + int(b.G)) / 2), B: uint8((int(a.B) + int(b.B)) / 2), A: 255}
+}
+
+func setIn(img *image.RGBA, x, y int, c color.RGBA) {
+	if image.Pt(x, y).In(img.Rect) {
+		img.SetRGBA(x, y, c)
+	}
+}
@@ -0,0 +1,28 @@
+package decode
+
+import "math"
+
+// The inner loops of demodulate and correlateSync. They are plain Go here;
+// a build with GOEXPERIMENT=simd swaps in the vector versions in
+// kernel_simd.go where the CPU has them.
+var (
+	envelope = envelopeGo
+	axpy     = axpyGo
+)
+
+// envelopeGo sets dst[k] to the subcarrier amplitude at x[k], from x[k]
+// and the sample before it, which is prev for x[0]. See demodulate.
+func envelopeGo(dst, x []float64, prev, cosPhi, sinPhi float64) {
+	for k, v := range x {
+		dst[k] = math.Sqrt(math.Max(v*v+prev*prev-2*v*prev*cosPhi, 0)) / sinPhi
+		prev = v
+	}
+}
+
+// axpyGo adds w·src to dst.
+func axpyGo(dst []float64, w float64, src []float64) {
+	src = src[:len(dst)]
+	for k := range dst {
+		dst[k] += w * src[k]
+	}
+}
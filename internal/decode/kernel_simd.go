@@ -0,0 +1,55 @@
+//go:build goexperiment.simd && (amd64 || arm64)
+
+package decode
+
+import (
+	"math"
+	"runtime"
+	"simd/archsimd"
+)
+
+// The kernels two samples at a time in 128-bit vectors: NEON on arm64,
+// where it is always there, and AVX on amd64 when the CPU has it.
+func init() {
+	if runtime.GOARCH == "arm64" || archsimd.X86.AVX() {
+		envelope, axpy = envelopeSIMD, axpySIMD
+	}
+}
+
+// envelopeSIMD is envelopeGo with the same operations in the same order.
+func envelopeSIMD(dst, x []float64, prev, cosPhi, sinPhi float64) {
+	if len(x) == 0 {
+		return
+	}
+	dst = dst[:len(x)]
+	dst[0] = math.Sqrt(math.Max(x[0]*x[0]+prev*prev-2*x[0]*prev*cosPhi, 0)) / sinPhi
+	two := archsimd.BroadcastFloat64x2(2)
+	zero := archsimd.BroadcastFloat64x2(0)
+	c := archsimd.BroadcastFloat64x2(cosPhi)
+	s := archsimd.BroadcastFloat64x2(sinPhi)
+	k := 1
+	for ; k+2 <= len(x); k += 2 {
+		v := archsimd.LoadFloat64x2(x[k:])
+		p := archsimd.LoadFloat64x2(x[k-1:])
+		sq := v.Mul(v).Add(p.Mul(p))
+		cross := two.Mul(v).Mul(p).Mul(c)
+		sq.Sub(cross).Max(zero).Sqrt().Div(s).Store(dst[k:])
+	}
+	if k < len(x) {
+		envelopeGo(dst[k:], x[k:], x[k-1], cosPhi, sinPhi)
+	}
+}
+
+// axpySIMD is axpyGo two at a time.
+func axpySIMD(dst []float64, w float64, src []float64) {
+	src = src[:len(dst)]
+	wv := archsimd.BroadcastFloat64x2(w)
+	k := 0
+	for ; k+2 <= len(dst); k += 2 {
+		d := archsimd.LoadFloat64x2(dst[k:])
+		d.Add(wv.Mul(archsimd.LoadFloat64x2(src[k:]))).Store(dst[k:])
+	}
+	if k < len(dst) {
+		axpyGo(dst[k:], w, src[k:])
+	}
+}
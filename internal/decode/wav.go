@@ -0,0 +1,147 @@
+package decode
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// wavReader streams the samples of a PCM WAV file as floats in [-1, 1].
+// Only the first channel of a multi-channel file is used.
+type wavReader struct {
+	f          *os.File
+	r          *bufio.Reader
+	sampleRate int
+	channels   int
+	bits       int
+	remaining  int64 // bytes of sample data left
+	total      int64
+	frameSize  int    // bytes per sample frame, across all channels
+	raw        []byte // read buffer
+}
+
+func openWAV(path string) (*wavReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &wavReader{f: f, r: bufio.NewReaderSize(f, 64<<10)}
+	if err := w.readHeader(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return w, nil
+}
+
+func (w *wavReader) Close() error { return w.f.Close() }
+
+// readHeader walks the RIFF chunks up to the start of the sample data.
+func (w *wavReader) readHeader() error {
+	var riff [12]byte
+	if _, err := io.ReadFull(w.r, riff[:]); err != nil || string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return errors.New("not a WAV file")
+	}
+	st, err := w.f.Stat()
+	if err != nil {
+		return err
+	}
+	offset := int64(12)
+	for {
+		var hdr [8]byte
+		if _, err := io.ReadFull(w.r, hdr[:]); err != nil {
+			return errors.New("WAV file has no data chunk")
+		}
+		offset += 8
+		id := string(hdr[0:4])
+		size := int64(binary.LittleEndian.Uint32(hdr[4:8]))
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return errors.New("WAV fmt chunk too short")
+			}
+			b := make([]byte, size+size%2)
+			if _, err := io.ReadFull(w.r, b); err != nil {
+				return err
+			}
+			if format := binary.LittleEndian.Uint16(b[0:2]); format != 1 && format != 0xFFFE {
+				return fmt.Errorf("unsupported WAV encoding %d (need PCM)", format)
+			}
+			w.channels = int(binary.LittleEndian.Uint16(b[2:4]))
+			w.sampleRate = int(binary.LittleEndian.Uint32(b[4:8]))
+			w.bits = int(binary.LittleEndian.Uint16(b[14:16]))
+			if w.bits != 8 && w.bits != 16 {
+				return fmt.Errorf("unsupported WAV sample size %d bits (need 8 or 16)", w.bits)
+			}
+			if w.channels < 1 || w.sampleRate <= 0 {
+				return errors.New("bad WAV fmt chunk")
+			}
+		case "data":
+			if w.sampleRate == 0 {
+				return errors.New("WAV data chunk before fmt chunk")
+			}
+			// A recording cut short may not have had its sizes patched.
+			if rest := st.Size() - offset; size == 0 || size == 0xFFFFFFFF || size > rest {
+				size = rest
+			}
+			w.frameSize = w.channels * w.bits / 8
+			w.remaining, w.total = size, size
+			return nil
+		default:
+			if _, err := w.r.Discard(int(size + size%2)); err != nil {
+				return err
+			}
+		}
+		offset += size + size%2
+	}
+}
+
+// read fills dst with the next samples and returns how many it read, or
+// io.EOF after the last. Samples are converted a block at a time, which
+// matters on slow boards: a per-sample read costs more than the
+// demodulation itself.
+func (w *wavReader) read(dst []float64) (int, error) {
+	n := min(int64(len(dst)), w.remaining/int64(w.frameSize))
+	if n == 0 {
+		return 0, io.EOF
+	}
+	size := int(n) * w.frameSize
+	if cap(w.raw) < size {
+		w.raw = make([]byte, size)
+	}
+	raw := w.raw[:size]
+	got, err := io.ReadFull(w.r, raw)
+	n = int64(got / w.frameSize)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, io.EOF
+	}
+	if err != nil {
+		w.remaining = 0 // the file is shorter than its header says
+	} else {
+		w.remaining -= int64(size)
+	}
+
+	if w.bits == 8 {
+		for i := range n {
+			dst[i] = (float64(raw[int(i)*w.frameSize]) - 128) / 128
+		}
+	} else {
+		for i := range n {
+			dst[i] = float64(int16(binary.LittleEndian.Uint16(raw[int(i)*w.frameSize:]))) / 32768
+		}
+	}
+	return int(n), nil
+}
+
+// progress returns the fraction of the sample data read so far.
+func (w *wavReader) progress() float64 {
+	if w.total == 0 {
+		return 1
+	}
+	return 1 - float64(w.remaining)/float64(w.total)
+}
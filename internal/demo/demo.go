@@ -83,6 +83,8 @@ func (r *Runner) runPass(ctx context.Context, setState func(string)) {
 		"freq_hz":    sat.Freq,
 		"aos":        aos.Format(time.RFC3339),
 		"los":        los.Format(time.RFC3339),
+		"aos_unix":   aos.Unix(),
+		"los_unix":   los.Unix(),
 		"max_elev":   maxElev,
 		"duration_s": int(passDur.Seconds()),
 	})
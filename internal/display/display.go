@@ -0,0 +1,278 @@
+// Package display renders a station summary for e-paper panels and small
+// microcontroller screens: the daemon's state, the next pass, and a
+// thumbnail of the last image, in pure black and white at the panel's
+// resolution, so the client only has to copy pixels.
+package display
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/api"
+)
+
+// Summary is what a display shows, as served by /api/summary.
+type Summary struct {
+	State string     `json:"state"`
+	Clock *api.Clock `json:"clock"`
+	// NextPass is the next pass to start, or nil if none is predicted.
+	NextPass      *NextPass `json:"next_pass"`
+	NextPassError string    `json:"next_pass_error,omitempty"`
+	// LastCapture is the most recent capture with a decoded image.
+	LastCapture *LastCapture `json:"last_capture"`
+	// DiskFreePercent is the free space on the data root.
+	DiskFreePercent *int `json:"disk_free_percent,omitempty"`
+
+	// Thumbnail is LastCapture's image, drawn by Render.
+	Thumbnail image.Image `json:"-"`
+}
+
+// NextPass is the part of a predicted pass a display needs.
+type NextPass struct {
+	Satellite  string  `json:"satellite"`
+	AOS        string  `json:"aos"`
+	AOSLocal   string  `json:"aos_local"`
+	LOS        string  `json:"los"`
+	LOSLocal   string  `json:"los_local"`
+	AOSUnix    int64   `json:"aos_unix"`
+	LOSUnix    int64   `json:"los_unix"`
+	MaxElev    float64 `json:"max_elev"`
+	Direction  string  `json:"direction"`
+	CountdownS int     `json:"countdown_s"`
+}
+
+// LastCapture is the part of a capture a display needs.
+type LastCapture struct {
+	Satellite string `json:"satellite"`
+	AOS       string `json:"aos"`
+	AOSLocal  string `json:"aos_local"`
+	File      string `json:"file"`
+	// Image is the decoded image shown as the thumbnail.
+	Image string `json:"image"`
+}
+
+// Smallest and largest panels Render draws for.
+const (
+	MinSize = 64
+	MaxSize = 2048
+)
+
+// The two colors of a rendered summary. White is index 0, so a new image
+// starts blank.
+var palette = color.Palette{color.White, color.Black}
+
+// Render draws s on a w×h black and white image. Text is set at a scale
+// that suits the panel: 1 up to 296×128, the common 2.9" panel, and
+// larger in proportion above it.
+func Render(s Summary, w, h int) *image.Paletted {
+	img := image.NewPaletted(image.Rect(0, 0, w, h), palette)
+	scale := max(1, min(w/296, h/128))
+	pad := 3 * scale
+
+	// The thumbnail takes the right of the panel at full height.
+	textW := w
+	if s.Thumbnail != nil {
+		b := s.Thumbnail.Bounds()
+		tw := min(h*b.Dx()/max(1, b.Dy()), w*2/5)
+		if tw > 0 {
+			dither(img, image.Rect(w-tw, 0, w, h), s.Thumbnail)
+			textW = w - tw - pad
+		}
+	}
+
+	p := pen{img: img, x: pad, y: pad, right: textW - pad}
+	clock := ""
+	if s.Clock != nil {
+		if t, err := time.Parse(time.RFC3339, s.Clock.Local); err == nil {
+			clock = t.Format("15:04 ") + s.Clock.Abbrev
+		}
+	}
+	p.line(scale, s.State, clock)
+	p.rule(scale)
+
+	if np := s.NextPass; np != nil {
+		p.line(2*scale, "NEXT "+np.Satellite, "")
+		when := "NOW"
+		if np.CountdownS > 0 {
+			when = "IN " + countdown(time.Duration(np.CountdownS)*time.Second)
+		}
+		p.line(2*scale, localClock(np.AOSLocal, np.AOS)+" "+when, "")
+		dur := ""
+		if aos, err := time.Parse(time.RFC3339, np.AOS); err == nil {
+			if los, err := time.Parse(time.RFC3339, np.LOS); err == nil {
+				dur = fmt.Sprintf("%d MIN", int(los.Sub(aos).Round(time.Minute).Minutes()))
+			}
+		}
+		p.line(scale, fmt.Sprintf("MAX %.0f° %s", np.MaxElev, direction(np.Direction)), dur)
+	} else {
+		p.line(2*scale, "NO PASSES", "")
+		if s.NextPassError != "" {
+			p.line(scale, s.NextPassError, "")
+		}
+	}
+	p.rule(scale)
+
+	if lc := s.LastCapture; lc != nil {
+		p.line(scale, "LAST "+lc.Satellite, localDate(lc.AOSLocal, lc.AOS))
+	}
+	if s.DiskFreePercent != nil {
+		p.y = max(p.y, h-pad-glyphH*scale)
+		p.line(scale, fmt.Sprintf("DISK %d%% FREE", *s.DiskFreePercent), "")
+	}
+	return img
+}
+
+// pen sets lines of text down the left of an image.
+type pen struct {
+	img   *image.Paletted
+	x, y  int
+	right int // text is clipped here
+}
+
+// line sets left at the pen and right against the right edge, dropping
+// characters from left's end when the two do not fit, and moves down.
+// A line too wide at scale is set at scale 1 instead.
+func (p *pen) line(scale int, left, right string) {
+	if p.y+glyphH*scale > p.img.Bounds().Dy() {
+		return
+	}
+	width := p.right - p.x
+	gap := 0
+	if right != "" {
+		gap = (glyphW + 1) * scale
+	}
+	if scale > 1 && textWidth(left, scale)+gap+textWidth(right, scale) > width {
+		gap = gap / scale
+		scale = 1
+	}
+	rw := textWidth(right, scale)
+	if rw > width {
+		right, rw = "", 0
+	}
+	lr := []rune(left)
+	for len(lr) > 0 && textWidth(string(lr), scale)+gap+rw > width {
+		lr = lr[:len(lr)-1]
+	}
+	p.text(p.x, scale, string(lr))
+	if right != "" {
+		p.text(p.right-rw, scale, right)
+	}
+	p.y += (glyphH + 3) * scale
+}
+
+// text draws s with its top left at x and the pen's y.
+func (p *pen) text(x, scale int, s string) {
+	for _, r := range s {
+		g := glyph(r)
+		for gy, row := range g {
+			for gx := range glyphW {
+				if row&(1<<(glyphW-1-gx)) == 0 {
+					continue
+				}
+				for dy := range scale {
+					for dx := range scale {
+						p.img.SetColorIndex(x+gx*scale+dx, p.y+gy*scale+dy, 1)
+					}
+				}
+			}
+		}
+		x += (glyphW + 1) * scale
+	}
+}
+
+// rule draws a horizontal line across the text area.
+func (p *pen) rule(scale int) {
+	if p.y >= p.img.Bounds().Dy() {
+		return
+	}
+	for x := p.x; x < p.right; x++ {
+		for dy := range scale {
+			p.img.SetColorIndex(x, p.y+dy, 1)
+		}
+	}
+	p.y += 3 * scale
+}
+
+// dither scales src into r of dst, nearest neighbor, and reduces it to
+// black and white by Floyd–Steinberg error diffusion, which keeps the
+// shading of a weather image that a plain threshold would lose.
+func dither(dst *image.Paletted, r image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	w, h := r.Dx(), r.Dy()
+	lum := make([]float64, w*h)
+	for y := range h {
+		sy := sb.Min.Y + y*sb.Dy()/h
+		for x := range w {
+			sx := sb.Min.X + x*sb.Dx()/w
+			lum[y*w+x] = float64(color.GrayModel.Convert(src.At(sx, sy)).(color.Gray).Y)
+		}
+	}
+	for y := range h {
+		for x := range w {
+			old := lum[y*w+x]
+			v, idx := 255.0, uint8(0)
+			if old < 128 {
+				v, idx = 0, 1
+			}
+			dst.SetColorIndex(r.Min.X+x, r.Min.Y+y, idx)
+			e := old - v
+			if x+1 < w {
+				lum[y*w+x+1] += e * 7 / 16
+			}
+			if y+1 < h {
+				if x > 0 {
+					lum[(y+1)*w+x-1] += e * 3 / 16
+				}
+				lum[(y+1)*w+x] += e * 5 / 16
+				if x+1 < w {
+					lum[(y+1)*w+x+1] += e * 1 / 16
+				}
+			}
+		}
+	}
+}
+
+// localClock renders the time of day of local, or of utc if local is
+// empty.
+func localClock(local, utc string) string {
+	return reformat(local, utc, "15:04")
+}
+
+// localDate renders the date and time of day of local, or of utc.
+func localDate(local, utc string) string {
+	return reformat(local, utc, "01-02 15:04")
+}
+
+func reformat(local, utc, layout string) string {
+	s := local
+	if s == "" {
+		s = utc
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return ""
+	}
+	return t.Format(layout)
+}
+
+// countdown renders d as 7M or 3H05M.
+func countdown(d time.Duration) string {
+	m := int(d.Round(time.Minute).Minutes())
+	if m < 60 {
+		return fmt.Sprintf("%dM", m)
+	}
+	return fmt.Sprintf("%dH%02dM", m/60, m%60)
+}
+
+// direction renders a pass direction as N or S.
+func direction(dir string) string {
+	switch dir {
+	case "northbound":
+		return "N"
+	case "southbound":
+		return "S"
+	}
+	return ""
+}
@@ -0,0 +1,214 @@
+// Package eventlog persists the daemon's broadcast events to disk, so what
+// happened during a pass can be reconstructed after the fact even if no
+// client was watching. Events are appended as they were broadcast, one JSON
+// object per line, to a file per UTC day:
+//
+//	<dir>/events-2026-10-16.ndjson
+//
+// Files older than the retention period are deleted when the log rotates
+// to a new day. Query reads them back for the events history API.
+package eventlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	filePrefix = "events-"
+	fileSuffix = ".ndjson"
+	dayLayout  = "2006-01-02"
+)
+
+// FileName returns the name of the log file holding events of day's UTC
+// date.
+func FileName(day time.Time) string {
+	return filePrefix + day.UTC().Format(dayLayout) + fileSuffix
+}
+
+// fileDay parses the date out of a log file name.
+func fileDay(name string) (time.Time, bool) {
+	s, ok := strings.CutPrefix(name, filePrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	s, ok = strings.CutSuffix(s, fileSuffix)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(dayLayout, s)
+	return t, err == nil
+}
+
+// Writer appends events to the daily log files. It is not safe for
+// concurrent use; the daemon feeds it from a single goroutine.
+type Writer struct {
+	dir  string
+	name string // current file name
+	f    *os.File
+}
+
+// Write appends one event, a JSON object without a trailing newline, to
+// the file for now in dir. Moving to a new day or directory closes the
+// previous file and prunes files more than retentionDays old; 0 keeps
+// them all.
+func (w *Writer) Write(dir string, retentionDays int, event []byte, now time.Time) error {
+	name := FileName(now)
+	if w.f == nil || dir != w.dir || name != w.name {
+		_ = w.Close()
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("create event log dir: %w", err)
+		}
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		w.dir, w.name, w.f = dir, name, f
+		if retentionDays > 0 {
+			_ = Prune(dir, now.AddDate(0, 0, -retentionDays))
+		}
+	}
+	line := make([]byte, 0, len(event)+1)
+	line = append(append(line, event...), '\n')
+	_, err := w.f.Write(line)
+	return err
+}
+
+// Close closes the current file, if any.
+func (w *Writer) Close() error {
+	if w.f == nil {
+		return nil
+	}
+	err := w.f.Close()
+	w.f = nil
+	return err
+}
+
+// Files returns the log files in dir, oldest first.
+func Files(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if _, ok := fileDay(e.Name()); ok && !e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Prune deletes the log files in dir for days before cutoff's UTC date.
+func Prune(dir string, cutoff time.Time) error {
+	names, err := Files(dir)
+	if err != nil {
+		return err
+	}
+	limit := cutoff.UTC().Truncate(24 * time.Hour)
+	for _, name := range names {
+		if day, _ := fileDay(name); day.Before(limit) {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Filter selects events in Query. Zero fields match everything.
+type Filter struct {
+	Types     []string  // event types
+	Satellite string    // events naming this satellite, ignoring case
+	Since     time.Time // ts at or after
+	Until     time.Time // ts before
+	Limit     int       // keep only the newest Limit matches
+}
+
+// eventHead holds the fields of an event that Query filters on.
+type eventHead struct {
+	Type      string    `json:"type"`
+	TS        time.Time `json:"ts"`
+	Satellite string    `json:"satellite"`
+}
+
+func (f Filter) match(ev eventHead) bool {
+	switch {
+	case len(f.Types) > 0 && !slices.Contains(f.Types, ev.Type):
+	case f.Satellite != "" && !strings.EqualFold(ev.Satellite, f.Satellite):
+	case !f.Since.IsZero() && ev.TS.Before(f.Since):
+	case !f.Until.IsZero() && !ev.TS.Before(f.Until):
+	default:
+		return true
+	}
+	return false
+}
+
+// Query returns the logged events in dir that match f, oldest first. Only
+// the files for days inside Since and Until are read. A line that cannot
+// be parsed, such as one cut short by a power loss, is skipped.
+func Query(dir string, f Filter) ([]json.RawMessage, error) {
+	names, err := Files(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []json.RawMessage
+	for _, name := range names {
+		day, _ := fileDay(name)
+		if !f.Since.IsZero() && day.Add(24*time.Hour).Before(f.Since) {
+			continue
+		}
+		if !f.Until.IsZero() && !day.Before(f.Until) {
+			continue
+		}
+		if out, err = queryFile(filepath.Join(dir, name), f, out); err != nil {
+			return nil, err
+		}
+		if f.Limit > 0 && len(out) > f.Limit {
+			out = out[len(out)-f.Limit:]
+		}
+	}
+	return out, nil
+}
+
+// queryFile appends the matching events in the file at path to out.
+func queryFile(path string, f Filter, out []json.RawMessage) ([]json.RawMessage, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return out, nil // pruned while we were reading
+		}
+		return out, err
+	}
+	defer file.Close()
+
+	r := bufio.NewReader(file)
+	for {
+		line, err := r.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var ev eventHead
+			if json.Unmarshal(line, &ev) == nil && f.match(ev) {
+				out = append(out, json.RawMessage(line))
+			}
+		}
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+	}
+}
@@ -0,0 +1,316 @@
+// Package gallery exports the station's decoded images as a static web
+// site: an index of days, one page per day, and the images with their
+// thumbnails, all with relative links. The directory can be served by any
+// web server or synced to GitHub Pages as it is.
+package gallery
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+//go:embed templates/*.html
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+// Capture is one capture shown in the gallery.
+type Capture struct {
+	Satellite string
+	AOS       time.Time
+	MaxElev   float64
+	// Images are the decoded images, relative to the data root.
+	Images []string
+}
+
+// Options says where the gallery comes from and goes.
+type Options struct {
+	Root     string // the data root the images are relative to
+	Dir      string // where the site is written
+	Title    string
+	Location *time.Location // days and times are shown in this zone
+}
+
+// Result summarizes an export. Copied counts the images that were new or
+// had changed, and Removed the files of captures no longer shown.
+type Result struct {
+	Days     int `json:"days"`
+	Captures int `json:"captures"`
+	Images   int `json:"images"`
+	Copied   int `json:"copied"`
+	Removed  int `json:"removed"`
+}
+
+// Subdirectories of the gallery for full-size images and thumbnails.
+const (
+	imagesDir = "images"
+	thumbsDir = "thumbs"
+)
+
+// dayPage matches the pages Export writes for days, so it only ever
+// removes its own.
+var dayPage = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}\.html$`)
+
+type imageEntry struct {
+	Name  string
+	Full  string
+	Thumb string
+}
+
+type captureEntry struct {
+	Satellite string
+	Time      string
+	MaxElev   float64
+	Images    []imageEntry
+}
+
+type dayEntry struct {
+	Date       string
+	Page       string
+	Satellites string
+	Captures   []captureEntry
+	Cover      imageEntry
+	Prev, Next string // pages of the neighboring days, older and newer
+}
+
+// Export writes the gallery for caps to opts.Dir. Images are copied only
+// when new or changed, so a re-export after each pass is cheap. Captures
+// without images are left out.
+func Export(opts Options, caps []Capture) (Result, error) {
+	var res Result
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	for _, sub := range []string{imagesDir, thumbsDir} {
+		if err := os.MkdirAll(filepath.Join(opts.Dir, sub), 0o755); err != nil {
+			return res, err
+		}
+	}
+
+	sort.Slice(caps, func(i, j int) bool { return caps[i].AOS.Before(caps[j].AOS) })
+	byDate := map[string]*dayEntry{}
+	var days []*dayEntry
+	keep := map[string]bool{}
+	for _, c := range caps {
+		var images []imageEntry
+		for _, img := range c.Images {
+			// Image paths are relative to the data root; cleaning them
+			// against "/" keeps them inside it.
+			rel := filepath.Clean("/" + img)[1:]
+			copied, err := copyImage(opts.Root, opts.Dir, rel)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return res, fmt.Errorf("%s: %w", rel, err)
+			}
+			if copied {
+				res.Copied++
+			}
+			keep[filepath.Join(imagesDir, rel)] = true
+			keep[filepath.Join(thumbsDir, thumbName(rel))] = true
+			images = append(images, imageEntry{
+				Name:  filepath.Base(rel),
+				Full:  filepath.ToSlash(filepath.Join(imagesDir, rel)),
+				Thumb: filepath.ToSlash(filepath.Join(thumbsDir, thumbName(rel))),
+			})
+		}
+		if len(images) == 0 {
+			continue
+		}
+		res.Captures++
+		res.Images += len(images)
+
+		aos := c.AOS.In(loc)
+		date := aos.Format("2006-01-02")
+		d, ok := byDate[date]
+		if !ok {
+			d = &dayEntry{Date: date, Page: date + ".html"}
+			byDate[date] = d
+			days = append(days, d)
+		}
+		d.Captures = append(d.Captures, captureEntry{
+			Satellite: c.Satellite,
+			Time:      aos.Format("15:04 MST"),
+			MaxElev:   c.MaxElev,
+			Images:    images,
+		})
+		if !strings.Contains(d.Satellites, c.Satellite) {
+			if d.Satellites != "" {
+				d.Satellites += ", "
+			}
+			d.Satellites += c.Satellite
+		}
+		// The day's cover is channel A of its highest pass.
+		if len(d.Captures) == 1 || c.MaxElev > bestElev(d) {
+			d.Cover = images[0]
+			for _, img := range images {
+				if strings.HasSuffix(img.Name, "-A.png") {
+					d.Cover = img
+				}
+			}
+		}
+	}
+	res.Days = len(days)
+
+	// Newest first.
+	sort.Slice(days, func(i, j int) bool { return days[i].Date > days[j].Date })
+	for i, d := range days {
+		if i > 0 {
+			d.Next = days[i-1].Page
+		}
+		if i+1 < len(days) {
+			d.Prev = days[i+1].Page
+		}
+		if err := render(filepath.Join(opts.Dir, d.Page), "day.html", map[string]any{"Title": opts.Title, "Day": d}); err != nil {
+			return res, err
+		}
+		keep[d.Page] = true
+	}
+	err := render(filepath.Join(opts.Dir, "index.html"), "index.html", map[string]any{
+		"Title":     opts.Title,
+		"Days":      days,
+		"Generated": time.Now().In(loc).Format("2006-01-02 15:04 MST"),
+	})
+	if err != nil {
+		return res, err
+	}
+
+	res.Removed, err = removeStale(opts.Dir, keep)
+	return res, err
+}
+
+// bestElev is the highest pass of d so far, not counting the one just
+// added.
+func bestElev(d *dayEntry) float64 {
+	best := 0.0
+	for _, c := range d.Captures[:len(d.Captures)-1] {
+		best = max(best, c.MaxElev)
+	}
+	return best
+}
+
+// thumbName is the thumbnail file for image rel.
+func thumbName(rel string) string {
+	return strings.TrimSuffix(rel, filepath.Ext(rel)) + ".jpg"
+}
+
+// copyImage copies image rel from root into the gallery with its
+// thumbnail, unless the copy already there has the same size and time.
+// It reports whether it copied.
+func copyImage(root, dir, rel string) (bool, error) {
+	src := filepath.Join(root, rel)
+	dst := filepath.Join(dir, imagesDir, rel)
+	si, err := os.Stat(src)
+	if err != nil {
+		return false, err
+	}
+	if di, err := os.Stat(dst); err == nil && di.Size() == si.Size() && di.ModTime().Equal(si.ModTime()) {
+		if _, err := os.Stat(filepath.Join(dir, thumbsDir, thumbName(rel))); err == nil {
+			return false, nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return false, err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return false, err
+	}
+	defer in.Close()
+	err = writeFile(dst, func(w io.Writer) error {
+		_, err := io.Copy(w, in)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := os.Chtimes(dst, si.ModTime(), si.ModTime()); err != nil {
+		return false, err
+	}
+	return true, writeThumb(dst, filepath.Join(dir, thumbsDir, thumbName(rel)))
+}
+
+// render executes template name into path.
+func render(path, name string, data any) error {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, data); err != nil {
+		return err
+	}
+	return writeFile(path, func(w io.Writer) error {
+		_, err := w.Write(buf.Bytes())
+		return err
+	})
+}
+
+// writeFile writes path through a temp file, so a web server never serves
+// a partial page or image.
+func writeFile(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// removeStale deletes the day pages, images and thumbnails in dir that
+// are not in keep, such as those of captures since pruned. Other files
+// are left alone.
+func removeStale(dir string, keep map[string]bool) (int, error) {
+	removed := 0
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	for _, e := range entries {
+		if dayPage.MatchString(e.Name()) && !keep[e.Name()] {
+			if err := os.Remove(filepath.Join(dir, e.Name())); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	for _, sub := range []string{imagesDir, thumbsDir} {
+		err := filepath.WalkDir(filepath.Join(dir, sub), func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, _ := filepath.Rel(dir, path)
+			if keep[rel] {
+				return nil
+			}
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed++
+			return nil
+		})
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,58 @@
+package gallery
+
+import (
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png" // decoded images are PNGs
+	"io"
+	"os"
+)
+
+// thumbWidth is the width of thumbnails in pixels. An APT image is 909
+// pixels wide per channel, so this shrinks it by about three.
+const thumbWidth = 320
+
+// writeThumb writes a JPEG thumbnail of the image at src to dst.
+func writeThumb(src, dst string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	thumb := shrink(img, thumbWidth)
+	return writeFile(dst, func(w io.Writer) error {
+		return jpeg.Encode(w, thumb, &jpeg.Options{Quality: 80})
+	})
+}
+
+// shrink scales src down to width w, keeping its aspect, by averaging the
+// source pixels under each thumbnail pixel. An image narrower than w is
+// returned as it is.
+func shrink(src image.Image, w int) image.Image {
+	sb := src.Bounds()
+	if sb.Dx() <= w {
+		return src
+	}
+	h := max(1, sb.Dy()*w/sb.Dx())
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		y0, y1 := sb.Min.Y+y*sb.Dy()/h, sb.Min.Y+(y+1)*sb.Dy()/h
+		for x := range w {
+			x0, x1 := sb.Min.X+x*sb.Dx()/w, sb.Min.X+(x+1)*sb.Dx()/w
+			var r, g, b, n uint32
+			for sy := y0; sy < max(y1, y0+1); sy++ {
+				for sx := x0; sx < max(x1, x0+1); sx++ {
+					cr, cg, cb, _ := src.At(sx, sy).RGBA()
+					r, g, b, n = r+cr, g+cg, b+cb, n+1
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(b / n), A: 0xffff})
+		}
+	}
+	return dst
+}
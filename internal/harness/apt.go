@@ -0,0 +1,81 @@
+package harness
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// APT frame layout, as internal/decode reads it: 4160 words a second in
+// lines of 2080, channel A then channel B, each a sync pulse train, a
+// space, the image and a telemetry wedge.
+const (
+	wordRate     = 4160
+	lineWords    = 2080
+	channelWords = lineWords / 2
+	syncWords    = 39
+	spaceWords   = 47
+	imageWords   = 909
+	carrierHz    = 2400
+)
+
+// APTAudio returns seconds of canned APT audio at rate, as the signed
+// 16-bit little-endian mono samples rtl_fm writes. Channel A carries a
+// gradient from black at the left to white at the right and channel B
+// vertical bars, so a decode that loses sync or swaps channels shows.
+func APTAudio(rate int, seconds float64) []byte {
+	n := int(seconds * float64(rate))
+	out := make([]byte, 2*n)
+	for i := range n {
+		t := float64(i) / float64(rate)
+		word := int(t * wordRate)
+		v := aptWord(word/lineWords, word%lineWords)
+		// The subcarrier is amplitude modulated by the pixel value.
+		amp := 0.05 + 0.9*v
+		s := amp * math.Sin(2*math.Pi*carrierHz*t)
+		binary.LittleEndian.PutUint16(out[2*i:], uint16(int16(s*30000)))
+	}
+	return out
+}
+
+// aptWord is the brightness, from 0 to 1, of word pos of line.
+func aptWord(line, pos int) float64 {
+	ch, pos := pos/channelWords, pos%channelWords
+	switch {
+	case pos < syncWords:
+		return syncPulse(ch, pos)
+	case pos < syncWords+spaceWords:
+		// Channel A's space is black and B's white, as on a night pass.
+		return float64(ch)
+	case pos < syncWords+spaceWords+imageWords:
+		x := pos - syncWords - spaceWords
+		if ch == 0 {
+			return float64(x) / imageWords
+		}
+		if (x/101+line/16)%2 == 0 {
+			return 0.8
+		}
+		return 0.2
+	default:
+		// Telemetry wedges step through eight gray levels down the image.
+		return float64(line/8%8) / 7
+	}
+}
+
+// syncPulse is word pos of the sync train of channel ch: after four words
+// of black, seven cycles of 1040 Hz for A or 832 Hz for B.
+func syncPulse(ch, pos int) float64 {
+	if pos < 4 {
+		return 0
+	}
+	pos -= 4
+	if ch == 0 {
+		if pos < 28 && pos%4 < 2 {
+			return 1
+		}
+		return 0
+	}
+	if pos < 35 && pos%5 < 3 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,65 @@
+package harness
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fakeRtlFm stands in for rtl_fm: it saves its arguments next to itself,
+// for checking the tuning, and writes the canned audio to stdout. The
+// capture ends when the audio does, well before LOS.
+const fakeRtlFm = `#!/bin/sh
+# Fake rtl_fm installed by the ephemeris end-to-end harness.
+printf '%s\n' "$@" > "$0.args"
+exec cat AUDIO
+`
+
+// InstallFakeRtlFm writes an executable rtl_fm into dir that plays back
+// the raw samples in audio, such as APTAudio's, whatever it is asked to
+// tune to. Put dir first on PATH for the daemon to run it.
+func InstallFakeRtlFm(dir, audio string) error {
+	script := strings.Replace(fakeRtlFm, "AUDIO", shellQuote(audio), 1)
+	return os.WriteFile(filepath.Join(dir, "rtl_fm"), []byte(script), 0o755)
+}
+
+// shellQuote quotes s for /bin/sh.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// TLEs are the NOAA satellites' elements the Station serves in place of
+// CelesTrak, so predictions and the overlay work offline. The checksums
+// are computed, as the elements are made up to stay near the epoch.
+var TLEs = withChecksums(`NOAA 15
+1 25338U 98030A   26045.47916667  .00000028  00000+0  20453-4 0  999
+2 25338  98.5681 115.2370 0010817 134.2580 225.9530 14.2620795345678
+NOAA 18
+1 28654U 05018A   26045.47916667  .00000038  00000+0  30985-4 0  999
+2 28654  99.0405 199.8736 0013788 298.6437  61.3312 14.1258730567890
+NOAA 19
+1 33591U 09005A   26045.47916667  .00000049  00000+0  38046-4 0  999
+2 33591  99.1531  98.3254 0013437 172.3478 187.7912 14.1241678901234
+`)
+
+// withChecksums appends the modulo-10 checksum to each element line: the
+// sum of its digits, with each minus sign counting as one.
+func withChecksums(tles string) string {
+	lines := strings.Split(strings.TrimSpace(tles), "\n")
+	for i, l := range lines {
+		if !strings.HasPrefix(l, "1 ") && !strings.HasPrefix(l, "2 ") {
+			continue
+		}
+		sum := 0
+		for _, c := range l {
+			switch {
+			case c >= '0' && c <= '9':
+				sum += int(c - '0')
+			case c == '-':
+				sum++
+			}
+		}
+		lines[i] = l + string(rune('0'+sum%10))
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
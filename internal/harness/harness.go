@@ -0,0 +1,255 @@
+// Package harness runs a whole daemon against a fake SDR, so the pass
+// pipeline can be exercised end to end without a radio or the network.
+//
+// A Station is a real app.App serving its API on a free loopback port,
+// with a temporary data root, TLEs served from a local HTTP server, and a
+// fake rtl_fm first on PATH that plays back canned APT audio. Pipeline
+// drives it through the API from trigger to capture, decode and the
+// capture index, checking each step as an operator would see it.
+package harness
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/api"
+	"github.com/large-farva/ephemeris-engine/internal/app"
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// Options configures a Station. The zero value is usable.
+type Options struct {
+	// Dir holds the station's files. Empty makes a temporary directory,
+	// removed by Close.
+	Dir string
+	// AudioSeconds is how much canned audio the fake rtl_fm plays back
+	// for each capture. The default of 30 is 60 lines.
+	AudioSeconds float64
+	// Logger receives the daemon's log. Nil discards it.
+	Logger *slog.Logger
+	// Configure, when set, adjusts the config before the daemon starts.
+	Configure func(*config.Config)
+}
+
+// Station is a running daemon wired to a fake SDR.
+type Station struct {
+	// URL is the base URL of the API, such as http://127.0.0.1:41234.
+	URL string
+	// Dir holds the fake rtl_fm, its audio, and Cfg.Data.Root.
+	Dir string
+	Cfg config.Config
+
+	client  *http.Client
+	tles    *httptest.Server
+	cancel  context.CancelFunc
+	done    chan error
+	oldPath string
+	tempDir bool
+}
+
+// Start starts a daemon on a free loopback port and waits until it is
+// serving. It puts the fake rtl_fm first on PATH for the whole process,
+// so only one Station should run at a time.
+func Start(opts Options) (*Station, error) {
+	s := &Station{Dir: opts.Dir, client: &http.Client{Timeout: 10 * time.Second}}
+	if s.Dir == "" {
+		dir, err := os.MkdirTemp("", "ephemeris-harness-")
+		if err != nil {
+			return nil, err
+		}
+		s.Dir, s.tempDir = dir, true
+	}
+	if opts.AudioSeconds <= 0 {
+		opts.AudioSeconds = 30
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+
+	cfg := config.Default()
+	cfg.Demo.Enabled = false
+	cfg.Data.Root = filepath.Join(s.Dir, "data")
+	cfg.Data.Archive = filepath.Join(s.Dir, "archive")
+	cfg.Station.Latitude, cfg.Station.Longitude = 51.48, -0.01
+	cfg.Station.GeoIP = false
+	cfg.Server.PublicReadonly = false
+	cfg.Catalog.Sync = false
+	cfg.Weather.Enabled = false
+	if opts.Configure != nil {
+		opts.Configure(&cfg)
+	}
+
+	bin := filepath.Join(s.Dir, "bin")
+	if err := os.MkdirAll(bin, 0o755); err != nil {
+		s.cleanup()
+		return nil, err
+	}
+	audio := filepath.Join(s.Dir, "apt.s16")
+	if err := os.WriteFile(audio, APTAudio(cfg.SDR.SampleRate, opts.AudioSeconds), 0o644); err != nil {
+		s.cleanup()
+		return nil, err
+	}
+	if err := InstallFakeRtlFm(bin, audio); err != nil {
+		s.cleanup()
+		return nil, err
+	}
+	s.oldPath = os.Getenv("PATH")
+	os.Setenv("PATH", bin+string(os.PathListSeparator)+s.oldPath)
+
+	s.tles = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, TLEs)
+	}))
+	cfg.Predict.TLEURL = s.tles.URL
+	if err := config.EnsureDirectories(cfg); err != nil {
+		s.cleanup()
+		return nil, err
+	}
+	s.Cfg = cfg
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		s.cleanup()
+		return nil, err
+	}
+	s.URL = "http://" + ln.Addr().String()
+	a := app.New(app.Options{Logger: logger, Cfg: cfg, Listener: ln})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.done = make(chan error, 1)
+	go func() { s.done <- a.Run(ctx) }()
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer waitCancel()
+	if _, err := s.WaitForState(waitCtx, "IDLE", "WAITING_FOR_PASS"); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("daemon did not start: %w", err)
+	}
+	return s, nil
+}
+
+// Close stops the daemon, restores PATH, and removes a temporary Dir.
+func (s *Station) Close() error {
+	var err error
+	if s.cancel != nil {
+		s.cancel()
+		if runErr := <-s.done; runErr != nil && !errors.Is(runErr, http.ErrServerClosed) {
+			err = runErr
+		}
+		s.cancel = nil
+	}
+	s.cleanup()
+	return err
+}
+
+func (s *Station) cleanup() {
+	if s.tles != nil {
+		s.tles.Close()
+		s.tles = nil
+	}
+	if s.oldPath != "" {
+		os.Setenv("PATH", s.oldPath)
+		s.oldPath = ""
+	}
+	if s.tempDir {
+		os.RemoveAll(s.Dir)
+		s.tempDir = false
+	}
+}
+
+// Get fetches path from the API and decodes its JSON body into v.
+func (s *Station) Get(ctx context.Context, path string, v any) error {
+	return s.do(ctx, http.MethodGet, path, nil, v)
+}
+
+// Post sends body as JSON to path and decodes the response into v, which
+// may be nil.
+func (s *Station) Post(ctx context.Context, path string, body, v any) error {
+	return s.do(ctx, http.MethodPost, path, body, v)
+}
+
+func (s *Station) do(ctx context.Context, method, path string, body, v any) error {
+	var rd io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, s.URL+path, rd)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(b)))
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(b, v)
+}
+
+// Status returns /api/status.
+func (s *Station) Status(ctx context.Context) (api.StatusResponse, error) {
+	var st api.StatusResponse
+	err := s.Get(ctx, "/api/status", &st)
+	return st, err
+}
+
+// WaitForState polls /api/status until the daemon is in one of states.
+func (s *Station) WaitForState(ctx context.Context, states ...string) (api.StatusResponse, error) {
+	for {
+		st, err := s.Status(ctx)
+		if err == nil {
+			for _, want := range states {
+				if st.State == want {
+					return st, nil
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if err == nil {
+				err = fmt.Errorf("state is %s, not %s", st.State, strings.Join(states, " or "))
+			}
+			return st, fmt.Errorf("%w: %v", ctx.Err(), err)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// RtlFmArgs returns the arguments of the last fake rtl_fm run, or nil if
+// it has not run.
+func (s *Station) RtlFmArgs() []string {
+	b, err := os.ReadFile(filepath.Join(s.Dir, "bin", "rtl_fm.args"))
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(b))
+}
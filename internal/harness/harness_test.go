@@ -0,0 +1,107 @@
+package harness
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+)
+
+// TestPipeline runs a triggered pass through a daemon with the fake
+// rtl_fm, as `ephemerisd selftest` does, and checks what it left: the
+// recording and its sidecar, the decoded images, the history, and the
+// download of the capture through the API.
+func TestPipeline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts a daemon")
+	}
+	st, err := Start(Options{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := st.Close(); err != nil {
+			t.Errorf("close: %v", err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	rep, err := Pipeline(ctx, st, "NOAA-19")
+	for _, s := range rep.Steps {
+		t.Logf("%-8s %8s  %s", s.Name, s.Elapsed.Round(time.Millisecond), s.Detail)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var steps []string
+	for _, s := range rep.Steps {
+		steps = append(steps, s.Name)
+	}
+	if want := []string{"trigger", "capture", "decode", "index"}; !slices.Equal(steps, want) {
+		t.Errorf("steps = %v, want %v", steps, want)
+	}
+
+	// Capture: the recording is in data.root with a finished sidecar.
+	if !strings.HasPrefix(rep.Capture, "NOAA-19_") || filepath.Ext(rep.Capture) != ".wav" {
+		t.Errorf("capture = %q, want a NOAA-19 WAV", rep.Capture)
+	}
+	path := filepath.Join(st.Cfg.Data.Root, rep.Capture)
+	meta, err := capture.ReadMetadata(path)
+	if err != nil {
+		t.Fatalf("sidecar: %v", err)
+	}
+	if meta.Satellite != "NOAA-19" || meta.SHA256 == "" {
+		t.Errorf("sidecar = %+v, want NOAA-19 with a checksum", meta)
+	}
+	if sum, err := capture.VerifyChecksum(path); err != nil {
+		t.Errorf("checksum: %v", err)
+	} else if sum != meta.SHA256 {
+		t.Errorf("checksum = %s, want %s", sum, meta.SHA256)
+	}
+
+	// Decode: the 30 seconds of canned audio are 60 lines.
+	if meta.DecodeStatus() != capture.DecodeDone {
+		t.Errorf("decode status = %s, want %s", meta.DecodeStatus(), capture.DecodeDone)
+	}
+	if rep.Lines < 55 || rep.Lines > 60 {
+		t.Errorf("lines = %d, want about 60", rep.Lines)
+	}
+	for _, img := range rep.Images {
+		if _, err := os.Stat(filepath.Join(st.Cfg.Data.Root, img)); err != nil {
+			t.Errorf("image: %v", err)
+		}
+	}
+
+	// Index: the listed capture downloads intact over HTTP.
+	resp, err := http.Get(st.URL + "/api/captures/file?name=" + url.QueryEscape(rep.Capture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("download: %s: %s", resp.Status, body)
+	}
+	sum := sha256.Sum256(body)
+	if want := "sha-256=:" + base64.StdEncoding.EncodeToString(sum[:]) + ":"; resp.Header.Get("Repr-Digest") != want {
+		t.Errorf("Repr-Digest = %q, want %q", resp.Header.Get("Repr-Digest"), want)
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() != int64(len(body)) {
+		t.Errorf("downloaded %d bytes, file has %d", len(body), info.Size())
+	}
+}
@@ -0,0 +1,188 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/store"
+)
+
+// Step is one checked stage of Pipeline.
+type Step struct {
+	Name    string
+	Elapsed time.Duration
+	Detail  string
+}
+
+// Report is what Pipeline found.
+type Report struct {
+	Capture string   // capture file name in data.root
+	Images  []string // decoded images, relative to data.root
+	Lines   int      // lines decoded
+	Steps   []Step
+}
+
+// capturedFile is the part of an /api/captures entry Pipeline checks.
+type capturedFile struct {
+	Filename  string   `json:"filename"`
+	Satellite string   `json:"satellite"`
+	Size      int64    `json:"size"`
+	SHA256    string   `json:"sha256"`
+	Images    []string `json:"images"`
+}
+
+// Pipeline triggers a capture of satellite through the API and follows
+// it as an operator would: the trigger is accepted, the fake rtl_fm is
+// tuned to the satellite, the daemon records and decodes and returns to
+// IDLE, the capture is listed with its checksum and A and B images, and
+// the pass history records it. It returns at the first step that fails.
+func Pipeline(ctx context.Context, s *Station, satellite string) (Report, error) {
+	var rep Report
+	sat := capture.SatelliteByName(satellite)
+	if sat == nil {
+		return rep, fmt.Errorf("unknown satellite %q", satellite)
+	}
+	start := time.Now()
+	step := func(name, detail string) {
+		rep.Steps = append(rep.Steps, Step{Name: name, Elapsed: time.Since(start), Detail: detail})
+		start = time.Now()
+	}
+
+	var res struct {
+		OK         bool   `json:"ok"`
+		Message    string `json:"message"`
+		Resolution string `json:"resolution"`
+	}
+	if err := s.Post(ctx, "/api/trigger", map[string]any{"satellite": sat.Name, "duration_seconds": 60}, &res); err != nil {
+		return rep, fmt.Errorf("trigger: %w", err)
+	}
+	if !res.OK {
+		return rep, fmt.Errorf("trigger: refused: %s", res.Message)
+	}
+	step("trigger", res.Message)
+
+	// The canned audio is played back at once, so recording and decoding
+	// are over within a poll or two; follow them by what they leave.
+	var c *capturedFile
+	for c == nil {
+		select {
+		case <-ctx.Done():
+			return rep, fmt.Errorf("capture: no capture of %s listed: %w", sat.Name, ctx.Err())
+		case <-time.After(100 * time.Millisecond):
+		}
+		if args := s.RtlFmArgs(); args == nil {
+			continue
+		}
+		var list struct {
+			Captures []capturedFile `json:"captures"`
+		}
+		if err := s.Get(ctx, "/api/captures", &list); err != nil {
+			return rep, fmt.Errorf("index: %w", err)
+		}
+		for i := range list.Captures {
+			if list.Captures[i].Satellite == sat.Name {
+				c = &list.Captures[i]
+			}
+		}
+	}
+	args := s.RtlFmArgs()
+	if i := slices.Index(args, "-f"); i < 0 || i+1 >= len(args) || args[i+1] != strconv.Itoa(sat.Freq) {
+		return rep, fmt.Errorf("capture: rtl_fm was run with %q, not tuned to %d Hz", strings.Join(args, " "), sat.Freq)
+	}
+	step("capture", fmt.Sprintf("rtl_fm tuned to %d Hz", sat.Freq))
+
+	// The images are listed once the decode has finished.
+	if _, err := s.WaitForState(ctx, "IDLE", "WAITING_FOR_PASS"); err != nil {
+		return rep, fmt.Errorf("decode: %w", err)
+	}
+	var list struct {
+		Captures []capturedFile `json:"captures"`
+	}
+	if err := s.Get(ctx, "/api/captures", &list); err != nil {
+		return rep, fmt.Errorf("index: %w", err)
+	}
+	for i := range list.Captures {
+		if list.Captures[i].Filename == c.Filename {
+			c = &list.Captures[i]
+		}
+	}
+	switch {
+	case c.Size == 0:
+		return rep, fmt.Errorf("index: %s is empty", c.Filename)
+	case c.SHA256 == "":
+		return rep, fmt.Errorf("index: %s has no checksum", c.Filename)
+	}
+	rep.Capture, rep.Images = c.Filename, c.Images
+	for _, suffix := range []string{"-A.png", "-B.png"} {
+		if !slices.ContainsFunc(c.Images, func(img string) bool { return strings.HasSuffix(img, suffix) }) {
+			return rep, fmt.Errorf("decode: %s has no %s image (images: %s)", c.Filename, suffix, strings.Join(c.Images, ", "))
+		}
+	}
+	lines, err := checkGradient(filepath.Join(s.Cfg.Data.Root, c.Images[slices.IndexFunc(c.Images, func(img string) bool {
+		return strings.HasSuffix(img, "-A.png")
+	})]))
+	if err != nil {
+		return rep, fmt.Errorf("decode: %w", err)
+	}
+	rep.Lines = lines
+	step("decode", fmt.Sprintf("%d lines, %d images", rep.Lines, len(c.Images)))
+
+	var hist struct {
+		History []store.Record `json:"history"`
+	}
+	if err := s.Get(ctx, "/api/history?satellite="+sat.Name, &hist); err != nil {
+		return rep, fmt.Errorf("history: %w", err)
+	}
+	if !slices.ContainsFunc(hist.History, func(r store.Record) bool {
+		return r.File == c.Filename && r.Outcome == store.OutcomeCaptured && r.Manual
+	}) {
+		return rep, fmt.Errorf("history: no captured, manual record of %s", c.Filename)
+	}
+	step("index", c.Filename)
+	return rep, nil
+}
+
+// checkGradient checks that the channel A image at path shows APTAudio's
+// gradient, dark on the left and light on the right, which it only does
+// when every line was synced. It returns the image's height in lines.
+func checkGradient(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	b := img.Bounds()
+	if b.Dy() == 0 {
+		return 0, fmt.Errorf("%s has no lines", filepath.Base(path))
+	}
+	// Mean brightness of a band of columns a tenth of the width.
+	band := func(x0 int) float64 {
+		sum, n := 0.0, 0
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := x0; x < x0+b.Dx()/10; x++ {
+				sum += float64(color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y)
+				n++
+			}
+		}
+		return sum / float64(n)
+	}
+	// The image may be rotated, so compare the two sides either way.
+	left, right := band(b.Min.X+b.Dx()/10), band(b.Max.X-2*b.Dx()/10)
+	if d := right - left; d < 100 && d > -100 {
+		return b.Dy(), fmt.Errorf("%s does not show the test gradient (sides %.0f and %.0f)", filepath.Base(path), left, right)
+	}
+	return b.Dy(), nil
+}
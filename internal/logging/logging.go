@@ -0,0 +1,205 @@
+// Package logging builds the daemon's structured logger: log/slog records,
+// as text or JSON, written to stdout and to a size-rotated file under the
+// data root, so the daemon's log is kept on hosts without journald too.
+// The levels can change on reload; the format and file are fixed at start.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// FileName is the log file's name in Dir.
+const FileName = "ephemerisd.log"
+
+// Dir returns the directory log files are written to under root.
+func Dir(root string) string {
+	return filepath.Join(root, "logs")
+}
+
+// Output is where a logger built by New writes, and how it filters.
+type Output struct {
+	level slog.LevelVar
+	// components holds the levels of [logging.components], which override
+	// level for records with that component attribute.
+	components atomic.Pointer[map[string]slog.Level]
+	file       *rotator // nil when file logging is off
+	redact     atomic.Pointer[func(string) string]
+}
+
+// New returns a logger for cfg that writes to stdout and, when cfg.File
+// is set, to FileName under Dir(root), with the Output that controls it.
+func New(cfg config.LoggingConfig, root string) (*slog.Logger, *Output, error) {
+	out := &Output{}
+	out.SetLevel(cfg.Level)
+	out.SetComponentLevels(cfg.Components)
+	var w io.Writer = os.Stdout
+	if cfg.File {
+		dir := Dir(root)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, nil, err
+		}
+		f, err := openRotator(filepath.Join(dir, FileName), int64(cfg.MaxSizeMB)<<20, cfg.MaxFiles)
+		if err != nil {
+			return nil, nil, err
+		}
+		out.file = f
+		w = io.MultiWriter(os.Stdout, f)
+	}
+	w = &redactingWriter{w: w, out: out}
+
+	// The handlers take every level; componentHandler does the filtering.
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var h slog.Handler
+	if cfg.Format == "json" {
+		h = slog.NewJSONHandler(w, opts)
+	} else {
+		h = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(&componentHandler{h: h, out: out}), out, nil
+}
+
+// SetLevel changes the level records are logged at. An unknown level is
+// taken as info.
+func (o *Output) SetLevel(level string) {
+	o.level.Set(parseLevel(level))
+}
+
+// SetComponentLevels changes the levels of single components, by the
+// value of their component attribute. Components not in levels are logged
+// at the level SetLevel set.
+func (o *Output) SetComponentLevels(levels map[string]string) {
+	m := make(map[string]slog.Level, len(levels))
+	for component, level := range levels {
+		m[component] = parseLevel(level)
+	}
+	o.components.Store(&m)
+}
+
+// ComponentLevel returns the level component is logged at, and whether
+// [logging.components] sets it rather than logging.level.
+func (o *Output) ComponentLevel(component string) (slog.Level, bool) {
+	if m := o.components.Load(); m != nil {
+		if l, ok := (*m)[component]; ok {
+			return l, true
+		}
+	}
+	return o.level.Level(), false
+}
+
+// minLevel is the least severe level any component is logged at.
+func (o *Output) minLevel() slog.Level {
+	min := o.level.Level()
+	if m := o.components.Load(); m != nil {
+		for _, l := range *m {
+			if l < min {
+				min = l
+			}
+		}
+	}
+	return min
+}
+
+// parseLevel parses a level of the config, taking an unknown one as info.
+func parseLevel(level string) slog.Level {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// SetRedact installs fn to scrub every record before it is written, such
+// as to hide secrets from the config.
+func (o *Output) SetRedact(fn func(string) string) {
+	o.redact.Store(&fn)
+}
+
+// Path returns the path of the log file, or "" when file logging is off.
+func (o *Output) Path() string {
+	if o.file == nil {
+		return ""
+	}
+	return o.file.path
+}
+
+// Close closes the log file.
+func (o *Output) Close() error {
+	if o.file == nil {
+		return nil
+	}
+	return o.file.Close()
+}
+
+// redactingWriter applies the Output's redact function. The handlers
+// issue one Write per record, so a secret is never split across calls.
+type redactingWriter struct {
+	w   io.Writer
+	out *Output
+}
+
+func (rw *redactingWriter) Write(p []byte) (int, error) {
+	fn := rw.out.redact.Load()
+	if fn == nil {
+		return rw.w.Write(p)
+	}
+	if _, err := io.WriteString(rw.w, (*fn)(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// componentHandler filters records at the level of their component: the
+// component attribute given to Logger.With, as the scheduler and capture
+// loggers are built, or failing that one on the record itself.
+type componentHandler struct {
+	h         slog.Handler
+	out       *Output
+	component string // from With; "" until one is given
+}
+
+func (c *componentHandler) Enabled(_ context.Context, level slog.Level) bool {
+	if c.component != "" {
+		min, _ := c.out.ComponentLevel(c.component)
+		return level >= min
+	}
+	// The record's own attributes are not known yet; Handle decides.
+	return level >= c.out.minLevel()
+}
+
+func (c *componentHandler) Handle(ctx context.Context, r slog.Record) error {
+	if c.component == "" {
+		var component string
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == "component" {
+				component = a.Value.String()
+				return false
+			}
+			return true
+		})
+		if min, _ := c.out.ComponentLevel(component); r.Level < min {
+			return nil
+		}
+	}
+	return c.h.Handle(ctx, r)
+}
+
+func (c *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	component := c.component
+	for _, a := range attrs {
+		if a.Key == "component" {
+			component = a.Value.String()
+		}
+	}
+	return &componentHandler{h: c.h.WithAttrs(attrs), out: c.out, component: component}
+}
+
+func (c *componentHandler) WithGroup(name string) slog.Handler {
+	return &componentHandler{h: c.h.WithGroup(name), out: c.out, component: c.component}
+}
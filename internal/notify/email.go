@@ -0,0 +1,239 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// maxAttachmentBytes bounds the images attached to one email. Many mail
+// servers refuse messages over 25 MB, and base64 adds a third.
+const maxAttachmentBytes = 15 << 20
+
+// Email sends messages through an SMTP server. The config is re-read for
+// every message, so a reload takes effect for the next one.
+type Email struct {
+	cfg func() config.EmailConfig
+	log *slog.Logger
+}
+
+// NewEmail returns an email backend.
+func NewEmail(cfg func() config.EmailConfig, logger *slog.Logger) *Email {
+	return &Email{cfg: cfg, log: logger.With("component", "email")}
+}
+
+// Name returns "email".
+func (e *Email) Name() string { return "email" }
+
+// Enabled reports whether notify.email.enabled is set.
+func (e *Email) Enabled() bool { return e.cfg().Enabled }
+
+// Accepts reports whether kind is a test or in notify.email.events.
+func (e *Email) Accepts(kind string) bool { return routed(e.cfg().Events, kind) }
+
+// Limits returns the quiet hours and limits set under notify.email.
+func (e *Email) Limits() Limits {
+	c := e.cfg()
+	return limitsFrom(c.QuietHours, c.MaxPerHour, c.DedupeMinutes)
+}
+
+// Send mails m to every address in notify.email.to.
+func (e *Email) Send(ctx context.Context, m Message) error {
+	cfg := e.cfg()
+	images := m.Images
+	if !cfg.Images {
+		images = nil
+	}
+	msg, err := e.compose(cfg, m, images)
+	if err != nil {
+		return err
+	}
+	return sendMail(ctx, cfg, msg)
+}
+
+// compose renders m as a MIME message: the text, then each image as an
+// attachment while they fit in maxAttachmentBytes.
+func (e *Email) compose(cfg config.EmailConfig, m Message, images []string) ([]byte, error) {
+	subject := m.Subject
+	if subject == "" {
+		subject, _, _ = strings.Cut(m.Text, "\n")
+	}
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", messageID(cfg.From))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mw.Boundary())
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := io.WriteString(qp, strings.ReplaceAll(m.Text, "\n", "\r\n")); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+
+	var attached int64
+	for _, img := range images {
+		b, err := os.ReadFile(img)
+		if err != nil {
+			e.log.Warn("could not attach image", "file", img, "err", err)
+			continue
+		}
+		if attached+int64(len(b)) > maxAttachmentBytes {
+			e.log.Warn("image not attached; the email is full", "file", img)
+			continue
+		}
+		attached += int64(len(b))
+		name := filepath.Base(img)
+		ctype := mime.TypeByExtension(filepath.Ext(name))
+		if ctype == "" {
+			ctype = "application/octet-stream"
+		}
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {mime.FormatMediaType(ctype, map[string]string{"name": name})},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": name})},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64Lines(part, b); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64Lines writes b base64-encoded in lines of 76 characters, as
+// RFC 2045 requires.
+func writeBase64Lines(w io.Writer, b []byte) error {
+	enc := base64.StdEncoding.EncodeToString(b)
+	for len(enc) > 0 {
+		n := min(76, len(enc))
+		if _, err := io.WriteString(w, enc[:n]+"\r\n"); err != nil {
+			return err
+		}
+		enc = enc[n:]
+	}
+	return nil
+}
+
+// messageID returns a unique Message-ID in the sender's domain.
+func messageID(from string) string {
+	domain := "localhost"
+	if a, err := mail.ParseAddress(from); err == nil {
+		if _, d, ok := strings.Cut(a.Address, "@"); ok {
+			domain = d
+		}
+	}
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return "<" + hex.EncodeToString(b[:]) + "@" + domain + ">"
+}
+
+// sendMail delivers msg to cfg.To through the SMTP server, giving up when
+// ctx is done.
+func sendMail(ctx context.Context, cfg config.EmailConfig, msg []byte) error {
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	// Closing the connection unblocks the client if ctx is cancelled.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+	if cfg.TLS == "tls" {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	c, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer c.Close()
+	if cfg.TLS == "starttls" {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("smtp: the server does not offer STARTTLS; set notify.email.tls")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("smtp: starttls: %w", err)
+		}
+	}
+	if cfg.Username != "" {
+		// PlainAuth refuses to send the password unencrypted, except to
+		// localhost.
+		if err := c.Auth(smtp.PlainAuth("", cfg.Username, cfg.Password.Value(), cfg.Host)); err != nil {
+			return fmt.Errorf("smtp: auth: %w", err)
+		}
+	}
+
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return fmt.Errorf("smtp: from: %w", err)
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	for _, to := range cfg.To {
+		a, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("smtp: to %q: %w", to, err)
+		}
+		if err := c.Rcpt(a.Address); err != nil {
+			return fmt.Errorf("smtp: %s: %w", a.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return c.Quit()
+}
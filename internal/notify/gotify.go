@@ -0,0 +1,64 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// Gotify pushes messages to a Gotify server as an application. Gotify
+// messages carry no attachments, so images are not sent. The config is
+// re-read for every message, so a reload takes effect for the next one.
+type Gotify struct {
+	cfg    func() config.GotifyConfig
+	client *http.Client
+}
+
+// NewGotify returns a Gotify backend.
+func NewGotify(cfg func() config.GotifyConfig) *Gotify {
+	return &Gotify{cfg: cfg, client: &http.Client{}}
+}
+
+// Name returns "gotify".
+func (g *Gotify) Name() string { return "gotify" }
+
+// Enabled reports whether notify.gotify.enabled is set.
+func (g *Gotify) Enabled() bool { return g.cfg().Enabled }
+
+// Accepts reports whether kind is a test or in notify.gotify.events.
+func (g *Gotify) Accepts(kind string) bool { return routed(g.cfg().Events, kind) }
+
+// Limits returns the quiet hours and limits set under notify.gotify.
+func (g *Gotify) Limits() Limits {
+	c := g.cfg()
+	return limitsFrom(c.QuietHours, c.MaxPerHour, c.DedupeMinutes)
+}
+
+// Send posts m as a message of the application.
+func (g *Gotify) Send(ctx context.Context, m Message) error {
+	cfg := g.cfg()
+	title := m.Subject
+	if title == "" {
+		title = "Ephemeris Engine"
+	}
+	body, err := json.Marshal(map[string]any{
+		"title":    title,
+		"message":  m.Text,
+		"priority": cfg.Priority,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(cfg.Server, "/")+"/message", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("gotify: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", cfg.Token.Value())
+	return pushResponse("gotify", g.client, req)
+}
@@ -0,0 +1,322 @@
+// Package notify sends pass results, failed captures and a daily summary
+// to the services an operator reads: a Telegram chat, email, or a push
+// service such as ntfy or Gotify. Each backend is sent the kinds of message
+// its config lists. Messages are queued and sent in the background, so a
+// slow or unreachable service never holds up the scheduler.
+//
+// A backend may hold back queued messages during its quiet hours, when it
+// was sent the same news a short while ago, or past a cap per hour, so a
+// failure that repeats every pass overnight does not page anyone fifty
+// times. What was held back is counted and reported in the daily summary.
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// Kinds of message.
+const (
+	KindPass    = "pass"    // a capture was decoded
+	KindFailure = "failure" // a capture failed
+	KindSummary = "summary" // the passes of the last day
+	KindTest    = "test"    // sent on request to check the settings
+)
+
+// queueSize is how many messages may wait to be sent before new ones are
+// dropped.
+const queueSize = 64
+
+// sendTimeout bounds one message to one backend, image included.
+const sendTimeout = 2 * time.Minute
+
+// heldKept is how long held-back messages are remembered for Held.
+const heldKept = 48 * time.Hour
+
+// Reasons a message was held back.
+const (
+	HeldQuiet     = "quiet_hours" // in the backend's quiet hours
+	HeldDuplicate = "duplicate"   // the backend was sent the same news recently
+	HeldRate      = "rate_limit"  // the backend reached its cap for the hour
+)
+
+// Message is one notification.
+type Message struct {
+	Kind    string
+	Subject string // a one-line title, for backends that show one
+	Text    string
+	Images  []string // paths of images to attach, best first
+	// Key identifies the news the message carries, so that repeats of it
+	// can be held back; "" uses the kind and text.
+	Key string
+}
+
+// Limits says which queued messages a backend holds back. The zero value
+// holds back none.
+type Limits struct {
+	QuietHours config.DailyWindow
+	Quiet      bool // QuietHours is set
+	MaxPerHour int  // 0 is no cap
+	Dedupe     time.Duration
+}
+
+// limitsFrom returns the Limits set by a backend's config, which Validate
+// has checked.
+func limitsFrom(quietHours string, maxPerHour, dedupeMinutes int) Limits {
+	l := Limits{MaxPerHour: maxPerHour, Dedupe: time.Duration(dedupeMinutes) * time.Minute}
+	if w, err := config.ParseDailyWindow(quietHours); quietHours != "" && err == nil {
+		l.QuietHours, l.Quiet = w, true
+	}
+	return l
+}
+
+// Backend is a service messages are sent to.
+type Backend interface {
+	Name() string
+	// Enabled reports whether the backend is configured to send.
+	Enabled() bool
+	// Accepts reports whether the backend sends messages of kind.
+	Accepts(kind string) bool
+	// Limits reports which queued messages the backend holds back.
+	Limits() Limits
+	Send(ctx context.Context, m Message) error
+}
+
+// Status describes one backend and what it has sent since the daemon
+// started.
+type Status struct {
+	Name        string `json:"name"`
+	Enabled     bool   `json:"enabled"`
+	Sent        int    `json:"sent"`
+	Failed      int    `json:"failed"`
+	Dropped     int    `json:"dropped"` // not sent because the queue was full
+	Held        int    `json:"held"`    // held back by quiet hours or limits
+	Quiet       bool   `json:"quiet"`   // in quiet hours now
+	LastSent    string `json:"last_sent,omitempty"`
+	LastError   string `json:"last_error,omitempty"`
+	LastErrorAt string `json:"last_error_at,omitempty"`
+}
+
+// Result is how sending one message to one backend ended.
+type Result struct {
+	Backend string `json:"backend"`
+	Error   string `json:"error,omitempty"`
+	Held    string `json:"held,omitempty"` // why the message was held back, if it was
+}
+
+// Notifier queues messages and sends each to every enabled backend.
+type Notifier struct {
+	log      *slog.Logger
+	loc      func() *time.Location
+	backends []Backend
+	done     func(m Message, res Result)
+	queue    chan Message
+
+	mu     sync.Mutex
+	status map[string]*Status
+	recent map[string]*recent
+	held   []heldMessage
+}
+
+// recent is what a backend was sent lately, for its limits.
+type recent struct {
+	sent []time.Time          // within the last hour
+	keys map[string]time.Time // when each key was last sent
+}
+
+// heldMessage is one message held back from one backend.
+type heldMessage struct {
+	at     time.Time
+	reason string
+}
+
+// New returns a Notifier for backends. loc returns the time zone quiet
+// hours are in. done, if set, is called after each message is sent to a
+// backend, fails to be, or is held back.
+func New(logger *slog.Logger, loc func() *time.Location, done func(m Message, res Result), backends ...Backend) *Notifier {
+	n := &Notifier{
+		log:      logger.With("component", "notify"),
+		loc:      loc,
+		backends: backends,
+		done:     done,
+		queue:    make(chan Message, queueSize),
+		status:   map[string]*Status{},
+		recent:   map[string]*recent{},
+	}
+	for _, b := range backends {
+		n.status[b.Name()] = &Status{Name: b.Name()}
+		n.recent[b.Name()] = &recent{keys: map[string]time.Time{}}
+	}
+	return n
+}
+
+// Notify queues m for every enabled backend that accepts its kind. It
+// never blocks: when the queue is full the message is dropped.
+func (n *Notifier) Notify(m Message) {
+	select {
+	case n.queue <- m:
+	default:
+		n.mu.Lock()
+		for _, b := range n.backends {
+			if wants(b, m) {
+				n.status[b.Name()].Dropped++
+			}
+		}
+		n.mu.Unlock()
+		n.log.Warn("queue full; dropping notification", "kind", m.Kind)
+	}
+}
+
+// Run sends queued messages until ctx is cancelled.
+func (n *Notifier) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m := <-n.queue:
+			for _, b := range n.backends {
+				if !wants(b, m) {
+					continue
+				}
+				if reason := n.hold(b, m, time.Now()); reason != "" {
+					n.log.Info("held back notification", "backend", b.Name(), "kind", m.Kind, "reason", reason)
+					if n.done != nil {
+						n.done(m, Result{Backend: b.Name(), Held: reason})
+					}
+					continue
+				}
+				n.send(ctx, b, m)
+			}
+		}
+	}
+}
+
+// SendNow sends m to every enabled backend that accepts its kind, without
+// queueing or limits, and reports how each went.
+func (n *Notifier) SendNow(ctx context.Context, m Message) []Result {
+	out := []Result{}
+	for _, b := range n.backends {
+		if wants(b, m) {
+			out = append(out, n.send(ctx, b, m))
+		}
+	}
+	return out
+}
+
+// hold decides whether b holds back m at now, and returns why, or "" to
+// send it, in which case it counts against b's limits. Summaries and tests
+// are never held back.
+func (n *Notifier) hold(b Backend, m Message, now time.Time) string {
+	if m.Kind == KindSummary || m.Kind == KindTest {
+		return ""
+	}
+	lim := b.Limits()
+	key := m.Key
+	if key == "" {
+		key = m.Kind + "\x00" + m.Text
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	r := n.recent[b.Name()]
+	r.sent = slices.DeleteFunc(r.sent, func(t time.Time) bool { return now.Sub(t) >= time.Hour })
+	for k, t := range r.keys {
+		if now.Sub(t) >= lim.Dedupe {
+			delete(r.keys, k)
+		}
+	}
+
+	reason := ""
+	switch {
+	case lim.Quiet && lim.QuietHours.Contains(now.In(n.loc())):
+		reason = HeldQuiet
+	case lim.Dedupe > 0 && !r.keys[key].IsZero():
+		reason = HeldDuplicate
+	case lim.MaxPerHour > 0 && len(r.sent) >= lim.MaxPerHour:
+		reason = HeldRate
+	}
+	if reason != "" {
+		n.status[b.Name()].Held++
+		n.held = slices.DeleteFunc(n.held, func(h heldMessage) bool { return now.Sub(h.at) >= heldKept })
+		n.held = append(n.held, heldMessage{at: now, reason: reason})
+		return reason
+	}
+	r.sent = append(r.sent, now)
+	if lim.Dedupe > 0 {
+		r.keys[key] = now
+	}
+	return ""
+}
+
+// Held counts the messages held back since since, by reason. Only the
+// last two days are remembered.
+func (n *Notifier) Held(since time.Time) map[string]int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := map[string]int{}
+	for _, h := range n.held {
+		if !h.at.Before(since) {
+			out[h.reason]++
+		}
+	}
+	return out
+}
+
+// wants reports whether b should be sent m.
+func wants(b Backend, m Message) bool {
+	return b.Enabled() && b.Accepts(m.Kind)
+}
+
+// routed reports whether a backend configured with events takes kind.
+// Tests go to every backend.
+func routed(events []string, kind string) bool {
+	return kind == KindTest || slices.Contains(events, kind)
+}
+
+// Status reports every backend, in the order they were given to New.
+func (n *Notifier) Status() []Status {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	out := make([]Status, len(n.backends))
+	now := time.Now().In(n.loc())
+	for i, b := range n.backends {
+		out[i] = *n.status[b.Name()]
+		out[i].Enabled = b.Enabled()
+		lim := b.Limits()
+		out[i].Quiet = lim.Quiet && lim.QuietHours.Contains(now)
+	}
+	return out
+}
+
+func (n *Notifier) send(ctx context.Context, b Backend, m Message) Result {
+	sctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	err := b.Send(sctx, m)
+	cancel()
+
+	res := Result{Backend: b.Name()}
+	now := time.Now().UTC().Format(time.RFC3339)
+	n.mu.Lock()
+	st := n.status[b.Name()]
+	if err != nil {
+		res.Error = err.Error()
+		st.Failed++
+		st.LastError, st.LastErrorAt = res.Error, now
+	} else {
+		st.Sent++
+		st.LastSent = now
+	}
+	n.mu.Unlock()
+
+	if err != nil {
+		n.log.Warn("could not send notification", "backend", b.Name(), "kind", m.Kind, "err", err)
+	}
+	if n.done != nil {
+		n.done(m, res)
+	}
+	return res
+}
@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// ntfyTags are the emoji shown with each kind of message, by ntfy's tag
+// names.
+var ntfyTags = map[string]string{
+	KindPass:    "satellite",
+	KindFailure: "warning",
+	KindSummary: "spiral_notepad",
+	KindTest:    "white_check_mark",
+}
+
+// Ntfy publishes messages to a topic on an ntfy server. The config is
+// re-read for every message, so a reload takes effect for the next one.
+type Ntfy struct {
+	cfg    func() config.NtfyConfig
+	client *http.Client
+}
+
+// NewNtfy returns an ntfy backend.
+func NewNtfy(cfg func() config.NtfyConfig) *Ntfy {
+	return &Ntfy{cfg: cfg, client: &http.Client{}}
+}
+
+// Name returns "ntfy".
+func (n *Ntfy) Name() string { return "ntfy" }
+
+// Enabled reports whether notify.ntfy.enabled is set.
+func (n *Ntfy) Enabled() bool { return n.cfg().Enabled }
+
+// Accepts reports whether kind is a test or in notify.ntfy.events.
+func (n *Ntfy) Accepts(kind string) bool { return routed(n.cfg().Events, kind) }
+
+// Limits returns the quiet hours and limits set under notify.ntfy.
+func (n *Ntfy) Limits() Limits {
+	c := n.cfg()
+	return limitsFrom(c.QuietHours, c.MaxPerHour, c.DedupeMinutes)
+}
+
+// Send publishes m to the topic, with its first image attached when it has
+// one and images are on.
+func (n *Ntfy) Send(ctx context.Context, m Message) error {
+	cfg := n.cfg()
+	endpoint := strings.TrimRight(cfg.Server, "/") + "/" + cfg.Topic
+
+	var body io.Reader = strings.NewReader(m.Text)
+	size := int64(len(m.Text))
+	header := http.Header{}
+	if len(m.Images) > 0 && cfg.Images {
+		f, err := os.Open(m.Images[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		body, size = f, fi.Size()
+		header.Set("Filename", filepath.Base(m.Images[0]))
+		// Headers are ASCII; ntfy decodes RFC 2047 encoded words.
+		header.Set("Message", mime.BEncoding.Encode("utf-8", m.Text))
+	}
+	if m.Subject != "" {
+		header.Set("Title", mime.BEncoding.Encode("utf-8", m.Subject))
+	}
+	if tag := ntfyTags[m.Kind]; tag != "" {
+		header.Set("Tags", tag)
+	}
+	if cfg.Priority > 0 {
+		header.Set("Priority", strconv.Itoa(cfg.Priority))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("ntfy: %w", err)
+	}
+	req.Header = header
+	req.ContentLength = size
+	switch {
+	case cfg.Token != "":
+		req.Header.Set("Authorization", "Bearer "+cfg.Token.Value())
+	case cfg.Username != "":
+		req.SetBasicAuth(cfg.Username, cfg.Password.Value())
+	}
+	return pushResponse("ntfy", n.client, req)
+}
+
+// pushResponse sends req and turns a refusal into an error with the
+// server's explanation, which ntfy and Gotify give as JSON.
+func pushResponse(service string, client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", service, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		return nil
+	}
+	var e struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"errorDescription"`
+	}
+	_ = json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&e)
+	msg := e.ErrorDescription
+	if msg == "" {
+		msg = e.Error
+	}
+	if msg == "" {
+		return fmt.Errorf("%s: %s", service, resp.Status)
+	}
+	return fmt.Errorf("%s: %s: %s", service, resp.Status, msg)
+}
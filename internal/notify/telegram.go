@@ -0,0 +1,261 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// Telegram's limits on message text and photo captions, in characters.
+const (
+	telegramMaxText    = 4096
+	telegramMaxCaption = 1024
+)
+
+// telegramPollTimeout is how long a getUpdates call waits for a message.
+const telegramPollTimeout = 50 * time.Second
+
+// telegramRetryDelay is the wait before polling again after an error, or
+// before checking again whether commands have been turned on.
+const telegramRetryDelay = 30 * time.Second
+
+// telegramMaxCommandAge is how old a command may be and still be answered.
+// Commands sent while the daemon was down are dropped rather than acted on
+// long after the fact.
+const telegramMaxCommandAge = 2 * time.Minute
+
+// CommandFunc answers the bot command cmd, such as "status" for /status,
+// with the text to reply with.
+type CommandFunc func(cmd string) string
+
+// Telegram sends messages to a chat through the Telegram Bot API and
+// answers bot commands from authorized users. The config is re-read for
+// every call, so a reload takes effect for the next message.
+type Telegram struct {
+	cfg    func() config.TelegramConfig
+	log    *slog.Logger
+	client *http.Client
+}
+
+// NewTelegram returns a Telegram backend.
+func NewTelegram(cfg func() config.TelegramConfig, logger *slog.Logger) *Telegram {
+	return &Telegram{
+		cfg:    cfg,
+		log:    logger.With("component", "telegram"),
+		client: &http.Client{Timeout: sendTimeout + telegramPollTimeout},
+	}
+}
+
+// Name returns "telegram".
+func (t *Telegram) Name() string { return "telegram" }
+
+// Enabled reports whether notify.telegram.enabled is set.
+func (t *Telegram) Enabled() bool { return t.cfg().Enabled }
+
+// Accepts reports whether kind is a test or in notify.telegram.events.
+func (t *Telegram) Accepts(kind string) bool { return routed(t.cfg().Events, kind) }
+
+// Limits returns the quiet hours and limits set under notify.telegram.
+func (t *Telegram) Limits() Limits {
+	c := t.cfg()
+	return limitsFrom(c.QuietHours, c.MaxPerHour, c.DedupeMinutes)
+}
+
+// Send posts m to the configured chat, as its first image with m.Text as
+// the caption when it has one and images are on.
+func (t *Telegram) Send(ctx context.Context, m Message) error {
+	cfg := t.cfg()
+	if len(m.Images) > 0 && cfg.Images {
+		return t.sendPhoto(ctx, cfg, cfg.ChatID, m.Text, m.Images[0])
+	}
+	return t.sendMessage(ctx, cfg, cfg.ChatID, m.Text, 0)
+}
+
+func (t *Telegram) sendMessage(ctx context.Context, cfg config.TelegramConfig, chatID, text string, replyTo int64) error {
+	form := url.Values{
+		"chat_id": {chatID},
+		"text":    {truncate(text, telegramMaxText)},
+	}
+	if replyTo != 0 {
+		form.Set("reply_to_message_id", strconv.FormatInt(replyTo, 10))
+	}
+	return t.call(ctx, cfg, "sendMessage", "application/x-www-form-urlencoded", strings.NewReader(form.Encode()), nil)
+}
+
+func (t *Telegram) sendPhoto(ctx context.Context, cfg config.TelegramConfig, chatID, caption, image string) error {
+	f, err := os.Open(image)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("chat_id", chatID)
+	_ = mw.WriteField("caption", truncate(caption, telegramMaxCaption))
+	part, err := mw.CreateFormFile("photo", filepath.Base(image))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return err
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+	return t.call(ctx, cfg, "sendPhoto", mw.FormDataContentType(), &body, nil)
+}
+
+// telegramResponse is the envelope of every Bot API response.
+type telegramResponse struct {
+	OK          bool            `json:"ok"`
+	Description string          `json:"description"`
+	Result      json.RawMessage `json:"result"`
+}
+
+// call invokes the Bot API method and decodes its result into out.
+func (t *Telegram) call(ctx context.Context, cfg config.TelegramConfig, method, ctype string, body io.Reader, out any) error {
+	endpoint := strings.TrimRight(cfg.APIURL, "/") + "/bot" + cfg.BotToken.Value() + "/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("telegram %s: bad request", method)
+	}
+	req.Header.Set("Content-Type", ctype)
+	resp, err := t.client.Do(req)
+	if err != nil {
+		// The URL holds the bot token; keep it out of the error.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return fmt.Errorf("telegram %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var r telegramResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&r); err != nil {
+		return fmt.Errorf("telegram %s: %s", method, resp.Status)
+	}
+	if !r.OK {
+		return fmt.Errorf("telegram %s: %s", method, r.Description)
+	}
+	if out != nil {
+		return json.Unmarshal(r.Result, out)
+	}
+	return nil
+}
+
+// telegramUpdate is the part of a Bot API update the bot reads.
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		MessageID int64  `json:"message_id"`
+		Date      int64  `json:"date"`
+		Text      string `json:"text"`
+		From      *struct {
+			ID       int64  `json:"id"`
+			Username string `json:"username"`
+		} `json:"from"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+// Poll answers bot commands with handle until ctx is cancelled. It waits
+// while Telegram or notify.telegram.commands is off.
+func (t *Telegram) Poll(ctx context.Context, handle CommandFunc) {
+	var offset int64
+	for {
+		cfg := t.cfg()
+		if !cfg.Enabled || !cfg.Commands {
+			if !sleep(ctx, telegramRetryDelay) {
+				return
+			}
+			continue
+		}
+
+		form := url.Values{
+			"offset":          {strconv.FormatInt(offset, 10)},
+			"timeout":         {strconv.Itoa(int(telegramPollTimeout.Seconds()))},
+			"allowed_updates": {`["message"]`},
+		}
+		var updates []telegramUpdate
+		err := t.call(ctx, cfg, "getUpdates", "application/x-www-form-urlencoded", strings.NewReader(form.Encode()), &updates)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			t.log.Warn("could not fetch commands", "err", err)
+			if !sleep(ctx, telegramRetryDelay) {
+				return
+			}
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			t.answer(ctx, cfg, u, handle)
+		}
+	}
+}
+
+// answer replies to the command in u, if it is one from an authorized user.
+func (t *Telegram) answer(ctx context.Context, cfg config.TelegramConfig, u telegramUpdate, handle CommandFunc) {
+	m := u.Message
+	if m == nil || m.From == nil || !strings.HasPrefix(m.Text, "/") {
+		return
+	}
+	if time.Since(time.Unix(m.Date, 0)) > telegramMaxCommandAge {
+		return
+	}
+	// "/status@ExampleBot extra" is the command "status".
+	cmd, _, _ := strings.Cut(strings.TrimPrefix(m.Text, "/"), " ")
+	cmd, _, _ = strings.Cut(cmd, "@")
+	cmd = strings.ToLower(cmd)
+	if !slices.Contains(cfg.AllowedUsers, m.From.ID) {
+		t.log.Warn("ignoring command from a user not in allowed_users", "command", cmd, "user", m.From.ID, "username", m.From.Username)
+		return
+	}
+	t.log.Info("command", "command", cmd, "user", m.From.ID)
+	reply := handle(cmd)
+	chatID := strconv.FormatInt(m.Chat.ID, 10)
+	if err := t.sendMessage(ctx, cfg, chatID, reply, m.MessageID); err != nil {
+		t.log.Warn("could not reply to command", "command", cmd, "err", err)
+	}
+}
+
+// truncate shortens s to at most n characters.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// sleep waits for d, returning false if ctx is cancelled first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
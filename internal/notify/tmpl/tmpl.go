@@ -0,0 +1,194 @@
+// Package tmpl renders the text of notifications from Go templates, the
+// built-in ones or those set under [notify.templates]. It has no other
+// dependencies, so the config can check templates when it is loaded.
+package tmpl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Kinds of message that may be templated.
+const (
+	KindPass    = "pass"
+	KindFailure = "failure"
+	KindSummary = "summary"
+)
+
+// Kinds are the kinds of message that may be templated.
+var Kinds = []string{KindPass, KindFailure, KindSummary}
+
+// Pass is the data of the pass template: a decoded capture. Times are in
+// the station's time zone.
+type Pass struct {
+	Satellite string
+	Station   string // station profile, if any
+	AOS       time.Time
+	LOS       time.Time
+	MaxElev   float64
+	File      string   // capture file name
+	Images    []string // decoded image file names
+	Image     string   // the best of Images, or ""
+	ImageURL  string   // Image on the daemon, when notify.base_url is set
+}
+
+// Failure is the data of the failure template: a failed capture.
+type Failure struct {
+	Satellite string
+	Time      time.Time // when it failed, in the station's time zone
+	Error     string
+}
+
+// Summary is the data of the summary template: the passes between Since
+// and Until, oldest first.
+type Summary struct {
+	Since    time.Time
+	Until    time.Time
+	Captured int
+	Failed   int
+	Passes   []SummaryPass
+	Attached int // how many images are attached
+	// Held counts the notifications held back by quiet hours or limits,
+	// and HeldBy breaks it down by reason: quiet_hours, duplicate or
+	// rate_limit.
+	Held   int
+	HeldBy map[string]int
+}
+
+// SummaryPass is one pass in a Summary.
+type SummaryPass struct {
+	Satellite string
+	AOS       time.Time
+	MaxElev   float64
+	Outcome   string // captured, failed, cancelled or skipped
+	Error     string
+	Image     string // best image file name, for a capture with one
+	ImageURL  string
+}
+
+// Built-in templates, used where [notify.templates] sets none.
+const (
+	DefaultPass = `{{.Satellite}} pass decoded
+{{.AOS.Format "Mon 2 Jan 15:04 MST"}}, max elevation {{round .MaxElev}}°
+{{- if not .Image}}
+No image was decoded.{{end}}`
+
+	DefaultFailure = `{{.Satellite}} capture failed: {{.Error}}`
+
+	DefaultSummary = `Passes from {{.Since.Format "Mon 2 Jan 15:04"}} to {{.Until.Format "Mon 2 Jan 15:04 MST"}}:
+
+{{range .Passes -}}
+{{.AOS.Format "Mon 15:04"}}  {{printf "%-10s" .Satellite}} {{printf "%3.0f" .MaxElev}}°  {{.Outcome}}
+{{- if .Error}}: {{.Error}}{{else if and (eq .Outcome "captured") (not .Image)}}, no image{{end}}
+{{else -}}
+No passes.
+{{end}}
+Captured: {{.Captured}}
+Failed: {{.Failed}}
+{{- if .Held}}
+Notifications held back: {{.Held}}{{end}}
+{{- if .Attached}}
+
+The best images are attached.{{end}}`
+)
+
+// Default returns the built-in template for kind.
+func Default(kind string) string {
+	switch kind {
+	case KindPass:
+		return DefaultPass
+	case KindFailure:
+		return DefaultFailure
+	case KindSummary:
+		return DefaultSummary
+	}
+	return ""
+}
+
+// funcs are the functions templates may call besides the built-in ones.
+var funcs = template.FuncMap{
+	"round": func(f float64) int { return int(math.Round(f)) },
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Render executes text, or the built-in template for kind if text is "",
+// over data.
+func Render(kind, text string, data any) (string, error) {
+	if text == "" {
+		text = Default(kind)
+	}
+	t, err := parse(kind, text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// Check parses text and executes it over Sample(kind), so that a field
+// that does not exist is found when the config is loaded rather than when
+// the first message is sent.
+func Check(kind, text string) error {
+	t, err := parse(kind, text)
+	if err != nil {
+		return err
+	}
+	if err := t.Execute(io.Discard, Sample(kind)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func parse(kind, text string) (*template.Template, error) {
+	t, err := template.New(kind).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template: %w", err)
+	}
+	return t, nil
+}
+
+// Sample returns example data for kind, for checking templates and for
+// test messages when there is nothing real to show.
+func Sample(kind string) any {
+	aos := time.Date(2026, 2, 15, 14, 30, 22, 0, time.UTC)
+	switch kind {
+	case KindPass:
+		return Pass{
+			Satellite: "NOAA-19",
+			AOS:       aos,
+			LOS:       aos.Add(14 * time.Minute),
+			MaxElev:   54.2,
+			File:      "NOAA-19_20260215T143022Z.wav",
+			Images:    []string{"NOAA-19_20260215T143022Z-A.png"},
+			Image:     "NOAA-19_20260215T143022Z-A.png",
+			ImageURL:  "http://ephemeris.local:8080/api/captures/file?name=NOAA-19_20260215T143022Z-A.png",
+		}
+	case KindFailure:
+		return Failure{Satellite: "NOAA-19", Time: aos, Error: "sdr: usb device not found"}
+	case KindSummary:
+		return Summary{
+			Since:    aos.Add(-24 * time.Hour),
+			Until:    aos,
+			Captured: 1,
+			Failed:   1,
+			Passes: []SummaryPass{
+				{Satellite: "METEOR-M2 3", AOS: aos.Add(-20 * time.Hour), MaxElev: 31, Outcome: "failed", Error: "sdr: usb device not found"},
+				{Satellite: "NOAA-19", AOS: aos.Add(-2 * time.Hour), MaxElev: 54.2, Outcome: "captured", Image: "NOAA-19_20260215T123022Z-A.png"},
+			},
+			Attached: 1,
+			Held:     3,
+			HeldBy:   map[string]int{"quiet_hours": 2, "duplicate": 1},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,481 @@
+// Package plugin runs user programs that consume the daemon's telemetry
+// events, so automations such as forum posts or LED displays can be added
+// without changing daemon code.
+//
+// A plugin is any executable. The daemon starts it and writes one
+// JSON-RPC 2.0 notification per line to its stdin for each event:
+//
+//	{"jsonrpc":"2.0","method":"event","params":{"type":"state","to":"RECORDING",...}}
+//
+// The event is exactly what WebSocket clients receive. A plugin may write
+// messages back on stdout; "log" with params {"level","message"} adds a
+// line to the daemon log. A plugin configured with control = true may also
+// send requests for the daemon's limited control API (see ControlFunc),
+// and gets a response on stdin like any JSON-RPC call. Whatever the plugin
+// writes to stderr goes to the daemon's own log output. Closing stdin (or
+// SIGTERM) asks the plugin to exit; one that crashes is restarted with
+// backoff.
+package plugin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/ws"
+)
+
+const (
+	// queueSize is how many events a plugin may fall behind by before
+	// events are dropped for it.
+	queueSize = 256
+	// stopTimeout is how long a plugin has to exit after SIGTERM before it
+	// is killed.
+	stopTimeout = 5 * time.Second
+	// maxBackoff caps the delay before restarting a crashed plugin. A run
+	// that lasted longer than this resets the delay.
+	maxBackoff = time.Minute
+	// maxLineSize bounds one message from a plugin.
+	maxLineSize = 64 << 10
+)
+
+// Status describes one configured plugin.
+type Status struct {
+	Name      string   `json:"name"`
+	Command   []string `json:"command"`
+	Events    []string `json:"events,omitempty"`
+	Control   bool     `json:"control,omitempty"`
+	Running   bool     `json:"running"`
+	PID       int      `json:"pid,omitempty"`
+	StartedAt string   `json:"started_at,omitempty"`
+	Restarts  int      `json:"restarts"`
+	Delivered uint64   `json:"delivered"`
+	Dropped   uint64   `json:"dropped"`
+	LastError string   `json:"last_error,omitempty"`
+}
+
+// EmitFunc publishes a daemon event, as App.emit does.
+type EmitFunc func(component string, payload map[string]any)
+
+// ControlFunc serves a control API request from plugin. It returns
+// ErrUnknownMethod for methods outside the API.
+type ControlFunc func(plugin, method string, params json.RawMessage) (json.RawMessage, error)
+
+// ErrUnknownMethod is returned by a ControlFunc for a method it does not
+// provide.
+var ErrUnknownMethod = errors.New("method not found")
+
+// JSON-RPC 2.0 error codes.
+const (
+	codeMethodNotFound = -32601
+	codeServerError    = -32000
+)
+
+// Manager keeps the configured plugins running and feeds them events.
+type Manager struct {
+	hub     *ws.Hub
+	log     *slog.Logger
+	emit    EmitFunc
+	control ControlFunc
+	env     []string // extra environment for every plugin
+
+	mu      sync.Mutex
+	ctx     context.Context // set while Run is active
+	want    []config.PluginConfig
+	running map[string]*instance
+}
+
+// NewManager returns a Manager for hub's events. control serves the
+// control API for plugins allowed to use it. env is added to each plugin's
+// environment, after the daemon's own.
+func NewManager(hub *ws.Hub, logger *slog.Logger, emit EmitFunc, control ControlFunc, env []string) *Manager {
+	return &Manager{
+		hub:     hub,
+		log:     logger.With("component", "plugin"),
+		emit:    emit,
+		control: control,
+		env:     env,
+		running: make(map[string]*instance),
+	}
+}
+
+// Update sets the plugins that should be running. Plugins whose entry is
+// new or changed are (re)started, and ones no longer listed are stopped.
+// It may be called before Run, which then starts them.
+func (m *Manager) Update(cfgs []config.PluginConfig) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.want = append([]config.PluginConfig(nil), cfgs...)
+	if m.ctx != nil {
+		m.reconcileLocked()
+	}
+}
+
+// Run starts the plugins and delivers events to them until ctx is
+// cancelled, then stops them all.
+func (m *Manager) Run(ctx context.Context) {
+	events, cancel := m.hub.Subscribe(queueSize)
+	defer cancel()
+
+	m.mu.Lock()
+	m.ctx = ctx
+	m.reconcileLocked()
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		m.ctx = nil
+		stopping := m.running
+		m.running = make(map[string]*instance)
+		m.mu.Unlock()
+		for _, p := range stopping {
+			p.stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-events:
+			var ev struct {
+				Type   string `json:"type"`
+				Plugin string `json:"plugin"`
+			}
+			_ = json.Unmarshal(msg, &ev)
+			m.mu.Lock()
+			for _, p := range m.running {
+				p.deliver(msg, ev.Type, ev.Plugin)
+			}
+			m.mu.Unlock()
+		}
+	}
+}
+
+// Status reports every configured plugin, in name order.
+func (m *Manager) Status() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Status, 0, len(m.want))
+	for _, c := range m.want {
+		if p, ok := m.running[c.Name]; ok {
+			out = append(out, p.status())
+			continue
+		}
+		out = append(out, Status{Name: c.Name, Command: c.Command, Events: c.Events, Control: c.Control})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (m *Manager) reconcileLocked() {
+	want := make(map[string]config.PluginConfig, len(m.want))
+	for _, c := range m.want {
+		want[c.Name] = c
+	}
+	for name, p := range m.running {
+		if c, ok := want[name]; !ok || !reflect.DeepEqual(c, p.cfg) {
+			p.stop()
+			delete(m.running, name)
+		}
+	}
+	for name, c := range want {
+		if _, ok := m.running[name]; ok {
+			continue
+		}
+		p := newInstance(m, c)
+		m.running[name] = p
+		ctx, cancel := context.WithCancel(m.ctx)
+		p.cancel = cancel
+		go p.run(ctx)
+	}
+}
+
+// instance is one plugin and the supervisor goroutine restarting it.
+type instance struct {
+	m      *Manager
+	cfg    config.PluginConfig
+	events map[string]bool // nil delivers every event
+	queue  chan []byte
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu sync.Mutex
+	st Status
+}
+
+func newInstance(m *Manager, cfg config.PluginConfig) *instance {
+	p := &instance{
+		m:     m,
+		cfg:   cfg,
+		queue: make(chan []byte, queueSize),
+		done:  make(chan struct{}),
+		st:    Status{Name: cfg.Name, Command: cfg.Command, Events: cfg.Events, Control: cfg.Control},
+	}
+	if len(cfg.Events) > 0 {
+		p.events = make(map[string]bool, len(cfg.Events))
+		for _, t := range cfg.Events {
+			p.events[t] = true
+		}
+	}
+	return p
+}
+
+// deliver queues an event for the plugin, dropping it if the plugin has
+// fallen too far behind. A plugin never receives its own log lines back.
+func (p *instance) deliver(msg []byte, eventType, fromPlugin string) {
+	if fromPlugin == p.cfg.Name {
+		return
+	}
+	if p.events != nil && !p.events[eventType] {
+		return
+	}
+	select {
+	case p.queue <- msg:
+	default:
+		p.mu.Lock()
+		p.st.Dropped++
+		first := p.st.Dropped == 1
+		p.mu.Unlock()
+		if first {
+			p.logf("warn", "plugin %s is not keeping up; dropping events", p.cfg.Name)
+		}
+	}
+}
+
+func (p *instance) status() Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.st
+}
+
+// stop terminates the plugin and waits for its supervisor to finish.
+func (p *instance) stop() {
+	p.cancel()
+	<-p.done
+}
+
+// run starts the plugin and restarts it whenever it exits, until ctx is
+// cancelled.
+func (p *instance) run(ctx context.Context) {
+	defer close(p.done)
+
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := p.runOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if time.Since(start) > maxBackoff {
+			backoff = time.Second
+		}
+		if err == nil {
+			err = errors.New("exited")
+		}
+		p.mu.Lock()
+		p.st.Running = false
+		p.st.PID = 0
+		p.st.LastError = err.Error()
+		p.st.Restarts++
+		p.mu.Unlock()
+		p.logf("error", "plugin %s stopped: %v; restarting in %s", p.cfg.Name, err, backoff)
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// runOnce runs the plugin process until it exits or ctx is cancelled.
+func (p *instance) runOnce(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, p.cfg.Command[0], p.cfg.Command[1:]...)
+	cmd.Env = append(append(os.Environ(), p.m.env...), "EPHEMERIS_PLUGIN="+p.cfg.Name)
+	cmd.Cancel = func() error { return cmd.Process.Signal(syscall.SIGTERM) }
+	cmd.WaitDelay = stopTimeout
+	cmd.Stderr = &lineLogger{log: p.m.log.With("plugin", p.cfg.Name)}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.st.Running = true
+	p.st.PID = cmd.Process.Pid
+	p.st.StartedAt = time.Now().UTC().Format(time.RFC3339)
+	p.mu.Unlock()
+	p.logf("info", "plugin %s started (pid %d)", p.cfg.Name, cmd.Process.Pid)
+
+	// Replies to the plugin's requests share stdin with events.
+	var writeMu sync.Mutex
+	write := func(b []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		_, err := stdin.Write(b)
+		return err
+	}
+
+	exited := make(chan struct{})
+	var waitErr error
+	go func() {
+		p.readMessages(stdout, write)
+		waitErr = cmd.Wait()
+		close(exited)
+	}()
+
+	for {
+		select {
+		case <-exited:
+			return waitErr
+		case <-ctx.Done():
+			stdin.Close()
+			<-exited
+			return ctx.Err()
+		case msg := <-p.queue:
+			frame := make([]byte, 0, len(msg)+64)
+			frame = append(frame, `{"jsonrpc":"2.0","method":"event","params":`...)
+			frame = append(frame, msg...)
+			frame = append(frame, "}\n"...)
+			if err := write(frame); err != nil {
+				// The plugin closed stdin or is exiting; report why.
+				stdin.Close()
+				<-exited
+				if waitErr != nil {
+					return waitErr
+				}
+				return err
+			}
+			p.mu.Lock()
+			p.st.Delivered++
+			p.mu.Unlock()
+		}
+	}
+}
+
+// rpcMessage is a JSON-RPC 2.0 message from a plugin.
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// readMessages handles the plugin's stdout until it closes.
+func (p *instance) readMessages(r io.Reader, reply func([]byte) error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 4096), maxLineSize)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var msg rpcMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil || msg.JSONRPC != "2.0" {
+			p.m.log.Warn("ignoring non-JSON-RPC output", "plugin", p.cfg.Name, "line", fmt.Sprintf("%.200s", line))
+			continue
+		}
+		result, err := p.call(msg.Method, msg.Params)
+		if len(msg.ID) == 0 {
+			if err != nil {
+				p.logf("warn", "plugin %s: %s: %v", p.cfg.Name, msg.Method, err)
+			}
+			continue
+		}
+		if err != nil {
+			code := codeServerError
+			if errors.Is(err, ErrUnknownMethod) {
+				code = codeMethodNotFound
+			}
+			errMsg, _ := json.Marshal(err.Error())
+			_ = reply(fmt.Appendf(nil, `{"jsonrpc":"2.0","id":%s,"error":{"code":%d,"message":%s}}`+"\n", msg.ID, code, errMsg))
+			continue
+		}
+		_ = reply(fmt.Appendf(nil, `{"jsonrpc":"2.0","id":%s,"result":%s}`+"\n", msg.ID, result))
+	}
+	// Keep draining so the plugin is never blocked writing to a full pipe.
+	_, _ = io.Copy(io.Discard, r)
+}
+
+// call handles one request or notification from the plugin.
+func (p *instance) call(method string, params json.RawMessage) (json.RawMessage, error) {
+	if method == "log" {
+		var lp struct {
+			Level   string `json:"level"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(params, &lp); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+		if lp.Level != "warn" && lp.Level != "error" {
+			lp.Level = "info"
+		}
+		p.logf(lp.Level, "%s: %s", p.cfg.Name, lp.Message)
+		return json.RawMessage("true"), nil
+	}
+	if !p.cfg.Control || p.m.control == nil {
+		return nil, fmt.Errorf("%w: %s (set control = true to use the control API)", ErrUnknownMethod, method)
+	}
+	if len(params) == 0 || string(params) == "null" {
+		params = json.RawMessage("{}")
+	}
+	return p.m.control(p.cfg.Name, method, params)
+}
+
+// logf emits a log event tagged with the plugin's name.
+func (p *instance) logf(level, format string, args ...any) {
+	p.m.emit("plugin", map[string]any{
+		"type":    "log",
+		"level":   level,
+		"plugin":  p.cfg.Name,
+		"message": fmt.Sprintf(format, args...),
+	})
+}
+
+// lineLogger writes each complete line it receives to a logger.
+type lineLogger struct {
+	log *slog.Logger
+	buf []byte
+}
+
+func (l *lineLogger) Write(b []byte) (int, error) {
+	l.buf = append(l.buf, b...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+		l.log.Info(string(l.buf[:i]))
+		l.buf = l.buf[i+1:]
+	}
+	if len(l.buf) > maxLineSize {
+		l.log.Info(string(l.buf))
+		l.buf = l.buf[:0]
+	}
+	return len(b), nil
+}
@@ -0,0 +1,75 @@
+package predict
+
+import (
+	"math"
+	"time"
+
+	"github.com/akhenakh/sgp4"
+)
+
+// elevation returns the satellite's elevation above the observer at t, in
+// degrees.
+func elevation(tle *sgp4.TLE, observer *sgp4.Location, t time.Time) (float64, error) {
+	el, _, err := lookAngles(tle, observer, t)
+	return el, err
+}
+
+// lookAngles returns the satellite's elevation and azimuth as seen by the
+// observer at t, in degrees.
+func lookAngles(tle *sgp4.TLE, observer *sgp4.Location, t time.Time) (el, az float64, err error) {
+	eci, err := tle.FindPositionAtTime(t)
+	if err != nil {
+		return 0, 0, err
+	}
+	sv := &sgp4.StateVector{X: eci.Position.X, Y: eci.Position.Y, Z: eci.Position.Z}
+	obs, err := sv.GetLookAngle(observer, t)
+	if err != nil {
+		return 0, 0, err
+	}
+	return obs.LookAngles.Elevation, trueAzimuth(obs.LookAngles.Azimuth), nil
+}
+
+// trueAzimuth turns an azimuth from sgp4 into one from true north: its
+// look angles are taken from the south.
+func trueAzimuth(az float64) float64 {
+	return math.Mod(az+180, 360)
+}
+
+// usableWindow returns when pass p is above minElev, which its peak must
+// exceed. The crossings on either side of the peak are found by bisection
+// to within a second. If the track cannot be computed the whole pass is
+// returned.
+func usableWindow(tle *sgp4.TLE, loc Location, p sgp4.PassDetails, minElev float64) (time.Time, time.Time) {
+	observer := &sgp4.Location{Latitude: loc.Lat, Longitude: loc.Lon, Altitude: loc.Alt}
+	above := func(t time.Time) (bool, error) {
+		el, err := elevation(tle, observer, t)
+		return el >= minElev, err
+	}
+	// crossing narrows [below, over], where the satellite is under minElev
+	// at below and above it at over, to the moment it crosses.
+	crossing := func(below, over time.Time) (time.Time, error) {
+		for over.Sub(below).Abs() > time.Second {
+			mid := below.Add(over.Sub(below) / 2)
+			up, err := above(mid)
+			if err != nil {
+				return time.Time{}, err
+			}
+			if up {
+				over = mid
+			} else {
+				below = mid
+			}
+		}
+		return over, nil
+	}
+
+	start, err := crossing(p.AOS, p.MaxElevationTime)
+	if err != nil {
+		return p.AOS, p.LOS
+	}
+	end, err := crossing(p.LOS, p.MaxElevationTime)
+	if err != nil {
+		return p.AOS, p.LOS
+	}
+	return start, end
+}
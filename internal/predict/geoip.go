@@ -0,0 +1,96 @@
+package predict
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// geoIPMaxAge is how long an IP-geolocation result is reused. The answer
+// only changes when the network does, and free services rate-limit hard.
+const geoIPMaxAge = 6 * time.Hour
+
+// geoIPRetryAfter is how long a failed lookup is not retried, so a
+// rate-limited or offline host does not hit the service on every request.
+const geoIPRetryAfter = 10 * time.Minute
+
+var (
+	// lastGeoIP caches the most recent IP-geolocation result.
+	lastGeoIP atomic.Pointer[Fix]
+	// geoIPFailedAt is when the last lookup failed, in Unix nanoseconds.
+	geoIPFailedAt atomic.Int64
+)
+
+// geoIPResponse covers the two common response shapes: ipapi.co and
+// ipwho.is use latitude/longitude, ip-api.com uses lat/lon and reports
+// failures through status/message.
+type geoIPResponse struct {
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	Lat       *float64 `json:"lat"`
+	Lon       *float64 `json:"lon"`
+	Status    string   `json:"status"`
+	Message   string   `json:"message"`
+	Error     bool     `json:"error"`
+	Reason    string   `json:"reason"`
+}
+
+// LocationFromGeoIP looks up the approximate position of this host's public
+// IP address. The result is city-level at best, and can be hundreds of
+// kilometers off behind a VPN or mobile carrier, so it is only a stand-in
+// until real coordinates are configured. Altitude is always zero.
+func LocationFromGeoIP(url string, timeout time.Duration) (Location, error) {
+	if f := lastGeoIP.Load(); f != nil && time.Since(f.At) < geoIPMaxAge {
+		return f.Location, nil
+	}
+	if at := geoIPFailedAt.Load(); at != 0 && time.Since(time.Unix(0, at)) < geoIPRetryAfter {
+		return Location{}, errors.New("geoip lookup failed recently; not retrying yet")
+	}
+
+	loc, err := lookupGeoIP(url, timeout)
+	if err != nil {
+		geoIPFailedAt.Store(time.Now().UnixNano())
+		return Location{}, err
+	}
+	geoIPFailedAt.Store(0)
+	lastGeoIP.Store(&Fix{Location: loc, At: time.Now()})
+	return loc, nil
+}
+
+// lookupGeoIP queries the service at url once.
+func lookupGeoIP(url string, timeout time.Duration) (Location, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return Location{}, fmt.Errorf("geoip lookup: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Location{}, fmt.Errorf("geoip lookup: HTTP %d", resp.StatusCode)
+	}
+
+	var r geoIPResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return Location{}, fmt.Errorf("geoip decode: %w", err)
+	}
+	if r.Error || (r.Status != "" && r.Status != "success") {
+		msg := r.Reason
+		if msg == "" {
+			msg = r.Message
+		}
+		return Location{}, fmt.Errorf("geoip lookup failed: %s", msg)
+	}
+
+	lat, lon := r.Latitude, r.Longitude
+	if lat == nil || lon == nil {
+		lat, lon = r.Lat, r.Lon
+	}
+	if lat == nil || lon == nil {
+		return Location{}, errors.New("geoip lookup: response has no coordinates")
+	}
+
+	return Location{Lat: *lat, Lon: *lon, Source: SourceGeoIP}, nil
+}
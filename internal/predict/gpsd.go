@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"sync/atomic"
 	"time"
 )
 
@@ -13,6 +14,41 @@ type Location struct {
 	Lat float64 // degrees North
 	Lon float64 // degrees East
 	Alt float64 // meters above sea level
+
+	Source string // SourceConfig, SourceGPSD, or SourceGeoIP
+}
+
+// Location sources.
+const (
+	SourceConfig = "config"
+	SourceGPSD   = "gpsd"
+	SourceGeoIP  = "geoip"
+)
+
+// Approximate reports whether the position is a rough IP-based estimate
+// rather than configured or measured coordinates.
+func (l Location) Approximate() bool {
+	return l.Source == SourceGeoIP
+}
+
+// Fix is a gpsd position and the time it was obtained.
+type Fix struct {
+	Location
+	At time.Time
+}
+
+// lastFix holds the most recent successful gpsd fix, so the position can be
+// written back to the config file without querying the receiver again.
+var lastFix atomic.Pointer[Fix]
+
+// LastGPSDFix returns the most recent fix obtained by LocationFromGPSD in
+// this process, if any.
+func LastGPSDFix() (Fix, bool) {
+	f := lastFix.Load()
+	if f == nil {
+		return Fix{}, false
+	}
+	return *f, true
 }
 
 // tpvReport is the subset of a gpsd TPV JSON object we need.
@@ -51,11 +87,15 @@ func LocationFromGPSD(addr string, timeout time.Duration) (Location, error) {
 			continue
 		}
 		if report.Mode >= 2 {
-			return Location{
+			loc := Location{
 				Lat: report.Lat,
 				Lon: report.Lon,
 				Alt: report.Alt,
-			}, nil
+
+				Source: SourceGPSD,
+			}
+			lastFix.Store(&Fix{Location: loc, At: time.Now()})
+			return loc, nil
 		}
 	}
 
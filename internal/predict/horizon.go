@@ -0,0 +1,124 @@
+package predict
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/akhenakh/sgp4"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// horizonStep is how finely a pass is walked for where it clears the
+// horizon mask. The crossings are then narrowed to a second.
+const horizonStep = 5 * time.Second
+
+// horizon is a station's horizon mask, its points sorted by azimuth as
+// config.StationConfig.Horizon returns them. A nil horizon is flat.
+type horizon []config.HorizonPoint
+
+// at returns the elevation of the horizon at azimuth az, interpolated
+// between the points on either side of it, around north too.
+func (h horizon) at(az float64) float64 {
+	switch len(h) {
+	case 0:
+		return 0
+	case 1:
+		return h[0].Elevation
+	}
+	az = math.Mod(az, 360)
+	if az < 0 {
+		az += 360
+	}
+	i := sort.Search(len(h), func(i int) bool { return h[i].Azimuth >= az })
+	lo, hi := h[len(h)-1], h[0]
+	if i > 0 && i < len(h) {
+		lo, hi = h[i-1], h[i]
+	}
+	span := hi.Azimuth - lo.Azimuth
+	if span <= 0 {
+		span += 360
+	}
+	off := az - lo.Azimuth
+	if off < 0 {
+		off += 360
+	}
+	return lo.Elevation + (hi.Elevation-lo.Elevation)*off/span
+}
+
+// mask trims pass p to the part of it above the horizon mask: AOS and LOS
+// become the first and last moments the satellite clears it, and the
+// maximum elevation the highest it is seen at. A pass blocked in the
+// middle is kept whole between them. ok is false when the mask hides the
+// whole pass. If the track cannot be computed p is returned as it is.
+func (h horizon) mask(tle *sgp4.TLE, loc Location, p sgp4.PassDetails) (out sgp4.PassDetails, ok bool) {
+	observer := &sgp4.Location{Latitude: loc.Lat, Longitude: loc.Lon, Altitude: loc.Alt}
+	visible := func(t time.Time) (bool, float64, error) {
+		el, az, err := lookAngles(tle, observer, t)
+		return el >= h.at(az), el, err
+	}
+	// crossing narrows [hidden, seen] to the moment the satellite clears
+	// the mask, from either side.
+	crossing := func(hidden, seen time.Time) time.Time {
+		for seen.Sub(hidden).Abs() > time.Second {
+			mid := hidden.Add(seen.Sub(hidden) / 2)
+			if up, _, err := visible(mid); err == nil && up {
+				seen = mid
+			} else {
+				hidden = mid
+			}
+		}
+		return seen
+	}
+
+	var first, last, prev, peakAt time.Time
+	peak := math.Inf(-1)
+	for t := p.AOS; ; t = t.Add(horizonStep) {
+		if t.After(p.LOS) {
+			t = p.LOS
+		}
+		up, el, err := visible(t)
+		if err != nil {
+			return p, true
+		}
+		if up {
+			if first.IsZero() {
+				first = t
+				if t.After(p.AOS) {
+					first = crossing(prev, t)
+				}
+			}
+			last = t
+			if el > peak {
+				peak, peakAt = el, t
+			}
+		} else if !last.IsZero() && last.Equal(prev) {
+			last = crossing(t, prev)
+		}
+		if t.Equal(p.LOS) {
+			break
+		}
+		prev = t
+	}
+	if first.IsZero() {
+		return p, false
+	}
+	if !p.MaxElevationTime.Before(first) && !p.MaxElevationTime.After(last) {
+		if up, _, _ := visible(p.MaxElevationTime); up {
+			peak, peakAt = p.MaxElevation, p.MaxElevationTime
+		}
+	}
+
+	out = p
+	out.AOS, out.LOS = first, last
+	out.MaxElevation, out.MaxElevationTime = peak, peakAt
+	out.Duration = last.Sub(first)
+	if _, az, err := lookAngles(tle, observer, first); err == nil {
+		out.AOSAzimuth = az
+	}
+	if _, az, err := lookAngles(tle, observer, last); err == nil {
+		out.LOSAzimuth = az
+	}
+	return out, true
+}
@@ -1,22 +1,27 @@
 // Package predict computes upcoming NOAA satellite passes for a ground
 // station using SGP4 orbital propagation. It handles TLE fetching, station
-// location resolution (static config or GPSD), and pass filtering by
-// minimum elevation.
+// location resolution (static config, GPSD, or approximate IP geolocation),
+// and pass filtering by minimum elevation and the local horizon mask.
 package predict
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"sort"
+	"strings"
 	"time"
 
 	"github.com/large-farva/ephemeris-engine/internal/capture"
 	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/weather"
 	"github.com/large-farva/ephemeris-engine/internal/ws"
 )
 
 // Pass describes a single predicted overhead pass, from acquisition of
-// signal (AOS) through loss of signal (LOS).
+// signal (AOS) through loss of signal (LOS). With a station horizon mask,
+// these are when the satellite clears it, and MaxElev is the highest it is
+// seen at.
 type Pass struct {
 	Satellite   capture.Satellite
 	AOS         time.Time
@@ -26,6 +31,69 @@ type Pass struct {
 	AOSAzimuth  float64
 	LOSAzimuth  float64
 	Duration    time.Duration
+
+	// RecordAOS and RecordLOS bound the recording: the part of the pass
+	// above station.usable_elevation, or AOS and LOS when that is unset
+	// or the pass never rises above it.
+	RecordAOS time.Time
+	RecordLOS time.Time
+
+	// SunSeparation is the closest the track comes to the sun, in degrees
+	// (180 when the sun is down). SunInterference is set when that is
+	// within predict.sun_avoid_degrees, as solar noise then tends to
+	// degrade the image.
+	SunSeparation   float64
+	SunInterference bool
+
+	// Daylight is set when the sun is up at maximum elevation, so the
+	// visible channel carries an image. CloudCover is the forecast total
+	// cloud cover in percent, or nil when [weather] is off or has no
+	// forecast for the pass.
+	Daylight   bool
+	CloudCover *int
+
+	// Quality rates the recording from 0 to 100 on its peak elevation,
+	// the time it spends high in the sky, and its length; see
+	// passQuality. predict.min_quality keeps lower passes out of the
+	// schedule. QualityFactors are the points it is made of; they are
+	// zero for a pass restored from a saved schedule.
+	Quality        int
+	QualityFactors QualityFactors
+}
+
+// Trimmed reports whether the recording is shorter than the pass.
+func (p Pass) Trimmed() bool {
+	return !p.RecordAOS.Equal(p.AOS) || !p.RecordLOS.Equal(p.LOS)
+}
+
+// Pass directions. A northbound pass is on the ascending part of the orbit,
+// rising in the south and setting in the north; southbound is the reverse.
+const (
+	Northbound = "northbound"
+	Southbound = "southbound"
+)
+
+// Direction reports whether the satellite travels north or south across the
+// sky, judged by whether it sets further north than it rises.
+func (p Pass) Direction() string {
+	if math.Cos(p.LOSAzimuth*math.Pi/180) > math.Cos(p.AOSAzimuth*math.Pi/180) {
+		return Northbound
+	}
+	return Southbound
+}
+
+// ParseDirection accepts N/S, north/south, northbound/southbound, or
+// ascending/descending (case-insensitive) and returns Northbound or
+// Southbound.
+func ParseDirection(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "n", "north", "northbound", "asc", "ascending":
+		return Northbound, nil
+	case "s", "south", "southbound", "desc", "descending":
+		return Southbound, nil
+	default:
+		return "", fmt.Errorf("invalid direction %q (want N or S)", s)
+	}
 }
 
 // Predictor resolves the ground station location, fetches current TLE data,
@@ -33,32 +101,35 @@ type Pass struct {
 type Predictor struct {
 	hub      *ws.Hub
 	cfg      config.Config
-	log      *log.Logger
+	log      *slog.Logger
 	tleStore *TLEStore
+
+	// MinElevation, when set, returns the lowest peak elevation at which a
+	// satellite's passes are kept, in place of the config it was made with,
+	// so a reloaded config applies to a long-lived predictor.
+	MinElevation func(name string) float64
 }
 
 // NewPredictor creates a predictor backed by a TLE store rooted in the
 // configured data directory.
-func NewPredictor(hub *ws.Hub, cfg config.Config, logger *log.Logger) *Predictor {
+func NewPredictor(hub *ws.Hub, cfg config.Config, logger *slog.Logger) *Predictor {
 	return &Predictor{
-		hub: hub,
-		cfg: cfg,
-		log: logger,
-		tleStore: NewTLEStore(
-			cfg.Predict.TLEURL,
-			cfg.Data.Root,
-			cfg.Predict.TLERefreshHours,
-		),
+		hub:      hub,
+		cfg:      cfg,
+		log:      logger.With("component", "predict"),
+		tleStore: NewTLEStore(cfg),
 	}
 }
 
 // ResolveLocation determines the ground station position. If use_gpsd is
-// true, it tries gpsd first and falls back to the TOML config values.
+// true, it tries gpsd first and falls back to the TOML config values. When
+// those are unset (0, 0) and geoip is enabled, an approximate position from
+// IP geolocation is used instead of predicting for null island.
 func (p *Predictor) ResolveLocation() (Location, error) {
 	if p.cfg.Station.UseGPSD {
 		loc, err := LocationFromGPSD(p.cfg.Station.GPSDHost, 10*time.Second)
 		if err != nil {
-			p.log.Printf("predict: gpsd failed (%v), falling back to config", err)
+			p.log.Warn("gpsd failed; using the configured location", "err", err)
 		} else {
 			p.broadcast(map[string]any{
 				"type":    "log",
@@ -69,42 +140,69 @@ func (p *Predictor) ResolveLocation() (Location, error) {
 		}
 	}
 
+	st := p.cfg.Station
+	if st.GeoIP && st.Latitude == 0 && st.Longitude == 0 {
+		loc, err := LocationFromGeoIP(st.GeoIPURL, 10*time.Second)
+		if err != nil {
+			p.log.Warn("geoip failed; using the configured location", "err", err)
+		} else {
+			return loc, nil
+		}
+	}
+
 	return Location{
-		Lat: p.cfg.Station.Latitude,
-		Lon: p.cfg.Station.Longitude,
-		Alt: p.cfg.Station.Altitude,
+		Lat: st.Latitude,
+		Lon: st.Longitude,
+		Alt: st.Altitude,
+
+		Source: SourceConfig,
 	}, nil
 }
 
 // ComputePasses fetches TLEs, resolves the station location, and computes
-// all upcoming passes within the lookahead window. Passes below min_elevation
-// are filtered out. Results are sorted by AOS ascending.
+// all upcoming passes within the lookahead window. Passes are trimmed to
+// the part above the station's horizon mask, if it has one, and those that
+// peak below the satellite's min_elevation, or station.min_elevation, are
+// filtered out. Results are sorted by AOS ascending.
 func (p *Predictor) ComputePasses() ([]Pass, error) {
 	loc, err := p.ResolveLocation()
 	if err != nil {
 		return nil, fmt.Errorf("resolve location: %w", err)
 	}
 
-	p.broadcast(map[string]any{
-		"type":    "log",
-		"level":   "info",
-		"message": fmt.Sprintf("station: %.4f, %.4f, %.0fm", loc.Lat, loc.Lon, loc.Alt),
-	})
+	if loc.Approximate() {
+		p.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "warn",
+			"message": fmt.Sprintf("station: %.2f, %.2f (approximate, from IP geolocation; set station.latitude/longitude for accurate predictions)", loc.Lat, loc.Lon),
+		})
+	} else {
+		p.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": fmt.Sprintf("station: %.4f, %.4f, %.0fm", loc.Lat, loc.Lon, loc.Alt),
+		})
+	}
 
 	tles, err := p.tleStore.Fetch()
 	if err != nil {
 		return nil, fmt.Errorf("fetch TLEs: %w", err)
 	}
 
+	mask, err := p.cfg.Station.Horizon()
+	if err != nil {
+		p.log.Warn("ignoring the horizon mask", "err", err)
+	}
+
 	now := time.Now().UTC()
 	end := now.Add(time.Duration(p.cfg.Predict.LookaheadHours) * time.Hour)
 
 	var allPasses []Pass
 
-	for _, sat := range capture.Satellites {
+	for _, sat := range capture.Catalog() {
 		tle, ok := tles[sat.NoradID]
 		if !ok {
-			p.log.Printf("predict: no TLE for %s (NORAD %d)", sat.Name, sat.NoradID)
+			p.log.Warn("no TLE", "satellite", sat.Name, "norad_id", sat.NoradID)
 			continue
 		}
 
@@ -114,14 +212,32 @@ func (p *Predictor) ComputePasses() ([]Pass, error) {
 			1, // 1-second step for precision
 		)
 		if err != nil {
-			p.log.Printf("predict: error computing passes for %s: %v", sat.Name, err)
+			p.log.Error("computing passes failed", "satellite", sat.Name, "err", err)
 			continue
 		}
 
+		minElev := p.cfg.SatelliteMinElevation(sat.Name)
+		if p.MinElevation != nil {
+			minElev = p.MinElevation(sat.Name)
+		}
 		for _, rp := range rawPasses {
-			if rp.MaxElevation < p.cfg.Station.MinElevation {
+			rp.AOSAzimuth, rp.LOSAzimuth = trueAzimuth(rp.AOSAzimuth), trueAzimuth(rp.LOSAzimuth)
+			if len(mask) > 0 {
+				var seen bool
+				if rp, seen = horizon(mask).mask(tle, loc, rp); !seen {
+					continue
+				}
+			}
+			if rp.MaxElevation < minElev {
 				continue
 			}
+			recAOS, recLOS := rp.AOS, rp.LOS
+			if usable := p.cfg.Station.UsableElevation; usable > 0 && rp.MaxElevation > usable {
+				recAOS, recLOS = usableWindow(tle, loc, rp, usable)
+			}
+			sunSep := sunSeparation(tle, loc, rp.AOS, rp.LOS)
+			_, sunEl := SunPosition(rp.MaxElevationTime, loc.Lat, loc.Lon)
+			quality := passQuality(tle, loc, mask, recAOS, recLOS, rp.MaxElevation)
 			allPasses = append(allPasses, Pass{
 				Satellite:   sat,
 				AOS:         rp.AOS,
@@ -131,6 +247,15 @@ func (p *Predictor) ComputePasses() ([]Pass, error) {
 				AOSAzimuth:  rp.AOSAzimuth,
 				LOSAzimuth:  rp.LOSAzimuth,
 				Duration:    rp.Duration,
+				RecordAOS:   recAOS,
+				RecordLOS:   recLOS,
+
+				SunSeparation:   sunSep,
+				SunInterference: sunSep < p.cfg.Predict.SunAvoidDegrees,
+				Daylight:        sunEl > 0,
+
+				Quality:        quality.Score(),
+				QualityFactors: quality,
 			})
 		}
 	}
@@ -139,6 +264,10 @@ func (p *Predictor) ComputePasses() ([]Pass, error) {
 		return allPasses[i].AOS.Before(allPasses[j].AOS)
 	})
 
+	if p.cfg.Weather.Enabled && len(allPasses) > 0 {
+		p.attachWeather(allPasses, loc)
+	}
+
 	p.broadcast(map[string]any{
 		"type":    "log",
 		"level":   "info",
@@ -148,6 +277,21 @@ func (p *Predictor) ComputePasses() ([]Pass, error) {
 	return allPasses, nil
 }
 
+// attachWeather sets CloudCover on each pass from the forecast at its
+// maximum elevation. A failed lookup only costs the annotation.
+func (p *Predictor) attachWeather(passes []Pass, loc Location) {
+	fc, err := weather.Lookup(p.cfg.Weather.URL, loc.Lat, loc.Lon, p.cfg.Predict.LookaheadHours, 10*time.Second)
+	if err != nil {
+		p.log.Warn("weather lookup failed", "err", err)
+		return
+	}
+	for i := range passes {
+		if cover, ok := fc.CloudCover(passes[i].MaxElevTime); ok {
+			passes[i].CloudCover = &cover
+		}
+	}
+}
+
 // ForceRefreshTLEs fetches TLEs from the network regardless of cache age
 // and returns the number of satellites updated.
 func (p *Predictor) ForceRefreshTLEs() (int, error) {
@@ -158,6 +302,12 @@ func (p *Predictor) ForceRefreshTLEs() (int, error) {
 	return len(tles), nil
 }
 
+// TLEElements describes the element set each satellite is predicted from,
+// as of now, without fetching any.
+func (p *Predictor) TLEElements() []TLEElement {
+	return p.tleStore.Elements(time.Now())
+}
+
 func (p *Predictor) broadcast(v map[string]any) {
 	v["ts"] = time.Now().UTC().Format(time.RFC3339Nano)
 	v["component"] = "predict"
@@ -0,0 +1,63 @@
+package predict
+
+import (
+	"math"
+	"time"
+
+	"github.com/akhenakh/sgp4"
+)
+
+// Pass quality weights. A pass scores up to qualityElevPoints for its peak
+// elevation, up to qualityHighPoints for the time it spends above
+// qualityHighElev, where the signal is strong and the image clean, and up
+// to qualityLengthPoints for the length of its recording. A pass peaking
+// at qualityElevCap or more, high for qualityHighCap and recorded for
+// qualityLengthCap scores 100.
+const (
+	qualityElevPoints   = 50
+	qualityHighPoints   = 30
+	qualityLengthPoints = 20
+
+	qualityElevCap   = 60.0 // degrees
+	qualityHighElev  = 20.0 // degrees
+	qualityHighCap   = 8 * time.Minute
+	qualityLengthCap = 14 * time.Minute
+
+	// qualityStep is how finely the track is walked for the time above
+	// qualityHighElev.
+	qualityStep = 10 * time.Second
+)
+
+// QualityFactors are the points a pass's quality score is made of: up to
+// 50 for its peak elevation, up to 30 for HighS, the seconds it spends
+// above 20°, and up to 20 for the length of its recording.
+type QualityFactors struct {
+	ElevationPoints float64 `json:"elevation_points"`
+	HighS           int     `json:"high_s"`
+	HighPoints      float64 `json:"high_points"`
+	LengthPoints    float64 `json:"length_points"`
+}
+
+// Score returns the quality score the factors add up to, from 0 to 100.
+func (q QualityFactors) Score() int {
+	return int(math.Round(math.Max(q.ElevationPoints+q.HighPoints+q.LengthPoints, 0)))
+}
+
+// passQuality rates the recording of a pass peaking at maxElev from aos to
+// los, from 0 to 100. A marginal 11° pass scores about 20 and an overhead
+// one 100. Time spent behind the horizon mask does not count as high.
+func passQuality(tle *sgp4.TLE, loc Location, h horizon, aos, los time.Time, maxElev float64) QualityFactors {
+	observer := &sgp4.Location{Latitude: loc.Lat, Longitude: loc.Lon, Altitude: loc.Alt}
+	var high time.Duration
+	for t := aos; t.Before(los); t = t.Add(qualityStep) {
+		if el, az, err := lookAngles(tle, observer, t); err == nil && el >= qualityHighElev && el >= h.at(az) {
+			high += qualityStep
+		}
+	}
+	return QualityFactors{
+		ElevationPoints: qualityElevPoints * math.Min(maxElev/qualityElevCap, 1),
+		HighS:           int(high.Seconds()),
+		HighPoints:      qualityHighPoints * math.Min(high.Seconds()/qualityHighCap.Seconds(), 1),
+		LengthPoints:    qualityLengthPoints * math.Min(los.Sub(aos).Seconds()/qualityLengthCap.Seconds(), 1),
+	}
+}
@@ -0,0 +1,82 @@
+package predict
+
+import (
+	"math"
+	"time"
+
+	"github.com/akhenakh/sgp4"
+)
+
+// sunTrackStep is the sampling interval when comparing a pass track with
+// the sun's position. The sun moves about 0.04° in that time and a NOAA
+// satellite at most a few degrees, well inside any useful threshold.
+const sunTrackStep = 10 * time.Second
+
+// noSunSeparation is reported for passes during which the sun stays below
+// the horizon, where it cannot add noise.
+const noSunSeparation = 180.0
+
+// Sun policies for passes flagged by predict.sun_avoid_degrees.
+const (
+	SunPolicyFlag         = "flag"         // report only
+	SunPolicyDeprioritize = "deprioritize" // prefer an overlapping clean pass
+	SunPolicySkip         = "skip"         // never record flagged passes
+)
+
+// SunPosition returns the sun's azimuth (degrees clockwise from north) and
+// elevation (degrees above the horizon) seen from lat/lon at t. It uses
+// the low-precision solar coordinates from the Astronomical Almanac, good
+// to about 0.01° between 1950 and 2050. Refraction is ignored.
+func SunPosition(t time.Time, lat, lon float64) (az, el float64) {
+	const rad = math.Pi / 180
+
+	// Days since J2000.0.
+	n := float64(t.UTC().Sub(time.Date(2000, 1, 1, 12, 0, 0, 0, time.UTC))) / float64(24*time.Hour)
+
+	meanLon := math.Mod(280.460+0.9856474*n, 360)
+	anomaly := math.Mod(357.528+0.9856003*n, 360) * rad
+	eclLon := (meanLon + 1.915*math.Sin(anomaly) + 0.020*math.Sin(2*anomaly)) * rad
+	obliquity := (23.439 - 0.0000004*n) * rad
+
+	ra := math.Atan2(math.Cos(obliquity)*math.Sin(eclLon), math.Cos(eclLon))
+	dec := math.Asin(math.Sin(obliquity) * math.Sin(eclLon))
+
+	gmst := math.Mod(280.46061837+360.98564736629*n, 360)
+	hourAngle := (gmst+lon)*rad - ra
+	phi := lat * rad
+
+	el = math.Asin(math.Sin(phi)*math.Sin(dec) + math.Cos(phi)*math.Cos(dec)*math.Cos(hourAngle))
+	az = math.Atan2(-math.Sin(hourAngle), math.Tan(dec)*math.Cos(phi)-math.Sin(phi)*math.Cos(hourAngle))
+	return math.Mod(az/rad+360, 360), el / rad
+}
+
+// angularSeparation returns the angle in degrees between two directions
+// given as azimuth/elevation pairs.
+func angularSeparation(az1, el1, az2, el2 float64) float64 {
+	const rad = math.Pi / 180
+	cos := math.Sin(el1*rad)*math.Sin(el2*rad) +
+		math.Cos(el1*rad)*math.Cos(el2*rad)*math.Cos((az1-az2)*rad)
+	return math.Acos(math.Max(-1, math.Min(1, cos))) / rad
+}
+
+// sunSeparation walks a pass from AOS to LOS and returns the closest the
+// antenna comes to pointing at the sun, in degrees. Only moments with the
+// sun above the horizon count. The moon is not considered: at 137 MHz it
+// is a faint thermal source that does not measurably degrade APT.
+func sunSeparation(tle *sgp4.TLE, loc Location, aos, los time.Time) float64 {
+	observer := &sgp4.Location{Latitude: loc.Lat, Longitude: loc.Lon, Altitude: loc.Alt}
+	closest := noSunSeparation
+	for t := aos; !t.After(los); t = t.Add(sunTrackStep) {
+		sunAz, sunEl := SunPosition(t, loc.Lat, loc.Lon)
+		if sunEl <= 0 {
+			continue
+		}
+		el, az, err := lookAngles(tle, observer, t)
+		if err != nil {
+			continue
+		}
+		sep := angularSeparation(az, el, sunAz, sunEl)
+		closest = math.Min(closest, sep)
+	}
+	return closest
+}
@@ -2,40 +2,75 @@ package predict
 
 import (
 	_ "embed"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/akhenakh/sgp4"
 	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/config"
 )
 
 //go:embed noaa_tle.txt
 var embeddedTLE string
 
-const tleCacheFile = "weather_tle.txt"
+const (
+	tleCacheFile   = "weather_tle.txt"
+	tleSourcesFile = "weather_tle_sources.json"
+)
+
+// Sources recorded for element sets that did not come from a URL.
+const (
+	sourceCache    = "cache"
+	sourceEmbedded = "embedded"
+)
 
 // TLEStore fetches and caches Two-Line Element sets for the NOAA satellites.
 // It uses a tiered fallback strategy: fresh disk cache, network fetch,
 // stale disk cache, and finally embedded data baked into the binary.
+//
+// The network tier reads a satellite's own URL first, when it has one,
+// then the configured sources in order until every satellite in the
+// catalog has an element set, so a source that is down or lacks a
+// satellite is made up for by the next. Satellites no source served keep
+// the element set they had in the cache, or the embedded one.
 type TLEStore struct {
-	url      string
+	urls     []string
+	satURLs  map[int]string // NORAD ID -> the satellite's own source
 	dataRoot string
 	maxAge   time.Duration
+	// maxEpochAge is how old an element set's epoch may grow before it is
+	// reported stale; 0 never.
+	maxEpochAge time.Duration
 }
 
-// NewTLEStore returns a store that fetches TLEs from the given URL and
-// caches them under dataRoot.
-func NewTLEStore(tleURL, dataRoot string, refreshHours int) *TLEStore {
-	return &TLEStore{
-		url:      tleURL,
-		dataRoot: dataRoot,
-		maxAge:   time.Duration(refreshHours) * time.Hour,
+// NewTLEStore returns a store that fetches TLEs from the sources of cfg
+// and caches them under its data root.
+func NewTLEStore(cfg config.Config) *TLEStore {
+	s := &TLEStore{
+		urls:     cfg.Predict.TLESources(),
+		satURLs:  map[int]string{},
+		dataRoot: cfg.Data.Root,
+		maxAge:   time.Duration(cfg.Predict.TLERefreshHours) * time.Hour,
+
+		maxEpochAge: time.Duration(cfg.Predict.TLEMaxEpochAgeDays) * 24 * time.Hour,
+	}
+	for _, sat := range capture.Catalog() {
+		if u := cfg.Satellites[cfg.SatelliteKey(sat.Name)].TLEURL; u != "" {
+			s.satURLs[sat.NoradID] = u
+		}
 	}
+	return s
 }
 
 // Fetch returns TLEs for the hardcoded NOAA satellites, keyed by NORAD ID.
@@ -64,10 +99,8 @@ func (s *TLEStore) loadOrFetch(cachePath string) (string, error) {
 	}
 
 	// Tier 2: network fetch
-	body, fetchErr := s.fetchFromNetwork()
+	body, fetchErr := s.fetchFromNetwork(cachePath)
 	if fetchErr == nil {
-		// Cache write failure is non-fatal; we already have the data in memory.
-		_ = s.writeCache(cachePath, body)
 		return body, nil
 	}
 
@@ -84,18 +117,105 @@ func (s *TLEStore) loadOrFetch(cachePath string) (string, error) {
 	return "", fmt.Errorf("all TLE sources exhausted: %w", fetchErr)
 }
 
-// fetchFromNetwork downloads the TLE data set from CelesTrak (or whatever
-// URL is configured). Times out after 30 seconds.
-func (s *TLEStore) fetchFromNetwork() (string, error) {
+// fetchFromNetwork gathers element sets for the catalog from the
+// satellites' own URLs and then the configured sources, in order, and
+// writes them to the cache with the source of each. Satellites none of
+// them served are filled in from the cache or the embedded data. It fails
+// only when no source served any satellite.
+func (s *TLEStore) fetchFromNetwork(cachePath string) (string, error) {
+	wanted := catalogIDs()
+	groups := map[int]string{}
+	sources := map[int]string{}
+	var errs []error
+
+	// A satellite's own URL is fetched once even when it serves several.
+	bodies := map[string]string{}
+	for _, id := range slices.Sorted(maps.Keys(s.satURLs)) {
+		u := s.satURLs[id]
+		body, ok := bodies[u]
+		if !ok {
+			var err error
+			if body, err = fetchURL(u); err != nil {
+				errs = append(errs, err)
+			}
+			bodies[u] = body
+		}
+		if g, ok := parseGroups(body)[id]; ok {
+			groups[id], sources[id] = g, u
+		}
+	}
+
+	for _, u := range s.urls {
+		if len(groups) == len(wanted) {
+			break
+		}
+		body, err := fetchURL(u)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for id, g := range parseGroups(body) {
+			if _, have := groups[id]; wanted[id] && !have {
+				groups[id], sources[id] = g, u
+			}
+		}
+	}
+
+	if len(groups) == 0 {
+		if len(errs) == 0 {
+			errs = append(errs, errors.New("no TLE source configured serves a catalog satellite"))
+		}
+		return "", errors.Join(errs...)
+	}
+
+	// Fill in what no source served from the last cache, then the
+	// embedded data, so one missing satellite does not drop its passes.
+	stale, _ := os.ReadFile(cachePath)
+	oldSources := s.readSources()
+	for _, fill := range []struct {
+		raw    string
+		source func(id int) string
+	}{
+		{string(stale), func(id int) string {
+			if src, ok := oldSources[strconv.Itoa(id)]; ok {
+				return src
+			}
+			return sourceCache
+		}},
+		{embeddedTLE, func(int) string { return sourceEmbedded }},
+	} {
+		for id, g := range parseGroups(fill.raw) {
+			if _, have := groups[id]; wanted[id] && !have {
+				groups[id], sources[id] = g, fill.source(id)
+			}
+		}
+	}
+
+	var b strings.Builder
+	for _, id := range slices.Sorted(maps.Keys(groups)) {
+		b.WriteString(groups[id])
+		b.WriteString("\n")
+	}
+	body := b.String()
+
+	// Cache write failure is non-fatal; we already have the data in memory.
+	if err := s.writeCache(cachePath, body); err == nil {
+		s.writeSources(sources)
+	}
+	return body, nil
+}
+
+// fetchURL downloads one TLE source. Times out after 30 seconds.
+func fetchURL(u string) (string, error) {
 	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Get(s.url)
+	resp, err := client.Get(u)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("TLE fetch returned HTTP %d", resp.StatusCode)
+		return "", fmt.Errorf("TLE fetch from %s returned HTTP %d", redactURL(u), resp.StatusCode)
 	}
 
 	b, err := io.ReadAll(resp.Body)
@@ -105,6 +225,41 @@ func (s *TLEStore) fetchFromNetwork() (string, error) {
 	return string(b), nil
 }
 
+// redactURL masks the password of a source URL for messages.
+func redactURL(u string) string {
+	if pu, err := url.Parse(u); err == nil && pu.User != nil {
+		return pu.Redacted()
+	}
+	return u
+}
+
+// readSources returns the source of each cached element set, keyed by
+// NORAD ID, as written with the cache; nil when it was not.
+func (s *TLEStore) readSources() map[string]string {
+	b, err := os.ReadFile(filepath.Join(s.dataRoot, tleSourcesFile))
+	if err != nil {
+		return nil
+	}
+	var m map[string]string
+	if json.Unmarshal(b, &m) != nil {
+		return nil
+	}
+	return m
+}
+
+// writeSources records the source of each element set next to the cache.
+func (s *TLEStore) writeSources(sources map[int]string) {
+	m := make(map[string]string, len(sources))
+	for id, u := range sources {
+		m[strconv.Itoa(id)] = redactURL(u)
+	}
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return
+	}
+	_ = s.writeCache(filepath.Join(s.dataRoot, tleSourcesFile), string(b)+"\n")
+}
+
 // writeCache atomically writes data to cachePath via a temp file and rename
 // so readers never see a half-written file.
 func (s *TLEStore) writeCache(cachePath, data string) error {
@@ -136,37 +291,83 @@ func (s *TLEStore) writeCache(cachePath, data string) error {
 func (s *TLEStore) ForceRefresh() (map[int]*sgp4.TLE, error) {
 	cachePath := filepath.Join(s.dataRoot, tleCacheFile)
 
-	body, err := s.fetchFromNetwork()
+	body, err := s.fetchFromNetwork(cachePath)
 	if err != nil {
 		return nil, err
 	}
-
-	_ = s.writeCache(cachePath, body)
 	return s.parseForNOAA(body)
 }
 
-// TLECacheInfo describes the state of the TLE disk cache.
+// TLEEpoch returns the epoch of a parsed element set as a UTC time.
+func TLEEpoch(t *sgp4.TLE) time.Time {
+	start := time.Date(t.EpochYear, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return start.Add(time.Duration((t.EpochDay - 1) * float64(24*time.Hour)))
+}
+
+// TLEElement describes the element set a satellite is predicted from.
+// Its epoch is when the elements were fitted; predictions drift the
+// further from it they are, a few seconds of pass timing a day once a
+// week or so old. Stale is set once the epoch is older than
+// predict.tle_max_epoch_age_days.
+type TLEElement struct {
+	NoradID    int     `json:"norad_id"`
+	Satellite  string  `json:"satellite"`
+	Epoch      string  `json:"epoch"`
+	EpochUnix  int64   `json:"epoch_unix"`
+	AgeS       int     `json:"age_s"`
+	ElementSet int     `json:"element_set"`
+	MeanMotion float64 `json:"mean_motion"` // revolutions per day
+	Source     string  `json:"source,omitempty"`
+	Stale      bool    `json:"stale,omitempty"`
+}
+
+// TLECacheInfo describes the state of the TLE disk cache. SourceURL is the
+// first of Sources, the configured sources in the order they are tried;
+// SatelliteURLs are the satellites' own, keyed by NORAD ID. Satellites
+// names where each cached element set came from, keyed by NORAD ID: a
+// URL, "cache" or "embedded". Elements describes the element sets in use,
+// from the cache or, without one, the embedded data.
 type TLECacheInfo struct {
-	Path      string `json:"path"`
-	Exists    bool   `json:"exists"`
-	Fresh     bool   `json:"fresh"`
-	ModTime   string `json:"mod_time,omitempty"`
-	AgeS      int    `json:"age_s"`
-	Size      int64  `json:"size"`
-	SourceURL string `json:"source_url"`
-	MaxAgeH   int    `json:"max_age_hours"`
+	Path          string            `json:"path"`
+	Exists        bool              `json:"exists"`
+	Fresh         bool              `json:"fresh"`
+	ModTime       string            `json:"mod_time,omitempty"`
+	AgeS          int               `json:"age_s"`
+	Size          int64             `json:"size"`
+	SourceURL     string            `json:"source_url"`
+	Sources       []string          `json:"sources"`
+	SatelliteURLs map[string]string `json:"satellite_urls,omitempty"`
+	Satellites    map[string]string `json:"satellites,omitempty"`
+	MaxAgeH       int               `json:"max_age_hours"`
+	MaxEpochAgeD  int               `json:"max_epoch_age_days"`
+	Elements      []TLEElement      `json:"elements"`
 }
 
 // CacheInfo returns metadata about the TLE disk cache.
 func (s *TLEStore) CacheInfo() TLECacheInfo {
 	info := TLECacheInfo{
-		Path:      filepath.Join(s.dataRoot, tleCacheFile),
-		SourceURL: s.url,
-		MaxAgeH:   int(s.maxAge.Hours()),
+		Path:    filepath.Join(s.dataRoot, tleCacheFile),
+		Sources: make([]string, len(s.urls)),
+		MaxAgeH: int(s.maxAge.Hours()),
+
+		MaxEpochAgeD: int(s.maxEpochAge.Hours() / 24),
+	}
+	for i, u := range s.urls {
+		info.Sources[i] = redactURL(u)
+	}
+	if len(info.Sources) > 0 {
+		info.SourceURL = info.Sources[0]
+	}
+	if len(s.satURLs) > 0 {
+		info.SatelliteURLs = make(map[string]string, len(s.satURLs))
+		for id, u := range s.satURLs {
+			info.SatelliteURLs[strconv.Itoa(id)] = redactURL(u)
+		}
 	}
 
 	fi, err := os.Stat(info.Path)
 	if err != nil {
+		info.Elements = s.Elements(time.Now())
 		return info
 	}
 
@@ -175,39 +376,112 @@ func (s *TLEStore) CacheInfo() TLECacheInfo {
 	info.AgeS = int(time.Since(fi.ModTime()).Seconds())
 	info.Size = fi.Size()
 	info.Fresh = time.Since(fi.ModTime()) < s.maxAge
+	info.Satellites = s.readSources()
+	info.Elements = s.Elements(time.Now())
 	return info
 }
 
-// parseForNOAA extracts TLEs for the hardcoded NOAA satellites from a bulk
-// TLE text dump. Input is expected in standard 3-line format (name, line 1,
-// line 2) as served by CelesTrak.
-func (s *TLEStore) parseForNOAA(raw string) (map[int]*sgp4.TLE, error) {
+// Elements describes the element set of each catalog satellite as of now,
+// read from the cache, or the embedded data when there is none, without
+// going to the network. Satellites without one are left out.
+func (s *TLEStore) Elements(now time.Time) []TLEElement {
+	raw, cached := embeddedTLE, false
+	var sources map[string]string
+	if b, err := os.ReadFile(filepath.Join(s.dataRoot, tleCacheFile)); err == nil && len(b) > 0 {
+		raw, cached = string(b), true
+		sources = s.readSources()
+	}
+	tles, err := s.parseForNOAA(raw)
+	if err != nil {
+		return []TLEElement{}
+	}
+
+	out := []TLEElement{}
+	for _, sat := range capture.Catalog() {
+		t, ok := tles[sat.NoradID]
+		if !ok {
+			continue
+		}
+		epoch := TLEEpoch(t)
+		age := now.Sub(epoch)
+		source := sourceEmbedded
+		if cached {
+			source = sources[strconv.Itoa(sat.NoradID)]
+		}
+		out = append(out, TLEElement{
+			NoradID:    sat.NoradID,
+			Satellite:  sat.Name,
+			Epoch:      epoch.Format(time.RFC3339),
+			EpochUnix:  epoch.Unix(),
+			AgeS:       int(age.Seconds()),
+			ElementSet: t.ElementNumber,
+			MeanMotion: t.MeanMotion,
+			Source:     source,
+			Stale:      s.maxEpochAge > 0 && age > s.maxEpochAge,
+		})
+	}
+	return out
+}
+
+// catalogIDs returns the NORAD IDs of the satellite catalog.
+func catalogIDs() map[int]bool {
 	wanted := make(map[int]bool, len(capture.Satellites))
-	for _, sat := range capture.Satellites {
+	for _, sat := range capture.Catalog() {
 		wanted[sat.NoradID] = true
 	}
+	return wanted
+}
 
-	result := make(map[int]*sgp4.TLE)
-	lines := strings.Split(strings.TrimSpace(raw), "\n")
-
-	for i := 0; i+2 < len(lines); i += 3 {
-		group := strings.TrimSpace(lines[i]) + "\n" +
-			strings.TrimSpace(lines[i+1]) + "\n" +
-			strings.TrimSpace(lines[i+2])
+// parseForNOAA extracts TLEs for the catalog satellites from a bulk TLE
+// text dump.
+func (s *TLEStore) parseForNOAA(raw string) (map[int]*sgp4.TLE, error) {
+	wanted := catalogIDs()
 
-		tle, err := sgp4.ParseTLE(group)
-		if err != nil {
+	result := make(map[int]*sgp4.TLE)
+	for id, group := range parseGroups(raw) {
+		if !wanted[id] {
 			continue
 		}
-
-		if wanted[tle.SatelliteNumber] {
-			result[tle.SatelliteNumber] = tle
+		if tle, err := sgp4.ParseTLE(group); err == nil {
+			result[id] = tle
 		}
 	}
 
 	if len(result) == 0 {
-		return nil, fmt.Errorf("no matching NOAA TLEs found in %d lines of input", len(lines))
+		return nil, fmt.Errorf("no matching NOAA TLEs found in %d bytes of input", len(raw))
 	}
 
 	return result, nil
 }
+
+// parseGroups splits a TLE text dump into element sets keyed by NORAD ID.
+// It takes the 3-line format (name, line 1, line 2) served by CelesTrak as
+// well as bare line pairs, which some other sources serve. An element set
+// whose lines are not 69 characters is skipped.
+func parseGroups(raw string) map[int]string {
+	lines := strings.Split(strings.TrimSpace(raw), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimSpace(lines[i])
+	}
+	isLine := func(i int, n byte) bool {
+		return i < len(lines) && len(lines[i]) == 69 && lines[i][0] == n && lines[i][1] == ' '
+	}
+
+	groups := map[int]string{}
+	for i := 0; i < len(lines); i++ {
+		if !isLine(i, '1') || !isLine(i+1, '2') {
+			continue
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(lines[i][2:7]))
+		if err != nil {
+			continue
+		}
+		group := lines[i] + "\n" + lines[i+1]
+		if i > 0 && lines[i-1] != "" && !isLine(i-1, '2') {
+			group = lines[i-1] + "\n" + group
+		}
+		groups[id] = group
+		i++
+	}
+	return groups
+}
@@ -0,0 +1,117 @@
+package predict
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/akhenakh/sgp4"
+)
+
+// speedOfLight in km/s, for the Doppler shift.
+const speedOfLight = 299792.458
+
+// TrackFunc returns a satellite's sub-satellite point at t: geodetic
+// latitude and longitude in degrees and altitude in km.
+type TrackFunc func(t time.Time) (lat, lon, altKm float64, err error)
+
+// Track returns the ground track of satellite noradID from the current
+// TLEs, as used to georeference its decoded images.
+func (p *Predictor) Track(noradID int) (TrackFunc, error) {
+	tles, err := p.tleStore.Fetch()
+	if err != nil {
+		return nil, fmt.Errorf("fetch TLEs: %w", err)
+	}
+	tle, ok := tles[noradID]
+	if !ok {
+		return nil, fmt.Errorf("no TLE for NORAD %d", noradID)
+	}
+	return func(t time.Time) (float64, float64, float64, error) {
+		eci, err := tle.FindPositionAtTime(t)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		// sgp4 stamps the position with t truncated to the minute, which
+		// would rotate the Earth under it by up to a quarter of a degree.
+		eci.DateTime = t
+		lat, lon, alt := eci.ToGeodetic()
+		return lat, lon, alt, nil
+	}, nil
+}
+
+// TrackPoint is where a satellite is at one moment of a pass: in the
+// station's sky and above the ground.
+type TrackPoint struct {
+	Time      time.Time
+	Azimuth   float64 // degrees clockwise from true north
+	Elevation float64 // degrees
+	RangeKm   float64
+	RangeRate float64 // km/s, positive while moving away
+	// DopplerHz is the shift of the satellite's downlink frequency as
+	// received at the station.
+	DopplerHz float64
+	Lat       float64 // sub-satellite point, degrees
+	Lon       float64
+	AltKm     float64
+}
+
+// PassTrack samples pass every step from its AOS to its LOS, LOS
+// included, from the current TLEs and station location.
+func (p *Predictor) PassTrack(pass Pass, step time.Duration) ([]TrackPoint, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("step must be positive")
+	}
+	loc, err := p.ResolveLocation()
+	if err != nil {
+		return nil, fmt.Errorf("resolve location: %w", err)
+	}
+	tles, err := p.tleStore.Fetch()
+	if err != nil {
+		return nil, fmt.Errorf("fetch TLEs: %w", err)
+	}
+	tle, ok := tles[pass.Satellite.NoradID]
+	if !ok {
+		return nil, fmt.Errorf("no TLE for NORAD %d", pass.Satellite.NoradID)
+	}
+	observer := &sgp4.Location{Latitude: loc.Lat, Longitude: loc.Lon, Altitude: loc.Alt}
+	look := func(t time.Time) (*sgp4.Observation, error) {
+		eci, err := tle.FindPositionAtTime(t)
+		if err != nil {
+			return nil, err
+		}
+		sv := &sgp4.StateVector{X: eci.Position.X, Y: eci.Position.Y, Z: eci.Position.Z}
+		return sv.GetLookAngle(observer, t)
+	}
+
+	var points []TrackPoint
+	for t := pass.AOS; ; t = t.Add(step) {
+		if t.After(pass.LOS) {
+			t = pass.LOS
+		}
+		obs, err := look(t)
+		if err != nil {
+			return nil, err
+		}
+		// The range rate is taken over the next second: sgp4's own mixes
+		// frames and is far off.
+		next, err := look(t.Add(time.Second))
+		if err != nil {
+			return nil, err
+		}
+		angles := obs.LookAngles
+		rate := next.LookAngles.Range - angles.Range
+		points = append(points, TrackPoint{
+			Time:      t,
+			Azimuth:   trueAzimuth(angles.Azimuth),
+			Elevation: angles.Elevation,
+			RangeKm:   angles.Range,
+			RangeRate: rate,
+			DopplerHz: -rate / speedOfLight * float64(pass.Satellite.Freq),
+			Lat:       obs.SatellitePos.Latitude,
+			Lon:       obs.SatellitePos.Longitude,
+			AltKm:     obs.SatellitePos.Altitude,
+		})
+		if !t.Before(pass.LOS) {
+			return points, nil
+		}
+	}
+}
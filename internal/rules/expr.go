@@ -0,0 +1,408 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StatNames are the station statistics a condition may refer to, besides
+// the fields of the triggering event ("event.type", "event.satellite",
+// ...). Sizes are in bytes and the uptime in seconds.
+var StatNames = []string{
+	"captures_failed",
+	"captures_total",
+	"disk_free",
+	"disk_total",
+	"disk_used_percent",
+	"mode",
+	"paused",
+	"state",
+	"uptime_seconds",
+}
+
+// Condition is a parsed rule condition such as
+//
+//	disk_free < 2GB and not paused
+//	event.type == capture_corrupt
+//	event.type == log and (event.level == error or event.level == warn)
+//
+// A comparison is a name, an operator (<, <=, >, >=, ==, !=) and a
+// number, a quoted or bare string, or true/false. Numbers may carry a
+// binary size unit (KB, MB, GB, TB) or %. A bare name tests that the value
+// is set and not false, zero or empty. String equality ignores case.
+// Comparisons with a value that is missing or of the wrong kind are false.
+type Condition struct {
+	src  string
+	root node
+	vars []string
+}
+
+// Parse compiles a condition, checking that every name it uses is an
+// event field or one of StatNames.
+func Parse(src string) (*Condition, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{toks: toks, vars: map[string]bool{}}
+	if len(toks) == 0 {
+		return nil, errors.New("empty condition")
+	}
+	root, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.toks) {
+		return nil, fmt.Errorf("unexpected %q", p.toks[p.pos].text)
+	}
+	c := &Condition{src: src, root: root}
+	for name := range p.vars {
+		c.vars = append(c.vars, name)
+	}
+	sort.Strings(c.vars)
+	return c, nil
+}
+
+// String returns the condition as written.
+func (c *Condition) String() string { return c.src }
+
+// UsesEvent reports whether the condition refers to event fields, as
+// opposed to station statistics alone.
+func (c *Condition) UsesEvent() bool {
+	for _, v := range c.vars {
+		if strings.HasPrefix(v, "event.") {
+			return true
+		}
+	}
+	return false
+}
+
+// Eval evaluates the condition, looking names up with lookup.
+func (c *Condition) Eval(lookup func(name string) (any, bool)) bool {
+	return c.root.eval(lookup)
+}
+
+type node interface {
+	eval(lookup func(string) (any, bool)) bool
+}
+
+type andNode struct{ l, r node }
+type orNode struct{ l, r node }
+type notNode struct{ n node }
+
+// truthNode is a bare name.
+type truthNode struct{ name string }
+
+type cmpNode struct {
+	name string
+	op   string
+	val  any // float64, string or bool
+}
+
+func (n andNode) eval(f func(string) (any, bool)) bool { return n.l.eval(f) && n.r.eval(f) }
+func (n orNode) eval(f func(string) (any, bool)) bool  { return n.l.eval(f) || n.r.eval(f) }
+func (n notNode) eval(f func(string) (any, bool)) bool { return !n.n.eval(f) }
+
+func (n truthNode) eval(f func(string) (any, bool)) bool {
+	v, ok := f(n.name)
+	if !ok || v == nil {
+		return false
+	}
+	switch v := v.(type) {
+	case bool:
+		return v
+	case string:
+		return v != ""
+	}
+	if x, ok := toFloat(v); ok {
+		return x != 0
+	}
+	return true
+}
+
+func (n cmpNode) eval(f func(string) (any, bool)) bool {
+	v, ok := f(n.name)
+	if !ok || v == nil {
+		return false
+	}
+	switch want := n.val.(type) {
+	case float64:
+		got, ok := toFloat(v)
+		if !ok {
+			return false
+		}
+		switch n.op {
+		case "<":
+			return got < want
+		case "<=":
+			return got <= want
+		case ">":
+			return got > want
+		case ">=":
+			return got >= want
+		case "==":
+			return got == want
+		case "!=":
+			return got != want
+		}
+	case bool:
+		got, ok := v.(bool)
+		if !ok {
+			return false
+		}
+		return (got == want) == (n.op == "==")
+	case string:
+		got, ok := v.(string)
+		if !ok {
+			got = fmt.Sprint(v)
+		}
+		return strings.EqualFold(got, want) == (n.op == "==")
+	}
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// Lexing.
+
+type token struct {
+	kind string // "op", "(", ")", "str" (quoted) or "word"
+	text string
+}
+
+func lex(src string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(' || c == ')':
+			toks = append(toks, token{kind: string(c), text: string(c)})
+			i++
+		case strings.ContainsRune("<>=!", rune(c)):
+			op := string(c)
+			if i+1 < len(src) && src[i+1] == '=' {
+				op += "="
+			}
+			i += len(op)
+			switch op {
+			case "=":
+				op = "=="
+			case "!":
+				return nil, fmt.Errorf("unexpected '!' at %d (use not or !=)", i-1)
+			}
+			toks = append(toks, token{kind: "op", text: op})
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(src[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at %d", i)
+			}
+			toks = append(toks, token{kind: "str", text: src[i+1 : i+1+end]})
+			i += end + 2
+		case isWordChar(c):
+			j := i
+			for j < len(src) && isWordChar(src[j]) {
+				j++
+			}
+			toks = append(toks, token{kind: "word", text: src[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected %q at %d", c, i)
+		}
+	}
+	return toks, nil
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '.' || c == '-' || c == '%' || c == ':'
+}
+
+// Parsing: or binds loosest, then and, then not.
+
+type parser struct {
+	toks []token
+	pos  int
+	vars map[string]bool
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos < len(p.toks) {
+		return p.toks[p.pos], true
+	}
+	return token{}, false
+}
+
+func (p *parser) keyword(kw string) bool {
+	t, ok := p.peek()
+	if ok && t.kind == "word" && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) or() (node, error) {
+	l, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		r, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		l = orNode{l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) and() (node, error) {
+	l, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		l = andNode{l, r}
+	}
+	return l, nil
+}
+
+func (p *parser) unary() (node, error) {
+	if p.keyword("not") {
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	}
+	t, ok := p.peek()
+	if !ok {
+		return nil, errors.New("condition ends early")
+	}
+	if t.kind == "(" {
+		p.pos++
+		n, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := p.peek(); !ok || t.kind != ")" {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return n, nil
+	}
+	return p.comparison()
+}
+
+var nameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z0-9_-]+)*$`)
+
+func (p *parser) comparison() (node, error) {
+	t := p.toks[p.pos]
+	if t.kind != "word" || !nameRe.MatchString(t.text) {
+		return nil, fmt.Errorf("expected a name, got %q", t.text)
+	}
+	name := t.text
+	if !strings.HasPrefix(name, "event.") && !contains(StatNames, name) {
+		return nil, fmt.Errorf("unknown name %q (use event.FIELD or one of %s)", name, strings.Join(StatNames, ", "))
+	}
+	p.vars[name] = true
+	p.pos++
+
+	op, ok := p.peek()
+	if !ok || op.kind != "op" {
+		return truthNode{name}, nil
+	}
+	p.pos++
+	v, ok := p.peek()
+	if !ok || (v.kind != "word" && v.kind != "str") {
+		return nil, fmt.Errorf("%s %s: missing value", name, op.text)
+	}
+	p.pos++
+
+	val, err := parseValue(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s %s: %w", name, op.text, v.text, err)
+	}
+	if _, num := val.(float64); !num && op.text != "==" && op.text != "!=" {
+		return nil, fmt.Errorf("%s %s %s: %s compares numbers only", name, op.text, v.text, op.text)
+	}
+	return cmpNode{name: name, op: op.text, val: val}, nil
+}
+
+var numberRe = regexp.MustCompile(`^(-?[0-9]+(?:\.[0-9]+)?)([A-Za-z%]*)$`)
+
+// sizeUnits are binary; "GiB" is read as "GB".
+var sizeUnits = map[string]float64{
+	"":   1,
+	"%":  1,
+	"k":  1 << 10,
+	"kb": 1 << 10,
+	"m":  1 << 20,
+	"mb": 1 << 20,
+	"g":  1 << 30,
+	"gb": 1 << 30,
+	"t":  1 << 40,
+	"tb": 1 << 40,
+}
+
+func parseValue(t token) (any, error) {
+	if t.kind == "str" {
+		return t.text, nil
+	}
+	switch strings.ToLower(t.text) {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	m := numberRe.FindStringSubmatch(t.text)
+	if m == nil {
+		return t.text, nil
+	}
+	f, err := strconv.ParseFloat(m[1], 64)
+	if err != nil || math.IsInf(f, 0) {
+		return nil, errors.New("bad number")
+	}
+	mult, ok := sizeUnits[strings.Replace(strings.ToLower(m[2]), "ib", "b", 1)]
+	if !ok {
+		return nil, fmt.Errorf("unknown unit %q (use KB, MB, GB, TB or %%)", m[2])
+	}
+	return f * mult, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,292 @@
+// Package rules evaluates the [[rules]] in the config: condition/action
+// pairs over event fields and station statistics, such as "when disk_free
+// < 2GB, pause and notify". It is the declarative counterpart to plugins
+// for simple automations.
+//
+// A rule whose condition refers to event fields is checked against every
+// event and fires each time one matches. A rule over station statistics
+// alone describes a situation rather than an occurrence, so it fires once
+// when the condition becomes true and again only after it has been false.
+// Either kind may also set a cooldown between firings.
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/ws"
+)
+
+// Actions a rule may take. "notify" logs the rule's message as a warning,
+// which reaches every WebSocket client and plugin; the others are the
+// scheduler controls of the same name.
+var Actions = []string{"notify", "pause", "resume", "skip"}
+
+// Component is the event component for everything the engine emits.
+// Events from it are not evaluated, so a rule cannot trigger itself.
+const Component = "rules"
+
+// queueSize is how many events the engine may fall behind by before
+// events are dropped.
+const queueSize = 256
+
+// Rule is one rule to evaluate.
+type Rule struct {
+	Name     string
+	When     string
+	Actions  []string
+	Message  string // for notify; defaults to the condition
+	Cooldown time.Duration
+}
+
+// Status describes one rule and how often it has fired.
+type Status struct {
+	Name       string   `json:"name"`
+	When       string   `json:"when"`
+	Actions    []string `json:"actions"`
+	Active     bool     `json:"active"` // the condition held at the last check
+	Hits       uint64   `json:"hits"`
+	Suppressed uint64   `json:"suppressed"` // matches skipped for the cooldown
+	LastFired  string   `json:"last_fired,omitempty"`
+	LastError  string   `json:"last_error,omitempty"`
+}
+
+// EmitFunc publishes a daemon event, as App.emit does.
+type EmitFunc func(component string, payload map[string]any)
+
+// ActFunc performs action (other than notify) on behalf of rule.
+type ActFunc func(rule, action string) error
+
+// StatsFunc returns the current station statistics, keyed by StatNames.
+type StatsFunc func() map[string]any
+
+// Engine evaluates rules against the daemon's events.
+type Engine struct {
+	hub   *ws.Hub
+	log   *slog.Logger
+	emit  EmitFunc
+	act   ActFunc
+	stats StatsFunc
+
+	mu    sync.Mutex
+	rules []*rule
+}
+
+type rule struct {
+	Rule
+	cond *Condition
+
+	active     bool
+	hits       uint64
+	suppressed uint64
+	lastFired  time.Time
+	lastError  string
+}
+
+// NewEngine returns an Engine for hub's events.
+func NewEngine(hub *ws.Hub, logger *slog.Logger, emit EmitFunc, act ActFunc, stats StatsFunc) *Engine {
+	return &Engine{hub: hub, log: logger.With("component", Component), emit: emit, act: act, stats: stats}
+}
+
+// Check reports whether r is a valid rule.
+func Check(r Rule) error {
+	if _, err := Parse(r.When); err != nil {
+		return err
+	}
+	if len(r.Actions) == 0 {
+		return fmt.Errorf("no actions (use %s)", strings.Join(Actions, ", "))
+	}
+	for _, a := range r.Actions {
+		if !contains(Actions, a) {
+			return fmt.Errorf("unknown action %q (use %s)", a, strings.Join(Actions, ", "))
+		}
+	}
+	return nil
+}
+
+// Update replaces the rules. Counters carry over for rules that are
+// unchanged. If any rule is invalid, nothing is replaced.
+func (e *Engine) Update(rs []Rule) error {
+	next := make([]*rule, 0, len(rs))
+	for _, r := range rs {
+		if err := Check(r); err != nil {
+			return fmt.Errorf("rule %s: %w", r.Name, err)
+		}
+		cond, _ := Parse(r.When)
+		next = append(next, &rule{Rule: r, cond: cond})
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, n := range next {
+		for _, old := range e.rules {
+			if reflect.DeepEqual(old.Rule, n.Rule) {
+				next[i] = old
+			}
+		}
+	}
+	e.rules = next
+	return nil
+}
+
+// Status reports every rule, in name order.
+func (e *Engine) Status() []Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Status, 0, len(e.rules))
+	for _, r := range e.rules {
+		s := Status{
+			Name:       r.Name,
+			When:       r.When,
+			Actions:    r.Actions,
+			Active:     r.active,
+			Hits:       r.hits,
+			Suppressed: r.suppressed,
+			LastError:  r.lastError,
+		}
+		if !r.lastFired.IsZero() {
+			s.LastFired = r.lastFired.UTC().Format(time.RFC3339)
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Run evaluates the rules against each event until ctx is cancelled.
+// Heartbeats keep rules over station statistics checked even when
+// nothing else is happening.
+func (e *Engine) Run(ctx context.Context) {
+	events, cancel := e.hub.Subscribe(queueSize)
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-events:
+			var ev map[string]any
+			if json.Unmarshal(msg, &ev) != nil || ev["component"] == Component {
+				continue
+			}
+			e.evaluate(ev)
+		}
+	}
+}
+
+// firing is a rule that matched, to be acted on outside the lock.
+type firing struct {
+	r       *rule
+	trigger string
+}
+
+func (e *Engine) evaluate(ev map[string]any) {
+	var stats map[string]any
+	lookup := func(name string) (any, bool) {
+		if field, ok := strings.CutPrefix(name, "event."); ok {
+			return lookupField(ev, field)
+		}
+		if stats == nil {
+			stats = e.stats()
+		}
+		v, ok := stats[name]
+		return v, ok
+	}
+
+	now := time.Now()
+	var fire []firing
+	e.mu.Lock()
+	for _, r := range e.rules {
+		held := r.cond.Eval(lookup)
+		was := r.active
+		r.active = held
+		if !held || (was && !r.cond.UsesEvent()) {
+			continue
+		}
+		if r.Cooldown > 0 && !r.lastFired.IsZero() && now.Sub(r.lastFired) < r.Cooldown {
+			r.suppressed++
+			continue
+		}
+		r.hits++
+		r.lastFired = now
+		trigger, _ := ev["type"].(string)
+		fire = append(fire, firing{r: r, trigger: trigger})
+	}
+	e.mu.Unlock()
+
+	for _, f := range fire {
+		e.fire(f.r, f.trigger)
+	}
+}
+
+// fire runs a matched rule's actions and reports it.
+func (e *Engine) fire(r *rule, trigger string) {
+	var errs []string
+	for _, action := range r.Actions {
+		if action == "notify" {
+			msg := r.Message
+			if msg == "" {
+				msg = fmt.Sprintf("rule %s: %s", r.Name, r.When)
+			}
+			e.emit(Component, map[string]any{
+				"type":    "log",
+				"level":   "warn",
+				"rule":    r.Name,
+				"message": msg,
+			})
+			continue
+		}
+		if err := e.act(r.Name, action); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", action, err))
+		}
+	}
+
+	e.mu.Lock()
+	r.lastError = strings.Join(errs, "; ")
+	e.mu.Unlock()
+
+	e.emit(Component, map[string]any{
+		"type":    "rule_fired",
+		"rule":    r.Name,
+		"when":    r.When,
+		"actions": r.Actions,
+		"trigger": trigger,
+	})
+	level, msg := "info", fmt.Sprintf("rule %s fired (%s)", r.Name, strings.Join(r.Actions, ", "))
+	if len(errs) > 0 {
+		level, msg = "error", fmt.Sprintf("rule %s: %s", r.Name, strings.Join(errs, "; "))
+	}
+	if len(errs) > 0 {
+		e.log.Error("rule actions failed", "rule", r.Name, "errors", errs)
+	} else {
+		e.log.Info("rule fired", "rule", r.Name, "actions", r.Actions, "trigger", trigger)
+	}
+	e.emit(Component, map[string]any{
+		"type":    "log",
+		"level":   level,
+		"rule":    r.Name,
+		"message": msg,
+	})
+}
+
+// lookupField finds a dotted field path in an event.
+func lookupField(ev map[string]any, path string) (any, bool) {
+	var cur any = ev
+	for _, key := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
@@ -0,0 +1,129 @@
+// Package satnogs looks satellites up in SatNOGS DB, the community
+// database of satellites and their transmitters, so the catalog can follow
+// a satellite that changes frequency or goes silent.
+package satnogs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Satellite is what SatNOGS DB knows about one NORAD ID.
+type Satellite struct {
+	NoradID int    `json:"norad_id"`
+	Name    string `json:"name"`
+	// Status is "alive", "dead", "re-entered" or "future".
+	Status       string        `json:"status"`
+	Transmitters []Transmitter `json:"transmitters"`
+}
+
+// Transmitter is one of a satellite's downlinks.
+type Transmitter struct {
+	Description string `json:"description"`
+	Mode        string `json:"mode"`        // such as "APT" or "LRPT"
+	DownlinkHz  int    `json:"downlink_hz"` // 0 for uplink-only entries
+	Active      bool   `json:"active"`      // listed as active and alive
+}
+
+// Downlink returns the active transmitter in mode, matched ignoring case.
+// When there are several, the one nearest near wins, so a stale entry
+// cannot pull the catalog across the band.
+func (s Satellite) Downlink(mode string, near int) (Transmitter, bool) {
+	var best Transmitter
+	found := false
+	for _, t := range s.Transmitters {
+		if !t.Active || t.DownlinkHz == 0 || !strings.EqualFold(t.Mode, mode) {
+			continue
+		}
+		if !found || abs(t.DownlinkHz-near) < abs(best.DownlinkHz-near) {
+			best, found = t, true
+		}
+	}
+	return best, found
+}
+
+// dbSatellite and dbTransmitter are the subsets of the SatNOGS DB API
+// responses we use.
+type dbSatellite struct {
+	NoradID int    `json:"norad_cat_id"`
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+}
+
+type dbTransmitter struct {
+	Description string `json:"description"`
+	Alive       bool   `json:"alive"`
+	Status      string `json:"status"`
+	DownlinkLow *int   `json:"downlink_low"`
+	Mode        string `json:"mode"`
+}
+
+// Lookup fetches satellite noradID and its transmitters from the SatNOGS
+// DB instance at baseURL, such as https://db.satnogs.org.
+func Lookup(baseURL string, noradID int, timeout time.Duration) (Satellite, error) {
+	client := &http.Client{Timeout: timeout}
+	id := strconv.Itoa(noradID)
+
+	var sats []dbSatellite
+	if err := get(client, baseURL, "/api/satellites/", "norad_cat_id", id, &sats); err != nil {
+		return Satellite{}, err
+	}
+	if len(sats) == 0 {
+		return Satellite{}, fmt.Errorf("NORAD %d is not in SatNOGS DB", noradID)
+	}
+	var txs []dbTransmitter
+	if err := get(client, baseURL, "/api/transmitters/", "satellite__norad_cat_id", id, &txs); err != nil {
+		return Satellite{}, err
+	}
+
+	sat := Satellite{NoradID: noradID, Name: sats[0].Name, Status: sats[0].Status, Transmitters: []Transmitter{}}
+	for _, t := range txs {
+		tx := Transmitter{
+			Description: t.Description,
+			Mode:        t.Mode,
+			Active:      t.Alive && t.Status == "active",
+		}
+		if t.DownlinkLow != nil {
+			tx.DownlinkHz = *t.DownlinkLow
+		}
+		sat.Transmitters = append(sat.Transmitters, tx)
+	}
+	return sat, nil
+}
+
+// get queries one SatNOGS DB list endpoint filtered by key=value.
+func get(client *http.Client, baseURL, path, key, value string, dst any) error {
+	u, err := url.Parse(strings.TrimRight(baseURL, "/") + path)
+	if err != nil {
+		return fmt.Errorf("satnogs url: %w", err)
+	}
+	q := u.Query()
+	q.Set(key, value)
+	q.Set("format", "json")
+	u.RawQuery = q.Encode()
+
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return fmt.Errorf("satnogs lookup: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("satnogs lookup %s: HTTP %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return fmt.Errorf("satnogs decode %s: %w", path, err)
+	}
+	return nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
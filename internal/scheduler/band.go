@@ -0,0 +1,133 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+// bandGroup returns the indexes of upcoming[i] and of the later passes that
+// overlap it in time and can be recorded along with it in one band
+// capture. Without sdr.band_capture it is just i, and the overlapping
+// passes are missed as before.
+func (r *Runner) bandGroup(upcoming []predict.Pass, i int) []int {
+	group := []int{i}
+	if !r.Cfg.SDR.BandCapture {
+		return group
+	}
+	end := upcoming[i].RecordLOS
+	for j := i + 1; j < len(upcoming) && upcoming[j].AOS.Before(end); j++ {
+		p := upcoming[j]
+		if !p.RecordAOS.Before(end) {
+			continue
+		}
+		reqs := []capture.CaptureRequest{captureRequest(p, "")}
+		for _, k := range group {
+			reqs = append(reqs, captureRequest(upcoming[k], ""))
+		}
+		if !r.capturer.BandFits(reqs) {
+			continue
+		}
+		group = append(group, j)
+		if p.RecordLOS.After(end) {
+			end = p.RecordLOS
+		}
+	}
+	return group
+}
+
+// satelliteNames lists the satellites of passes.
+func satelliteNames(passes []predict.Pass) []string {
+	names := make([]string, len(passes))
+	for i, p := range passes {
+		names[i] = p.Satellite.Name
+	}
+	return names
+}
+
+// runBandCapture records the overlapping passes of group, the first of
+// which has just reached its AOS, in one band capture, then decodes each
+// recording in turn. It mirrors the single-pass path in Run.
+func (r *Runner) runBandCapture(ctx, passCtx context.Context, group []predict.Pass, setState func(string)) {
+	pass := group[0]
+	reqs := make([]capture.CaptureRequest, len(group))
+	for i, p := range group {
+		reqs[i] = captureRequest(p, r.Cfg.Station.Active)
+	}
+	r.notifyPass(&PassInfo{
+		Satellite: pass.Satellite.Name,
+		NoradID:   pass.Satellite.NoradID,
+		FreqHz:    pass.Satellite.Freq,
+		AOS:       pass.AOS.Format(time.RFC3339),
+		LOS:       pass.LOS.Format(time.RFC3339),
+		MaxElev:   pass.MaxElev,
+		Stage:     "recording",
+		Station:   r.Cfg.Station.Active,
+	})
+
+	spanCtx, captureSpan := r.tracer.Start(passCtx, "capture", "band", strings.Join(satelliteNames(group), ", "))
+	captureCtx, captureCancel := context.WithCancel(spanCtx)
+	r.captureMu.Lock()
+	r.captureCancel = captureCancel
+	r.captureMu.Unlock()
+
+	los := reqs[0].LOS
+	for _, req := range reqs {
+		if req.LOS.After(los) {
+			los = req.LOS
+		}
+	}
+	r.expectBusyUntil(los)
+	for i, p := range group {
+		r.spendEnergy(reqs[i])
+		r.notifyCaptureStart(p.Satellite.Name)
+	}
+	results := r.capturer.CaptureBand(captureCtx, reqs, setState)
+	stopped := captureCtx.Err() != nil
+	captureCancel()
+	captureSpan.End()
+
+	r.captureMu.Lock()
+	r.captureCancel = nil
+	r.captureMu.Unlock()
+
+	for i, res := range results {
+		p := group[i]
+		r.recordOutcome(ctx, reqs[i], res.Path, res.Err, stopped, false)
+		if res.Err != nil {
+			r.broadcast(map[string]any{
+				"type":    "log",
+				"level":   "error",
+				"message": fmt.Sprintf("capture of %s failed: %v", p.Satellite.Name, res.Err),
+			})
+			r.notifyCaptureFailed(p.Satellite.Name, res.Err)
+		} else if res.Path != "" && r.captureCallback != nil {
+			if size, statErr := captureFileSize(res.Path); statErr == nil {
+				r.captureCallback(p.Satellite.Name, size)
+			}
+		}
+	}
+
+	for i, res := range results {
+		if res.Err != nil || res.Path == "" || ctx.Err() != nil {
+			continue
+		}
+		p := group[i]
+		setState("DECODING")
+		r.notifyPass(&PassInfo{
+			Satellite: p.Satellite.Name,
+			NoradID:   p.Satellite.NoradID,
+			FreqHz:    p.Satellite.Freq,
+			AOS:       p.AOS.Format(time.RFC3339),
+			LOS:       p.LOS.Format(time.RFC3339),
+			MaxElev:   p.MaxElev,
+			Stage:     "decoding",
+			Station:   r.Cfg.Station.Active,
+		})
+		r.decodeCapture(passCtx, p.Satellite, res.Path, p.Direction() == predict.Northbound)
+	}
+}
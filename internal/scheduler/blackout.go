@@ -0,0 +1,78 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+// SetBlackouts registers a function returning the [[schedule.blackout]]
+// windows. Like the satellite filter it is consulted on every schedule
+// computation, so a reload applies them after a Reschedule.
+func (r *Runner) SetBlackouts(fn func() []config.Blackout) {
+	r.blackouts = fn
+}
+
+// applyBlackouts drops passes whose recording overlaps a blackout window,
+// in the station's time zone. A pass_blackout event names each, once per
+// pass.
+func (r *Runner) applyBlackouts(passes []predict.Pass) []predict.Pass {
+	if r.blackouts == nil {
+		return passes
+	}
+	windows := r.blackouts()
+	if len(windows) == 0 {
+		return passes
+	}
+	loc := r.Cfg.Station.Location()
+	kept := make([]predict.Pass, 0, len(passes))
+	for _, p := range passes {
+		blocked := false
+		for _, b := range windows {
+			if start, end, ok := b.Overlaps(p.RecordAOS.In(loc), p.RecordLOS); ok {
+				r.announceBlackout(p, b, start, end)
+				blocked = true
+				break
+			}
+		}
+		if !blocked {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// announceBlackout emits a pass_blackout event for p, kept out of the
+// schedule by b from start to end, unless it was announced already.
+func (r *Runner) announceBlackout(p predict.Pass, b config.Blackout, start, end time.Time) {
+	now := time.Now()
+	for key, aos := range r.blackoutsAnnounced {
+		if aos.Before(now) {
+			delete(r.blackoutsAnnounced, key)
+		}
+	}
+	key := p.Satellite.Name + "@" + p.AOS.Format(time.RFC3339)
+	if _, ok := r.blackoutsAnnounced[key]; ok {
+		return
+	}
+	if r.blackoutsAnnounced == nil {
+		r.blackoutsAnnounced = make(map[string]time.Time)
+	}
+	r.blackoutsAnnounced[key] = p.AOS
+
+	r.broadcast(map[string]any{
+		"type":           "pass_blackout",
+		"satellite":      p.Satellite.Name,
+		"aos":            p.AOS.Format(time.RFC3339),
+		"los":            p.LOS.Format(time.RFC3339),
+		"aos_unix":       p.AOS.Unix(),
+		"los_unix":       p.LOS.Unix(),
+		"max_elev":       p.MaxElev,
+		"blackout":       b.Name,
+		"blackout_start": start.Format(time.RFC3339),
+		"blackout_end":   end.Format(time.RFC3339),
+		"reason":         fmt.Sprintf("blackout %s, %s to %s", b.Name, start.Format("Mon 15:04"), end.Format("15:04 MST")),
+	})
+}
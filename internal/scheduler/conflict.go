@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+// SetSatellitePriority registers a function returning a satellite's
+// conflict priority. It is consulted on every schedule computation.
+func (r *Runner) SetSatellitePriority(fn func(name string) int) {
+	r.satellitePriority = fn
+}
+
+// PassScore is what a pass scores under predict.conflict_policy =
+// "score", and the points it is made of.
+type PassScore struct {
+	Value   float64      `json:"value"`
+	Factors ScoreFactors `json:"factors"`
+}
+
+// ScoreFactors are the parts of a PassScore: 100 points per step of the
+// satellite's priority, a point per degree of peak elevation and a point
+// per minute recorded.
+type ScoreFactors struct {
+	Priority        int     `json:"priority"`
+	PriorityPoints  float64 `json:"priority_points"`
+	ElevationPoints float64 `json:"elevation_points"`
+	LengthPoints    float64 `json:"length_points"`
+}
+
+// ScorePass rates a pass for predict.conflict_policy = "score": 100 per
+// step of priority, so priority always decides first, then the peak
+// elevation in degrees plus the recorded length in minutes.
+func ScorePass(p predict.Pass, priority int) PassScore {
+	f := ScoreFactors{
+		Priority:        priority,
+		PriorityPoints:  100 * float64(priority),
+		ElevationPoints: p.MaxElev,
+		LengthPoints:    p.RecordLOS.Sub(p.RecordAOS).Minutes(),
+	}
+	return PassScore{Value: f.PriorityPoints + f.ElevationPoints + f.LengthPoints, Factors: f}
+}
+
+// recordingsOverlap reports whether the recordings of a and b overlap.
+func recordingsOverlap(a, b predict.Pass) bool {
+	return a.RecordAOS.Before(b.RecordLOS) && b.RecordAOS.Before(a.RecordLOS)
+}
+
+// resolveConflicts drops passes whose recording overlaps one that is kept,
+// taking them best first under predict.conflict_policy: by score, or in
+// AOS order for "first". Passes a band capture can record together are
+// not in conflict. A pass_conflict event names each dropped pass and the
+// one it lost to, once per pass. Passes the operator forced are taken
+// before all others.
+func (r *Runner) resolveConflicts(passes []predict.Pass) []predict.Pass {
+	policy := r.Cfg.Predict.ConflictPolicy
+	priority := make([]int, len(passes))
+	score := make([]float64, len(passes))
+	order := make([]int, len(passes))
+	for i, p := range passes {
+		if r.satellitePriority != nil {
+			priority[i] = r.satellitePriority(p.Satellite.Name)
+		}
+		score[i] = ScorePass(p, priority[i]).Value
+		order[i] = i
+	}
+	if policy != "first" {
+		// Stable, so equal scores keep AOS order.
+		slices.SortStableFunc(order, func(a, b int) int {
+			switch {
+			case score[a] > score[b]:
+				return -1
+			case score[a] < score[b]:
+				return 1
+			}
+			return 0
+		})
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		fa, fb := r.override(passes[a]) == OverrideForce, r.override(passes[b]) == OverrideForce
+		switch {
+		case fa && !fb:
+			return -1
+		case fb && !fa:
+			return 1
+		}
+		return 0
+	})
+
+	var kept []int
+	for _, i := range order {
+		var overlapping []int
+		for _, k := range kept {
+			if recordingsOverlap(passes[i], passes[k]) {
+				overlapping = append(overlapping, k)
+			}
+		}
+		if len(overlapping) == 0 || r.shareBand(passes, append(overlapping, i)) {
+			kept = append(kept, i)
+			continue
+		}
+		w := overlapping[0]
+		r.announceConflict(passes[i], passes[w], policy, [2]int{priority[i], priority[w]}, [2]float64{score[i], score[w]})
+	}
+
+	slices.Sort(kept)
+	out := make([]predict.Pass, len(kept))
+	for j, i := range kept {
+		out[j] = passes[i]
+	}
+	return out
+}
+
+// shareBand reports whether the passes at idx can all be recorded in one
+// band capture.
+func (r *Runner) shareBand(passes []predict.Pass, idx []int) bool {
+	if !r.Cfg.SDR.BandCapture {
+		return false
+	}
+	reqs := make([]capture.CaptureRequest, len(idx))
+	for k, i := range idx {
+		reqs[k] = captureRequest(passes[i], "")
+	}
+	return r.capturer.BandFits(reqs)
+}
+
+// announceConflict emits a pass_conflict event for lost, dropped in favor
+// of won, unless it was announced already. Announcements for passes that
+// have begun are forgotten.
+func (r *Runner) announceConflict(lost, won predict.Pass, policy string, priority [2]int, score [2]float64) {
+	now := time.Now()
+	for key, aos := range r.conflictsAnnounced {
+		if aos.Before(now) {
+			delete(r.conflictsAnnounced, key)
+		}
+	}
+	key := lost.Satellite.Name + "@" + lost.AOS.Format(time.RFC3339)
+	if _, ok := r.conflictsAnnounced[key]; ok {
+		return
+	}
+	if r.conflictsAnnounced == nil {
+		r.conflictsAnnounced = make(map[string]time.Time)
+	}
+	r.conflictsAnnounced[key] = lost.AOS
+
+	reason := fmt.Sprintf("%s rises first, at %s", won.Satellite.Name, won.AOS.UTC().Format("15:04:05Z"))
+	if policy != "first" {
+		reason = fmt.Sprintf("%s scores %.1f (%s) against %.1f (%s)",
+			won.Satellite.Name, score[1], describeScore(won, priority[1]), score[0], describeScore(lost, priority[0]))
+	}
+	r.broadcast(map[string]any{
+		"type":            "pass_conflict",
+		"satellite":       lost.Satellite.Name,
+		"aos":             lost.AOS.Format(time.RFC3339),
+		"los":             lost.LOS.Format(time.RFC3339),
+		"aos_unix":        lost.AOS.Unix(),
+		"los_unix":        lost.LOS.Unix(),
+		"max_elev":        lost.MaxElev,
+		"score":           roundScore(score[0]),
+		"winner":          won.Satellite.Name,
+		"winner_aos":      won.AOS.Format(time.RFC3339),
+		"winner_los":      won.LOS.Format(time.RFC3339),
+		"winner_aos_unix": won.AOS.Unix(),
+		"winner_los_unix": won.LOS.Unix(),
+		"winner_max_elev": won.MaxElev,
+		"winner_score":    roundScore(score[1]),
+		"policy":          policy,
+		"reason":          reason,
+	})
+}
+
+// describeScore lists what went into a pass's score.
+func describeScore(p predict.Pass, priority int) string {
+	return fmt.Sprintf("priority %d, max elev %.1f°, %.1f min", priority, p.MaxElev, p.RecordLOS.Sub(p.RecordAOS).Minutes())
+}
+
+func roundScore(s float64) float64 {
+	return math.Round(s*10) / 10
+}
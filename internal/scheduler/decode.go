@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/decode"
+)
+
+// decodeBudget is how long the watchdog allows for decoding one capture.
+// A fifteen-minute pass decodes in seconds, so this is only a backstop.
+const decodeBudget = 5 * time.Minute
+
+// decodeCapture turns the recording at path into images, broadcasting
+// progress as it goes. rotate flips the images for northbound passes. A
+// failed decode is logged and recorded in the sidecar; the recording
+// itself is kept either way. Only APT satellites are decoded. Unless the
+// daemon is shutting down, the processed callback is called for path once
+// decoding is over, whether or not it succeeded.
+func (r *Runner) decodeCapture(ctx context.Context, sat capture.Satellite, path string, rotate bool) {
+	defer func() {
+		if ctx.Err() == nil {
+			r.notifyProcessed(path)
+		}
+	}()
+	satellite := sat.Name
+	if sat.Mode != capture.ModeAPT {
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": fmt.Sprintf("%s transmits %s, which is not decoded; recording kept", satellite, sat.Mode),
+		})
+		r.recordDecode(path, &capture.DecodeInfo{Status: capture.DecodeSkipped})
+		return
+	}
+	_, span := r.tracer.Start(ctx, "decode", "satellite", satellite)
+	defer span.End()
+	r.expectBusyUntil(time.Now().Add(decodeBudget))
+
+	var lastReport time.Time
+	progress := func(pct int) {
+		if pct < 100 && time.Since(lastReport) < 2*time.Second {
+			return
+		}
+		r.broadcast(map[string]any{
+			"type":    "progress",
+			"stage":   "decoding",
+			"percent": pct,
+			"detail":  fmt.Sprintf("%s APT decode", satellite),
+		})
+		lastReport = time.Now()
+	}
+
+	opts := decode.Options{
+		Rotate:   rotate,
+		Sync:     r.Cfg.Data.FsyncOnFinalize,
+		Progress: progress,
+		Enhance:  r.Cfg.Decode.Enhancements,
+		Shapes:   r.Cfg.Decode.OverlayShapes,
+	}
+	for _, e := range opts.Enhance {
+		if e != decode.EnhanceOverlay {
+			continue
+		}
+		if track, err := r.predictor.Track(sat.NoradID); err == nil {
+			opts.Track = track
+		} else {
+			r.Log.Warn("no ground track for the map overlay", "satellite", satellite, "err", err)
+		}
+	}
+	res, err := decode.Capture(ctx, r.Cfg.Data.Root, path, opts)
+	r.beat()
+	span.SetAttr("lines", res.Lines)
+	span.SetAttr("synced", res.Synced)
+	span.SetAttr("snr_db", res.SNR)
+	span.RecordError(err)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "error",
+			"message": fmt.Sprintf("decoding %s failed: %v", satellite, err),
+		})
+		r.recordDecode(path, &capture.DecodeInfo{
+			Status: capture.DecodeFailed,
+			Error:  err.Error(),
+			At:     time.Now().UTC().Format(time.RFC3339),
+		})
+		return
+	}
+	r.broadcast(map[string]any{
+		"type":    "log",
+		"level":   "info",
+		"message": fmt.Sprintf("decoded %s: %d lines, %d%% in sync, SNR %.1f dB, %d images", satellite, res.Lines, res.SyncPercent(), res.SNR, len(res.Images)),
+	})
+	for _, note := range res.Notes {
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "warn",
+			"message": fmt.Sprintf("decoding %s: %s", satellite, note),
+		})
+	}
+}
+
+// recordDecode keeps how decoding the capture at path went in its sidecar,
+// if it has one.
+func (r *Runner) recordDecode(path string, info *capture.DecodeInfo) {
+	m, err := capture.ReadMetadata(path)
+	if err != nil {
+		return
+	}
+	m.Decode = info
+	if err := capture.WriteMetadata(path, m, r.Cfg.Data.FsyncOnFinalize); err != nil {
+		r.Log.Warn("could not record the decode result", "file", filepath.Base(path), "err", err)
+	}
+}
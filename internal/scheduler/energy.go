@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+// EnergyBudget is how the day's schedule.energy_budget_wh is being spent:
+// on the captures made so far today, in the station's time zone, and on
+// the passes scheduled for the rest of it.
+type EnergyBudget struct {
+	Day       string  `json:"day"`
+	BudgetWh  float64 `json:"budget_wh"`
+	SpentWh   float64 `json:"spent_wh"`
+	PlannedWh float64 `json:"planned_wh"`
+}
+
+// savedEnergy is what the captures of Day have used, saved with the
+// scheduler's state so a restart does not hand out the budget again.
+type savedEnergy struct {
+	Day string  `json:"day"`
+	Wh  float64 `json:"wh"`
+}
+
+// SetEnergyBudget registers a function returning the [schedule] settings
+// the energy budget is read from. Like the blackouts it is consulted on
+// every schedule computation, so a reload applies it after a Reschedule.
+func (r *Runner) SetEnergyBudget(fn func() config.ScheduleConfig) {
+	r.energy = fn
+}
+
+// Energy returns how today's energy budget is being spent as of the
+// schedule last computed, or nil when there is no budget.
+func (r *Runner) Energy() *EnergyBudget {
+	r.planMu.Lock()
+	defer r.planMu.Unlock()
+	if r.energyBudget == nil {
+		return nil
+	}
+	b := *r.energyBudget
+	return &b
+}
+
+// passEnergy estimates the watt-hours recording p uses.
+func passEnergy(cfg config.ScheduleConfig, p predict.Pass) float64 {
+	return cfg.CaptureEnergy(p.RecordLOS.Sub(p.RecordAOS))
+}
+
+// energyDay returns the day t falls on in the station's time zone.
+func (r *Runner) energyDay(t time.Time) string {
+	return t.In(r.Cfg.Station.Location()).Format(time.DateOnly)
+}
+
+// spentEnergy returns the watt-hours the captures of day have used.
+func (r *Runner) spentEnergy(day string) float64 {
+	if e := r.state.Energy; e != nil && e.Day == day {
+		return e.Wh
+	}
+	return 0
+}
+
+// spendEnergy counts a capture starting against its day's budget.
+func (r *Runner) spendEnergy(req capture.CaptureRequest) {
+	if r.energy == nil {
+		return
+	}
+	day := r.energyDay(req.AOS)
+	r.state.Energy = &savedEnergy{Day: day, Wh: r.spentEnergy(day) + r.energy().CaptureEnergy(req.LOS.Sub(req.AOS))}
+	r.saveState()
+}
+
+// applyEnergyBudget keeps, of each day's passes, the ones scoring highest
+// whose captures fit in schedule.energy_budget_wh, less what the day's
+// captures have used already. Forced passes are always kept, and spend
+// the budget first.
+func (r *Runner) applyEnergyBudget(passes []predict.Pass, now time.Time) []predict.Pass {
+	var cfg config.ScheduleConfig
+	if r.energy != nil {
+		cfg = r.energy()
+	}
+	if cfg.EnergyBudgetWh <= 0 {
+		r.planMu.Lock()
+		r.energyBudget = nil
+		r.planMu.Unlock()
+		return passes
+	}
+
+	today := r.energyDay(now)
+	left := map[string]float64{}
+	byDay := map[string][]int{}
+	for i, p := range passes {
+		day := r.energyDay(p.RecordAOS)
+		if _, ok := left[day]; !ok {
+			left[day] = cfg.EnergyBudgetWh - r.spentEnergy(day)
+		}
+		byDay[day] = append(byDay[day], i)
+	}
+
+	keep := make([]bool, len(passes))
+	for day, idx := range byDay {
+		// Forced passes first, then the rest from the highest score down.
+		slices.SortStableFunc(idx, func(a, b int) int {
+			pa, pb := passes[a], passes[b]
+			fa, fb := r.override(pa) == OverrideForce, r.override(pb) == OverrideForce
+			if fa != fb {
+				if fa {
+					return -1
+				}
+				return 1
+			}
+			return cmp.Or(
+				cmp.Compare(pb.Quality, pa.Quality),
+				cmp.Compare(pb.MaxElev, pa.MaxElev),
+				pa.AOS.Compare(pb.AOS),
+			)
+		})
+		for _, i := range idx {
+			cost := passEnergy(cfg, passes[i])
+			if r.override(passes[i]) == OverrideForce || cost <= left[day] {
+				keep[i] = true
+				left[day] -= cost
+			}
+		}
+	}
+
+	kept := make([]predict.Pass, 0, len(passes))
+	budget := &EnergyBudget{Day: today, BudgetWh: cfg.EnergyBudgetWh, SpentWh: r.spentEnergy(today)}
+	for i, p := range passes {
+		if !keep[i] {
+			continue
+		}
+		kept = append(kept, p)
+		if r.energyDay(p.RecordAOS) == today {
+			budget.PlannedWh += passEnergy(cfg, p)
+		}
+	}
+	budget.PlannedWh = math.Round(budget.PlannedWh*100) / 100
+	r.planMu.Lock()
+	r.energyBudget = budget
+	r.planMu.Unlock()
+
+	if n := len(passes) - len(kept); n > 0 {
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": fmt.Sprintf("not scheduling %d passes over the daily energy budget of %.1f Wh", n, cfg.EnergyBudgetWh),
+		})
+	}
+	return kept
+}
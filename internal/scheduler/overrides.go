@@ -0,0 +1,306 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+	"github.com/large-farva/ephemeris-engine/internal/store"
+)
+
+// Statuses of a ScheduledPass.
+const (
+	PassScheduled = "scheduled"
+	PassDropped   = "dropped"
+)
+
+// Operator overrides of a single pass, set through the "override" command.
+const (
+	// OverrideSkip keeps the pass out of the schedule.
+	OverrideSkip = "skip"
+	// OverrideForce records the pass whatever the blackout windows,
+	// min_quality, sun and weather policies and disabled satellites say,
+	// and lets it win conflicts with passes that are not forced.
+	OverrideForce = "force"
+	// OverrideClear removes either override again.
+	OverrideClear = "clear"
+)
+
+// ScheduledPass is one pass of the schedule last computed, and whether it
+// made it into the schedule.
+type ScheduledPass struct {
+	// ID names the pass in the override command: the satellite's NORAD ID
+	// and its AOS in Unix seconds, such as "33591-1792267200".
+	ID        string  `json:"id"`
+	Satellite string  `json:"satellite"`
+	NoradID   int     `json:"norad_id"`
+	AOS       string  `json:"aos"`
+	LOS       string  `json:"los"`
+	AOSUnix   int64   `json:"aos_unix"`
+	LOSUnix   int64   `json:"los_unix"`
+	MaxElev   float64 `json:"max_elev"`
+	Quality   int     `json:"quality"`
+	Status    string  `json:"status"`
+	// Reason says what dropped the pass: skipped, blackout, quality, sun,
+	// weather, disabled, conflict or energy.
+	Reason   string `json:"reason,omitempty"`
+	Override string `json:"override,omitempty"`
+	// EnergyWh is what recording the pass is estimated to use, while
+	// schedule.energy_budget_wh is set.
+	EnergyWh float64 `json:"energy_wh,omitempty"`
+}
+
+// PassID returns the ID of p in a ScheduledPass.
+func PassID(p predict.Pass) string {
+	return fmt.Sprintf("%d-%d", p.Satellite.NoradID, p.AOS.Unix())
+}
+
+// Schedule returns the passes of the schedule last computed, passes that
+// were dropped included, in AOS order, and when it was computed. It is
+// empty until the scheduler has computed one.
+func (r *Runner) Schedule() (time.Time, []ScheduledPass) {
+	r.planMu.Lock()
+	defer r.planMu.Unlock()
+	return r.planAt, slices.Clone(r.plan)
+}
+
+// planner records which step of the schedule computation drops each pass.
+// Forced passes are left out of the steps until admitted for the last.
+type planner struct {
+	passes []predict.Pass
+	out    []ScheduledPass
+	live   map[string]int // passes not dropped yet, by ID, to their index
+}
+
+func (r *Runner) newPlanner(passes []predict.Pass) *planner {
+	pl := &planner{passes: passes, live: make(map[string]int, len(passes))}
+	for i, p := range passes {
+		pl.out = append(pl.out, ScheduledPass{
+			ID:        PassID(p),
+			Satellite: p.Satellite.Name,
+			NoradID:   p.Satellite.NoradID,
+			AOS:       p.AOS.Format(time.RFC3339),
+			LOS:       p.LOS.Format(time.RFC3339),
+			AOSUnix:   p.AOS.Unix(),
+			LOSUnix:   p.LOS.Unix(),
+			MaxElev:   p.MaxElev,
+			Quality:   p.Quality,
+			Status:    PassScheduled,
+			Override:  r.override(p),
+		})
+		if r.energy != nil {
+			if cfg := r.energy(); cfg.EnergyBudgetWh > 0 {
+				pl.out[i].EnergyWh = math.Round(passEnergy(cfg, p)*100) / 100
+			}
+		}
+		if pl.out[i].Override != OverrideForce {
+			pl.live[pl.out[i].ID] = i
+		}
+	}
+	return pl
+}
+
+// admit has the following steps weigh the forced passes too.
+func (pl *planner) admit(forced []predict.Pass) {
+	for _, p := range forced {
+		for i := range pl.out {
+			if pl.out[i].ID == PassID(p) {
+				pl.live[pl.out[i].ID] = i
+			}
+		}
+	}
+}
+
+// drop marks the passes still in the schedule that are not in kept as
+// dropped for reason, and returns kept.
+func (pl *planner) drop(reason string, kept []predict.Pass) []predict.Pass {
+	keep := make(map[string]bool, len(kept))
+	for _, p := range kept {
+		keep[PassID(p)] = true
+	}
+	for id, i := range pl.live {
+		if !keep[id] {
+			pl.out[i].Status, pl.out[i].Reason = PassDropped, reason
+			delete(pl.live, id)
+		}
+	}
+	return kept
+}
+
+// publishPlan makes pl what Schedule returns.
+func (r *Runner) publishPlan(pl *planner) {
+	scheduled := 0
+	for _, p := range pl.out {
+		if p.Status == PassScheduled {
+			scheduled++
+			continue
+		}
+		r.Log.Debug("pass dropped", "id", p.ID, "satellite", p.Satellite, "aos", p.AOS, "reason", p.Reason)
+	}
+	r.Log.Debug("schedule computed", "passes", len(pl.out), "scheduled", scheduled)
+	r.planned = pl.passes
+	r.planMu.Lock()
+	r.plan, r.planAt = pl.out, time.Now().UTC()
+	r.planMu.Unlock()
+}
+
+// debugPlan reports how long predicting and planning the schedule took,
+// and what each step dropped, to the debug tap.
+func (r *Runner) debugPlan(pl *planner, predict, plan time.Duration) {
+	if !r.Hub.Tapped() {
+		return
+	}
+	scheduled, dropped := 0, map[string]int{}
+	for _, p := range pl.out {
+		if p.Status == PassDropped {
+			dropped[p.Reason]++
+		} else {
+			scheduled++
+		}
+	}
+	r.Hub.Debug("scheduler", map[string]any{
+		"predict_ms": float64(predict.Microseconds()) / 1000,
+		"plan_ms":    float64(plan.Microseconds()) / 1000,
+		"passes":     len(pl.out),
+		"scheduled":  scheduled,
+		"dropped":    dropped,
+		"commands":   len(r.Commands),
+	})
+}
+
+// override returns the operator's override of p, if any.
+func (r *Runner) override(p predict.Pass) string {
+	match := func(s savedPass) bool { return s.matches(p) }
+	switch {
+	case slices.ContainsFunc(r.state.Forced, match):
+		return OverrideForce
+	case slices.ContainsFunc(r.state.Skipped, match):
+		return OverrideSkip
+	}
+	return ""
+}
+
+// takeForced splits the passes the operator forced off the others, so the
+// filters do not see them, and forgets forced passes that have ended.
+func (r *Runner) takeForced(passes []predict.Pass, now time.Time) (forced, rest []predict.Pass) {
+	r.state.Forced = slices.DeleteFunc(r.state.Forced, func(s savedPass) bool { return s.LOS.Before(now) })
+	for _, p := range passes {
+		if r.override(p) == OverrideForce {
+			forced = append(forced, p)
+		} else {
+			rest = append(rest, p)
+		}
+	}
+	return forced, rest
+}
+
+// mergeForced adds the forced passes back to passes, in AOS order.
+func mergeForced(passes, forced []predict.Pass) []predict.Pass {
+	if len(forced) == 0 {
+		return passes
+	}
+	out := append(slices.Clone(passes), forced...)
+	slices.SortStableFunc(out, func(a, b predict.Pass) int { return a.AOS.Compare(b.AOS) })
+	return out
+}
+
+// handleOverrideCommand skips or forces one pass of the schedule, or
+// clears that again. The pass is found by its ID in the schedule last
+// computed; the override is saved with the scheduler's state and survives
+// TLE refreshes and restarts. Handling it interrupts any wait, so the
+// schedule is recomputed straight away.
+func (r *Runner) handleOverrideCommand(cmd Command) {
+	var payload struct {
+		ID     string `json:"id"`
+		Action string `json:"action"`
+	}
+	if err := json.Unmarshal(cmd.Payload, &payload); err != nil {
+		cmd.Reply <- CommandResult{OK: false, Error: "invalid payload: " + err.Error()}
+		return
+	}
+	if payload.Action != OverrideSkip && payload.Action != OverrideForce && payload.Action != OverrideClear {
+		cmd.Reply <- CommandResult{OK: false, Error: fmt.Sprintf("unknown action %q; use skip, force or clear", payload.Action)}
+		return
+	}
+	p, ok := r.plannedPass(payload.ID)
+	if !ok {
+		cmd.Reply <- CommandResult{OK: false, Error: fmt.Sprintf("no pass %s in the schedule", payload.ID)}
+		return
+	}
+
+	match := func(s savedPass) bool { return s.matches(p) }
+	r.state.Skipped = slices.DeleteFunc(r.state.Skipped, match)
+	r.state.Forced = slices.DeleteFunc(r.state.Forced, match)
+	var msg string
+	switch payload.Action {
+	case OverrideSkip:
+		r.state.Skipped = append(r.state.Skipped, toSaved(p))
+		msg = "skipping"
+		if r.nextPass != nil && toSaved(*r.nextPass).matches(p) {
+			r.recordSkipped(p)
+			r.notifyPass(nil)
+		}
+	case OverrideForce:
+		r.state.Forced = append(r.state.Forced, toSaved(p))
+		msg = "forcing"
+	default:
+		msg = "cleared the override of"
+	}
+	r.saveState()
+
+	override := r.override(p)
+	r.planMu.Lock()
+	for i := range r.plan {
+		if r.plan[i].ID == PassID(p) {
+			r.plan[i].Override = override
+		}
+	}
+	r.planMu.Unlock()
+
+	msg = fmt.Sprintf("%s %s pass at %s", msg, p.Satellite.Name, p.AOS.Format("2006-01-02 15:04:05Z"))
+	r.broadcast(map[string]any{
+		"type":    "log",
+		"level":   "info",
+		"message": msg + " by user request",
+	})
+	cmd.Reply <- CommandResult{OK: true, Message: msg + ", recomputing schedule"}
+}
+
+// plannedPass returns the pass of the schedule last computed with ID id,
+// or a pass within passMatch of it should a TLE refresh have moved it.
+func (r *Runner) plannedPass(id string) (predict.Pass, bool) {
+	norad, unix, ok := strings.Cut(id, "-")
+	n, err1 := strconv.Atoi(norad)
+	secs, err2 := strconv.ParseInt(unix, 10, 64)
+	if !ok || err1 != nil || err2 != nil {
+		return predict.Pass{}, false
+	}
+	want := savedPass{NoradID: n, AOS: time.Unix(secs, 0)}
+	for _, p := range r.planned {
+		if want.matches(p) {
+			return p, true
+		}
+	}
+	return predict.Pass{}, false
+}
+
+// recordSkipped records p in the history as skipped by the operator.
+func (r *Runner) recordSkipped(p predict.Pass) {
+	if r.outcomeCallback == nil {
+		return
+	}
+	r.outcomeCallback(store.Record{
+		Satellite: p.Satellite.Name,
+		NoradID:   p.Satellite.NoradID,
+		AOS:       p.AOS,
+		LOS:       p.LOS,
+		MaxElev:   p.MaxElev,
+		Station:   r.Cfg.Station.Active,
+		Outcome:   store.OutcomeSkipped,
+	})
+}
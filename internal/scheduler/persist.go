@@ -0,0 +1,284 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/capture"
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+const (
+	// scheduleFile keeps the scheduler's state under data.root: the last
+	// schedule computed, the passes the operator skipped, whether it is
+	// paused, and the pass and capture it was busy with. A restart, even
+	// one seconds before AOS, picks up from there.
+	scheduleFile = ".schedule.json"
+	// passMatch is how far a recomputed pass's AOS may be from a saved
+	// one's, such as after a TLE refresh, for the two to be the same pass.
+	passMatch = 2 * time.Minute
+	// minResume is the least recording time that must be left of an
+	// interrupted pass or capture for it to be resumed after a restart.
+	minResume = time.Minute
+)
+
+// savedState is the content of scheduleFile. Skipped and Forced are the
+// operator's overrides of single passes; see overrides.go. Only the scheduler goroutine
+// touches it.
+type savedState struct {
+	SavedAt  time.Time   `json:"saved_at"`
+	Paused   bool        `json:"paused,omitempty"`
+	Schedule []savedPass `json:"schedule,omitempty"`
+	Skipped  []savedPass `json:"skipped,omitempty"`
+	Forced   []savedPass `json:"forced,omitempty"`
+	// Next is the scheduled pass the loop was waiting for or recording.
+	Next *savedPass `json:"next,omitempty"`
+	// Capture is the capture in progress, scheduled or triggered.
+	Capture *savedCapture `json:"capture,omitempty"`
+	// Energy is what the day's captures have used of
+	// schedule.energy_budget_wh; see energy.go.
+	Energy *savedEnergy `json:"energy,omitempty"`
+}
+
+// savedPass is a predict.Pass as saved in scheduleFile.
+type savedPass struct {
+	Satellite       string    `json:"satellite"`
+	NoradID         int       `json:"norad_id"`
+	AOS             time.Time `json:"aos"`
+	LOS             time.Time `json:"los"`
+	RecordAOS       time.Time `json:"record_aos"`
+	RecordLOS       time.Time `json:"record_los"`
+	MaxElev         float64   `json:"max_elev"`
+	MaxElevTime     time.Time `json:"max_elev_time"`
+	AOSAzimuth      float64   `json:"aos_azimuth"`
+	LOSAzimuth      float64   `json:"los_azimuth"`
+	SunSeparation   float64   `json:"sun_separation"`
+	SunInterference bool      `json:"sun_interference,omitempty"`
+	Daylight        bool      `json:"daylight,omitempty"`
+	CloudCover      *int      `json:"cloud_cover,omitempty"`
+	Quality         int       `json:"quality,omitempty"`
+}
+
+// savedCapture is a capture in progress.
+type savedCapture struct {
+	Satellite string    `json:"satellite"`
+	NoradID   int       `json:"norad_id"`
+	Started   time.Time `json:"started"`
+	Until     time.Time `json:"until"`
+	Manual    bool      `json:"manual,omitempty"` // a triggered capture
+}
+
+func toSaved(p predict.Pass) savedPass {
+	return savedPass{
+		Satellite:       p.Satellite.Name,
+		NoradID:         p.Satellite.NoradID,
+		AOS:             p.AOS,
+		LOS:             p.LOS,
+		RecordAOS:       p.RecordAOS,
+		RecordLOS:       p.RecordLOS,
+		MaxElev:         p.MaxElev,
+		MaxElevTime:     p.MaxElevTime,
+		AOSAzimuth:      p.AOSAzimuth,
+		LOSAzimuth:      p.LOSAzimuth,
+		SunSeparation:   p.SunSeparation,
+		SunInterference: p.SunInterference,
+		Daylight:        p.Daylight,
+		CloudCover:      p.CloudCover,
+		Quality:         p.Quality,
+	}
+}
+
+// pass returns the saved pass, or false if its satellite is no longer in
+// the catalog.
+func (s savedPass) pass() (predict.Pass, bool) {
+	sat := capture.SatelliteByNoradID(s.NoradID)
+	if sat == nil {
+		return predict.Pass{}, false
+	}
+	return predict.Pass{
+		Satellite:       *sat,
+		AOS:             s.AOS,
+		LOS:             s.LOS,
+		MaxElev:         s.MaxElev,
+		MaxElevTime:     s.MaxElevTime,
+		AOSAzimuth:      s.AOSAzimuth,
+		LOSAzimuth:      s.LOSAzimuth,
+		Duration:        s.LOS.Sub(s.AOS),
+		RecordAOS:       s.RecordAOS,
+		RecordLOS:       s.RecordLOS,
+		SunSeparation:   s.SunSeparation,
+		SunInterference: s.SunInterference,
+		Daylight:        s.Daylight,
+		CloudCover:      s.CloudCover,
+		Quality:         s.Quality,
+	}, true
+}
+
+// matches reports whether p is the saved pass, recomputed.
+func (s savedPass) matches(p predict.Pass) bool {
+	d := p.AOS.Sub(s.AOS)
+	return p.Satellite.NoradID == s.NoradID && d > -passMatch && d < passMatch
+}
+
+// restoreState loads what the scheduler saved before the daemon last
+// stopped: it stays paused if it was, does not schedule skipped passes
+// again, and resumes the pass or triggered capture it was busy with.
+func (r *Runner) restoreState() {
+	b, err := os.ReadFile(filepath.Join(r.Cfg.Data.Root, scheduleFile))
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			r.Log.Warn("could not read the saved schedule", "file", scheduleFile, "err", err)
+		}
+		return
+	}
+	var st savedState
+	if err := json.Unmarshal(b, &st); err != nil {
+		r.Log.Warn("ignoring saved schedule", "file", scheduleFile, "err", err)
+		return
+	}
+	now := time.Now().UTC()
+	st.Skipped = slices.DeleteFunc(st.Skipped, func(s savedPass) bool { return s.LOS.Before(now) })
+	r.state = st
+	if st.Paused {
+		r.paused.Store(true)
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": "scheduler was paused before the restart; staying paused",
+		})
+	}
+	if len(st.Skipped) > 0 {
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": fmt.Sprintf("restored %d skipped passes", len(st.Skipped)),
+		})
+	}
+
+	// A triggered capture is resumed through the trigger command, which
+	// merges with the scheduled pass of the same satellite as usual.
+	if c := st.Capture; c != nil && c.Manual && !st.Paused && c.Until.Sub(now) >= minResume {
+		payload, _ := json.Marshal(map[string]int{
+			"norad_id":         c.NoradID,
+			"duration_seconds": int(c.Until.Sub(now).Seconds()),
+		})
+		select {
+		case r.Commands <- Command{Type: "trigger", Payload: payload, Reply: make(chan CommandResult, 1)}:
+			r.broadcast(map[string]any{
+				"type":    "log",
+				"level":   "info",
+				"message": fmt.Sprintf("resuming the triggered %s capture interrupted by the restart, until %s", c.Satellite, c.Until.Format("15:04:05Z")),
+			})
+		default:
+		}
+		return
+	}
+	r.resume = st.Next
+}
+
+// saveState writes r.state to scheduleFile. A failure is logged; the
+// scheduler carries on without it.
+func (r *Runner) saveState() {
+	r.state.SavedAt = time.Now().UTC()
+	r.state.Paused = r.paused.Load()
+	b, err := json.MarshalIndent(r.state, "", "  ")
+	if err != nil {
+		return
+	}
+	path := filepath.Join(r.Cfg.Data.Root, scheduleFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		r.Log.Warn("could not save the schedule", "err", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		r.Log.Warn("could not save the schedule", "err", err)
+	}
+}
+
+// keepSchedule saves the passes just computed that have not ended, to be
+// used should prediction fail after a restart.
+func (r *Runner) keepSchedule(passes []predict.Pass, now time.Time) {
+	r.state.Schedule = r.state.Schedule[:0]
+	for _, p := range passes {
+		if p.RecordLOS.After(now) {
+			r.state.Schedule = append(r.state.Schedule, toSaved(p))
+		}
+	}
+	r.saveState()
+}
+
+// savedSchedule returns the passes of the saved schedule that have not
+// ended.
+func (r *Runner) savedSchedule(now time.Time) []predict.Pass {
+	var out []predict.Pass
+	for _, s := range r.state.Schedule {
+		if p, ok := s.pass(); ok && p.RecordLOS.After(now) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// resumable reports whether p, which has reached AOS, is the pass the
+// scheduler was waiting for or recording when the daemon stopped, with
+// enough of it left to be worth recording.
+func (r *Runner) resumable(p predict.Pass, now time.Time) bool {
+	return r.resume != nil && r.resume.matches(p) && p.RecordLOS.Sub(now) >= minResume
+}
+
+// setNext records the pass the loop is waiting for, nil when none, and
+// whether it is being recorded.
+func (r *Runner) setNext(p *predict.Pass, recording bool) {
+	r.state.Next, r.state.Capture = nil, nil
+	if p != nil {
+		s := toSaved(*p)
+		r.state.Next = &s
+		if recording {
+			r.state.Capture = &savedCapture{
+				Satellite: p.Satellite.Name,
+				NoradID:   p.Satellite.NoradID,
+				Started:   time.Now().UTC(),
+				Until:     p.RecordLOS,
+			}
+		}
+	}
+	r.saveState()
+}
+
+// skipPass remembers that the operator skipped p, so it is not scheduled
+// again when the schedule is recomputed, before or after a restart.
+func (r *Runner) skipPass(p predict.Pass) {
+	r.state.Skipped = append(r.state.Skipped, toSaved(p))
+	r.saveState()
+}
+
+// dropSkipped drops the passes the operator skipped and forgets skips of
+// passes that have ended.
+func (r *Runner) dropSkipped(passes []predict.Pass, now time.Time) []predict.Pass {
+	r.state.Skipped = slices.DeleteFunc(r.state.Skipped, func(s savedPass) bool { return s.LOS.Before(now) })
+	if len(r.state.Skipped) == 0 {
+		return passes
+	}
+	kept := make([]predict.Pass, 0, len(passes))
+	for _, p := range passes {
+		if !slices.ContainsFunc(r.state.Skipped, func(s savedPass) bool { return s.matches(p) }) {
+			kept = append(kept, p)
+		}
+	}
+	if n := len(passes) - len(kept); n > 0 {
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": fmt.Sprintf("not scheduling %d passes skipped by the operator", n),
+		})
+	}
+	return kept
+}
@@ -0,0 +1,40 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+// SetMinQuality registers a function returning predict.min_quality. It is
+// consulted on every schedule computation, so a reload applies it after a
+// Reschedule.
+func (r *Runner) SetMinQuality(fn func() int) {
+	r.minQuality = fn
+}
+
+// applyMinQuality drops passes whose quality score is below
+// predict.min_quality.
+func (r *Runner) applyMinQuality(passes []predict.Pass) []predict.Pass {
+	if r.minQuality == nil {
+		return passes
+	}
+	limit := r.minQuality()
+	if limit <= 0 {
+		return passes
+	}
+	kept := make([]predict.Pass, 0, len(passes))
+	for _, p := range passes {
+		if p.Quality >= limit {
+			kept = append(kept, p)
+		}
+	}
+	if n := len(passes) - len(kept); n > 0 {
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": fmt.Sprintf("not scheduling %d passes scoring below min_quality %d", n, limit),
+		})
+	}
+	return kept
+}
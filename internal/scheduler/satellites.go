@@ -0,0 +1,79 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+// SetSatelliteFilter registers a function deciding whether a satellite is
+// scheduled. It is consulted on every schedule computation, so changes
+// apply without restarting the scheduler; see Reschedule.
+func (r *Runner) SetSatelliteFilter(fn func(name string) bool) {
+	r.satelliteEnabled = fn
+}
+
+// SetFreqOffset registers a function returning a satellite's frequency
+// offset in Hz. The capturer consults it at the start of each recording.
+func (r *Runner) SetFreqOffset(fn func(name string) int) {
+	r.capturer.FreqOffset = fn
+}
+
+// SetMinElevation registers a function returning the lowest peak
+// elevation at which a satellite's passes are scheduled. Like the filter it
+// is consulted on every schedule computation.
+func (r *Runner) SetMinElevation(fn func(name string) float64) {
+	r.predictor.MinElevation = fn
+}
+
+// Reschedule asks the scheduler to recompute its schedule, such as after
+// a satellite is disabled or the blackout windows change. It does not wait: a capture in progress is
+// finished first, and the schedule is computed afresh after it anyway.
+func (r *Runner) Reschedule() {
+	select {
+	case r.Commands <- Command{Type: "reschedule", Reply: make(chan CommandResult, 1)}:
+	default:
+	}
+}
+
+func (r *Runner) handleRescheduleCommand(cmd Command) {
+	r.broadcast(map[string]any{
+		"type":    "log",
+		"level":   "info",
+		"message": "schedule settings changed, recomputing schedule",
+	})
+	r.notifyPass(nil)
+	cmd.Reply <- CommandResult{OK: true, Message: "recomputing schedule"}
+}
+
+// applySatelliteFilter drops passes of disabled satellites.
+func (r *Runner) applySatelliteFilter(passes []predict.Pass) []predict.Pass {
+	if r.satelliteEnabled == nil {
+		return passes
+	}
+
+	kept := make([]predict.Pass, 0, len(passes))
+	dropped := map[string]int{}
+	for _, p := range passes {
+		if r.satelliteEnabled(p.Satellite.Name) {
+			kept = append(kept, p)
+			continue
+		}
+		dropped[p.Satellite.Name]++
+	}
+	if len(dropped) > 0 {
+		names := make([]string, 0, len(dropped))
+		for name, n := range dropped {
+			names = append(names, fmt.Sprintf("%s (%d)", name, n))
+		}
+		sort.Strings(names)
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": "not scheduling passes of disabled satellites: " + strings.Join(names, ", "),
+		})
+	}
+	return kept
+}
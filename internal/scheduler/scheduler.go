@@ -7,28 +7,26 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/large-farva/ephemeris-engine/internal/api"
 	"github.com/large-farva/ephemeris-engine/internal/capture"
 	"github.com/large-farva/ephemeris-engine/internal/config"
 	"github.com/large-farva/ephemeris-engine/internal/predict"
+	"github.com/large-farva/ephemeris-engine/internal/store"
+	"github.com/large-farva/ephemeris-engine/internal/tracing"
 	"github.com/large-farva/ephemeris-engine/internal/ws"
 )
 
-// PassInfo mirrors the app.PassInfo struct for callback typing.
-type PassInfo struct {
-	Satellite string  `json:"satellite"`
-	NoradID   int     `json:"norad_id"`
-	FreqHz    int     `json:"freq_hz"`
-	AOS       string  `json:"aos"`
-	LOS       string  `json:"los"`
-	MaxElev   float64 `json:"max_elev"`
-	Stage     string  `json:"stage"`
-}
+// PassInfo is the pass reported to OnPassUpdate. It is served as the
+// current_pass of /api/v1/status, so its fields are frozen there.
+type PassInfo = api.PassInfo
 
 // Command represents an external command sent to the scheduler via its
 // Commands channel. The Reply channel receives exactly one result.
@@ -44,14 +42,29 @@ type CommandResult struct {
 	Message           string `json:"message,omitempty"`
 	Error             string `json:"error,omitempty"`
 	SatellitesUpdated int    `json:"satellites_updated,omitempty"`
+	// Resolution says how a trigger was carried out; see the Trigger*
+	// constants.
+	Resolution string `json:"resolution,omitempty"`
 }
 
+// Trigger resolutions reported in CommandResult.Resolution.
+const (
+	// TriggerStarted is a new capture of the requested length.
+	TriggerStarted = "started"
+	// TriggerMerged means the trigger overlapped the scheduled pass of the
+	// same satellite; one capture covers both windows.
+	TriggerMerged = "merged"
+	// TriggerAlreadyRecording means that satellite was already being
+	// recorded, so nothing new was started.
+	TriggerAlreadyRecording = "already_recording"
+)
+
 // Runner owns the main scheduling loop, coordinating the predictor and
 // capture runner through each satellite pass.
 type Runner struct {
 	Hub *ws.Hub
 	Cfg config.Config
-	Log *log.Logger
+	Log *slog.Logger
 
 	// Commands receives external commands from HTTP handlers.
 	// The scheduler checks this channel during wait periods.
@@ -59,25 +72,91 @@ type Runner struct {
 
 	predictor *predict.Predictor
 	capturer  *capture.Runner
+	tracer    *tracing.Tracer // nil when tracing is disabled
 
 	// Pause state.
 	paused atomic.Bool
 
+	// Liveness bookkeeping for the app's watchdog, both in Unix nanos.
+	// lastBeat is touched whenever the loop makes progress; busyUntil
+	// extends the deadline across long blocking work such as a capture.
+	lastBeat  atomic.Int64
+	busyUntil atomic.Int64
+
+	// nextPass is the scheduled pass the loop is waiting for, so a trigger
+	// for the same satellite can merge with it. Only the scheduler
+	// goroutine touches it; commands are handled on that goroutine.
+	nextPass *predict.Pass
+
 	// Cancel support: when a capture is active, captureCancel can abort it.
 	captureMu     sync.Mutex
 	captureCancel context.CancelFunc
 
 	// Callbacks into the app layer.
-	passCallback    func(*PassInfo)
-	captureCallback func(satellite string, bytesWritten int64)
+	passCallback          func(*PassInfo)
+	captureCallback       func(satellite string, bytesWritten int64)
+	captureStartCallback  func(satellite string)
+	captureFailedCallback func(satellite string, err error)
+	outcomeCallback       func(store.Record)
+	processedCallback     func(path string)
+	// captureGate, when set, returns why nothing can be recorded now,
+	// such as a read-only data root, or nil.
+	captureGate func() error
+
+	// satelliteEnabled, when set, decides which satellites are scheduled.
+	satelliteEnabled func(name string) bool
+	// satellitePriority, when set, ranks satellites in conflicts.
+	satellitePriority func(name string) int
+	// conflictsAnnounced holds the passes a pass_conflict event has been
+	// sent for, by satellite and AOS, with their AOS.
+	conflictsAnnounced map[string]time.Time
+	// blackouts, when set, returns the windows no pass is recorded in;
+	// blackoutsAnnounced is to pass_blackout what conflictsAnnounced is to
+	// pass_conflict.
+	blackouts          func() []config.Blackout
+	blackoutsAnnounced map[string]time.Time
+	// minQuality, when set, returns predict.min_quality.
+	minQuality func() int
+	// tleMaxEpochAge, when set, returns predict.tle_max_epoch_age_days;
+	// tleStaleAnnounced holds the epoch a tle_stale event has been sent
+	// for, in Unix seconds, by NORAD ID.
+	tleMaxEpochAge    func() int
+	tleStaleAnnounced map[int]int64
+
+	// state is what the scheduler saves across restarts; see persist.go.
+	// restored is set once it has been loaded. resume is the pass the loop
+	// was busy with when the daemon stopped, recorded on the first schedule
+	// computed even if its AOS has passed.
+	state    savedState
+	restored bool
+	resume   *savedPass
+
+	// planned is the schedule last computed, before any pass was dropped;
+	// only the scheduler goroutine touches it. plan is what became of each
+	// of its passes and planAt when, for Schedule, guarded by planMu.
+	planned []predict.Pass
+	planMu  sync.Mutex
+	plan    []ScheduledPass
+	planAt  time.Time
+
+	// energy, when set, returns the [schedule] settings of the energy
+	// budget; energyBudget is how it is being spent, for Energy, guarded
+	// by planMu.
+	energy       func() config.ScheduleConfig
+	energyBudget *EnergyBudget
+
+	// refreshedFrom is planned as it was before a tle_refresh command,
+	// kept until the schedule computed from the new elements is compared
+	// with it.
+	refreshedFrom []predict.Pass
 }
 
 // New creates a scheduler with its own predictor and capture runner.
-func New(hub *ws.Hub, cfg config.Config, logger *log.Logger) *Runner {
+func New(hub *ws.Hub, cfg config.Config, logger *slog.Logger) *Runner {
 	return &Runner{
 		Hub:       hub,
 		Cfg:       cfg,
-		Log:       logger,
+		Log:       logger.With("component", "scheduler"),
 		Commands:  make(chan Command, 4),
 		predictor: predict.NewPredictor(hub, cfg, logger),
 		capturer:  capture.New(hub, cfg, logger, false),
@@ -94,6 +173,72 @@ func (r *Runner) SetCaptureCallback(fn func(string, int64)) {
 	r.captureCallback = fn
 }
 
+// SetCaptureStartCallback registers a function called when a capture begins.
+func (r *Runner) SetCaptureStartCallback(fn func(string)) {
+	r.captureStartCallback = fn
+}
+
+// SetCaptureFailedCallback registers a function called when a capture fails.
+func (r *Runner) SetCaptureFailedCallback(fn func(string, error)) {
+	r.captureFailedCallback = fn
+}
+
+// SetOutcomeCallback registers a function called when a pass attempt
+// ends: captured, failed, cancelled, or skipped before AOS.
+func (r *Runner) SetOutcomeCallback(fn func(store.Record)) {
+	r.outcomeCallback = fn
+}
+
+// SetProcessedCallback registers a function called with the recording's
+// path when a capture has been recorded and decoded.
+func (r *Runner) SetProcessedCallback(fn func(path string)) {
+	r.processedCallback = fn
+}
+
+// SetCaptureGate registers a function consulted at the start of every
+// capture, scheduled or triggered. While it returns an error nothing is
+// recorded; passes are still predicted and waited for.
+func (r *Runner) SetCaptureGate(fn func() error) {
+	r.captureGate = fn
+}
+
+// captureBlocked returns why nothing can be recorded now, or nil.
+func (r *Runner) captureBlocked() error {
+	if r.captureGate == nil {
+		return nil
+	}
+	return r.captureGate()
+}
+
+// SetTracer registers the tracer used to record pass pipeline spans.
+func (r *Runner) SetTracer(t *tracing.Tracer) {
+	r.tracer = t
+}
+
+// Liveness returns when the loop last made progress and the time by which
+// it is expected to make progress again. A loop that misses its deadline
+// is probably hung.
+func (r *Runner) Liveness() (last, deadline time.Time) {
+	last = time.Unix(0, r.lastBeat.Load())
+	deadline = last.Add(beatInterval + beatGrace)
+	if busy := time.Unix(0, r.busyUntil.Load()); busy.After(deadline) {
+		deadline = busy
+	}
+	return last, deadline
+}
+
+// beat records loop progress.
+func (r *Runner) beat() {
+	r.lastBeat.Store(time.Now().UnixNano())
+}
+
+// expectBusyUntil records loop progress and tells the watchdog the loop
+// will block until t (plus grace) without further heartbeats.
+func (r *Runner) expectBusyUntil(t time.Time) {
+	r.beat()
+	r.busyUntil.Store(t.Add(beatGrace).UnixNano())
+}
+
 // IsPaused reports whether the scheduler is paused.
 func (r *Runner) IsPaused() bool {
 	return r.paused.Load()
@@ -107,14 +252,24 @@ func (r *Runner) IsPaused() bool {
 //  3. Pick next pass, transition to WAITING_FOR_PASS
 //  4. Sleep until AOS
 //  5. Transition to RECORDING, run capture
-//  6. Transition to DECODING (placeholder for future APT decoding)
+//  6. Transition to DECODING, turn the recording into images
 //  7. Transition to IDLE, loop back to step 1
 func (r *Runner) Run(ctx context.Context, setState func(string)) {
+	// Run may be restarted after a panic; drop any stale capture handle.
+	r.captureMu.Lock()
+	r.captureCancel = nil
+	r.captureMu.Unlock()
+	r.beat()
+
 	r.broadcast(map[string]any{
 		"type":    "log",
 		"level":   "info",
 		"message": "scheduler started",
 	})
+	if !r.restored {
+		r.restoreState()
+		r.restored = true
+	}
 
 	for {
 		if ctx.Err() != nil {
@@ -137,30 +292,81 @@ func (r *Runner) Run(ctx context.Context, setState func(string)) {
 			continue
 		}
 
+		// Prediction may fetch TLEs over the network (30s timeout).
+		r.expectBusyUntil(time.Now().Add(time.Minute))
+		_, predictSpan := r.tracer.Start(ctx, "predict")
+		predictStart := time.Now()
 		passes, err := r.predictor.ComputePasses()
+		predictTook := time.Since(predictStart)
+		predictSpan.SetAttr("passes", len(passes))
+		predictSpan.RecordError(err)
+		predictSpan.End()
+		now := time.Now().UTC()
 		if err != nil {
-			r.broadcast(map[string]any{
-				"type":    "log",
-				"level":   "error",
-				"message": "prediction failed: " + err.Error(),
-			})
-			if r.sleepOrCommand(ctx, 5*time.Minute, setState) != sleepCompleted {
-				if ctx.Err() != nil {
-					return
+			// Without TLEs or a location, as after a restart with the
+			// network down, fall back on the schedule saved before.
+			passes = r.savedSchedule(now)
+			if len(passes) == 0 {
+				r.broadcast(map[string]any{
+					"type":    "log",
+					"level":   "error",
+					"message": "prediction failed: " + err.Error(),
+				})
+				if r.sleepOrCommand(ctx, 5*time.Minute, setState) != sleepCompleted {
+					if ctx.Err() != nil {
+						return
+					}
+					continue
 				}
 				continue
 			}
-			continue
+			r.broadcast(map[string]any{
+				"type":    "log",
+				"level":   "warn",
+				"message": fmt.Sprintf("prediction failed: %v; using the schedule saved at %s", err, r.state.SavedAt.Format(time.RFC3339)),
+			})
+		} else {
+			r.keepSchedule(passes, now)
+			r.announceTLEShift(passes, now)
 		}
+		r.checkTLEAge()
 
-		// Drop any passes whose AOS is already in the past.
-		now := time.Now().UTC()
+		// Drop any passes whose AOS is already in the past, except one
+		// the daemon was waiting for or recording when it stopped.
 		var upcoming []predict.Pass
 		for _, p := range passes {
-			if p.AOS.After(now) {
+			switch {
+			case p.AOS.After(now):
+				upcoming = append(upcoming, p)
+			case r.resumable(p, now):
+				if p.RecordAOS.Before(now) {
+					p.RecordAOS = now
+				}
+				r.broadcast(map[string]any{
+					"type":    "log",
+					"level":   "info",
+					"message": fmt.Sprintf("resuming the %s pass interrupted by the restart, until %s", p.Satellite.Name, p.RecordLOS.Format("15:04:05Z")),
+				})
 				upcoming = append(upcoming, p)
 			}
 		}
+		r.resume = nil
+		// Passes the operator forced bypass the filters; they are only
+		// weighed against each other in conflicts.
+		planStart := time.Now()
+		plan := r.newPlanner(upcoming)
+		forced, upcoming := r.takeForced(upcoming, now)
+		upcoming = plan.drop("skipped", r.dropSkipped(upcoming, now))
+		upcoming = plan.drop("blackout", r.applyBlackouts(upcoming))
+		upcoming = plan.drop("quality", r.applyMinQuality(upcoming))
+		upcoming = plan.drop("sun", r.applySunPolicy(upcoming))
+		upcoming = plan.drop("weather", r.applyWeatherPolicy(upcoming))
+		upcoming = plan.drop("disabled", r.applySatelliteFilter(upcoming))
+		plan.admit(forced)
+		upcoming = plan.drop("conflict", r.resolveConflicts(mergeForced(upcoming, forced)))
+		upcoming = plan.drop("energy", r.applyEnergyBudget(upcoming, now))
+		r.publishPlan(plan)
+		r.debugPlan(plan, predictTook, time.Since(planStart))
 
 		if len(upcoming) == 0 {
 			r.broadcast(map[string]any{
@@ -178,13 +384,19 @@ func (r *Runner) Run(ctx context.Context, setState func(string)) {
 			continue
 		}
 
-		for _, pass := range upcoming {
+		// Passes already recorded as part of a band capture.
+		banded := make(map[int]bool)
+		for i, pass := range upcoming {
 			if ctx.Err() != nil {
 				return
 			}
+			if banded[i] {
+				continue
+			}
 
-			// A long capture may push us past the next pass's AOS; skip it.
-			if time.Now().UTC().After(pass.AOS) {
+			// A long capture may push us past the start of the next
+			// pass's recording; skip it. A resumed pass is late already.
+			if time.Now().UTC().After(pass.RecordAOS) && pass.AOS.After(now) {
 				continue
 			}
 
@@ -193,6 +405,19 @@ func (r *Runner) Run(ctx context.Context, setState func(string)) {
 				break
 			}
 
+			var group []predict.Pass
+			for _, j := range r.bandGroup(upcoming, i) {
+				group = append(group, upcoming[j])
+				banded[j] = true
+			}
+
+			// One trace per pass: wait -> capture -> decode.
+			passCtx, passSpan := r.tracer.Start(ctx, "pass",
+				"satellite", pass.Satellite.Name,
+				"norad_id", pass.Satellite.NoradID,
+				"max_elev", pass.MaxElev,
+			)
+
 			setState("WAITING_FOR_PASS")
 
 			r.notifyPass(&PassInfo{
@@ -203,6 +428,7 @@ func (r *Runner) Run(ctx context.Context, setState func(string)) {
 				LOS:       pass.LOS.Format(time.RFC3339),
 				MaxElev:   pass.MaxElev,
 				Stage:     "waiting",
+				Station:   r.Cfg.Station.Active,
 			})
 
 			r.broadcast(map[string]any{
@@ -211,25 +437,84 @@ func (r *Runner) Run(ctx context.Context, setState func(string)) {
 				"message": fmt.Sprintf("next pass: %s at %s (max elev %.1f°, duration %s)", pass.Satellite.Name, pass.AOS.Format(time.RFC3339), pass.MaxElev, pass.Duration.Truncate(time.Second)),
 			})
 
-			r.broadcast(map[string]any{
-				"type":       "pass_scheduled",
-				"satellite":  pass.Satellite.Name,
-				"norad_id":   pass.Satellite.NoradID,
-				"freq_hz":    pass.Satellite.Freq,
-				"aos":        pass.AOS.Format(time.RFC3339),
-				"los":        pass.LOS.Format(time.RFC3339),
-				"max_elev":   pass.MaxElev,
-				"duration_s": int(pass.Duration.Seconds()),
-			})
-
-			if !r.waitForAOS(ctx, pass, setState) {
+			scheduled := map[string]any{
+				"type":             "pass_scheduled",
+				"satellite":        pass.Satellite.Name,
+				"norad_id":         pass.Satellite.NoradID,
+				"freq_hz":          pass.Satellite.Freq,
+				"aos":              pass.AOS.Format(time.RFC3339),
+				"los":              pass.LOS.Format(time.RFC3339),
+				"aos_unix":         pass.AOS.Unix(),
+				"los_unix":         pass.LOS.Unix(),
+				"max_elev":         pass.MaxElev,
+				"duration_s":       int(pass.Duration.Seconds()),
+				"station":          r.Cfg.Station.Active,
+				"sun_separation":   pass.SunSeparation,
+				"sun_interference": pass.SunInterference,
+				"daylight":         pass.Daylight,
+				"cloud_cover":      pass.CloudCover,
+				"quality":          pass.Quality,
+			}
+			if pass.Trimmed() {
+				scheduled["record_aos"] = pass.RecordAOS.Format(time.RFC3339)
+				scheduled["record_los"] = pass.RecordLOS.Format(time.RFC3339)
+				scheduled["record_aos_unix"] = pass.RecordAOS.Unix()
+				scheduled["record_los_unix"] = pass.RecordLOS.Unix()
+			}
+			if len(group) > 1 {
+				scheduled["band_with"] = satelliteNames(group[1:])
+				r.broadcast(map[string]any{
+					"type":    "log",
+					"level":   "info",
+					"message": fmt.Sprintf("%s overlap; recording them together in one band capture", strings.Join(satelliteNames(group), ", ")),
+				})
+			}
+			r.broadcast(scheduled)
+
+			_, waitSpan := r.tracer.Start(passCtx, "wait_for_aos")
+			r.nextPass = &pass
+			r.setNext(&pass, false)
+			reached := r.waitForAOS(ctx, pass, setState)
+			r.nextPass = nil
+			waitSpan.SetAttr("reached_aos", reached)
+			waitSpan.End()
+			if !reached {
+				passSpan.SetAttr("interrupted", true)
+				passSpan.End()
 				if ctx.Err() != nil {
 					return
 				}
 				// A command interrupted the wait; break to recompute passes.
+				r.setNext(nil, false)
 				break
 			}
 
+			if err := r.captureBlocked(); err != nil {
+				passSpan.RecordError(err)
+				passSpan.End()
+				r.broadcast(map[string]any{
+					"type":    "log",
+					"level":   "warn",
+					"message": fmt.Sprintf("not recording the %s pass: %v", pass.Satellite.Name, err),
+				})
+				r.setNext(nil, false)
+				r.notifyPass(nil)
+				setState("IDLE")
+				continue
+			}
+			r.setNext(&pass, true)
+			if len(group) > 1 {
+				r.runBandCapture(ctx, passCtx, group, setState)
+				passSpan.End()
+				if ctx.Err() != nil {
+					return
+				}
+				r.setNext(nil, false)
+				r.notifyPass(nil)
+				setState("IDLE")
+				continue
+			}
+
 			// Update pass stage to recording.
 			r.notifyPass(&PassInfo{
 				Satellite: pass.Satellite.Name,
@@ -239,34 +524,43 @@ func (r *Runner) Run(ctx context.Context, setState func(string)) {
 				LOS:       pass.LOS.Format(time.RFC3339),
 				MaxElev:   pass.MaxElev,
 				Stage:     "recording",
+				Station:   r.Cfg.Station.Active,
 			})
 
-			req := capture.CaptureRequest{
-				Satellite: pass.Satellite,
-				AOS:       pass.AOS,
-				LOS:       pass.LOS,
-				MaxElev:   pass.MaxElev,
-			}
+			req := captureRequest(pass, r.Cfg.Station.Active)
 
 			// Create a cancellable child context for this capture.
-			captureCtx, captureCancel := context.WithCancel(ctx)
+			spanCtx, captureSpan := r.tracer.Start(passCtx, "capture")
+			captureCtx, captureCancel := context.WithCancel(spanCtx)
 			r.captureMu.Lock()
 			r.captureCancel = captureCancel
 			r.captureMu.Unlock()
 
+			r.expectBusyUntil(req.LOS)
+			r.spendEnergy(req)
+			r.notifyCaptureStart(pass.Satellite.Name)
 			outPath, err := r.capturer.Capture(captureCtx, req, setState)
+			stopped := captureCtx.Err() != nil
 			captureCancel()
+			captureSpan.RecordError(err)
+			captureSpan.End()
 
 			r.captureMu.Lock()
 			r.captureCancel = nil
 			r.captureMu.Unlock()
+			r.recordOutcome(ctx, req, outPath, err, stopped, false)
+			if ctx.Err() == nil {
+				r.setNext(nil, false)
+			}
 
 			if err != nil {
+				passSpan.RecordError(err)
 				r.broadcast(map[string]any{
 					"type":    "log",
 					"level":   "error",
 					"message": "capture failed: " + err.Error(),
 				})
+				r.notifyCaptureFailed(pass.Satellite.Name, err)
 			} else if outPath != "" {
 				// Notify stats callback.
 				if r.captureCallback != nil {
@@ -276,23 +570,22 @@ func (r *Runner) Run(ctx context.Context, setState func(string)) {
 				}
 			}
 
-			// TODO: APT decoding — this is where it'll process the WAV into an image.
-			setState("DECODING")
-			r.notifyPass(&PassInfo{
-				Satellite: pass.Satellite.Name,
-				NoradID:   pass.Satellite.NoradID,
-				FreqHz:    pass.Satellite.Freq,
-				AOS:       pass.AOS.Format(time.RFC3339),
-				LOS:       pass.LOS.Format(time.RFC3339),
-				MaxElev:   pass.MaxElev,
-				Stage:     "decoding",
-			})
-			r.broadcast(map[string]any{
-				"type":    "log",
-				"level":   "info",
-				"message": fmt.Sprintf("decoding placeholder for %s (not yet implemented)", pass.Satellite.Name),
-			})
-			if !sleepOrCancel(ctx, 2*time.Second) {
+			if err == nil && outPath != "" {
+				setState("DECODING")
+				r.notifyPass(&PassInfo{
+					Satellite: pass.Satellite.Name,
+					NoradID:   pass.Satellite.NoradID,
+					FreqHz:    pass.Satellite.Freq,
+					AOS:       pass.AOS.Format(time.RFC3339),
+					LOS:       pass.LOS.Format(time.RFC3339),
+					MaxElev:   pass.MaxElev,
+					Stage:     "decoding",
+					Station:   r.Cfg.Station.Active,
+				})
+				r.decodeCapture(passCtx, pass.Satellite, outPath, pass.Direction() == predict.Northbound)
+			}
+			passSpan.End()
+			if ctx.Err() != nil {
 				return
 			}
 
@@ -302,6 +595,58 @@ func (r *Runner) Run(ctx context.Context, setState func(string)) {
 	}
 }
 
+// captureRequest returns the request that records pass.
+func captureRequest(pass predict.Pass, station string) capture.CaptureRequest {
+	return capture.CaptureRequest{
+		Satellite:  pass.Satellite,
+		AOS:        pass.RecordAOS,
+		LOS:        pass.RecordLOS,
+		MaxElev:    pass.MaxElev,
+		Station:    station,
+		CloudCover: pass.CloudCover,
+	}
+}
+
+// recordOutcome reports how the capture for req ended to the outcome
+// callback. stopped is whether the capture's context was cancelled, by
+// the operator or, when ctx is done too, by shutdown.
+func (r *Runner) recordOutcome(ctx context.Context, req capture.CaptureRequest, outPath string, err error, stopped, manual bool) {
+	if r.outcomeCallback == nil {
+		return
+	}
+	rec := store.Record{
+		Satellite: req.Satellite.Name,
+		NoradID:   req.Satellite.NoradID,
+		AOS:       req.AOS,
+		LOS:       req.LOS,
+		MaxElev:   req.MaxElev,
+		Station:   req.Station,
+		Outcome:   store.OutcomeCaptured,
+		Manual:    manual,
+	}
+	switch {
+	case err != nil:
+		rec.Outcome, rec.Error = store.OutcomeFailed, err.Error()
+	case stopped:
+		rec.Outcome = store.OutcomeCancelled
+		if ctx.Err() != nil {
+			rec.Error = "daemon shut down"
+		}
+	}
+	if outPath != "" {
+		rec.File = filepath.Base(outPath)
+		rec.Bytes, _ = captureFileSize(outPath)
+	}
+	r.outcomeCallback(rec)
+}
+
+// notifyProcessed calls the processed callback if set.
+func (r *Runner) notifyProcessed(path string) {
+	if r.processedCallback != nil {
+		r.processedCallback(path)
+	}
+}
+
 // notifyPass calls the pass callback if set.
 func (r *Runner) notifyPass(info *PassInfo) {
 	if r.passCallback != nil {
@@ -309,6 +654,20 @@ func (r *Runner) notifyPass(info *PassInfo) {
 	}
 }
 
+// notifyCaptureStart calls the capture start callback if set.
+func (r *Runner) notifyCaptureStart(satellite string) {
+	if r.captureStartCallback != nil {
+		r.captureStartCallback(satellite)
+	}
+}
+
+// notifyCaptureFailed calls the capture failed callback if set.
+func (r *Runner) notifyCaptureFailed(satellite string, err error) {
+	if r.captureFailedCallback != nil {
+		r.captureFailedCallback(satellite, err)
+	}
+}
+
 // sleepResult indicates what ended a sleep period.
 type sleepResult int
 
@@ -318,29 +677,48 @@ const (
 	sleepInterrupted                    // a command was received and handled
 )
 
+// Watchdog heartbeat timing. The loop beats at least every beatInterval
+// while idle; beatGrace is the slack allowed before it is considered hung.
+const (
+	beatInterval = 30 * time.Second
+	beatGrace    = 90 * time.Second
+)
+
 // sleepOrCommand blocks for duration d, until ctx is cancelled, or until a
 // command arrives on r.Commands. Commands are handled inline. Returns what
-// ended the sleep.
+// ended the sleep. The loop heartbeat is refreshed while sleeping.
 func (r *Runner) sleepOrCommand(ctx context.Context, d time.Duration, setState func(string)) sleepResult {
 	t := time.NewTimer(d)
 	defer t.Stop()
-	select {
-	case <-ctx.Done():
-		return sleepCancelled
-	case <-t.C:
-		return sleepCompleted
-	case cmd := <-r.Commands:
-		r.handleCommand(ctx, cmd, setState)
-		return sleepInterrupted
+	beat := time.NewTicker(beatInterval)
+	defer beat.Stop()
+	for {
+		r.beat()
+		select {
+		case <-ctx.Done():
+			return sleepCancelled
+		case <-t.C:
+			return sleepCompleted
+		case <-beat.C:
+		case cmd := <-r.Commands:
+			r.handleCommand(ctx, cmd, setState)
+			return sleepInterrupted
+		}
 	}
 }
 
-// waitForAOS sleeps until AOS, broadcasting countdown progress every 30s.
-// Returns true if AOS was reached, false if interrupted (by context cancel
-// or a command).
+// waitForAOS sleeps until the pass's recording starts, at AOS unless
+// station.usable_elevation trims it, broadcasting countdown progress every
+// 30s. Returns true if that time was reached, false if interrupted (by
+// context cancel or a command).
 func (r *Runner) waitForAOS(ctx context.Context, pass predict.Pass, setState func(string)) bool {
+	what := "AOS"
+	if pass.Trimmed() {
+		what = "recording"
+	}
+	r.Log.Debug("waiting for pass", "satellite", pass.Satellite.Name, "record_aos", pass.RecordAOS.UTC().Format(time.RFC3339), "max_elev", pass.MaxElev)
 	for {
-		remaining := time.Until(pass.AOS)
+		remaining := time.Until(pass.RecordAOS)
 		if remaining <= 0 {
 			return true
 		}
@@ -349,7 +727,7 @@ func (r *Runner) waitForAOS(ctx context.Context, pass predict.Pass, setState fun
 			"type":    "progress",
 			"stage":   "waiting",
 			"percent": 0,
-			"detail":  fmt.Sprintf("AOS in %s for %s", remaining.Truncate(time.Second), pass.Satellite.Name),
+			"detail":  fmt.Sprintf("%s in %s for %s", what, remaining.Truncate(time.Second), pass.Satellite.Name),
 		})
 
 		sleepDur := 30 * time.Second
@@ -378,6 +756,10 @@ func (r *Runner) handleCommand(ctx context.Context, cmd Command, setState func(s
 		r.handleSkipCommand(cmd)
 	case "cancel":
 		r.handleCancelCommand(cmd)
+	case "reschedule":
+		r.handleRescheduleCommand(cmd)
+	case "override":
+		r.handleOverrideCommand(cmd)
 	default:
 		cmd.Reply <- CommandResult{OK: false, Error: "unknown command: " + cmd.Type}
 	}
@@ -399,40 +781,99 @@ func (r *Runner) handleTriggerCommand(ctx context.Context, cmd Command, setState
 		cmd.Reply <- CommandResult{OK: false, Error: fmt.Sprintf("unknown NORAD ID: %d", payload.NoradID)}
 		return
 	}
+	if err := r.captureBlocked(); err != nil {
+		cmd.Reply <- CommandResult{OK: false, Error: "cannot record: " + err.Error()}
+		return
+	}
 
 	dur := time.Duration(payload.DurationSeconds) * time.Second
 	now := time.Now().UTC()
 
+	req := capture.CaptureRequest{
+		Satellite: *sat,
+		AOS:       now,
+		LOS:       now.Add(dur),
+		MaxElev:   90,
+		Station:   r.Cfg.Station.Active,
+	}
+
+	// A trigger overlapping the upcoming pass of the same satellite would
+	// otherwise record now and then again at AOS, with the two captures
+	// competing for the SDR. Record once, through the later of the two
+	// ends; the scheduled pass is then in the past and is dropped when the
+	// loop recomputes.
+	resolution := TriggerStarted
+	message := fmt.Sprintf("capture triggered for %s (%s)", sat.Name, dur.Truncate(time.Second))
+	if next := r.nextPass; next != nil && next.Satellite.NoradID == sat.NoradID &&
+		now.Before(next.LOS) && req.LOS.After(next.AOS) {
+		if next.LOS.After(req.LOS) {
+			req.LOS = next.LOS
+		}
+		req.MaxElev = next.MaxElev
+		req.CloudCover = next.CloudCover
+		resolution = TriggerMerged
+		message = fmt.Sprintf("trigger merged with the scheduled %s pass at %s; recording until %s",
+			sat.Name, next.AOS.Format("15:04:05Z"), req.LOS.Format("15:04:05Z"))
+	}
+
 	r.broadcast(map[string]any{
 		"type":    "log",
 		"level":   "info",
-		"message": fmt.Sprintf("manual trigger: capturing %s for %s", sat.Name, dur.Truncate(time.Second)),
+		"message": fmt.Sprintf("manual trigger: capturing %s for %s (%s)", sat.Name, req.LOS.Sub(now).Truncate(time.Second), resolution),
 	})
 
 	// Reply immediately so the HTTP handler is not blocked during capture.
 	cmd.Reply <- CommandResult{
-		OK:      true,
-		Message: fmt.Sprintf("capture triggered for %s (%s)", sat.Name, dur.Truncate(time.Second)),
+		OK:         true,
+		Message:    message,
+		Resolution: resolution,
 	}
 
-	req := capture.CaptureRequest{
-		Satellite: *sat,
-		AOS:       now,
-		LOS:       now.Add(dur),
-		MaxElev:   90,
-	}
+	r.notifyPass(&PassInfo{
+		Satellite: sat.Name,
+		NoradID:   sat.NoradID,
+		FreqHz:    sat.Freq,
+		AOS:       req.AOS.Format(time.RFC3339),
+		LOS:       req.LOS.Format(time.RFC3339),
+		MaxElev:   req.MaxElev,
+		Stage:     "recording",
+		Station:   req.Station,
+		Manual:    true,
+	})
+
+	triggerCtx, triggerSpan := r.tracer.Start(ctx, "trigger",
+		"satellite", sat.Name,
+		"norad_id", sat.NoradID,
+		"duration_s", payload.DurationSeconds,
+	)
+	defer triggerSpan.End()
+	spanCtx, captureSpan := r.tracer.Start(triggerCtx, "capture")
 
-	captureCtx, captureCancel := context.WithCancel(ctx)
+	captureCtx, captureCancel := context.WithCancel(spanCtx)
 	r.captureMu.Lock()
 	r.captureCancel = captureCancel
 	r.captureMu.Unlock()
 
+	r.state.Capture = &savedCapture{Satellite: sat.Name, NoradID: sat.NoradID, Started: now, Until: req.LOS, Manual: true}
+	r.saveState()
+	r.expectBusyUntil(req.LOS)
+	r.spendEnergy(req)
+	r.notifyCaptureStart(sat.Name)
 	outPath, err := r.capturer.Capture(captureCtx, req, setState)
+	stopped := captureCtx.Err() != nil
 	captureCancel()
+	captureSpan.RecordError(err)
+	captureSpan.End()
+	triggerSpan.RecordError(err)
 
 	r.captureMu.Lock()
 	r.captureCancel = nil
 	r.captureMu.Unlock()
+	r.recordOutcome(ctx, req, outPath, err, stopped, true)
+	if ctx.Err() == nil {
+		r.state.Capture = nil
+		r.saveState()
+	}
 
 	if err != nil {
 		r.broadcast(map[string]any{
@@ -440,12 +881,29 @@ func (r *Runner) handleTriggerCommand(ctx context.Context, cmd Command, setState
 			"level":   "error",
 			"message": "triggered capture failed: " + err.Error(),
 		})
-	} else if outPath != "" && r.captureCallback != nil {
-		if size, statErr := captureFileSize(outPath); statErr == nil {
-			r.captureCallback(sat.Name, size)
+		r.notifyCaptureFailed(sat.Name, err)
+	} else if outPath != "" {
+		if r.captureCallback != nil {
+			if size, statErr := captureFileSize(outPath); statErr == nil {
+				r.captureCallback(sat.Name, size)
+			}
 		}
+		setState("DECODING")
+		r.notifyPass(&PassInfo{
+			Satellite: sat.Name,
+			NoradID:   sat.NoradID,
+			FreqHz:    sat.Freq,
+			AOS:       req.AOS.Format(time.RFC3339),
+			LOS:       req.LOS.Format(time.RFC3339),
+			MaxElev:   req.MaxElev,
+			Stage:     "decoding",
+			Station:   req.Station,
+			Manual:    true,
+		})
+		r.decodeCapture(triggerCtx, *sat, outPath, false)
 	}
 
+	r.notifyPass(nil)
 	setState("IDLE")
 }
 
@@ -457,15 +915,21 @@ func (r *Runner) handleTLERefreshCommand(cmd Command) {
 		return
 	}
 
+	// The command interrupts whatever wait the loop is in, so the schedule
+	// is recomputed from the new elements at once rather than after the
+	// pass it was waiting for.
+	r.refreshedFrom = r.planned
+	r.notifyPass(nil)
+	msg := fmt.Sprintf("TLE data refreshed, %d satellites updated; recomputing schedule", n)
 	r.broadcast(map[string]any{
 		"type":    "log",
 		"level":   "info",
-		"message": fmt.Sprintf("TLE data refreshed, %d satellites updated", n),
+		"message": msg,
 	})
 
 	cmd.Reply <- CommandResult{
 		OK:                true,
-		Message:           fmt.Sprintf("TLE data refreshed, %d satellites updated", n),
+		Message:           msg,
 		SatellitesUpdated: n,
 	}
 }
@@ -476,6 +940,7 @@ func (r *Runner) handlePauseCommand(cmd Command) {
 		return
 	}
 	r.paused.Store(true)
+	r.saveState()
 	r.broadcast(map[string]any{
 		"type":    "log",
 		"level":   "info",
@@ -490,6 +955,7 @@ func (r *Runner) handleResumeCommand(cmd Command) {
 		return
 	}
 	r.paused.Store(false)
+	r.saveState()
 	r.broadcast(map[string]any{
 		"type":    "log",
 		"level":   "info",
@@ -504,6 +970,10 @@ func (r *Runner) handleSkipCommand(cmd Command) {
 		"level":   "info",
 		"message": "skipping current pass by user request",
 	})
+	if p := r.nextPass; p != nil {
+		r.skipPass(*p)
+		r.recordSkipped(*p)
+	}
 	r.notifyPass(nil)
 	cmd.Reply <- CommandResult{OK: true, Message: "pass skipped, recomputing schedule"}
 }
@@ -533,19 +1003,6 @@ func (r *Runner) broadcast(v map[string]any) {
 	r.Hub.BroadcastJSON(v)
 }
 
-// sleepOrCancel blocks for duration d or until the context is cancelled.
-// Returns true if the sleep completed, false if interrupted.
-func sleepOrCancel(ctx context.Context, d time.Duration) bool {
-	t := time.NewTimer(d)
-	defer t.Stop()
-	select {
-	case <-ctx.Done():
-		return false
-	case <-t.C:
-		return true
-	}
-}
-
 // captureFileSize returns the size of a capture file.
 func captureFileSize(path string) (int64, error) {
 	info, err := os.Stat(path)
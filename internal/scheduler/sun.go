@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+// applySunPolicy drops passes flagged for sun interference according to
+// predict.sun_policy and logs each one it drops. Under "deprioritize" a
+// flagged pass is dropped only when it overlaps a clean pass, which would
+// otherwise be missed while the flagged one records.
+func (r *Runner) applySunPolicy(passes []predict.Pass) []predict.Pass {
+	policy := r.Cfg.Predict.SunPolicy
+	if policy != predict.SunPolicySkip && policy != predict.SunPolicyDeprioritize {
+		return passes
+	}
+
+	kept := make([]predict.Pass, 0, len(passes))
+	for i, p := range passes {
+		if !p.SunInterference {
+			kept = append(kept, p)
+			continue
+		}
+		if policy == predict.SunPolicyDeprioritize && !overlapsCleanPass(passes, i) {
+			kept = append(kept, p)
+			continue
+		}
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": fmt.Sprintf("dropping %s pass at %s: track comes within %.1f° of the sun (sun_policy %s)", p.Satellite.Name, p.AOS.Format("15:04:05Z"), p.SunSeparation, policy),
+		})
+	}
+	return kept
+}
+
+// overlapsCleanPass reports whether passes[i] overlaps in time with a pass
+// that is not flagged for sun interference.
+func overlapsCleanPass(passes []predict.Pass, i int) bool {
+	for j, q := range passes {
+		if j == i || q.SunInterference {
+			continue
+		}
+		if q.AOS.Before(passes[i].LOS) && passes[i].AOS.Before(q.LOS) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+)
+
+// SetTLEMaxEpochAge registers a function returning
+// predict.tle_max_epoch_age_days. It is consulted on every schedule
+// computation, so a reload applies it after a Reschedule.
+func (r *Runner) SetTLEMaxEpochAge(fn func() int) {
+	r.tleMaxEpochAge = fn
+}
+
+// checkTLEAge sends a tle_stale warning for each satellite whose element
+// set is older, by its epoch, than predict.tle_max_epoch_age_days, once per
+// element set: the next warning for a satellite waits for an epoch that is
+// stale in turn.
+func (r *Runner) checkTLEAge() {
+	if r.tleMaxEpochAge == nil {
+		return
+	}
+	days := r.tleMaxEpochAge()
+	if days <= 0 {
+		return
+	}
+	limit := time.Duration(days) * 24 * time.Hour
+	if r.tleStaleAnnounced == nil {
+		r.tleStaleAnnounced = make(map[int]int64)
+	}
+	for _, el := range r.predictor.TLEElements() {
+		age := time.Duration(el.AgeS) * time.Second
+		announced, ok := r.tleStaleAnnounced[el.NoradID]
+		if age <= limit {
+			if ok {
+				delete(r.tleStaleAnnounced, el.NoradID)
+				r.broadcast(map[string]any{
+					"type":    "log",
+					"level":   "info",
+					"message": fmt.Sprintf("%s TLE is current again, epoch %s", el.Satellite, el.Epoch),
+				})
+			}
+			continue
+		}
+		if ok && announced == el.EpochUnix {
+			continue
+		}
+		r.tleStaleAnnounced[el.NoradID] = el.EpochUnix
+
+		ageDays := age.Hours() / 24
+		r.Log.Warn("stale TLE", "satellite", el.Satellite, "epoch", el.Epoch, "age_days", fmt.Sprintf("%.1f", ageDays), "source", el.Source)
+		r.broadcast(map[string]any{
+			"type":         "tle_stale",
+			"level":        "warn",
+			"satellite":    el.Satellite,
+			"norad_id":     el.NoradID,
+			"epoch":        el.Epoch,
+			"epoch_unix":   el.EpochUnix,
+			"age_days":     ageDays,
+			"max_age_days": days,
+			"source":       el.Source,
+			"message":      fmt.Sprintf("%s TLE epoch is %.1f days old (limit %d); pass times may be off", el.Satellite, ageDays, days),
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package scheduler
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+// maxShiftMatch is how far apart the AOS of a pass may be, before and
+// after a TLE refresh, for the two to count as the same pass. Fresh
+// elements move a pass by seconds, rarely minutes; passes of one
+// satellite are an orbit, some 100 minutes, apart.
+const maxShiftMatch = 20 * time.Minute
+
+// announceTLEShift sends a tle_shift event comparing passes, computed from
+// freshly refreshed elements, with the schedule computed before the
+// refresh: how far each upcoming pass's AOS moved. Passes that did not
+// move are left out.
+func (r *Runner) announceTLEShift(passes []predict.Pass, now time.Time) {
+	before := r.refreshedFrom
+	r.refreshedFrom = nil
+	if len(before) == 0 {
+		return
+	}
+
+	shifts := []map[string]any{}
+	matched := 0
+	var maxShift, sum float64
+	for _, p := range passes {
+		if !p.AOS.After(now) {
+			continue
+		}
+		var old *predict.Pass
+		for i := range before {
+			b := &before[i]
+			if b.Satellite.NoradID != p.Satellite.NoradID {
+				continue
+			}
+			if d := p.AOS.Sub(b.AOS).Abs(); d < maxShiftMatch && (old == nil || d < p.AOS.Sub(old.AOS).Abs()) {
+				old = b
+			}
+		}
+		if old == nil {
+			continue
+		}
+		matched++
+		shift := math.Round(p.AOS.Sub(old.AOS).Seconds()*10) / 10
+		if shift == 0 {
+			continue
+		}
+		maxShift = math.Max(maxShift, math.Abs(shift))
+		sum += math.Abs(shift)
+		shifts = append(shifts, map[string]any{
+			"satellite":      p.Satellite.Name,
+			"aos":            p.AOS.Format(time.RFC3339),
+			"aos_unix":       p.AOS.Unix(),
+			"aos_before":     old.AOS.Format(time.RFC3339),
+			"aos_shift_s":    shift,
+			"max_elev":       p.MaxElev,
+			"max_elev_shift": math.Round((p.MaxElev-old.MaxElev)*10) / 10,
+		})
+	}
+	if len(shifts) == 0 {
+		if matched > 0 {
+			r.broadcast(map[string]any{
+				"type":    "log",
+				"level":   "info",
+				"message": "refreshed TLEs left the upcoming passes where they were",
+			})
+		}
+		return
+	}
+	mean := math.Round(sum/float64(len(shifts))*10) / 10
+
+	r.broadcast(map[string]any{
+		"type":             "tle_shift",
+		"passes":           shifts,
+		"max_aos_shift_s":  maxShift,
+		"mean_aos_shift_s": mean,
+		"message":          fmt.Sprintf("refreshed TLEs moved %d upcoming passes, AOS by %.1fs on average and up to %.1fs", len(shifts), mean, maxShift),
+	})
+}
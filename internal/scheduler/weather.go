@@ -0,0 +1,32 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"github.com/large-farva/ephemeris-engine/internal/predict"
+)
+
+// applyWeatherPolicy drops daylight passes whose forecast cloud cover is at
+// or above weather.skip_overcast_percent. Night passes are always kept: the
+// infrared channel sees through to cloud tops either way, and passes with
+// no forecast are kept rather than guessed at.
+func (r *Runner) applyWeatherPolicy(passes []predict.Pass) []predict.Pass {
+	limit := r.Cfg.Weather.SkipOvercastPercent
+	if !r.Cfg.Weather.Enabled || limit <= 0 {
+		return passes
+	}
+
+	kept := make([]predict.Pass, 0, len(passes))
+	for _, p := range passes {
+		if !p.Daylight || p.CloudCover == nil || *p.CloudCover < limit {
+			kept = append(kept, p)
+			continue
+		}
+		r.broadcast(map[string]any{
+			"type":    "log",
+			"level":   "info",
+			"message": fmt.Sprintf("dropping %s pass at %s: %d%% cloud cover forecast (skip_overcast_percent %d)", p.Satellite.Name, p.AOS.Format("15:04:05Z"), *p.CloudCover, limit),
+		})
+	}
+	return kept
+}
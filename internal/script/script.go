@@ -0,0 +1,433 @@
+// Package script runs the [[scripts]] in the config: Starlark programs
+// that react to the daemon's events. A script defines
+//
+//	def on_event(event):
+//	    ...
+//
+// which is called with each event as a dict, exactly what WebSocket
+// clients receive. It acts through builtins that call the daemon's
+// control API, such as pause(), skip(), trigger("NOAA-19") and
+// tag(name, add = ["noisy"]), and reads through status(), next_pass(),
+// captures() and stats().
+//
+// Starlark has no access to files, the network or processes, and a script
+// cannot load other files, so the builtins are all it can reach. Each call
+// is also bounded in steps and time, so a runaway loop cannot stall the
+// daemon. It is the programmable counterpart to rules for automations a
+// condition cannot express, without the separate process a plugin needs.
+package script
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+	"github.com/large-farva/ephemeris-engine/internal/ws"
+)
+
+const (
+	// Component is the event component for everything the engine emits.
+	// Events from it are not passed to scripts, so a script's own output
+	// cannot trigger it.
+	Component = "scripts"
+	// queueSize is how many events the engine may fall behind by before
+	// events are dropped.
+	queueSize = 256
+	// maxSteps bounds the work of one call into a script, loading it
+	// included.
+	maxSteps = 1_000_000
+	// callTimeout bounds the time of one call into a script.
+	callTimeout = 5 * time.Second
+	// hook is the function a script defines to receive events.
+	hook = "on_event"
+)
+
+// builtin is a function scripts can call and the control API method
+// behind it. arg names the parameter a positional argument fills, for the
+// builtins that take one.
+type builtin struct {
+	name, method, arg string
+}
+
+var builtins = []builtin{
+	{"status", "status", ""},
+	{"next_pass", "next_pass", ""},
+	{"captures", "captures", ""},
+	{"stats", "stats", ""},
+	{"pause", "pause", ""},
+	{"resume", "resume", ""},
+	{"skip", "skip", ""},
+	{"trigger", "trigger", "satellite"},
+	{"tag", "tag_capture", "name"},
+}
+
+// Status describes one configured script.
+type Status struct {
+	Name   string   `json:"name"`
+	Path   string   `json:"path"`
+	Events []string `json:"events,omitempty"`
+	// Loaded is whether the script loaded and defines on_event.
+	Loaded    bool   `json:"loaded"`
+	Runs      uint64 `json:"runs"`
+	Failures  uint64 `json:"failures"`
+	LastRun   string `json:"last_run,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// EmitFunc publishes a daemon event, as App.emit does.
+type EmitFunc func(component string, payload map[string]any)
+
+// CallFunc serves a control API request from script, as plugins make
+// them: method is one of the plugin control methods and params its JSON
+// body.
+type CallFunc func(script, method string, params json.RawMessage) (json.RawMessage, error)
+
+// Engine runs scripts against the daemon's events.
+type Engine struct {
+	hub  *ws.Hub
+	log  *slog.Logger
+	emit EmitFunc
+	call CallFunc
+
+	mu      sync.Mutex
+	scripts []*script
+}
+
+type script struct {
+	config.ScriptConfig
+	onEvent starlark.Callable // nil if the script did not load
+
+	runs      uint64
+	failures  uint64
+	lastRun   time.Time
+	lastError string
+}
+
+// NewEngine returns an Engine for hub's events.
+func NewEngine(hub *ws.Hub, logger *slog.Logger, emit EmitFunc, call CallFunc) *Engine {
+	return &Engine{hub: hub, log: logger.With("component", Component), emit: emit, call: call}
+}
+
+// Update loads the scripts afresh, so edits to their files take effect.
+// Counters carry over for scripts whose entry is unchanged. A script that
+// fails to load is kept with its error and not run; the errors are also
+// returned, together.
+func (e *Engine) Update(cfgs []config.ScriptConfig) error {
+	next := make([]*script, 0, len(cfgs))
+	var errs []error
+	for _, c := range cfgs {
+		s := &script{ScriptConfig: c}
+		if fn, err := e.load(c); err != nil {
+			s.lastError = errorText(err)
+			errs = append(errs, fmt.Errorf("script %s: %s", c.Name, s.lastError))
+		} else {
+			s.onEvent = fn
+		}
+		next = append(next, s)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, n := range next {
+		for _, old := range e.scripts {
+			if reflect.DeepEqual(old.ScriptConfig, n.ScriptConfig) {
+				n.runs, n.failures, n.lastRun = old.runs, old.failures, old.lastRun
+				if n.lastError == "" {
+					n.lastError = old.lastError
+				}
+			}
+		}
+	}
+	e.scripts = next
+	return errors.Join(errs...)
+}
+
+// load runs the script file of c and returns its on_event function.
+func (e *Engine) load(c config.ScriptConfig) (starlark.Callable, error) {
+	src, err := os.ReadFile(c.Path)
+	if err != nil {
+		return nil, err
+	}
+	thread, stop := e.thread(c.Name)
+	// The control API is for on_event; loading runs again on every reload.
+	thread.SetLocal("loading", true)
+	globals, err := starlark.ExecFileOptions(&syntax.FileOptions{}, thread, c.Path, src, e.predeclared(c.Name))
+	stop()
+	if err != nil {
+		return nil, err
+	}
+	fn, ok := globals[hook].(starlark.Callable)
+	if !ok {
+		return nil, fmt.Errorf("%s defines no %s function", c.Path, hook)
+	}
+	return fn, nil
+}
+
+// thread returns a thread for a call into script, with print going to the
+// daemon log and the call bounded by maxSteps and callTimeout. stop
+// releases the timer.
+func (e *Engine) thread(name string) (thread *starlark.Thread, stop func()) {
+	thread = &starlark.Thread{
+		Name: name,
+		Print: func(_ *starlark.Thread, msg string) {
+			e.emit(Component, map[string]any{
+				"type":    "log",
+				"level":   "info",
+				"script":  name,
+				"message": fmt.Sprintf("script %s: %s", name, msg),
+			})
+		},
+	}
+	thread.SetMaxExecutionSteps(maxSteps)
+	t := time.AfterFunc(callTimeout, func() {
+		thread.Cancel(fmt.Sprintf("took longer than %s", callTimeout))
+	})
+	return thread, func() { t.Stop() }
+}
+
+// predeclared returns the builtins for script name.
+func (e *Engine) predeclared(name string) starlark.StringDict {
+	d := starlark.StringDict{}
+	for _, b := range builtins {
+		d[b.name] = starlark.NewBuiltin(b.name, func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+			if thread.Local("loading") != nil {
+				return nil, fmt.Errorf("%s: the control API can only be called from %s", fn.Name(), hook)
+			}
+			params := map[string]any{}
+			switch {
+			case len(args) > 1 || len(args) == 1 && b.arg == "":
+				return nil, fmt.Errorf("%s: unexpected positional argument; name the parameters", fn.Name())
+			case len(args) == 1:
+				v, err := fromStarlark(args[0])
+				if err != nil {
+					return nil, fmt.Errorf("%s: %s: %v", fn.Name(), b.arg, err)
+				}
+				params[b.arg] = v
+			}
+			for _, kv := range kwargs {
+				k := string(kv[0].(starlark.String))
+				v, err := fromStarlark(kv[1])
+				if err != nil {
+					return nil, fmt.Errorf("%s: %s: %v", fn.Name(), k, err)
+				}
+				params[k] = v
+			}
+			var body json.RawMessage
+			if len(params) > 0 {
+				body, _ = json.Marshal(params)
+			}
+			out, err := e.call(name, b.method, body)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", fn.Name(), err)
+			}
+			return decode(out)
+		})
+	}
+	return d
+}
+
+// Status reports every script, in name order.
+func (e *Engine) Status() []Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := make([]Status, 0, len(e.scripts))
+	for _, s := range e.scripts {
+		st := Status{
+			Name:      s.Name,
+			Path:      s.Path,
+			Events:    s.Events,
+			Loaded:    s.onEvent != nil,
+			Runs:      s.runs,
+			Failures:  s.failures,
+			LastError: s.lastError,
+		}
+		if !s.lastRun.IsZero() {
+			st.LastRun = s.lastRun.UTC().Format(time.RFC3339)
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Run passes each event to the scripts until ctx is cancelled.
+func (e *Engine) Run(ctx context.Context) {
+	events, cancel := e.hub.Subscribe(queueSize)
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-events:
+			e.handle(msg)
+		}
+	}
+}
+
+// handle passes one event to the scripts that want it, one at a time.
+func (e *Engine) handle(msg []byte) {
+	var ev map[string]any
+	dec := json.NewDecoder(bytes.NewReader(msg))
+	dec.UseNumber()
+	if dec.Decode(&ev) != nil || ev["component"] == Component {
+		return
+	}
+	typ, _ := ev["type"].(string)
+
+	e.mu.Lock()
+	var run []*script
+	for _, s := range e.scripts {
+		if s.onEvent != nil && (len(s.Events) == 0 || slices.Contains(s.Events, typ)) {
+			run = append(run, s)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, s := range run {
+		thread, stop := e.thread(s.Name)
+		_, err := starlark.Call(thread, s.onEvent, starlark.Tuple{toStarlark(ev)}, nil)
+		stop()
+
+		e.mu.Lock()
+		s.runs++
+		s.lastRun = time.Now()
+		if err != nil {
+			s.failures++
+			s.lastError = errorText(err)
+		}
+		e.mu.Unlock()
+		if err != nil {
+			e.log.Warn("script failed", "script", s.Name, "event", typ, "err", errorText(err))
+			e.emit(Component, map[string]any{
+				"type":    "log",
+				"level":   "warn",
+				"script":  s.Name,
+				"message": fmt.Sprintf("script %s failed on %s: %s", s.Name, typ, errorText(err)),
+			})
+		}
+	}
+}
+
+// errorText describes err, with the script position it was raised at.
+func errorText(err error) string {
+	var ee *starlark.EvalError
+	if !errors.As(err, &ee) {
+		return err.Error()
+	}
+	for i := range ee.CallStack {
+		if pos := ee.CallStack.At(i).Pos; pos.Filename() != "<builtin>" {
+			return fmt.Sprintf("%s: %s", pos, ee.Msg)
+		}
+	}
+	return ee.Msg
+}
+
+// decode converts a control API response to Starlark.
+func decode(b json.RawMessage) (starlark.Value, error) {
+	if len(b) == 0 {
+		return starlark.None, nil
+	}
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return toStarlark(v), nil
+}
+
+// toStarlark converts a value decoded from JSON, with numbers as
+// json.Number, to Starlark. Objects become dicts with their keys sorted.
+func toStarlark(v any) starlark.Value {
+	switch v := v.(type) {
+	case nil:
+		return starlark.None
+	case bool:
+		return starlark.Bool(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return starlark.MakeInt64(i)
+		}
+		f, _ := v.Float64()
+		return starlark.Float(f)
+	case string:
+		return starlark.String(v)
+	case []any:
+		elems := make([]starlark.Value, len(v))
+		for i, e := range v {
+			elems[i] = toStarlark(e)
+		}
+		return starlark.NewList(elems)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		d := starlark.NewDict(len(v))
+		for _, k := range keys {
+			_ = d.SetKey(starlark.String(k), toStarlark(v[k]))
+		}
+		return d
+	}
+	return starlark.String(fmt.Sprint(v))
+}
+
+// fromStarlark converts a Starlark value to one json.Marshal takes.
+func fromStarlark(v starlark.Value) (any, error) {
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.Int:
+		if i, ok := v.Int64(); ok {
+			return i, nil
+		}
+		return nil, fmt.Errorf("%s is out of range", v)
+	case starlark.Float:
+		return float64(v), nil
+	case starlark.String:
+		return string(v), nil
+	case *starlark.List, starlark.Tuple:
+		seq := v.(starlark.Indexable)
+		out := make([]any, seq.Len())
+		for i := range out {
+			e, err := fromStarlark(seq.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = e
+		}
+		return out, nil
+	case *starlark.Dict:
+		out := make(map[string]any, v.Len())
+		for _, item := range v.Items() {
+			k, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("dict key %s is not a string", item[0])
+			}
+			e, err := fromStarlark(item[1])
+			if err != nil {
+				return nil, err
+			}
+			out[string(k)] = e
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("cannot pass a %s", v.Type())
+}
@@ -0,0 +1,128 @@
+package script
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// call is one control API request a script made.
+type call struct {
+	method string
+	params map[string]any
+}
+
+// newTestEngine returns an Engine whose control API records the calls and
+// answers captures with NOAA-15 scores that have been poor lately.
+func newTestEngine(t *testing.T) (*Engine, *[]call) {
+	t.Helper()
+	var calls []call
+	e := NewEngine(nil, slog.New(slog.NewTextHandler(io.Discard, nil)), func(string, map[string]any) {},
+		func(_, method string, params json.RawMessage) (json.RawMessage, error) {
+			c := call{method: method}
+			if len(params) > 0 {
+				if err := json.Unmarshal(params, &c.params); err != nil {
+					t.Errorf("%s params: %v", method, err)
+				}
+			}
+			calls = append(calls, c)
+			if method == "captures" {
+				return json.RawMessage(`{"captures": [
+					{"filename": "a.wav", "satellite": "NOAA-15", "image_score": 55},
+					{"filename": "b.wav", "satellite": "NOAA-19", "image_score": 80},
+					{"filename": "c.wav", "satellite": "NOAA-15", "image_score": 30},
+					{"filename": "d.wav", "satellite": "NOAA-15", "image_score": 35},
+					{"filename": "e.wav", "satellite": "NOAA-15", "image_score": 20}]}`), nil
+			}
+			return json.RawMessage(`{"ok": true}`), nil
+		})
+	return e, &calls
+}
+
+// writeScript writes src to a file named name.star and returns its entry.
+func writeScript(t *testing.T, name, src string) config.ScriptConfig {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name+".star")
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return config.ScriptConfig{Name: name, Path: path}
+}
+
+func TestOnEvent(t *testing.T) {
+	e, calls := newTestEngine(t)
+	err := e.Update([]config.ScriptConfig{writeScript(t, "poor-noaa15", `
+def on_event(event):
+    if event["type"] == "capture_corrupt":
+        tag(event["file"], add = ["corrupt"])
+    if event["type"] != "pass_scheduled" or event["satellite"] != "NOAA-15":
+        return
+    scores = [c["image_score"] for c in captures()["captures"] if c["satellite"] == "NOAA-15"]
+    if len(scores) >= 3 and max(scores[-3:]) < 40:
+        skip()
+`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	e.handle([]byte(`{"type": "pass_scheduled", "satellite": "NOAA-19"}`))
+	if len(*calls) != 0 {
+		t.Errorf("NOAA-19 pass: calls = %v, want none", *calls)
+	}
+	e.handle([]byte(`{"type": "pass_scheduled", "satellite": "NOAA-15"}`))
+	if got := *calls; len(got) != 2 || got[0].method != "captures" || got[1].method != "skip" {
+		t.Errorf("NOAA-15 pass: calls = %v, want captures then skip", got)
+	}
+	*calls = nil
+	e.handle([]byte(`{"type": "capture_corrupt", "file": "c.wav"}`))
+	if got := *calls; len(got) != 1 || got[0].method != "tag_capture" ||
+		got[0].params["name"] != "c.wav" || len(got[0].params["add"].([]any)) != 1 {
+		t.Errorf("corrupt capture: calls = %v, want tag_capture of c.wav", got)
+	}
+
+	st := e.Status()
+	if len(st) != 1 || !st[0].Loaded || st[0].Runs != 3 || st[0].Failures != 0 || st[0].LastError != "" {
+		t.Errorf("status = %+v, want loaded with 3 runs", st)
+	}
+}
+
+// TestSandbox checks that a script reaches nothing but the builtins, and
+// only from on_event, and that a call into one is bounded.
+func TestSandbox(t *testing.T) {
+	e, calls := newTestEngine(t)
+	err := e.Update([]config.ScriptConfig{
+		writeScript(t, "load", `load("other.star", "x")`+"\ndef on_event(event):\n    pass\n"),
+		writeScript(t, "at-load", "pause()\ndef on_event(event):\n    pass\n"),
+		writeScript(t, "no-hook", "x = 1\n"),
+		writeScript(t, "spin", "def on_event(event):\n    for i in range(100000000):\n        pass\n"),
+	})
+	if err == nil {
+		t.Error("Update: no error for the scripts that cannot load")
+	}
+	e.handle([]byte(`{"type": "heartbeat"}`))
+	if len(*calls) != 0 {
+		t.Errorf("calls = %v, want none", *calls)
+	}
+
+	want := map[string]struct {
+		loaded bool
+		err    string
+	}{
+		"load":    {false, "load not implemented"},
+		"at-load": {false, "can only be called from on_event"},
+		"no-hook": {false, "defines no on_event"},
+		"spin":    {true, "too many steps"},
+	}
+	for _, st := range e.Status() {
+		w := want[st.Name]
+		if st.Loaded != w.loaded || !strings.Contains(st.LastError, w.err) {
+			t.Errorf("%s: loaded %v, error %q; want loaded %v, error containing %q", st.Name, st.Loaded, st.LastError, w.loaded, w.err)
+		}
+	}
+}
@@ -0,0 +1,420 @@
+// Package store keeps the daemon's pass history: every pass it tried to
+// record, how the attempt ended, and the capture file it produced. The
+// history is a SQLite database under data.root, so it survives restarts
+// and can be queried with any SQLite tool. The driver is pure Go, so the
+// daemon still builds without cgo.
+//
+// Times are stored as fixed-width UTC text, so they read naturally and
+// sort as they compare; a zero time is NULL.
+package store
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" driver
+)
+
+// Outcomes of a pass attempt.
+const (
+	OutcomeCaptured  = "captured"  // recorded to File
+	OutcomeFailed    = "failed"    // the capture errored; see Error
+	OutcomeCancelled = "cancelled" // stopped by the operator; File holds what was recorded
+	OutcomeSkipped   = "skipped"   // skipped by the operator before AOS
+)
+
+// Sources of records the daemon did not record itself.
+const (
+	SourceImport   = "import"   // brought in from another tool
+	SourceBackfill = "backfill" // found in data.root, not yet in the history
+)
+
+// Outcomes lists the valid outcomes, for filter validation.
+var Outcomes = []string{OutcomeCaptured, OutcomeFailed, OutcomeCancelled, OutcomeSkipped}
+
+// Record is one pass attempt.
+type Record struct {
+	ID        int64     `json:"id"`
+	Satellite string    `json:"satellite"`
+	NoradID   int       `json:"norad_id,omitempty"`
+	AOS       time.Time `json:"aos,omitzero"`
+	LOS       time.Time `json:"los,omitzero"`
+	MaxElev   float64   `json:"max_elev"`
+	Station   string    `json:"station,omitempty"`
+	Outcome   string    `json:"outcome"`
+	File      string    `json:"file,omitempty"` // capture file name in data.root
+	Bytes     int64     `json:"bytes,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	Manual    bool      `json:"manual,omitempty"` // a triggered capture
+	// Source is empty for passes the daemon recorded, or SourceImport or
+	// SourceBackfill.
+	Source     string    `json:"source,omitempty"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Deleted    bool      `json:"deleted,omitempty"` // File has since been deleted
+	// RemoteURL is where File was uploaded to object storage.
+	RemoteURL string `json:"remote_url,omitempty"`
+}
+
+// HasFile reports whether the record still has a capture file.
+func (r Record) HasFile() bool {
+	return r.File != "" && !r.Deleted
+}
+
+// Filter selects records in Query. Zero fields match everything.
+type Filter struct {
+	Satellite string // ignoring case
+	Outcome   string
+	Station   string
+	Since     time.Time // AOS at or after
+	Until     time.Time // AOS before
+	MinElev   float64
+	Limit     int
+}
+
+// where returns the SQL condition for f and its arguments.
+func (f Filter) where() (string, []any) {
+	conds := []string{"1"}
+	var args []any
+	if f.Satellite != "" {
+		conds = append(conds, "satellite = ? COLLATE NOCASE")
+		args = append(args, f.Satellite)
+	}
+	if f.Outcome != "" {
+		conds = append(conds, "outcome = ?")
+		args = append(args, f.Outcome)
+	}
+	if f.Station != "" {
+		conds = append(conds, "station = ?")
+		args = append(args, f.Station)
+	}
+	if !f.Since.IsZero() {
+		conds = append(conds, "aos >= ?")
+		args = append(args, dbTime(f.Since))
+	}
+	if !f.Until.IsZero() {
+		// A record without an AOS counts as before any time.
+		conds = append(conds, "(aos IS NULL OR aos < ?)")
+		args = append(args, dbTime(f.Until))
+	}
+	if f.MinElev != 0 {
+		conds = append(conds, "max_elev >= ?")
+		args = append(args, f.MinElev)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// Summary aggregates the history, as served by /api/stats.
+type Summary struct {
+	TotalCaptures int
+	TotalBytes    int64
+	CapturesBySat map[string]int
+	FailuresBySat map[string]int
+	LastCapture   time.Time
+	LastBySat     map[string]time.Time
+}
+
+// Store is the pass history. It is safe for concurrent use.
+type Store struct {
+	db *sql.DB
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS passes (
+	id          INTEGER PRIMARY KEY,
+	satellite   TEXT NOT NULL,
+	norad_id    INTEGER NOT NULL DEFAULT 0,
+	aos         TEXT,
+	los         TEXT,
+	max_elev    REAL NOT NULL DEFAULT 0,
+	station     TEXT NOT NULL DEFAULT '',
+	outcome     TEXT NOT NULL,
+	file        TEXT NOT NULL DEFAULT '',
+	bytes       INTEGER NOT NULL DEFAULT 0,
+	error       TEXT NOT NULL DEFAULT '',
+	manual      INTEGER NOT NULL DEFAULT 0,
+	source      TEXT NOT NULL DEFAULT '',
+	recorded_at TEXT NOT NULL,
+	deleted     INTEGER NOT NULL DEFAULT 0,
+	remote_url  TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS passes_aos ON passes (aos);
+CREATE INDEX IF NOT EXISTS passes_file ON passes (file);
+`
+
+// columns are the passes columns in Record field order, as scanRecord
+// reads them.
+const columns = `id, satellite, norad_id, aos, los, max_elev, station, outcome,
+	file, bytes, error, manual, source, recorded_at, deleted, remote_url`
+
+// timeLayout is fixed width, unlike RFC 3339 with nanoseconds, so stored
+// times sort as text.
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// Open opens the history database at path, creating it if needed. An
+// empty path gives a store that lives only in memory. Each change is
+// synced before it returns, so a power cut loses nothing acknowledged.
+func Open(path string) (*Store, error) {
+	dsn := ":memory:"
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		dsn = path + "?_pragma=journal_mode(WAL)&_pragma=synchronous(FULL)&_pragma=busy_timeout(5000)"
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// One connection: an in-memory database is per connection, and the
+	// daemon's writes are few enough that serialising them costs nothing.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the history database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// ImportJSONL adds the records of a JSON Lines history, as kept by
+// earlier versions of the daemon, keeping their IDs. Where a record
+// appears more than once the last version wins, and records already in
+// the store with the same ID are replaced, so importing the same file
+// twice is harmless. A line that cannot be parsed, such as one cut short
+// by a power loss, is skipped. It returns the number of records imported.
+func (s *Store) ImportJSONL(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	byID := map[int64]Record{}
+	var order []int64
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var r Record
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil || r.ID <= 0 {
+			continue
+		}
+		if _, ok := byID[r.ID]; !ok {
+			order = append(order, r.ID)
+		}
+		byID[r.ID] = r
+	}
+	if err := sc.Err(); err != nil {
+		return 0, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	for _, id := range order {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO passes ("+columns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			append([]any{id}, recordArgs(byID[id])...)...); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(order), nil
+}
+
+// Add records a pass attempt, assigning its ID and, if unset, RecordedAt.
+func (s *Store) Add(r Record) (Record, error) {
+	if r.RecordedAt.IsZero() {
+		r.RecordedAt = time.Now().UTC()
+	}
+	res, err := s.db.Exec("INSERT INTO passes ("+columns+") VALUES (NULL, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		recordArgs(r)...)
+	if err != nil {
+		return Record{}, err
+	}
+	if r.ID, err = res.LastInsertId(); err != nil {
+		return Record{}, err
+	}
+	return r, nil
+}
+
+// MarkDeleted notes that capture file name was deleted. The attempt stays
+// in the history. It reports whether a record had that file.
+func (s *Store) MarkDeleted(name string) (bool, error) {
+	return s.updateFile(name, false, "deleted = 1")
+}
+
+// MarkRestored notes that capture file name, marked deleted, is back, such
+// as from the trash. It reports whether a record had that file.
+func (s *Store) MarkRestored(name string) (bool, error) {
+	return s.updateFile(name, true, "deleted = 0")
+}
+
+// SetRemoteURL notes that capture file name was uploaded to url. It
+// reports whether a record had that file.
+func (s *Store) SetRemoteURL(name, url string) (bool, error) {
+	return s.updateFile(name, false, "remote_url = ?", url)
+}
+
+// ReplaceFile notes that capture file name was replaced by newName, of
+// size bytes, as when it is compressed. It reports whether a record had
+// that file.
+func (s *Store) ReplaceFile(name, newName string, bytes int64) (bool, error) {
+	return s.updateFile(name, false, "file = ?, bytes = ?", newName, bytes)
+}
+
+// updateFile applies set to the newest record holding capture file name,
+// among those marked deleted or not as deleted says. It reports whether
+// there was one.
+func (s *Store) updateFile(name string, deleted bool, set string, args ...any) (bool, error) {
+	res, err := s.db.Exec("UPDATE passes SET "+set+
+		" WHERE id = (SELECT id FROM passes WHERE file = ? AND deleted = ? ORDER BY id DESC LIMIT 1)",
+		append(args, name, deleted)...)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Get returns the record with the given ID.
+func (s *Store) Get(id int64) (Record, bool, error) {
+	r, err := scanRecord(s.db.QueryRow("SELECT "+columns+" FROM passes WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return Record{}, false, nil
+	}
+	if err != nil {
+		return Record{}, false, err
+	}
+	return r, true, nil
+}
+
+// HasFile reports whether some record holds capture file name.
+func (s *Store) HasFile(name string) (bool, error) {
+	var ok bool
+	err := s.db.QueryRow("SELECT EXISTS (SELECT 1 FROM passes WHERE file = ? AND deleted = 0)", name).Scan(&ok)
+	return ok, err
+}
+
+// Query returns the records f matches, newest AOS first.
+func (s *Store) Query(f Filter) ([]Record, error) {
+	where, args := f.where()
+	q := "SELECT " + columns + " FROM passes WHERE " + where + " ORDER BY aos DESC, id"
+	if f.Limit > 0 {
+		q += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+	return s.query(q, args...)
+}
+
+// Captures returns the records whose capture file has not been deleted,
+// in file name order.
+func (s *Store) Captures() ([]Record, error) {
+	return s.query("SELECT " + columns + " FROM passes WHERE file != '' AND deleted = 0 ORDER BY file, id")
+}
+
+// Summary aggregates the recorded captures and failures. Imported files
+// were not recorded by this station and skipped passes were not attempted,
+// so neither counts.
+func (s *Store) Summary() (Summary, error) {
+	sum := Summary{
+		CapturesBySat: map[string]int{},
+		FailuresBySat: map[string]int{},
+		LastBySat:     map[string]time.Time{},
+	}
+	rows, err := s.db.Query(`SELECT satellite, outcome, COUNT(*), SUM(bytes), MAX(recorded_at)
+		FROM passes WHERE source != ? AND outcome IN (?, ?, ?)
+		GROUP BY satellite, outcome`,
+		SourceImport, OutcomeCaptured, OutcomeCancelled, OutcomeFailed)
+	if err != nil {
+		return Summary{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var sat, outcome, last string
+		var n int
+		var bytes int64
+		if err := rows.Scan(&sat, &outcome, &n, &bytes, &last); err != nil {
+			return Summary{}, err
+		}
+		if outcome == OutcomeFailed {
+			sum.FailuresBySat[sat] += n
+			continue
+		}
+		sum.TotalCaptures += n
+		sum.TotalBytes += bytes
+		sum.CapturesBySat[sat] += n
+		t, _ := time.Parse(timeLayout, last)
+		if t.After(sum.LastCapture) {
+			sum.LastCapture = t
+		}
+		if t.After(sum.LastBySat[sat]) {
+			sum.LastBySat[sat] = t
+		}
+	}
+	return sum, rows.Err()
+}
+
+// query runs a SELECT of columns and returns the records it finds.
+func (s *Store) query(q string, args ...any) ([]Record, error) {
+	rows, err := s.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []Record{}
+	for rows.Next() {
+		r, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// recordArgs returns the values of r for columns after id.
+func recordArgs(r Record) []any {
+	return []any{r.Satellite, r.NoradID, dbTime(r.AOS), dbTime(r.LOS), r.MaxElev, r.Station, r.Outcome,
+		r.File, r.Bytes, r.Error, r.Manual, r.Source, r.RecordedAt.UTC().Format(timeLayout), r.Deleted, r.RemoteURL}
+}
+
+// scanRecord reads one row of columns.
+func scanRecord(row interface{ Scan(...any) error }) (Record, error) {
+	var r Record
+	var aos, los sql.NullString
+	var recordedAt string
+	err := row.Scan(&r.ID, &r.Satellite, &r.NoradID, &aos, &los, &r.MaxElev, &r.Station, &r.Outcome,
+		&r.File, &r.Bytes, &r.Error, &r.Manual, &r.Source, &recordedAt, &r.Deleted, &r.RemoteURL)
+	if err != nil {
+		return Record{}, err
+	}
+	if aos.Valid {
+		r.AOS, _ = time.Parse(timeLayout, aos.String)
+	}
+	if los.Valid {
+		r.LOS, _ = time.Parse(timeLayout, los.String)
+	}
+	r.RecordedAt, _ = time.Parse(timeLayout, recordedAt)
+	return r, nil
+}
+
+// dbTime returns t as stored, or nil for the zero time.
+func dbTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC().Format(timeLayout)
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestStorePersists checks that records, and the changes made to them,
+// are still there when the database is opened again, and that Query and
+// Summary read them back.
+func TestStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	aos := time.Date(2026, 10, 16, 11, 21, 34, 0, time.UTC)
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	add := func(r Record) Record {
+		t.Helper()
+		r, err := s.Add(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	first := add(Record{Satellite: "NOAA-19", AOS: aos, LOS: aos.Add(14 * time.Minute), MaxElev: 62.5,
+		Outcome: OutcomeCaptured, File: "NOAA-19_20261016T112134Z.wav", Bytes: 1000})
+	add(Record{Satellite: "NOAA-15", AOS: aos.Add(time.Hour), MaxElev: 20, Outcome: OutcomeFailed, Error: "no signal"})
+	add(Record{Satellite: "noaa-19", AOS: aos.Add(2 * time.Hour), MaxElev: 35, Outcome: OutcomeSkipped})
+	add(Record{Satellite: "NOAA-19", Outcome: OutcomeCaptured, File: "imported.wav", Bytes: 50, Source: SourceImport})
+	if first.ID != 1 || first.RecordedAt.IsZero() {
+		t.Errorf("first record = %+v, want ID 1 and RecordedAt set", first)
+	}
+
+	if ok, err := s.ReplaceFile(first.File, "NOAA-19_20261016T112134Z.flac", 400); !ok || err != nil {
+		t.Fatalf("ReplaceFile = %t, %v", ok, err)
+	}
+	if ok, err := s.MarkDeleted("NOAA-19_20261016T112134Z.flac"); !ok || err != nil {
+		t.Fatalf("MarkDeleted = %t, %v", ok, err)
+	}
+	if ok, _ := s.MarkDeleted("NOAA-19_20261016T112134Z.flac"); ok {
+		t.Error("MarkDeleted found a file already marked deleted")
+	}
+	if ok, err := s.MarkRestored("NOAA-19_20261016T112134Z.flac"); !ok || err != nil {
+		t.Fatalf("MarkRestored = %t, %v", ok, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	got, ok, err := s.Get(first.ID)
+	if err != nil || !ok {
+		t.Fatalf("Get(%d) = %t, %v", first.ID, ok, err)
+	}
+	if got.File != "NOAA-19_20261016T112134Z.flac" || got.Bytes != 400 || got.Deleted ||
+		!got.AOS.Equal(first.AOS) || !got.LOS.Equal(first.LOS) || got.MaxElev != 62.5 || !got.RecordedAt.Equal(first.RecordedAt) {
+		t.Errorf("after reopening, record 1 = %+v", got)
+	}
+	if has, err := s.HasFile("NOAA-19_20261016T112134Z.wav"); has || err != nil {
+		t.Errorf("HasFile(old name) = %t, %v", has, err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		f    Filter
+		want []int64
+	}{
+		{"all, newest AOS first", Filter{}, []int64{3, 2, 1, 4}},
+		{"satellite ignoring case", Filter{Satellite: "NOAA-19"}, []int64{3, 1, 4}},
+		{"outcome", Filter{Outcome: OutcomeFailed}, []int64{2}},
+		{"since", Filter{Since: aos.Add(time.Hour)}, []int64{3, 2}},
+		{"until counts no AOS as before", Filter{Until: aos.Add(time.Hour)}, []int64{1, 4}},
+		{"min elevation", Filter{MinElev: 30}, []int64{3, 1}},
+		{"limit", Filter{Limit: 1}, []int64{3}},
+	} {
+		recs, err := s.Query(tc.f)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		var ids []int64
+		for _, r := range recs {
+			ids = append(ids, r.ID)
+		}
+		if len(ids) != len(tc.want) {
+			t.Errorf("%s: IDs %v, want %v", tc.name, ids, tc.want)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tc.want[i] {
+				t.Errorf("%s: IDs %v, want %v", tc.name, ids, tc.want)
+				break
+			}
+		}
+	}
+
+	sum, err := s.Summary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.TotalCaptures != 1 || sum.TotalBytes != 400 || sum.CapturesBySat["NOAA-19"] != 1 ||
+		sum.FailuresBySat["NOAA-15"] != 1 || !sum.LastCapture.Equal(first.RecordedAt) {
+		t.Errorf("summary = %+v", sum)
+	}
+}
+
+// TestImportJSONL checks that a JSON Lines history keeps its IDs, the last
+// version of a record wins, a torn line is skipped, and new records carry
+// on after the imported IDs.
+func TestImportJSONL(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, "history.jsonl")
+	lines := `{"id":1,"satellite":"NOAA-19","aos":"2026-10-16T11:21:34Z","max_elev":40,"outcome":"captured","file":"a.wav","recorded_at":"2026-10-16T11:36:00Z"}
+{"id":2,"satellite":"NOAA-15","max_elev":12,"outcome":"failed","error":"no signal","recorded_at":"2026-10-16T12:00:00Z"}
+{"id":1,"satellite":"NOAA-19","aos":"2026-10-16T11:21:34Z","max_elev":40,"outcome":"captured","file":"a.wav","deleted":true,"recorded_at":"2026-10-16T11:36:00Z"}
+{"id":3,"satellite":"NOAA-18","outc`
+	if err := os.WriteFile(legacy, []byte(lines), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(filepath.Join(dir, "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	for range 2 {
+		n, err := s.ImportJSONL(legacy)
+		if err != nil || n != 2 {
+			t.Fatalf("ImportJSONL = %d, %v; want 2 records", n, err)
+		}
+	}
+
+	r, ok, err := s.Get(1)
+	if err != nil || !ok || !r.Deleted || r.File != "a.wav" || r.AOS.IsZero() {
+		t.Errorf("record 1 = %+v, %t, %v; want the deleted version", r, ok, err)
+	}
+	if r, _, _ := s.Get(2); r.Error != "no signal" || !r.AOS.IsZero() {
+		t.Errorf("record 2 = %+v", r)
+	}
+	next, err := s.Add(Record{Satellite: "METEOR-M2 3", Outcome: OutcomeSkipped})
+	if err != nil || next.ID != 3 {
+		t.Errorf("next record got ID %d, %v; want 3", next.ID, err)
+	}
+}
@@ -10,10 +10,25 @@ import "time"
 type EventType string
 
 const (
-	EventHeartbeat EventType = "heartbeat"
-	EventState     EventType = "state"
-	EventProgress  EventType = "progress"
-	EventLog       EventType = "log"
+	EventHeartbeat  EventType = "heartbeat"
+	EventState      EventType = "state"
+	EventProgress   EventType = "progress"
+	EventLog        EventType = "log"
+	EventHealth     EventType = "health"
+	EventCrash      EventType = "crash"
+	EventWatchdog   EventType = "watchdog"
+	EventConfig     EventType = "config_changed"
+	EventMode       EventType = "mode_changed"
+	EventStation    EventType = "station_changed"
+	EventSDRBusy    EventType = "sdr_busy"
+	EventScrub      EventType = "scrub"
+	EventCorrupt    EventType = "capture_corrupt"
+	EventRetention  EventType = "retention"
+	EventPruned     EventType = "capture_pruned"
+	EventUpload     EventType = "upload"
+	EventGallery    EventType = "gallery"
+	EventNotify     EventType = "notify"
+	EventSubscribed EventType = "subscribed"
 )
 
 // Event is the base envelope shared by every event type.
@@ -29,11 +44,18 @@ func NowTS() string {
 }
 
 // Heartbeat is sent periodically so clients can detect connectivity and
-// monitor daemon uptime.
+// monitor daemon uptime. Seq starts at 1 and increases by one per
+// heartbeat for the life of the process identified by BootID: a reset or a
+// new BootID means the daemon restarted, and a gap means events were
+// missed. ServerTimeMs is the daemon's wall clock in Unix milliseconds.
 type Heartbeat struct {
 	Event
-	State         string `json:"state"`
-	UptimeSeconds int64  `json:"uptime_seconds"`
+	Seq             int64  `json:"seq"`
+	BootID          string `json:"boot_id"`
+	ServerTimeMs    int64  `json:"server_time_ms"`
+	IntervalSeconds int    `json:"interval_seconds"`
+	State           string `json:"state"`
+	UptimeSeconds   int64  `json:"uptime_seconds"`
 }
 
 // StateTransition is emitted whenever the daemon moves between operating
@@ -59,3 +81,173 @@ type LogLine struct {
 	Level   string `json:"level"`
 	Message string `json:"message"`
 }
+
+// HealthChange announces that a component health check changed state or
+// started flapping. Changes on a flapping check are suppressed until it
+// settles.
+type HealthChange struct {
+	Event
+	Check    string `json:"check"`
+	OK       bool   `json:"ok"`
+	Flapping bool   `json:"flapping"`
+	Message  string `json:"message"`
+}
+
+// Crash reports a recovered panic. Source names the handler or loop that
+// panicked; Report is the crash report path under data.root, if written.
+type Crash struct {
+	Event
+	Source  string `json:"source"`
+	Message string `json:"message"`
+	Report  string `json:"report,omitempty"`
+}
+
+// Watchdog announces that the scheduler loop missed its heartbeat deadline
+// (Hung true) or has started making progress again (Hung false).
+type Watchdog struct {
+	Event
+	Hung     bool   `json:"hung"`
+	LastBeat string `json:"last_beat"`
+	Message  string `json:"message"`
+}
+
+// ConfigChanged is broadcast after a config reload. Source is "api" or
+// "sighup". Secret values in Changes are redacted.
+type ConfigChanged struct {
+	Event
+	Source  string        `json:"source"`
+	Path    string        `json:"path"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// FieldChange is one changed config field, keyed by its dotted TOML name.
+type FieldChange struct {
+	Key string `json:"key"`
+	Old any    `json:"old"`
+	New any    `json:"new"`
+}
+
+// ModeChanged is broadcast when the daemon switches between the demo
+// runner and the live scheduler at runtime. From and To are "demo" or
+// "live".
+type ModeChanged struct {
+	Event
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Source string `json:"source"`
+}
+
+// StationChanged is broadcast when the active station profile is switched
+// at runtime. From and To are profile names; empty means the bare [station]
+// location.
+type StationChanged struct {
+	Event
+	From      string  `json:"from"`
+	To        string  `json:"to"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"`
+	Source    string  `json:"source"`
+}
+
+// SDRBusy is emitted when a capture cannot claim the SDR because another
+// process holds it. Action is "waiting" (retried until RetryUntil, the
+// pass LOS) or "killing" (sdr.kill_competing is set). It is sent again
+// only when the set of holders changes.
+type SDRBusy struct {
+	Event
+	Device     int      `json:"device"`
+	Satellite  string   `json:"satellite"`
+	Holders    []string `json:"holders"`
+	Action     string   `json:"action"`
+	RetryUntil string   `json:"retry_until"`
+}
+
+// Scrub summarizes a finished integrity scrub. Corrupt lists every capture
+// currently failing its checksum; Interrupted is set when the run stopped
+// early, e.g. because a capture started.
+type Scrub struct {
+	Event
+	Checked     int      `json:"checked"`
+	Unverified  int      `json:"unverified"`
+	Corrupt     []string `json:"corrupt"`
+	Interrupted string   `json:"interrupted,omitempty"`
+	DurationS   int      `json:"duration_s"`
+}
+
+// CaptureCorrupt is emitted once when the scrub finds that a capture no
+// longer matches its recorded checksum, or can no longer be read.
+type CaptureCorrupt struct {
+	Event
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// Retention summarizes a finished retention sweep. Warnings name limits
+// the sweep could not meet, such as captures tagged "keep" holding the
+// total over max_total_bytes.
+type Retention struct {
+	Event
+	Action      string   `json:"action"` // "archive" or "delete"
+	Pruned      int      `json:"pruned"`
+	FreedBytes  int64    `json:"freed_bytes"`
+	TotalBytes  int64    `json:"total_bytes"`
+	Warnings    []string `json:"warnings"`
+	Interrupted string   `json:"interrupted,omitempty"`
+}
+
+// CapturePruned is emitted for each capture a retention sweep archives or
+// deletes. Reason names the limit, such as "max_age_days".
+type CapturePruned struct {
+	Event
+	File   string `json:"file"`
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// Upload is emitted when a capture's upload to object storage ends. State
+// is "uploaded", with URL the recording's and Bytes the total sent, or
+// "failed" after the last retry, with Error.
+type Upload struct {
+	Event
+	File     string `json:"file"`
+	State    string `json:"state"`
+	Attempts int    `json:"attempts"`
+	URL      string `json:"url,omitempty"`
+	Objects  int    `json:"objects,omitempty"`
+	Bytes    int64  `json:"bytes,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Gallery is emitted after each static gallery export. Copied counts the
+// images new since the last export and Removed the files of captures no
+// longer shown.
+type Gallery struct {
+	Event
+	Dir      string `json:"dir"`
+	Days     int    `json:"days"`
+	Captures int    `json:"captures"`
+	Images   int    `json:"images"`
+	Copied   int    `json:"copied"`
+	Removed  int    `json:"removed"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Notify is emitted after each notification is sent to a backend:
+// "telegram", "email", "ntfy" or "gotify". Kind is "pass", "failure",
+// "summary" or "test". Error is set when the message could not be sent.
+type Notify struct {
+	Event
+	Backend string `json:"backend"`
+	Kind    string `json:"kind"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Subscribed is sent to one WebSocket client, and no one else, after it
+// sends {"subscribe": [...]}, listing the event types it now receives.
+// Types is empty when it receives every type again.
+type Subscribed struct {
+	Event
+	Types []string `json:"types"`
+}
@@ -0,0 +1,300 @@
+// Package tracing records spans for the pass pipeline (predict, wait,
+// capture, decode) and optionally exports them to an OpenTelemetry collector
+// using OTLP/HTTP with JSON encoding. It intentionally avoids the full
+// OpenTelemetry SDK: spans are simple structs, parent/child links travel in
+// the context, and finished traces are also kept in memory so operators can
+// inspect them with ephctl without running a collector.
+//
+// A nil *Tracer is valid and records nothing, so callers never need to check
+// whether tracing is enabled.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+const (
+	recentCap     = 200 // finished spans kept for /api/traces
+	flushInterval = 5 * time.Second
+)
+
+// Span is a single timed operation. Spans are created with Tracer.Start and
+// must be finished with End.
+type Span struct {
+	TraceID   string         `json:"trace_id"`
+	SpanID    string         `json:"span_id"`
+	ParentID  string         `json:"parent_id,omitempty"`
+	Name      string         `json:"name"`
+	StartTime time.Time      `json:"start"`
+	EndTime   time.Time      `json:"end"`
+	Attrs     map[string]any `json:"attributes,omitempty"`
+	Error     string         `json:"error,omitempty"`
+
+	tracer *Tracer // guards the mutable fields above via tracer.mu
+	ended  bool
+}
+
+// Tracer creates spans and ships finished ones to the configured exporter.
+type Tracer struct {
+	endpoint    string
+	serviceName string
+	log         *slog.Logger
+	client      *http.Client
+
+	mu      sync.Mutex
+	pending []*Span
+	recent  []*Span
+}
+
+type ctxKey struct{}
+
+// New returns a tracer for the given config, or nil if tracing is disabled.
+func New(cfg config.TracingConfig, logger *slog.Logger) *Tracer {
+	if !cfg.Enabled {
+		return nil
+	}
+	name := cfg.ServiceName
+	if name == "" {
+		name = "ephemerisd"
+	}
+	return &Tracer{
+		endpoint:    cfg.OTLPEndpoint,
+		serviceName: name,
+		log:         logger.With("component", "tracing"),
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Start begins a span named name. If ctx carries a span, the new span is
+// its child; otherwise it starts a new trace. attrs alternates key, value.
+// The span's fields are only written while holding the tracer's mutex.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...any) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	s := &Span{
+		SpanID:    randomHex(8),
+		Name:      name,
+		StartTime: time.Now(),
+		tracer:    t,
+	}
+	if parent, ok := ctx.Value(ctxKey{}).(*Span); ok && parent != nil {
+		s.TraceID = parent.TraceID
+		s.ParentID = parent.SpanID
+	} else {
+		s.TraceID = randomHex(16)
+	}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if k, ok := attrs[i].(string); ok {
+			s.SetAttr(k, attrs[i+1])
+		}
+	}
+	return context.WithValue(ctx, ctxKey{}, s), s
+}
+
+// SetAttr attaches a key/value attribute to the span. Ended spans are
+// immutable and ignore further attributes.
+func (s *Span) SetAttr(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	if s.ended {
+		return
+	}
+	if s.Attrs == nil {
+		s.Attrs = make(map[string]any)
+	}
+	s.Attrs[key] = value
+}
+
+// RecordError marks the span as failed. A nil error is ignored.
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.Error = err.Error()
+}
+
+// End finishes the span and queues it for export. Calling End more than
+// once has no effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	t := s.tracer
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.ended = true
+	s.EndTime = time.Now()
+	if t.endpoint != "" {
+		t.pending = append(t.pending, s)
+	}
+	if len(t.recent) >= recentCap {
+		t.recent = t.recent[1:]
+	}
+	t.recent = append(t.recent, s)
+}
+
+// Recent returns copies of recently finished spans, oldest first.
+func (t *Tracer) Recent() []Span {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]Span, 0, len(t.recent))
+	for _, s := range t.recent {
+		attrs := make(map[string]any, len(s.Attrs))
+		for k, v := range s.Attrs {
+			attrs[k] = v
+		}
+		out = append(out, Span{
+			TraceID:   s.TraceID,
+			SpanID:    s.SpanID,
+			ParentID:  s.ParentID,
+			Name:      s.Name,
+			StartTime: s.StartTime,
+			EndTime:   s.EndTime,
+			Attrs:     attrs,
+			Error:     s.Error,
+		})
+	}
+	return out
+}
+
+// Run periodically flushes finished spans to the OTLP endpoint until ctx is
+// cancelled, then performs a final flush.
+func (t *Tracer) Run(ctx context.Context) {
+	if t == nil || t.endpoint == "" {
+		return
+	}
+	tick := time.NewTicker(flushInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			t.flush()
+			return
+		case <-tick.C:
+			t.flush()
+		}
+	}
+}
+
+func (t *Tracer) flush() {
+	t.mu.Lock()
+	batch := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+	if err := t.export(batch); err != nil && t.log != nil {
+		t.log.Error("span export failed", "spans", len(batch), "err", err)
+	}
+}
+
+// export posts spans to the collector as an OTLP ExportTraceServiceRequest.
+func (t *Tracer) export(spans []*Span) error {
+	otlpSpans := make([]map[string]any, 0, len(spans))
+	for _, s := range spans {
+		span := map[string]any{
+			"traceId":           s.TraceID,
+			"spanId":            s.SpanID,
+			"name":              s.Name,
+			"kind":              1, // SPAN_KIND_INTERNAL
+			"startTimeUnixNano": fmt.Sprintf("%d", s.StartTime.UnixNano()),
+			"endTimeUnixNano":   fmt.Sprintf("%d", s.EndTime.UnixNano()),
+			"attributes":        otlpAttributes(s.Attrs),
+		}
+		if s.ParentID != "" {
+			span["parentSpanId"] = s.ParentID
+		}
+		if s.Error != "" {
+			span["status"] = map[string]any{"code": 2, "message": s.Error} // STATUS_CODE_ERROR
+		}
+		otlpSpans = append(otlpSpans, span)
+	}
+
+	body := map[string]any{
+		"resourceSpans": []any{
+			map[string]any{
+				"resource": map[string]any{
+					"attributes": otlpAttributes(map[string]any{"service.name": t.serviceName}),
+				},
+				"scopeSpans": []any{
+					map[string]any{
+						"scope": map[string]any{"name": "github.com/large-farva/ephemeris-engine"},
+						"spans": otlpSpans,
+					},
+				},
+			},
+		},
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := t.client.Post(t.endpoint, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTP %s from %s", resp.Status, t.endpoint)
+	}
+	return nil
+}
+
+// otlpAttributes converts a map into the OTLP KeyValue list encoding.
+func otlpAttributes(attrs map[string]any) []map[string]any {
+	out := make([]map[string]any, 0, len(attrs))
+	for k, v := range attrs {
+		var val map[string]any
+		switch x := v.(type) {
+		case string:
+			val = map[string]any{"stringValue": x}
+		case bool:
+			val = map[string]any{"boolValue": x}
+		case int:
+			val = map[string]any{"intValue": fmt.Sprintf("%d", x)}
+		case int64:
+			val = map[string]any{"intValue": fmt.Sprintf("%d", x)}
+		case float64:
+			val = map[string]any{"doubleValue": x}
+		default:
+			val = map[string]any{"stringValue": fmt.Sprint(x)}
+		}
+		out = append(out, map[string]any{"key": k, "value": val})
+	}
+	return out
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
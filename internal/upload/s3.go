@@ -0,0 +1,128 @@
+package upload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// bucket is an S3-compatible bucket reached with access key credentials.
+type bucket struct {
+	endpoint  *url.URL
+	region    string
+	name      string
+	prefix    string
+	pathStyle bool
+	accessKey string
+	secretKey string
+	publicURL string
+	client    *http.Client
+}
+
+func newBucket(cfg config.UploadConfig, client *http.Client) (*bucket, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("upload.endpoint: %w", err)
+	}
+	return &bucket{
+		endpoint:  u,
+		region:    cfg.Region,
+		name:      cfg.Bucket,
+		prefix:    strings.Trim(cfg.Prefix, "/"),
+		pathStyle: cfg.PathStyle,
+		accessKey: cfg.AccessKeyID,
+		secretKey: cfg.SecretAccessKey.Value(),
+		publicURL: strings.TrimRight(cfg.PublicURL, "/"),
+		client:    client,
+	}, nil
+}
+
+// key returns the object key for a file name.
+func (b *bucket) key(name string) string {
+	if b.prefix == "" {
+		return name
+	}
+	return path.Join(b.prefix, name)
+}
+
+// objectURL is where the object for key is written: under the endpoint's
+// path with path-style addressing, as MinIO expects, and on the bucket's
+// own host otherwise, as AWS prefers.
+func (b *bucket) objectURL(key string) *url.URL {
+	u := *b.endpoint
+	if b.pathStyle {
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + b.name + "/" + key
+	} else {
+		u.Host = b.name + "." + u.Host
+		u.Path = "/" + key
+	}
+	u.RawPath = escapePath(u.Path)
+	return &u
+}
+
+// remoteURL is the URL recorded for an uploaded object: under public_url
+// when it is set, such as a CDN in front of the bucket, or the object's
+// own URL.
+func (b *bucket) remoteURL(key string) string {
+	if b.publicURL != "" {
+		return b.publicURL + "/" + escapePath(key)
+	}
+	return b.objectURL(key).String()
+}
+
+// put uploads the file at p as key, returning its size.
+func (b *bucket) put(ctx context.Context, key, p string) (int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	// The payload hash is part of the signature, so the file is read
+	// twice: once to hash it and once to send it.
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, b.objectURL(key).String(), io.NopCloser(f))
+	if err != nil {
+		return 0, err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	ctype := mime.TypeByExtension(filepath.Ext(p))
+	if ctype == "" {
+		ctype = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", ctype)
+	sign(req, hex.EncodeToString(h.Sum(nil)), b.region, b.accessKey, b.secretKey, time.Now())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return 0, fmt.Errorf("PUT %s: %s: %s", key, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return size, nil
+}
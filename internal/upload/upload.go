@@ -0,0 +1,246 @@
+// Package upload copies finished captures to S3-compatible object storage,
+// such as AWS S3, MinIO or Backblaze B2, so they are kept off the station
+// and can be shared. Each capture is uploaded with its sidecar and images
+// once it has been decoded, in the background and with retries, so a slow
+// or absent network never holds up the next pass.
+package upload
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/large-farva/ephemeris-engine/internal/config"
+)
+
+// Upload states of a capture.
+const (
+	StateQueued    = "queued"    // waiting for a free slot, or to retry
+	StateUploading = "uploading" // being sent
+	StateUploaded  = "uploaded"  // every file is in the bucket
+	StateFailed    = "failed"    // gave up after the last retry; see Error
+)
+
+// retryDelays are the waits before the second and later attempts at a
+// capture. After the last the capture is marked failed.
+var retryDelays = []time.Duration{30 * time.Second, 2 * time.Minute, 10 * time.Minute}
+
+// Object is one uploaded file.
+type Object struct {
+	File  string `json:"file"`
+	URL   string `json:"url"`
+	Bytes int64  `json:"bytes"`
+}
+
+// Status is where a capture's upload stands. URL is the recording's.
+type Status struct {
+	State     string   `json:"state"`
+	URL       string   `json:"url,omitempty"`
+	Objects   []Object `json:"objects,omitempty"`
+	Error     string   `json:"error,omitempty"`
+	Attempts  int      `json:"attempts,omitempty"`
+	UpdatedAt string   `json:"updated_at,omitempty"`
+}
+
+// job uploads files, relative to root with the recording first, for the
+// capture name.
+type job struct {
+	root  string
+	name  string
+	files []string
+}
+
+// Uploader queues captures and uploads them, at most upload.concurrency
+// at a time. The config is re-read for each attempt, so a reload can
+// change the bucket or credentials.
+type Uploader struct {
+	cfg    func() config.UploadConfig
+	log    *slog.Logger
+	done   func(name string, st Status)
+	client *http.Client
+
+	mu     sync.Mutex
+	queue  []job
+	active int
+	status map[string]*Status
+	wake   chan struct{}
+}
+
+// New returns an Uploader. done, if set, is called once a capture has
+// been uploaded or has failed for good.
+func New(cfg func() config.UploadConfig, logger *slog.Logger, done func(name string, st Status)) *Uploader {
+	return &Uploader{
+		cfg:    cfg,
+		log:    logger.With("component", "upload"),
+		done:   done,
+		client: &http.Client{Timeout: 10 * time.Minute},
+		status: map[string]*Status{},
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Enqueue queues the capture name for upload. files are relative to root,
+// the recording first. It never blocks, and a capture already queued or
+// uploading is left as it is.
+func (u *Uploader) Enqueue(root, name string, files []string) {
+	u.mu.Lock()
+	if st, ok := u.status[name]; ok && (st.State == StateQueued || st.State == StateUploading) {
+		u.mu.Unlock()
+		return
+	}
+	u.queue = append(u.queue, job{root: root, name: name, files: files})
+	u.status[name] = &Status{State: StateQueued, UpdatedAt: now()}
+	u.mu.Unlock()
+	u.poke()
+}
+
+// Status returns the upload status of capture name since the daemon
+// started, if it was queued.
+func (u *Uploader) Status(name string) (Status, bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	st, ok := u.status[name]
+	if !ok {
+		return Status{}, false
+	}
+	return *st, true
+}
+
+// Pending returns how many captures are queued or uploading.
+func (u *Uploader) Pending() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.queue) + u.active
+}
+
+// Run starts queued uploads until ctx is cancelled.
+func (u *Uploader) Run(ctx context.Context) {
+	for {
+		u.start(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-u.wake:
+		}
+	}
+}
+
+func (u *Uploader) poke() {
+	select {
+	case u.wake <- struct{}{}:
+	default:
+	}
+}
+
+// start begins as many queued uploads as there are free slots.
+func (u *Uploader) start(ctx context.Context) {
+	slots := max(1, u.cfg().Concurrency)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for len(u.queue) > 0 && u.active < slots {
+		j := u.queue[0]
+		u.queue = u.queue[1:]
+		u.active++
+		go u.run(ctx, j)
+	}
+}
+
+// run uploads j, retrying after each of retryDelays, and records how it
+// ended.
+func (u *Uploader) run(ctx context.Context, j job) {
+	defer func() {
+		u.mu.Lock()
+		u.active--
+		u.mu.Unlock()
+		u.poke()
+	}()
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		u.update(j.name, func(st *Status) {
+			st.State, st.Attempts = StateUploading, attempt
+		})
+		var objs []Object
+		objs, err = u.upload(ctx, j)
+		if err == nil {
+			st := u.update(j.name, func(st *Status) {
+				st.State, st.Objects, st.Error = StateUploaded, objs, ""
+				st.URL = objs[0].URL
+			})
+			u.finish(j.name, st)
+			return
+		}
+		if ctx.Err() != nil || attempt > len(retryDelays) || errors.Is(err, errDisabled) {
+			break
+		}
+		delay := retryDelays[attempt-1]
+		u.log.Warn("upload failed; retrying", "file", j.name, "attempt", attempt, "retry_in", delay, "err", err)
+		u.update(j.name, func(st *Status) {
+			st.State, st.Error = StateQueued, err.Error()
+		})
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(delay):
+			continue
+		}
+		break
+	}
+	st := u.update(j.name, func(st *Status) {
+		st.State, st.Error = StateFailed, err.Error()
+	})
+	u.finish(j.name, st)
+}
+
+// update applies fn to the status of name and returns a copy.
+func (u *Uploader) update(name string, fn func(*Status)) Status {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	st := u.status[name]
+	fn(st)
+	st.UpdatedAt = now()
+	return *st
+}
+
+func (u *Uploader) finish(name string, st Status) {
+	if u.done != nil {
+		u.done(name, st)
+	}
+}
+
+var errDisabled = errors.New("upload is disabled")
+
+// upload sends every file of j. A sidecar or image removed since the
+// capture was queued is skipped; a missing recording fails the upload.
+func (u *Uploader) upload(ctx context.Context, j job) ([]Object, error) {
+	cfg := u.cfg()
+	if !cfg.Enabled {
+		return nil, errDisabled
+	}
+	b, err := newBucket(cfg, u.client)
+	if err != nil {
+		return nil, err
+	}
+	objs := make([]Object, 0, len(j.files))
+	for i, file := range j.files {
+		key := b.key(file)
+		n, err := b.put(ctx, key, filepath.Join(j.root, file))
+		if errors.Is(err, os.ErrNotExist) && i > 0 {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, Object{File: file, URL: b.remoteURL(key), Bytes: n})
+	}
+	return objs, nil
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
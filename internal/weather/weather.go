@@ -0,0 +1,144 @@
+// Package weather fetches hourly cloud-cover forecasts from Open-Meteo so
+// predicted passes can carry the expected sky conditions. Visible-light APT
+// channels are of little use under full cloud, and some stations would
+// rather skip those passes than record them.
+package weather
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// cacheTTL is how long a forecast is reused. Open-Meteo's models
+	// update hourly at most.
+	cacheTTL = time.Hour
+	// retryAfter is how long a failed lookup is not retried.
+	retryAfter = 10 * time.Minute
+	// maxForecastDays is the longest forecast Open-Meteo serves.
+	maxForecastDays = 16
+)
+
+// Forecast is an hourly cloud-cover forecast for one location.
+type Forecast struct {
+	Start time.Time // first hour, UTC
+	Cover []int     // total cloud cover in percent, one value per hour
+}
+
+// CloudCover returns the forecast cloud cover at t, or false when t is
+// outside the forecast.
+func (f *Forecast) CloudCover(t time.Time) (int, bool) {
+	if f == nil {
+		return 0, false
+	}
+	i := int(math.Floor(t.Sub(f.Start).Hours()))
+	if i < 0 || i >= len(f.Cover) {
+		return 0, false
+	}
+	return f.Cover[i], true
+}
+
+// openMeteoResponse is the subset of an Open-Meteo forecast we use.
+type openMeteoResponse struct {
+	Hourly struct {
+		Time       []string `json:"time"`
+		CloudCover []*int   `json:"cloud_cover"`
+	} `json:"hourly"`
+	Error  bool   `json:"error"`
+	Reason string `json:"reason"`
+}
+
+var (
+	mu       sync.Mutex
+	cached   *Forecast
+	cacheKey string
+	cachedAt time.Time
+	failedAt time.Time
+)
+
+// Lookup returns the forecast for lat/lon covering at least the given
+// number of hours, from the Open-Meteo-compatible endpoint at baseURL. The
+// last forecast is cached for an hour, and a failed lookup is not retried
+// for ten minutes so an offline station does not stall every prediction.
+func Lookup(baseURL string, lat, lon float64, hours int, timeout time.Duration) (*Forecast, error) {
+	// Two decimals is ~1 km, well inside a forecast grid cell.
+	key := fmt.Sprintf("%s|%.2f|%.2f|%d", baseURL, lat, lon, hours)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if cached != nil && cacheKey == key && time.Since(cachedAt) < cacheTTL {
+		return cached, nil
+	}
+	if !failedAt.IsZero() && time.Since(failedAt) < retryAfter {
+		return nil, errors.New("weather lookup failed recently; not retrying yet")
+	}
+
+	f, err := fetch(baseURL, lat, lon, hours, timeout)
+	if err != nil {
+		failedAt = time.Now()
+		return nil, err
+	}
+	cached, cacheKey, cachedAt, failedAt = f, key, time.Now(), time.Time{}
+	return f, nil
+}
+
+// fetch queries the forecast endpoint once.
+func fetch(baseURL string, lat, lon float64, hours int, timeout time.Duration) (*Forecast, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("weather url: %w", err)
+	}
+	// One extra day so a pass late in the lookahead still has data.
+	days := min(hours/24+2, maxForecastDays)
+	q := u.Query()
+	q.Set("latitude", strconv.FormatFloat(lat, 'f', 4, 64))
+	q.Set("longitude", strconv.FormatFloat(lon, 'f', 4, 64))
+	q.Set("hourly", "cloud_cover")
+	q.Set("timezone", "UTC")
+	q.Set("forecast_days", strconv.Itoa(days))
+	u.RawQuery = q.Encode()
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("weather lookup: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var r openMeteoResponse
+	decodeErr := json.NewDecoder(resp.Body).Decode(&r)
+	if r.Error {
+		return nil, fmt.Errorf("weather lookup failed: %s", r.Reason)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("weather lookup: HTTP %d", resp.StatusCode)
+	}
+	if decodeErr != nil {
+		return nil, fmt.Errorf("weather decode: %w", decodeErr)
+	}
+	if len(r.Hourly.Time) == 0 || len(r.Hourly.Time) != len(r.Hourly.CloudCover) {
+		return nil, errors.New("weather lookup: response has no hourly cloud_cover")
+	}
+
+	start, err := time.Parse("2006-01-02T15:04", r.Hourly.Time[0])
+	if err != nil {
+		return nil, fmt.Errorf("weather decode: %w", err)
+	}
+	f := &Forecast{Start: start, Cover: make([]int, len(r.Hourly.CloudCover))}
+	for i, c := range r.Hourly.CloudCover {
+		if c == nil {
+			// Past the model's horizon; stop rather than guess.
+			f.Cover = f.Cover[:i]
+			break
+		}
+		f.Cover[i] = *c
+	}
+	return f, nil
+}
@@ -0,0 +1,148 @@
+package ws
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DebugType is the event type of the debug tap: internal diagnostics such
+// as queue depths and timings. Unlike every other type, debug events only
+// go to clients that name "debug" in a subscription; a client receiving
+// every type, the event log and in-process subscribers never see them.
+// Producers check Tapped first, so the tap costs nothing while no client
+// is listening.
+const DebugType = "debug"
+
+// debugInterval is how often the hub reports its own queues and delivery
+// timings to the debug tap.
+const debugInterval = 5 * time.Second
+
+// deliveryStats accumulates delivery timings between hub reports while
+// the tap is open. Only Run touches it.
+type deliveryStats struct {
+	events   int
+	bytes    int
+	writes   int
+	writeSum time.Duration
+	writeMax time.Duration
+	slowest  string // type of the event behind writeMax
+}
+
+// Tapped reports whether any client subscribes to debug events. Producers
+// should build debug payloads only when it is true.
+func (h *Hub) Tapped() bool {
+	return h.tapped.Load() > 0
+}
+
+// Debug sends a debug event from source with fields to the clients that
+// subscribe to them. It does nothing while none does. Debug events queue
+// apart from other events, so they never crowd them out; when their queue
+// is full they are dropped, and counted.
+func (h *Hub) Debug(source string, fields map[string]any) {
+	if !h.Tapped() {
+		return
+	}
+	ev := make(map[string]any, len(fields)+3)
+	for k, v := range fields {
+		ev[k] = v
+	}
+	ev["type"] = DebugType
+	ev["ts"] = time.Now().UTC().Format(time.RFC3339Nano)
+	ev["source"] = source
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+	select {
+	case h.debugq <- b:
+	default:
+		h.debugDropped.Add(1)
+	}
+}
+
+// wantsDebug reports whether c subscribed to debug events and its filter
+// lets them through.
+func (c *client) wantsDebug() bool {
+	return c.types[DebugType] && (c.filter == nil || c.filter(DebugType))
+}
+
+// sampled reports whether c receives the next debug event, passing on the
+// fraction of them its subscription asked for.
+func (c *client) sampled() bool {
+	if c.sample <= 0 || c.sample >= 1 {
+		return true
+	}
+	c.credit += c.sample
+	if c.credit < 1 {
+		return false
+	}
+	c.credit--
+	return true
+}
+
+// deliverDebug sends a debug event to the clients that subscribe to them.
+func (h *Hub) deliverDebug(msg []byte) {
+	for c, cl := range h.clients {
+		if !cl.wantsDebug() || !cl.sampled() {
+			continue
+		}
+		_ = c.SetWriteDeadline(time.Now().Add(3 * time.Second))
+		if err := c.WriteMessage(websocket.TextMessage, msg); err != nil {
+			h.evict(c, "write_failed")
+		}
+	}
+}
+
+// noteDelivery adds the delivery of one event to the timings reported to
+// the tap.
+func (h *Hub) noteDelivery(msg []byte, writes int, took, slowest time.Duration) {
+	d := &h.delivery
+	d.events++
+	d.bytes += len(msg)
+	d.writes += writes
+	d.writeSum += took
+	if slowest > d.writeMax {
+		d.writeMax, d.slowest = slowest, messageType(msg)
+	}
+}
+
+// reportDebug sends the hub's queue depths, and the delivery timings since
+// the last report, to the tap.
+func (h *Hub) reportDebug() {
+	d := h.delivery
+	h.delivery = deliveryStats{}
+	if !h.Tapped() {
+		return
+	}
+
+	subs := make([]map[string]int, 0, len(h.subs))
+	for ch := range h.subs {
+		subs = append(subs, map[string]int{"depth": len(ch), "capacity": cap(ch)})
+	}
+	h.throttle.mu.Lock()
+	pending := len(h.throttle.pending)
+	h.throttle.mu.Unlock()
+
+	fields := map[string]any{
+		"clients":          len(h.clients),
+		"debug_clients":    h.tapped.Load(),
+		"queue_depth":      len(h.broadcast),
+		"queue_capacity":   cap(h.broadcast),
+		"debug_depth":      len(h.debugq),
+		"debug_dropped":    h.debugDropped.Load(),
+		"throttled":        pending,
+		"subscribers":      subs,
+		"events":           d.events,
+		"bytes":            d.bytes,
+		"writes":           d.writes,
+		"write_max_ms":     float64(d.writeMax.Microseconds()) / 1000,
+		"write_max_type":   d.slowest,
+		"interval_seconds": debugInterval.Seconds(),
+	}
+	if d.writes > 0 {
+		fields["write_avg_ms"] = float64((d.writeSum / time.Duration(d.writes)).Microseconds()) / 1000
+	}
+	h.Debug("hub", fields)
+}
@@ -1,13 +1,19 @@
 // Package ws provides a lightweight WebSocket pub/sub hub.
 // Components broadcast JSON events through the hub, and every connected client
-// receives them in real time. The hub also handles ping/pong keepalives
-// so stale connections get cleaned up automatically.
+// receives them in real time. A client may send {"subscribe": ["log", ...]}
+// to receive only those event types, and must subscribe to "debug" to
+// receive the debug tap (see DebugType). The hub also handles ping/pong
+// keepalives so stale connections get cleaned up automatically.
 package ws
 
 import (
 	"context"
 	"encoding/json"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -17,36 +23,349 @@ import (
 // to all of them. It is safe for concurrent use; register, unregister, and
 // broadcast all go through channels.
 type Hub struct {
-	clients    map[*websocket.Conn]struct{}
-	register   chan *websocket.Conn
+	clients    map[*websocket.Conn]*client
+	register   chan registration
 	unregister chan *websocket.Conn
 	broadcast  chan []byte
 	upgrader   websocket.Upgrader
+
+	// resubscribe carries subscribe messages from client readers to Run,
+	// which owns the clients.
+	resubscribe chan subscription
+
+	// In-process subscribers, such as plugins, see the same events as
+	// WebSocket clients.
+	subs        map[chan []byte]struct{}
+	subscribe   chan chan []byte
+	unsubscribe chan chan []byte
+
+	// throttle coalesces high-rate events; see ThrottledTypes.
+	throttle *throttle
+
+	// logFilter, when set, decides which log events are delivered.
+	logFilter atomic.Pointer[func(component, level string) bool]
+
+	// Connection accounting for Limits. Counts are taken before the
+	// upgrade, so they are guarded by mu rather than owned by Run.
+	mu       sync.Mutex
+	limits   Limits
+	conns    int
+	connsIP  map[string]int
+	accepted int64
+	rejected map[string]int64
+	evicted  map[string]int64
+	// subscribed counts clients with a subscription; withheld counts the
+	// events not sent to them because of it.
+	subscribed int
+	withheld   atomic.Int64
+
+	// The debug tap: tapped counts the clients subscribed to it, and its
+	// events queue on debugq. delivery is owned by Run.
+	tapped       atomic.Int32
+	debugq       chan []byte
+	debugDropped atomic.Int64
+	delivery     deliveryStats
+}
+
+// DefaultPongTimeout is how long a client may stay silent, answering no
+// ping, before it is evicted when Limits.PongTimeout is unset.
+const DefaultPongTimeout = 60 * time.Second
+
+// maxPingInterval is the longest time between keepalive pings.
+const maxPingInterval = 20 * time.Second
+
+// Limits bounds which browsers may open the event stream and how many
+// connections are held at once, so a storm of dashboard tabs cannot
+// exhaust a small Pi. Zero values mean no limit.
+type Limits struct {
+	// AllowedOrigins lists the Origin values, such as
+	// "http://vensat.local:3000", a browser may connect from. Empty or
+	// "*" allows any. Requests without an Origin header (ephctl, scripts)
+	// are always allowed.
+	AllowedOrigins  []string
+	MaxClients      int
+	MaxClientsPerIP int
+	// ClientIP returns the address a connection is counted against for
+	// MaxClientsPerIP. Nil uses the request's remote address.
+	ClientIP func(r *http.Request) string
+	// PongTimeout evicts a client that has sent nothing, not even a pong,
+	// for this long. Zero means DefaultPongTimeout.
+	PongTimeout time.Duration
+}
+
+// Stats is a snapshot of the hub's connection accounting.
+type Stats struct {
+	Clients  int              `json:"clients"`
+	Accepted int64            `json:"accepted"` // connections upgraded since start
+	Rejected map[string]int64 `json:"rejected"` // by reason: origin, max_clients, max_clients_per_ip
+	Evicted  map[string]int64 `json:"evicted"`  // by reason: pong_timeout, write_failed
+	// Backlog is the number of events queued for delivery, and Dropped
+	// counts those lost because the queue was full.
+	Backlog int   `json:"backlog"`
+	Dropped int64 `json:"dropped"`
+	// ThrottleInterval is how often each stream of throttled events is
+	// currently sent, and Coalesced counts the updates, by event type,
+	// that were replaced by a newer one before they went out.
+	ThrottleInterval time.Duration    `json:"throttle_interval"`
+	Coalesced        map[string]int64 `json:"coalesced"`
+	// Subscribed is how many clients receive only the event types they
+	// subscribed to, and Withheld counts the events they were not sent.
+	Subscribed int   `json:"subscribed"`
+	Withheld   int64 `json:"withheld"`
+	// DebugClients is how many clients subscribe to the debug tap.
+	DebugClients int `json:"debug_clients"`
+}
+
+// client is one registered connection.
+type client struct {
+	filter   Filter
+	lastSeen atomic.Int64 // unix nanoseconds of the last frame received
+	// types is the client's subscription, or nil for every type. Only Run
+	// reads or writes it.
+	types map[string]bool
+	// sample is the fraction of debug events the client asked for, 0 for
+	// all of them, and credit carries the remainder between events.
+	sample float64
+	credit float64
+}
+
+func (c *client) touch() {
+	c.lastSeen.Store(time.Now().UnixNano())
+}
+
+func (c *client) idle(now time.Time) time.Duration {
+	return now.Sub(time.Unix(0, c.lastSeen.Load()))
+}
+
+// Filter decides whether a client receives an event, by its "type" field.
+// A nil Filter receives everything.
+type Filter func(eventType string) bool
+
+// wants reports whether c receives events of type eventType.
+func (c *client) wants(eventType string) bool {
+	if c.filter != nil && !c.filter(eventType) {
+		return false
+	}
+	return c.types == nil || c.types[eventType]
+}
+
+// subscription is a client's request to receive only types, or every type
+// when types is empty.
+type subscription struct {
+	conn   *websocket.Conn
+	types  []string
+	sample float64
+}
+
+// subscribeMessage is what a client sends to choose its event types.
+// Sample, between 0 and 1, passes on only that fraction of debug events.
+type subscribeMessage struct {
+	Subscribe *[]string `json:"subscribe"`
+	Sample    float64   `json:"sample"`
+}
+
+// subscribedEvent is sent to a client to confirm its subscription.
+type subscribedEvent struct {
+	Type   string   `json:"type"`
+	TS     string   `json:"ts"`
+	Types  []string `json:"types"`
+	Sample float64  `json:"sample,omitempty"`
+}
+
+// registration pairs a new connection with its client state.
+type registration struct {
+	conn   *websocket.Conn
+	client *client
 }
 
 // NewHub allocates a hub with buffered channels.
 // Call Run in a goroutine to start the event loop.
 func NewHub() *Hub {
-	return &Hub{
-		clients:    make(map[*websocket.Conn]struct{}),
-		register:   make(chan *websocket.Conn, 16),
-		unregister: make(chan *websocket.Conn, 16),
-		broadcast:  make(chan []byte, 256),
-		upgrader: websocket.Upgrader{
-			ReadBufferSize:  1024,
-			WriteBufferSize: 1024,
-			CheckOrigin: func(r *http.Request) bool {
-				return true
-			},
-		},
+	h := &Hub{
+		clients:     make(map[*websocket.Conn]*client),
+		register:    make(chan registration, 16),
+		unregister:  make(chan *websocket.Conn, 16),
+		broadcast:   make(chan []byte, 256),
+		debugq:      make(chan []byte, 64),
+		resubscribe: make(chan subscription, 16),
+		subs:        make(map[chan []byte]struct{}),
+		subscribe:   make(chan chan []byte, 4),
+		unsubscribe: make(chan chan []byte, 4),
+		connsIP:     make(map[string]int),
+		rejected:    make(map[string]int64),
+		evicted:     make(map[string]int64),
+		throttle:    newThrottle(),
+	}
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     h.checkOrigin,
+	}
+	return h
+}
+
+// SetLimits replaces the connection limits. Connections already open are
+// kept even if they now exceed them.
+func (h *Hub) SetLimits(l Limits) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.limits = l
+}
+
+// Stats returns the current client count and the connections accepted,
+// rejected, and evicted so far.
+func (h *Hub) Stats() Stats {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	st := Stats{
+		Clients:  h.conns,
+		Accepted: h.accepted,
+		Rejected: make(map[string]int64, len(h.rejected)),
+		Evicted:  make(map[string]int64, len(h.evicted)),
+	}
+	for reason, n := range h.rejected {
+		st.Rejected[reason] = n
+	}
+	for reason, n := range h.evicted {
+		st.Evicted[reason] = n
+	}
+	st.Backlog = len(h.broadcast)
+
+	t := h.throttle
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	st.Dropped = t.dropped
+	st.ThrottleInterval = t.interval
+	st.Coalesced = make(map[string]int64, len(t.coalesced))
+	for typ, n := range t.coalesced {
+		st.Coalesced[typ] = n
+	}
+	st.Subscribed = h.subscribed
+	st.Withheld = h.withheld.Load()
+	st.DebugClients = int(h.tapped.Load())
+	return st
+}
+
+// pongTimeout returns the configured eviction window.
+func (h *Hub) pongTimeout() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.limits.PongTimeout > 0 {
+		return h.limits.PongTimeout
+	}
+	return DefaultPongTimeout
+}
+
+// pingInterval pings often enough that a live client answers at least
+// twice within the pong timeout.
+func (h *Hub) pingInterval() time.Duration {
+	return min(h.pongTimeout()/3, maxPingInterval)
+}
+
+// evict drops c from the hub, recording why.
+func (h *Hub) evict(c *websocket.Conn, reason string) {
+	h.remove(c)
+	h.mu.Lock()
+	h.evicted[reason]++
+	h.mu.Unlock()
+}
+
+// remove drops c from the hub and closes it.
+func (h *Hub) remove(c *websocket.Conn) {
+	if cl, ok := h.clients[c]; ok && cl.types != nil {
+		h.mu.Lock()
+		h.subscribed--
+		h.mu.Unlock()
+		if cl.wantsDebug() {
+			h.tapped.Add(-1)
+		}
+	}
+	delete(h.clients, c)
+	_ = c.Close()
+}
+
+// checkOrigin accepts requests without an Origin header and those whose
+// Origin is in Limits.AllowedOrigins.
+func (h *Hub) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	h.mu.Lock()
+	allowed := h.limits.AllowedOrigins
+	h.mu.Unlock()
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, o := range allowed {
+		if o == "*" || strings.EqualFold(strings.TrimRight(o, "/"), origin) {
+			return true
+		}
+	}
+	h.reject("origin")
+	return false
+}
+
+// acquire reserves a connection slot for r's client, or returns the
+// HTTP status and reason for turning it away.
+func (h *Hub) acquire(r *http.Request) (ip string, status int, reason string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ip = remoteIP(r)
+	if h.limits.ClientIP != nil {
+		ip = h.limits.ClientIP(r)
+	}
+	if max := h.limits.MaxClients; max > 0 && h.conns >= max {
+		h.rejected["max_clients"]++
+		return ip, http.StatusServiceUnavailable, "max_clients"
+	}
+	if max := h.limits.MaxClientsPerIP; max > 0 && h.connsIP[ip] >= max {
+		h.rejected["max_clients_per_ip"]++
+		return ip, http.StatusTooManyRequests, "max_clients_per_ip"
+	}
+	h.conns++
+	h.connsIP[ip]++
+	return ip, 0, ""
+}
+
+// release frees a slot taken by acquire.
+func (h *Hub) release(ip string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.conns--
+	if h.connsIP[ip]--; h.connsIP[ip] <= 0 {
+		delete(h.connsIP, ip)
 	}
 }
 
+func (h *Hub) reject(reason string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rejected[reason]++
+}
+
+// remoteIP is the host part of r.RemoteAddr.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
 // Run processes registrations, unregistrations, broadcasts, and keepalive
-// pings in a single select loop. It closes all clients when ctx is cancelled.
+// pings in a single select loop. Clients silent for longer than the pong
+// timeout are evicted at each ping, and throttled events that have waited
+// out the interval are delivered as it ticks. While the debug tap is open
+// it reports its queues every debugInterval. It closes all clients when
+// ctx is cancelled.
 func (h *Hub) Run(ctx context.Context) {
-	ping := time.NewTicker(20 * time.Second)
+	ping := time.NewTicker(h.pingInterval())
 	defer ping.Stop()
+	flush := time.NewTicker(minThrottle)
+	defer flush.Stop()
+	debug := time.NewTicker(debugInterval)
+	defer debug.Stop()
 
 	for {
 		select {
@@ -56,72 +375,271 @@ func (h *Hub) Run(ctx context.Context) {
 			}
 			return
 
-		case c := <-h.register:
-			h.clients[c] = struct{}{}
+		case reg := <-h.register:
+			h.clients[reg.conn] = reg.client
 
 		case c := <-h.unregister:
-			delete(h.clients, c)
-			_ = c.Close()
+			h.remove(c)
+
+		case s := <-h.resubscribe:
+			h.setSubscription(s)
+
+		case ch := <-h.subscribe:
+			h.subs[ch] = struct{}{}
+
+		case ch := <-h.unsubscribe:
+			delete(h.subs, ch)
 
 		case msg := <-h.broadcast:
-			for c := range h.clients {
-				_ = c.SetWriteDeadline(time.Now().Add(3 * time.Second))
-				if err := c.WriteMessage(websocket.TextMessage, msg); err != nil {
-					delete(h.clients, c)
-					_ = c.Close()
-				}
+			h.deliver(msg)
+
+		case msg := <-h.debugq:
+			h.deliverDebug(msg)
+
+		case <-debug.C:
+			h.reportDebug()
+
+		case now := <-flush.C:
+			for _, msg := range h.flush(now) {
+				h.deliver(msg)
 			}
 
 		case <-ping.C:
-			for c := range h.clients {
-				_ = c.SetWriteDeadline(time.Now().Add(2 * time.Second))
+			now := time.Now()
+			h.throttle.forget(now)
+			timeout := h.pongTimeout()
+			for c, cl := range h.clients {
+				if cl.idle(now) > timeout {
+					h.evict(c, "pong_timeout")
+					continue
+				}
+				_ = c.SetWriteDeadline(now.Add(2 * time.Second))
 				if err := c.WriteMessage(websocket.PingMessage, nil); err != nil {
-					delete(h.clients, c)
-					_ = c.Close()
+					h.evict(c, "write_failed")
 				}
 			}
+			ping.Reset(h.pingInterval())
+		}
+	}
+}
+
+// deliver sends msg to every subscriber and to every client whose filter
+// allows it.
+func (h *Hub) deliver(msg []byte) {
+	var start time.Time
+	var writes int
+	var slowest time.Duration
+	if h.Tapped() {
+		start = time.Now()
+		defer func() { h.noteDelivery(msg, writes, time.Since(start), slowest) }()
+	}
+	for ch := range h.subs {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+	var eventType string
+	typed := false
+	for c, cl := range h.clients {
+		if cl.filter != nil || cl.types != nil {
+			if !typed {
+				eventType, typed = messageType(msg), true
+			}
+			if !cl.wants(eventType) {
+				if cl.types != nil {
+					h.withheld.Add(1)
+				}
+				continue
+			}
+		}
+		wrote := time.Now()
+		_ = c.SetWriteDeadline(wrote.Add(3 * time.Second))
+		if err := c.WriteMessage(websocket.TextMessage, msg); err != nil {
+			h.evict(c, "write_failed")
+		}
+		if !start.IsZero() {
+			writes++
+			slowest = max(slowest, time.Since(wrote))
+		}
+	}
+}
+
+// setSubscription applies s to its client and confirms it.
+func (h *Hub) setSubscription(s subscription) {
+	cl, ok := h.clients[s.conn]
+	if !ok {
+		return
+	}
+	had, hadDebug := cl.types != nil, cl.wantsDebug()
+	cl.types = nil
+	if len(s.types) > 0 {
+		cl.types = make(map[string]bool, len(s.types))
+		for _, t := range s.types {
+			cl.types[t] = true
+		}
+	}
+	if had != (cl.types != nil) {
+		h.mu.Lock()
+		if had {
+			h.subscribed--
+		} else {
+			h.subscribed++
+		}
+		h.mu.Unlock()
+	}
+	if debug := cl.wantsDebug(); debug != hadDebug {
+		if debug {
+			h.tapped.Add(1)
+		} else {
+			h.tapped.Add(-1)
 		}
 	}
+	cl.sample, cl.credit = 0, 0
+	if s.sample > 0 && s.sample < 1 && cl.wantsDebug() {
+		cl.sample = s.sample
+	}
+
+	ack, _ := json.Marshal(subscribedEvent{
+		Type:   "subscribed",
+		TS:     time.Now().UTC().Format(time.RFC3339Nano),
+		Types:  append([]string{}, s.types...),
+		Sample: cl.sample,
+	})
+	_ = s.conn.SetWriteDeadline(time.Now().Add(3 * time.Second))
+	if err := s.conn.WriteMessage(websocket.TextMessage, ack); err != nil {
+		h.evict(s.conn, "write_failed")
+	}
 }
 
 // Handler returns an http.Handler that upgrades incoming requests to
 // WebSocket connections and registers them with the hub.
 func (h *Hub) Handler() http.Handler {
+	return h.FilteredHandler(nil)
+}
+
+// FilteredHandler is Handler for clients that should only see the events
+// filter allows. Requests over the hub's Limits are refused with 503
+// (hub full) or 429 (too many from one address) before upgrading.
+func (h *Hub) FilteredHandler(filter Filter) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, status, reason := h.acquire(r)
+		if status != 0 {
+			w.Header().Set("Retry-After", "30")
+			http.Error(w, "websocket connection limit reached ("+reason+")", status)
+			return
+		}
+		// Upgrade answers a disallowed Origin with 403 itself.
 		conn, err := h.upgrader.Upgrade(w, r, nil)
 		if err != nil {
-			http.Error(w, "websocket upgrade failed", http.StatusBadRequest)
+			h.release(ip)
 			return
 		}
-		h.register <- conn
+		cl := &client{filter: filter}
+		cl.touch()
+		h.mu.Lock()
+		h.accepted++
+		h.mu.Unlock()
+		h.register <- registration{conn: conn, client: cl}
 
+		// Any frame from the client, including a pong, counts as activity.
+		// The hub evicts clients that stay silent; the read deadline is a
+		// backstop in case the hub loop is slow to notice.
 		go func() {
-			defer func() { h.unregister <- conn }()
-			_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
+			defer func() {
+				h.unregister <- conn
+				h.release(ip)
+			}()
+			deadline := func() time.Time { return time.Now().Add(h.pongTimeout() + maxPingInterval) }
+			_ = conn.SetReadDeadline(deadline())
 			conn.SetPongHandler(func(string) error {
-				_ = conn.SetReadDeadline(time.Now().Add(60 * time.Second))
-				return nil
+				cl.touch()
+				return conn.SetReadDeadline(deadline())
 			})
 
 			for {
-				if _, _, err := conn.ReadMessage(); err != nil {
+				typ, msg, err := conn.ReadMessage()
+				if err != nil {
 					return
 				}
+				cl.touch()
+				_ = conn.SetReadDeadline(deadline())
+				if typ != websocket.TextMessage {
+					continue
+				}
+				// Anything other than a subscribe message is ignored.
+				var sm subscribeMessage
+				if json.Unmarshal(msg, &sm) == nil && sm.Subscribe != nil {
+					h.resubscribe <- subscription{conn: conn, types: *sm.Subscribe, sample: sm.Sample}
+				}
 			}
 		}()
 	})
 }
 
+// Subscribe returns a channel that receives every broadcast event as JSON,
+// debug events aside, for consumers inside the daemon. Like slow WebSocket clients, a
+// subscriber that falls more than buf events behind misses events rather
+// than stalling the hub. Call cancel to stop receiving; the channel is
+// never closed.
+func (h *Hub) Subscribe(buf int) (events <-chan []byte, cancel func()) {
+	ch := make(chan []byte, buf)
+	h.subscribe <- ch
+	return ch, func() { h.unsubscribe <- ch }
+}
+
 // BroadcastJSON marshals v to JSON and queues it for delivery to all
 // connected clients. If the broadcast channel is full the message is
-// silently dropped to avoid blocking the caller.
+// dropped, and counted, to avoid blocking the caller. Throttled types
+// are instead held back while their stream is within its interval.
 func (h *Hub) BroadcastJSON(v any) {
+	if !h.keepLog(v) {
+		return
+	}
 	b, err := json.Marshal(v)
 	if err != nil {
 		return
 	}
+	if key := streamKey(v, b); key != "" {
+		h.offer(key, b)
+		return
+	}
 	select {
 	case h.broadcast <- b:
 	default:
+		h.throttle.mu.Lock()
+		h.throttle.dropped++
+		h.throttle.mu.Unlock()
+	}
+}
+
+// SetLogFilter installs keep to decide, by their component and level,
+// which "log" events are delivered. Events it rejects are dropped before
+// they reach clients or in-process subscribers. nil delivers them all.
+func (h *Hub) SetLogFilter(keep func(component, level string) bool) {
+	h.logFilter.Store(&keep)
+}
+
+// keepLog reports whether v, if it is a log event, passes the log filter.
+func (h *Hub) keepLog(v any) bool {
+	m, ok := v.(map[string]any)
+	if !ok || m["type"] != "log" {
+		return true
+	}
+	keep := h.logFilter.Load()
+	if keep == nil || *keep == nil {
+		return true
+	}
+	component, _ := m["component"].(string)
+	level, _ := m["level"].(string)
+	return (*keep)(component, level)
+}
+
+// messageType extracts the "type" field of a JSON event.
+func messageType(msg []byte) string {
+	var ev struct {
+		Type string `json:"type"`
 	}
+	_ = json.Unmarshal(msg, &ev)
+	return ev.Type
 }
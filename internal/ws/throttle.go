@@ -0,0 +1,125 @@
+package ws
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// ThrottledTypes are the high-rate telemetry events where only the latest
+// value matters. Each stream of them (one per type, stage and satellite)
+// is sent at most once per throttle interval; an update arriving sooner
+// replaces the one waiting, so a backlog never fills with stale progress.
+var ThrottledTypes = map[string]bool{
+	"progress":  true,
+	"waterfall": true,
+	"position":  true,
+}
+
+// Bounds of the throttle interval. It starts at minThrottle, doubles each
+// flush the broadcast queue is more than a quarter full, and halves back
+// once it has drained below a sixteenth.
+const (
+	minThrottle = 250 * time.Millisecond
+	maxThrottle = 8 * time.Second
+)
+
+// throttle holds the latest pending update of each throttled stream.
+type throttle struct {
+	mu        sync.Mutex
+	interval  time.Duration
+	pending   map[string][]byte
+	sent      map[string]time.Time
+	coalesced map[string]int64 // by event type
+	dropped   int64            // events lost to a full broadcast queue
+}
+
+func newThrottle() *throttle {
+	return &throttle{
+		interval:  minThrottle,
+		pending:   make(map[string][]byte),
+		sent:      make(map[string]time.Time),
+		coalesced: make(map[string]int64),
+	}
+}
+
+// streamKey identifies the stream a throttled event belongs to, or returns
+// "" when v is not throttled.
+func streamKey(v any, msg []byte) string {
+	var ev struct {
+		Type      string `json:"type"`
+		Stage     string `json:"stage"`
+		Satellite string `json:"satellite"`
+	}
+	if m, ok := v.(map[string]any); ok {
+		ev.Type, _ = m["type"].(string)
+		ev.Stage, _ = m["stage"].(string)
+		ev.Satellite, _ = m["satellite"].(string)
+	} else {
+		_ = json.Unmarshal(msg, &ev)
+	}
+	if !ThrottledTypes[ev.Type] {
+		return ""
+	}
+	return ev.Type + "\x00" + ev.Stage + "\x00" + ev.Satellite
+}
+
+// offer sends msg now if its stream is due and the queue has room, and
+// otherwise keeps it as the stream's pending update.
+func (h *Hub) offer(key string, msg []byte) {
+	t := h.throttle
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if old, ok := t.pending[key]; ok {
+		t.coalesced[messageType(old)]++
+		t.pending[key] = msg
+		return
+	}
+	if time.Since(t.sent[key]) >= t.interval {
+		select {
+		case h.broadcast <- msg:
+			t.sent[key] = time.Now()
+			return
+		default:
+		}
+	}
+	t.pending[key] = msg
+}
+
+// flush adapts the interval to the broadcast backlog and returns the
+// pending updates that are now due, for Run to deliver.
+func (h *Hub) flush(now time.Time) [][]byte {
+	t := h.throttle
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch backlog := len(h.broadcast); {
+	case backlog > cap(h.broadcast)/4:
+		t.interval = min(2*t.interval, maxThrottle)
+	case backlog < cap(h.broadcast)/16:
+		t.interval = max(t.interval/2, minThrottle)
+	}
+	// Ticks jitter, so a stream within half a tick of due goes now rather
+	// than a whole tick late.
+	var due [][]byte
+	for key, msg := range t.pending {
+		if now.Sub(t.sent[key]) < t.interval-minThrottle/2 {
+			continue
+		}
+		due = append(due, msg)
+		delete(t.pending, key)
+		t.sent[key] = now
+	}
+	return due
+}
+
+// forget drops send times old enough not to hold back any stream, so
+// streams that have ended do not accumulate.
+func (t *throttle) forget(now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for key, at := range t.sent {
+		if _, waiting := t.pending[key]; !waiting && now.Sub(at) > maxThrottle {
+			delete(t.sent, key)
+		}
+	}
+}